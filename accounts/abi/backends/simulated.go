@@ -0,0 +1,252 @@
+// Copyright 2016 The go-expanse Authors
+// This file is part of the go-expanse library.
+//
+// The go-expanse library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-expanse library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-expanse library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package backends contains contract backends running without a network, for
+// testing contracts from Go.
+package backends
+
+import (
+	"crypto/ecdsa"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/expanse-project/go-expanse/common"
+	"github.com/expanse-project/go-expanse/common/registrar"
+	"github.com/expanse-project/go-expanse/core"
+	"github.com/expanse-project/go-expanse/core/state"
+	"github.com/expanse-project/go-expanse/core/types"
+	"github.com/expanse-project/go-expanse/crypto"
+	"github.com/expanse-project/go-expanse/ethdb"
+)
+
+var (
+	defaultTxGas   = big.NewInt(90000)    // Gas of transactions sent without one
+	defaultCallGas = big.NewInt(50000000) // Gas of calls made without one
+)
+
+// SimulatedBackend is a contract backend running an in-memory chain of its
+// own. Transactions are executed into a pending block right away, which is
+// added to the chain by Commit or discarded by Rollback, so every Commit mines
+// a block instantly. Calls and storage reads see the latest committed block.
+// SimulatedBackend implements registrar.Backend.
+type SimulatedBackend struct {
+	database   ethdb.Database
+	blockchain *core.BlockChain
+	keys       map[common.Address]*ecdsa.PrivateKey
+
+	mu              sync.Mutex
+	pendingHeader   *types.Header      // Header of the block collecting the transactions since the last commit
+	pendingTxs      types.Transactions // Transactions sent since the last commit
+	pendingReceipts types.Receipts     // Receipts of the pending transactions
+	pendingState    *state.StateDB     // State after the pending transactions
+}
+
+// Verify that SimulatedBackend implements registrar.Backend.
+var _ registrar.Backend = (*SimulatedBackend)(nil)
+
+// NewSimulatedBackend creates a backend on a pristine chain whose genesis block
+// funds the accounts of the given keys with balance each. Transactions sent
+// from these accounts are signed with their keys.
+func NewSimulatedBackend(balance *big.Int, keys ...*ecdsa.PrivateKey) *SimulatedBackend {
+	database, _ := ethdb.NewMemDatabase()
+
+	b := &SimulatedBackend{
+		database: database,
+		keys:     make(map[common.Address]*ecdsa.PrivateKey),
+	}
+	accounts := make([]core.GenesisAccount, len(keys))
+	for i, key := range keys {
+		addr := crypto.PubkeyToAddress(key.PublicKey)
+		b.keys[addr] = key
+		accounts[i] = core.GenesisAccount{Address: addr, Balance: balance}
+	}
+	core.WriteGenesisBlockForTesting(database, accounts...)
+	b.blockchain, _ = core.NewBlockChain(database, core.FakePow{})
+	b.rollback()
+
+	return b
+}
+
+// BlockChain returns the chain of the backend, holding the committed blocks.
+func (b *SimulatedBackend) BlockChain() *core.BlockChain {
+	return b.blockchain
+}
+
+// Commit adds the pending block with the transactions sent since the last
+// commit to the chain, and starts a new empty pending block on top of it.
+func (b *SimulatedBackend) Commit() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	core.AccumulateRewards(b.pendingState, b.pendingHeader, nil)
+	root, err := b.pendingState.Commit()
+	if err != nil {
+		panic(fmt.Sprintf("state write error: %v", err))
+	}
+	b.pendingHeader.Root = root
+	block := types.NewBlock(b.pendingHeader, b.pendingTxs, nil, b.pendingReceipts)
+
+	if _, err := b.blockchain.InsertChain(types.Blocks{block}); err != nil {
+		panic(fmt.Sprintf("pending block rejected: %v", err)) // transactions are validated when sent
+	}
+	b.rollback()
+}
+
+// Rollback discards the transactions sent since the last commit.
+func (b *SimulatedBackend) Rollback() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.rollback()
+}
+
+// rollback starts a new empty pending block on top of the head.
+func (b *SimulatedBackend) rollback() {
+	parent := b.blockchain.CurrentBlock()
+
+	b.pendingHeader = &types.Header{
+		ParentHash: parent.Hash(),
+		Number:     new(big.Int).Add(parent.Number(), common.Big1),
+		Time:       new(big.Int).Add(parent.Time(), big.NewInt(10)), // block time is fixed at 10 seconds
+		GasLimit:   core.CalcGasLimit(parent),
+		GasUsed:    new(big.Int),
+	}
+	b.pendingHeader.Difficulty = core.CalcDifficulty(b.pendingHeader.Time.Uint64(), parent.Time().Uint64(), parent.Number(), parent.Difficulty())
+	b.pendingTxs, b.pendingReceipts = nil, nil
+	b.pendingState, _ = state.New(parent.Root(), b.database)
+}
+
+// TransactionReceipt returns the receipt of a committed transaction, or nil if
+// the transaction isn't part of the chain.
+func (b *SimulatedBackend) TransactionReceipt(hash common.Hash) *types.Receipt {
+	return core.GetReceipt(b.database, hash)
+}
+
+// StorageAt returns the value of a storage slot of a contract in the latest
+// committed block.
+func (b *SimulatedBackend) StorageAt(addr, storageAddr string) string {
+	statedb, err := b.blockchain.State()
+	if err != nil {
+		return common.Hash{}.Hex()
+	}
+	return statedb.GetState(common.HexToAddress(addr), common.HexToHash(storageAddr)).Hex()
+}
+
+// Call executes a message on top of the latest committed block without
+// including it in the chain, returning its output and the gas it used. The
+// sender is funded for the duration of the call.
+func (b *SimulatedBackend) Call(fromStr, toStr, valueStr, gasStr, gasPriceStr, dataStr string) (string, string, error) {
+	statedb, err := b.blockchain.State()
+	if err != nil {
+		return "", "", err
+	}
+	from := statedb.GetOrNewStateObject(common.HexToAddress(fromStr))
+	from.SetBalance(common.MaxBig)
+
+	msg := callmsg{
+		from:     from,
+		gas:      common.Big(gasStr),
+		gasPrice: common.Big(gasPriceStr),
+		value:    common.Big(valueStr),
+		data:     common.FromHex(dataStr),
+	}
+	if len(toStr) > 0 {
+		addr := common.HexToAddress(toStr)
+		msg.to = &addr
+	}
+	if msg.gas.Sign() == 0 {
+		msg.gas = defaultCallGas
+	}
+	vmenv := core.NewEnv(statedb, b.blockchain, msg, b.blockchain.CurrentBlock().Header())
+	gp := new(core.GasPool).AddGas(common.MaxBig)
+	res, gas, err := core.ApplyMessage(vmenv, msg, gp)
+	if err != nil {
+		return "", "", err
+	}
+	return common.ToHex(res), gas.String(), nil
+}
+
+// Transact signs a transaction with the key of the sender and executes it into
+// the pending block, returning its hash. A transaction without a recipient
+// creates a contract. Transactions which couldn't be included in a block, e.g.
+// because of a wrong nonce or insufficient funds, are rejected.
+func (b *SimulatedBackend) Transact(fromStr, toStr, nonceStr, valueStr, gasStr, gasPriceStr, codeStr string) (string, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	from := common.HexToAddress(fromStr)
+	key, ok := b.keys[from]
+	if !ok {
+		return "", fmt.Errorf("no key for account %x", from)
+	}
+	var (
+		value = common.Big(valueStr)
+		gas   = defaultTxGas
+		price = common.Big(gasPriceStr)
+		data  = common.FromHex(codeStr)
+		nonce = b.pendingState.GetNonce(from)
+	)
+	if len(gasStr) != 0 {
+		gas = common.Big(gasStr)
+	}
+	if len(nonceStr) != 0 {
+		nonce = common.Big(nonceStr).Uint64()
+	}
+	var tx *types.Transaction
+	if len(toStr) == 0 {
+		tx = types.NewContractCreation(nonce, value, gas, price, data)
+	} else {
+		tx = types.NewTransaction(nonce, common.HexToAddress(toStr), value, gas, price, data)
+	}
+	signed, err := tx.SignECDSA(key)
+	if err != nil {
+		return "", err
+	}
+	// Execute the transaction on a copy of the pending state, so a rejected
+	// transaction leaves no partial changes behind
+	statedb := b.pendingState.Copy()
+	statedb.StartRecord(signed.Hash(), common.Hash{}, len(b.pendingTxs))
+
+	gp := new(core.GasPool).AddGas(new(big.Int).Sub(b.pendingHeader.GasLimit, b.pendingHeader.GasUsed))
+	receipt, _, _, err := core.ApplyTransaction(b.blockchain, gp, statedb, b.pendingHeader, signed, b.pendingHeader.GasUsed)
+	if err != nil {
+		return "", err
+	}
+	b.pendingState = statedb
+	b.pendingTxs = append(b.pendingTxs, signed)
+	b.pendingReceipts = append(b.pendingReceipts, receipt)
+
+	return signed.Hash().Hex(), nil
+}
+
+// callmsg is the message of a call, implementing core.Message.
+type callmsg struct {
+	from          *state.StateObject
+	to            *common.Address
+	gas, gasPrice *big.Int
+	value         *big.Int
+	data          []byte
+}
+
+func (m callmsg) From() (common.Address, error)         { return m.from.Address(), nil }
+func (m callmsg) FromFrontier() (common.Address, error) { return m.from.Address(), nil }
+func (m callmsg) Nonce() uint64                         { return m.from.Nonce() }
+func (m callmsg) To() *common.Address                   { return m.to }
+func (m callmsg) GasPrice() *big.Int                    { return m.gasPrice }
+func (m callmsg) Gas() *big.Int                         { return m.gas }
+func (m callmsg) Value() *big.Int                       { return m.value }
+func (m callmsg) Data() []byte                          { return m.data }
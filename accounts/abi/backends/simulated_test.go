@@ -0,0 +1,101 @@
+// Copyright 2016 The go-expanse Authors
+// This file is part of the go-expanse library.
+//
+// The go-expanse library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-expanse library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-expanse library. If not, see <http://www.gnu.org/licenses/>.
+
+package backends
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/expanse-project/go-expanse/common"
+	"github.com/expanse-project/go-expanse/crypto"
+)
+
+// Contract code storing 0x2a in slot 0 and deploying code returning 0x2a.
+const testCode = "0x602a600055600a8060106000396000f3602a60005260206000f3"
+
+func TestSimulatedBackend(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	addr := crypto.PubkeyToAddress(key.PublicKey)
+
+	sim := NewSimulatedBackend(big.NewInt(1000000000), key)
+
+	// A rolled back deployment leaves no trace
+	if _, err := sim.Transact(addr.Hex(), "", "", "", "200000", "", testCode); err != nil {
+		t.Fatalf("failed to deploy contract: %v", err)
+	}
+	sim.Rollback()
+	if sim.BlockChain().CurrentBlock().NumberU64() != 0 {
+		t.Fatalf("rollback mined a block")
+	}
+	// A committed deployment is mined right away
+	hash, err := sim.Transact(addr.Hex(), "", "", "", "200000", "", testCode)
+	if err != nil {
+		t.Fatalf("failed to deploy contract: %v", err)
+	}
+	contract := crypto.CreateAddress(addr, 0)
+	if value := sim.StorageAt(contract.Hex(), "0x0"); common.HexToHash(value) != (common.Hash{}) {
+		t.Errorf("uncommitted storage visible: %s", value)
+	}
+	sim.Commit()
+
+	if sim.BlockChain().CurrentBlock().NumberU64() != 1 {
+		t.Fatalf("commit didn't mine a block")
+	}
+	receipt := sim.TransactionReceipt(common.HexToHash(hash))
+	if receipt == nil {
+		t.Fatalf("no receipt for deployment")
+	}
+	if receipt.ContractAddress != contract {
+		t.Errorf("contract address mismatch: have %x, want %x", receipt.ContractAddress, contract)
+	}
+	if value := sim.StorageAt(contract.Hex(), "0x0"); common.HexToHash(value) != common.BigToHash(big.NewInt(0x2a)) {
+		t.Errorf("storage mismatch: have %s, want 0x2a", value)
+	}
+	res, _, err := sim.Call(addr.Hex(), contract.Hex(), "", "", "", "")
+	if err != nil {
+		t.Fatalf("failed to call contract: %v", err)
+	}
+	if common.HexToHash(res) != common.BigToHash(big.NewInt(0x2a)) {
+		t.Errorf("call result mismatch: have %s, want 0x2a", res)
+	}
+	// Transactions which can't be mined are rejected
+	if _, err := sim.Transact(addr.Hex(), "", "0", "", "200000", "", testCode); err == nil {
+		t.Errorf("transaction with a used nonce accepted")
+	}
+	if _, err := sim.Transact(common.Address{}.Hex(), addr.Hex(), "", "", "", "", ""); err == nil {
+		t.Errorf("transaction from an unknown account accepted")
+	}
+	// Transactions sent between commits end up in the same block
+	var hashes []string
+	for i := 0; i < 3; i++ {
+		hash, err := sim.Transact(addr.Hex(), "", "", "", "200000", "", testCode)
+		if err != nil {
+			t.Fatalf("failed to deploy contract %d: %v", i, err)
+		}
+		hashes = append(hashes, hash)
+	}
+	sim.Commit()
+
+	if txs := sim.BlockChain().CurrentBlock().Transactions(); len(txs) != len(hashes) {
+		t.Fatalf("transaction count mismatch: have %d, want %d", len(txs), len(hashes))
+	}
+	for i, hash := range hashes {
+		if receipt := sim.TransactionReceipt(common.HexToHash(hash)); receipt == nil {
+			t.Errorf("no receipt for deployment %d", i)
+		}
+	}
+}
@@ -44,9 +44,19 @@ type Account struct {
 	Address common.Address
 }
 
+// AccountDetail describes an account along with its key file and whether it
+// is currently unlocked.
+type AccountDetail struct {
+	Account
+	KeyFile  string
+	Created  time.Time
+	Unlocked bool
+}
+
 type Manager struct {
 	keyStore crypto.KeyStore
 	unlocked map[common.Address]*unlocked
+	audit    *AuditLog
 	mutex    sync.RWMutex
 }
 
@@ -62,6 +72,21 @@ func NewManager(keyStore crypto.KeyStore) *Manager {
 	}
 }
 
+// SetAuditLog sets the log recording the signing operations performed with
+// the managed accounts.
+func (am *Manager) SetAuditLog(log *AuditLog) {
+	am.mutex.Lock()
+	defer am.mutex.Unlock()
+	am.audit = log
+}
+
+// AuditLog returns the signing audit log, or nil if none is set.
+func (am *Manager) AuditLog() *AuditLog {
+	am.mutex.RLock()
+	defer am.mutex.RUnlock()
+	return am.audit
+}
+
 func (am *Manager) HasAccount(addr common.Address) bool {
 	accounts, _ := am.Accounts()
 	for _, acct := range accounts {
@@ -124,6 +149,22 @@ func (am *Manager) TimedUnlock(addr common.Address, keyAuth string, timeout time
 	return nil
 }
 
+// Lock removes the private key of the account with the given address from
+// memory, cancelling any pending automatic lock. Locking an account which
+// isn't unlocked is a no-op.
+func (am *Manager) Lock(addr common.Address) {
+	am.mutex.Lock()
+	defer am.mutex.Unlock()
+
+	if u, found := am.unlocked[addr]; found {
+		if u.abort != nil {
+			close(u.abort)
+		}
+		zeroKey(u.PrivateKey)
+		delete(am.unlocked, addr)
+	}
+}
+
 func (am *Manager) expire(addr common.Address, u *unlocked, timeout time.Duration) {
 	t := time.NewTimer(timeout)
 	defer t.Stop()
@@ -182,6 +223,31 @@ func (am *Manager) Accounts() ([]Account, error) {
 	return accounts, err
 }
 
+// AccountDetails returns the accounts in the same order as Accounts, along with
+// their key files and lock status.
+func (am *Manager) AccountDetails() ([]AccountDetail, error) {
+	files, err := am.keyStore.GetKeyFiles()
+	if os.IsNotExist(err) {
+		return nil, ErrNoKeys
+	} else if err != nil {
+		return nil, err
+	}
+	am.mutex.RLock()
+	defer am.mutex.RUnlock()
+
+	details := make([]AccountDetail, len(files))
+	for i, file := range files {
+		_, unlocked := am.unlocked[file.Address]
+		details[i] = AccountDetail{
+			Account:  Account{Address: file.Address},
+			KeyFile:  file.Path,
+			Created:  file.Created,
+			Unlocked: unlocked,
+		}
+	}
+	return details, nil
+}
+
 // zeroKey zeroes a private key in memory.
 func zeroKey(k *ecdsa.PrivateKey) {
 	b := k.D.Bits()
@@ -75,6 +75,34 @@ func TestTimedUnlock(t *testing.T) {
 	}
 }
 
+func TestLock(t *testing.T) {
+	dir, ks := tmpKeyStore(t, crypto.NewKeyStorePlain)
+	defer os.RemoveAll(dir)
+
+	am := NewManager(ks)
+	pass := "foo"
+	a1, err := am.NewAccount(pass)
+
+	// Locking before the timeout expires takes effect immediately
+	if err = am.TimedUnlock(a1.Address, pass, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	am.Lock(a1.Address)
+	if _, err = am.Sign(a1, testSigData); err != ErrLocked {
+		t.Fatal("Signing should've failed with ErrLocked after locking, got ", err)
+	}
+
+	// Locking an indefinitely unlocked or a locked account works too
+	if err = am.Unlock(a1.Address, pass); err != nil {
+		t.Fatal(err)
+	}
+	am.Lock(a1.Address)
+	am.Lock(a1.Address)
+	if _, err = am.Sign(a1, testSigData); err != ErrLocked {
+		t.Fatal("Signing should've failed with ErrLocked after locking, got ", err)
+	}
+}
+
 func TestOverrideUnlock(t *testing.T) {
 	dir, ks := tmpKeyStore(t, crypto.NewKeyStorePlain)
 	defer os.RemoveAll(dir)
@@ -141,6 +169,49 @@ func TestSignRace(t *testing.T) {
 	t.Errorf("Account did not lock within the timeout")
 }
 
+func TestAccountOrdering(t *testing.T) {
+	dir, ks := tmpKeyStore(t, crypto.NewKeyStorePlain)
+	defer os.RemoveAll(dir)
+
+	am := NewManager(ks)
+	var created []Account
+	for i := 0; i < 5; i++ {
+		a, err := am.NewAccount("")
+		if err != nil {
+			t.Fatal(err)
+		}
+		created = append(created, a)
+	}
+	if err := am.Unlock(created[2].Address, ""); err != nil {
+		t.Fatal(err)
+	}
+	accounts, err := am.Accounts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	details, err := am.AccountDetails()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(accounts) != len(created) || len(details) != len(created) {
+		t.Fatalf("account count mismatch: have %d/%d, want %d", len(accounts), len(details), len(created))
+	}
+	for i, a := range created {
+		if accounts[i] != a {
+			t.Errorf("account %d: address mismatch: have %x, want %x", i, accounts[i].Address, a.Address)
+		}
+		if details[i].Account != a {
+			t.Errorf("detail %d: address mismatch: have %x, want %x", i, details[i].Address, a.Address)
+		}
+		if details[i].Unlocked != (i == 2) {
+			t.Errorf("detail %d: unlocked mismatch: have %v, want %v", i, details[i].Unlocked, i == 2)
+		}
+		if _, err := os.Stat(details[i].KeyFile); err != nil {
+			t.Errorf("detail %d: key file missing: %v", i, err)
+		}
+	}
+}
+
 func tmpKeyStore(t *testing.T, new func(string) crypto.KeyStore) (string, crypto.KeyStore) {
 	d, err := ioutil.TempDir("", "exp-keystore-test")
 	if err != nil {
@@ -0,0 +1,188 @@
+// Copyright 2016 The go-expanse Authors
+// This file is part of the go-expanse library.
+//
+// The go-expanse library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-expanse library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-expanse library. If not, see <http://www.gnu.org/licenses/>.
+
+package accounts
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/expanse-project/go-expanse/common"
+)
+
+// AuditEntry records a single signing operation.
+type AuditEntry struct {
+	Time    time.Time       `json:"time"`
+	Account common.Address  `json:"account"`
+	To      *common.Address `json:"to,omitempty"`
+	Value   *big.Int        `json:"value,omitempty"`
+	Hash    *common.Hash    `json:"hash,omitempty"` // signed hash or transaction hash
+	Method  string          `json:"method"`
+	Origin  string          `json:"origin"` // transport the request arrived on
+	Result  string          `json:"result"` // "ok" or the reason signing failed
+
+	// Mac chains the entry to its predecessor if the log is keyed.
+	Mac string `json:"mac,omitempty"`
+}
+
+// AuditLog appends signing operations to a file, one JSON entry per line.
+// If a key is given, every entry carries an HMAC over itself and the previous
+// entry's HMAC, so removed, reordered or altered entries are detected by
+// VerifyAuditLog.
+type AuditLog struct {
+	file *os.File
+	key  []byte
+	prev []byte // HMAC of the last entry written
+	mu   sync.Mutex
+}
+
+// NewAuditLog opens the audit log at path for appending, creating it if
+// necessary. A keyed log continues the chain of the entries already present.
+func NewAuditLog(path string, key []byte) (*AuditLog, error) {
+	log := &AuditLog{key: key}
+	if len(key) > 0 {
+		prev, err := lastAuditMac(path)
+		if err != nil {
+			return nil, err
+		}
+		log.prev = prev
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+	log.file = file
+	return log, nil
+}
+
+// Record appends an entry to the log, setting its time and MAC.
+func (l *AuditLog) Record(entry AuditEntry) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	entry.Time = time.Now().UTC()
+	entry.Mac = ""
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if len(l.key) > 0 {
+		mac := auditMac(l.key, l.prev, line)
+		entry.Mac = hex.EncodeToString(mac)
+		if line, err = json.Marshal(entry); err != nil {
+			return err
+		}
+		l.prev = mac
+	}
+	_, err = l.file.Write(append(line, '\n'))
+	return err
+}
+
+// Close closes the underlying file.
+func (l *AuditLog) Close() error {
+	return l.file.Close()
+}
+
+// VerifyAuditLog checks the HMAC chain of the audit log at path, returning
+// the number of entries verified.
+func VerifyAuditLog(path string, key []byte) (int, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	var (
+		prev []byte
+		n    int
+	)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var entry AuditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return n, fmt.Errorf("entry %d: %v", n+1, err)
+		}
+		have, err := hex.DecodeString(entry.Mac)
+		if err != nil || len(have) == 0 {
+			return n, fmt.Errorf("entry %d: missing or invalid mac", n+1)
+		}
+		entry.Mac = ""
+		line, err := json.Marshal(entry)
+		if err != nil {
+			return n, err
+		}
+		want := auditMac(key, prev, line)
+		if !hmac.Equal(have, want) {
+			return n, fmt.Errorf("entry %d: mac mismatch", n+1)
+		}
+		prev, n = want, n+1
+	}
+	return n, scanner.Err()
+}
+
+// lastAuditMac returns the MAC of the last entry in the audit log at path,
+// or nil if the log doesn't exist or is empty.
+func lastAuditMac(path string) ([]byte, error) {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var last []byte
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if line := bytes.TrimSpace(scanner.Bytes()); len(line) > 0 {
+			last = append(last[:0], line...)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if last == nil {
+		return nil, nil
+	}
+	var entry AuditEntry
+	if err := json.Unmarshal(last, &entry); err != nil {
+		return nil, fmt.Errorf("invalid last audit entry: %v", err)
+	}
+	mac, err := hex.DecodeString(entry.Mac)
+	if err != nil || len(mac) == 0 {
+		return nil, fmt.Errorf("last audit entry has no valid mac")
+	}
+	return mac, nil
+}
+
+func auditMac(key, prev, line []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(prev)
+	mac.Write(line)
+	return mac.Sum(nil)
+}
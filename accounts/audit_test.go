@@ -0,0 +1,76 @@
+// Copyright 2016 The go-expanse Authors
+// This file is part of the go-expanse library.
+//
+// The go-expanse library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-expanse library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-expanse library. If not, see <http://www.gnu.org/licenses/>.
+
+package accounts
+
+import (
+	"bytes"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/expanse-project/go-expanse/common"
+)
+
+func TestAuditLogChain(t *testing.T) {
+	dir, err := ioutil.TempDir("", "audit-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var (
+		path = filepath.Join(dir, "audit.log")
+		key  = []byte("secret")
+		to   = common.HexToAddress("0x0102")
+	)
+	// Write entries across two sessions, the chain must continue
+	for i := 0; i < 2; i++ {
+		log, err := NewAuditLog(path, key)
+		if err != nil {
+			t.Fatalf("session %d: failed to open log: %v", i, err)
+		}
+		for j := 0; j < 3; j++ {
+			entry := AuditEntry{To: &to, Value: big.NewInt(int64(j)), Method: "sendTransaction", Origin: "ipc", Result: "ok"}
+			if err := log.Record(entry); err != nil {
+				t.Fatalf("session %d: failed to record entry %d: %v", i, j, err)
+			}
+		}
+		log.Close()
+	}
+	if n, err := VerifyAuditLog(path, key); err != nil || n != 6 {
+		t.Fatalf("verification failed: have %d, %v, want 6 entries", n, err)
+	}
+	if _, err := VerifyAuditLog(path, []byte("wrong")); err == nil {
+		t.Errorf("verification succeeded with the wrong key")
+	}
+	// Tampering with or removing an entry must break the chain
+	blob, _ := ioutil.ReadFile(path)
+	lines := bytes.SplitAfter(blob, []byte("\n"))
+
+	tampered := bytes.Replace(blob, []byte(`"value":2`), []byte(`"value":9`), 1)
+	ioutil.WriteFile(path, tampered, 0600)
+	if n, err := VerifyAuditLog(path, key); err == nil || n != 2 {
+		t.Errorf("tampered entry: have %d, %v, want failure after 2 entries", n, err)
+	}
+	removed := bytes.Join(append(lines[:1:1], lines[2:]...), nil)
+	ioutil.WriteFile(path, removed, 0600)
+	if n, err := VerifyAuditLog(path, key); err == nil || n != 1 {
+		t.Errorf("removed entry: have %d, %v, want failure after 1 entry", n, err)
+	}
+}
@@ -17,6 +17,18 @@ if [ -f ".git/HEAD" ]; then
     echo "-ldflags '-X main.gitCommit$sep$(git rev-parse HEAD)'"
 fi
 
+tags=""
 if [ ! -z "$GO_OPENCL" ]; then
-   echo "-tags opencl"
+   tags="$tags opencl"
+fi
+
+# GO_NOCGO selects the pure Go implementations of signatures in package
+# crypto and of ethash mining in package pow/ethash, allowing builds
+# without a C compiler.
+if [ ! -z "$GO_NOCGO" ]; then
+   tags="$tags nocgo"
+fi
+
+if [ ! -z "$tags" ]; then
+   echo "-tags '${tags# }'"
 fi
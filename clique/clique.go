@@ -0,0 +1,546 @@
+// Copyright 2016 The go-expanse Authors
+// This file is part of the go-expanse library.
+//
+// The go-expanse library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-expanse library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-expanse library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package clique implements a proof-of-authority consensus engine. Instead of
+// mining, a set of authorized signers take turns sealing blocks and vote on
+// adding and removing signers, which suits private and consortium networks.
+//
+// The initial signers are listed in the extra data of the genesis block, which
+// consists of 32 bytes of vanity, the 20 byte addresses of the signers and 65
+// zero bytes in place of a seal. Every other block carries the 32 bytes of
+// vanity followed by the 65 byte signature of its signer. Blocks at the start
+// of an epoch (checkpoints) repeat the list of signers between the two and
+// reset all pending votes.
+//
+// A signer votes by setting the coinbase of its block to the account voted on
+// and the nonce to all ones for adding it or all zeroes for removing it. Once
+// more than half of the signers agree, the change takes effect immediately.
+// Blocks carry no rewards, transaction fees are credited to the coinbase.
+package clique
+
+import (
+	"errors"
+	"math/big"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/expanse-project/go-expanse/common"
+	"github.com/expanse-project/go-expanse/core"
+	"github.com/expanse-project/go-expanse/core/state"
+	"github.com/expanse-project/go-expanse/core/types"
+	"github.com/expanse-project/go-expanse/crypto"
+	"github.com/expanse-project/go-expanse/ethdb"
+	"github.com/expanse-project/go-expanse/logger"
+	"github.com/expanse-project/go-expanse/logger/glog"
+	"github.com/expanse-project/go-expanse/rlp"
+	"github.com/hashicorp/golang-lru"
+)
+
+const (
+	extraVanity   = 32 // Bytes of extra data reserved for the signer vanity
+	extraSeal     = 65 // Bytes of extra data reserved for the signer seal
+	addressLength = 20 // Bytes of a signer address in the checkpoint signer list
+
+	inmemorySnapshots  = 128  // Number of recent vote snapshots kept in memory
+	inmemorySignatures = 4096 // Number of recent block signers kept in memory
+	trackedHeaders     = 4096 // Number of headers handed in by the chain kept in memory
+
+	wiggleTime = 500 * time.Millisecond // Delay per signer of out-of-turn seals
+)
+
+var (
+	// DefaultPeriod is the default minimum number of seconds between blocks.
+	DefaultPeriod = uint64(15)
+
+	// DefaultEpoch is the default number of blocks between checkpoints.
+	DefaultEpoch = uint64(30000)
+
+	nonceAuthVote = types.BlockNonce{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}
+	nonceDropVote = types.BlockNonce{}
+
+	diffInTurn = big.NewInt(2) // Difficulty of blocks sealed by the in-turn signer
+	diffNoTurn = big.NewInt(1) // Difficulty of blocks sealed out of turn
+)
+
+var (
+	errUnknownBlock              = errors.New("unknown block")
+	errMissingVanity             = errors.New("extra-data 32 byte vanity prefix missing")
+	errMissingSignature          = errors.New("extra-data 65 byte signature suffix missing")
+	errExtraSigners              = errors.New("non-checkpoint block contains extra signer list")
+	errInvalidCheckpointSigners  = errors.New("invalid signer list on checkpoint block")
+	errInvalidCheckpointCoinbase = errors.New("coinbase of checkpoint block non-zero")
+	errInvalidVote               = errors.New("vote nonce not 0x00..0 or 0xff..f")
+	errInvalidCheckpointVote     = errors.New("vote nonce in checkpoint block non-zero")
+	errInvalidMixDigest          = errors.New("non-zero mix digest")
+	errInvalidUncleHash          = errors.New("non empty uncle hash")
+	errUnclesNotAllowed          = errors.New("uncles not allowed")
+	errInvalidDifficulty         = errors.New("invalid difficulty")
+	errInvalidTimestamp          = errors.New("invalid timestamp")
+	errInvalidVotingChain        = errors.New("invalid voting chain")
+	errUnauthorized              = errors.New("unauthorized signer")
+	errRecentlySigned            = errors.New("recently signed")
+)
+
+// Config is the configuration of the proof-of-authority engine, which needs to
+// be the same on all nodes of a network.
+type Config struct {
+	Period uint64 // Minimum number of seconds between two blocks
+	Epoch  uint64 // Number of blocks after which votes are reset
+}
+
+// GenesisConfig returns the proof-of-authority settings configured in the
+// genesis of the chain stored in db, keeping the defaults for those it leaves
+// unset. Like the signers in the genesis extra data, they are the same on all
+// nodes of the network.
+func GenesisConfig(db ethdb.Database) Config {
+	config := Config{Period: DefaultPeriod, Epoch: DefaultEpoch}
+	if chainConfig := core.GetChainConfig(db, core.GetCanonicalHash(db, 0)); chainConfig != nil {
+		if chainConfig.CliquePeriod != nil {
+			config.Period = chainConfig.CliquePeriod.Uint64()
+		}
+		if chainConfig.CliqueEpoch != nil {
+			config.Epoch = chainConfig.CliqueEpoch.Uint64()
+		}
+	}
+	return config
+}
+
+// SignerFn signs hash with the key of the given account.
+type SignerFn func(signer common.Address, hash []byte) ([]byte, error)
+
+// Clique is the proof-of-authority consensus engine.
+type Clique struct {
+	config Config
+	db     ethdb.Database
+
+	recents    *lru.Cache // Recent vote snapshots, by block hash
+	signatures *lru.Cache // Recent block signers, by block hash
+	headers    *lru.Cache // Headers handed in by the chain, maybe not written yet
+
+	lock      sync.RWMutex
+	proposals map[common.Address]bool // Accounts to vote on, true for adding
+	signer    common.Address          // Account sealing blocks
+	signFn    SignerFn                // Signs with the key of the signer
+}
+
+// New creates a proof-of-authority consensus engine for the chain stored in db.
+func New(config Config, db ethdb.Database) *Clique {
+	if config.Epoch == 0 {
+		config.Epoch = DefaultEpoch
+	}
+	recents, _ := lru.New(inmemorySnapshots)
+	signatures, _ := lru.New(inmemorySignatures)
+	headers, _ := lru.New(trackedHeaders)
+
+	return &Clique{
+		config:     config,
+		db:         db,
+		recents:    recents,
+		signatures: signatures,
+		headers:    headers,
+		proposals:  make(map[common.Address]bool),
+	}
+}
+
+// sigHash returns the hash signed by the signer of a header, which is the hash
+// of the header without the seal.
+func sigHash(header *types.Header) common.Hash {
+	data, _ := rlp.EncodeToBytes([]interface{}{
+		header.ParentHash,
+		header.UncleHash,
+		header.Coinbase,
+		header.Root,
+		header.TxHash,
+		header.ReceiptHash,
+		header.Bloom,
+		header.Difficulty,
+		header.Number,
+		header.GasLimit,
+		header.GasUsed,
+		header.Time,
+		header.Extra[:len(header.Extra)-extraSeal],
+		header.MixDigest,
+		header.Nonce,
+	})
+	return crypto.Sha3Hash(data)
+}
+
+// ecrecover returns the account which sealed header.
+func ecrecover(header *types.Header, sigcache *lru.Cache) (common.Address, error) {
+	hash := header.Hash()
+	if signer, ok := sigcache.Get(hash); ok {
+		return signer.(common.Address), nil
+	}
+	if len(header.Extra) < extraSeal {
+		return common.Address{}, errMissingSignature
+	}
+	signature := header.Extra[len(header.Extra)-extraSeal:]
+
+	pubkey, err := crypto.Ecrecover(sigHash(header).Bytes(), signature)
+	if err != nil {
+		return common.Address{}, err
+	}
+	signer := common.BytesToAddress(crypto.Sha3(pubkey[1:])[12:])
+
+	sigcache.Add(hash, signer)
+	return signer, nil
+}
+
+// checkpointSigners returns the signers listed in the extra data of a
+// checkpoint header.
+func checkpointSigners(header *types.Header) ([]common.Address, error) {
+	if len(header.Extra) < extraVanity+extraSeal {
+		return nil, errInvalidCheckpointSigners
+	}
+	list := header.Extra[extraVanity : len(header.Extra)-extraSeal]
+	if len(list)%addressLength != 0 {
+		return nil, errInvalidCheckpointSigners
+	}
+	signers := make([]common.Address, len(list)/addressLength)
+	for i := range signers {
+		copy(signers[i][:], list[i*addressLength:])
+	}
+	return signers, nil
+}
+
+// TrackHeaders implements core.HeaderTracker, remembering headers which are
+// about to be verified so their descendants can resolve the signers.
+func (c *Clique) TrackHeaders(headers []*types.Header) {
+	for _, header := range headers {
+		c.headers.Add(header.Hash(), header)
+	}
+}
+
+// getHeader retrieves a header handed in by the chain or written to the
+// database.
+func (c *Clique) getHeader(hash common.Hash) *types.Header {
+	if header, ok := c.headers.Get(hash); ok {
+		return header.(*types.Header)
+	}
+	return core.GetHeader(c.db, hash)
+}
+
+// snapshot returns the vote snapshot at the given block.
+func (c *Clique) snapshot(number uint64, hash common.Hash) (*Snapshot, error) {
+	var (
+		headers []*types.Header
+		snap    *Snapshot
+	)
+	for snap == nil {
+		if cached, ok := c.recents.Get(hash); ok {
+			snap = cached.(*Snapshot)
+			break
+		}
+		// Checkpoints written to the database have been verified already, so
+		// the signer list in their extra data can be trusted.
+		if number%c.config.Epoch == 0 {
+			if header := core.GetHeader(c.db, hash); header != nil {
+				signers, err := checkpointSigners(header)
+				if err != nil {
+					return nil, err
+				}
+				snap = newSnapshot(c.config.Epoch, number, hash, signers)
+				break
+			}
+		}
+		header := c.getHeader(hash)
+		if header == nil || header.Number.Uint64() != number || number == 0 {
+			return nil, errUnknownBlock
+		}
+		headers = append(headers, header)
+		number, hash = number-1, header.ParentHash
+	}
+	for i := 0; i < len(headers)/2; i++ {
+		headers[i], headers[len(headers)-1-i] = headers[len(headers)-1-i], headers[i]
+	}
+	snap, err := snap.apply(headers, c.signatures)
+	if err != nil {
+		return nil, err
+	}
+	c.recents.Add(snap.Hash, snap)
+	return snap, nil
+}
+
+// Prepare implements core.Engine, casting a vote on one of the proposals and
+// setting the difficulty, extra data and minimum timestamp of the header.
+func (c *Clique) Prepare(header, parent *types.Header) error {
+	number := header.Number.Uint64()
+	snap, err := c.snapshot(number-1, header.ParentHash)
+	if err != nil {
+		return err
+	}
+	checkpoint := number%c.config.Epoch == 0
+
+	header.Coinbase = common.Address{}
+	header.Nonce = nonceDropVote
+
+	c.lock.RLock()
+	if !checkpoint {
+		var addresses []common.Address
+		for address, authorize := range c.proposals {
+			if snap.validVote(address, authorize) {
+				addresses = append(addresses, address)
+			}
+		}
+		if len(addresses) > 0 {
+			header.Coinbase = addresses[rand.Intn(len(addresses))]
+			if c.proposals[header.Coinbase] {
+				header.Nonce = nonceAuthVote
+			}
+		}
+	}
+	signer := c.signer
+	c.lock.RUnlock()
+
+	header.Difficulty = new(big.Int).Set(diffNoTurn)
+	if snap.inturn(number, signer) {
+		header.Difficulty.Set(diffInTurn)
+	}
+	extra := make([]byte, extraVanity, extraVanity+extraSeal)
+	copy(extra, header.Extra)
+	if checkpoint {
+		for _, signer := range snap.SortedSigners() {
+			extra = append(extra, signer[:]...)
+		}
+	}
+	header.Extra = append(extra, make([]byte, extraSeal)...)
+	header.MixDigest = common.Hash{}
+
+	if min := parent.Time.Uint64() + c.config.Period; header.Time.Uint64() < min {
+		header.Time = new(big.Int).SetUint64(min)
+	}
+	return nil
+}
+
+// VerifyHeader implements core.Engine, checking the fields of the header used
+// for voting and sealing.
+func (c *Clique) VerifyHeader(header, parent *types.Header, uncle bool) error {
+	if uncle {
+		return errUnclesNotAllowed
+	}
+	number := header.Number.Uint64()
+	checkpoint := number%c.config.Epoch == 0
+
+	if checkpoint && header.Coinbase != (common.Address{}) {
+		return errInvalidCheckpointCoinbase
+	}
+	if header.Nonce != nonceAuthVote && header.Nonce != nonceDropVote {
+		return errInvalidVote
+	}
+	if checkpoint && header.Nonce != nonceDropVote {
+		return errInvalidCheckpointVote
+	}
+	if len(header.Extra) < extraVanity {
+		return errMissingVanity
+	}
+	if len(header.Extra) < extraVanity+extraSeal {
+		return errMissingSignature
+	}
+	signersBytes := len(header.Extra) - extraVanity - extraSeal
+	if !checkpoint && signersBytes != 0 {
+		return errExtraSigners
+	}
+	if checkpoint && signersBytes%addressLength != 0 {
+		return errInvalidCheckpointSigners
+	}
+	if header.MixDigest != (common.Hash{}) {
+		return errInvalidMixDigest
+	}
+	if header.UncleHash != types.EmptyUncleHash {
+		return errInvalidUncleHash
+	}
+	if header.Difficulty == nil || (header.Difficulty.Cmp(diffInTurn) != 0 && header.Difficulty.Cmp(diffNoTurn) != 0) {
+		return errInvalidDifficulty
+	}
+	if parent.Time.Uint64()+c.config.Period > header.Time.Uint64() {
+		return errInvalidTimestamp
+	}
+	snap, err := c.snapshot(number-1, header.ParentHash)
+	if err != nil {
+		return err
+	}
+	if checkpoint {
+		signers, _ := checkpointSigners(header)
+		expected := snap.SortedSigners()
+		if len(signers) != len(expected) {
+			return errInvalidCheckpointSigners
+		}
+		for i, signer := range signers {
+			if signer != expected[i] {
+				return errInvalidCheckpointSigners
+			}
+		}
+	}
+	return c.verifySeal(snap, header)
+}
+
+// VerifySeal implements core.Engine, checking that the header is signed by an
+// authorized signer which didn't sign any of the most recent blocks.
+func (c *Clique) VerifySeal(header *types.Header) error {
+	number := header.Number.Uint64()
+	if number == 0 {
+		return errUnknownBlock
+	}
+	snap, err := c.snapshot(number-1, header.ParentHash)
+	if err != nil {
+		return err
+	}
+	return c.verifySeal(snap, header)
+}
+
+// verifySeal checks the seal of a header against the snapshot of its parent.
+func (c *Clique) verifySeal(snap *Snapshot, header *types.Header) error {
+	number := header.Number.Uint64()
+
+	signer, err := ecrecover(header, c.signatures)
+	if err != nil {
+		return err
+	}
+	if _, ok := snap.Signers[signer]; !ok {
+		return errUnauthorized
+	}
+	if snap.signedRecently(number, signer) {
+		return errRecentlySigned
+	}
+	expected := diffNoTurn
+	if snap.inturn(number, signer) {
+		expected = diffInTurn
+	}
+	if header.Difficulty.Cmp(expected) != 0 {
+		return errInvalidDifficulty
+	}
+	return nil
+}
+
+// Finalize implements core.Engine, proof-of-authority blocks carry no rewards.
+func (c *Clique) Finalize(statedb *state.StateDB, header *types.Header, uncles []*types.Header) {
+}
+
+// Seal implements core.Engine, signing the block once its timestamp is reached
+// if the local signer is authorized to. Out-of-turn signers wait a little
+// longer, giving the in-turn signer the chance to seal first.
+func (c *Clique) Seal(block *types.Block, stop <-chan struct{}, index int) *types.Block {
+	// A single sealer is enough, the others idle
+	if index > 0 {
+		<-stop
+		return nil
+	}
+	header := block.Header()
+	number := header.Number.Uint64()
+
+	// Sealing empty blocks without a period would spin
+	if c.config.Period == 0 && len(block.Transactions()) == 0 {
+		return nil
+	}
+	c.lock.RLock()
+	signer, signFn := c.signer, c.signFn
+	c.lock.RUnlock()
+
+	if signFn == nil {
+		glog.V(logger.Warn).Infoln("Proof-of-authority signer not set, can't seal")
+		return nil
+	}
+	snap, err := c.snapshot(number-1, header.ParentHash)
+	if err != nil {
+		glog.V(logger.Error).Infof("Failed to retrieve signers of block #%d: %v", number, err)
+		return nil
+	}
+	if _, ok := snap.Signers[signer]; !ok {
+		glog.V(logger.Info).Infof("Not sealing block #%d: %x is not an authorized signer", number, signer)
+		return nil
+	}
+	if snap.signedRecently(number, signer) {
+		glog.V(logger.Detail).Infof("Not sealing block #%d: signed recently, waiting for others", number)
+		return nil
+	}
+	delay := time.Unix(header.Time.Int64(), 0).Sub(time.Now())
+	if header.Difficulty.Cmp(diffNoTurn) == 0 {
+		wiggle := time.Duration(len(snap.Signers)/2+1) * wiggleTime
+		delay += time.Duration(rand.Int63n(int64(wiggle)))
+	}
+	select {
+	case <-stop:
+		return nil
+	case <-time.After(delay):
+	}
+	sig, err := signFn(signer, sigHash(header).Bytes())
+	if err != nil {
+		glog.V(logger.Error).Infof("Failed to seal block #%d: %v", number, err)
+		return nil
+	}
+	copy(header.Extra[len(header.Extra)-extraSeal:], sig)
+
+	return block.WithSeal(header)
+}
+
+// Hashrate implements core.Engine, signers don't hash.
+func (c *Clique) Hashrate() int64 {
+	return 0
+}
+
+// Authorize sets the account sealing blocks and the function signing with its
+// key.
+func (c *Clique) Authorize(signer common.Address, signFn SignerFn) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.signer = signer
+	c.signFn = signFn
+}
+
+// Propose adds a proposal to add (authorize) or remove an account from the
+// signers, which the local signer votes for in the blocks it seals.
+func (c *Clique) Propose(address common.Address, authorize bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.proposals[address] = authorize
+}
+
+// Discard drops the proposal on an account.
+func (c *Clique) Discard(address common.Address) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	delete(c.proposals, address)
+}
+
+// Proposals returns the current proposals, true meaning adding the account.
+func (c *Clique) Proposals() map[common.Address]bool {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	proposals := make(map[common.Address]bool, len(c.proposals))
+	for address, authorize := range c.proposals {
+		proposals[address] = authorize
+	}
+	return proposals
+}
+
+// Signers returns the signers authorized at the given block in ascending order.
+func (c *Clique) Signers(header *types.Header) ([]common.Address, error) {
+	snap, err := c.snapshot(header.Number.Uint64(), header.Hash())
+	if err != nil {
+		return nil, err
+	}
+	return snap.SortedSigners(), nil
+}
+
+// Snapshot returns the state of the signer voting at the given block.
+func (c *Clique) Snapshot(header *types.Header) (*Snapshot, error) {
+	return c.snapshot(header.Number.Uint64(), header.Hash())
+}
@@ -0,0 +1,195 @@
+// Copyright 2016 The go-expanse Authors
+// This file is part of the go-expanse library.
+//
+// The go-expanse library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-expanse library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-expanse library. If not, see <http://www.gnu.org/licenses/>.
+
+package clique
+
+import (
+	"crypto/ecdsa"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/expanse-project/go-expanse/common"
+	"github.com/expanse-project/go-expanse/core"
+	"github.com/expanse-project/go-expanse/core/types"
+	"github.com/expanse-project/go-expanse/crypto"
+	"github.com/expanse-project/go-expanse/ethdb"
+	"github.com/hashicorp/golang-lru"
+)
+
+// testSigner is a signer key used to seal test headers.
+type testSigner struct {
+	key     *ecdsa.PrivateKey
+	address common.Address
+}
+
+func newTestSigner(t *testing.T) *testSigner {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testSigner{key: key, address: crypto.PubkeyToAddress(key.PublicKey)}
+}
+
+func (s *testSigner) sign(address common.Address, hash []byte) ([]byte, error) {
+	return crypto.Sign(hash, s.key)
+}
+
+// header creates a header at number signed by s, voting on address.
+func (s *testSigner) header(number uint64, address common.Address, authorize bool) *types.Header {
+	header := &types.Header{
+		Number:   new(big.Int).SetUint64(number),
+		Time:     new(big.Int).SetUint64(number),
+		Coinbase: address,
+		Extra:    make([]byte, extraVanity+extraSeal),
+	}
+	if authorize {
+		header.Nonce = nonceAuthVote
+	}
+	sig, _ := s.sign(s.address, sigHash(header).Bytes())
+	copy(header.Extra[extraVanity:], sig)
+	return header
+}
+
+func TestSnapshotVoting(t *testing.T) {
+	a, b, c := newTestSigner(t), newTestSigner(t), newTestSigner(t)
+	sigcache, _ := lru.New(inmemorySignatures)
+
+	// A single signer adds a second one right away
+	snap := newSnapshot(DefaultEpoch, 0, common.Hash{}, []common.Address{a.address})
+	snap, err := snap.apply([]*types.Header{a.header(1, b.address, true)}, sigcache)
+	if err != nil {
+		t.Fatalf("failed to apply vote: %v", err)
+	}
+	if _, ok := snap.Signers[b.address]; !ok || len(snap.Signers) != 2 {
+		t.Fatalf("signers mismatch: have %v, want A and B", snap.SortedSigners())
+	}
+	// Out of two signers, both need to vote to add a third
+	snap, err = snap.apply([]*types.Header{b.header(2, c.address, true)}, sigcache)
+	if err != nil {
+		t.Fatalf("failed to apply vote: %v", err)
+	}
+	if _, ok := snap.Signers[c.address]; ok {
+		t.Fatalf("signer added by a minority")
+	}
+	if tally := snap.Tally[c.address]; tally.Votes != 1 || !tally.Authorize {
+		t.Fatalf("tally mismatch: have %+v, want 1 vote to authorize", tally)
+	}
+	snap, err = snap.apply([]*types.Header{a.header(3, c.address, true)}, sigcache)
+	if err != nil {
+		t.Fatalf("failed to apply vote: %v", err)
+	}
+	if _, ok := snap.Signers[c.address]; !ok || len(snap.Votes) != 0 || len(snap.Tally) != 0 {
+		t.Fatalf("signer not added or votes left: signers %v, votes %d", snap.SortedSigners(), len(snap.Votes))
+	}
+	// Two out of three signers remove one
+	snap, err = snap.apply([]*types.Header{b.header(4, a.address, false), c.header(5, a.address, false)}, sigcache)
+	if err != nil {
+		t.Fatalf("failed to apply votes: %v", err)
+	}
+	if _, ok := snap.Signers[a.address]; ok || len(snap.Signers) != 2 {
+		t.Fatalf("signers mismatch: have %v, want B and C", snap.SortedSigners())
+	}
+}
+
+func TestSnapshotRejections(t *testing.T) {
+	a, b, c := newTestSigner(t), newTestSigner(t), newTestSigner(t)
+	sigcache, _ := lru.New(inmemorySignatures)
+	snap := newSnapshot(DefaultEpoch, 0, common.Hash{}, []common.Address{a.address, b.address})
+
+	if _, err := snap.apply([]*types.Header{c.header(1, common.Address{}, false)}, sigcache); err != errUnauthorized {
+		t.Errorf("unauthorized signer: error mismatch: have %v, want %v", err, errUnauthorized)
+	}
+	if _, err := snap.apply([]*types.Header{a.header(1, common.Address{}, false), a.header(2, common.Address{}, false)}, sigcache); err != errRecentlySigned {
+		t.Errorf("repeated signer: error mismatch: have %v, want %v", err, errRecentlySigned)
+	}
+	if _, err := snap.apply([]*types.Header{a.header(2, common.Address{}, false)}, sigcache); err != errInvalidVotingChain {
+		t.Errorf("gapped chain: error mismatch: have %v, want %v", err, errInvalidVotingChain)
+	}
+}
+
+func TestSealAndVerify(t *testing.T) {
+	signer := newTestSigner(t)
+
+	db, _ := ethdb.NewMemDatabase()
+	genesis, err := core.WriteGenesisBlock(db, strings.NewReader(fmt.Sprintf(`{
+	"nonce": "0x0000000000000000",
+	"gasLimit": "0x2fefd8",
+	"difficulty": "0x1",
+	"extraData": "0x%x%x%x",
+	"alloc": {}
+}`, make([]byte, extraVanity), signer.address, make([]byte, extraSeal))))
+	if err != nil {
+		t.Fatalf("failed to write genesis: %v", err)
+	}
+	engine := New(Config{Period: 1}, db)
+	engine.Authorize(signer.address, signer.sign)
+
+	header := &types.Header{
+		ParentHash: genesis.Hash(),
+		UncleHash:  types.EmptyUncleHash,
+		Number:     big.NewInt(1),
+		GasLimit:   genesis.GasLimit(),
+		GasUsed:    new(big.Int),
+		Time:       big.NewInt(time.Now().Unix() - 1),
+		Extra:      []byte("vanity"),
+	}
+	if err := engine.Prepare(header, genesis.Header()); err != nil {
+		t.Fatalf("failed to prepare header: %v", err)
+	}
+	if header.Difficulty.Cmp(diffInTurn) != 0 {
+		t.Errorf("difficulty mismatch: have %v, want %v", header.Difficulty, diffInTurn)
+	}
+	block := engine.Seal(types.NewBlockWithHeader(header), make(chan struct{}), 0)
+	if block == nil {
+		t.Fatalf("failed to seal block")
+	}
+	if err := engine.VerifyHeader(block.Header(), genesis.Header(), false); err != nil {
+		t.Fatalf("failed to verify sealed header: %v", err)
+	}
+	if err := engine.VerifySeal(block.Header()); err != nil {
+		t.Fatalf("failed to verify seal: %v", err)
+	}
+	// Tampering with the header invalidates the seal
+	tampered := block.Header()
+	tampered.GasUsed = big.NewInt(1)
+	if err := engine.VerifySeal(tampered); err != errUnauthorized {
+		t.Errorf("tampered header: error mismatch: have %v, want %v", err, errUnauthorized)
+	}
+	if err := engine.VerifyHeader(block.Header(), genesis.Header(), true); err != errUnclesNotAllowed {
+		t.Errorf("uncle: error mismatch: have %v, want %v", err, errUnclesNotAllowed)
+	}
+}
+
+func TestGenesisConfig(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+	if _, err := core.WriteGenesisBlock(db, strings.NewReader(`{"difficulty": "0x1", "gasLimit": "0x2fefd8"}`)); err != nil {
+		t.Fatalf("failed to write genesis: %v", err)
+	}
+	if config := GenesisConfig(db); config.Period != DefaultPeriod || config.Epoch != DefaultEpoch {
+		t.Errorf("default config mismatch: have %+v", config)
+	}
+
+	db, _ = ethdb.NewMemDatabase()
+	if _, err := core.WriteGenesisBlock(db, strings.NewReader(`{"difficulty": "0x1", "gasLimit": "0x2fefd8", "config": {"cliquePeriod": "0x0", "cliqueEpoch": "0x64"}}`)); err != nil {
+		t.Fatalf("failed to write genesis: %v", err)
+	}
+	if config := GenesisConfig(db); config.Period != 0 || config.Epoch != 100 {
+		t.Errorf("genesis config mismatch: have %+v, want period 0, epoch 100", config)
+	}
+}
@@ -0,0 +1,253 @@
+// Copyright 2016 The go-expanse Authors
+// This file is part of the go-expanse library.
+//
+// The go-expanse library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-expanse library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-expanse library. If not, see <http://www.gnu.org/licenses/>.
+
+package clique
+
+import (
+	"bytes"
+	"sort"
+
+	"github.com/expanse-project/go-expanse/common"
+	"github.com/expanse-project/go-expanse/core/types"
+	"github.com/hashicorp/golang-lru"
+)
+
+// Vote is a vote cast by an authorized signer to add or remove a signer.
+type Vote struct {
+	Signer    common.Address `json:"signer"`    // Authorized signer that cast the vote
+	Block     uint64         `json:"block"`     // Block number the vote was cast in
+	Address   common.Address `json:"address"`   // Account voted on
+	Authorize bool           `json:"authorize"` // Whether to add or remove the account
+}
+
+// Tally is the running count of the votes on an account.
+type Tally struct {
+	Authorize bool `json:"authorize"` // Whether the votes are about adding or removing
+	Votes     int  `json:"votes"`     // Number of votes in favour
+}
+
+// Snapshot is the state of the signer voting at a given block.
+type Snapshot struct {
+	epoch uint64 // Number of blocks after which the votes are reset
+
+	Number  uint64                      // Block number the snapshot was taken at
+	Hash    common.Hash                 // Block hash the snapshot was taken at
+	Signers map[common.Address]struct{} // Currently authorized signers
+	Recents map[uint64]common.Address   // Recent signers by block number, for spam protection
+	Votes   []*Vote                     // Votes cast, in chronological order
+	Tally   map[common.Address]Tally    // Current vote tally, by account
+}
+
+// newSnapshot creates a snapshot with the given signers and no votes, as at
+// the genesis block or a checkpoint.
+func newSnapshot(epoch, number uint64, hash common.Hash, signers []common.Address) *Snapshot {
+	snap := &Snapshot{
+		epoch:   epoch,
+		Number:  number,
+		Hash:    hash,
+		Signers: make(map[common.Address]struct{}),
+		Recents: make(map[uint64]common.Address),
+		Tally:   make(map[common.Address]Tally),
+	}
+	for _, signer := range signers {
+		snap.Signers[signer] = struct{}{}
+	}
+	return snap
+}
+
+// copy creates a deep copy of the snapshot, votes are immutable.
+func (s *Snapshot) copy() *Snapshot {
+	cpy := &Snapshot{
+		epoch:   s.epoch,
+		Number:  s.Number,
+		Hash:    s.Hash,
+		Signers: make(map[common.Address]struct{}),
+		Recents: make(map[uint64]common.Address),
+		Votes:   make([]*Vote, len(s.Votes)),
+		Tally:   make(map[common.Address]Tally),
+	}
+	for signer := range s.Signers {
+		cpy.Signers[signer] = struct{}{}
+	}
+	for block, signer := range s.Recents {
+		cpy.Recents[block] = signer
+	}
+	for address, tally := range s.Tally {
+		cpy.Tally[address] = tally
+	}
+	copy(cpy.Votes, s.Votes)
+	return cpy
+}
+
+// validVote returns whether voting on address makes sense, i.e. it would add
+// an account which isn't a signer yet or remove one which is.
+func (s *Snapshot) validVote(address common.Address, authorize bool) bool {
+	_, signer := s.Signers[address]
+	return (signer && !authorize) || (!signer && authorize)
+}
+
+// cast adds a vote to the tally, returning whether it was counted.
+func (s *Snapshot) cast(address common.Address, authorize bool) bool {
+	if !s.validVote(address, authorize) {
+		return false
+	}
+	if tally, ok := s.Tally[address]; ok {
+		tally.Votes++
+		s.Tally[address] = tally
+	} else {
+		s.Tally[address] = Tally{Authorize: authorize, Votes: 1}
+	}
+	return true
+}
+
+// uncast removes a previously cast vote from the tally.
+func (s *Snapshot) uncast(address common.Address, authorize bool) bool {
+	tally, ok := s.Tally[address]
+	if !ok || tally.Authorize != authorize {
+		return false
+	}
+	if tally.Votes > 1 {
+		tally.Votes--
+		s.Tally[address] = tally
+	} else {
+		delete(s.Tally, address)
+	}
+	return true
+}
+
+// signedRecently returns whether signer sealed one of the blocks before number
+// recently enough to not be allowed to seal block number yet.
+func (s *Snapshot) signedRecently(number uint64, signer common.Address) bool {
+	limit := uint64(len(s.Signers)/2 + 1)
+	for seen, recent := range s.Recents {
+		if recent == signer && (number < limit || seen > number-limit) {
+			return true
+		}
+	}
+	return false
+}
+
+// apply creates a new snapshot by applying the given headers, which need to
+// follow the snapshot block without gaps, to the snapshot.
+func (s *Snapshot) apply(headers []*types.Header, sigcache *lru.Cache) (*Snapshot, error) {
+	if len(headers) == 0 {
+		return s, nil
+	}
+	for i, header := range headers {
+		if header.Number.Uint64() != s.Number+uint64(i)+1 {
+			return nil, errInvalidVotingChain
+		}
+	}
+	snap := s.copy()
+	for _, header := range headers {
+		number := header.Number.Uint64()
+		if number%s.epoch == 0 {
+			snap.Votes = nil
+			snap.Tally = make(map[common.Address]Tally)
+		}
+		// Let the oldest recent signer sign again
+		if limit := uint64(len(snap.Signers)/2 + 1); number >= limit {
+			delete(snap.Recents, number-limit)
+		}
+		signer, err := ecrecover(header, sigcache)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := snap.Signers[signer]; !ok {
+			return nil, errUnauthorized
+		}
+		if snap.signedRecently(number, signer) {
+			return nil, errRecentlySigned
+		}
+		snap.Recents[number] = signer
+
+		// Replace any earlier vote of the signer on the same account
+		for i, vote := range snap.Votes {
+			if vote.Signer == signer && vote.Address == header.Coinbase {
+				snap.uncast(vote.Address, vote.Authorize)
+				snap.Votes = append(snap.Votes[:i], snap.Votes[i+1:]...)
+				break
+			}
+		}
+		authorize := header.Nonce == nonceAuthVote
+		if snap.cast(header.Coinbase, authorize) {
+			snap.Votes = append(snap.Votes, &Vote{
+				Signer:    signer,
+				Block:     number,
+				Address:   header.Coinbase,
+				Authorize: authorize,
+			})
+		}
+		// Update the signers once a majority agrees
+		if tally := snap.Tally[header.Coinbase]; tally.Votes > len(snap.Signers)/2 {
+			if tally.Authorize {
+				snap.Signers[header.Coinbase] = struct{}{}
+			} else {
+				delete(snap.Signers, header.Coinbase)
+
+				// The signer list shrunk, let the next recent signer sign again
+				if limit := uint64(len(snap.Signers)/2 + 1); number >= limit {
+					delete(snap.Recents, number-limit)
+				}
+				// Discard the votes cast by the removed signer
+				for i := 0; i < len(snap.Votes); i++ {
+					if snap.Votes[i].Signer == header.Coinbase {
+						snap.uncast(snap.Votes[i].Address, snap.Votes[i].Authorize)
+						snap.Votes = append(snap.Votes[:i], snap.Votes[i+1:]...)
+						i--
+					}
+				}
+			}
+			// Discard the votes on the account which was just decided
+			for i := 0; i < len(snap.Votes); i++ {
+				if snap.Votes[i].Address == header.Coinbase {
+					snap.Votes = append(snap.Votes[:i], snap.Votes[i+1:]...)
+					i--
+				}
+			}
+			delete(snap.Tally, header.Coinbase)
+		}
+	}
+	last := headers[len(headers)-1]
+	snap.Number, snap.Hash = last.Number.Uint64(), last.Hash()
+
+	return snap, nil
+}
+
+// SortedSigners returns the authorized signers in ascending order.
+func (s *Snapshot) SortedSigners() []common.Address {
+	signers := make([]common.Address, 0, len(s.Signers))
+	for signer := range s.Signers {
+		signers = append(signers, signer)
+	}
+	sort.Sort(addresses(signers))
+	return signers
+}
+
+// inturn returns whether it is the turn of signer to seal block number.
+func (s *Snapshot) inturn(number uint64, signer common.Address) bool {
+	signers := s.SortedSigners()
+	if len(signers) == 0 {
+		return false
+	}
+	return signers[number%uint64(len(signers))] == signer
+}
+
+type addresses []common.Address
+
+func (a addresses) Len() int           { return len(a) }
+func (a addresses) Less(i, j int) bool { return bytes.Compare(a[i][:], a[j][:]) < 0 }
+func (a addresses) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
@@ -18,9 +18,12 @@ package main
 
 import (
 	"fmt"
+	"math"
 	"os"
 	"path/filepath"
+	"runtime"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/codegangsta/cli"
@@ -35,10 +38,55 @@ import (
 )
 
 var (
+	importCommandBenchFlag = cli.BoolFlag{
+		Name:  "bench",
+		Usage: "Report per-phase timings and allocation statistics of the import",
+	}
 	importCommand = cli.Command{
 		Action: importChain,
 		Name:   "import",
 		Usage:  `import a blockchain file`,
+		Description: `
+
+    gexp import [--bench] <file>
+
+Imports the RLP encoded blocks of <file>, in the current encoding or the
+legacy one of eth/61 and earlier exports. With --bench, the time spent
+validating headers, recovering transaction senders, executing blocks,
+committing state tries and writing to the database is reported together
+with the memory allocations made over the imported range.
+`,
+		Flags: []cli.Flag{
+			importCommandBenchFlag,
+		},
+	}
+	importdbCommand = cli.Command{
+		Action: importChainDatabase,
+		Name:   "importdb",
+		Usage:  `import blocks from another client's chain database`,
+		Description: `
+Requires a first argument of the chaindata directory to read
+(e.g. ~/.ethereum/chaindata of a geth node). The canonical chain
+of that database is verified against the Expanse rules and imported.
+A local chain without blocks is reset to the genesis of the source
+database first. Optional second and third arguments control the
+first and last block to import. Use the import command for exported
+RLP files.
+		`,
+	}
+	exportCommandFormatFlag = cli.StringFlag{
+		Name:  "format",
+		Value: "rlp",
+		Usage: "Export format (rlp, csv, json)",
+	}
+	exportCommandTableFlag = cli.StringFlag{
+		Name:  "table",
+		Value: "blocks",
+		Usage: "Table exported in csv and json format (blocks, transactions, receipts)",
+	}
+	exportCommandColumnsFlag = cli.StringFlag{
+		Name:  "columns",
+		Usage: "Comma separated columns exported in csv and json format (default all)",
 	}
 	exportCommand = cli.Command{
 		Action: exportChain,
@@ -49,7 +97,26 @@ Requires a first argument of the file to write to.
 Optional second and third arguments control the first and
 last block to write. In this mode, the file will be appended
 if already existing.
+
+With --format csv or json, the blocks, transactions or receipts
+of the canonical chain are written as a flat table instead, one
+line per row, for loading into analytical databases. The file is
+overwritten and --columns selects the columns written:
+
+  blocks:       number, hash, parentHash, timestamp, miner, difficulty,
+                gasLimit, gasUsed, transactionCount, uncleCount, size,
+                stateRoot, extraData
+  transactions: blockNumber, blockHash, index, hash, from, to, value,
+                gas, gasPrice, nonce, input
+  receipts:     blockNumber, blockHash, index, transactionHash,
+                contractAddress, gasUsed, cumulativeGasUsed, postState,
+                logCount
 		`,
+		Flags: []cli.Flag{
+			exportCommandFormatFlag,
+			exportCommandTableFlag,
+			exportCommandColumnsFlag,
+		},
 	}
 	upgradedbCommand = cli.Command{
 		Action: upgradeDB,
@@ -77,12 +144,93 @@ func importChain(ctx *cli.Context) {
 		utils.Fatalf("This command requires an argument.")
 	}
 	chain, chainDb := utils.MakeChain(ctx)
+
+	var (
+		bench   *core.ImportStats
+		memPre  runtime.MemStats
+		memPost runtime.MemStats
+	)
+	if ctx.Bool(importCommandBenchFlag.Name) {
+		bench = new(core.ImportStats)
+		chain.SetImportStats(bench)
+		runtime.ReadMemStats(&memPre)
+	}
 	start := time.Now()
 	err := utils.ImportChain(chain, ctx.Args().First())
+	elapsed := time.Since(start)
+	if bench != nil {
+		runtime.ReadMemStats(&memPost)
+	}
 	chainDb.Close()
 	if err != nil {
 		utils.Fatalf("Import error: %v", err)
 	}
+	fmt.Printf("Import done in %v\n", elapsed)
+	if bench != nil {
+		printImportStats(bench, elapsed, &memPre, &memPost)
+	}
+}
+
+// printImportStats reports the phase timings and memory allocations of a
+// benchmarked chain import.
+func printImportStats(stats *core.ImportStats, elapsed time.Duration, pre, post *runtime.MemStats) {
+	fmt.Printf("Imported %d blocks, %d transactions\n", stats.Blocks, stats.Txs)
+
+	phases := []struct {
+		name string
+		took time.Duration
+	}{
+		{"header validation", stats.Validation},
+		{"sender recovery", stats.Senders},
+		{"evm execution", stats.Execution},
+		{"trie commit", stats.Commit},
+		{"db write", stats.Write},
+	}
+	for _, phase := range phases {
+		var share, perBlock float64
+		if elapsed > 0 {
+			share = 100 * float64(phase.took) / float64(elapsed)
+		}
+		if stats.Blocks > 0 {
+			perBlock = float64(phase.took) / float64(stats.Blocks)
+		}
+		fmt.Printf("  %-18s %12v %6.2f%% %12v/block\n", phase.name, phase.took, share, time.Duration(perBlock))
+	}
+	fmt.Printf("Allocations: %d objects, %v, %d GC cycles, %v GC pause\n",
+		post.Mallocs-pre.Mallocs,
+		common.StorageSize(post.TotalAlloc-pre.TotalAlloc),
+		post.NumGC-pre.NumGC,
+		time.Duration(post.PauseTotalNs-pre.PauseTotalNs))
+}
+
+func importChainDatabase(ctx *cli.Context) {
+	if len(ctx.Args()) != 1 && len(ctx.Args()) != 3 {
+		utils.Fatalf("This command requires one or three arguments.")
+	}
+	first, last := uint64(0), uint64(math.MaxUint64)
+	if len(ctx.Args()) == 3 {
+		var ferr, lerr error
+		first, ferr = strconv.ParseUint(ctx.Args().Get(1), 10, 64)
+		last, lerr = strconv.ParseUint(ctx.Args().Get(2), 10, 64)
+		if ferr != nil || lerr != nil {
+			utils.Fatalf("Import error in parsing parameters: block number not an integer")
+		}
+	}
+	dir := ctx.Args().First()
+	if _, err := os.Stat(dir); err != nil {
+		utils.Fatalf("Could not open source database: %v", err)
+	}
+	srcDb, err := ethdb.NewLDBDatabase(dir, ctx.GlobalInt(utils.CacheFlag.Name))
+	if err != nil {
+		utils.Fatalf("Could not open source database: %v", err)
+	}
+	chain, chainDb := utils.MakeChain(ctx)
+	start := time.Now()
+	err = utils.ImportChainDatabase(chain, chainDb, srcDb, first, last)
+	closeAll(chainDb, srcDb)
+	if err != nil {
+		utils.Fatalf("Import error: %v", err)
+	}
 	fmt.Printf("Import done in %v", time.Since(start))
 }
 
@@ -93,21 +241,42 @@ func exportChain(ctx *cli.Context) {
 	chain, _ := utils.MakeChain(ctx)
 	start := time.Now()
 
-	var err error
-	fp := ctx.Args().First()
-	if len(ctx.Args()) < 3 {
-		err = utils.ExportChain(chain, fp)
-	} else {
+	var (
+		err    error
+		fp     = ctx.Args().First()
+		ranged = len(ctx.Args()) >= 3
+		format = ctx.String(exportCommandFormatFlag.Name)
+
+		first, last uint64
+	)
+	if ranged {
 		// This can be improved to allow for numbers larger than 9223372036854775807
-		first, ferr := strconv.ParseInt(ctx.Args().Get(1), 10, 64)
-		last, lerr := strconv.ParseInt(ctx.Args().Get(2), 10, 64)
+		ifirst, ferr := strconv.ParseInt(ctx.Args().Get(1), 10, 64)
+		ilast, lerr := strconv.ParseInt(ctx.Args().Get(2), 10, 64)
 		if ferr != nil || lerr != nil {
 			utils.Fatalf("Export error in parsing parameters: block number not an integer\n")
 		}
-		if first < 0 || last < 0 {
+		if ifirst < 0 || ilast < 0 {
 			utils.Fatalf("Export error: block number must be greater than 0\n")
 		}
-		err = utils.ExportAppendChain(chain, fp, uint64(first), uint64(last))
+		first, last = uint64(ifirst), uint64(ilast)
+	}
+	switch {
+	case format != "rlp":
+		if !ranged {
+			last = chain.CurrentBlock().NumberU64()
+		}
+		var columns []string
+		for _, name := range strings.Split(ctx.String(exportCommandColumnsFlag.Name), ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				columns = append(columns, name)
+			}
+		}
+		err = utils.ExportChainTable(chain, fp, ctx.String(exportCommandTableFlag.Name), format, columns, first, last)
+	case ranged:
+		err = utils.ExportAppendChain(chain, fp, first, last)
+	default:
+		err = utils.ExportChain(chain, fp)
 	}
 
 	if err != nil {
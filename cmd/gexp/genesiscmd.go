@@ -0,0 +1,242 @@
+// Copyright 2016 The go-expanse Authors
+// This file is part of the go-expanse library.
+//
+// The go-expanse library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-expanse library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-expanse library. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/codegangsta/cli"
+	"github.com/expanse-project/go-expanse/cmd/utils"
+	"github.com/expanse-project/go-expanse/common"
+	"github.com/expanse-project/go-expanse/p2p/discover"
+	"github.com/expanse-project/go-expanse/params"
+)
+
+var (
+	genesisCommandPresetFlag = cli.StringFlag{
+		Name:  "preset",
+		Value: "consortium",
+		Usage: "Difficulty and gas limit preset (dev, consortium)",
+	}
+	genesisCommandBalanceFlag = cli.StringFlag{
+		Name:  "balance",
+		Value: "1000000000000000000000000",
+		Usage: "Balance in wei prefunded to each local account",
+	}
+	genesisCommandNodesFlag = cli.StringFlag{
+		Name:  "nodes",
+		Usage: "Comma separated enode URLs of the network's static nodes",
+	}
+	genesisCommandNetworkIdFlag = cli.IntFlag{
+		Name:  "networkid",
+		Usage: "Network and chain identifier of the network (random if unset)",
+	}
+	genesisCommand = cli.Command{
+		Action: makeGenesis,
+		Name:   "genesis",
+		Usage:  `generate the genesis and static nodes files of a private network`,
+		Description: `
+
+    gexp genesis [--preset dev|consortium] [--networkid <id>] [--nodes <enode,...>] <directory>
+
+Writes genesis.json and static-nodes.json into <directory>. Every account
+of the local keystore is prefunded in the genesis block, and the genesis
+nonce and network id are chosen randomly unless given, so that the network
+can't be confused with another one created the same way.
+
+The chain config of the genesis enables all forks and replay protection
+from the first block, using the network id as chain id. The dev preset
+uses a minimal difficulty for single node testing, the consortium preset
+the minimum difficulty of the protocol and a higher gas limit. Every node
+of the network has to be started with the same --genesis file, and
+static-nodes.json copied into its data directory.
+`,
+		Flags: []cli.Flag{
+			genesisCommandPresetFlag,
+			genesisCommandBalanceFlag,
+			genesisCommandNodesFlag,
+			genesisCommandNetworkIdFlag,
+		},
+	}
+)
+
+// genesisPreset is a set of genesis block parameters suited for a kind of
+// private network.
+type genesisPreset struct {
+	difficulty *big.Int
+	gasLimit   *big.Int
+}
+
+var genesisPresets = map[string]genesisPreset{
+	"dev":        {difficulty: big.NewInt(0x400), gasLimit: big.NewInt(4712388)},
+	"consortium": {difficulty: params.MinimumDifficulty, gasLimit: big.NewInt(8000000)},
+}
+
+// genesisMaxTxSize is the maximum RLP encoded size of a transaction configured
+// for generated networks.
+var genesisMaxTxSize = big.NewInt(128 * 1024)
+
+// genesisAccount is a prefunded account of the genesis JSON format read by
+// core.WriteGenesisBlock.
+type genesisAccount struct {
+	Balance string `json:"balance"`
+}
+
+// genesisSpec is the genesis JSON format read by core.WriteGenesisBlock.
+type genesisSpec struct {
+	Nonce      string                    `json:"nonce"`
+	Timestamp  string                    `json:"timestamp"`
+	ParentHash string                    `json:"parentHash"`
+	ExtraData  string                    `json:"extraData"`
+	GasLimit   string                    `json:"gasLimit"`
+	Difficulty string                    `json:"difficulty"`
+	Mixhash    string                    `json:"mixhash"`
+	Coinbase   string                    `json:"coinbase"`
+	Alloc      map[string]genesisAccount `json:"alloc"`
+	Config     *genesisConfig            `json:"config"`
+}
+
+// genesisConfig is the chain config section of the genesis JSON format, see
+// core.ChainConfig.
+type genesisConfig struct {
+	ChainId              string `json:"chainId"`
+	HomesteadBlock       string `json:"homesteadBlock"`
+	EIP155Block          string `json:"eip155Block"`
+	NetworkId            string `json:"networkId"`
+	MinGasLimit          string `json:"minGasLimit"`
+	GasLimitBoundDivisor string `json:"gasLimitBoundDivisor"`
+	MaxTransactionSize   string `json:"maxTransactionSize"`
+}
+
+func makeGenesis(ctx *cli.Context) {
+	if len(ctx.Args()) != 1 {
+		utils.Fatalf("This command requires an argument.")
+	}
+	dir := ctx.Args().First()
+
+	preset, ok := genesisPresets[ctx.String(genesisCommandPresetFlag.Name)]
+	if !ok {
+		utils.Fatalf("Unknown preset %q", ctx.String(genesisCommandPresetFlag.Name))
+	}
+	balance, ok := new(big.Int).SetString(ctx.String(genesisCommandBalanceFlag.Name), 10)
+	if !ok || balance.Sign() < 0 {
+		utils.Fatalf("Invalid balance %q", ctx.String(genesisCommandBalanceFlag.Name))
+	}
+	accounts, err := utils.MakeAccountManager(ctx).Accounts()
+	if err != nil {
+		utils.Fatalf("Could not list accounts: %v", err)
+	}
+	if len(accounts) == 0 {
+		utils.Fatalf("No accounts to prefund, create one with 'gexp account new'")
+	}
+	addrs := make([]common.Address, len(accounts))
+	for i, acct := range accounts {
+		addrs[i] = acct.Address
+	}
+	nodes, err := parseStaticNodes(ctx.String(genesisCommandNodesFlag.Name))
+	if err != nil {
+		utils.Fatalf("%v", err)
+	}
+	var nonce [8]byte
+	if _, err := rand.Read(nonce[:]); err != nil {
+		utils.Fatalf("Could not generate genesis nonce: %v", err)
+	}
+	networkId := uint64(ctx.Int(genesisCommandNetworkIdFlag.Name))
+	if networkId == 0 {
+		// Derive the network id from the genesis nonce, like localnet does
+		networkId = new(big.Int).SetBytes(nonce[:3]).Uint64() + 100
+	}
+	if networkId > math.MaxUint32 {
+		utils.Fatalf("Invalid network id %d", networkId)
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		utils.Fatalf("Could not create %s: %v", dir, err)
+	}
+	genesis := newGenesisSpec(preset, nonce, networkId, addrs, balance)
+	if err := writeJSON(filepath.Join(dir, "genesis.json"), genesis); err != nil {
+		utils.Fatalf("Could not write genesis: %v", err)
+	}
+	if err := writeJSON(filepath.Join(dir, "static-nodes.json"), nodes); err != nil {
+		utils.Fatalf("Could not write static nodes: %v", err)
+	}
+	fmt.Printf("Genesis of network %d with %d prefunded accounts and %d static nodes written to %s\n", networkId, len(addrs), len(nodes), dir)
+}
+
+// newGenesisSpec assembles a genesis block of the given network prefunding each
+// of the accounts with balance wei.
+func newGenesisSpec(preset genesisPreset, nonce [8]byte, networkId uint64, accounts []common.Address, balance *big.Int) *genesisSpec {
+	id := common.ToHex(new(big.Int).SetUint64(networkId).Bytes())
+	genesis := &genesisSpec{
+		Nonce:      common.ToHex(nonce[:]),
+		Timestamp:  "0x00",
+		ParentHash: common.Hash{}.Hex(),
+		ExtraData:  "0x",
+		GasLimit:   common.ToHex(preset.gasLimit.Bytes()),
+		Difficulty: common.ToHex(preset.difficulty.Bytes()),
+		Mixhash:    common.Hash{}.Hex(),
+		Coinbase:   common.Address{}.Hex(),
+		Alloc:      make(map[string]genesisAccount),
+		Config: &genesisConfig{
+			ChainId:              id,
+			HomesteadBlock:       "0x0",
+			EIP155Block:          "0x0",
+			NetworkId:            id,
+			MinGasLimit:          common.ToHex(params.MinGasLimit.Bytes()),
+			GasLimitBoundDivisor: common.ToHex(params.GasLimitBoundDivisor.Bytes()),
+			MaxTransactionSize:   common.ToHex(genesisMaxTxSize.Bytes()),
+		},
+	}
+	for _, addr := range accounts {
+		genesis.Alloc[addr.Hex()] = genesisAccount{Balance: balance.String()}
+	}
+	return genesis
+}
+
+// parseStaticNodes validates a comma separated list of enode URLs and returns
+// them sorted.
+func parseStaticNodes(list string) ([]string, error) {
+	nodes := []string{}
+	for _, url := range strings.Split(list, ",") {
+		if url = strings.TrimSpace(url); url == "" {
+			continue
+		}
+		if _, err := discover.ParseNode(url); err != nil {
+			return nil, fmt.Errorf("invalid node URL %s: %v", url, err)
+		}
+		nodes = append(nodes, url)
+	}
+	sort.Strings(nodes)
+	return nodes, nil
+}
+
+func writeJSON(path string, v interface{}) error {
+	blob, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, append(blob, '\n'), 0644)
+}
@@ -0,0 +1,99 @@
+// Copyright 2016 The go-expanse Authors
+// This file is part of the go-expanse library.
+//
+// The go-expanse library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-expanse library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-expanse library. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/expanse-project/go-expanse/common"
+	"github.com/expanse-project/go-expanse/core"
+	"github.com/expanse-project/go-expanse/core/state"
+	"github.com/expanse-project/go-expanse/ethdb"
+	"github.com/expanse-project/go-expanse/params"
+)
+
+func TestGenesisSpec(t *testing.T) {
+	var (
+		accounts = []common.Address{common.HexToAddress("0x01"), common.HexToAddress("0x02")}
+		balance  = big.NewInt(1000)
+		preset   = genesisPresets["consortium"]
+	)
+	blob, err := json.Marshal(newGenesisSpec(preset, [8]byte{1, 2, 3, 4, 5, 6, 7, 8}, 4242, accounts, balance))
+	if err != nil {
+		t.Fatalf("failed to encode genesis: %v", err)
+	}
+	db, _ := ethdb.NewMemDatabase()
+	block, err := core.WriteGenesisBlock(db, bytes.NewReader(blob))
+	if err != nil {
+		t.Fatalf("failed to write genesis: %v", err)
+	}
+	if block.Nonce() != 0x0102030405060708 {
+		t.Errorf("nonce mismatch: have %x, want %x", block.Nonce(), 0x0102030405060708)
+	}
+	if block.Difficulty().Cmp(preset.difficulty) != 0 || block.GasLimit().Cmp(preset.gasLimit) != 0 {
+		t.Errorf("preset mismatch: have difficulty %v gas limit %v", block.Difficulty(), block.GasLimit())
+	}
+	config := core.GetChainConfig(db, block.Hash())
+	if config == nil {
+		t.Fatalf("chain config not stored")
+	}
+	if id := config.Network(1); id != 4242 {
+		t.Errorf("network id mismatch: have %d, want 4242", id)
+	}
+	if config.ChainId == nil || config.ChainId.Int64() != 4242 {
+		t.Errorf("chain id mismatch: have %v, want 4242", config.ChainId)
+	}
+	if config.HomesteadBlock == nil || config.HomesteadBlock.Sign() != 0 || config.EIP155Block == nil || config.EIP155Block.Sign() != 0 {
+		t.Errorf("fork blocks mismatch: have homestead %v, EIP-155 %v", config.HomesteadBlock, config.EIP155Block)
+	}
+	if config.MinGasLimit.Cmp(params.MinGasLimit) != 0 || config.GasLimitBoundDivisor.Cmp(params.GasLimitBoundDivisor) != 0 {
+		t.Errorf("gas limit rules mismatch: have minimum %v, divisor %v", config.MinGasLimit, config.GasLimitBoundDivisor)
+	}
+	if config.MaxTransactionSize.Cmp(genesisMaxTxSize) != 0 {
+		t.Errorf("maximum transaction size mismatch: have %v, want %v", config.MaxTransactionSize, genesisMaxTxSize)
+	}
+	statedb, err := state.New(block.Root(), db)
+	if err != nil {
+		t.Fatalf("failed to open genesis state: %v", err)
+	}
+	for _, addr := range accounts {
+		if have := statedb.GetBalance(addr); have.Cmp(balance) != 0 {
+			t.Errorf("balance mismatch for %x: have %v, want %v", addr, have, balance)
+		}
+	}
+}
+
+func TestParseStaticNodes(t *testing.T) {
+	const node = "enode://1dd9d65c4552b5eb43d5ad55a2ee3f56c6cbc1c64a5c8d659f51fcd51bace24351232b8d7821617d2b29b54b81cdefb9b3e9c37d7fd5f63270bcc9e1a6f6a439@127.0.0.1:30303"
+
+	nodes, err := parseStaticNodes(" " + node + ", ")
+	if err != nil {
+		t.Fatalf("failed to parse nodes: %v", err)
+	}
+	if len(nodes) != 1 || nodes[0] != node {
+		t.Errorf("nodes mismatch: have %v, want [%s]", nodes, node)
+	}
+	if nodes, _ := parseStaticNodes(""); nodes == nil || len(nodes) != 0 {
+		t.Errorf("expected empty node list, got %v", nodes)
+	}
+	if _, err := parseStaticNodes("enode://invalid@127.0.0.1:30303"); err == nil {
+		t.Errorf("expected error for invalid node URL")
+	}
+}
@@ -188,7 +188,8 @@ func newJSRE(expanse *exp.Expanse, docRoot, corsDomain string, client comms.Expa
 		f = js
 	}
 
-	js.xeth = xeth.New(expanse, f)
+	js.xeth = xeth.New(expanse.ApiBackend(), f)
+	js.xeth.SetOrigin("console")
 
 	js.wait = js.xeth.UpdateState()
 	js.client = client
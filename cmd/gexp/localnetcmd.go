@@ -0,0 +1,257 @@
+// Copyright 2016 The go-expanse Authors
+// This file is part of the go-expanse library.
+//
+// The go-expanse library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-expanse library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-expanse library. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+
+	"github.com/codegangsta/cli"
+	"github.com/expanse-project/go-expanse/accounts"
+	"github.com/expanse-project/go-expanse/cmd/utils"
+	"github.com/expanse-project/go-expanse/common"
+	"github.com/expanse-project/go-expanse/crypto"
+	"github.com/expanse-project/go-expanse/p2p/discover"
+)
+
+var (
+	localnetCountFlag = cli.IntFlag{
+		Name:  "count",
+		Value: 3,
+		Usage: "Number of nodes of the network",
+	}
+	localnetPortFlag = cli.IntFlag{
+		Name:  "baseport",
+		Value: 30310,
+		Usage: "Network listening port of the first node, the others use the following ones",
+	}
+	localnetRPCPortFlag = cli.IntFlag{
+		Name:  "rpcbaseport",
+		Value: 9660,
+		Usage: "HTTP-RPC port of the first node, the others use the following ones",
+	}
+	localnetCommand = cli.Command{
+		Action: runLocalNet,
+		Name:   "testnet-local",
+		Usage:  `run a private network of several nodes on this machine`,
+		Description: `
+
+    gexp testnet-local [--count n] [--preset dev|consortium] <directory>
+
+Runs a private network of n local nodes, each in a gexp process of its own.
+On first use, <directory> is populated with a data directory per node, an
+account per node prefunded in a newly generated genesis.json, and the
+static-nodes.json files connecting every node to all others. Later runs
+with the same directory restart the existing network.
+
+The nodes listen on sequential ports starting at --baseport and serve
+HTTP-RPC on sequential ports starting at --rpcbaseport. Their accounts
+have empty passwords. The layout of the network is written to
+localnet.json and the output of each node to the gexp.log file in its
+data directory. Interrupting the command stops all nodes.
+`,
+		Flags: []cli.Flag{
+			localnetCountFlag,
+			localnetPortFlag,
+			localnetRPCPortFlag,
+			genesisCommandPresetFlag,
+			genesisCommandBalanceFlag,
+		},
+	}
+)
+
+// localNet describes a private network of local nodes.
+type localNet struct {
+	NetworkId int          `json:"networkid"`
+	Genesis   string       `json:"genesis"`
+	Nodes     []*localNode `json:"nodes"`
+}
+
+// localNode describes a node of a local network.
+type localNode struct {
+	DataDir   string         `json:"datadir"`
+	Port      int            `json:"port"`
+	RPCPort   int            `json:"rpcport"`
+	Etherbase common.Address `json:"etherbase"`
+	Enode     string         `json:"enode"`
+}
+
+func runLocalNet(ctx *cli.Context) {
+	if len(ctx.Args()) != 1 {
+		utils.Fatalf("This command requires an argument.")
+	}
+	dir := ctx.Args().First()
+
+	network, err := loadLocalNet(dir)
+	if os.IsNotExist(err) {
+		preset, ok := genesisPresets[ctx.String(genesisCommandPresetFlag.Name)]
+		if !ok {
+			utils.Fatalf("Unknown preset %q", ctx.String(genesisCommandPresetFlag.Name))
+		}
+		balance, ok := new(big.Int).SetString(ctx.String(genesisCommandBalanceFlag.Name), 10)
+		if !ok || balance.Sign() < 0 {
+			utils.Fatalf("Invalid balance %q", ctx.String(genesisCommandBalanceFlag.Name))
+		}
+		count := ctx.Int(localnetCountFlag.Name)
+		if count < 1 {
+			utils.Fatalf("Invalid node count %d", count)
+		}
+		network, err = setupLocalNet(dir, count, ctx.Int(localnetPortFlag.Name), ctx.Int(localnetRPCPortFlag.Name), preset, balance)
+		if err != nil {
+			utils.Fatalf("Could not set up network: %v", err)
+		}
+		fmt.Printf("Network of %d nodes set up in %s\n", count, dir)
+	} else if err != nil {
+		utils.Fatalf("Could not load network: %v", err)
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		utils.Fatalf("Could not locate gexp executable: %v", err)
+	}
+	var procs []*exec.Cmd
+	defer func() {
+		for _, proc := range procs {
+			proc.Process.Signal(os.Interrupt)
+		}
+		for _, proc := range procs {
+			proc.Wait()
+		}
+	}()
+	verbosity := ctx.GlobalInt(utils.VerbosityFlag.Name)
+	for i, node := range network.Nodes {
+		logfile, err := os.OpenFile(filepath.Join(node.DataDir, "gexp.log"), os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+		if err != nil {
+			utils.Fatalf("Could not open log of node %d: %v", i, err)
+		}
+		defer logfile.Close()
+
+		proc := exec.Command(exe, network.nodeArgs(node, verbosity)...)
+		proc.Stdout, proc.Stderr = logfile, logfile
+		if err := proc.Start(); err != nil {
+			utils.Fatalf("Could not start node %d: %v", i, err)
+		}
+		procs = append(procs, proc)
+		fmt.Printf("Node %d: %s, RPC on port %d, etherbase %x\n", i, node.DataDir, node.RPCPort, node.Etherbase)
+	}
+	fmt.Println("Press Ctrl-C to stop the network")
+
+	sigc := make(chan os.Signal, 1)
+	signal.Notify(sigc, os.Interrupt)
+	defer signal.Stop(sigc)
+	<-sigc
+	fmt.Println("Stopping the network...")
+}
+
+// nodeArgs returns the command line running node as part of the network.
+func (network *localNet) nodeArgs(node *localNode, verbosity int) []string {
+	return []string{
+		"--" + utils.DataDirFlag.Name, node.DataDir,
+		"--" + utils.GenesisFileFlag.Name, network.Genesis,
+		"--" + utils.NetworkIdFlag.Name, strconv.Itoa(network.NetworkId),
+		"--" + utils.ListenPortFlag.Name, strconv.Itoa(node.Port),
+		"--" + utils.NoDiscoverFlag.Name,
+		"--" + utils.RPCEnabledFlag.Name,
+		"--" + utils.RPCPortFlag.Name, strconv.Itoa(node.RPCPort),
+		"--" + utils.EtherbaseFlag.Name, node.Etherbase.Hex(),
+		"--" + utils.VerbosityFlag.Name, strconv.Itoa(verbosity),
+	}
+}
+
+// loadLocalNet reads the description of the network set up in dir.
+func loadLocalNet(dir string) (*localNet, error) {
+	blob, err := ioutil.ReadFile(filepath.Join(dir, "localnet.json"))
+	if err != nil {
+		return nil, err
+	}
+	network := new(localNet)
+	if err := json.Unmarshal(blob, network); err != nil {
+		return nil, err
+	}
+	return network, nil
+}
+
+// setupLocalNet creates the data directories, keys and genesis block of a
+// network of count nodes in dir.
+func setupLocalNet(dir string, count, port, rpcport int, preset genesisPreset, balance *big.Int) (*localNet, error) {
+	var nonce [8]byte
+	if _, err := rand.Read(nonce[:]); err != nil {
+		return nil, err
+	}
+	network := &localNet{
+		// Derive the network id from the genesis nonce, so networks created
+		// the same way don't accept each other's nodes.
+		NetworkId: int(new(big.Int).SetBytes(nonce[:3]).Int64()) + 100,
+		Genesis:   filepath.Join(dir, "genesis.json"),
+	}
+	addrs := make([]common.Address, count)
+	for i := 0; i < count; i++ {
+		node := &localNode{
+			DataDir: filepath.Join(dir, fmt.Sprintf("node%d", i)),
+			Port:    port + i,
+			RPCPort: rpcport + i,
+		}
+		if err := os.MkdirAll(node.DataDir, 0700); err != nil {
+			return nil, err
+		}
+		key, err := crypto.GenerateKey()
+		if err != nil {
+			return nil, err
+		}
+		if err := crypto.SaveECDSA(filepath.Join(node.DataDir, "nodekey"), key); err != nil {
+			return nil, err
+		}
+		id := discover.PubkeyID(&key.PublicKey)
+		node.Enode = fmt.Sprintf("enode://%x@127.0.0.1:%d", id[:], node.Port)
+
+		ks := crypto.NewKeyStorePassphrase(filepath.Join(node.DataDir, "keystore"), crypto.LightScryptN, crypto.LightScryptP)
+		account, err := accounts.NewManager(ks).NewAccount("")
+		if err != nil {
+			return nil, err
+		}
+		node.Etherbase, addrs[i] = account.Address, account.Address
+		network.Nodes = append(network.Nodes, node)
+	}
+	// Connect every node to all others
+	for _, node := range network.Nodes {
+		var peers []string
+		for _, peer := range network.Nodes {
+			if peer != node {
+				peers = append(peers, peer.Enode)
+			}
+		}
+		if err := writeJSON(filepath.Join(node.DataDir, "static-nodes.json"), peers); err != nil {
+			return nil, err
+		}
+	}
+	if err := writeJSON(network.Genesis, newGenesisSpec(preset, nonce, uint64(network.NetworkId), addrs, balance)); err != nil {
+		return nil, err
+	}
+	// Written last, so an interrupted setup is started over.
+	if err := writeJSON(filepath.Join(dir, "localnet.json"), network); err != nil {
+		return nil, err
+	}
+	return network, nil
+}
@@ -0,0 +1,86 @@
+// Copyright 2016 The go-expanse Authors
+// This file is part of the go-expanse library.
+//
+// The go-expanse library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-expanse library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-expanse library. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/expanse-project/go-expanse/p2p/discover"
+)
+
+func TestLocalNetSetup(t *testing.T) {
+	dir, err := ioutil.TempDir("", "localnet-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	network, err := setupLocalNet(dir, 3, 30400, 9700, genesisPresets["dev"], big.NewInt(1000))
+	if err != nil {
+		t.Fatalf("failed to set up network: %v", err)
+	}
+	if len(network.Nodes) != 3 {
+		t.Fatalf("node count mismatch: have %d, want 3", len(network.Nodes))
+	}
+	for i, node := range network.Nodes {
+		if node.Port != 30400+i || node.RPCPort != 9700+i {
+			t.Errorf("node %d: port mismatch: have %d/%d", i, node.Port, node.RPCPort)
+		}
+		if _, err := discover.ParseNode(node.Enode); err != nil {
+			t.Errorf("node %d: invalid enode %s: %v", i, node.Enode, err)
+		}
+		// Every node must be statically connected to all others
+		var peers []string
+		blob, _ := ioutil.ReadFile(filepath.Join(node.DataDir, "static-nodes.json"))
+		if err := json.Unmarshal(blob, &peers); err != nil {
+			t.Fatalf("node %d: invalid static nodes: %v", i, err)
+		}
+		if len(peers) != 2 {
+			t.Errorf("node %d: static node count mismatch: have %d, want 2", i, len(peers))
+		}
+		for _, peer := range peers {
+			if peer == node.Enode {
+				t.Errorf("node %d: connected to itself", i)
+			}
+		}
+	}
+	// The etherbase of every node must be prefunded
+	var genesis genesisSpec
+	blob, _ := ioutil.ReadFile(network.Genesis)
+	if err := json.Unmarshal(blob, &genesis); err != nil {
+		t.Fatalf("invalid genesis: %v", err)
+	}
+	for i, node := range network.Nodes {
+		if _, ok := genesis.Alloc[node.Etherbase.Hex()]; !ok {
+			t.Errorf("node %d: etherbase %x not prefunded", i, node.Etherbase)
+		}
+	}
+	// Restarting must pick up the same network
+	loaded, err := loadLocalNet(dir)
+	if err != nil {
+		t.Fatalf("failed to load network: %v", err)
+	}
+	if !reflect.DeepEqual(loaded, network) {
+		t.Errorf("loaded network mismatch:\nhave %+v\nwant %+v", loaded, network)
+	}
+}
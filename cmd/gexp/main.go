@@ -29,7 +29,6 @@ import (
 	"time"
 
 	"github.com/codegangsta/cli"
-	"github.com/expanse-project/ethash"
 	"github.com/expanse-project/go-expanse/accounts"
 	"github.com/expanse-project/go-expanse/cmd/utils"
 	"github.com/expanse-project/go-expanse/common"
@@ -41,7 +40,9 @@ import (
 	"github.com/expanse-project/go-expanse/logger/glog"
 	"github.com/expanse-project/go-expanse/metrics"
 	"github.com/expanse-project/go-expanse/params"
+	"github.com/expanse-project/go-expanse/pow/ethash"
     "github.com/expanse-project/go-expanse/rlp"
+	"github.com/expanse-project/go-expanse/rpc/api"
 	"github.com/expanse-project/go-expanse/rpc/codec"
 	"github.com/expanse-project/go-expanse/rpc/comms"
 )
@@ -86,11 +87,15 @@ recover <hex> recovers by hash
 		},
 		blocktestCommand,
 		importCommand,
+		importdbCommand,
 		exportCommand,
 		upgradedbCommand,
 		removedbCommand,
+		nodedbCommand,
 		dumpCommand,
 		monitorCommand,
+		genesisCommand,
+		localnetCommand,
 		{
 			Action: makedag,
 			Name:   "makedag",
@@ -283,6 +288,11 @@ The Gexp console is an interactive shell for the JavaScript runtime environment
 which exposes a node admin interface as well as the Ðapp JavaScript API.
 See https://github.com/expanse-project/go-expanse/wiki/Javascipt-Console.
 This command allows to open a console on a running gexp node.
+
+The endpoint may list several redundant nodes separated by commas, e.g.
+ipc:/tmp/gexp.ipc,rpc:http://10.0.0.2:9656. The console uses the first
+reachable one and fails over to the next if it goes down, reinstalling the
+filters created through it.
 `,
 		},
 		{
@@ -299,18 +309,37 @@ JavaScript API. See https://github.com/expanse-project/go-expanse/wiki/Javascipt
 		utils.IdentityFlag,
 		utils.UnlockedAccountFlag,
 		utils.PasswordFileFlag,
+		utils.TxPolicyFlag,
+		utils.AuditLogFlag,
+		utils.AuditKeyFlag,
 		utils.GenesisFileFlag,
 		utils.BootnodesFlag,
 		utils.DataDirFlag,
 		utils.BlockchainVersionFlag,
 		utils.OlympicFlag,
 		utils.FastSyncFlag,
+		utils.CheckpointFlag,
 		utils.CacheFlag,
+		utils.ChainCacheFlag,
+		utils.EthashCachesInMemFlag,
+		utils.EthashCachesOnDiskFlag,
+		utils.InternalTxsFlag,
+		utils.MaxFutureDriftFlag,
+		utils.MedianTimeFlag,
+		utils.TxMaxSizeFlag,
+		utils.TxGasFlag,
+		utils.TxGasCapFlag,
+		utils.TxGasPriceFlag,
 		utils.LightKDFFlag,
 		utils.JSpathFlag,
 		utils.ListenPortFlag,
 		utils.MaxPeersFlag,
 		utils.MaxPendingPeersFlag,
+		utils.HandshakeTimeoutFlag,
+		utils.ProtocolHandshakeTimeoutFlag,
+		utils.PeerReadTimeoutFlag,
+		utils.NodeDBMaxAgeFlag,
+		utils.NodeDBMaxNodesFlag,
 		utils.EtherbaseFlag,
 		utils.GasPriceFlag,
 		utils.MinerThreadsFlag,
@@ -319,6 +348,10 @@ JavaScript API. See https://github.com/expanse-project/go-expanse/wiki/Javascipt
 		utils.AutoDAGFlag,
 		utils.NATFlag,
 		utils.NatspecEnabledFlag,
+		utils.NatspecHostsFlag,
+		utils.WebhooksFlag,
+		utils.ChainJournalFlag,
+		utils.ChainJournalDepthFlag,
 		utils.NoDiscoverFlag,
 		utils.NodeKeyFileFlag,
 		utils.NodeKeyHexFlag,
@@ -326,6 +359,23 @@ JavaScript API. See https://github.com/expanse-project/go-expanse/wiki/Javascipt
 		utils.RPCListenAddrFlag,
 		utils.RPCPortFlag,
 		utils.RpcApiFlag,
+		utils.RPCStrictFlag,
+		utils.RPCReadTimeoutFlag,
+		utils.RPCWriteTimeoutFlag,
+		utils.RPCIdleTimeoutFlag,
+		utils.RPCMaxRequestSizeFlag,
+		utils.FilterMaxLogsFlag,
+		utils.FilterMaxHashesFlag,
+		utils.RPCTLSCertFlag,
+		utils.RPCTLSKeyFlag,
+		utils.WSEnabledFlag,
+		utils.WSListenAddrFlag,
+		utils.WSPortFlag,
+		utils.WSApiFlag,
+		utils.WSAllowedOriginsFlag,
+		utils.WSMaxFrameSizeFlag,
+		utils.WSCompressionFlag,
+		utils.WSMaxPendingFlag,
 		utils.IPCDisabledFlag,
 		utils.IPCApiFlag,
 		utils.IPCPathFlag,
@@ -337,6 +387,8 @@ JavaScript API. See https://github.com/expanse-project/go-expanse/wiki/Javascipt
 		utils.VMForceJitFlag,
 		utils.VMJitCacheFlag,
 		utils.VMEnableJitFlag,
+		utils.VMPrecompileAuditFlag,
+		utils.VMGasMetricsFlag,
 		utils.NetworkIdFlag,
 		utils.RPCCORSDomainFlag,
 		utils.VerbosityFlag,
@@ -354,11 +406,21 @@ JavaScript API. See https://github.com/expanse-project/go-expanse/wiki/Javascipt
 		utils.GpobaseStepUpFlag,
 		utils.GpobaseCorrectionFactorFlag,
 		utils.ExtraDataFlag,
+		utils.ExtraNonceFlag,
+		utils.MinerPayoutsFlag,
+		utils.CliqueFlag,
 	}
 	app.Before = func(ctx *cli.Context) error {
 		utils.SetupLogger(ctx)
 		utils.SetupNetwork(ctx)
 		utils.SetupVM(ctx)
+		utils.SetupChainCache(ctx)
+		utils.SetupEthash(ctx)
+		utils.SetupNodeDB(ctx)
+		utils.SetupInternalTxs(ctx)
+		utils.SetupRemoteMining(ctx)
+		utils.SetupClockChecks(ctx)
+		utils.SetupFilters(ctx)
 		if ctx.GlobalBool(utils.PProfEanbledFlag.Name) {
 			utils.StartPProf(ctx)
 		}
@@ -578,6 +640,7 @@ func startEth(ctx *cli.Context, exp *exp.Expanse) {
 		}
 	}
 	// Start auxiliary services if enabled.
+	api.StrictArgs = ctx.GlobalBool(utils.RPCStrictFlag.Name)
 	if !ctx.GlobalBool(utils.IPCDisabledFlag.Name) {
 		if err := utils.StartIPC(exp, ctx); err != nil {
 			utils.Fatalf("Error string IPC: %v", err)
@@ -588,6 +651,11 @@ func startEth(ctx *cli.Context, exp *exp.Expanse) {
 			utils.Fatalf("Error starting RPC: %v", err)
 		}
 	}
+	if ctx.GlobalBool(utils.WSEnabledFlag.Name) {
+		if err := utils.StartWS(exp, ctx); err != nil {
+			utils.Fatalf("Error starting WS: %v", err)
+		}
+	}
 	if ctx.GlobalBool(utils.MiningEnabledFlag.Name) {
 
 		err := exp.StartMining(
@@ -0,0 +1,112 @@
+// Copyright 2016 The go-expanse Authors
+// This file is part of go-expanse.
+//
+// go-expanse is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-expanse is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-expanse. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/codegangsta/cli"
+	"github.com/expanse-project/go-expanse/cmd/utils"
+	"github.com/expanse-project/go-expanse/p2p/discover"
+)
+
+var nodedbCommand = cli.Command{
+	Name:  "nodedb",
+	Usage: "inspect and maintain the database of known network nodes",
+	Description: `
+
+    gexp nodedb info|prune|clear
+
+The node database remembers the nodes seen on the network, to find peers
+faster after a restart. Nodes not seen for --nodedbmaxage are pruned, as
+are the least recently seen ones beyond --nodedbmaxnodes. These commands
+must not be run while gexp is running on the same data directory.
+`,
+	Subcommands: []cli.Command{
+		{
+			Action: nodedbInfo,
+			Name:   "info",
+			Usage:  "print the number and age of the known nodes",
+		},
+		{
+			Action: nodedbPrune,
+			Name:   "prune",
+			Usage:  "drop the expired and superfluous nodes",
+		},
+		{
+			Action: nodedbClear,
+			Name:   "clear",
+			Usage:  "remove the node database",
+		},
+	},
+}
+
+// nodedbPath returns the location of the node database in the data directory.
+func nodedbPath(ctx *cli.Context) string {
+	return filepath.Join(utils.MustDataDir(ctx), "nodes")
+}
+
+func nodedbInfo(ctx *cli.Context) {
+	stats, err := discover.InspectNodeDB(nodedbPath(ctx))
+	if err != nil {
+		utils.Fatalf("Could not open node database: %v", err)
+	}
+	fmt.Printf("Nodes:   %d\n", stats.Nodes)
+	fmt.Printf("Expired: %d (not seen for %v)\n", stats.Expired, discover.NodeDBExpiration)
+	if discover.NodeDBMaxNodes > 0 && stats.Nodes > discover.NodeDBMaxNodes {
+		fmt.Printf("Excess:  %d (limit %d)\n", stats.Nodes-discover.NodeDBMaxNodes, discover.NodeDBMaxNodes)
+	}
+	if stats.Nodes > 0 {
+		fmt.Printf("Oldest:  %v\n", nodedbSeen(stats.Oldest))
+		fmt.Printf("Newest:  %v\n", nodedbSeen(stats.Newest))
+	}
+}
+
+// nodedbSeen formats the time a node was last seen.
+func nodedbSeen(seen time.Time) string {
+	if seen.Unix() == 0 {
+		return "never"
+	}
+	return fmt.Sprintf("%v (%v ago)", seen.Format(time.RFC3339), time.Since(seen).Round(time.Second))
+}
+
+func nodedbPrune(ctx *cli.Context) {
+	start := time.Now()
+	deleted, err := discover.PruneNodeDB(nodedbPath(ctx))
+	if err != nil {
+		utils.Fatalf("Could not prune node database: %v", err)
+	}
+	fmt.Printf("Pruned %d nodes in %v\n", deleted, time.Since(start))
+}
+
+func nodedbClear(ctx *cli.Context) {
+	confirm, err := utils.PromptConfirm("Remove node database?")
+	if err != nil {
+		utils.Fatalf("%v", err)
+	}
+	if !confirm {
+		fmt.Println("Operation aborted")
+		return
+	}
+	if err := os.RemoveAll(nodedbPath(ctx)); err != nil {
+		utils.Fatalf("Could not remove node database: %v", err)
+	}
+	fmt.Println("Removed node database")
+}
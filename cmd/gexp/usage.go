@@ -69,8 +69,19 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.GenesisFileFlag,
 			utils.IdentityFlag,
 			utils.FastSyncFlag,
+			utils.CheckpointFlag,
 			utils.LightKDFFlag,
 			utils.CacheFlag,
+			utils.ChainCacheFlag,
+			utils.EthashCachesInMemFlag,
+			utils.EthashCachesOnDiskFlag,
+			utils.InternalTxsFlag,
+			utils.MaxFutureDriftFlag,
+			utils.MedianTimeFlag,
+			utils.TxMaxSizeFlag,
+			utils.TxGasFlag,
+			utils.TxGasCapFlag,
+			utils.TxGasPriceFlag,
 			utils.BlockchainVersionFlag,
 		},
 	},
@@ -79,6 +90,9 @@ var AppHelpFlagGroups = []flagGroup{
 		Flags: []cli.Flag{
 			utils.UnlockedAccountFlag,
 			utils.PasswordFileFlag,
+			utils.TxPolicyFlag,
+			utils.AuditLogFlag,
+			utils.AuditKeyFlag,
 		},
 	},
 	{
@@ -88,6 +102,23 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.RPCListenAddrFlag,
 			utils.RPCPortFlag,
 			utils.RpcApiFlag,
+			utils.RPCStrictFlag,
+			utils.RPCReadTimeoutFlag,
+			utils.RPCWriteTimeoutFlag,
+			utils.RPCIdleTimeoutFlag,
+			utils.RPCMaxRequestSizeFlag,
+			utils.FilterMaxLogsFlag,
+			utils.FilterMaxHashesFlag,
+			utils.RPCTLSCertFlag,
+			utils.RPCTLSKeyFlag,
+			utils.WSEnabledFlag,
+			utils.WSListenAddrFlag,
+			utils.WSPortFlag,
+			utils.WSApiFlag,
+			utils.WSAllowedOriginsFlag,
+			utils.WSMaxFrameSizeFlag,
+			utils.WSCompressionFlag,
+			utils.WSMaxPendingFlag,
 			utils.IPCDisabledFlag,
 			utils.IPCApiFlag,
 			utils.IPCPathFlag,
@@ -103,6 +134,11 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.ListenPortFlag,
 			utils.MaxPeersFlag,
 			utils.MaxPendingPeersFlag,
+			utils.HandshakeTimeoutFlag,
+			utils.ProtocolHandshakeTimeoutFlag,
+			utils.PeerReadTimeoutFlag,
+			utils.NodeDBMaxAgeFlag,
+			utils.NodeDBMaxNodesFlag,
 			utils.NATFlag,
 			utils.NoDiscoverFlag,
 			utils.NodeKeyFileFlag,
@@ -119,6 +155,9 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.EtherbaseFlag,
 			utils.GasPriceFlag,
 			utils.ExtraDataFlag,
+			utils.ExtraNonceFlag,
+			utils.MinerPayoutsFlag,
+			utils.CliqueFlag,
 		},
 	},
 	{
@@ -137,6 +176,8 @@ var AppHelpFlagGroups = []flagGroup{
 		Flags: []cli.Flag{
 			utils.VMDebugFlag,
 			utils.VMEnableJitFlag,
+			utils.VMPrecompileAuditFlag,
+			utils.VMGasMetricsFlag,
 			utils.VMForceJitFlag,
 			utils.VMJitCacheFlag,
 		},
@@ -158,6 +199,10 @@ var AppHelpFlagGroups = []flagGroup{
 		Flags: []cli.Flag{
 			utils.WhisperEnabledFlag,
 			utils.NatspecEnabledFlag,
+			utils.NatspecHostsFlag,
+			utils.WebhooksFlag,
+			utils.ChainJournalFlag,
+			utils.ChainJournalDepthFlag,
 		},
 	},
 	{
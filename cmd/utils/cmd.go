@@ -28,11 +28,14 @@ import (
 
 	"github.com/expanse-project/go-expanse/common"
 	"github.com/expanse-project/go-expanse/core"
+	"github.com/expanse-project/go-expanse/core/state"
 	"github.com/expanse-project/go-expanse/core/types"
+	"github.com/expanse-project/go-expanse/ethdb"
 	"github.com/expanse-project/go-expanse/exp"
 	"github.com/expanse-project/go-expanse/logger"
 	"github.com/expanse-project/go-expanse/logger/glog"
 	"github.com/expanse-project/go-expanse/rlp"
+	"github.com/expanse-project/go-expanse/trie"
 	"github.com/peterh/liner"
 )
 
@@ -148,21 +151,20 @@ func FormatTransactionData(data string) []byte {
 	return d
 }
 
-func ImportChain(chain *core.BlockChain, fn string) error {
-	// Watch for Ctrl-C while the import is running.
-	// If a signal is received, the import will stop at the next batch.
+// watchInterrupt watches for Ctrl-C while an import is running. The returned
+// check function reports whether a signal has been received. The release
+// function must be called when the import is done.
+func watchInterrupt() (check func() bool, release func()) {
 	interrupt := make(chan os.Signal, 1)
 	stop := make(chan struct{})
 	signal.Notify(interrupt, os.Interrupt)
-	defer signal.Stop(interrupt)
-	defer close(interrupt)
 	go func() {
 		if _, ok := <-interrupt; ok {
 			glog.Info("caught interrupt during import, will stop at next batch")
 		}
 		close(stop)
 	}()
-	checkInterrupt := func() bool {
+	check = func() bool {
 		select {
 		case <-stop:
 			return true
@@ -170,6 +172,17 @@ func ImportChain(chain *core.BlockChain, fn string) error {
 			return false
 		}
 	}
+	release = func() {
+		signal.Stop(interrupt)
+		close(interrupt)
+	}
+	return check, release
+}
+
+func ImportChain(chain *core.BlockChain, fn string) error {
+	// If a signal is received, the import will stop at the next batch.
+	checkInterrupt, release := watchInterrupt()
+	defer release()
 
 	glog.Infoln("Importing blockchain", fn)
 	fh, err := os.Open(fn)
@@ -189,18 +202,22 @@ func ImportChain(chain *core.BlockChain, fn string) error {
 		}
 		i := 0
 		for ; i < importBatchSize; i++ {
-			var b types.Block
-			if err := stream.Decode(&b); err == io.EOF {
+			raw, err := stream.Raw()
+			if err == io.EOF {
 				break
 			} else if err != nil {
 				return fmt.Errorf("at block %d: %v", n, err)
 			}
+			b, err := decodeBlock(raw)
+			if err != nil {
+				return fmt.Errorf("at block %d: %v", n, err)
+			}
 			// don't import first block
 			if b.NumberU64() == 0 {
 				i--
 				continue
 			}
-			blocks[i] = &b
+			blocks[i] = b
 			n++
 		}
 		if i == 0 {
@@ -223,6 +240,134 @@ func ImportChain(chain *core.BlockChain, fn string) error {
 	return nil
 }
 
+// decodeBlock decodes an exported block of any protocol version: the current
+// encoding of header, transactions and uncles, or the legacy one of eth/61 and
+// earlier, which carries the total difficulty as fourth field.
+func decodeBlock(raw []byte) (*types.Block, error) {
+	content, _, err := rlp.SplitList(raw)
+	if err != nil {
+		return nil, err
+	}
+	fields, err := rlp.CountValues(content)
+	if err != nil {
+		return nil, err
+	}
+	switch fields {
+	case 3:
+		block := new(types.Block)
+		if err := rlp.DecodeBytes(raw, block); err != nil {
+			return nil, err
+		}
+		return block, nil
+	case 4:
+		block := new(types.StorageBlock)
+		if err := rlp.DecodeBytes(raw, block); err != nil {
+			return nil, err
+		}
+		return (*types.Block)(block), nil
+	default:
+		return nil, fmt.Errorf("unknown block encoding with %d fields", fields)
+	}
+}
+
+// ImportChainDatabase imports the canonical chain stored in srcDb, which may
+// belong to another client (e.g. a geth chaindata directory), into chain, which
+// is stored in chainDb. Blocks first through last are imported, stopping early
+// at the end of the source chain. Every block is verified against the local
+// consensus rules. Both the current and the legacy combined block storage
+// formats are read.
+//
+// The source chain is replayed on top of its own genesis block. A local chain
+// with a different genesis is reset to the one of the source, together with
+// its state, as long as it holds no blocks beyond its genesis.
+func ImportChainDatabase(chain *core.BlockChain, chainDb, srcDb ethdb.Database, first, last uint64) error {
+	// If a signal is received, the import will stop at the next batch.
+	checkInterrupt, release := watchInterrupt()
+	defer release()
+
+	genesis := readSourceBlock(srcDb, core.GetCanonicalHash(srcDb, 0))
+	if genesis == nil {
+		return fmt.Errorf("genesis block missing from source database")
+	}
+	if local := chain.Genesis().Hash(); genesis.Hash() != local {
+		if head := chain.CurrentBlock().NumberU64(); head != 0 {
+			return fmt.Errorf("genesis mismatch: source database has %x, local chain of %d blocks has %x", genesis.Hash().Bytes()[:4], head, local[:4])
+		}
+		glog.Infof("Resetting local chain to the source genesis [%x]", genesis.Hash().Bytes()[:4])
+		if err := copyState(chainDb, srcDb, genesis.Root()); err != nil {
+			return fmt.Errorf("genesis state: %v", err)
+		}
+		chain.ResetWithGenesisBlock(genesis)
+	}
+	if first == 0 {
+		first = 1 // the genesis block can't be imported
+	}
+	glog.Infof("Importing blockchain from database, blocks %d-%d", first, last)
+
+	blocks := make(types.Blocks, 0, importBatchSize)
+	for n, batch := first, 0; n <= last; batch++ {
+		if checkInterrupt() {
+			return fmt.Errorf("interrupted")
+		}
+		// Load a batch of canonical blocks.
+		blocks = blocks[:0]
+		for ; n <= last && len(blocks) < importBatchSize; n++ {
+			hash := core.GetCanonicalHash(srcDb, n)
+			if hash == (common.Hash{}) {
+				last = n - 1 // end of the source chain
+				break
+			}
+			block := readSourceBlock(srcDb, hash)
+			if block == nil {
+				return fmt.Errorf("block %d [%x] missing from source database", n, hash[:4])
+			}
+			blocks = append(blocks, block)
+		}
+		if len(blocks) == 0 {
+			break
+		}
+		// Import the batch.
+		if hasAllBlocks(chain, blocks) {
+			glog.Infof("skipping batch %d, all blocks present [%x / %x]",
+				batch, blocks[0].Hash().Bytes()[:4], blocks[len(blocks)-1].Hash().Bytes()[:4])
+			continue
+		}
+		if i, err := chain.InsertChain(blocks); err != nil {
+			return fmt.Errorf("invalid block %d: %v", blocks[i].NumberU64(), err)
+		}
+	}
+	return nil
+}
+
+// readSourceBlock reads a block from a source database in the current or the
+// legacy combined storage format.
+func readSourceBlock(db ethdb.Database, hash common.Hash) *types.Block {
+	if block := core.GetBlock(db, hash); block != nil {
+		return block
+	}
+	return core.GetBlockByHashOld(db, hash)
+}
+
+// copyState copies the state trie with the given root, including contract
+// storage and code, from src into db.
+func copyState(db, src ethdb.Database, root common.Hash) error {
+	sched := state.NewStateSync(root, db)
+	for sched.Pending() > 0 {
+		var results []trie.SyncResult
+		for _, hash := range sched.Missing(importBatchSize) {
+			data, err := src.Get(hash[:])
+			if err != nil {
+				return fmt.Errorf("entry %x missing from source database", hash[:4])
+			}
+			results = append(results, trie.SyncResult{Hash: hash, Data: data})
+		}
+		if _, err := sched.Process(results); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func hasAllBlocks(chain *core.BlockChain, bs []*types.Block) bool {
 	for _, b := range bs {
 		if !chain.HasBlock(b.Hash()) {
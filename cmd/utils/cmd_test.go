@@ -0,0 +1,138 @@
+// Copyright 2015 The go-expanse Authors
+// This file is part of go-expanse.
+//
+// go-expanse is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-expanse is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-expanse. If not, see <http://www.gnu.org/licenses/>.
+
+package utils
+
+import (
+	"io/ioutil"
+	"math"
+	"math/big"
+	"os"
+	"testing"
+
+	"github.com/expanse-project/go-expanse/common"
+	"github.com/expanse-project/go-expanse/core"
+	"github.com/expanse-project/go-expanse/core/types"
+	"github.com/expanse-project/go-expanse/ethdb"
+	"github.com/expanse-project/go-expanse/rlp"
+)
+
+// newSourceDatabase creates a database containing a canonical chain of n
+// blocks on top of the given genesis account, laid out like a client's
+// chaindata directory.
+func newSourceDatabase(t *testing.T, genesisAccount core.GenesisAccount, n int) (ethdb.Database, []*types.Block) {
+	db, _ := ethdb.NewMemDatabase()
+	genesis := core.WriteGenesisBlockForTesting(db, genesisAccount)
+	blocks, _ := core.GenerateChain(genesis, db, n, func(i int, b *core.BlockGen) {
+		b.SetCoinbase(common.Address{1})
+	})
+	for _, block := range blocks {
+		if err := core.WriteBlock(db, block); err != nil {
+			t.Fatalf("failed to write block: %v", err)
+		}
+		if err := core.WriteCanonicalHash(db, block.Hash(), block.NumberU64()); err != nil {
+			t.Fatalf("failed to write canonical hash: %v", err)
+		}
+	}
+	return db, blocks
+}
+
+func newTestChain(t *testing.T, genesisAccount core.GenesisAccount) (*core.BlockChain, ethdb.Database) {
+	db, _ := ethdb.NewMemDatabase()
+	core.WriteGenesisBlockForTesting(db, genesisAccount)
+	chain, err := core.NewBlockChain(db, core.FakePow{})
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	return chain, db
+}
+
+func TestImportChainDatabase(t *testing.T) {
+	account := core.GenesisAccount{Address: common.Address{2}, Balance: big.NewInt(1000000)}
+	srcDb, blocks := newSourceDatabase(t, account, 10)
+
+	// Import a range, then the rest of the chain.
+	chain, chainDb := newTestChain(t, account)
+	if err := ImportChainDatabase(chain, chainDb, srcDb, 0, 4); err != nil {
+		t.Fatalf("range import failed: %v", err)
+	}
+	if head := chain.CurrentBlock().Hash(); head != blocks[3].Hash() {
+		t.Errorf("head mismatch after range import: have %x, want %x", head, blocks[3].Hash())
+	}
+	if err := ImportChainDatabase(chain, chainDb, srcDb, 5, math.MaxUint64); err != nil {
+		t.Fatalf("import failed: %v", err)
+	}
+	if head := chain.CurrentBlock().Hash(); head != blocks[9].Hash() {
+		t.Errorf("head mismatch after import: have %x, want %x", head, blocks[9].Hash())
+	}
+
+	// A chain of another genesis without blocks is reset to the source one.
+	other, otherDb := newTestChain(t, core.GenesisAccount{Address: common.Address{3}, Balance: big.NewInt(1)})
+	if err := ImportChainDatabase(other, otherDb, srcDb, 0, math.MaxUint64); err != nil {
+		t.Fatalf("import into chain of another genesis failed: %v", err)
+	}
+	if head := other.CurrentBlock().Hash(); head != blocks[9].Hash() {
+		t.Errorf("head mismatch after reset: have %x, want %x", head, blocks[9].Hash())
+	}
+	statedb, err := other.State()
+	if err != nil {
+		t.Fatalf("failed to open state: %v", err)
+	}
+	if balance := statedb.GetBalance(account.Address); balance.Cmp(account.Balance) != 0 {
+		t.Errorf("genesis balance mismatch: have %v, want %v", balance, account.Balance)
+	}
+	// Once it holds blocks, a chain of another genesis must not be replaced.
+	srcDb2, _ := newSourceDatabase(t, core.GenesisAccount{Address: common.Address{4}, Balance: big.NewInt(1)}, 2)
+	if err := ImportChainDatabase(other, otherDb, srcDb2, 0, math.MaxUint64); err == nil {
+		t.Error("expected error for genesis mismatch")
+	}
+}
+
+// Tests that exported blocks are imported whichever protocol version encoded
+// them.
+func TestImportChainVersions(t *testing.T) {
+	account := core.GenesisAccount{Address: common.Address{2}, Balance: big.NewInt(1000000)}
+	_, blocks := newSourceDatabase(t, account, 4)
+
+	f, err := ioutil.TempFile("", "import-test")
+	if err != nil {
+		t.Fatalf("failed to create file: %v", err)
+	}
+	defer os.Remove(f.Name())
+
+	// Alternate the current and the legacy encoding carrying the total difficulty
+	for i, block := range blocks {
+		var v interface{} = block
+		if i%2 == 1 {
+			v = []interface{}{block.Header(), block.Transactions(), block.Uncles(), big.NewInt(int64(i))}
+		}
+		if err := rlp.Encode(f, v); err != nil {
+			t.Fatalf("failed to encode block %d: %v", i, err)
+		}
+	}
+	f.Close()
+
+	chain, _ := newTestChain(t, account)
+	if err := ImportChain(chain, f.Name()); err != nil {
+		t.Fatalf("import failed: %v", err)
+	}
+	if head := chain.CurrentBlock().Hash(); head != blocks[3].Hash() {
+		t.Errorf("head mismatch: have %x, want %x", head, blocks[3].Hash())
+	}
+	if _, err := decodeBlock(common.FromHex("0xc3010203")); err == nil {
+		t.Error("expected error for unknown block encoding")
+	}
+}
@@ -0,0 +1,248 @@
+// Copyright 2016 The go-expanse Authors
+// This file is part of the go-expanse library.
+//
+// The go-expanse library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-expanse library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-expanse library. If not, see <http://www.gnu.org/licenses/>.
+
+package utils
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/expanse-project/go-expanse/common"
+	"github.com/expanse-project/go-expanse/core"
+	"github.com/expanse-project/go-expanse/core/types"
+	"github.com/expanse-project/go-expanse/logger/glog"
+)
+
+// exportRecord is a single row of an exported table. Block rows only carry the
+// block, transaction and receipt rows carry the transaction and its receipt
+// too.
+type exportRecord struct {
+	block   *types.Block
+	index   int
+	tx      *types.Transaction
+	receipt *types.Receipt
+}
+
+// exportColumn is a named column of an exported table.
+type exportColumn struct {
+	name  string
+	value func(r *exportRecord) string
+}
+
+// exportTables contains the columns of the tables which can be exported, in
+// their default order.
+var exportTables = map[string][]exportColumn{
+	"blocks": {
+		{"number", func(r *exportRecord) string { return r.block.Number().String() }},
+		{"hash", func(r *exportRecord) string { return r.block.Hash().Hex() }},
+		{"parentHash", func(r *exportRecord) string { return r.block.ParentHash().Hex() }},
+		{"timestamp", func(r *exportRecord) string { return r.block.Time().String() }},
+		{"miner", func(r *exportRecord) string { return r.block.Coinbase().Hex() }},
+		{"difficulty", func(r *exportRecord) string { return r.block.Difficulty().String() }},
+		{"gasLimit", func(r *exportRecord) string { return r.block.GasLimit().String() }},
+		{"gasUsed", func(r *exportRecord) string { return r.block.GasUsed().String() }},
+		{"transactionCount", func(r *exportRecord) string { return strconv.Itoa(len(r.block.Transactions())) }},
+		{"uncleCount", func(r *exportRecord) string { return strconv.Itoa(len(r.block.Uncles())) }},
+		{"size", func(r *exportRecord) string { return strconv.FormatInt(r.block.Size().Int64(), 10) }},
+		{"stateRoot", func(r *exportRecord) string { return r.block.Root().Hex() }},
+		{"extraData", func(r *exportRecord) string { return common.ToHex(r.block.Extra()) }},
+	},
+	"transactions": {
+		{"blockNumber", func(r *exportRecord) string { return r.block.Number().String() }},
+		{"blockHash", func(r *exportRecord) string { return r.block.Hash().Hex() }},
+		{"index", func(r *exportRecord) string { return strconv.Itoa(r.index) }},
+		{"hash", func(r *exportRecord) string { return r.tx.Hash().Hex() }},
+		{"from", func(r *exportRecord) string {
+			from, _ := r.tx.FromFrontier()
+			return from.Hex()
+		}},
+		{"to", func(r *exportRecord) string {
+			if to := r.tx.To(); to != nil {
+				return to.Hex()
+			}
+			return ""
+		}},
+		{"value", func(r *exportRecord) string { return r.tx.Value().String() }},
+		{"gas", func(r *exportRecord) string { return r.tx.Gas().String() }},
+		{"gasPrice", func(r *exportRecord) string { return r.tx.GasPrice().String() }},
+		{"nonce", func(r *exportRecord) string { return strconv.FormatUint(r.tx.Nonce(), 10) }},
+		{"input", func(r *exportRecord) string { return common.ToHex(r.tx.Data()) }},
+	},
+	"receipts": {
+		{"blockNumber", func(r *exportRecord) string { return r.block.Number().String() }},
+		{"blockHash", func(r *exportRecord) string { return r.block.Hash().Hex() }},
+		{"index", func(r *exportRecord) string { return strconv.Itoa(r.index) }},
+		{"transactionHash", func(r *exportRecord) string { return r.tx.Hash().Hex() }},
+		{"contractAddress", func(r *exportRecord) string {
+			if r.tx.To() == nil {
+				return r.receipt.ContractAddress.Hex()
+			}
+			return ""
+		}},
+		{"gasUsed", func(r *exportRecord) string { return r.receipt.GasUsed.String() }},
+		{"cumulativeGasUsed", func(r *exportRecord) string { return r.receipt.CumulativeGasUsed.String() }},
+		{"postState", func(r *exportRecord) string { return common.ToHex(r.receipt.PostState) }},
+		{"logCount", func(r *exportRecord) string { return strconv.Itoa(len(r.receipt.Logs)) }},
+	},
+}
+
+// exportColumns returns the requested columns of table, or all of them if no
+// names are given.
+func exportColumns(table string, names []string) ([]exportColumn, error) {
+	all, ok := exportTables[table]
+	if !ok {
+		return nil, fmt.Errorf("unknown table %q", table)
+	}
+	if len(names) == 0 {
+		return all, nil
+	}
+	columns := make([]exportColumn, 0, len(names))
+	for _, name := range names {
+		found := false
+		for _, column := range all {
+			if column.name == name {
+				columns = append(columns, column)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown column %q of table %s", name, table)
+		}
+	}
+	return columns, nil
+}
+
+// exportWriter writes the rows of an exported table in a flat format.
+type exportWriter interface {
+	Write(row []string) error
+	Flush() error
+}
+
+// csvExportWriter writes a header line with the column names followed by one
+// comma separated line per row.
+type csvExportWriter struct {
+	w *csv.Writer
+}
+
+func (w *csvExportWriter) Write(row []string) error { return w.w.Write(row) }
+
+func (w *csvExportWriter) Flush() error {
+	w.w.Flush()
+	return w.w.Error()
+}
+
+// jsonExportWriter writes a JSON object per row, each on its own line.
+type jsonExportWriter struct {
+	enc   *json.Encoder
+	names []string
+}
+
+func (w *jsonExportWriter) Write(row []string) error {
+	obj := make(map[string]string, len(row))
+	for i, value := range row {
+		obj[w.names[i]] = value
+	}
+	return w.enc.Encode(obj)
+}
+
+func (w *jsonExportWriter) Flush() error { return nil }
+
+// ExportChainTable exports a table of the canonical chain from block first to
+// last (inclusive) into the file fn, overwriting it. Supported tables are
+// blocks, transactions and receipts, supported formats are csv and json (one
+// JSON object per line). If no columns are given, all of them are exported.
+func ExportChainTable(blockchain *core.BlockChain, fn, table, format string, columns []string, first, last uint64) error {
+	glog.Infof("Exporting %s table to %s", table, fn)
+	fh, err := os.OpenFile(fn, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.ModePerm)
+	if err != nil {
+		return err
+	}
+	defer fh.Close()
+	if err := exportTable(fh, blockchain, table, format, columns, first, last); err != nil {
+		return err
+	}
+	glog.Infof("Exported %s table to %s", table, fn)
+	return nil
+}
+
+func exportTable(w io.Writer, blockchain *core.BlockChain, table, format string, names []string, first, last uint64) error {
+	if first > last {
+		return fmt.Errorf("export failed: first (%d) is greater than last (%d)", first, last)
+	}
+	columns, err := exportColumns(table, names)
+	if err != nil {
+		return err
+	}
+	header := make([]string, len(columns))
+	for i, column := range columns {
+		header[i] = column.name
+	}
+	var out exportWriter
+	switch format {
+	case "csv":
+		out = &csvExportWriter{csv.NewWriter(w)}
+		if err := out.Write(header); err != nil {
+			return err
+		}
+	case "json":
+		out = &jsonExportWriter{json.NewEncoder(w), header}
+	default:
+		return fmt.Errorf("unknown export format %q", format)
+	}
+
+	row := make([]string, len(columns))
+	write := func(r *exportRecord) error {
+		for i, column := range columns {
+			row[i] = column.value(r)
+		}
+		return out.Write(row)
+	}
+	for nr := first; nr <= last; nr++ {
+		block := blockchain.GetBlockByNumber(nr)
+		if block == nil {
+			return fmt.Errorf("export failed on #%d: not found", nr)
+		}
+		switch table {
+		case "blocks":
+			if err := write(&exportRecord{block: block}); err != nil {
+				return err
+			}
+		case "transactions", "receipts":
+			var receipts types.Receipts
+			if table == "receipts" {
+				receipts = blockchain.GetBlockReceipts(block.Hash())
+				if len(receipts) != len(block.Transactions()) {
+					return fmt.Errorf("export failed on #%d: receipts not found", nr)
+				}
+			}
+			for i, tx := range block.Transactions() {
+				r := &exportRecord{block: block, index: i, tx: tx}
+				if receipts != nil {
+					r.receipt = receipts[i]
+				}
+				if err := write(r); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return out.Flush()
+}
@@ -0,0 +1,117 @@
+// Copyright 2016 The go-expanse Authors
+// This file is part of the go-expanse library.
+//
+// The go-expanse library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-expanse library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-expanse library. If not, see <http://www.gnu.org/licenses/>.
+
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/expanse-project/go-expanse/common"
+	"github.com/expanse-project/go-expanse/core"
+	"github.com/expanse-project/go-expanse/core/types"
+	"github.com/expanse-project/go-expanse/crypto"
+	"github.com/expanse-project/go-expanse/ethdb"
+	"github.com/expanse-project/go-expanse/params"
+)
+
+func TestExportTable(t *testing.T) {
+	var (
+		key, _ = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		addr   = crypto.PubkeyToAddress(key.PublicKey)
+		db, _  = ethdb.NewMemDatabase()
+	)
+	genesis := core.WriteGenesisBlockForTesting(db, core.GenesisAccount{Address: addr, Balance: big.NewInt(1000000000)})
+	blocks, _ := core.GenerateChain(genesis, db, 2, func(i int, gen *core.BlockGen) {
+		if i == 1 {
+			tx, err := types.NewTransaction(gen.TxNonce(addr), common.Address{0x01}, big.NewInt(1000), params.TxGas, nil, nil).SignECDSA(key)
+			if err != nil {
+				t.Fatalf("failed to create tx: %v", err)
+			}
+			gen.AddTx(tx)
+		}
+	})
+	chain, err := core.NewBlockChain(db, core.FakePow{})
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	tx := blocks[1].Transactions()[0]
+
+	// Export selected block columns as CSV.
+	var buf bytes.Buffer
+	if err := exportTable(&buf, chain, "blocks", "csv", []string{"number", "hash", "transactionCount"}, 0, 2); err != nil {
+		t.Fatalf("block export failed: %v", err)
+	}
+	want := "number,hash,transactionCount\n" +
+		"0," + genesis.Hash().Hex() + ",0\n" +
+		"1," + blocks[0].Hash().Hex() + ",0\n" +
+		"2," + blocks[1].Hash().Hex() + ",1\n"
+	if buf.String() != want {
+		t.Errorf("block export mismatch:\nhave:\n%s\nwant:\n%s", buf.String(), want)
+	}
+
+	// Export all transaction columns as JSON.
+	buf.Reset()
+	if err := exportTable(&buf, chain, "transactions", "json", nil, 1, 2); err != nil {
+		t.Fatalf("transaction export failed: %v", err)
+	}
+	var row map[string]string
+	if err := json.Unmarshal(buf.Bytes(), &row); err != nil {
+		t.Fatalf("failed to decode transaction row %q: %v", buf.String(), err)
+	}
+	if len(row) != len(exportTables["transactions"]) {
+		t.Errorf("transaction column count mismatch: have %d, want %d", len(row), len(exportTables["transactions"]))
+	}
+	if row["hash"] != tx.Hash().Hex() || row["from"] != addr.Hex() || row["value"] != "1000" || row["blockNumber"] != "2" {
+		t.Errorf("transaction row mismatch: %v", row)
+	}
+
+	// Export receipts as CSV.
+	buf.Reset()
+	if err := exportTable(&buf, chain, "receipts", "csv", []string{"transactionHash", "gasUsed", "contractAddress"}, 2, 2); err != nil {
+		t.Fatalf("receipt export failed: %v", err)
+	}
+	want = "transactionHash,gasUsed,contractAddress\n" + tx.Hash().Hex() + "," + params.TxGas.String() + ",\n"
+	if buf.String() != want {
+		t.Errorf("receipt export mismatch:\nhave:\n%s\nwant:\n%s", buf.String(), want)
+	}
+
+	// Invalid parameters must be rejected.
+	tests := []struct {
+		table, format string
+		columns       []string
+		err           string
+	}{
+		{"accounts", "csv", nil, "unknown table"},
+		{"blocks", "csv", []string{"nonsense"}, "unknown column"},
+		{"blocks", "xml", nil, "unknown export format"},
+	}
+	for _, tt := range tests {
+		err := exportTable(new(bytes.Buffer), chain, tt.table, tt.format, tt.columns, 0, 2)
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%s/%s/%v: error mismatch: have %v, want %q", tt.table, tt.format, tt.columns, err, tt.err)
+		}
+	}
+	if err := exportTable(new(bytes.Buffer), chain, "blocks", "csv", nil, 0, 3); err == nil {
+		t.Error("expected error exporting missing block")
+	}
+}
@@ -17,8 +17,10 @@
 package utils
 
 import (
+	"bytes"
 	"crypto/ecdsa"
 	"fmt"
+	"io/ioutil"
 	"log"
 	"math"
 	"math/big"
@@ -28,22 +30,29 @@ import (
 	"path/filepath"
 	"runtime"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/codegangsta/cli"
-	"github.com/expanse-project/ethash"
 	"github.com/expanse-project/go-expanse/accounts"
+	"github.com/expanse-project/go-expanse/clique"
 	"github.com/expanse-project/go-expanse/common"
 	"github.com/expanse-project/go-expanse/core"
 	"github.com/expanse-project/go-expanse/core/vm"
 	"github.com/expanse-project/go-expanse/crypto"
 	"github.com/expanse-project/go-expanse/exp"
+	"github.com/expanse-project/go-expanse/exp/downloader"
+	"github.com/expanse-project/go-expanse/exp/filters"
+	"github.com/expanse-project/go-expanse/exp/notify"
 	"github.com/expanse-project/go-expanse/ethdb"
-	"github.com/expanse-project/go-expanse/event"
 	"github.com/expanse-project/go-expanse/logger"
 	"github.com/expanse-project/go-expanse/logger/glog"
 	"github.com/expanse-project/go-expanse/metrics"
+	"github.com/expanse-project/go-expanse/miner"
+	"github.com/expanse-project/go-expanse/p2p/discover"
 	"github.com/expanse-project/go-expanse/p2p/nat"
 	"github.com/expanse-project/go-expanse/params"
+	"github.com/expanse-project/go-expanse/pow/ethash"
 	"github.com/expanse-project/go-expanse/rpc/api"
 	"github.com/expanse-project/go-expanse/rpc/codec"
 	"github.com/expanse-project/go-expanse/rpc/comms"
@@ -106,7 +115,7 @@ var (
 	}
 	NetworkIdFlag = cli.IntFlag{
 		Name:  "networkid",
-		Usage: "Network identifier (integer, 0=Olympic, 1=Frontier, 2=Morden)",
+		Usage: "Network identifier (integer, 0=Olympic, 1=Frontier, 2=Morden), unless configured in the genesis",
 		Value: exp.NetworkId,
 	}
 	OlympicFlag = cli.BoolFlag{
@@ -133,6 +142,25 @@ var (
 		Name:  "natspec",
 		Usage: "Enable NatSpec confirmation notice",
 	}
+	NatspecHostsFlag = cli.StringFlag{
+		Name:  "natspec.hosts",
+		Usage: "Comma separated list of hosts NatSpec contract info may be fetched from over http(s)",
+		Value: "",
+	}
+	WebhooksFlag = cli.StringFlag{
+		Name:  "webhooks",
+		Usage: "Space separated list of webhooks notified about watched addresses (url=address,address...)",
+		Value: "",
+	}
+	ChainJournalFlag = cli.StringFlag{
+		Name:  "chainjournal",
+		Usage: "File or named pipe to which new heads, reorgs and finalized blocks are written as JSON lines",
+	}
+	ChainJournalDepthFlag = cli.IntFlag{
+		Name:  "chainjournaldepth",
+		Usage: "Number of confirmations after which the chain journal marks blocks final (0 = no markers)",
+		Value: 12,
+	}
 	DocRootFlag = DirectoryFlag{
 		Name:  "docroot",
 		Usage: "Document Root for HTTPClient file scheme",
@@ -143,6 +171,53 @@ var (
 		Usage: "Megabytes of memory allocated to internal caching (min 16MB / database forced)",
 		Value: 0,
 	}
+	ChainCacheFlag = cli.IntFlag{
+		Name:  "chaincache",
+		Usage: "Number of recently accessed blocks, bodies and receipts cached in memory",
+		Value: 256,
+	}
+	EthashCachesInMemFlag = cli.IntFlag{
+		Name:  "ethashcachesinmem",
+		Usage: "Number of ethash verification caches kept in memory (the next epoch's is generated in the background)",
+		Value: 2,
+	}
+	EthashCachesOnDiskFlag = cli.IntFlag{
+		Name:  "ethashcachesondisk",
+		Usage: "Number of ethash verification caches kept in the data directory (0 = disabled)",
+		Value: 3,
+	}
+	InternalTxsFlag = cli.BoolFlag{
+		Name:  "internaltxs",
+		Usage: "Record value transfers of internal calls in processed blocks (eth_getInternalTransactions)",
+	}
+	MaxFutureDriftFlag = cli.IntFlag{
+		Name:  "maxfuturedrift",
+		Usage: "Seconds a block's timestamp may be ahead of the local clock before it is rejected",
+		Value: int(core.MaxFutureDrift),
+	}
+	MedianTimeFlag = cli.IntFlag{
+		Name:  "mediantime",
+		Usage: "Reject blocks if the local clock is behind the median time of this many recent blocks (0 = off)",
+		Value: 0,
+	}
+	TxMaxSizeFlag = cli.IntFlag{
+		Name:  "txmaxsize",
+		Usage: "Maximum size in bytes of transactions accepted into the pool (0 = no limit)",
+		Value: core.DefaultTxMaxSize,
+	}
+	TxGasFlag = cli.StringFlag{
+		Name:  "txgas",
+		Usage: "Gas of sent transactions that don't specify any",
+		Value: exp.DefaultTxGas.String(),
+	}
+	TxGasCapFlag = cli.StringFlag{
+		Name:  "txgascap",
+		Usage: "Maximum gas given to sent transactions that don't specify any (block gas limit if unset)",
+	}
+	TxGasPriceFlag = cli.StringFlag{
+		Name:  "txgasprice",
+		Usage: "Gas price of sent transactions that don't specify any (suggested by the gas price oracle if unset)",
+	}
 	BlockchainVersionFlag = cli.IntFlag{
 		Name:  "blockchainversion",
 		Usage: "Blockchain version (integer)",
@@ -152,6 +227,10 @@ var (
 		Name:  "fast",
 		Usage: "Enable fast syncing through state downloads",
 	}
+	CheckpointFlag = cli.StringFlag{
+		Name:  "checkpoint",
+		Usage: "Trusted block (number:hash) the synced chain must contain, also used as the fast sync pivot",
+	}
 	LightKDFFlag = cli.BoolFlag{
 		Name:  "lightkdf",
 		Usage: "Reduce key-derivation RAM & CPU usage at some expense of KDF strength",
@@ -189,6 +268,19 @@ var (
 		Name:  "extradata",
 		Usage: "Block extra data set by the miner (default = client version)",
 	}
+	ExtraNonceFlag = cli.IntFlag{
+		Name:  "extranonce",
+		Usage: "Leading nonce bytes leased to remote workers asking for work with an id (0 = off, max 4)",
+		Value: 0,
+	}
+	MinerPayoutsFlag = cli.StringFlag{
+		Name:  "minerpayouts",
+		Usage: "Comma separated shares of confirmed mined block rewards sent on from the unlocked etherbase (address=percent,...)",
+	}
+	CliqueFlag = cli.BoolFlag{
+		Name:  "clique",
+		Usage: "Seal blocks by proof-of-authority of the signers listed in the genesis extra data instead of mining (period and epoch set by the genesis config)",
+	}
 	// Account settings
 	UnlockedAccountFlag = cli.StringFlag{
 		Name:  "unlock",
@@ -200,6 +292,18 @@ var (
 		Usage: "Password file to use with options/subcommands needing a pass phrase",
 		Value: "",
 	}
+	TxPolicyFlag = cli.StringFlag{
+		Name:  "txpolicy",
+		Usage: "JSON file with the rules deciding which RPC/IPC transactions are signed (default = ask the frontend)",
+	}
+	AuditLogFlag = cli.StringFlag{
+		Name:  "auditlog",
+		Usage: "File to which every signing operation is appended",
+	}
+	AuditKeyFlag = cli.StringFlag{
+		Name:  "auditkey",
+		Usage: "File containing the key chaining the audit log entries with HMACs",
+	}
 
 	// vm flags
 	VMDebugFlag = cli.BoolFlag{
@@ -219,6 +323,14 @@ var (
 		Name:  "jitvm",
 		Usage: "Enable the JIT VM",
 	}
+	VMPrecompileAuditFlag = cli.BoolFlag{
+		Name:  "vmaudit",
+		Usage: "Record precompiled contract and SHA3 invocation counts and gas usage into metrics (requires --metrics)",
+	}
+	VMGasMetricsFlag = cli.BoolFlag{
+		Name:  "vmgasmetrics",
+		Usage: "Record refunds, SSTORE set/clear counts and per-opcode gas of processed blocks into metrics (requires --metrics)",
+	}
 
 	// logging and debug settings
 	VerbosityFlag = cli.IntFlag{
@@ -275,11 +387,93 @@ var (
 		Usage: "Domains from which to accept cross origin requests (browser enforced)",
 		Value: "",
 	}
+	RPCStrictFlag = cli.BoolFlag{
+		Name:  "rpcstrict",
+		Usage: "Reject malformed hashes and out of range indices instead of returning null",
+	}
+	RPCReadTimeoutFlag = cli.DurationFlag{
+		Name:  "rpcreadtimeout",
+		Usage: "HTTP-RPC server timeout for reading a request",
+		Value: 15 * time.Second,
+	}
+	RPCWriteTimeoutFlag = cli.DurationFlag{
+		Name:  "rpcwritetimeout",
+		Usage: "HTTP-RPC server timeout for processing and answering a request",
+		Value: 15 * time.Second,
+	}
+	RPCIdleTimeoutFlag = cli.DurationFlag{
+		Name:  "rpcidletimeout",
+		Usage: "HTTP-RPC server timeout for idle keep-alive connections",
+		Value: 10 * time.Second,
+	}
+	RPCMaxRequestSizeFlag = cli.IntFlag{
+		Name:  "rpcmaxrequestsize",
+		Usage: "Maximum size in bytes of HTTP-RPC request bodies",
+		Value: 1024 * 1024,
+	}
+	FilterMaxLogsFlag = cli.IntFlag{
+		Name:  "filtermaxlogs",
+		Usage: "Maximum number of logs a filter retains between polls (0 = no limit)",
+		Value: filters.MaxFilterLogs,
+	}
+	FilterMaxHashesFlag = cli.IntFlag{
+		Name:  "filtermaxhashes",
+		Usage: "Maximum number of block or transaction hashes a filter retains between polls (0 = no limit)",
+		Value: filters.MaxFilterHashes,
+	}
+	RPCTLSCertFlag = cli.StringFlag{
+		Name:  "rpctlscert",
+		Usage: "Serve HTTP-RPC over TLS with this certificate, accepting only the client certificates in <datadir>/rpcclients",
+		Value: "",
+	}
+	RPCTLSKeyFlag = cli.StringFlag{
+		Name:  "rpctlskey",
+		Usage: "Private key of the HTTP-RPC TLS certificate",
+		Value: "",
+	}
 	RpcApiFlag = cli.StringFlag{
 		Name:  "rpcapi",
 		Usage: "API's offered over the HTTP-RPC interface",
 		Value: comms.DefaultHttpRpcApis,
 	}
+	WSEnabledFlag = cli.BoolFlag{
+		Name:  "ws",
+		Usage: "Enable the WS-RPC server",
+	}
+	WSListenAddrFlag = cli.StringFlag{
+		Name:  "wsaddr",
+		Usage: "WS-RPC server listening interface",
+		Value: "127.0.0.1",
+	}
+	WSPortFlag = cli.IntFlag{
+		Name:  "wsport",
+		Usage: "WS-RPC server listening port",
+		Value: 9657,
+	}
+	WSApiFlag = cli.StringFlag{
+		Name:  "wsapi",
+		Usage: "API's offered over the WS-RPC interface",
+		Value: comms.DefaultWsApis,
+	}
+	WSAllowedOriginsFlag = cli.StringFlag{
+		Name:  "wsorigins",
+		Usage: "Comma or space separated browser origins from which to accept WebSocket connections (\"*\" for any)",
+		Value: "",
+	}
+	WSMaxFrameSizeFlag = cli.IntFlag{
+		Name:  "wsframesize",
+		Usage: "Maximum payload size in bytes of received WebSocket frames",
+		Value: 1024 * 1024,
+	}
+	WSCompressionFlag = cli.BoolFlag{
+		Name:  "wscompression",
+		Usage: "Offer permessage-deflate compression to WebSocket clients",
+	}
+	WSMaxPendingFlag = cli.IntFlag{
+		Name:  "wsmaxpending",
+		Usage: "Maximum number of WS-RPC requests queued or executing (defaults used if set to 0)",
+		Value: 0,
+	}
 	IPCDisabledFlag = cli.BoolFlag{
 		Name:  "ipcdisable",
 		Usage: "Disable the IPC-RPC server",
@@ -309,6 +503,31 @@ var (
 		Usage: "Maximum number of pending connection attempts (defaults used if set to 0)",
 		Value: 0,
 	}
+	HandshakeTimeoutFlag = cli.DurationFlag{
+		Name:  "handshaketimeout",
+		Usage: "Maximum time allowed for the encryption handshake with a peer",
+		Value: 5 * time.Second,
+	}
+	ProtocolHandshakeTimeoutFlag = cli.DurationFlag{
+		Name:  "protohandshaketimeout",
+		Usage: "Maximum time allowed for the protocol handshake with a peer",
+		Value: 5 * time.Second,
+	}
+	PeerReadTimeoutFlag = cli.DurationFlag{
+		Name:  "peerreadtimeout",
+		Usage: "Maximum time allowed for reading a message from a peer, i.e. for a peer connection to be idle",
+		Value: 30 * time.Second,
+	}
+	NodeDBMaxAgeFlag = cli.DurationFlag{
+		Name:  "nodedbmaxage",
+		Usage: "Time after which unseen nodes are dropped from the node database",
+		Value: 24 * time.Hour,
+	}
+	NodeDBMaxNodesFlag = cli.IntFlag{
+		Name:  "nodedbmaxnodes",
+		Usage: "Maximum number of nodes kept in the node database (0 = no limit)",
+		Value: 20000,
+	}
 	ListenPortFlag = cli.IntFlag{
 		Name:  "port",
 		Usage: "Network listening port",
@@ -413,6 +632,78 @@ func MakeNodeKey(ctx *cli.Context) (key *ecdsa.PrivateKey) {
 	return key
 }
 
+// MakeNatspecHosts parses the NatSpec host whitelist from the command line.
+func MakeNatspecHosts(ctx *cli.Context) []string {
+	var hosts []string
+	for _, host := range strings.Split(ctx.GlobalString(NatspecHostsFlag.Name), ",") {
+		if host = strings.TrimSpace(host); host != "" {
+			hosts = append(hosts, host)
+		}
+	}
+	return hosts
+}
+
+// MakeWebhooks parses the webhook registrations from the command line.
+func MakeWebhooks(ctx *cli.Context) []notify.Hook {
+	var hooks []notify.Hook
+	for _, entry := range strings.Fields(ctx.GlobalString(WebhooksFlag.Name)) {
+		idx := strings.LastIndex(entry, "=")
+		if idx < 0 {
+			Fatalf("Invalid webhook %q, expected url=address,address...", entry)
+		}
+		hook := notify.Hook{URL: entry[:idx]}
+		for _, addr := range strings.Split(entry[idx+1:], ",") {
+			if len(common.FromHex(addr)) != len(common.Address{}) {
+				Fatalf("Invalid webhook address %q for %s", addr, hook.URL)
+			}
+			hook.Addresses = append(hook.Addresses, common.HexToAddress(addr))
+		}
+		hooks = append(hooks, hook)
+	}
+	return hooks
+}
+
+// MakeMinerPayouts parses the payout shares of mined block rewards from the
+// command line.
+func MakeMinerPayouts(ctx *cli.Context) []miner.Payout {
+	var payouts []miner.Payout
+	for _, entry := range strings.Split(ctx.GlobalString(MinerPayoutsFlag.Name), ",") {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+		parts := strings.Split(entry, "=")
+		if len(parts) != 2 || len(common.FromHex(parts[0])) != len(common.Address{}) {
+			Fatalf("Invalid miner payout %q, expected address=percent", entry)
+		}
+		percent, err := strconv.ParseUint(strings.TrimSuffix(parts[1], "%"), 10, 64)
+		if err != nil {
+			Fatalf("Invalid miner payout share %q: %v", parts[1], err)
+		}
+		payouts = append(payouts, miner.Payout{Address: common.HexToAddress(parts[0]), Percent: percent})
+	}
+	return payouts
+}
+
+// MakeCheckpoint parses the trusted sync checkpoint from the command line.
+func MakeCheckpoint(ctx *cli.Context) *downloader.Checkpoint {
+	spec := ctx.GlobalString(CheckpointFlag.Name)
+	if spec == "" {
+		return nil
+	}
+	parts := strings.Split(spec, ":")
+	if len(parts) != 2 {
+		Fatalf("Invalid checkpoint %q, expected number:hash", spec)
+	}
+	number, err := strconv.ParseUint(parts[0], 10, 64)
+	if err != nil || number == 0 {
+		Fatalf("Invalid checkpoint block number %q", parts[0])
+	}
+	if len(common.FromHex(parts[1])) != len(common.Hash{}) {
+		Fatalf("Invalid checkpoint block hash %q", parts[1])
+	}
+	return &downloader.Checkpoint{Number: number, Hash: common.HexToHash(parts[1])}
+}
+
 // MakeEthConfig creates expanse options from set command line flags.
 func MakeEthConfig(clientID, version string, ctx *cli.Context) *exp.Config {
 	customName := ctx.GlobalString(IdentityFlag.Name)
@@ -431,6 +722,7 @@ func MakeEthConfig(clientID, version string, ctx *cli.Context) *exp.Config {
 		DataDir:                 MustDataDir(ctx),
 		GenesisFile:             ctx.GlobalString(GenesisFileFlag.Name),
 		FastSync:                ctx.GlobalBool(FastSyncFlag.Name),
+		Checkpoint:              MakeCheckpoint(ctx),
 		BlockChainVersion:       ctx.GlobalInt(BlockchainVersionFlag.Name),
 		DatabaseCache:           ctx.GlobalInt(CacheFlag.Name),
 		SkipBcVersionCheck:      false,
@@ -439,21 +731,32 @@ func MakeEthConfig(clientID, version string, ctx *cli.Context) *exp.Config {
 		Verbosity:               ctx.GlobalInt(VerbosityFlag.Name),
 		Etherbase:               common.HexToAddress(etherbase),
 		MinerThreads:            ctx.GlobalInt(MinerThreadsFlag.Name),
+		MinerPayouts:            MakeMinerPayouts(ctx),
+		Clique:                  ctx.GlobalBool(CliqueFlag.Name),
 		AccountManager:          am,
 		VmDebug:                 ctx.GlobalBool(VMDebugFlag.Name),
 		MaxPeers:                ctx.GlobalInt(MaxPeersFlag.Name),
 		MaxPendingPeers:         ctx.GlobalInt(MaxPendingPeersFlag.Name),
+		HandshakeTimeout:        ctx.GlobalDuration(HandshakeTimeoutFlag.Name),
+		ProtoHandshakeTimeout:   ctx.GlobalDuration(ProtocolHandshakeTimeoutFlag.Name),
+		PeerReadTimeout:         ctx.GlobalDuration(PeerReadTimeoutFlag.Name),
 		Port:                    ctx.GlobalString(ListenPortFlag.Name),
 		Olympic:                 ctx.GlobalBool(OlympicFlag.Name),
 		NAT:                     MakeNAT(ctx),
 		NatSpec:                 ctx.GlobalBool(NatspecEnabledFlag.Name),
 		DocRoot:                 ctx.GlobalString(DocRootFlag.Name),
+		DocHosts:                MakeNatspecHosts(ctx),
+		Webhooks:                MakeWebhooks(ctx),
+		JournalPath:             ctx.GlobalString(ChainJournalFlag.Name),
+		JournalDepth:            uint64(ctx.GlobalInt(ChainJournalDepthFlag.Name)),
 		Discovery:               !ctx.GlobalBool(NoDiscoverFlag.Name),
 		NodeKey:                 MakeNodeKey(ctx),
 		Shh:                     ctx.GlobalBool(WhisperEnabledFlag.Name),
 		Dial:                    true,
 		BootNodes:               ctx.GlobalString(BootnodesFlag.Name),
 		GasPrice:                common.String2Big(ctx.GlobalString(GasPriceFlag.Name)),
+		TxMaxSize:               uint64(ctx.GlobalInt(TxMaxSizeFlag.Name)),
+		TxGas:                   common.String2Big(ctx.GlobalString(TxGasFlag.Name)),
 		GpoMinGasPrice:          common.String2Big(ctx.GlobalString(GpoMinGasPriceFlag.Name)),
 		GpoMaxGasPrice:          common.String2Big(ctx.GlobalString(GpoMaxGasPriceFlag.Name)),
 		GpoFullBlockRatio:       ctx.GlobalInt(GpoFullBlockRatioFlag.Name),
@@ -464,6 +767,13 @@ func MakeEthConfig(clientID, version string, ctx *cli.Context) *exp.Config {
 		AutoDAG:                 ctx.GlobalBool(AutoDAGFlag.Name) || ctx.GlobalBool(MiningEnabledFlag.Name),
 	}
 
+	if ctx.GlobalIsSet(TxGasCapFlag.Name) {
+		cfg.TxGasCap = common.String2Big(ctx.GlobalString(TxGasCapFlag.Name))
+	}
+	if ctx.GlobalIsSet(TxGasPriceFlag.Name) {
+		cfg.TxGasPrice = common.String2Big(ctx.GlobalString(TxGasPriceFlag.Name))
+	}
+
 	if ctx.GlobalBool(DevModeFlag.Name) && ctx.GlobalBool(TestNetFlag.Name) {
 		glog.Fatalf("%s and %s are mutually exclusive\n", DevModeFlag.Name, TestNetFlag.Name)
 	}
@@ -507,6 +817,16 @@ func MakeEthConfig(clientID, version string, ctx *cli.Context) *exp.Config {
 	return cfg
 }
 
+// MakeTxPolicy loads the transaction signing policy configured on the command
+// line, returning nil if none is set.
+func MakeTxPolicy(ctx *cli.Context) (xeth.Policy, error) {
+	path := ctx.GlobalString(TxPolicyFlag.Name)
+	if path == "" {
+		return nil, nil
+	}
+	return xeth.LoadPolicy(path)
+}
+
 // SetupLogger configures glog from the logging-related command line flags.
 func SetupLogger(ctx *cli.Context) {
 	glog.SetV(ctx.GlobalInt(VerbosityFlag.Name))
@@ -533,9 +853,71 @@ func SetupNetwork(ctx *cli.Context) {
 func SetupVM(ctx *cli.Context) {
 	vm.EnableJit = ctx.GlobalBool(VMEnableJitFlag.Name)
 	vm.ForceJit = ctx.GlobalBool(VMForceJitFlag.Name)
+	vm.PrecompileAudit = ctx.GlobalBool(VMPrecompileAuditFlag.Name)
+	vm.GasMetrics = ctx.GlobalBool(VMGasMetricsFlag.Name)
 	vm.SetJITCacheSize(ctx.GlobalInt(VMJitCacheFlag.Name))
 }
 
+// SetupChainCache configures the in-memory caches of the block chain.
+func SetupChainCache(ctx *cli.Context) {
+	core.SetCacheLimits(ctx.GlobalInt(ChainCacheFlag.Name))
+}
+
+// SetupEthash configures how many ethash verification caches are kept in memory
+// and on disk.
+func SetupEthash(ctx *cli.Context) {
+	inMem := ctx.GlobalInt(EthashCachesInMemFlag.Name)
+	if inMem < 1 {
+		Fatalf("Invalid number of in-memory ethash caches %d, expected at least 1", inMem)
+	}
+	ethash.CachesInMem = inMem
+	ethash.CachesOnDisk = ctx.GlobalInt(EthashCachesOnDiskFlag.Name)
+	if ethash.CachesOnDisk > 0 {
+		ethash.CacheDir = filepath.Join(MustDataDir(ctx), "ethash")
+	}
+}
+
+// SetupNodeDB configures the pruning of the database of known network nodes.
+func SetupNodeDB(ctx *cli.Context) {
+	if maxAge := ctx.GlobalDuration(NodeDBMaxAgeFlag.Name); maxAge > 0 {
+		discover.NodeDBExpiration = maxAge
+	}
+	maxNodes := ctx.GlobalInt(NodeDBMaxNodesFlag.Name)
+	if maxNodes < 0 {
+		Fatalf("Invalid node database size limit %d", maxNodes)
+	}
+	discover.NodeDBMaxNodes = maxNodes
+}
+
+// SetupInternalTxs enables recording the internal transactions of processed
+// blocks.
+func SetupInternalTxs(ctx *cli.Context) {
+	core.RecordInternalTxs = ctx.GlobalBool(InternalTxsFlag.Name)
+}
+
+// SetupRemoteMining configures the extraNonce leases of remote workers.
+func SetupRemoteMining(ctx *cli.Context) {
+	size := ctx.GlobalInt(ExtraNonceFlag.Name)
+	if size < 0 || size > miner.MaxExtraNonceSize {
+		Fatalf("Invalid extraNonce size %d, expected 0-%d bytes", size, miner.MaxExtraNonceSize)
+	}
+	miner.ExtraNonceSize = size
+}
+
+// SetupClockChecks configures the timestamp drift checks of block validation.
+func SetupClockChecks(ctx *cli.Context) {
+	if drift := ctx.GlobalInt(MaxFutureDriftFlag.Name); drift >= 0 {
+		core.MaxFutureDrift = uint64(drift)
+	}
+	core.MedianTimeBlocks = ctx.GlobalInt(MedianTimeFlag.Name)
+}
+
+// SetupFilters configures the retention limits of installed filters.
+func SetupFilters(ctx *cli.Context) {
+	filters.MaxFilterLogs = ctx.GlobalInt(FilterMaxLogsFlag.Name)
+	filters.MaxFilterHashes = ctx.GlobalInt(FilterMaxHashesFlag.Name)
+}
+
 
 // MakeChain creates a chain manager from set command line flags.
 func MakeChain(ctx *cli.Context) (chain *core.BlockChain, chainDb ethdb.Database) {
@@ -546,6 +928,9 @@ func MakeChain(ctx *cli.Context) (chain *core.BlockChain, chainDb ethdb.Database
 	if chainDb, err = ethdb.NewLDBDatabase(filepath.Join(datadir, "chaindata"), cache); err != nil {
 		Fatalf("Could not open database: %v", err)
 	}
+	if err := exp.MigrateChainDatabase(chainDb); err != nil {
+		Fatalf("Could not migrate database: %v", err)
+	}
 	if ctx.GlobalBool(OlympicFlag.Name) {
 		_, err := core.WriteTestNetGenesisBlock(chainDb, 42)
 		if err != nil {
@@ -553,10 +938,13 @@ func MakeChain(ctx *cli.Context) (chain *core.BlockChain, chainDb ethdb.Database
 		}
 	}
 
-	eventMux := new(event.TypeMux)
-	pow := ethash.New()
-	//genesis := core.GenesisBlock(uint64(ctx.GlobalInt(GenesisNonceFlag.Name)), blockDB)
-	chain, err = core.NewBlockChain(chainDb, pow, eventMux)
+	if ctx.GlobalBool(CliqueFlag.Name) {
+		chain, err = core.NewBlockChainWithEngine(chainDb, clique.New(clique.GenesisConfig(chainDb), chainDb))
+	} else {
+		pow := ethash.New()
+		//genesis := core.GenesisBlock(uint64(ctx.GlobalInt(GenesisNonceFlag.Name)), blockDB)
+		chain, err = core.NewBlockChain(chainDb, pow)
+	}
 	if err != nil {
 		Fatalf("Could not start chainmanager: %v", err)
 	}
@@ -577,7 +965,23 @@ func MakeAccountManager(ctx *cli.Context) *accounts.Manager {
 		scryptP = crypto.LightScryptP
 	}
 	ks := crypto.NewKeyStorePassphrase(filepath.Join(dataDir, "keystore"), scryptN, scryptP)
-	return accounts.NewManager(ks)
+	am := accounts.NewManager(ks)
+	if path := ctx.GlobalString(AuditLogFlag.Name); path != "" {
+		var key []byte
+		if keyfile := ctx.GlobalString(AuditKeyFlag.Name); keyfile != "" {
+			blob, err := ioutil.ReadFile(keyfile)
+			if err != nil {
+				Fatalf("Failed to read audit key file '%s': %v", keyfile, err)
+			}
+			key = bytes.TrimSpace(blob)
+		}
+		audit, err := accounts.NewAuditLog(path, key)
+		if err != nil {
+			Fatalf("Failed to open audit log '%s': %v", path, err)
+		}
+		am.SetAuditLog(audit)
+	}
+	return am
 }
 
 // MustDataDir retrieves the currently requested data directory, terminating if
@@ -609,16 +1013,38 @@ func IpcSocketPath(ctx *cli.Context) (ipcpath string) {
 	return
 }
 
+// apiList returns the API modules selected by the given flag, failing with an
+// error naming the flag if the list is malformed or selects unknown modules.
+func apiList(ctx *cli.Context, flag cli.StringFlag) (string, error) {
+	apistr := ctx.GlobalString(flag.Name)
+	if _, err := api.ParseApiNames(apistr); err != nil {
+		return "", fmt.Errorf("invalid --%s: %v", flag.Name, err)
+	}
+	return apistr, nil
+}
+
 func StartIPC(exp *exp.Expanse, ctx *cli.Context) error {
 	config := comms.IpcConfig{
 		Endpoint: IpcSocketPath(ctx),
 	}
-
+	policy, err := MakeTxPolicy(ctx)
+	if err != nil {
+		return err
+	}
+	// Validate the modules up front, the APIs are created per connection
+	apistr, err := apiList(ctx, IPCApiFlag)
+	if err != nil {
+		return err
+	}
 
 	initializer := func(conn net.Conn) (comms.Stopper, shared.ExpanseApi, error) {
 		fe := useragent.NewRemoteFrontend(conn, exp.AccountManager())
-		xeth := xeth.New(exp, fe)
-		apis, err := api.ParseApiString(ctx.GlobalString(IPCApiFlag.Name), codec.JSON, xeth, exp)
+		xeth := xeth.New(exp.ApiBackend(), fe)
+		xeth.SetOrigin("ipc")
+		if policy != nil {
+			xeth.SetPolicy(policy)
+		}
+		apis, err := api.ParseApiString(apistr, codec.JSON, xeth, exp)
 		if err != nil {
 			return nil, nil, err
 		}
@@ -633,12 +1059,33 @@ func StartRPC(exp *exp.Expanse, ctx *cli.Context) error {
 		ListenAddress: ctx.GlobalString(RPCListenAddrFlag.Name),
 		ListenPort:    uint(ctx.GlobalInt(RPCPortFlag.Name)),
 		CorsDomain:    ctx.GlobalString(RPCCORSDomainFlag.Name),
+
+		ReadTimeout:    ctx.GlobalDuration(RPCReadTimeoutFlag.Name),
+		WriteTimeout:   ctx.GlobalDuration(RPCWriteTimeoutFlag.Name),
+		IdleTimeout:    ctx.GlobalDuration(RPCIdleTimeoutFlag.Name),
+		MaxRequestSize: int64(ctx.GlobalInt(RPCMaxRequestSizeFlag.Name)),
+
+		TLSCert:       ctx.GlobalString(RPCTLSCertFlag.Name),
+		TLSKey:        ctx.GlobalString(RPCTLSKeyFlag.Name),
+		ClientCertDir: filepath.Join(MustDataDir(ctx), "rpcclients"),
 	}
 
-	xeth := xeth.New(exp, nil)
+	apistr, err := apiList(ctx, RpcApiFlag)
+	if err != nil {
+		return err
+	}
+	policy, err := MakeTxPolicy(ctx)
+	if err != nil {
+		return err
+	}
+	xeth := xeth.New(exp.ApiBackend(), nil)
+	xeth.SetOrigin("rpc")
+	if policy != nil {
+		xeth.SetPolicy(policy)
+	}
 	codec := codec.JSON
 
-	apis, err := api.ParseApiString(ctx.GlobalString(RpcApiFlag.Name), codec, xeth, exp)
+	apis, err := api.ParseApiString(apistr, codec, xeth, exp)
 	if err != nil {
 		return err
 	}
@@ -646,6 +1093,43 @@ func StartRPC(exp *exp.Expanse, ctx *cli.Context) error {
 	return comms.StartHttp(config, codec, api.Merge(apis...))
 }
 
+func StartWS(exp *exp.Expanse, ctx *cli.Context) error {
+	config := comms.WsConfig{
+		ListenAddress: ctx.GlobalString(WSListenAddrFlag.Name),
+		ListenPort:    uint(ctx.GlobalInt(WSPortFlag.Name)),
+		Origins:       ctx.GlobalString(WSAllowedOriginsFlag.Name),
+		MaxPending:    ctx.GlobalInt(WSMaxPendingFlag.Name),
+		MaxFrameSize:  ctx.GlobalInt(WSMaxFrameSizeFlag.Name),
+		Compression:   ctx.GlobalBool(WSCompressionFlag.Name),
+	}
+
+	apistr, err := apiList(ctx, WSApiFlag)
+	if err != nil {
+		return err
+	}
+	policy, err := MakeTxPolicy(ctx)
+	if err != nil {
+		return err
+	}
+	xeth := xeth.New(exp.ApiBackend(), nil)
+	xeth.SetOrigin("ws")
+	if policy != nil {
+		xeth.SetPolicy(policy)
+	}
+	// Every connection gets a session of its own, so its filters are removed
+	// once it disconnects
+	initializer := func(net.Conn) (comms.Stopper, shared.ExpanseApi, error) {
+		session := xeth.Session()
+		apis, err := api.ParseApiString(apistr, codec.JSON, session, exp)
+		if err != nil {
+			session.Stop()
+			return nil, nil, err
+		}
+		return session, api.Merge(apis...), nil
+	}
+	return comms.StartWs(config, codec.JSON, initializer)
+}
+
 func StartPProf(ctx *cli.Context) {
 	address := fmt.Sprintf("localhost:%d", ctx.GlobalInt(PProfPortFlag.Name))
 	go func() {
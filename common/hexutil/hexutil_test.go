@@ -0,0 +1,124 @@
+// Copyright 2015 The go-expanse Authors
+// This file is part of the go-expanse library.
+//
+// The go-expanse library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-expanse library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-expanse library. If not, see <http://www.gnu.org/licenses/>.
+
+package hexutil
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/big"
+	"testing"
+)
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []byte
+		err   error
+	}{
+		{"", nil, ErrEmptyString},
+		{"0", nil, ErrMissingPrefix},
+		{"0x0", nil, ErrOddLength},
+		{"0xz1", nil, ErrSyntax},
+		{"0x", []byte{}, nil},
+		{"0x02", []byte{0x02}, nil},
+		{"0X0bFF", []byte{0x0b, 0xff}, nil},
+	}
+	for _, test := range tests {
+		dec, err := Decode(test.input)
+		if err != test.err {
+			t.Errorf("input %q: error mismatch: got %v, want %v", test.input, err, test.err)
+			continue
+		}
+		if err == nil && !bytes.Equal(dec, test.want) {
+			t.Errorf("input %q: value mismatch: got %x, want %x", test.input, dec, test.want)
+		}
+	}
+}
+
+func TestDecodeUint64(t *testing.T) {
+	tests := []struct {
+		input string
+		want  uint64
+		err   error
+	}{
+		{"", 0, ErrEmptyString},
+		{"12", 0, ErrMissingPrefix},
+		{"0x", 0, ErrEmptyNumber},
+		{"0x01", 0, ErrLeadingZero},
+		{"0xfffffffffffffffff", 0, ErrUint64Range},
+		{"0xg", 0, ErrSyntax},
+		{"0x0", 0, nil},
+		{"0x2a", 42, nil},
+		{"0xffffffffffffffff", 0xffffffffffffffff, nil},
+	}
+	for _, test := range tests {
+		dec, err := DecodeUint64(test.input)
+		if err != test.err {
+			t.Errorf("input %q: error mismatch: got %v, want %v", test.input, err, test.err)
+			continue
+		}
+		if dec != test.want {
+			t.Errorf("input %q: value mismatch: got %d, want %d", test.input, dec, test.want)
+		}
+	}
+}
+
+func TestEncodeBig(t *testing.T) {
+	tests := []struct {
+		input *big.Int
+		want  string
+	}{
+		{big.NewInt(0), "0x0"},
+		{big.NewInt(1), "0x1"},
+		{big.NewInt(-1), "0x1"},
+		{new(big.Int).Lsh(big.NewInt(1), 100), "0x10000000000000000000000000"},
+	}
+	for _, test := range tests {
+		if enc := EncodeBig(test.input); enc != test.want {
+			t.Errorf("input %v: got %q, want %q", test.input, enc, test.want)
+		}
+	}
+}
+
+func TestJSONRoundTrip(t *testing.T) {
+	type resp struct {
+		Data   Bytes  `json:"data"`
+		Number *Big   `json:"number"`
+		Index  Uint64 `json:"index"`
+		Total  *Big   `json:"total"`
+	}
+	in := resp{Data: Bytes{0x01, 0x02}, Number: (*Big)(big.NewInt(1024)), Index: 0}
+	enc, err := json.Marshal(in)
+	if err != nil {
+		t.Fatalf("marshal error: %v", err)
+	}
+	want := `{"data":"0x0102","number":"0x400","index":"0x0","total":null}`
+	if string(enc) != want {
+		t.Fatalf("marshal output mismatch:\ngot  %s\nwant %s", enc, want)
+	}
+
+	var out resp
+	if err := json.Unmarshal(enc, &out); err != nil {
+		t.Fatalf("unmarshal error: %v", err)
+	}
+	if !bytes.Equal(out.Data, in.Data) || out.Number.ToInt().Cmp(in.Number.ToInt()) != 0 || out.Index != in.Index || out.Total != nil {
+		t.Errorf("round trip mismatch: got %+v, want %+v", out, in)
+	}
+	if err := json.Unmarshal([]byte(`{"number":"0x0400"}`), &out); err != ErrLeadingZero {
+		t.Errorf("non-canonical quantity: got error %v, want %v", err, ErrLeadingZero)
+	}
+}
@@ -18,18 +18,32 @@ package httpclient
 
 import (
 	"fmt"
+	"io"
 	"io/ioutil"
+	"net"
 	"net/http"
+	"net/url"
 	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/expanse-project/go-expanse/common"
 	"github.com/expanse-project/go-expanse/crypto"
 )
 
+var (
+	// MaxContentSize is the largest document (in bytes) the client will download.
+	MaxContentSize int64 = 1024 * 1024
+	// RequestTimeout bounds the total time of a single request, including
+	// redirects and reading the response body.
+	RequestTimeout = 10 * time.Second
+)
+
 type HTTPClient struct {
 	*http.Transport
 	DocRoot string
 	schemes []string
+	hosts   []string // remote hosts GetAuthContent may fetch from
 }
 
 func New(docRoot string) (self *HTTPClient) {
@@ -49,6 +63,7 @@ func New(docRoot string) (self *HTTPClient) {
 func (self *HTTPClient) Client() *http.Client {
 	return &http.Client{
 		Transport: self,
+		Timeout:   RequestTimeout,
 	}
 }
 
@@ -66,9 +81,57 @@ func (self *HTTPClient) HasScheme(scheme string) bool {
 	return false
 }
 
+// AllowHost adds host (optionally with port) to the whitelist of remote hosts
+// that authenticated content may be retrieved from over http(s).
+func (self *HTTPClient) AllowHost(host string) {
+	self.hosts = append(self.hosts, strings.ToLower(host))
+}
+
+// checkAuthURL reports whether uri may be used to retrieve authenticated
+// content. Registered schemes (file, bzz, ...) are always permitted, http and
+// https only if the host is on the whitelist.
+func (self *HTTPClient) checkAuthURL(u *url.URL) error {
+	scheme := strings.ToLower(u.Scheme)
+	if scheme != "http" && scheme != "https" {
+		if self.HasScheme(scheme) {
+			return nil
+		}
+		return fmt.Errorf("scheme '%s' not allowed", u.Scheme)
+	}
+	host := strings.ToLower(u.Host)
+	hostname := host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		hostname = h
+	}
+	for _, h := range self.hosts {
+		if h == host || h == hostname {
+			return nil
+		}
+	}
+	return fmt.Errorf("host '%s' not whitelisted", u.Host)
+}
+
+// GetAuthContent retrieves the document at uri and checks that it hashes to
+// hash. Remote documents are only fetched from whitelisted hosts (following
+// redirects is subject to the same restriction).
 func (self *HTTPClient) GetAuthContent(uri string, hash common.Hash) ([]byte, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, err
+	}
+	if err := self.checkAuthURL(u); err != nil {
+		return nil, fmt.Errorf("cannot retrieve %s: %v", uri, err)
+	}
+	client := self.Client()
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if len(via) >= 10 {
+			return fmt.Errorf("stopped after 10 redirects")
+		}
+		return self.checkAuthURL(req.URL)
+	}
+
 	// retrieve content
-	content, err := self.Get(uri, "")
+	content, err := self.get(client, uri)
 	if err != nil {
 		return nil, err
 	}
@@ -87,24 +150,9 @@ func (self *HTTPClient) GetAuthContent(uri string, hash common.Hash) ([]byte, er
 // is interpreted as a filepath to which the contents are saved
 func (self *HTTPClient) Get(uri, path string) ([]byte, error) {
 	// retrieve content
-	resp, err := self.Client().Get(uri)
-	if err != nil {
-		return nil, err
-	}
-	defer func() {
-		if resp != nil {
-			resp.Body.Close()
-		}
-	}()
-
-	var content []byte
-	content, err = ioutil.ReadAll(resp.Body)
+	content, err := self.get(self.Client(), uri)
 	if err != nil {
-		return nil, err
-	}
-
-	if resp.StatusCode/100 != 2 {
-		return content, fmt.Errorf("HTTP error: %s", resp.Status)
+		return content, err
 	}
 
 	if path != "" {
@@ -122,3 +170,29 @@ func (self *HTTPClient) Get(uri, path string) ([]byte, error) {
 	return content, nil
 
 }
+
+// get downloads the document at uri using client, refusing documents larger
+// than MaxContentSize.
+func (self *HTTPClient) get(client *http.Client, uri string) ([]byte, error) {
+	resp, err := client.Get(uri)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.ContentLength > MaxContentSize {
+		return nil, fmt.Errorf("content too large (%d > %d bytes)", resp.ContentLength, MaxContentSize)
+	}
+	content, err := ioutil.ReadAll(io.LimitReader(resp.Body, MaxContentSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(content)) > MaxContentSize {
+		return nil, fmt.Errorf("content too large (> %d bytes)", MaxContentSize)
+	}
+
+	if resp.StatusCode/100 != 2 {
+		return content, fmt.Errorf("HTTP error: %s", resp.Status)
+	}
+	return content, nil
+}
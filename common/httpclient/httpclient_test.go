@@ -19,8 +19,11 @@ package httpclient
 import (
 	"io/ioutil"
 	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"os"
 	"path"
+	"strings"
 	"testing"
 
 	"github.com/expanse-project/go-expanse/common"
@@ -75,3 +78,60 @@ func TestRegisterScheme(t *testing.T) {
 		t.Errorf("expected scheme to be registered")
 	}
 }
+
+func TestGetAuthContentWhitelist(t *testing.T) {
+	text := "test"
+	hash := crypto.Sha3Hash([]byte(text))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(text))
+	}))
+	defer server.Close()
+
+	client := New("/tmp/")
+	if _, err := client.GetAuthContent(server.URL, hash); err == nil {
+		t.Errorf("expected error for host not on whitelist")
+	}
+	if _, err := client.GetAuthContent("ftp://example.com/test.content", hash); err == nil {
+		t.Errorf("expected error for unregistered scheme")
+	}
+
+	u, _ := url.Parse(server.URL)
+	client.AllowHost(u.Host)
+	content, err := client.GetAuthContent(server.URL, hash)
+	if err != nil {
+		t.Fatalf("no error expected, got %v", err)
+	}
+	if string(content) != text {
+		t.Errorf("incorrect content. expected %v, got %v", text, string(content))
+	}
+}
+
+func TestGetAuthContentRedirect(t *testing.T) {
+	text := "test"
+	hash := crypto.Sha3Hash([]byte(text))
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(text))
+	}))
+	defer target.Close()
+	server := httptest.NewServer(http.RedirectHandler(strings.Replace(target.URL, "127.0.0.1", "localhost", 1), http.StatusFound))
+	defer server.Close()
+
+	client := New("/tmp/")
+	u, _ := url.Parse(server.URL)
+	client.AllowHost(u.Host)
+	if _, err := client.GetAuthContent(server.URL, hash); err == nil {
+		t.Errorf("expected error when redirected to host not on whitelist")
+	}
+}
+
+func TestGetMaxContentSize(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(make([]byte, MaxContentSize+1))
+	}))
+	defer server.Close()
+
+	client := New("/tmp/")
+	if _, err := client.Get(server.URL, ""); err == nil {
+		t.Errorf("expected error for oversized content")
+	}
+}
@@ -19,8 +19,11 @@ package natspec
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"runtime"
 	"strings"
+	"time"
 
 	"github.com/expanse-project/go-expanse/common"
 	"github.com/expanse-project/go-expanse/common/httpclient"
@@ -33,6 +36,45 @@ import (
 
 type abi2method map[[8]byte]*method
 
+var (
+	// EvalTimeout is the time the evaluation of a notice may take.
+	EvalTimeout = 100 * time.Millisecond
+
+	// EvalMemoryLimit is the heap growth allowed while evaluating a notice.
+	// The interpreter can't account for its own allocations, so the heap of
+	// the process is sampled instead.
+	EvalMemoryLimit uint64 = 64 * 1024 * 1024
+
+	// MaxNoticeLength is the maximum length of a notice and of the expression
+	// it is evaluated from.
+	MaxNoticeLength = 4096
+)
+
+// memCheckInterval is how often the heap is sampled during an evaluation.
+const memCheckInterval = 5 * time.Millisecond
+
+var (
+	errEvalTimeout  = errors.New("evaluation timed out")
+	errEvalMemory   = errors.New("evaluation exceeded the memory limit")
+	errNoticeLength = errors.New("notice too long")
+)
+
+// EvalError is returned if the NatSpec of a contract was found but its notice
+// failed to evaluate, e.g. because the expression is invalid or exceeded the
+// limits of the sandbox.
+type EvalError struct {
+	Err error
+}
+
+func (e *EvalError) Error() string {
+	return fmt.Sprintf("natspec.js error evaluating expression: %v", e.Err)
+}
+
+// sandboxHalt is the panic value used to abort a running evaluation.
+type sandboxHalt struct {
+	err error
+}
+
 type NatSpec struct {
 	jsvm       *otto.Otto
 	abiDocJson []byte
@@ -43,7 +85,6 @@ type NatSpec struct {
 // main entry point for to get natspec notice for a transaction
 // the implementation is frontend friendly in that it always gives back
 // a notice that is safe to display
-// :FIXME: the second return value is an error, which can be used to fine-tune bahaviour
 func GetNotice(xeth *xeth.XEth, tx string, http *httpclient.HTTPClient) (notice string) {
 	ns, err := New(xeth, tx, http)
 	if err != nil {
@@ -62,6 +103,17 @@ func GetNotice(xeth *xeth.XEth, tx string, http *httpclient.HTTPClient) (notice
 	return
 }
 
+// FetchNotice is like GetNotice, but returns evaluation failures as an
+// *EvalError instead of a fallback notice. Missing or invalid NatSpec info
+// still results in the fallback notice.
+func FetchNotice(xeth *xeth.XEth, tx string, http *httpclient.HTTPClient) (string, error) {
+	ns, err := New(xeth, tx, http)
+	if err != nil {
+		return GetNotice(xeth, tx, http), nil
+	}
+	return ns.Notice()
+}
+
 func getFallbackNotice(comment, tx string) string {
 	return fmt.Sprintf("About to submit transaction (%s): %s", comment, tx)
 }
@@ -124,7 +176,7 @@ func FetchDocsForContract(contractAddress string, xeth *xeth.XEth, client *httpc
 		return
 	}
 	if client.HasScheme("bzz") {
-		content, err = client.Get("bzz://"+hash.Hex()[2:], "")
+		content, err = client.GetAuthContent("bzz://"+hash.Hex()[2:], hash)
 		if err == nil { // non-fatal
 			return
 		}
@@ -137,7 +189,8 @@ func FetchDocsForContract(contractAddress string, xeth *xeth.XEth, client *httpc
 		return
 	}
 
-	// get content via http client and authenticate content using hash
+	// get content via http client and authenticate content using hash,
+	// remote urls are restricted to the client's host whitelist
 	content, err = client.GetAuthContent(uri, hash)
 	if err != nil {
 		return
@@ -161,12 +214,13 @@ func NewWithDocs(infoDoc []byte, tx string, data string) (self *NatSpec, err err
 		data:       data,
 	}
 
-	// load and require natspec js (but it is meant to be protected environment)
+	// load and require natspec js, the interpreter is private to this
+	// NatSpec and the console is its only host binding, stub it out.
 	_, err = self.jsvm.Run(natspecJS)
 	if err != nil {
 		return
 	}
-	_, err = self.jsvm.Run("var natspec = require('natspec');")
+	_, err = self.jsvm.Run("var natspec = require('natspec'); console = {log: function () {}, error: function () {}};")
 	return
 }
 
@@ -221,41 +275,97 @@ func (self *NatSpec) Notice() (notice string, err error) {
 		err = fmt.Errorf("abi key does not match any method")
 		return
 	}
-	notice, err = self.noticeForMethod(self.tx, meth.name, meth.Notice)
+	notice, err = self.noticeForMethod(meth.name, meth.Notice)
 	return
 }
 
-func (self *NatSpec) noticeForMethod(tx string, name, expression string) (notice string, err error) {
-
-	if _, err = self.jsvm.Run("var transaction = " + tx + ";"); err != nil {
+// noticeForMethod evaluates the notice expression of a method against the
+// transaction data. The inputs are handed to the interpreter as values, never
+// as source, so only the expression itself is evaluated.
+func (self *NatSpec) noticeForMethod(name, expression string) (notice string, err error) {
+	if len(expression) > MaxNoticeLength {
+		return "", &EvalError{errNoticeLength}
+	}
+	transaction := map[string]interface{}{
+		"params": []interface{}{map[string]interface{}{"data": self.data}},
+	}
+	if err = self.jsvm.Set("transaction", transaction); err != nil {
 		return "", fmt.Errorf("natspec.js error setting transaction: %v", err)
 	}
-
-	if _, err = self.jsvm.Run("var abi = " + string(self.abiDocJson) + ";"); err != nil {
+	if err = self.jsvm.Set("abiJson", string(self.abiDocJson)); err != nil {
 		return "", fmt.Errorf("natspec.js error setting abi: %v", err)
 	}
-
-	if _, err = self.jsvm.Run("var method = '" + name + "';"); err != nil {
+	if err = self.jsvm.Set("method", name); err != nil {
 		return "", fmt.Errorf("natspec.js error setting method: %v", err)
 	}
-
-	if _, err = self.jsvm.Run("var expression = \"" + expression + "\";"); err != nil {
+	if err = self.jsvm.Set("expression", expression); err != nil {
 		return "", fmt.Errorf("natspec.js error setting expression: %v", err)
 	}
 
-	self.jsvm.Run("var call = {method: method,abi: abi,transaction: transaction};")
-	value, err := self.jsvm.Run("natspec.evaluateExpression(expression, call);")
+	value, err := self.run("natspec.evaluateExpression(expression, {method: method, abi: JSON.parse(abiJson), transaction: transaction});")
 	if err != nil {
-		return "", fmt.Errorf("natspec.js error evaluating expression: %v", err)
+		return "", &EvalError{err}
 	}
 	evalError := "Natspec evaluation failed, wrong input params"
 	if value.String() == evalError {
-		return "", fmt.Errorf("natspec.js error evaluating expression: wrong input params in expression '%s'", expression)
+		return "", &EvalError{fmt.Errorf("wrong input params in expression '%s'", expression)}
 	}
 	if len(value.String()) == 0 {
-		return "", fmt.Errorf("natspec.js error evaluating expression")
+		return "", &EvalError{errors.New("empty notice")}
+	}
+	if len(value.String()) > MaxNoticeLength {
+		return "", &EvalError{errNoticeLength}
 	}
 
 	return value.String(), nil
+}
+
+// run executes src in the interpreter of the NatSpec, aborting it once it
+// takes longer than EvalTimeout or grows the heap by more than EvalMemoryLimit.
+func (self *NatSpec) run(src string) (value otto.Value, err error) {
+	interrupt := make(chan func(), 1)
+	done := make(chan struct{})
+	defer close(done)
+
+	self.jsvm.Interrupt = interrupt
+	go watchEvaluation(interrupt, done)
+
+	defer func() {
+		if r := recover(); r != nil {
+			halt, ok := r.(sandboxHalt)
+			if !ok {
+				panic(r)
+			}
+			err = halt.err
+		}
+	}()
+	return self.jsvm.Run(src)
+}
 
+// watchEvaluation interrupts a running evaluation if it exceeds its limits,
+// until done is closed.
+func watchEvaluation(interrupt chan<- func(), done <-chan struct{}) {
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	limit := stats.HeapAlloc + EvalMemoryLimit
+
+	timeout := time.NewTimer(EvalTimeout)
+	defer timeout.Stop()
+	sample := time.NewTicker(memCheckInterval)
+	defer sample.Stop()
+
+	var err error
+	for err == nil {
+		select {
+		case <-done:
+			return
+		case <-timeout.C:
+			err = errEvalTimeout
+		case <-sample.C:
+			if runtime.ReadMemStats(&stats); stats.HeapAlloc > limit {
+				err = errEvalMemory
+			}
+		}
+	}
+	interrupt <- func() { panic(sandboxHalt{err}) }
 }
@@ -175,8 +175,8 @@ func testInit(t *testing.T) (self *testFrontend) {
 
 	// mock frontend
 	self = &testFrontend{t: t, expanse: expanse}
-	self.xeth = xe.New(expanse, self)
-	self.wait = self.xexp.UpdateState()
+	self.xeth = xe.New(expanse.ApiBackend(), self)
+	self.wait = self.xeth.UpdateState()
 	addr, _ := self.expanse.Etherbase()
 
 	// initialise the registry contracts
@@ -191,7 +191,7 @@ func testInit(t *testing.T) (self *testFrontend) {
 	if !processTxs(self, t, 1) {
 		t.Fatalf("error mining txs")
 	}
-	recG := self.xexp.GetTxReceipt(common.HexToHash(txG))
+	recG := self.xeth.GetTxReceipt(common.HexToHash(txG))
 	if recG == nil {
 		t.Fatalf("blockchain error creating GlobalRegistrar")
 	}
@@ -204,7 +204,7 @@ func testInit(t *testing.T) (self *testFrontend) {
 	if !processTxs(self, t, 1) {
 		t.Errorf("error mining txs")
 	}
-	recH := self.xexp.GetTxReceipt(common.HexToHash(txH))
+	recH := self.xeth.GetTxReceipt(common.HexToHash(txH))
 	if recH == nil {
 		t.Fatalf("blockchain error creating HashReg")
 	}
@@ -217,7 +217,7 @@ func testInit(t *testing.T) (self *testFrontend) {
 	if !processTxs(self, t, 1) {
 		t.Errorf("error mining txs")
 	}
-	recU := self.xexp.GetTxReceipt(common.HexToHash(txU))
+	recU := self.xeth.GetTxReceipt(common.HexToHash(txU))
 	if recU == nil {
 		t.Fatalf("blockchain error creating UrlHint")
 	}
@@ -240,7 +240,7 @@ func TestNatspecE2E(t *testing.T) {
 	dochash := crypto.Sha3Hash([]byte(testContractInfo))
 
 	// take the codehash for the contract we wanna test
-	codeb := tf.xexp.CodeAtBytes(registrar.HashRegAddr)
+	codeb := tf.xeth.CodeAtBytes(registrar.HashRegAddr)
 	codehash := crypto.Sha3Hash(codeb)
 
 	reg := registrar.New(tf.xeth)
@@ -331,7 +331,7 @@ func processTxs(repl *testFrontend, t *testing.T, expTxc int) bool {
 	defer repl.expanse.StopMining()
 
 	timer := time.NewTimer(100 * time.Second)
-	height := new(big.Int).Add(repl.xexp.CurrentBlock().Number(), big.NewInt(1))
+	height := new(big.Int).Add(repl.xeth.CurrentBlock().Number(), big.NewInt(1))
 	repl.wait <- height
 	select {
 	case <-timer.C:
@@ -18,6 +18,7 @@ package natspec
 
 import (
 	"testing"
+	"time"
 )
 
 func makeInfoDoc(desc string) []byte {
@@ -104,7 +105,7 @@ func TestMissingMethod(t *testing.T) {
 		t.Errorf("New: error: %v", err)
 	}
 
-	notice, err := ns.noticeForMethod(tx, "missing_method", "")
+	notice, err := ns.noticeForMethod("missing_method", "")
 
 	if err == nil {
 		t.Errorf("expected error, got nothing (notice: '%v')", notice)
@@ -156,3 +157,52 @@ func TestWrongInputParams(t *testing.T) {
 	}
 
 }
+
+// test that the evaluation of runaway expressions is aborted
+func TestEvalLimits(t *testing.T) {
+	defer func(timeout time.Duration, limit uint64) {
+		EvalTimeout, EvalMemoryLimit = timeout, limit
+	}(EvalTimeout, EvalMemoryLimit)
+
+	tests := []struct {
+		desc    string
+		timeout time.Duration
+		limit   uint64
+		want    error
+	}{
+		{"Loops `(function () { while (true) {} })()` forever.", 50 * time.Millisecond, 1 << 30, errEvalTimeout},
+		{"Allocates `(function () { var a = []; while (true) { a.push('x' + a.length) } })()`.", 10 * time.Second, 1 << 20, errEvalMemory},
+	}
+	for _, test := range tests {
+		EvalTimeout, EvalMemoryLimit = test.timeout, test.limit
+
+		ns, err := NewWithDocs(makeInfoDoc(test.desc), tx, data)
+		if err != nil {
+			t.Fatalf("New: error: %v", err)
+		}
+		notice, err := ns.Notice()
+		if evalErr, ok := err.(*EvalError); !ok || evalErr.Err != test.want {
+			t.Errorf("%s: error mismatch: have %v, want %v (notice: '%v')", test.desc, err, test.want, notice)
+		}
+	}
+}
+
+// test that the inputs can't break out of the evaluated expression
+func TestEvalInputsNotEvaluated(t *testing.T) {
+	infodoc := makeInfoDoc("Will multiply `a` by 7.")
+	ns, err := NewWithDocs(infodoc, tx, data)
+	if err != nil {
+		t.Fatalf("New: error: %v", err)
+	}
+	if _, err := ns.noticeForMethod("multiply'; throw 'injected", "`a`"); err == nil {
+		t.Errorf("expected error for unknown method, got nothing")
+	} else if evalErr, ok := err.(*EvalError); !ok {
+		t.Errorf("error type mismatch: have %T, want *EvalError", err)
+	} else if evalErr.Err.Error() != "Natspec evaluation failed, method does not exist" {
+		t.Errorf("unexpected error: %v", evalErr.Err)
+	}
+	notice, err := ns.noticeForMethod("multiply", "Quoted \"`a`\" and 'single'.")
+	if err != nil || notice != "Quoted \"122\" and 'single'." {
+		t.Errorf("quoted notice mismatch: have '%v', err %v", notice, err)
+	}
+}
@@ -21,6 +21,8 @@ import (
 	"math/big"
 	"math/rand"
 	"reflect"
+
+	"github.com/expanse-project/go-expanse/common/hexutil"
 )
 
 const (
@@ -50,6 +52,24 @@ func (h Hash) Bytes() []byte { return h[:] }
 func (h Hash) Big() *big.Int { return Bytes2Big(h[:]) }
 func (h Hash) Hex() string   { return "0x" + Bytes2Hex(h[:]) }
 
+// String implements fmt.Stringer.
+func (h Hash) String() string { return h.Hex() }
+
+// Format implements fmt.Formatter. The %v and %s verbs print the 0x-prefixed
+// hex encoding, all other verbs format the raw bytes.
+func (h Hash) Format(s fmt.State, c rune) { formatBytes(s, c, h[:]) }
+
+// MarshalText implements encoding.TextMarshaler, encoding h as 0x-prefixed hex.
+func (h Hash) MarshalText() ([]byte, error) {
+	return []byte(h.Hex()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler. The input must be
+// 0x-prefixed hex of exactly hashLength bytes.
+func (h *Hash) UnmarshalText(input []byte) error {
+	return unmarshalFixedText("Hash", input, h[:])
+}
+
 // Sets the hash to the value of b. If b is larger than len(h) it will panic
 func (h *Hash) SetBytes(b []byte) {
 	if len(b) > len(h) {
@@ -99,6 +119,24 @@ func (a Address) Big() *big.Int { return Bytes2Big(a[:]) }
 func (a Address) Hash() Hash    { return BytesToHash(a[:]) }
 func (a Address) Hex() string   { return "0x" + Bytes2Hex(a[:]) }
 
+// String implements fmt.Stringer.
+func (a Address) String() string { return a.Hex() }
+
+// Format implements fmt.Formatter. The %v and %s verbs print the 0x-prefixed
+// hex encoding, all other verbs format the raw bytes.
+func (a Address) Format(s fmt.State, c rune) { formatBytes(s, c, a[:]) }
+
+// MarshalText implements encoding.TextMarshaler, encoding a as 0x-prefixed hex.
+func (a Address) MarshalText() ([]byte, error) {
+	return []byte(a.Hex()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler. The input must be
+// 0x-prefixed hex of exactly addressLength bytes.
+func (a *Address) UnmarshalText(input []byte) error {
+	return unmarshalFixedText("Address", input, a[:])
+}
+
 // Sets the address to the value of b. If b is larger than len(a) it will panic
 func (a *Address) SetBytes(b []byte) {
 	if len(b) > len(a) {
@@ -117,6 +155,42 @@ func (a *Address) Set(other Address) {
 	}
 }
 
+// unmarshalFixedText decodes 0x-prefixed hex input into out, which must
+// be exactly as long as the decoded input.
+func unmarshalFixedText(typname string, input, out []byte) error {
+	dec, err := hexutil.Decode(string(input))
+	if err != nil {
+		return fmt.Errorf("invalid %s: %v", typname, err)
+	}
+	if len(dec) != len(out) {
+		return fmt.Errorf("invalid %s: hex string has length %d, want %d", typname, len(dec)*2, len(out)*2)
+	}
+	copy(out, dec)
+	return nil
+}
+
+// formatBytes formats b for the verb c, honouring the flags and width
+// set in s. %v and %s print the 0x-prefixed hex encoding.
+func formatBytes(s fmt.State, c rune, b []byte) {
+	if c == 'v' || c == 's' {
+		fmt.Fprint(s, "0x"+Bytes2Hex(b))
+		return
+	}
+	format := "%"
+	for _, flag := range "+-# 0" {
+		if s.Flag(int(flag)) {
+			format += string(flag)
+		}
+	}
+	if width, ok := s.Width(); ok {
+		format += fmt.Sprint(width)
+	}
+	if prec, ok := s.Precision(); ok {
+		format += "." + fmt.Sprint(prec)
+	}
+	fmt.Fprintf(s, format+string(c), b)
+}
+
 // PP Pretty Prints a byte slice in the following format:
 // 	hex(value[:4])...(hex[len(value)-4:])
 func PP(value []byte) string {
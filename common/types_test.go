@@ -16,7 +16,13 @@
 
 package common
 
-import "testing"
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
 
 func TestBytesConversion(t *testing.T) {
 	bytes := []byte{5}
@@ -29,3 +35,67 @@ func TestBytesConversion(t *testing.T) {
 		t.Errorf("expected %x got %x", exp, hash)
 	}
 }
+
+func TestHashJsonValidation(t *testing.T) {
+	var tests = []struct {
+		Prefix string
+		Size   int
+		Error  string
+	}{
+		{"", 62, "invalid Hash: missing 0x prefix for hex data"},
+		{"0x", 66, "invalid Hash: hex string has length 66, want 64"},
+		{"0x", 63, "invalid Hash: hex string has odd length"},
+		{"0x", 0, "invalid Hash: hex string has length 0, want 64"},
+		{"0x", 64, ""},
+		{"0X", 64, ""},
+	}
+	for i, test := range tests {
+		input := `"` + test.Prefix + strings.Repeat("0", test.Size) + `"`
+		var v Hash
+		err := json.Unmarshal([]byte(input), &v)
+		if err == nil {
+			if test.Error != "" {
+				t.Errorf("test %d: expected error %q, got none", i, test.Error)
+			}
+		} else if err.Error() != test.Error {
+			t.Errorf("test %d: error mismatch: got %q, want %q", i, err, test.Error)
+		}
+	}
+}
+
+func TestAddressJsonRoundTrip(t *testing.T) {
+	addr := HexToAddress("0x0102030405060708090a0b0c0d0e0f1011121314")
+	in := map[Address]Hash{addr: BytesToHash([]byte{0x2a})}
+
+	enc, err := json.Marshal(in)
+	if err != nil {
+		t.Fatalf("marshal error: %v", err)
+	}
+	want := `{"0x0102030405060708090a0b0c0d0e0f1011121314":"0x000000000000000000000000000000000000000000000000000000000000002a"}`
+	if string(enc) != want {
+		t.Fatalf("marshal output mismatch:\ngot  %s\nwant %s", enc, want)
+	}
+	var out map[Address]Hash
+	if err := json.Unmarshal(enc, &out); err != nil {
+		t.Fatalf("unmarshal error: %v", err)
+	}
+	if !reflect.DeepEqual(in, out) {
+		t.Errorf("round trip mismatch: got %v, want %v", out, in)
+	}
+}
+
+func TestAddressFormat(t *testing.T) {
+	addr := HexToAddress("0x0102030405060708090a0b0c0d0e0f1011121314")
+	tests := map[string]string{
+		"%v":  "0x0102030405060708090a0b0c0d0e0f1011121314",
+		"%s":  "0x0102030405060708090a0b0c0d0e0f1011121314",
+		"%x":  "0102030405060708090a0b0c0d0e0f1011121314",
+		"%X":  "0102030405060708090A0B0C0D0E0F1011121314",
+		"%#x": "0x0102030405060708090a0b0c0d0e0f1011121314",
+	}
+	for format, want := range tests {
+		if got := fmt.Sprintf(format, addr); got != want {
+			t.Errorf("%s: got %q, want %q", format, got, want)
+		}
+	}
+}
@@ -19,6 +19,7 @@ package core
 import (
 	"fmt"
 	"math/big"
+	"sort"
 	"time"
 
 	"github.com/expanse-project/go-expanse/common"
@@ -26,14 +27,26 @@ import (
 	"github.com/expanse-project/go-expanse/core/types"
 	"github.com/expanse-project/go-expanse/logger/glog"
 	"github.com/expanse-project/go-expanse/params"
-	"github.com/expanse-project/go-expanse/pow"
 	"gopkg.in/fatih/set.v0"
 )
 
 var (
 	ExpDiffPeriod = big.NewInt(100000)
-	big60         = big.NewInt(60)
 	bigMinus99    = big.NewInt(-99)
+
+	// HomesteadDurationDivisor is the block time granularity (in seconds)
+	// of the homestead difficulty adjustment.
+	HomesteadDurationDivisor = big.NewInt(60)
+
+	// MaxFutureDrift is the number of seconds a block's timestamp may be ahead
+	// of the local clock. Blocks within the drift are queued until their time
+	// comes, blocks beyond it are rejected with a FutureBlockErr.
+	MaxFutureDrift uint64 = 30
+
+	// MedianTimeBlocks is the number of ancestors whose median timestamp the
+	// local clock is checked against. Zero disables the strict median time
+	// check.
+	MedianTimeBlocks = 0
 )
 
 // BlockValidator is responsible for validating block headers, uncles and
@@ -41,15 +54,15 @@ var (
 //
 // BlockValidator implements Validator.
 type BlockValidator struct {
-	bc  *BlockChain // Canonical block chain
-	Pow pow.PoW     // Proof of work used for validating
+	bc     *BlockChain // Canonical block chain
+	Engine Engine      // Consensus engine used for validating
 }
 
 // NewBlockValidator returns a new block validator which is safe for re-use
-func NewBlockValidator(blockchain *BlockChain, pow pow.PoW) *BlockValidator {
+func NewBlockValidator(blockchain *BlockChain, engine Engine) *BlockValidator {
 	validator := &BlockValidator{
-		Pow: pow,
-		bc:  blockchain,
+		Engine: engine,
+		bc:     blockchain,
 	}
 	return validator
 }
@@ -80,7 +93,10 @@ func (v *BlockValidator) ValidateBlock(block *types.Block) error {
 
 	header := block.Header()
 	// validate the block header
-	if err := ValidateHeader(v.Pow, header, parent.Header(), false, false); err != nil {
+	if err := v.validateMedianTime(header, parent.Header()); err != nil {
+		return err
+	}
+	if err := ValidateHeader(v.Engine, header, parent.Header(), false, false); err != nil {
 		return err
 	}
 	// verify the uncles are correctly rewarded
@@ -100,6 +116,14 @@ func (v *BlockValidator) ValidateBlock(block *types.Block) error {
 	if txSha != header.TxHash {
 		return fmt.Errorf("invalid transaction root hash. received=%x calculated=%x", header.TxHash, txSha)
 	}
+	// Transactions over the consensus size limit are never valid
+	if limit := params.MaxTransactionSize; limit > 0 {
+		for i, tx := range block.Transactions() {
+			if size := uint64(tx.Size()); size > limit {
+				return fmt.Errorf("transaction %d (%x) too large: %d > %d bytes", i, tx.Hash().Bytes()[:4], size, limit)
+			}
+		}
+	}
 
 	return nil
 }
@@ -113,6 +137,26 @@ func (v *BlockValidator) ValidateState(block, parent *types.Block, statedb *stat
 	if block.GasUsed().Cmp(usedGas) != 0 {
 		return ValidationError(fmt.Sprintf("gas used error (%v / %v)", block.GasUsed(), usedGas))
 	}
+	if err := ValidateReceipts(block, receipts); err != nil {
+		return err
+	}
+	// Validate the state root against the received state root and throw
+	// an error if they don't match.
+	if root := statedb.IntermediateRoot(); header.Root != root {
+		return fmt.Errorf("invalid merkle root: header=%x computed=%x", header.Root, root)
+	}
+	return nil
+}
+
+// ValidateReceipts validates the receipts of a block against the receipt root
+// and the log bloom of its header. It's used both for receipts generated by
+// processing the block and for receipts retrieved during fast sync, so that
+// inconsistent receipts are rejected before being persisted.
+func ValidateReceipts(block *types.Block, receipts types.Receipts) error {
+	header := block.Header()
+	if len(receipts) != len(block.Transactions()) {
+		return fmt.Errorf("receipt count mismatch: have %d, want %d", len(receipts), len(block.Transactions()))
+	}
 	// Validate the received block's bloom with the one derived from the generated receipts.
 	// For valid blocks this should always validate to true.
 	rbloom := types.CreateBloom(receipts)
@@ -124,11 +168,6 @@ func (v *BlockValidator) ValidateState(block, parent *types.Block, statedb *stat
 	if receiptSha != header.ReceiptHash {
 		return fmt.Errorf("invalid receipt root hash. received=%x calculated=%x", header.ReceiptHash, receiptSha)
 	}
-	// Validate the state root against the received state root and throw
-	// an error if they don't match.
-	if root := statedb.IntermediateRoot(); header.Root != root {
-		return fmt.Errorf("invalid merkle root: header=%x computed=%x", header.Root, root)
-	}
 	return nil
 }
 
@@ -175,7 +214,7 @@ func (v *BlockValidator) VerifyUncles(block, parent *types.Block) error {
 			return UncleError("uncle[%d](%x)'s parent is not ancestor (%x)", i, hash[:4], uncle.ParentHash[0:4])
 		}
 
-		if err := ValidateHeader(v.Pow, uncle, ancestors[uncle.ParentHash].Header(), true, true); err != nil {
+		if err := ValidateHeader(v.Engine, uncle, ancestors[uncle.ParentHash].Header(), true, true); err != nil {
 			return ValidationError(fmt.Sprintf("uncle[%d](%x) header invalid: %v", i, hash[:4], err))
 		}
 	}
@@ -195,23 +234,62 @@ func (v *BlockValidator) ValidateHeader(header, parent *types.Header, checkPow b
 	if v.bc.HasHeader(header.Hash()) {
 		return nil
 	}
-	return ValidateHeader(v.Pow, header, parent, checkPow, false)
+	if err := v.validateMedianTime(header, parent); err != nil {
+		return err
+	}
+	return ValidateHeader(v.Engine, header, parent, checkPow, false)
+}
+
+// validateMedianTime checks the local clock against the median timestamp of
+// the last MedianTimeBlocks blocks up to and including parent. A local clock
+// behind that median can't be explained by network latency, so rather than
+// queueing the header as a future block the skew is reported.
+func (v *BlockValidator) validateMedianTime(header, parent *types.Header) error {
+	if MedianTimeBlocks <= 0 {
+		return nil
+	}
+	median := v.medianTime(parent, MedianTimeBlocks)
+	if now := uint64(time.Now().Unix()); median > now {
+		return &MedianTimeErr{header.Number, header.Hash(), median, now, MedianTimeBlocks}
+	}
+	return nil
+}
+
+// medianTime returns the median timestamp of the given header and up to n-1
+// of its ancestors.
+func (v *BlockValidator) medianTime(header *types.Header, n int) uint64 {
+	times := make([]uint64, 0, n)
+	for header != nil && len(times) < n {
+		times = append(times, header.Time.Uint64())
+		if header.Number.Sign() == 0 {
+			break
+		}
+		header = v.bc.GetHeader(header.ParentHash)
+	}
+	sort.Sort(uint64s(times))
+	return times[len(times)/2]
 }
 
+type uint64s []uint64
+
+func (s uint64s) Len() int           { return len(s) }
+func (s uint64s) Less(i, j int) bool { return s[i] < s[j] }
+func (s uint64s) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+
 // Validates a header. Returns an error if the header is invalid.
 //
 // See YP section 4.3.4. "Block Header Validity"
-func ValidateHeader(pow pow.PoW, header *types.Header, parent *types.Header, checkPow, uncle bool) error {
-	if big.NewInt(int64(len(header.Extra))).Cmp(params.MaximumExtraDataSize) == 1 {
-		return fmt.Errorf("Header extra data too long (%d)", len(header.Extra))
-	}
-
+func ValidateHeader(engine Engine, header *types.Header, parent *types.Header, checkPow, uncle bool) error {
 	if uncle {
 		if header.Time.Cmp(common.MaxBig) == 1 {
 			return BlockTSTooBigErr
 		}
 	} else {
-		if header.Time.Cmp(big.NewInt(time.Now().Unix())) == 1 {
+		now := uint64(time.Now().Unix())
+		if header.Time.Cmp(new(big.Int).SetUint64(now+MaxFutureDrift)) == 1 {
+			return &FutureBlockErr{header.Number, header.Hash(), header.Time.Uint64(), now, MaxFutureDrift}
+		}
+		if header.Time.Cmp(new(big.Int).SetUint64(now)) == 1 {
 			return BlockFutureErr
 		}
 	}
@@ -219,9 +297,8 @@ func ValidateHeader(pow pow.PoW, header *types.Header, parent *types.Header, che
 		return BlockEqualTSErr
 	}
 
-	expd := CalcDifficulty(header.Time.Uint64(), parent.Time.Uint64(), parent.Number, parent.Difficulty)
-	if expd.Cmp(header.Difficulty) != 0 {
-		return fmt.Errorf("Difficulty check failed for header %v, %v", header.Difficulty, expd)
+	if err := engine.VerifyHeader(header, parent, uncle); err != nil {
+		return err
 	}
 
 	a := new(big.Int).Set(parent.GasLimit)
@@ -229,7 +306,8 @@ func ValidateHeader(pow pow.PoW, header *types.Header, parent *types.Header, che
 	a.Abs(a)
 	b := new(big.Int).Set(parent.GasLimit)
 	b = b.Div(b, params.GasLimitBoundDivisor)
-	if !(a.Cmp(b) < 0) || (header.GasLimit.Cmp(params.MinGasLimit) == -1) {
+	// a zero bound, from a divisor above the gas limit, fixes the gas limit
+	if !(a.Cmp(b) < 0 || a.Sign() == 0 && b.Sign() == 0) || (header.GasLimit.Cmp(params.MinGasLimit) == -1) {
 		return fmt.Errorf("GasLimit check failed for header %v (%v > %v)", header.GasLimit, a, b)
 	}
 
@@ -240,9 +318,9 @@ func ValidateHeader(pow pow.PoW, header *types.Header, parent *types.Header, che
 	}
 
 	if checkPow {
-		// Verify the nonce of the header. Return an error if it's not valid
-		if !pow.Verify(types.NewBlockWithHeader(header)) {
-			return &BlockNonceErr{header.Number, header.Hash(), header.Nonce.Uint64()}
+		// Verify the seal of the header. Return an error if it's not valid
+		if err := engine.VerifySeal(header); err != nil {
+			return err
 		}
 	}
 	return nil
@@ -273,9 +351,9 @@ func calcDifficultyHomestead(time, parentTime uint64, parentNumber, parentDiff *
 	x := new(big.Int)
 	y := new(big.Int)
 
-	// 1 - (block_timestamp -parent_timestamp) // 10
+	// 1 - (block_timestamp -parent_timestamp) // 60
 	x.Sub(bigTime, bigParentTime)
-	x.Div(x, big60)
+	x.Div(x, HomesteadDurationDivisor)
 	x.Sub(common.Big1, x)
 
 	// max(1 - (block_timestamp - parent_timestamp) // 10, -99)))
@@ -345,6 +423,11 @@ func CalcGasLimit(parent *types.Block) *big.Int {
 
 	// decay = parentGasLimit / 1024 -1
 	decay := new(big.Int).Div(parent.GasLimit(), params.GasLimitBoundDivisor)
+	if decay.Sign() == 0 {
+		// the bound divisor is above the gas limit, which private chains
+		// configure to keep it fixed.
+		return new(big.Int).Set(parent.GasLimit())
+	}
 	decay.Sub(decay, big.NewInt(1))
 
 	/*
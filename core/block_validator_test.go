@@ -20,22 +20,22 @@ import (
 	"fmt"
 	"math/big"
 	"testing"
+	"time"
 
 	"github.com/expanse-project/go-expanse/common"
 	"github.com/expanse-project/go-expanse/core/state"
 	"github.com/expanse-project/go-expanse/core/types"
 	"github.com/expanse-project/go-expanse/core/vm"
 	"github.com/expanse-project/go-expanse/ethdb"
-	"github.com/expanse-project/go-expanse/event"
+	"github.com/expanse-project/go-expanse/params"
 	"github.com/expanse-project/go-expanse/pow/ezp"
 )
 
 func proc() (Validator, *BlockChain) {
 	db, _ := ethdb.NewMemDatabase()
-	var mux event.TypeMux
 
 	WriteTestNetGenesisBlock(db, 0)
-	blockchain, err := NewBlockChain(db, thePow(), &mux)
+	blockchain, err := NewBlockChain(db, thePow())
 	if err != nil {
 		fmt.Println(err)
 	}
@@ -49,14 +49,14 @@ func TestNumber(t *testing.T) {
 	statedb, _ := state.New(chain.Genesis().Root(), chain.chainDb)
 	header := makeHeader(chain.Genesis(), statedb)
 	header.Number = big.NewInt(3)
-	err := ValidateHeader(pow, header, chain.Genesis().Header(), false, false)
+	err := ValidateHeader(NewPowEngine(pow), header, chain.Genesis().Header(), false, false)
 	if err != BlockNumberErr {
 		t.Errorf("expected block number error, got %q", err)
 	}
 
 	header = makeHeader(chain.Genesis(), statedb)
 
-	err = ValidateHeader(pow, header, chain.Genesis().Header(), false, false)
+	err = ValidateHeader(NewPowEngine(pow), header, chain.Genesis().Header(), false, false)
 	if err == BlockNumberErr {
 		t.Errorf("didn't expect block number error")
 	}
@@ -88,3 +88,55 @@ func TestPutReceipt(t *testing.T) {
 		t.Error("expected to get 1 receipt, got none.")
 	}
 }
+
+func TestCalcGasLimitFixed(t *testing.T) {
+	defer func(divisor *big.Int) { params.GasLimitBoundDivisor = divisor }(params.GasLimitBoundDivisor)
+
+	parent := types.NewBlock(&types.Header{GasLimit: big.NewInt(100000000), GasUsed: big.NewInt(90000000)}, nil, nil, nil)
+	if gl := CalcGasLimit(parent); gl.Cmp(parent.GasLimit()) == 0 {
+		t.Fatalf("expected the gas limit to change with the default divisor")
+	}
+	params.GasLimitBoundDivisor = new(big.Int).Add(parent.GasLimit(), common.Big1)
+	if gl := CalcGasLimit(parent); gl.Cmp(parent.GasLimit()) != 0 {
+		t.Errorf("gas limit mismatch: have %v, want fixed %v", gl, parent.GasLimit())
+	}
+}
+
+func TestFutureDrift(t *testing.T) {
+	defer func(drift uint64) { MaxFutureDrift = drift }(MaxFutureDrift)
+	MaxFutureDrift = 60
+
+	pow := ezp.New()
+	_, chain := proc()
+
+	statedb, _ := state.New(chain.Genesis().Root(), chain.chainDb)
+	header := makeHeader(chain.Genesis(), statedb)
+	header.Time = big.NewInt(time.Now().Unix() + 30)
+	if err := ValidateHeader(NewPowEngine(pow), header, chain.Genesis().Header(), false, false); err != BlockFutureErr {
+		t.Errorf("expected future block error within drift, got %v", err)
+	}
+	header.Time = big.NewInt(time.Now().Unix() + 120)
+	if err := ValidateHeader(NewPowEngine(pow), header, chain.Genesis().Header(), false, false); !IsFutureBlockErr(err) {
+		t.Errorf("expected drift violation, got %v", err)
+	}
+}
+
+func TestMedianTime(t *testing.T) {
+	defer func(blocks int) { MedianTimeBlocks = blocks }(MedianTimeBlocks)
+
+	validator, chain := proc()
+	statedb, _ := state.New(chain.Genesis().Root(), chain.chainDb)
+
+	parent := types.CopyHeader(chain.Genesis().Header())
+	parent.Time = big.NewInt(time.Now().Unix() + 600)
+	header := makeHeader(types.NewBlockWithHeader(parent), statedb)
+
+	MedianTimeBlocks = 0
+	if err := validator.ValidateHeader(header, parent, false); IsMedianTimeErr(err) {
+		t.Errorf("unexpected median time error with the check disabled: %v", err)
+	}
+	MedianTimeBlocks = 11
+	if err := validator.ValidateHeader(header, parent, false); !IsMedianTimeErr(err) {
+		t.Errorf("expected median time error, got %v", err)
+	}
+}
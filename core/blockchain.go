@@ -18,11 +18,9 @@
 package core
 
 import (
-	crand "crypto/rand"
 	"errors"
 	"fmt"
 	"io"
-	"math"
 	"math/big"
 	mrand "math/rand"
 	"runtime"
@@ -55,13 +53,17 @@ var (
 	ErrNoGenesis = errors.New("Genesis not found in chain")
 )
 
+var (
+	headerCacheLimit  = 512
+	bodyCacheLimit    = 256
+	tdCacheLimit      = 1024
+	blockCacheLimit   = 256
+	receiptCacheLimit = 256
+)
+
 const (
-	headerCacheLimit    = 512
-	bodyCacheLimit      = 256
-	tdCacheLimit        = 1024
-	blockCacheLimit     = 256
-	maxFutureBlocks     = 256
-	maxTimeFutureBlocks = 30
+	maxFutureBlocks = 256
+	maxRewindDepth  = 1024 // blocks searched below an incomplete head for a complete one
 	// must be bumped when consensus algorithm is changed, this forces the upgradedb
 	// command to be run (forces the blocks to be imported again using the new algorithm)
 	BlockChainVersion = 3
@@ -82,26 +84,33 @@ const (
 // included in the canonical one where as GetBlockByNumber always represents the
 // canonical chain.
 type BlockChain struct {
+	hc           *HeaderChain
 	chainDb      ethdb.Database
-	eventMux     *event.TypeMux
 	genesisBlock *types.Block
+
+	chainFeed     event.Feed // ChainEvent of canonical blocks
+	chainSideFeed event.Feed // ChainSideEvent of side chain blocks
+	chainHeadFeed event.Feed // ChainHeadEvent of new heads
+	logsFeed      event.Feed // vm.Logs of canonical blocks
+	rmTxFeed      event.Feed // RemovedTransactionEvent of reorgs
+	rmLogsFeed    event.Feed // RemovedLogsEvent of reorgs
+
 	// Last known total difficulty
 	mu      sync.RWMutex
 	chainmu sync.RWMutex
 	tsmu    sync.RWMutex
 	procmu  sync.RWMutex
 
-	checkpoint       int           // checkpoint counts towards the new checkpoint
-	currentHeader    *types.Header // Current head of the header chain (may be above the block chain!)
-	currentBlock     *types.Block  // Current head of the block chain
-	currentFastBlock *types.Block  // Current head of the fast-sync chain (may be above the block chain!)
+	checkpoint       int          // checkpoint counts towards the new checkpoint
+	currentBlock     *types.Block // Current head of the block chain
+	currentFastBlock *types.Block // Current head of the fast-sync chain (may be above the block chain!)
 
-	headerCache  *lru.Cache // Cache for the most recent block headers
 	bodyCache    *lru.Cache // Cache for the most recent block bodies
 	bodyRLPCache *lru.Cache // Cache for the most recent block bodies in RLP encoded format
-	tdCache      *lru.Cache // Cache for the most recent block total difficulties
 	blockCache   *lru.Cache // Cache for the most recent entire blocks
+	receiptCache *lru.Cache // Cache for the most recent block receipts
 	futureBlocks *lru.Cache // future blocks are blocks added for later processing
+	propDelays   *lru.Cache // Propagation delays of the most recently imported blocks
 
 	quit    chan struct{}
 	running int32 // running must be called automically
@@ -109,55 +118,73 @@ type BlockChain struct {
 	procInterrupt int32 // interrupt signaler for block processing
 	wg            sync.WaitGroup
 
-	pow       pow.PoW
-	rand      *mrand.Rand
+	engine    Engine
 	processor Processor
 	validator Validator
+
+	importStats *ImportStats // Phase timings of block insertion, nil if not benchmarking
+}
+
+// SetCacheLimits sets the number of recent blocks, bodies and receipts kept in
+// memory by block chains created afterwards. Headers and total difficulties
+// are small and are cached for two and four times as many blocks respectively.
+// Setting the limits is not thread safe, it should be done on startup.
+func SetCacheLimits(blocks int) {
+	if blocks < 1 {
+		blocks = 1
+	}
+	headerCacheLimit = 2 * blocks
+	bodyCacheLimit = blocks
+	tdCacheLimit = 4 * blocks
+	blockCacheLimit = blocks
+	receiptCacheLimit = blocks
 }
 
 // NewBlockChain returns a fully initialised block chain using information
 // available in the database. It initialiser the default Ethereum Validator and
 // Processor.
-func NewBlockChain(chainDb ethdb.Database, pow pow.PoW, mux *event.TypeMux) (*BlockChain, error) {
-	headerCache, _ := lru.New(headerCacheLimit)
+func NewBlockChain(chainDb ethdb.Database, pow pow.PoW) (*BlockChain, error) {
+	return NewBlockChainWithEngine(chainDb, NewPowEngine(pow))
+}
+
+// NewBlockChainWithEngine returns a fully initialised block chain sealed by the
+// given consensus engine instead of proof-of-work.
+func NewBlockChainWithEngine(chainDb ethdb.Database, engine Engine) (*BlockChain, error) {
 	bodyCache, _ := lru.New(bodyCacheLimit)
 	bodyRLPCache, _ := lru.New(bodyCacheLimit)
-	tdCache, _ := lru.New(tdCacheLimit)
 	blockCache, _ := lru.New(blockCacheLimit)
+	receiptCache, _ := lru.New(receiptCacheLimit)
 	futureBlocks, _ := lru.New(maxFutureBlocks)
+	propDelays, _ := lru.New(propagationCacheLimit)
 
 	bc := &BlockChain{
 		chainDb:      chainDb,
-		eventMux:     mux,
 		quit:         make(chan struct{}),
-		headerCache:  headerCache,
 		bodyCache:    bodyCache,
 		bodyRLPCache: bodyRLPCache,
-		tdCache:      tdCache,
 		blockCache:   blockCache,
+		receiptCache: receiptCache,
 		futureBlocks: futureBlocks,
-		pow:          pow,
+		propDelays:   propDelays,
+		engine:       engine,
 	}
-	// Seed a fast but crypto originating random generator
-	seed, err := crand.Int(crand.Reader, big.NewInt(math.MaxInt64))
+	bc.SetValidator(NewBlockValidator(bc, engine))
+	bc.SetProcessor(NewStateProcessor(bc))
+
+	gv := func() HeaderValidator { return bc.Validator() }
+	var err error
+	bc.hc, err = NewHeaderChain(chainDb, gv, bc.getProcInterrupt)
 	if err != nil {
 		return nil, err
 	}
-	bc.rand = mrand.New(mrand.NewSource(seed.Int64()))
-	bc.SetValidator(NewBlockValidator(bc, pow))
-	bc.SetProcessor(NewStateProcessor(bc))
-
 	bc.genesisBlock = bc.GetBlockByNumber(0)
 	if bc.genesisBlock == nil {
-		reader, err := NewDefaultGenesisReader()
-		if err != nil {
-			return nil, err
-		}
-		bc.genesisBlock, err = WriteGenesisBlock(chainDb, reader)
-		if err != nil {
-			return nil, err
-		}
-		glog.V(logger.Info).Infoln("WARNING: Wrote default expanse genesis block")
+		return nil, ErrNoGenesis
+	}
+	// Private chains may configure protocol parameters in their genesis, which
+	// the blocks must be validated with.
+	if config := GetChainConfig(chainDb, bc.genesisBlock.Hash()); config != nil {
+		config.Apply()
 	}
 	if err := bc.loadLastState(); err != nil {
 		return nil, err
@@ -175,49 +202,105 @@ func NewBlockChain(chainDb ethdb.Database, pow pow.PoW, mux *event.TypeMux) (*Bl
 	return bc, nil
 }
 
-// loadLastState loads the last known chain state from the database. This method
-// assumes that the chain manager mutex is held.
+func (self *BlockChain) getProcInterrupt() bool {
+	return atomic.LoadInt32(&self.procInterrupt) == 1
+}
+
+// loadLastState loads the last known chain state from the database. Heads left
+// pointing at partially written data by an unclean shutdown are rewound to the
+// last fully stored block. This method assumes that the chain manager mutex is
+// held.
 func (self *BlockChain) loadLastState() error {
 	// Restore the last known head block
 	head := GetHeadBlockHash(self.chainDb)
 	if head == (common.Hash{}) {
 		// Corrupt or empty database, init from scratch
 		self.Reset()
+	} else if self.completeBlock(head) {
+		// Block found, set as the current head
+		self.currentBlock = self.GetBlock(head)
 	} else {
-		if block := self.GetBlock(head); block != nil {
-			// Block found, set as the current head
-			self.currentBlock = block
-		} else {
-			// Corrupt or empty database, init from scratch
-			self.Reset()
+		// Block partially written, rewind to the last complete one
+		block, err := self.lastCompleteBlock(head)
+		if err != nil {
+			return err
+		}
+		glog.V(logger.Warn).Infof("Head block [%x…] incomplete, rewinding to #%d [%x…]", head[:4], block.Number(), block.Hash().Bytes()[:4])
+		if err := WriteHeadBlockHash(self.chainDb, block.Hash()); err != nil {
+			return err
 		}
+		self.currentBlock = block
 	}
-	// Restore the last known head header
-	self.currentHeader = self.currentBlock.Header()
+	// Restore the last known head header, skipping headers without a total difficulty
+	currentHeader := self.currentBlock.Header()
 	if head := GetHeadHeaderHash(self.chainDb); head != (common.Hash{}) {
-		if header := self.GetHeader(head); header != nil {
-			self.currentHeader = header
+		for header := self.GetHeader(head); header != nil && header.Number.Cmp(currentHeader.Number) > 0; header = self.GetHeader(header.ParentHash) {
+			if self.GetTd(header.Hash()) != nil {
+				currentHeader = header
+				break
+			}
+		}
+		if currentHeader.Hash() != head {
+			glog.V(logger.Warn).Infof("Head header [%x…] incomplete, rewinding to #%d [%x…]", head[:4], currentHeader.Number, currentHeader.Hash().Bytes()[:4])
 		}
 	}
-	// Restore the last known head fast block
+	self.hc.SetCurrentHeader(currentHeader)
+	// Restore the last known head fast block, skipping blocks without a total difficulty
 	self.currentFastBlock = self.currentBlock
 	if head := GetHeadFastBlockHash(self.chainDb); head != (common.Hash{}) {
-		if block := self.GetBlock(head); block != nil {
-			self.currentFastBlock = block
+		for block := self.GetBlock(head); block != nil && block.NumberU64() > self.currentBlock.NumberU64(); block = self.GetBlock(block.ParentHash()) {
+			if self.GetTd(block.Hash()) != nil {
+				self.currentFastBlock = block
+				break
+			}
+		}
+		if self.currentFastBlock.Hash() != head {
+			glog.V(logger.Warn).Infof("Head fast block [%x…] incomplete, rewinding to #%d [%x…]", head[:4], self.currentFastBlock.Number(), self.currentFastBlock.Hash().Bytes()[:4])
+			if err := WriteHeadFastBlockHash(self.chainDb, self.currentFastBlock.Hash()); err != nil {
+				return err
+			}
 		}
 	}
 	// Issue a status log and return
-	headerTd := self.GetTd(self.currentHeader.Hash())
+	headerTd := self.GetTd(currentHeader.Hash())
 	blockTd := self.GetTd(self.currentBlock.Hash())
 	fastTd := self.GetTd(self.currentFastBlock.Hash())
 
-	glog.V(logger.Info).Infof("Last header: #%d [%x…] TD=%v", self.currentHeader.Number, self.currentHeader.Hash().Bytes()[:4], headerTd)
+	glog.V(logger.Info).Infof("Last header: #%d [%x…] TD=%v", currentHeader.Number, currentHeader.Hash().Bytes()[:4], headerTd)
 	glog.V(logger.Info).Infof("Last block: #%d [%x…] TD=%v", self.currentBlock.Number(), self.currentBlock.Hash().Bytes()[:4], blockTd)
 	glog.V(logger.Info).Infof("Fast block: #%d [%x…] TD=%v", self.currentFastBlock.Number(), self.currentFastBlock.Hash().Bytes()[:4], fastTd)
 
 	return nil
 }
 
+// completeBlock returns whether the block with the given hash is fully stored:
+// its header, body, total difficulty and state.
+func (self *BlockChain) completeBlock(hash common.Hash) bool {
+	return self.HasBlockAndState(hash) && self.GetTd(hash) != nil
+}
+
+// lastCompleteBlock returns the highest fully stored canonical block below the
+// given head, searching at most maxRewindDepth blocks. If the head isn't stored
+// at all, the search starts at the head header. Failing to find one is an error
+// rather than a reason to reset the chain: below the pivot of a fast sync no
+// block has its state, and the database may need manual repair.
+func (self *BlockChain) lastCompleteBlock(head common.Hash) (*types.Block, error) {
+	header := GetHeader(self.chainDb, head)
+	if header == nil {
+		header = GetHeader(self.chainDb, GetHeadHeaderHash(self.chainDb))
+	}
+	if header == nil {
+		return nil, fmt.Errorf("head block [%x…] and head header missing", head[:4])
+	}
+	number := header.Number.Uint64()
+	for depth := uint64(0); depth <= maxRewindDepth && depth <= number; depth++ {
+		if hash := GetCanonicalHash(self.chainDb, number-depth); hash != head && self.completeBlock(hash) {
+			return self.GetBlock(hash), nil
+		}
+	}
+	return nil, fmt.Errorf("head block [%x…] #%d incomplete, no complete block within %d blocks below it", head[:4], number, maxRewindDepth)
+}
+
 // SetHead rewinds the local chain to a new head. In the case of headers, everything
 // above the new head will be deleted and the new one set. In the case of blocks
 // though, the head may be further rewound if block bodies are missing (non-archive
@@ -226,71 +309,36 @@ func (bc *BlockChain) SetHead(head uint64) {
 	bc.mu.Lock()
 	defer bc.mu.Unlock()
 
-	// Figure out the highest known canonical headers and/or blocks
-	height := uint64(0)
-	if bc.currentHeader != nil {
-		if hh := bc.currentHeader.Number.Uint64(); hh > height {
-			height = hh
-		}
-	}
-	if bc.currentBlock != nil {
-		if bh := bc.currentBlock.NumberU64(); bh > height {
-			height = bh
-		}
-	}
-	if bc.currentFastBlock != nil {
-		if fbh := bc.currentFastBlock.NumberU64(); fbh > height {
-			height = fbh
-		}
-	}
-	// Gather all the hashes that need deletion
-	drop := make(map[common.Hash]struct{})
-
-	for bc.currentHeader != nil && bc.currentHeader.Number.Uint64() > head {
-		drop[bc.currentHeader.Hash()] = struct{}{}
-		bc.currentHeader = bc.GetHeader(bc.currentHeader.ParentHash)
-	}
+	// Rewind the block heads first, their ancestors are looked up through the
+	// headers that the header chain is about to delete
 	for bc.currentBlock != nil && bc.currentBlock.NumberU64() > head {
-		drop[bc.currentBlock.Hash()] = struct{}{}
 		bc.currentBlock = bc.GetBlock(bc.currentBlock.ParentHash())
 	}
 	for bc.currentFastBlock != nil && bc.currentFastBlock.NumberU64() > head {
-		drop[bc.currentFastBlock.Hash()] = struct{}{}
 		bc.currentFastBlock = bc.GetBlock(bc.currentFastBlock.ParentHash())
 	}
-	// Roll back the canonical chain numbering
-	for i := height; i > head; i-- {
-		DeleteCanonicalHash(bc.chainDb, i)
-	}
-	// Delete everything found by the above rewind
-	for hash, _ := range drop {
-		DeleteHeader(bc.chainDb, hash)
+	delFn := func(hash common.Hash) {
 		DeleteBody(bc.chainDb, hash)
-		DeleteTd(bc.chainDb, hash)
 	}
+	bc.hc.SetHead(head, delFn)
+
 	// Clear out any stale content from the caches
-	bc.headerCache.Purge()
 	bc.bodyCache.Purge()
 	bc.bodyRLPCache.Purge()
 	bc.blockCache.Purge()
+	bc.receiptCache.Purge()
 	bc.futureBlocks.Purge()
 
 	// Update all computed fields to the new head
 	if bc.currentBlock == nil {
 		bc.currentBlock = bc.genesisBlock
 	}
-	if bc.currentHeader == nil {
-		bc.currentHeader = bc.genesisBlock.Header()
-	}
 	if bc.currentFastBlock == nil {
 		bc.currentFastBlock = bc.genesisBlock
 	}
 	if err := WriteHeadBlockHash(bc.chainDb, bc.currentBlock.Hash()); err != nil {
 		glog.Fatalf("failed to reset head block hash: %v", err)
 	}
-	if err := WriteHeadHeaderHash(bc.chainDb, bc.currentHeader.Hash()); err != nil {
-		glog.Fatalf("failed to reset head header hash: %v", err)
-	}
 	if err := WriteHeadFastBlockHash(bc.chainDb, bc.currentFastBlock.Hash()); err != nil {
 		glog.Fatalf("failed to reset head fast block hash: %v", err)
 	}
@@ -339,7 +387,7 @@ func (self *BlockChain) CurrentHeader() *types.Header {
 	self.mu.RLock()
 	defer self.mu.RUnlock()
 
-	return self.currentHeader
+	return self.hc.CurrentHeader()
 }
 
 // CurrentBlock retrieves the current head block of the canonical chain. The
@@ -397,8 +445,8 @@ func (self *BlockChain) Processor() Processor {
 	return self.processor
 }
 
-// AuxValidator returns the auxiliary validator (Proof of work atm)
-func (self *BlockChain) AuxValidator() pow.PoW { return self.pow }
+// Engine returns the consensus engine sealing the blocks of the chain.
+func (self *BlockChain) Engine() Engine { return self.engine }
 
 // State returns a new mutable state based on the current HEAD block.
 func (self *BlockChain) State() (*state.StateDB, error) {
@@ -420,7 +468,7 @@ func (bc *BlockChain) ResetWithGenesisBlock(genesis *types.Block) {
 	defer bc.mu.Unlock()
 
 	// Prepare the genesis block and reinitialise the chain
-	if err := WriteTd(bc.chainDb, genesis.Hash(), genesis.Difficulty()); err != nil {
+	if err := bc.hc.WriteTd(genesis.Hash(), genesis.Difficulty()); err != nil {
 		glog.Fatalf("failed to write genesis block TD: %v", err)
 	}
 	if err := WriteBlock(bc.chainDb, genesis); err != nil {
@@ -429,7 +477,8 @@ func (bc *BlockChain) ResetWithGenesisBlock(genesis *types.Block) {
 	bc.genesisBlock = genesis
 	bc.insert(bc.genesisBlock)
 	bc.currentBlock = bc.genesisBlock
-	bc.currentHeader = bc.genesisBlock.Header()
+	bc.hc.SetGenesis(bc.genesisBlock.Header())
+	bc.hc.SetCurrentHeader(bc.genesisBlock.Header())
 	bc.currentFastBlock = bc.genesisBlock
 }
 
@@ -443,26 +492,26 @@ func (self *BlockChain) Export(w io.Writer) error {
 
 // ExportN writes a subset of the active chain to the given writer.
 func (self *BlockChain) ExportN(w io.Writer, first uint64, last uint64) error {
-	self.mu.RLock()
-	defer self.mu.RUnlock()
-
 	if first > last {
 		return fmt.Errorf("export failed: first (%d) is greater than last (%d)", first, last)
 	}
 
 	glog.V(logger.Info).Infof("exporting %d blocks...\n", last-first+1)
 
-	for nr := first; nr <= last; nr++ {
-		block := self.GetBlockByNumber(nr)
-		if block == nil {
-			return fmt.Errorf("export failed on #%d: not found", nr)
-		}
+	it, err := self.NewIterator(first, last, 0, false)
+	if err != nil {
+		return err
+	}
+	defer it.Release()
 
-		if err := block.EncodeRLP(w); err != nil {
+	for it.Next() {
+		if err := it.Item().Block.EncodeRLP(w); err != nil {
 			return err
 		}
 	}
-
+	if err := it.Err(); err != nil {
+		return fmt.Errorf("export failed: %v", err)
+	}
 	return nil
 }
 
@@ -487,10 +536,7 @@ func (bc *BlockChain) insert(block *types.Block) {
 
 	// If the block is better than out head or is on a different chain, force update heads
 	if updateHeads {
-		if err := WriteHeadHeaderHash(bc.chainDb, block.Hash()); err != nil {
-			glog.Fatalf("failed to insert head header hash: %v", err)
-		}
-		bc.currentHeader = block.Header()
+		bc.hc.SetCurrentHeader(block.Header())
 
 		if err := WriteHeadFastBlockHash(bc.chainDb, block.Hash()); err != nil {
 			glog.Fatalf("failed to insert head fast block hash: %v", err)
@@ -507,33 +553,19 @@ func (bc *BlockChain) Genesis() *types.Block {
 // HasHeader checks if a block header is present in the database or not, caching
 // it if present.
 func (bc *BlockChain) HasHeader(hash common.Hash) bool {
-	return bc.GetHeader(hash) != nil
+	return bc.hc.HasHeader(hash)
 }
 
 // GetHeader retrieves a block header from the database by hash, caching it if
 // found.
 func (self *BlockChain) GetHeader(hash common.Hash) *types.Header {
-	// Short circuit if the header's already in the cache, retrieve otherwise
-	if header, ok := self.headerCache.Get(hash); ok {
-		return header.(*types.Header)
-	}
-	header := GetHeader(self.chainDb, hash)
-	if header == nil {
-		return nil
-	}
-	// Cache the found header for next time and return
-	self.headerCache.Add(header.Hash(), header)
-	return header
+	return self.hc.GetHeader(hash)
 }
 
 // GetHeaderByNumber retrieves a block header from the database by number,
 // caching it (associated with its hash) if found.
 func (self *BlockChain) GetHeaderByNumber(number uint64) *types.Header {
-	hash := GetCanonicalHash(self.chainDb, number)
-	if hash == (common.Hash{}) {
-		return nil
-	}
-	return self.GetHeader(hash)
+	return self.hc.GetHeaderByNumber(number)
 }
 
 // GetBody retrieves a block body (transactions and uncles) from the database by
@@ -572,17 +604,23 @@ func (self *BlockChain) GetBodyRLP(hash common.Hash) rlp.RawValue {
 // GetTd retrieves a block's total difficulty in the canonical chain from the
 // database by hash, caching it if found.
 func (self *BlockChain) GetTd(hash common.Hash) *big.Int {
-	// Short circuit if the td's already in the cache, retrieve otherwise
-	if cached, ok := self.tdCache.Get(hash); ok {
-		return cached.(*big.Int)
+	return self.hc.GetTd(hash)
+}
+
+// GetBlockReceipts retrieves the receipts generated by the transactions of a
+// block from the database by hash, caching them if found.
+func (self *BlockChain) GetBlockReceipts(hash common.Hash) types.Receipts {
+	// Short circuit if the receipts are already in the cache, retrieve otherwise
+	if cached, ok := self.receiptCache.Get(hash); ok {
+		return cached.(types.Receipts)
 	}
-	td := GetTd(self.chainDb, hash)
-	if td == nil {
+	receipts := GetBlockReceipts(self.chainDb, hash)
+	if receipts == nil {
 		return nil
 	}
-	// Cache the found body for next time and return
-	self.tdCache.Add(hash, td)
-	return td
+	// Cache the found receipts for next time and return
+	self.receiptCache.Add(hash, receipts)
+	return receipts
 }
 
 // HasBlock checks if a block is fully present in the database or not, caching
@@ -632,23 +670,7 @@ func (self *BlockChain) GetBlockByNumber(number uint64) *types.Block {
 // GetBlockHashesFromHash retrieves a number of block hashes starting at a given
 // hash, fetching towards the genesis block.
 func (self *BlockChain) GetBlockHashesFromHash(hash common.Hash, max uint64) []common.Hash {
-	// Get the origin header from which to fetch
-	header := self.GetHeader(hash)
-	if header == nil {
-		return nil
-	}
-	// Iterate the headers until enough is collected or the genesis reached
-	chain := make([]common.Hash, 0, max)
-	for i := uint64(0); i < max; i++ {
-		if header = self.GetHeader(header.ParentHash); header == nil {
-			break
-		}
-		chain = append(chain, header.Hash())
-		if header.Number.Cmp(common.Big0) == 0 {
-			break
-		}
-	}
-	return chain
+	return self.hc.GetBlockHashesFromHash(hash, max)
 }
 
 // [deprecated by eth/62]
@@ -711,65 +733,6 @@ const (
 	SideStatTy
 )
 
-// writeHeader writes a header into the local chain, given that its parent is
-// already known. If the total difficulty of the newly inserted header becomes
-// greater than the current known TD, the canonical chain is re-routed.
-//
-// Note: This method is not concurrent-safe with inserting blocks simultaneously
-// into the chain, as side effects caused by reorganisations cannot be emulated
-// without the real blocks. Hence, writing headers directly should only be done
-// in two scenarios: pure-header mode of operation (light clients), or properly
-// separated header/block phases (non-archive clients).
-func (self *BlockChain) writeHeader(header *types.Header) error {
-	self.wg.Add(1)
-	defer self.wg.Done()
-
-	// Calculate the total difficulty of the header
-	ptd := self.GetTd(header.ParentHash)
-	if ptd == nil {
-		return ParentError(header.ParentHash)
-	}
-
-	localTd := self.GetTd(self.currentHeader.Hash())
-	externTd := new(big.Int).Add(header.Difficulty, ptd)
-
-	// Make sure no inconsistent state is leaked during insertion
-	self.mu.Lock()
-	defer self.mu.Unlock()
-
-	// If the total difficulty is higher than our known, add it to the canonical chain
-	// Second clause in the if statement reduces the vulnerability to selfish mining.
-	// Please refer to http://www.cs.cornell.edu/~ie53/publications/btcProcFC.pdf
-	if externTd.Cmp(localTd) > 0 || (externTd.Cmp(localTd) == 0 && mrand.Float64() < 0.5) {
-		// Delete any canonical number assignments above the new head
-		for i := header.Number.Uint64() + 1; GetCanonicalHash(self.chainDb, i) != (common.Hash{}); i++ {
-			DeleteCanonicalHash(self.chainDb, i)
-		}
-		// Overwrite any stale canonical number assignments
-		head := self.GetHeader(header.ParentHash)
-		for GetCanonicalHash(self.chainDb, head.Number.Uint64()) != head.Hash() {
-			WriteCanonicalHash(self.chainDb, head.Hash(), head.Number.Uint64())
-			head = self.GetHeader(head.ParentHash)
-		}
-		// Extend the canonical chain with the new header
-		if err := WriteCanonicalHash(self.chainDb, header.Hash(), header.Number.Uint64()); err != nil {
-			glog.Fatalf("failed to insert header number: %v", err)
-		}
-		if err := WriteHeadHeaderHash(self.chainDb, header.Hash()); err != nil {
-			glog.Fatalf("failed to insert head header hash: %v", err)
-		}
-		self.currentHeader = types.CopyHeader(header)
-	}
-	// Irrelevant of the canonical status, write the header itself to the database
-	if err := WriteTd(self.chainDb, header.Hash(), externTd); err != nil {
-		glog.Fatalf("failed to write header total difficulty: %v", err)
-	}
-	if err := WriteHeader(self.chainDb, header); err != nil {
-		glog.Fatalf("filed to write header contents: %v", err)
-	}
-	return nil
-}
-
 // InsertHeaderChain attempts to insert the given header chain in to the local
 // chain, possibly creating a reorg. If an error is returned, it will return the
 // index number of the failing header as well an error describing what went wrong.
@@ -786,109 +749,17 @@ func (self *BlockChain) InsertHeaderChain(chain []*types.Header, checkFreq int)
 	self.chainmu.Lock()
 	defer self.chainmu.Unlock()
 
-	// Collect some import statistics to report on
-	stats := struct{ processed, ignored int }{}
-	start := time.Now()
+	whFunc := func(header *types.Header) error {
+		// Make sure no inconsistent state is leaked during insertion
+		self.mu.Lock()
+		defer self.mu.Unlock()
 
-	// Generate the list of headers that should be POW verified
-	verify := make([]bool, len(chain))
-	for i := 0; i < len(verify)/checkFreq; i++ {
-		index := i*checkFreq + self.rand.Intn(checkFreq)
-		if index >= len(verify) {
-			index = len(verify) - 1
-		}
-		verify[index] = true
-	}
-	verify[len(verify)-1] = true // Last should always be verified to avoid junk
-
-	// Create the header verification task queue and worker functions
-	tasks := make(chan int, len(chain))
-	for i := 0; i < len(chain); i++ {
-		tasks <- i
+		return self.hc.WriteHeader(header)
 	}
-	close(tasks)
-
-	errs, failed := make([]error, len(tasks)), int32(0)
-	process := func(worker int) {
-		for index := range tasks {
-			header, hash := chain[index], chain[index].Hash()
-
-			// Short circuit insertion if shutting down or processing failed
-			if atomic.LoadInt32(&self.procInterrupt) == 1 {
-				return
-			}
-			if atomic.LoadInt32(&failed) > 0 {
-				return
-			}
-			// Short circuit if the header is bad or already known
-			if BadHashes[hash] {
-				errs[index] = BadHashError(hash)
-				atomic.AddInt32(&failed, 1)
-				return
-			}
-			if self.HasHeader(hash) {
-				continue
-			}
-			// Verify that the header honors the chain parameters
-			checkPow := verify[index]
-
-			var err error
-			if index == 0 {
-				err = self.Validator().ValidateHeader(header, self.GetHeader(header.ParentHash), checkPow)
-			} else {
-				err = self.Validator().ValidateHeader(header, chain[index-1], checkPow)
-			}
-			if err != nil {
-				errs[index] = err
-				atomic.AddInt32(&failed, 1)
-				return
-			}
-		}
-	}
-	// Start as many worker threads as goroutines allowed
-	pending := new(sync.WaitGroup)
-	for i := 0; i < runtime.GOMAXPROCS(0); i++ {
-		pending.Add(1)
-		go func(id int) {
-			defer pending.Done()
-			process(id)
-		}(i)
-	}
-	pending.Wait()
-
-	// If anything failed, report
-	if failed > 0 {
-		for i, err := range errs {
-			if err != nil {
-				return i, err
-			}
-		}
-	}
-	// All headers passed verification, import them into the database
-	for i, header := range chain {
-		// Short circuit insertion if shutting down
-		if atomic.LoadInt32(&self.procInterrupt) == 1 {
-			glog.V(logger.Debug).Infoln("premature abort during header chain processing")
-			break
-		}
-		hash := header.Hash()
-
-		// If the header's already known, skip it, otherwise store
-		if self.HasHeader(hash) {
-			stats.ignored++
-			continue
-		}
-		if err := self.writeHeader(header); err != nil {
-			return i, err
-		}
-		stats.processed++
+	if tracker, ok := self.engine.(HeaderTracker); ok {
+		tracker.TrackHeaders(chain)
 	}
-	// Report some public statistics so the user has a clue what's going on
-	first, last := chain[0], chain[len(chain)-1]
-	glog.V(logger.Info).Infof("imported %d header(s) (%d ignored) in %v. #%v [%x… / %x…]", stats.processed, stats.ignored,
-		time.Since(start), last.Number, first.Hash().Bytes()[:4], last.Hash().Bytes()[:4])
-
-	return 0, nil
+	return self.hc.InsertHeaderChain(chain, checkFreq, whFunc)
 }
 
 // Rollback is designed to remove a chain of links from the database that aren't
@@ -900,9 +771,8 @@ func (self *BlockChain) Rollback(chain []common.Hash) {
 	for i := len(chain) - 1; i >= 0; i-- {
 		hash := chain[i]
 
-		if self.currentHeader.Hash() == hash {
-			self.currentHeader = self.GetHeader(self.currentHeader.ParentHash)
-			WriteHeadHeaderHash(self.chainDb, self.currentHeader.Hash())
+		if currentHeader := self.hc.CurrentHeader(); currentHeader.Hash() == hash {
+			self.hc.SetCurrentHeader(self.GetHeader(currentHeader.ParentHash))
 		}
 		if self.currentFastBlock.Hash() == hash {
 			self.currentFastBlock = self.GetBlock(self.currentFastBlock.ParentHash())
@@ -955,6 +825,12 @@ func (self *BlockChain) InsertReceiptChain(blockChain types.Blocks, receiptChain
 				atomic.AddInt32(&stats.ignored, 1)
 				continue
 			}
+			// Reject receipts not matching the header before persisting anything
+			if err := ValidateReceipts(block, receipts); err != nil {
+				errs[index] = fmt.Errorf("block #%d [%x…]: %v", block.Number(), block.Hash().Bytes()[:4], err)
+				atomic.AddInt32(&failed, 1)
+				return
+			}
 			// Compute all the non-consensus fields of the receipts
 			transactions, logIndex := block.Transactions(), uint(0)
 			for j := 0; j < len(receipts); j++ {
@@ -1081,7 +957,7 @@ func (self *BlockChain) WriteBlock(block *types.Block) (status writeStatus, err
 		status = SideStatTy
 	}
 	// Irrelevant of the canonical status, write the block itself to the database
-	if err := WriteTd(self.chainDb, block.Hash(), externTd); err != nil {
+	if err := self.hc.WriteTd(block.Hash(), externTd); err != nil {
 		glog.Fatalf("failed to write block total difficulty: %v", err)
 	}
 	if err := WriteBlock(self.chainDb, block); err != nil {
@@ -1092,6 +968,28 @@ func (self *BlockChain) WriteBlock(block *types.Block) (status writeStatus, err
 	return
 }
 
+// ImportStats accumulates the time spent in the individual phases of block
+// insertion. It is used to benchmark chain imports.
+type ImportStats struct {
+	Blocks int // Number of blocks processed
+	Txs    int // Number of transactions processed
+
+	Validation time.Duration // Header and body validation
+	Senders    time.Duration // Transaction sender recovery
+	Execution  time.Duration // Transaction execution and state validation
+	Commit     time.Duration // State trie commit
+	Write      time.Duration // Block, receipt and lookup index writes
+}
+
+// SetImportStats sets the collector of block insertion phase timings. Passing
+// nil disables the collection.
+func (self *BlockChain) SetImportStats(stats *ImportStats) {
+	self.chainmu.Lock()
+	defer self.chainmu.Unlock()
+
+	self.importStats = stats
+}
+
 // InsertChain will attempt to insert the given chain in to the canonical chain or, otherwise, create a fork. It an error is returned
 // it will return the index number of the failing block as well an error describing what went wrong (for possible errors see core/errors.go).
 func (self *BlockChain) InsertChain(chain types.Blocks) (int, error) {
@@ -1111,10 +1009,20 @@ func (self *BlockChain) InsertChain(chain types.Blocks) (int, error) {
 		tstart        = time.Now()
 
 		nonceChecked = make([]bool, len(chain))
+
+		bench  = self.importStats
+		pstart time.Time // start of the current phase, only tracked when benchmarking
 	)
 
 	// Start the parallel nonce verifier.
-	nonceAbort, nonceResults := verifyNoncesFromBlocks(self.pow, chain)
+	if tracker, ok := self.engine.(HeaderTracker); ok {
+		headers := make([]*types.Header, len(chain))
+		for i, block := range chain {
+			headers[i] = block.Header()
+		}
+		tracker.TrackHeaders(headers)
+	}
+	nonceAbort, nonceResults := verifyNoncesFromBlocks(self.engine, chain)
 	defer close(nonceAbort)
 
 	txcount := 0
@@ -1131,8 +1039,7 @@ func (self *BlockChain) InsertChain(chain types.Blocks) (int, error) {
 			r := <-nonceResults
 			nonceChecked[r.index] = true
 			if !r.valid {
-				block := chain[r.index]
-				return r.index, &BlockNonceErr{Hash: block.Hash(), Number: block.Number(), Nonce: block.Nonce()}
+				return r.index, r.err
 			}
 		}
 
@@ -1143,7 +1050,13 @@ func (self *BlockChain) InsertChain(chain types.Blocks) (int, error) {
 		}
 		// Stage 1 validation of the block using the chain's validator
 		// interface.
+		if bench != nil {
+			pstart = time.Now()
+		}
 		err := self.Validator().ValidateBlock(block)
+		if bench != nil {
+			bench.Validation += time.Since(pstart)
+		}
 		if err != nil {
 			if IsKnownBlockErr(err) {
 				stats.ignored++
@@ -1151,14 +1064,9 @@ func (self *BlockChain) InsertChain(chain types.Blocks) (int, error) {
 			}
 
 			if err == BlockFutureErr {
-				// Allow up to MaxFuture second in the future blocks. If this limit
-				// is exceeded the chain is discarded and processed at a later time
-				// if given.
-				max := big.NewInt(time.Now().Unix() + maxTimeFutureBlocks)
-				if block.Time().Cmp(max) == 1 {
-					return i, fmt.Errorf("%v: BlockFutureErr, %v > %v", BlockFutureErr, block.Time(), max)
-				}
-
+				// Blocks up to MaxFutureDrift seconds in the future are queued,
+				// anything beyond is rejected by the validator already and the
+				// chain is discarded and processed at a later time if given.
 				self.futureBlocks.Add(block.Hash(), block)
 				stats.queued++
 				continue
@@ -1175,6 +1083,17 @@ func (self *BlockChain) InsertChain(chain types.Blocks) (int, error) {
 			return i, err
 		}
 
+		// When benchmarking, recover the transaction senders up front so that
+		// the signature checks aren't accounted to the execution. The senders
+		// are cached in the transactions and reused by the processor.
+		if bench != nil {
+			pstart = time.Now()
+			for _, tx := range block.Transactions() {
+				tx.From()
+			}
+			bench.Senders += time.Since(pstart)
+			pstart = time.Now()
+		}
 		// Create a new statedb using the parent block and report an
 		// error if it fails.
 		statedb, err := state.New(self.GetBlock(block.ParentHash()).Root(), self.chainDb)
@@ -1195,10 +1114,18 @@ func (self *BlockChain) InsertChain(chain types.Blocks) (int, error) {
 			return i, err
 		}
 		// Write state changes to database
+		if bench != nil {
+			bench.Execution += time.Since(pstart)
+			pstart = time.Now()
+		}
 		_, err = statedb.Commit()
 		if err != nil {
 			return i, err
 		}
+		if bench != nil {
+			bench.Commit += time.Since(pstart)
+			pstart = time.Now()
+		}
 
 		// coalesce logs for later processing
 		coalescedLogs = append(coalescedLogs, logs...)
@@ -1206,6 +1133,11 @@ func (self *BlockChain) InsertChain(chain types.Blocks) (int, error) {
 		if err := WriteBlockReceipts(self.chainDb, block.Hash(), receipts); err != nil {
 			return i, err
 		}
+		if RecordInternalTxs {
+			if err := WriteInternalTxs(self.chainDb, block.Hash(), receipts); err != nil {
+				return i, err
+			}
+		}
 
 		txcount += len(block.Transactions())
 		// write the block to the chain and get the status
@@ -1213,6 +1145,7 @@ func (self *BlockChain) InsertChain(chain types.Blocks) (int, error) {
 		if err != nil {
 			return i, err
 		}
+		self.recordPropagation(block)
 
 		switch status {
 		case CanonStatTy:
@@ -1238,9 +1171,11 @@ func (self *BlockChain) InsertChain(chain types.Blocks) (int, error) {
 				glog.Infof("inserted forked block #%d (TD=%v) (%d TXs %d UNCs) (%x...). Took %v\n", block.Number(), block.Difficulty(), len(block.Transactions()), len(block.Uncles()), block.Hash().Bytes()[0:4], time.Since(bstart))
 			}
 			events = append(events, ChainSideEvent{block, logs})
-
-		case SplitStatTy:
-			events = append(events, ChainSplitEvent{block, logs})
+		}
+		if bench != nil {
+			bench.Write += time.Since(pstart)
+			bench.Blocks++
+			bench.Txs += len(block.Transactions())
 		}
 		stats.processed++
 	}
@@ -1250,7 +1185,7 @@ func (self *BlockChain) InsertChain(chain types.Blocks) (int, error) {
 		start, end := chain[0], chain[len(chain)-1]
 		glog.Infof("imported %d block(s) (%d queued %d ignored) including %d txs in %v. #%v [%x / %x]\n", stats.processed, stats.queued, stats.ignored, txcount, tend, end.Number(), start.Hash().Bytes()[:4], end.Hash().Bytes()[:4])
 	}
-	go self.postChainEvents(events, coalescedLogs)
+	self.PostChainEvents(events, coalescedLogs)
 
 	return 0, nil
 }
@@ -1265,6 +1200,18 @@ func (self *BlockChain) reorg(oldBlock, newBlock *types.Block) error {
 		oldStart    = oldBlock
 		newStart    = newBlock
 		deletedTxs  types.Transactions
+		deletedLogs vm.Logs
+		// collectLogs collects the logs of a block being dropped from the
+		// canonical chain, marked as removed.
+		collectLogs = func(h common.Hash) {
+			for _, receipt := range GetBlockReceipts(self.chainDb, h) {
+				for _, log := range receipt.Logs {
+					removed := *log
+					removed.Removed = true
+					deletedLogs = append(deletedLogs, &removed)
+				}
+			}
+		}
 	)
 
 	// first reduce whoever is higher bound
@@ -1272,6 +1219,7 @@ func (self *BlockChain) reorg(oldBlock, newBlock *types.Block) error {
 		// reduce old chain
 		for oldBlock = oldBlock; oldBlock != nil && oldBlock.NumberU64() != newBlock.NumberU64(); oldBlock = self.GetBlock(oldBlock.ParentHash()) {
 			deletedTxs = append(deletedTxs, oldBlock.Transactions()...)
+			collectLogs(oldBlock.Hash())
 		}
 	} else {
 		// reduce new chain and append new chain blocks for inserting later on
@@ -1294,6 +1242,7 @@ func (self *BlockChain) reorg(oldBlock, newBlock *types.Block) error {
 		}
 		newChain = append(newChain, newBlock)
 		deletedTxs = append(deletedTxs, oldBlock.Transactions()...)
+		collectLogs(oldBlock.Hash())
 
 		oldBlock, newBlock = self.GetBlock(oldBlock.ParentHash()), self.GetBlock(newBlock.ParentHash())
 		if oldBlock == nil {
@@ -1339,31 +1288,74 @@ func (self *BlockChain) reorg(oldBlock, newBlock *types.Block) error {
 		DeleteReceipt(self.chainDb, tx.Hash())
 		DeleteTransaction(self.chainDb, tx.Hash())
 	}
-	// Must be posted in a goroutine because of the transaction pool trying
-	// to acquire the chain manager lock
-	go self.eventMux.Post(RemovedTransactionEvent{diff})
+	self.rmTxFeed.Send(RemovedTransactionEvent{diff})
+	if len(deletedLogs) > 0 {
+		self.rmLogsFeed.Send(RemovedLogsEvent{deletedLogs})
+	}
 
 	return nil
 }
 
-// postChainEvents iterates over the events generated by a chain insertion and
-// posts them into the event mux.
-func (self *BlockChain) postChainEvents(events []interface{}, logs vm.Logs) {
+// PostChainEvents sends the events generated by a chain insertion to the
+// subscribers of the chain feeds. It is exported for the miner, which writes
+// the blocks it seals without going through InsertChain.
+func (self *BlockChain) PostChainEvents(events []interface{}, logs vm.Logs) {
 	// post event logs for further processing
-	self.eventMux.Post(logs)
+	if len(logs) > 0 {
+		self.logsFeed.Send(logs)
+	}
 	for _, event := range events {
-		if event, ok := event.(ChainEvent); ok {
+		switch ev := event.(type) {
+		case ChainEvent:
 			// We need some control over the mining operation. Acquiring locks and waiting for the miner to create new block takes too long
 			// and in most cases isn't even necessary.
-			if self.LastBlockHash() == event.Hash {
-				self.eventMux.Post(ChainHeadEvent{event.Block})
+			if self.LastBlockHash() == ev.Hash {
+				self.chainHeadFeed.Send(ChainHeadEvent{ev.Block})
 			}
+			self.chainFeed.Send(ev)
+		case ChainSideEvent:
+			self.chainSideFeed.Send(ev)
 		}
-		// Fire the insertion events individually too
-		self.eventMux.Post(event)
 	}
 }
 
+// SubscribeChainEvent registers a subscription of ChainEvent, sent for every
+// block added to the canonical chain.
+func (self *BlockChain) SubscribeChainEvent(ch chan<- ChainEvent) event.FeedSubscription {
+	return self.chainFeed.Subscribe(ch)
+}
+
+// SubscribeChainHeadEvent registers a subscription of ChainHeadEvent, sent
+// whenever the head of the canonical chain changes.
+func (self *BlockChain) SubscribeChainHeadEvent(ch chan<- ChainHeadEvent) event.FeedSubscription {
+	return self.chainHeadFeed.Subscribe(ch)
+}
+
+// SubscribeChainSideEvent registers a subscription of ChainSideEvent, sent for
+// every block stored on a side chain.
+func (self *BlockChain) SubscribeChainSideEvent(ch chan<- ChainSideEvent) event.FeedSubscription {
+	return self.chainSideFeed.Subscribe(ch)
+}
+
+// SubscribeLogsEvent registers a subscription of the logs generated by the
+// blocks added to the canonical chain.
+func (self *BlockChain) SubscribeLogsEvent(ch chan<- vm.Logs) event.FeedSubscription {
+	return self.logsFeed.Subscribe(ch)
+}
+
+// SubscribeRemovedTransactionEvent registers a subscription of
+// RemovedTransactionEvent, sent when a reorg drops transactions from the
+// canonical chain.
+func (self *BlockChain) SubscribeRemovedTransactionEvent(ch chan<- RemovedTransactionEvent) event.FeedSubscription {
+	return self.rmTxFeed.Subscribe(ch)
+}
+
+// SubscribeRemovedLogsEvent registers a subscription of RemovedLogsEvent, sent
+// when a reorg drops logs from the canonical chain.
+func (self *BlockChain) SubscribeRemovedLogsEvent(ch chan<- RemovedLogsEvent) event.FeedSubscription {
+	return self.rmLogsFeed.Subscribe(ch)
+}
+
 func (self *BlockChain) update() {
 	futureTimer := time.Tick(5 * time.Second)
 	for {
@@ -24,18 +24,19 @@ import (
 	"path/filepath"
 	"runtime"
 	"strconv"
+	"strings"
 	"testing"
+	"time"
 
-	"github.com/expanse-project/ethash"
 	"github.com/expanse-project/go-expanse/common"
 	"github.com/expanse-project/go-expanse/core/state"
 	"github.com/expanse-project/go-expanse/core/types"
 	"github.com/expanse-project/go-expanse/core/vm"
 	"github.com/expanse-project/go-expanse/crypto"
 	"github.com/expanse-project/go-expanse/ethdb"
-	"github.com/expanse-project/go-expanse/event"
 	"github.com/expanse-project/go-expanse/params"
 	"github.com/expanse-project/go-expanse/pow"
+	"github.com/expanse-project/go-expanse/pow/ethash"
 	"github.com/expanse-project/go-expanse/rlp"
 	"github.com/hashicorp/golang-lru"
 )
@@ -50,9 +51,8 @@ func thePow() pow.PoW {
 }
 
 func theBlockChain(db ethdb.Database, t *testing.T) *BlockChain {
-	var eventMux event.TypeMux
 	WriteTestNetGenesisBlock(db, 0)
-	blockchain, err := NewBlockChain(db, thePow(), &eventMux)
+	blockchain, err := NewBlockChain(db, thePow())
 	if err != nil {
 		t.Error("failed creating blockchain:", err)
 		t.FailNow()
@@ -211,6 +211,33 @@ func TestLastBlock(t *testing.T) {
 	}
 }
 
+// Tests that block receipts are served from the cache once retrieved and that
+// the cache is purged when the chain is rewound.
+func TestBlockReceiptCache(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+	bchain := theBlockChain(db, t)
+
+	hash := common.BytesToHash([]byte("block"))
+	if receipts := bchain.GetBlockReceipts(hash); receipts != nil {
+		t.Fatalf("unexpected receipts for unknown block: %v", receipts)
+	}
+	receipts := types.Receipts{types.NewReceipt(nil, big.NewInt(21000))}
+	if err := WriteBlockReceipts(db, hash, receipts); err != nil {
+		t.Fatalf("failed to write block receipts: %v", err)
+	}
+	if cached := bchain.GetBlockReceipts(hash); len(cached) != 1 {
+		t.Fatalf("receipt count mismatch: have %d, want 1", len(cached))
+	}
+	DeleteBlockReceipts(db, hash)
+	if cached := bchain.GetBlockReceipts(hash); len(cached) != 1 {
+		t.Fatalf("cached receipt count mismatch: have %d, want 1", len(cached))
+	}
+	bchain.SetHead(0)
+	if cached := bchain.GetBlockReceipts(hash); cached != nil {
+		t.Fatalf("unexpected receipts after rewind: %v", cached)
+	}
+}
+
 // Tests that given a starting canonical chain of a given size, it can be extended
 // with various length chains.
 func TestExtendCanonicalHeaders(t *testing.T) { testExtendCanonical(t, false) }
@@ -470,13 +497,13 @@ func makeBlockChainWithDiff(genesis *types.Block, d []int, seed byte) []*types.B
 }
 
 func chm(genesis *types.Block, db ethdb.Database) *BlockChain {
-	var eventMux event.TypeMux
-	bc := &BlockChain{chainDb: db, genesisBlock: genesis, eventMux: &eventMux, pow: FakePow{}, rand: rand.New(rand.NewSource(0))}
-	bc.headerCache, _ = lru.New(100)
+	bc := &BlockChain{chainDb: db, genesisBlock: genesis, engine: NewPowEngine(FakePow{})}
+	valFn := func() HeaderValidator { return bc.Validator() }
+	bc.hc, _ = NewHeaderChain(db, valFn, bc.getProcInterrupt)
 	bc.bodyCache, _ = lru.New(100)
 	bc.bodyRLPCache, _ = lru.New(100)
-	bc.tdCache, _ = lru.New(100)
 	bc.blockCache, _ = lru.New(100)
+	bc.receiptCache, _ = lru.New(100)
 	bc.futureBlocks, _ = lru.New(100)
 	bc.SetValidator(bproc{})
 	bc.SetProcessor(bproc{})
@@ -607,7 +634,7 @@ func testReorgBadHashes(t *testing.T, full bool) {
 		defer func() { delete(BadHashes, headers[3].Hash()) }()
 	}
 	// Create a new chain manager and check it rolled back the state
-	ncm, err := NewBlockChain(db, FakePow{}, new(event.TypeMux))
+	ncm, err := NewBlockChain(db, FakePow{})
 	if err != nil {
 		t.Fatalf("failed to create new chain manager: %v", err)
 	}
@@ -650,7 +677,7 @@ func testInsertNonceError(t *testing.T, full bool) {
 			failNum = blocks[failAt].NumberU64()
 			failHash = blocks[failAt].Hash()
 
-			blockchain.pow = failPow{failNum}
+			blockchain.engine = NewPowEngine(failPow{failNum})
 
 			failRes, err = blockchain.InsertChain(blocks)
 		} else {
@@ -660,8 +687,8 @@ func testInsertNonceError(t *testing.T, full bool) {
 			failNum = headers[failAt].Number.Uint64()
 			failHash = headers[failAt].Hash()
 
-			blockchain.pow = failPow{failNum}
-			blockchain.validator = NewBlockValidator(blockchain, failPow{failNum})
+			blockchain.engine = NewPowEngine(failPow{failNum})
+			blockchain.validator = NewBlockValidator(blockchain, NewPowEngine(failPow{failNum}))
 
 			failRes, err = blockchain.InsertHeaderChain(headers, 1)
 		}
@@ -727,7 +754,7 @@ func TestFastVsFullChains(t *testing.T) {
 	archiveDb, _ := ethdb.NewMemDatabase()
 	WriteGenesisBlockForTesting(archiveDb, GenesisAccount{address, funds})
 
-	archive, _ := NewBlockChain(archiveDb, FakePow{}, new(event.TypeMux))
+	archive, _ := NewBlockChain(archiveDb, FakePow{})
 
 	if n, err := archive.InsertChain(blocks); err != nil {
 		t.Fatalf("failed to process block %d: %v", n, err)
@@ -735,7 +762,7 @@ func TestFastVsFullChains(t *testing.T) {
 	// Fast import the chain as a non-archive node to test
 	fastDb, _ := ethdb.NewMemDatabase()
 	WriteGenesisBlockForTesting(fastDb, GenesisAccount{address, funds})
-	fast, _ := NewBlockChain(fastDb, FakePow{}, new(event.TypeMux))
+	fast, _ := NewBlockChain(fastDb, FakePow{})
 
 	headers := make([]*types.Header, len(blocks))
 	for i, block := range blocks {
@@ -811,7 +838,7 @@ func TestLightVsFastVsFullChainHeads(t *testing.T) {
 	archiveDb, _ := ethdb.NewMemDatabase()
 	WriteGenesisBlockForTesting(archiveDb, GenesisAccount{address, funds})
 
-	archive, _ := NewBlockChain(archiveDb, FakePow{}, new(event.TypeMux))
+	archive, _ := NewBlockChain(archiveDb, FakePow{})
 
 	if n, err := archive.InsertChain(blocks); err != nil {
 		t.Fatalf("failed to process block %d: %v", n, err)
@@ -823,7 +850,7 @@ func TestLightVsFastVsFullChainHeads(t *testing.T) {
 	// Import the chain as a non-archive node and ensure all pointers are updated
 	fastDb, _ := ethdb.NewMemDatabase()
 	WriteGenesisBlockForTesting(fastDb, GenesisAccount{address, funds})
-	fast, _ := NewBlockChain(fastDb, FakePow{}, new(event.TypeMux))
+	fast, _ := NewBlockChain(fastDb, FakePow{})
 
 	headers := make([]*types.Header, len(blocks))
 	for i, block := range blocks {
@@ -842,7 +869,7 @@ func TestLightVsFastVsFullChainHeads(t *testing.T) {
 	// Import the chain as a light node and ensure all pointers are updated
 	lightDb, _ := ethdb.NewMemDatabase()
 	WriteGenesisBlockForTesting(lightDb, GenesisAccount{address, funds})
-	light, _ := NewBlockChain(lightDb, FakePow{}, new(event.TypeMux))
+	light, _ := NewBlockChain(lightDb, FakePow{})
 
 	if n, err := light.InsertHeaderChain(headers, 1); err != nil {
 		t.Fatalf("failed to insert header %d: %v", n, err)
@@ -906,8 +933,7 @@ func TestChainTxReorgs(t *testing.T) {
 		}
 	})
 	// Import the chain. This runs all block validation rules.
-	evmux := &event.TypeMux{}
-	blockchain, _ := NewBlockChain(db, FakePow{}, evmux)
+	blockchain, _ := NewBlockChain(db, FakePow{})
 	if i, err := blockchain.InsertChain(chain); err != nil {
 		t.Fatalf("failed to insert original chain[%d]: %v", i, err)
 	}
@@ -963,3 +989,261 @@ func TestChainTxReorgs(t *testing.T) {
 		}
 	}
 }
+
+// Tests that the logs of blocks reorganised out of the chain are posted as removed.
+func TestLogReorgs(t *testing.T) {
+	params.MinGasLimit = big.NewInt(125000)      // Minimum the gas limit may ever be.
+	params.GenesisGasLimit = big.NewInt(3141592) // Gas limit of the Genesis block.
+
+	var (
+		key1, _ = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		addr1   = crypto.PubkeyToAddress(key1.PublicKey)
+		db, _   = ethdb.NewMemDatabase()
+		// this code emits an empty log when deployed
+		code = common.Hex2Bytes("60006000a0")
+	)
+	genesis := WriteGenesisBlockForTesting(db, GenesisAccount{addr1, big.NewInt(10000000000000)})
+
+	blockchain, _ := NewBlockChain(db, FakePow{})
+
+	rmLogsCh := make(chan RemovedLogsEvent, ChainChanSize)
+	sub := blockchain.SubscribeRemovedLogsEvent(rmLogsCh)
+	defer sub.Unsubscribe()
+
+	chain, _ := GenerateChain(genesis, db, 2, func(i int, gen *BlockGen) {
+		if i == 1 {
+			tx, err := types.NewContractCreation(gen.TxNonce(addr1), new(big.Int), big.NewInt(1000000), new(big.Int), code).SignECDSA(key1)
+			if err != nil {
+				t.Fatalf("failed to create tx: %v", err)
+			}
+			gen.AddTx(tx)
+		}
+	})
+	if _, err := blockchain.InsertChain(chain); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+
+	chain, _ = GenerateChain(genesis, db, 3, func(i int, gen *BlockGen) {})
+	if _, err := blockchain.InsertChain(chain); err != nil {
+		t.Fatalf("failed to insert forked chain: %v", err)
+	}
+
+	select {
+	case ev := <-rmLogsCh:
+		logs := ev.Logs
+		if len(logs) != 1 {
+			t.Fatalf("removed log count mismatch: have %d, want 1", len(logs))
+		}
+		if !logs[0].Removed {
+			t.Error("expected log to be marked as removed")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for removed logs event")
+	}
+}
+
+func TestImportStats(t *testing.T) {
+	var (
+		key, _ = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		addr   = crypto.PubkeyToAddress(key.PublicKey)
+		db, _  = ethdb.NewMemDatabase()
+	)
+	genesis := WriteGenesisBlockForTesting(db, GenesisAccount{addr, big.NewInt(10000000000000)})
+	blockchain, _ := NewBlockChain(db, FakePow{})
+
+	chain, _ := GenerateChain(genesis, db, 3, func(i int, gen *BlockGen) {
+		tx, err := types.NewTransaction(gen.TxNonce(addr), common.Address{0x01}, big.NewInt(1000), params.TxGas, nil, nil).SignECDSA(key)
+		if err != nil {
+			t.Fatalf("failed to create tx: %v", err)
+		}
+		gen.AddTx(tx)
+	})
+	// Insert the first block without statistics collection, the rest with it
+	if _, err := blockchain.InsertChain(chain[:1]); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	stats := new(ImportStats)
+	blockchain.SetImportStats(stats)
+	if _, err := blockchain.InsertChain(chain[1:]); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	if stats.Blocks != 2 || stats.Txs != 2 {
+		t.Errorf("import count mismatch: have %d blocks %d txs, want 2 blocks 2 txs", stats.Blocks, stats.Txs)
+	}
+	if stats.Validation <= 0 || stats.Senders <= 0 || stats.Execution <= 0 || stats.Commit <= 0 || stats.Write <= 0 {
+		t.Errorf("missing phase timings: %+v", *stats)
+	}
+}
+
+// Tests that fast sync receipts not matching their block headers are rejected
+// before being written into the database.
+func TestInsertReceiptChainValidation(t *testing.T) {
+	var (
+		gendb, _ = ethdb.NewMemDatabase()
+		key, _   = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		address  = crypto.PubkeyToAddress(key.PublicKey)
+		funds    = big.NewInt(1000000000)
+		genesis  = GenesisBlockForTesting(gendb, address, funds)
+		// this code emits an empty log when deployed
+		code = common.Hex2Bytes("60006000a0")
+	)
+	blocks, receipts := GenerateChain(genesis, gendb, 1, func(i int, gen *BlockGen) {
+		tx, err := types.NewContractCreation(gen.TxNonce(address), new(big.Int), big.NewInt(1000000), new(big.Int), code).SignECDSA(key)
+		if err != nil {
+			t.Fatalf("failed to create tx: %v", err)
+		}
+		gen.AddTx(tx)
+	})
+	if len(receipts[0]) != 1 || len(receipts[0][0].Logs) != 1 {
+		t.Fatalf("unexpected generated receipts: %v", receipts[0])
+	}
+	// Receipts stripped of their logs, and missing receipts
+	stripped := types.NewReceipt(receipts[0][0].PostState, receipts[0][0].CumulativeGasUsed)
+	stripped.Bloom = receipts[0][0].Bloom
+
+	tests := []struct {
+		name     string
+		receipts types.Receipts
+	}{
+		{"stripped logs", types.Receipts{stripped}},
+		{"missing receipt", types.Receipts{}},
+	}
+	for _, tt := range tests {
+		db, _ := ethdb.NewMemDatabase()
+		WriteGenesisBlockForTesting(db, GenesisAccount{address, funds})
+		chain, _ := NewBlockChain(db, FakePow{})
+		if _, err := chain.InsertHeaderChain([]*types.Header{blocks[0].Header()}, 1); err != nil {
+			t.Fatalf("%s: failed to insert header: %v", tt.name, err)
+		}
+		if _, err := chain.InsertReceiptChain(blocks, []types.Receipts{tt.receipts}); err == nil {
+			t.Errorf("%s: invalid receipts accepted", tt.name)
+		}
+		if chain.HasBlock(blocks[0].Hash()) || len(GetBlockReceipts(db, blocks[0].Hash())) != 0 {
+			t.Errorf("%s: invalid receipts persisted", tt.name)
+		}
+		// The valid receipts must still be accepted
+		if _, err := chain.InsertReceiptChain(blocks, receipts); err != nil {
+			t.Errorf("%s: valid receipts rejected: %v", tt.name, err)
+		}
+	}
+}
+
+// Tests that blocks containing transactions over the consensus size limit are
+// rejected.
+func TestInsertOversizedTransaction(t *testing.T) {
+	defer func(old uint64) { params.MaxTransactionSize = old }(params.MaxTransactionSize)
+
+	var (
+		gendb, _ = ethdb.NewMemDatabase()
+		key, _   = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		address  = crypto.PubkeyToAddress(key.PublicKey)
+		funds    = big.NewInt(1000000000)
+		genesis  = GenesisBlockForTesting(gendb, address, funds)
+	)
+	blocks, _ := GenerateChain(genesis, gendb, 1, func(i int, gen *BlockGen) {
+		tx, err := types.NewTransaction(gen.TxNonce(address), common.Address{}, new(big.Int), big.NewInt(100000), new(big.Int), make([]byte, 1024)).SignECDSA(key)
+		if err != nil {
+			t.Fatalf("failed to create tx: %v", err)
+		}
+		gen.AddTx(tx)
+	})
+	for _, limit := range []uint64{1024, 0} {
+		params.MaxTransactionSize = limit
+
+		db, _ := ethdb.NewMemDatabase()
+		WriteGenesisBlockForTesting(db, GenesisAccount{address, funds})
+		chain, _ := NewBlockChain(db, FakePow{})
+
+		_, err := chain.InsertChain(blocks)
+		if limit > 0 && err == nil {
+			t.Errorf("limit %d: oversized transaction accepted", limit)
+		}
+		if limit == 0 && err != nil {
+			t.Errorf("limit %d: block rejected: %v", limit, err)
+		}
+	}
+}
+
+// Tests that heads left pointing at partially written data by an unclean
+// shutdown are rewound to the last fully stored block on startup.
+func TestUncleanShutdownRecovery(t *testing.T) {
+	var (
+		gendb, _ = ethdb.NewMemDatabase()
+		key, _   = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		address  = crypto.PubkeyToAddress(key.PublicKey)
+		funds    = big.NewInt(1000000000)
+		genesis  = GenesisBlockForTesting(gendb, address, funds)
+	)
+	blocks, _ := GenerateChain(genesis, gendb, 10, func(i int, gen *BlockGen) {
+		gen.SetCoinbase(common.Address{byte(i)})
+	})
+	db, _ := ethdb.NewMemDatabase()
+	WriteGenesisBlockForTesting(db, GenesisAccount{address, funds})
+	chain, _ := NewBlockChain(db, FakePow{})
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	reopen := func(head uint64) *BlockChain {
+		chain, err := NewBlockChain(db, FakePow{})
+		if err != nil {
+			t.Fatalf("failed to reopen chain: %v", err)
+		}
+		if have := chain.CurrentBlock().NumberU64(); have != head {
+			t.Fatalf("head block mismatch: have #%d, want #%d", have, head)
+		}
+		if hash := GetHeadBlockHash(db); hash != chain.CurrentBlock().Hash() {
+			t.Fatalf("stored head block mismatch: have %x, want %x", hash, chain.CurrentBlock().Hash())
+		}
+		return chain
+	}
+	// A head block without total difficulty is skipped, its header too
+	DeleteTd(db, blocks[9].Hash())
+	chain = reopen(9)
+	if head := chain.CurrentHeader().Number.Uint64(); head != 9 {
+		t.Errorf("head header mismatch: have #%d, want #9", head)
+	}
+	if head := chain.CurrentFastBlock().NumberU64(); head != 9 {
+		t.Errorf("head fast block mismatch: have #%d, want #9", head)
+	}
+	// A head block without body is skipped
+	DeleteBody(db, blocks[8].Hash())
+	reopen(8)
+
+	// A head block without state is skipped
+	db.Delete(blocks[7].Root().Bytes())
+	reopen(7)
+
+	// An unknown head block rewinds to the highest complete canonical block
+	WriteHeadBlockHash(db, common.Hash{0xff})
+	reopen(7)
+
+	// Without any complete block to rewind to, the chain fails to open
+	for _, block := range blocks[:7] {
+		db.Delete(block.Root().Bytes())
+	}
+	db.Delete(genesis.Root().Bytes())
+	if _, err := NewBlockChain(db, FakePow{}); err == nil {
+		t.Fatalf("chain without complete blocks opened")
+	}
+	if hash := GetHeadBlockHash(db); hash != blocks[6].Hash() {
+		t.Errorf("stored head block changed: have %x, want %x", hash, blocks[6].Hash())
+	}
+}
+
+// Tests that a block chain validates blocks with the protocol parameters its
+// genesis configures, whichever tool opens it.
+func TestBlockChainChainConfig(t *testing.T) {
+	defer func(size uint64) { params.MaxTransactionSize = size }(params.MaxTransactionSize)
+
+	db, _ := ethdb.NewMemDatabase()
+	genesis := `{"difficulty": "0x400", "gasLimit": "0x2faf080", "config": {"maxTransactionSize": "0x400"}}`
+	if _, err := WriteGenesisBlock(db, strings.NewReader(genesis)); err != nil {
+		t.Fatalf("failed to write genesis block: %v", err)
+	}
+	if _, err := NewBlockChain(db, FakePow{}); err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	if params.MaxTransactionSize != 1024 {
+		t.Errorf("maximum transaction size mismatch: have %d, want 1024", params.MaxTransactionSize)
+	}
+}
@@ -0,0 +1,153 @@
+// Copyright 2016 The go-expanse Authors
+// This file is part of the go-expanse library.
+//
+// The go-expanse library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-expanse library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-expanse library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+
+	"github.com/expanse-project/go-expanse/common"
+	"github.com/expanse-project/go-expanse/core/types"
+)
+
+// CallFrame is a single message call or contract creation in the call tree of
+// a transaction. Input and output are cut to the tracer's data limit.
+type CallFrame struct {
+	Type    string // CALL, CALLCODE, DELEGATECALL or CREATE
+	From    common.Address
+	To      common.Address
+	Value   *big.Int
+	Gas     *big.Int
+	GasUsed *big.Int
+	Input   []byte
+	Output  []byte
+	Error   error
+	Calls   []*CallFrame
+}
+
+// CallTracer records the call tree of the messages executed in a VM
+// environment, without any of the per instruction logs of vm.Debug.
+type CallTracer struct {
+	limit int
+	root  *CallFrame
+	stack []*CallFrame
+}
+
+// NewCallTracer returns a tracer keeping at most limit bytes of the input and
+// output of every call.
+func NewCallTracer(limit int) *CallTracer {
+	return &CallTracer{limit: limit}
+}
+
+// Root returns the outermost call of the traced message.
+func (t *CallTracer) Root() *CallFrame {
+	return t.root
+}
+
+func (t *CallTracer) prefix(data []byte) []byte {
+	if len(data) > t.limit {
+		data = data[:t.limit]
+	}
+	return common.CopyBytes(data)
+}
+
+// enter opens a new frame as a child of the currently executing one.
+func (t *CallTracer) enter(typ string, from, to common.Address, input []byte, gas, value *big.Int) *CallFrame {
+	frame := &CallFrame{
+		Type:  typ,
+		From:  from,
+		To:    to,
+		Value: new(big.Int).Set(value),
+		Gas:   new(big.Int).Set(gas),
+		Input: t.prefix(input),
+	}
+	if len(t.stack) == 0 {
+		t.root = frame
+	} else {
+		parent := t.stack[len(t.stack)-1]
+		parent.Calls = append(parent.Calls, frame)
+	}
+	t.stack = append(t.stack, frame)
+	return frame
+}
+
+// exit closes the current frame. The gas is the pointer passed to the call,
+// which the VM reduced to what is left over.
+func (t *CallTracer) exit(output []byte, gas *big.Int, err error) {
+	frame := t.stack[len(t.stack)-1]
+	t.stack = t.stack[:len(t.stack)-1]
+
+	frame.Output = t.prefix(output)
+	frame.GasUsed = new(big.Int).Sub(frame.Gas, gas)
+	frame.Error = err
+}
+
+// RecordInternalTxs enables recording the value transfers of the internal
+// calls of transactions executed during block processing, which are stored
+// per block next to the receipts. Blocks imported by fast sync are never
+// executed and so not recorded. Setting it is not thread safe, it should
+// be done on startup.
+var RecordInternalTxs = false
+
+// internalTxs collects the value transfers in the call tree below root. Failed
+// calls are skipped together with their subcalls, as all of them got reverted.
+func internalTxs(hash common.Hash, root *CallFrame) []*types.InternalTx {
+	var (
+		txs  []*types.InternalTx
+		walk func(frame *CallFrame, depth uint)
+	)
+	walk = func(frame *CallFrame, depth uint) {
+		if frame.Error != nil {
+			return
+		}
+		if depth > 0 && frame.Value.Sign() > 0 && (frame.Type == "CALL" || frame.Type == "CREATE") {
+			txs = append(txs, &types.InternalTx{
+				TxHash: hash,
+				Type:   frame.Type,
+				From:   frame.From,
+				To:     frame.To,
+				Value:  frame.Value,
+				Depth:  depth,
+			})
+		}
+		for _, call := range frame.Calls {
+			walk(call, depth+1)
+		}
+	}
+	if root != nil {
+		walk(root, 0)
+	}
+	return txs
+}
+
+// TraceCalls re-executes the transaction at the given index of a block on top
+// of the state of its parent and returns the call tree of the transaction.
+func TraceCalls(bc *BlockChain, block *types.Block, index int, limit int) (*CallFrame, error) {
+	statedb, gp, err := stateAtTransaction(bc, block, index)
+	if err != nil {
+		return nil, err
+	}
+	tx := block.Transactions()[index]
+	statedb.StartRecord(tx.Hash(), block.Hash(), index)
+
+	tracer := NewCallTracer(limit)
+	env := NewEnv(statedb, bc, tx, block.Header())
+	env.SetCallTracer(tracer)
+	if _, _, err := ApplyMessage(env, tx, gp); err != nil {
+		return nil, err
+	}
+	return tracer.Root(), nil
+}
@@ -0,0 +1,141 @@
+// Copyright 2016 The go-expanse Authors
+// This file is part of the go-expanse library.
+//
+// The go-expanse library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-expanse library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-expanse library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/expanse-project/go-expanse/common"
+	"github.com/expanse-project/go-expanse/core/types"
+	"github.com/expanse-project/go-expanse/crypto"
+	"github.com/expanse-project/go-expanse/ethdb"
+)
+
+func TestTraceCalls(t *testing.T) {
+	var (
+		key, _ = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		addr   = crypto.PubkeyToAddress(key.PublicKey)
+		outer  = common.Address{0xaa}
+		inner  = common.BytesToAddress([]byte{0xbb})
+		db, _  = ethdb.NewMemDatabase()
+	)
+	// The outer contract calls the inner one, which returns a single byte
+	genesis, err := WriteGenesisBlock(db, strings.NewReader(fmt.Sprintf(`{
+	"nonce": "0x0000000000000042",
+	"gasLimit": "0x2fefd8",
+	"difficulty": "0x20000",
+	"alloc": {
+		"0x%x": {"balance": "1000000000000000000"},
+		"0x%x": {"code": "6000600060006000600060bb612710f100"},
+		"0x%x": {"code": "602a60005360016000f3"}
+	}
+}`, addr, outer, inner)))
+	if err != nil {
+		t.Fatalf("failed to write genesis: %v", err)
+	}
+	chain, _ := GenerateChain(genesis, db, 1, func(i int, gen *BlockGen) {
+		tx, _ := types.NewTransaction(gen.TxNonce(addr), outer, big.NewInt(0), big.NewInt(100000), big.NewInt(1), []byte{1, 2, 3, 4, 5, 6}).SignECDSA(key)
+		gen.AddTx(tx)
+	})
+	blockchain, _ := NewBlockChain(db, FakePow{})
+	if i, err := blockchain.InsertChain(chain); err != nil {
+		t.Fatalf("failed to insert block %d: %v", i, err)
+	}
+
+	root, err := TraceCalls(blockchain, chain[0], 0, 4)
+	if err != nil {
+		t.Fatalf("failed to trace: %v", err)
+	}
+	if root.Type != "CALL" || root.From != addr || root.To != outer {
+		t.Errorf("root call mismatch: have %s %x -> %x", root.Type, root.From, root.To)
+	}
+	if len(root.Input) != 4 {
+		t.Errorf("input not cut to the limit: have %d bytes, want 4", len(root.Input))
+	}
+	if len(root.Calls) != 1 {
+		t.Fatalf("nested call count mismatch: have %d, want 1", len(root.Calls))
+	}
+	call := root.Calls[0]
+	if call.From != outer || call.To != inner || call.Error != nil {
+		t.Errorf("nested call mismatch: have %x -> %x (%v)", call.From, call.To, call.Error)
+	}
+	if len(call.Output) != 1 || call.Output[0] != 0x2a {
+		t.Errorf("nested call output mismatch: have %x, want 2a", call.Output)
+	}
+	if call.Gas.Cmp(big.NewInt(10000)) != 0 || call.GasUsed.Sign() <= 0 || root.GasUsed.Cmp(call.GasUsed) <= 0 {
+		t.Errorf("gas mismatch: root used %v, nested got %v used %v", root.GasUsed, call.Gas, call.GasUsed)
+	}
+	if _, err := TraceCalls(blockchain, chain[0], 1, 4); err == nil {
+		t.Errorf("expected error for transaction index out of range")
+	}
+}
+
+func TestRecordInternalTxs(t *testing.T) {
+	defer func(record bool) { RecordInternalTxs = record }(RecordInternalTxs)
+	RecordInternalTxs = true
+
+	var (
+		key, _ = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		addr   = crypto.PubkeyToAddress(key.PublicKey)
+		outer  = common.Address{0xaa}
+		inner  = common.BytesToAddress([]byte{0xbb})
+		db, _  = ethdb.NewMemDatabase()
+	)
+	// The outer contract sends 5 wei to the inner one, which does nothing
+	genesis, err := WriteGenesisBlock(db, strings.NewReader(fmt.Sprintf(`{
+	"nonce": "0x0000000000000042",
+	"gasLimit": "0x2fefd8",
+	"difficulty": "0x20000",
+	"alloc": {
+		"0x%x": {"balance": "1000000000000000000"},
+		"0x%x": {"balance": "100", "code": "6000600060006000600560bb612710f100"},
+		"0x%x": {"code": "00"}
+	}
+}`, addr, outer, inner)))
+	if err != nil {
+		t.Fatalf("failed to write genesis: %v", err)
+	}
+	chain, _ := GenerateChain(genesis, db, 1, func(i int, gen *BlockGen) {
+		tx, _ := types.NewTransaction(gen.TxNonce(addr), outer, big.NewInt(1), big.NewInt(100000), big.NewInt(1), nil).SignECDSA(key)
+		gen.AddTx(tx)
+	})
+	blockchain, _ := NewBlockChain(db, FakePow{})
+	if i, err := blockchain.InsertChain(chain); err != nil {
+		t.Fatalf("failed to insert block %d: %v", i, err)
+	}
+
+	txs := GetInternalTxs(db, chain[0].Hash())
+	if len(txs) != 1 {
+		t.Fatalf("internal transaction count mismatch: have %d, want 1", len(txs))
+	}
+	tx := txs[0]
+	if tx.TxHash != chain[0].Transactions()[0].Hash() {
+		t.Errorf("transaction hash mismatch: have %x, want %x", tx.TxHash, chain[0].Transactions()[0].Hash())
+	}
+	if tx.Type != "CALL" || tx.From != outer || tx.To != inner || tx.Depth != 1 {
+		t.Errorf("internal transaction mismatch: have %s %x -> %x at depth %d", tx.Type, tx.From, tx.To, tx.Depth)
+	}
+	if tx.Value.Cmp(big.NewInt(5)) != 0 {
+		t.Errorf("value mismatch: have %v, want 5", tx.Value)
+	}
+	if txs := GetInternalTxs(db, genesis.Hash()); txs != nil {
+		t.Errorf("genesis has internal transactions: %v", txs)
+	}
+}
@@ -0,0 +1,181 @@
+// Copyright 2016 The go-expanse Authors
+// This file is part of the go-expanse library.
+//
+// The go-expanse library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-expanse library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-expanse library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"fmt"
+
+	"github.com/expanse-project/go-expanse/common"
+	"github.com/expanse-project/go-expanse/core/types"
+)
+
+// DefaultIteratorBatch is the number of blocks a ChainIterator prefetches at
+// once if no batch size is given.
+const DefaultIteratorBatch = 256
+
+// ChainItem is a canonical block together with its receipts. Receipts is only
+// filled if they were requested when creating the iterator.
+type ChainItem struct {
+	Block    *types.Block
+	Receipts types.Receipts
+}
+
+// chainBatch is a batch of items read ahead by the prefetcher of an iterator,
+// err is set if reading the batch failed.
+type chainBatch struct {
+	items []ChainItem
+	err   error
+}
+
+// ChainIterator iterates over the canonical blocks between two heights. The
+// blocks are read from the database in batches by a background prefetcher,
+// staying one batch ahead of the consumer. Iterating does not lock the chain,
+// if the canonical chain is reorganised under the iterator, iteration stops
+// with an error instead of mixing blocks of different branches.
+//
+//	it, err := chain.NewIterator(first, last, 0, false)
+//	if err != nil { ... }
+//	defer it.Release()
+//	for it.Next() {
+//		block := it.Item().Block
+//	}
+//	if err := it.Err(); err != nil { ... }
+type ChainIterator struct {
+	batches chan chainBatch
+	quit    chan struct{}
+
+	pending []ChainItem
+	item    ChainItem
+	err     error
+}
+
+// NewIterator creates an iterator over the canonical blocks first to last,
+// inclusive, prefetching batch blocks at a time. If receipts is set, the
+// receipts of the blocks are read too. The iterator must be released once it
+// isn't needed anymore.
+func (self *BlockChain) NewIterator(first, last uint64, batch int, receipts bool) (*ChainIterator, error) {
+	if first > last {
+		return nil, fmt.Errorf("invalid range: first (%d) is greater than last (%d)", first, last)
+	}
+	if batch <= 0 {
+		batch = DefaultIteratorBatch
+	}
+	it := &ChainIterator{
+		batches: make(chan chainBatch, 1),
+		quit:    make(chan struct{}),
+	}
+	go self.prefetch(it, first, last, uint64(batch), receipts)
+	return it, nil
+}
+
+// prefetch reads the blocks of the iterator range batch by batch and hands
+// them to the iterator until the range is exhausted or the iterator released.
+func (self *BlockChain) prefetch(it *ChainIterator, first, last, batch uint64, receipts bool) {
+	defer close(it.batches)
+
+	var parent common.Hash
+	for from := first; from <= last; from += batch {
+		to := from + batch - 1
+		if to > last || to < from {
+			to = last
+		}
+		var result chainBatch
+		for nr := from; nr <= to; nr++ {
+			hash := GetCanonicalHash(self.chainDb, nr)
+			block := GetBlock(self.chainDb, hash)
+			if hash == (common.Hash{}) || block == nil {
+				result.err = fmt.Errorf("block #%d not found", nr)
+				break
+			}
+			if nr > first && block.ParentHash() != parent {
+				result.err = fmt.Errorf("canonical chain reorganised at block #%d", nr)
+				break
+			}
+			parent = hash
+
+			item := ChainItem{Block: block}
+			if receipts {
+				item.Receipts = GetBlockReceipts(self.chainDb, hash)
+			}
+			result.items = append(result.items, item)
+		}
+		select {
+		case it.batches <- result:
+		case <-it.quit:
+			return
+		}
+		if result.err != nil || to == last {
+			return
+		}
+	}
+}
+
+// Next advances the iterator to the next block, returning false once the range
+// is exhausted or reading a block failed.
+func (it *ChainIterator) Next() bool {
+	for len(it.pending) == 0 {
+		if it.err != nil {
+			return false
+		}
+		batch, ok := <-it.batches
+		if !ok {
+			return false
+		}
+		it.pending, it.err = batch.items, batch.err
+	}
+	it.item, it.pending = it.pending[0], it.pending[1:]
+	return true
+}
+
+// Item returns the block the iterator is positioned at.
+func (it *ChainIterator) Item() ChainItem {
+	return it.item
+}
+
+// Err returns the error which stopped the iteration, if any.
+func (it *ChainIterator) Err() error {
+	return it.err
+}
+
+// Release stops the prefetcher of the iterator. It is safe to call it more
+// than once.
+func (it *ChainIterator) Release() {
+	select {
+	case <-it.quit:
+	default:
+		close(it.quit)
+	}
+}
+
+// GetBlocksInRange returns the canonical blocks first to last, inclusive,
+// along with their receipts if receipts is set.
+func (self *BlockChain) GetBlocksInRange(first, last uint64, receipts bool) ([]ChainItem, error) {
+	it, err := self.NewIterator(first, last, 0, receipts)
+	if err != nil {
+		return nil, err
+	}
+	defer it.Release()
+
+	var items []ChainItem
+	for it.Next() {
+		items = append(items, it.Item())
+	}
+	if err := it.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
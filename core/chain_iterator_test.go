@@ -0,0 +1,74 @@
+// Copyright 2016 The go-expanse Authors
+// This file is part of the go-expanse library.
+//
+// The go-expanse library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-expanse library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-expanse library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"testing"
+
+	"github.com/expanse-project/go-expanse/ethdb"
+)
+
+func TestChainIterator(t *testing.T) {
+	var (
+		db, _   = ethdb.NewMemDatabase()
+		genesis = WriteGenesisBlockForTesting(db)
+	)
+	chain, receipts := GenerateChain(genesis, db, 10, func(i int, gen *BlockGen) {})
+	blockchain, _ := NewBlockChain(db, FakePow{})
+	if i, err := blockchain.InsertChain(chain); err != nil {
+		t.Fatalf("failed to insert block %d: %v", i, err)
+	}
+	for i, block := range chain {
+		WriteBlockReceipts(db, block.Hash(), receipts[i])
+	}
+	// Iterate over a range spanning several batches
+	it, err := blockchain.NewIterator(2, 9, 3, true)
+	if err != nil {
+		t.Fatalf("failed to create iterator: %v", err)
+	}
+	next := uint64(2)
+	for it.Next() {
+		item := it.Item()
+		if item.Block.Hash() != chain[next-1].Hash() {
+			t.Errorf("block #%d: hash mismatch: have %x, want %x", next, item.Block.Hash(), chain[next-1].Hash())
+		}
+		if item.Receipts == nil {
+			t.Errorf("block #%d: receipts missing", next)
+		}
+		next++
+	}
+	it.Release()
+	if err := it.Err(); err != nil {
+		t.Fatalf("iteration failed: %v", err)
+	}
+	if next != 10 {
+		t.Errorf("iteration stopped early: have #%d, want #10", next-1)
+	}
+	// Ranges reaching beyond the head fail at the first missing block
+	items, err := blockchain.GetBlocksInRange(8, 12, false)
+	if err == nil {
+		t.Errorf("expected error for missing blocks, got %d items", len(items))
+	}
+	if _, err := blockchain.NewIterator(5, 4, 0, false); err == nil {
+		t.Errorf("expected error for inverted range")
+	}
+	// Releasing an iterator early stops the prefetcher
+	it, _ = blockchain.NewIterator(0, 10, 1, false)
+	it.Next()
+	it.Release()
+	it.Release()
+}
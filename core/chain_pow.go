@@ -20,40 +20,36 @@ import (
 	"runtime"
 
 	"github.com/expanse-project/go-expanse/core/types"
-	"github.com/expanse-project/go-expanse/pow"
 )
 
 // nonceCheckResult contains the result of a nonce verification.
 type nonceCheckResult struct {
-	index int  // Index of the item verified from an input array
-	valid bool // Result of the nonce verification
+	index int   // Index of the item verified from an input array
+	valid bool  // Result of the nonce verification
+	err   error // Reason of the failed verification, nil if valid
 }
 
 // verifyNoncesFromHeaders starts a concurrent header nonce verification,
 // returning a quit channel to abort the operations and a results channel
 // to retrieve the async verifications.
-func verifyNoncesFromHeaders(checker pow.PoW, headers []*types.Header) (chan<- struct{}, <-chan nonceCheckResult) {
-	items := make([]pow.Block, len(headers))
-	for i, header := range headers {
-		items[i] = types.NewBlockWithHeader(header)
-	}
-	return verifyNonces(checker, items)
+func verifyNoncesFromHeaders(checker Engine, headers []*types.Header) (chan<- struct{}, <-chan nonceCheckResult) {
+	return verifyNonces(checker, headers)
 }
 
 // verifyNoncesFromBlocks starts a concurrent block nonce verification,
 // returning a quit channel to abort the operations and a results channel
 // to retrieve the async verifications.
-func verifyNoncesFromBlocks(checker pow.PoW, blocks []*types.Block) (chan<- struct{}, <-chan nonceCheckResult) {
-	items := make([]pow.Block, len(blocks))
+func verifyNoncesFromBlocks(checker Engine, blocks []*types.Block) (chan<- struct{}, <-chan nonceCheckResult) {
+	items := make([]*types.Header, len(blocks))
 	for i, block := range blocks {
-		items[i] = block
+		items[i] = block.Header()
 	}
 	return verifyNonces(checker, items)
 }
 
 // verifyNonces starts a concurrent nonce verification, returning a quit channel
 // to abort the operations and a results channel to retrieve the async checks.
-func verifyNonces(checker pow.PoW, items []pow.Block) (chan<- struct{}, <-chan nonceCheckResult) {
+func verifyNonces(checker Engine, items []*types.Header) (chan<- struct{}, <-chan nonceCheckResult) {
 	// Spawn as many workers as allowed threads
 	workers := runtime.GOMAXPROCS(0)
 	if len(items) < workers {
@@ -65,7 +61,8 @@ func verifyNonces(checker pow.PoW, items []pow.Block) (chan<- struct{}, <-chan n
 	for i := 0; i < workers; i++ {
 		go func() {
 			for index := range tasks {
-				results <- nonceCheckResult{index: index, valid: checker.Verify(items[index])}
+				err := checker.VerifySeal(items[index])
+				results <- nonceCheckResult{index: index, valid: err == nil, err: err}
 			}
 		}()
 	}
@@ -74,13 +74,13 @@ func TestPowVerification(t *testing.T) {
 
 				switch {
 				case full && valid:
-					_, results = verifyNoncesFromBlocks(FakePow{}, []*types.Block{blocks[i]})
+					_, results = verifyNoncesFromBlocks(NewPowEngine(FakePow{}), []*types.Block{blocks[i]})
 				case full && !valid:
-					_, results = verifyNoncesFromBlocks(failPow{blocks[i].NumberU64()}, []*types.Block{blocks[i]})
+					_, results = verifyNoncesFromBlocks(NewPowEngine(failPow{blocks[i].NumberU64()}), []*types.Block{blocks[i]})
 				case !full && valid:
-					_, results = verifyNoncesFromHeaders(FakePow{}, []*types.Header{headers[i]})
+					_, results = verifyNoncesFromHeaders(NewPowEngine(FakePow{}), []*types.Header{headers[i]})
 				case !full && !valid:
-					_, results = verifyNoncesFromHeaders(failPow{headers[i].Number.Uint64()}, []*types.Header{headers[i]})
+					_, results = verifyNoncesFromHeaders(NewPowEngine(failPow{headers[i].Number.Uint64()}), []*types.Header{headers[i]})
 				}
 				// Wait for the verification result
 				select {
@@ -133,13 +133,13 @@ func testPowConcurrentVerification(t *testing.T, threads int) {
 
 			switch {
 			case full && valid:
-				_, results = verifyNoncesFromBlocks(FakePow{}, blocks)
+				_, results = verifyNoncesFromBlocks(NewPowEngine(FakePow{}), blocks)
 			case full && !valid:
-				_, results = verifyNoncesFromBlocks(failPow{uint64(len(blocks) - 1)}, blocks)
+				_, results = verifyNoncesFromBlocks(NewPowEngine(failPow{uint64(len(blocks) - 1)}), blocks)
 			case !full && valid:
-				_, results = verifyNoncesFromHeaders(FakePow{}, headers)
+				_, results = verifyNoncesFromHeaders(NewPowEngine(FakePow{}), headers)
 			case !full && !valid:
-				_, results = verifyNoncesFromHeaders(failPow{uint64(len(headers) - 1)}, headers)
+				_, results = verifyNoncesFromHeaders(NewPowEngine(failPow{uint64(len(headers) - 1)}), headers)
 			}
 			// Wait for all the verification results
 			checks := make(map[int]bool)
@@ -203,9 +203,9 @@ func testPowConcurrentAbortion(t *testing.T, threads int) {
 
 		// Start the verifications and immediately abort
 		if full {
-			abort, results = verifyNoncesFromBlocks(delayedPow{time.Millisecond}, blocks)
+			abort, results = verifyNoncesFromBlocks(NewPowEngine(delayedPow{time.Millisecond}), blocks)
 		} else {
-			abort, results = verifyNoncesFromHeaders(delayedPow{time.Millisecond}, headers)
+			abort, results = verifyNoncesFromHeaders(NewPowEngine(delayedPow{time.Millisecond}), headers)
 		}
 		close(abort)
 
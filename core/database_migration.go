@@ -0,0 +1,89 @@
+// Copyright 2016 The go-expanse Authors
+// This file is part of the go-expanse library.
+//
+// The go-expanse library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-expanse library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-expanse library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/expanse-project/go-expanse/ethdb"
+	"github.com/expanse-project/go-expanse/logger"
+	"github.com/expanse-project/go-expanse/logger/glog"
+	"github.com/expanse-project/go-expanse/rlp"
+)
+
+// schemaVersionKey stores the number of migrations applied to a database.
+var schemaVersionKey = []byte("SchemaVersion")
+
+// Migration is a forward change of the key layout of a database, converting
+// the entries written in the previous layout. Migrations must leave databases
+// without such entries untouched, as fresh databases run them all.
+type Migration struct {
+	Name    string
+	Migrate func(db ethdb.Database) error
+}
+
+// GetSchemaVersion returns the number of migrations applied to the database, 0
+// for databases created before versioning.
+func GetSchemaVersion(db ethdb.Database) uint64 {
+	data, _ := db.Get(schemaVersionKey)
+	if len(data) == 0 {
+		return 0
+	}
+	var version uint64
+	if err := rlp.DecodeBytes(data, &version); err != nil {
+		glog.V(logger.Error).Infof("invalid schema version RLP: %v", err)
+		return 0
+	}
+	return version
+}
+
+// WriteSchemaVersion records the number of migrations applied to the database.
+func WriteSchemaVersion(db ethdb.Database, version uint64) error {
+	data, _ := rlp.EncodeToBytes(version)
+	if err := db.Put(schemaVersionKey, data); err != nil {
+		glog.V(logger.Error).Infof("failed to store schema version into database: %v", err)
+		return err
+	}
+	return nil
+}
+
+// MigrateDatabase brings the database up to date with the given list of
+// migrations, running those it hasn't seen yet in order. The schema version is
+// recorded after each of them, so an interrupted upgrade resumes with the one
+// that failed. Databases written by a newer version of the software, with more
+// migrations applied than known, are rejected.
+func MigrateDatabase(db ethdb.Database, migrations []Migration) error {
+	version := GetSchemaVersion(db)
+	if version > uint64(len(migrations)) {
+		return fmt.Errorf("database schema version %d is newer than the supported %d", version, len(migrations))
+	}
+	for i := version; i < uint64(len(migrations)); i++ {
+		migration := migrations[i]
+
+		start := time.Now()
+		glog.V(logger.Info).Infof("Migrating database to schema version %d: %s", i+1, migration.Name)
+		if err := migration.Migrate(db); err != nil {
+			return fmt.Errorf("database migration %d (%s) failed: %v", i+1, migration.Name, err)
+		}
+		if err := WriteSchemaVersion(db, i+1); err != nil {
+			return err
+		}
+		glog.V(logger.Debug).Infof("Database migration %d completed in %v", i+1, time.Since(start))
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+// Copyright 2016 The go-expanse Authors
+// This file is part of the go-expanse library.
+//
+// The go-expanse library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-expanse library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-expanse library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/expanse-project/go-expanse/ethdb"
+)
+
+// Tests that migrations run once and in order, resuming after failures.
+func TestMigrateDatabase(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+
+	var (
+		ran  []string
+		fail = errors.New("disk full")
+		err2 error
+	)
+	migration := func(name string, err *error) Migration {
+		return Migration{Name: name, Migrate: func(ethdb.Database) error {
+			ran = append(ran, name)
+			if err != nil {
+				return *err
+			}
+			return nil
+		}}
+	}
+	migrations := []Migration{migration("a", nil), migration("b", nil)}
+
+	if version := GetSchemaVersion(db); version != 0 {
+		t.Fatalf("fresh database version mismatch: have %d, want 0", version)
+	}
+	if err := MigrateDatabase(db, migrations); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	if version := GetSchemaVersion(db); version != 2 {
+		t.Errorf("version mismatch: have %d, want 2", version)
+	}
+	// Applied migrations don't run again, a failing one stops the upgrade
+	// before recording its version
+	err2 = fail
+	migrations = append(migrations, migration("c", &err2), migration("d", nil))
+	if err := MigrateDatabase(db, migrations); err == nil {
+		t.Fatalf("failing migration succeeded")
+	}
+	if version := GetSchemaVersion(db); version != 2 {
+		t.Errorf("version mismatch after failure: have %d, want 2", version)
+	}
+	err2 = nil
+	if err := MigrateDatabase(db, migrations); err != nil {
+		t.Fatalf("failed to resume migration: %v", err)
+	}
+	if have, want := fmt.Sprint(ran), "[a b c c d]"; have != want {
+		t.Errorf("migrations run mismatch: have %s, want %s", have, want)
+	}
+	// Databases migrated by newer versions are rejected
+	if err := MigrateDatabase(db, migrations[:3]); err == nil {
+		t.Errorf("database with newer schema accepted")
+	}
+}
@@ -19,6 +19,7 @@ package core
 import (
 	"bytes"
 	"encoding/binary"
+	"encoding/json"
 	"fmt"
 	"math/big"
 
@@ -45,11 +46,16 @@ var (
 	txMetaSuffix        = []byte{0x01}
 	receiptsPrefix      = []byte("receipts-")
 	blockReceiptsPrefix = []byte("receipts-block-")
+	internalTxsPrefix   = []byte("internal-txs-")
 
 	mipmapPre    = []byte("mipmap-log-bloom-")
 	MIPMapLevels = []uint64{1000000, 500000, 100000, 50000, 1000}
 
 	blockHashPrefix = []byte("block-hash-") // [deprecated by the header/block split, remove eventually]
+
+	preimagePrefix = []byte("secure-key-") // key preimages stored by trie.SecureTrie
+
+	configPrefix = []byte("expanse-config-") // chain config keyed by the genesis hash
 )
 
 // GetCanonicalHash retrieves a hash assigned to a canonical block number.
@@ -169,6 +175,30 @@ func GetBlock(db ethdb.Database, hash common.Hash) *types.Block {
 	return types.NewBlockWithHeader(header).WithBody(body.Transactions, body.Uncles)
 }
 
+// FindCommonAncestor returns the last common ancestor of two block headers, or
+// nil if either chain is missing a header down to the ancestor.
+func FindCommonAncestor(db ethdb.Database, a, b *types.Header) *types.Header {
+	for bn := b.Number.Uint64(); a.Number.Uint64() > bn; {
+		if a = GetHeader(db, a.ParentHash); a == nil {
+			return nil
+		}
+	}
+	for an := a.Number.Uint64(); an < b.Number.Uint64(); {
+		if b = GetHeader(db, b.ParentHash); b == nil {
+			return nil
+		}
+	}
+	for a.Hash() != b.Hash() {
+		if a = GetHeader(db, a.ParentHash); a == nil {
+			return nil
+		}
+		if b = GetHeader(db, b.ParentHash); b == nil {
+			return nil
+		}
+	}
+	return a
+}
+
 // GetBlockReceipts retrieves the receipts generated by the transactions included
 // in a block given by its hash.
 func GetBlockReceipts(db ethdb.Database, hash common.Hash) types.Receipts {
@@ -188,6 +218,21 @@ func GetBlockReceipts(db ethdb.Database, hash common.Hash) types.Receipts {
 	return receipts
 }
 
+// GetInternalTxs retrieves the recorded internal value transfers of the
+// transactions included in a block given by its hash.
+func GetInternalTxs(db ethdb.Database, hash common.Hash) []*types.InternalTx {
+	data, _ := db.Get(append(internalTxsPrefix, hash[:]...))
+	if len(data) == 0 {
+		return nil
+	}
+	var txs []*types.InternalTx
+	if err := rlp.DecodeBytes(data, &txs); err != nil {
+		glog.V(logger.Error).Infof("invalid internal transaction RLP for hash %x: %v", hash, err)
+		return nil
+	}
+	return txs
+}
+
 // GetTransaction retrieves a specific transaction from the database, along with
 // its added positional metadata.
 func GetTransaction(db ethdb.Database, hash common.Hash) (*types.Transaction, common.Hash, uint64, uint64) {
@@ -347,6 +392,27 @@ func WriteBlockReceipts(db ethdb.Database, hash common.Hash, receipts types.Rece
 	return nil
 }
 
+// WriteInternalTxs stores the internal value transfers recorded in the receipts
+// of a block. Blocks without any are not stored.
+func WriteInternalTxs(db ethdb.Database, hash common.Hash, receipts types.Receipts) error {
+	var txs []*types.InternalTx
+	for _, receipt := range receipts {
+		txs = append(txs, receipt.InternalTxs...)
+	}
+	if len(txs) == 0 {
+		return nil
+	}
+	bytes, err := rlp.EncodeToBytes(txs)
+	if err != nil {
+		return err
+	}
+	if err := db.Put(append(internalTxsPrefix, hash.Bytes()...), bytes); err != nil {
+		glog.Fatalf("failed to store internal transactions into database: %v", err)
+		return err
+	}
+	return nil
+}
+
 // WriteTransactions stores the transactions associated with a specific block
 // into the given database. Beside writing the transaction, the function also
 // stores a metadata entry along with the transaction, detailing the position
@@ -513,3 +579,72 @@ func GetMipmapBloom(db ethdb.Database, number, level uint64) types.Bloom {
 	bloomDat, _ := db.Get(mipmapKey(number, level))
 	return types.BytesToBloom(bloomDat)
 }
+
+// WriteChainConfig stores the chain config of the chain with the given genesis
+// hash.
+func WriteChainConfig(db ethdb.Database, genesis common.Hash, config *ChainConfig) error {
+	data, err := json.Marshal(config)
+	if err != nil {
+		return err
+	}
+	return db.Put(append(configPrefix, genesis[:]...), data)
+}
+
+// GetChainConfig retrieves the chain config of the chain with the given genesis
+// hash, or nil if the chain uses the protocol defaults.
+func GetChainConfig(db ethdb.Database, genesis common.Hash) *ChainConfig {
+	data, _ := db.Get(append(configPrefix, genesis[:]...))
+	if len(data) == 0 {
+		return nil
+	}
+	config := new(ChainConfig)
+	if err := json.Unmarshal(data, config); err != nil {
+		glog.V(logger.Error).Infof("invalid chain config for genesis %x: %v", genesis, err)
+		return nil
+	}
+	return config
+}
+
+// PrefixSizer is implemented by databases which can report the disk usage of
+// the entries sharing a key prefix.
+type PrefixSizer interface {
+	PrefixSize(prefix []byte) (uint64, error)
+}
+
+// DatabaseSizes returns the approximate disk usage of the chain data in db,
+// broken down by the kind of data. Entries keyed by their hash alone, such as
+// state trie nodes and transaction lookups, can't be told apart by prefix and
+// are not included.
+func DatabaseSizes(db PrefixSizer) (map[string]uint64, error) {
+	prefixes := map[string][]byte{
+		"blocks":        blockPrefix,
+		"canonical":     blockNumPrefix,
+		"legacyBlocks":  blockHashPrefix,
+		"receipts":      receiptsPrefix,
+		"blockReceipts": blockReceiptsPrefix,
+		"internalTxs":   internalTxsPrefix,
+		"mipmaps":       mipmapPre,
+		"preimages":     preimagePrefix,
+	}
+	sizes := make(map[string]uint64, len(prefixes))
+	for name, prefix := range prefixes {
+		size, err := db.PrefixSize(prefix)
+		if err != nil {
+			return nil, err
+		}
+		sizes[name] = size
+	}
+	// Canonical hashes and legacy blocks share the block prefix, block
+	// receipts the transaction receipt one.
+	sizes["blocks"] = subSize(sizes["blocks"], sizes["canonical"]+sizes["legacyBlocks"])
+	sizes["receipts"] = subSize(sizes["receipts"], sizes["blockReceipts"])
+	return sizes, nil
+}
+
+// subSize subtracts b from a, stopping at zero as the sizes are approximate.
+func subSize(a, b uint64) uint64 {
+	if b > a {
+		return 0
+	}
+	return a - b
+}
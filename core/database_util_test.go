@@ -22,6 +22,7 @@ import (
 	"io/ioutil"
 	"math/big"
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/expanse-project/go-expanse/common"
@@ -30,6 +31,7 @@ import (
 	"github.com/expanse-project/go-expanse/crypto"
 	"github.com/expanse-project/go-expanse/crypto/sha3"
 	"github.com/expanse-project/go-expanse/ethdb"
+	"github.com/expanse-project/go-expanse/params"
 	"github.com/expanse-project/go-expanse/rlp"
 )
 
@@ -607,3 +609,142 @@ func TestMipmapChain(t *testing.T) {
 		t.Error("address was included in bloom and should not have")
 	}
 }
+
+func TestDatabaseSizes(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+
+	block := types.NewBlock(&types.Header{Number: big.NewInt(1), Extra: []byte("test block")}, nil, nil, nil)
+	if err := WriteBlock(db, block); err != nil {
+		t.Fatalf("failed to write block: %v", err)
+	}
+	if err := WriteCanonicalHash(db, block.Hash(), block.NumberU64()); err != nil {
+		t.Fatalf("failed to write canonical hash: %v", err)
+	}
+	sizes, err := DatabaseSizes(db)
+	if err != nil {
+		t.Fatalf("failed to inspect database: %v", err)
+	}
+	if sizes["blocks"] == 0 || sizes["canonical"] == 0 {
+		t.Errorf("expected block and canonical hash sizes, got %v", sizes)
+	}
+	if canonical := uint64(len(blockNumPrefix) + 1 + len(common.Hash{})); sizes["canonical"] != canonical {
+		t.Errorf("canonical hash size mismatch: have %d, want %d", sizes["canonical"], canonical)
+	}
+	if sizes["receipts"] != 0 || sizes["mipmaps"] != 0 {
+		t.Errorf("expected no receipts or mipmaps, got %v", sizes)
+	}
+}
+
+func TestGenesisChainConfig(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+
+	genesis := `{"difficulty": "0x400", "gasLimit": "0x2faf080", "config": {"minGasLimit": "0x2faf080", "gasLimitBoundDivisor": "0x5f5e100", "homesteadBlock": "0x0", "chainId": "0x3", "networkId": "0x7", "maxTransactionSize": "0x400"}}`
+	block, err := WriteGenesisBlock(db, strings.NewReader(genesis))
+	if err != nil {
+		t.Fatalf("failed to write genesis block: %v", err)
+	}
+	config := GetChainConfig(db, block.Hash())
+	if config == nil {
+		t.Fatalf("stored chain config not found")
+	}
+	if config.MinGasLimit.Cmp(big.NewInt(50000000)) != 0 {
+		t.Errorf("minimum gas limit mismatch: have %v, want 50000000", config.MinGasLimit)
+	}
+	if config.GasLimitBoundDivisor.Cmp(big.NewInt(100000000)) != 0 {
+		t.Errorf("gas limit bound divisor mismatch: have %v, want 100000000", config.GasLimitBoundDivisor)
+	}
+	if config.HomesteadBlock == nil || config.HomesteadBlock.Sign() != 0 {
+		t.Errorf("homestead block mismatch: have %v, want 0", config.HomesteadBlock)
+	}
+	if config.ChainId == nil || config.ChainId.Cmp(big.NewInt(3)) != 0 {
+		t.Errorf("chain id mismatch: have %v, want 3", config.ChainId)
+	}
+	if id := config.Network(1); id != 7 {
+		t.Errorf("network id mismatch: have %d, want 7", id)
+	}
+	// The fork schedule of the chain replaces the default one.
+	defer func(homestead, chainId *big.Int) {
+		params.HomesteadBlock, params.ChainId = homestead, chainId
+	}(params.HomesteadBlock, params.ChainId)
+	defer func(minGasLimit, divisor *big.Int) {
+		params.MinGasLimit, params.GasLimitBoundDivisor = minGasLimit, divisor
+	}(params.MinGasLimit, params.GasLimitBoundDivisor)
+	defer func(size uint64) { params.MaxTransactionSize = size }(params.MaxTransactionSize)
+
+	config.Apply()
+	if params.MaxTransactionSize != 1024 {
+		t.Errorf("applied maximum transaction size mismatch: have %d, want 1024", params.MaxTransactionSize)
+	}
+	if !params.IsHomestead(big.NewInt(0)) {
+		t.Errorf("homestead rules not active from the configured block")
+	}
+	if params.ChainId.Cmp(big.NewInt(3)) != 0 {
+		t.Errorf("applied chain id mismatch: have %v, want 3", params.ChainId)
+	}
+	// Chains without a config keep the protocol defaults.
+	db, _ = ethdb.NewMemDatabase()
+	if block, err = WriteGenesisBlock(db, strings.NewReader(`{"difficulty": "0x400", "gasLimit": "0x2faf080"}`)); err != nil {
+		t.Fatalf("failed to write genesis block: %v", err)
+	}
+	config = GetChainConfig(db, block.Hash())
+	if config != nil {
+		t.Errorf("unexpected chain config %v", config)
+	}
+	if id := config.Network(1); id != 1 {
+		t.Errorf("default network id mismatch: have %d, want 1", id)
+	}
+	if _, err := WriteGenesisBlock(db, strings.NewReader(`{"config": {"gasLimitBoundDivisor": "0x0"}}`)); err == nil {
+		t.Errorf("expected error for zero gas limit bound divisor")
+	}
+	if _, err := WriteGenesisBlock(db, strings.NewReader(`{"config": {"chainId": "0x0"}}`)); err == nil {
+		t.Errorf("expected error for zero chain id")
+	}
+	if _, err := WriteGenesisBlock(db, strings.NewReader(`{"config": {"eip155Block": "0x0"}}`)); err == nil {
+		t.Errorf("expected error for EIP-155 block without chain id")
+	}
+	if _, err := WriteGenesisBlock(db, strings.NewReader(`{"config": {"networkId": "0x100000000"}}`)); err == nil {
+		t.Errorf("expected error for network id overflowing the handshake")
+	}
+	if _, err := WriteGenesisBlock(db, strings.NewReader(`{"config": {"maxTransactionSize": "0x0"}}`)); err == nil {
+		t.Errorf("expected error for zero maximum transaction size")
+	}
+	if _, err := WriteGenesisBlock(db, strings.NewReader(`{"config": {"cliqueEpoch": "0x0"}}`)); err == nil {
+		t.Errorf("expected error for zero clique epoch")
+	}
+}
+
+// Tests that the common ancestor of two chains is found, whichever is longer.
+func TestFindCommonAncestor(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+	genesis := WriteGenesisBlockForTesting(db)
+
+	chain, _ := GenerateChain(genesis, db, 6, func(i int, gen *BlockGen) {})
+	fork, _ := GenerateChain(chain[2], db, 2, func(i int, gen *BlockGen) {
+		gen.SetCoinbase(common.Address{0x01})
+	})
+	for _, block := range append(chain, fork...) {
+		if err := WriteHeader(db, block.Header()); err != nil {
+			t.Fatalf("failed to write header: %v", err)
+		}
+	}
+	tests := []struct {
+		a, b *types.Block
+		want *types.Block
+	}{
+		{chain[5], fork[1], chain[2]},
+		{fork[1], chain[5], chain[2]},
+		{chain[5], chain[3], chain[3]},
+		{fork[0], fork[0], fork[0]},
+	}
+	for i, tt := range tests {
+		ancestor := FindCommonAncestor(db, tt.a.Header(), tt.b.Header())
+		if ancestor == nil || ancestor.Hash() != tt.want.Hash() {
+			t.Errorf("test %d: ancestor mismatch: have %v, want #%d", i, ancestor, tt.want.NumberU64())
+		}
+	}
+	// Chains with missing headers have no known ancestor
+	orphan := &types.Header{ParentHash: common.Hash{0xff}, Number: big.NewInt(3)}
+	if ancestor := FindCommonAncestor(db, chain[5].Header(), orphan); ancestor != nil {
+		t.Errorf("orphan ancestor mismatch: have #%d, want nil", ancestor.Number)
+	}
+}
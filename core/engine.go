@@ -0,0 +1,125 @@
+// Copyright 2016 The go-expanse Authors
+// This file is part of the go-expanse library.
+//
+// The go-expanse library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-expanse library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-expanse library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/expanse-project/go-expanse/common"
+	"github.com/expanse-project/go-expanse/core/state"
+	"github.com/expanse-project/go-expanse/core/types"
+	"github.com/expanse-project/go-expanse/params"
+	"github.com/expanse-project/go-expanse/pow"
+)
+
+// Engine is a consensus engine, deciding which blocks are sealed correctly and
+// how new ones get sealed. The block chain and the miner only reach the
+// consensus specific rules through it, so alternative engines can be plugged
+// in without touching either of them.
+type Engine interface {
+	// Prepare initialises the consensus specific fields of a header, like its
+	// difficulty, before it is filled with transactions and sealed.
+	Prepare(header, parent *types.Header) error
+
+	// VerifyHeader checks the consensus specific fields of a header, like its
+	// difficulty and extra data, against its parent. uncle is set if the
+	// header is included as an uncle.
+	VerifyHeader(header, parent *types.Header, uncle bool) error
+
+	// VerifySeal checks whether the header is sealed according to the rules
+	// of the engine. It may be called concurrently.
+	VerifySeal(header *types.Header) error
+
+	// Finalize credits the rewards of a block once its transactions have been
+	// applied to statedb.
+	Finalize(statedb *state.StateDB, header *types.Header, uncles []*types.Header)
+
+	// Seal tries to seal block, returning the sealed block or nil if stop was
+	// closed first. index tells multiple concurrent sealers apart.
+	Seal(block *types.Block, stop <-chan struct{}, index int) *types.Block
+
+	// Hashrate returns the number of sealing attempts per second done by the
+	// engine itself.
+	Hashrate() int64
+}
+
+// HeaderTracker is implemented by engines whose rules depend on the ancestors
+// of a header, like the signer set of proof-of-authority. The chain hands them
+// every batch of headers before verifying it, so the ancestors of a header can
+// be resolved before they are written to the database.
+type HeaderTracker interface {
+	TrackHeaders(headers []*types.Header)
+}
+
+// PowEngine is the proof-of-work consensus engine, sealing blocks by searching
+// for a nonce satisfying the difficulty of the block.
+type PowEngine struct {
+	PoW pow.PoW
+}
+
+// NewPowEngine creates a proof-of-work consensus engine from the given pow.
+func NewPowEngine(pow pow.PoW) *PowEngine {
+	return &PowEngine{PoW: pow}
+}
+
+// Prepare implements Engine by setting the difficulty of the header according
+// to the difficulty adjustment algorithm.
+func (e *PowEngine) Prepare(header, parent *types.Header) error {
+	header.Difficulty = CalcDifficulty(header.Time.Uint64(), parent.Time.Uint64(), parent.Number, parent.Difficulty)
+	return nil
+}
+
+// VerifyHeader implements Engine, checking the size of the extra data and the
+// difficulty of the header.
+func (e *PowEngine) VerifyHeader(header, parent *types.Header, uncle bool) error {
+	if big.NewInt(int64(len(header.Extra))).Cmp(params.MaximumExtraDataSize) == 1 {
+		return fmt.Errorf("Header extra data too long (%d)", len(header.Extra))
+	}
+	expd := CalcDifficulty(header.Time.Uint64(), parent.Time.Uint64(), parent.Number, parent.Difficulty)
+	if expd.Cmp(header.Difficulty) != 0 {
+		return fmt.Errorf("Difficulty check failed for header %v, %v", header.Difficulty, expd)
+	}
+	return nil
+}
+
+// VerifySeal implements Engine by checking the nonce of the header.
+func (e *PowEngine) VerifySeal(header *types.Header) error {
+	if !e.PoW.Verify(types.NewBlockWithHeader(header)) {
+		return &BlockNonceErr{header.Number, header.Hash(), header.Nonce.Uint64()}
+	}
+	return nil
+}
+
+// Finalize implements Engine, rewarding the coinbase and the uncles.
+func (e *PowEngine) Finalize(statedb *state.StateDB, header *types.Header, uncles []*types.Header) {
+	AccumulateRewards(statedb, header, uncles)
+}
+
+// Seal implements Engine by searching for a valid nonce.
+func (e *PowEngine) Seal(block *types.Block, stop <-chan struct{}, index int) *types.Block {
+	nonce, mixDigest := e.PoW.Search(block, stop, index)
+	if nonce == 0 {
+		return nil
+	}
+	return block.WithMiningResult(nonce, common.BytesToHash(mixDigest))
+}
+
+// Hashrate implements Engine.
+func (e *PowEngine) Hashrate() int64 {
+	return e.PoW.GetHashrate()
+}
@@ -120,6 +120,49 @@ func IsBlockNonceErr(err error) bool {
 	return ok
 }
 
+// FutureBlockErr indicates that a block's timestamp is further ahead of the
+// local clock than the allowed drift, so it can't simply be queued for later.
+type FutureBlockErr struct {
+	Number *big.Int
+	Hash   common.Hash
+	Time   uint64
+	Now    uint64
+	Drift  uint64
+}
+
+func (err *FutureBlockErr) Error() string {
+	return fmt.Sprintf("block #%d [%x…] timestamp %d is %ds ahead of the local clock (allowed drift %ds); check the clocks of this node and of the block's miner",
+		err.Number, err.Hash[:4], err.Time, err.Time-err.Now, err.Drift)
+}
+
+// IsFutureBlockErr returns true for blocks too far ahead of the local clock.
+func IsFutureBlockErr(err error) bool {
+	_, ok := err.(*FutureBlockErr)
+	return ok
+}
+
+// MedianTimeErr indicates that the local clock lags behind the median
+// timestamp of the recent ancestors of a block, which means the local clock
+// (or the clocks of most of the network's miners) is skewed.
+type MedianTimeErr struct {
+	Number *big.Int
+	Hash   common.Hash
+	Median uint64
+	Now    uint64
+	Blocks int
+}
+
+func (err *MedianTimeErr) Error() string {
+	return fmt.Sprintf("block #%d [%x…]: local clock %d is %ds behind the median time %d of the last %d blocks; the clock of this node is likely skewed",
+		err.Number, err.Hash[:4], err.Now, err.Median-err.Now, err.Median, err.Blocks)
+}
+
+// IsMedianTimeErr returns true for median time violations.
+func IsMedianTimeErr(err error) bool {
+	_, ok := err.(*MedianTimeErr)
+	return ok
+}
+
 type InvalidTxErr struct {
 	Message string
 }
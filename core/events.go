@@ -17,17 +17,30 @@
 package core
 
 import (
-	"math/big"
-
 	"github.com/expanse-project/go-expanse/common"
 	"github.com/expanse-project/go-expanse/core/types"
 	"github.com/expanse-project/go-expanse/core/vm"
 
 )
 
+// Sizes of the channels subscribed to the event feeds. Subscriptions are
+// dropped when they fall behind, so the buffers must absorb bursts such as
+// the transactions of a full pool or the blocks of an imported batch.
+const (
+	TxChanSize    = 4096 // channels of TxPreEvent and TxDropEvent
+	ChainChanSize = 64   // channels of chain events and logs
+)
+
 // TxPreEvent is posted when a transaction enters the transaction pool.
 type TxPreEvent struct{ Tx *types.Transaction }
 
+// TxDropEvent is posted when a transaction is removed from the transaction pool
+// without being included in the chain.
+type TxDropEvent struct {
+	Tx     *types.Transaction
+	Reason TxDropReason
+}
+
 // TxPostEvent is posted when a transaction has been processed.
 type TxPostEvent struct{ Tx *types.Transaction }
 
@@ -40,11 +53,9 @@ type NewMinedBlockEvent struct{ Block *types.Block }
 // RemovedTransactionEvent is posted when a reorg happens
 type RemovedTransactionEvent struct{ Txs types.Transactions }
 
-// ChainSplit is posted when a new head is detected
-type ChainSplitEvent struct {
-	Block *types.Block
-	Logs  vm.Logs
-}
+// RemovedLogsEvent is posted when a reorg happens, carrying the logs of the
+// blocks dropped from the canonical chain marked as removed.
+type RemovedLogsEvent struct{ Logs vm.Logs }
 
 type ChainEvent struct {
 	Block *types.Block
@@ -68,8 +79,6 @@ type ChainUncleEvent struct {
 
 type ChainHeadEvent struct{ Block *types.Block }
 
-type GasPriceChanged struct{ Price *big.Int }
-
 // Mining operation events
 type StartMining struct{}
 type TopMining struct{}
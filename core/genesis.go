@@ -33,6 +33,53 @@ import (
 	"github.com/expanse-project/go-expanse/params"
 )
 
+// ChainConfig holds the protocol parameters and fork schedule a chain configures
+// in its genesis file. Parameters which aren't set keep their defaults.
+type ChainConfig struct {
+	MinGasLimit          *big.Int `json:"minGasLimit,omitempty"`          // Minimum the gas limit may ever be
+	GasLimitBoundDivisor *big.Int `json:"gasLimitBoundDivisor,omitempty"` // Bound divisor of gas limit updates, above the gas limit to fix it
+	HomesteadBlock       *big.Int `json:"homesteadBlock,omitempty"`       // Block number of the Homestead transition
+	ChainId              *big.Int `json:"chainId,omitempty"`              // Chain identifier for replay protected signatures
+	EIP155Block          *big.Int `json:"eip155Block,omitempty"`          // Block number from which replay protected signatures are valid
+	NetworkId            *big.Int `json:"networkId,omitempty"`            // Network identifier exchanged in the p2p handshake
+	MaxTransactionSize   *big.Int `json:"maxTransactionSize,omitempty"`   // Maximum RLP encoded size of a transaction in a block
+	CliquePeriod         *big.Int `json:"cliquePeriod,omitempty"`         // Minimum number of seconds between proof-of-authority blocks
+	CliqueEpoch          *big.Int `json:"cliqueEpoch,omitempty"`          // Number of proof-of-authority blocks after which votes are reset
+}
+
+// Network returns the network id of the chain. The one configured in the
+// genesis takes precedence over def, the id selected on the command line, so
+// all nodes of a chain agree on it.
+func (c *ChainConfig) Network(def int) int {
+	if c == nil || c.NetworkId == nil {
+		return def
+	}
+	return int(c.NetworkId.Int64())
+}
+
+// Apply sets the protocol parameters to the ones configured, overriding the
+// defaults of the network selected on the command line.
+func (c *ChainConfig) Apply() {
+	if c.MinGasLimit != nil {
+		params.MinGasLimit = c.MinGasLimit
+	}
+	if c.GasLimitBoundDivisor != nil {
+		params.GasLimitBoundDivisor = c.GasLimitBoundDivisor
+	}
+	if c.HomesteadBlock != nil {
+		params.HomesteadBlock = c.HomesteadBlock
+	}
+	if c.ChainId != nil {
+		params.ChainId = c.ChainId
+	}
+	if c.EIP155Block != nil {
+		params.EIP155Block = c.EIP155Block
+	}
+	if c.MaxTransactionSize != nil {
+		params.MaxTransactionSize = c.MaxTransactionSize.Uint64()
+	}
+}
+
 // WriteGenesisBlock writes the genesis block to the database as block number 0
 func WriteGenesisBlock(chainDb ethdb.Database, reader io.Reader) (*types.Block, error) {
 	contents, err := ioutil.ReadAll(reader)
@@ -54,11 +101,83 @@ func WriteGenesisBlock(chainDb ethdb.Database, reader io.Reader) (*types.Block,
 			Storage map[string]string
 			Balance string
 		}
+		Config *struct {
+			MinGasLimit          string
+			GasLimitBoundDivisor string
+			HomesteadBlock       string
+			ChainId              string
+			EIP155Block          string
+			NetworkId            string
+			MaxTransactionSize   string
+			CliquePeriod         string
+			CliqueEpoch          string
+		}
 	}
 
 	if err := json.Unmarshal(contents, &genesis); err != nil {
 		return nil, err
 	}
+	var config *ChainConfig
+	if genesis.Config != nil {
+		config = new(ChainConfig)
+		if genesis.Config.MinGasLimit != "" {
+			config.MinGasLimit = common.String2Big(genesis.Config.MinGasLimit)
+			if config.MinGasLimit.Sign() <= 0 {
+				return nil, fmt.Errorf("invalid minimum gas limit %s", genesis.Config.MinGasLimit)
+			}
+		}
+		if genesis.Config.GasLimitBoundDivisor != "" {
+			config.GasLimitBoundDivisor = common.String2Big(genesis.Config.GasLimitBoundDivisor)
+			if config.GasLimitBoundDivisor.Sign() <= 0 {
+				return nil, fmt.Errorf("invalid gas limit bound divisor %s", genesis.Config.GasLimitBoundDivisor)
+			}
+		}
+		if genesis.Config.HomesteadBlock != "" {
+			config.HomesteadBlock = common.String2Big(genesis.Config.HomesteadBlock)
+			if config.HomesteadBlock.Sign() < 0 {
+				return nil, fmt.Errorf("invalid homestead block %s", genesis.Config.HomesteadBlock)
+			}
+		}
+		if genesis.Config.ChainId != "" {
+			config.ChainId = common.String2Big(genesis.Config.ChainId)
+			if config.ChainId.Sign() <= 0 {
+				return nil, fmt.Errorf("invalid chain id %s", genesis.Config.ChainId)
+			}
+		}
+		if genesis.Config.EIP155Block != "" {
+			if config.ChainId == nil {
+				return nil, fmt.Errorf("EIP-155 block %s configured without a chain id", genesis.Config.EIP155Block)
+			}
+			config.EIP155Block = common.String2Big(genesis.Config.EIP155Block)
+			if config.EIP155Block.Sign() < 0 {
+				return nil, fmt.Errorf("invalid EIP-155 block %s", genesis.Config.EIP155Block)
+			}
+		}
+		if genesis.Config.NetworkId != "" {
+			config.NetworkId = common.String2Big(genesis.Config.NetworkId)
+			if config.NetworkId.Sign() < 0 || config.NetworkId.BitLen() > 32 {
+				return nil, fmt.Errorf("invalid network id %s", genesis.Config.NetworkId)
+			}
+		}
+		if genesis.Config.MaxTransactionSize != "" {
+			config.MaxTransactionSize = common.String2Big(genesis.Config.MaxTransactionSize)
+			if config.MaxTransactionSize.Sign() <= 0 || config.MaxTransactionSize.BitLen() > 63 {
+				return nil, fmt.Errorf("invalid maximum transaction size %s", genesis.Config.MaxTransactionSize)
+			}
+		}
+		if genesis.Config.CliquePeriod != "" {
+			config.CliquePeriod = common.String2Big(genesis.Config.CliquePeriod)
+			if config.CliquePeriod.Sign() < 0 || config.CliquePeriod.BitLen() > 63 {
+				return nil, fmt.Errorf("invalid clique period %s", genesis.Config.CliquePeriod)
+			}
+		}
+		if genesis.Config.CliqueEpoch != "" {
+			config.CliqueEpoch = common.String2Big(genesis.Config.CliqueEpoch)
+			if config.CliqueEpoch.Sign() <= 0 || config.CliqueEpoch.BitLen() > 63 {
+				return nil, fmt.Errorf("invalid clique epoch %s", genesis.Config.CliqueEpoch)
+			}
+		}
+	}
 
 	// creating with empty hash always works
 	statedb, _ := state.New(common.Hash{}, chainDb)
@@ -91,6 +210,11 @@ func WriteGenesisBlock(chainDb ethdb.Database, reader io.Reader) (*types.Block,
 		if err != nil {
 			return nil, err
 		}
+		if config != nil {
+			if err := WriteChainConfig(chainDb, block.Hash(), config); err != nil {
+				return nil, err
+			}
+		}
 		return block, nil
 	}
 
@@ -112,6 +236,11 @@ func WriteGenesisBlock(chainDb ethdb.Database, reader io.Reader) (*types.Block,
 	if err := WriteHeadBlockHash(chainDb, block.Hash()); err != nil {
 		return nil, err
 	}
+	if config != nil {
+		if err := WriteChainConfig(chainDb, block.Hash(), config); err != nil {
+			return nil, err
+		}
+	}
 	return block, nil
 }
 
@@ -0,0 +1,80 @@
+// Copyright 2016 The go-expanse Authors
+// This file is part of the go-expanse library.
+//
+// The go-expanse library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-expanse library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-expanse library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// HashrateEstimate is the network hashrate estimated from the work proven by
+// the canonical blocks and their uncles after From up to and including To, and
+// the time which passed between the timestamps of From and To.
+type HashrateEstimate struct {
+	From, To uint64
+	Work     *big.Int // sum of the difficulties of the blocks and uncles
+	Seconds  uint64   // time between the timestamps of From and To
+}
+
+// Hashrate returns the estimated number of hashes per second.
+func (e *HashrateEstimate) Hashrate() *big.Int {
+	if e.Seconds == 0 {
+		return new(big.Int)
+	}
+	return new(big.Int).Div(e.Work, new(big.Int).SetUint64(e.Seconds))
+}
+
+// BlockTime returns the average number of seconds between two blocks.
+func (e *HashrateEstimate) BlockTime() float64 {
+	if e.To == e.From {
+		return 0
+	}
+	return float64(e.Seconds) / float64(e.To-e.From)
+}
+
+// EstimateHashrate estimates the network hashrate over the window of canonical
+// blocks ending at block number to. The window is cut at the genesis block.
+func (self *BlockChain) EstimateHashrate(to uint64, window uint64) (*HashrateEstimate, error) {
+	if window == 0 {
+		return nil, fmt.Errorf("empty window")
+	}
+	from := uint64(0)
+	if to > window {
+		from = to - window
+	}
+	first := self.GetHeaderByNumber(from)
+	if first == nil {
+		return nil, fmt.Errorf("block #%d not found", from)
+	}
+	estimate := &HashrateEstimate{From: from, To: to, Work: new(big.Int)}
+	last := first
+	for num := from + 1; num <= to; num++ {
+		block := self.GetBlockByNumber(num)
+		if block == nil {
+			return nil, fmt.Errorf("block #%d not found", num)
+		}
+		estimate.Work.Add(estimate.Work, block.Difficulty())
+		for _, uncle := range block.Uncles() {
+			estimate.Work.Add(estimate.Work, uncle.Difficulty)
+		}
+		last = block.Header()
+	}
+	if last.Time.Cmp(first.Time) > 0 {
+		estimate.Seconds = new(big.Int).Sub(last.Time, first.Time).Uint64()
+	}
+	return estimate, nil
+}
@@ -0,0 +1,65 @@
+// Copyright 2016 The go-expanse Authors
+// This file is part of the go-expanse library.
+//
+// The go-expanse library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-expanse library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-expanse library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/expanse-project/go-expanse/ethdb"
+)
+
+func TestEstimateHashrate(t *testing.T) {
+	var (
+		db, _   = ethdb.NewMemDatabase()
+		genesis = WriteGenesisBlockForTesting(db)
+	)
+	chain, _ := GenerateChain(genesis, db, 4, func(i int, gen *BlockGen) {
+		if i == 3 {
+			uncle := gen.PrevBlock(1).Header()
+			uncle.Extra = []byte("foo")
+			gen.AddUncle(uncle)
+		}
+	})
+	blockchain, _ := NewBlockChain(db, FakePow{})
+	if i, err := blockchain.InsertChain(chain); err != nil {
+		t.Fatalf("failed to insert block %d: %v", i, err)
+	}
+	estimate, err := blockchain.EstimateHashrate(4, 3)
+	if err != nil {
+		t.Fatalf("failed to estimate hashrate: %v", err)
+	}
+	work := new(big.Int).Add(chain[1].Difficulty(), chain[2].Difficulty())
+	work.Add(work, chain[3].Difficulty())
+	work.Add(work, chain[1].Difficulty())
+	if estimate.From != 1 || estimate.To != 4 || estimate.Work.Cmp(work) != 0 {
+		t.Errorf("estimate mismatch: have #%d-#%d work %v, want #1-#4 work %v", estimate.From, estimate.To, estimate.Work, work)
+	}
+	if estimate.Seconds != 30 || estimate.BlockTime() != 10 {
+		t.Errorf("time mismatch: have %ds, %vs per block, want 30s, 10s per block", estimate.Seconds, estimate.BlockTime())
+	}
+	if rate := estimate.Hashrate(); rate.Cmp(new(big.Int).Div(work, big.NewInt(30))) != 0 {
+		t.Errorf("hashrate mismatch: have %v, want %v", rate, new(big.Int).Div(work, big.NewInt(30)))
+	}
+	// Windows reaching beyond the genesis block are cut
+	if estimate, err = blockchain.EstimateHashrate(2, 10); err != nil || estimate.From != 0 {
+		t.Errorf("window not cut at genesis: have %v, %v", estimate, err)
+	}
+	if _, err := blockchain.EstimateHashrate(5, 3); err == nil {
+		t.Errorf("expected error for missing block")
+	}
+}
@@ -0,0 +1,77 @@
+// Copyright 2016 The go-expanse Authors
+// This file is part of the go-expanse library.
+//
+// The go-expanse library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-expanse library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-expanse library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"testing"
+
+	"github.com/expanse-project/go-expanse/common"
+	"github.com/expanse-project/go-expanse/core/types"
+	"github.com/expanse-project/go-expanse/ethdb"
+)
+
+// Tests that a header chain can be extended, reorganised and rewound without
+// any block bodies being present.
+func TestHeaderChainWithoutBodies(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+	genesis := WriteGenesisBlockForTesting(db)
+
+	hc, err := NewHeaderChain(db, func() HeaderValidator { return bproc{} }, func() bool { return false })
+	if err != nil {
+		t.Fatalf("failed to create header chain: %v", err)
+	}
+	if hc.CurrentHeader().Hash() != genesis.Hash() {
+		t.Fatalf("head mismatch: have %x, want genesis %x", hc.CurrentHeader().Hash(), genesis.Hash())
+	}
+	write := func(chain []*types.Header) {
+		if i, err := hc.InsertHeaderChain(chain, 1, hc.WriteHeader); err != nil {
+			t.Fatalf("failed to insert header %d: %v", i, err)
+		}
+	}
+	// Extend the canonical chain with headers only
+	canon := makeHeaderChainWithDiff(genesis, []int{1, 1, 1}, 10)
+	write(canon)
+	if head := hc.CurrentHeader(); head.Hash() != canon[2].Hash() {
+		t.Fatalf("head mismatch: have #%d, want #%d", head.Number, canon[2].Number)
+	}
+	// A heavier side chain must take over
+	side := makeHeaderChainWithDiff(genesis, []int{1, 1, 3}, 11)
+	write(side)
+	if head := hc.CurrentHeader(); head.Hash() != side[2].Hash() {
+		t.Fatalf("head not reorganised: have %x, want %x", head.Hash(), side[2].Hash())
+	}
+	for i, header := range side {
+		if hash := GetCanonicalHash(db, header.Number.Uint64()); hash != header.Hash() {
+			t.Errorf("canonical hash %d mismatch: have %x, want %x", i+1, hash, header.Hash())
+		}
+	}
+	// Rewinding must drop the headers above the new head
+	var deleted int
+	hc.SetHead(1, func(common.Hash) { deleted++ })
+	if head := hc.CurrentHeader(); head.Hash() != side[0].Hash() {
+		t.Fatalf("head mismatch after rewind: have #%d, want #1", head.Number)
+	}
+	if deleted != 2 {
+		t.Errorf("delete callback count mismatch: have %d, want 2", deleted)
+	}
+	if hc.HasHeader(side[2].Hash()) || hc.GetTd(side[2].Hash()) != nil {
+		t.Errorf("rewound header still present")
+	}
+	if hash := GetCanonicalHash(db, 2); hash != (common.Hash{}) {
+		t.Errorf("canonical hash above head not deleted: %x", hash)
+	}
+}
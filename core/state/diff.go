@@ -0,0 +1,132 @@
+// Copyright 2016 The go-expanse Authors
+// This file is part of the go-expanse library.
+//
+// The go-expanse library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-expanse library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-expanse library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/expanse-project/go-expanse/common"
+	"github.com/expanse-project/go-expanse/ethdb"
+	"github.com/expanse-project/go-expanse/rlp"
+	"github.com/expanse-project/go-expanse/trie"
+)
+
+// ModifiedAccount is an account whose state differs between two state roots,
+// along with the storage slots that were changed if requested.
+type ModifiedAccount struct {
+	Address common.Address
+	Storage []common.Hash
+}
+
+// ModifiedAccounts returns the accounts modified between the state roots from
+// and to, sorted by address. If storage is set, the modified storage slots of
+// each account are collected as well.
+func ModifiedAccounts(db ethdb.Database, from, to common.Hash, storage bool) ([]ModifiedAccount, error) {
+	a, err := trie.NewSecure(from, db)
+	if err != nil {
+		return nil, err
+	}
+	b, err := trie.NewSecure(to, db)
+	if err != nil {
+		return nil, err
+	}
+	keys, err := trie.Diff(a.Trie, b.Trie)
+	if err != nil {
+		return nil, err
+	}
+	var accounts []ModifiedAccount
+	for _, key := range keys {
+		addr := b.GetKey(key)
+		if addr == nil {
+			return nil, fmt.Errorf("missing preimage for account key %x", key)
+		}
+		account := ModifiedAccount{Address: common.BytesToAddress(addr)}
+		if storage {
+			if account.Storage, err = modifiedStorage(db, a.Get(addr), b.Get(addr)); err != nil {
+				return nil, err
+			}
+		}
+		accounts = append(accounts, account)
+	}
+	sort.Sort(modifiedAccounts(accounts))
+	return accounts, nil
+}
+
+// modifiedStorage diffs the storage tries of two RLP encoded accounts, either
+// of which may be missing.
+func modifiedStorage(db ethdb.Database, from, to []byte) ([]common.Hash, error) {
+	fromRoot, err := storageRoot(from)
+	if err != nil {
+		return nil, err
+	}
+	toRoot, err := storageRoot(to)
+	if err != nil {
+		return nil, err
+	}
+	if fromRoot == toRoot {
+		return nil, nil
+	}
+	a, err := trie.NewSecure(fromRoot, db)
+	if err != nil {
+		return nil, err
+	}
+	b, err := trie.NewSecure(toRoot, db)
+	if err != nil {
+		return nil, err
+	}
+	keys, err := trie.Diff(a.Trie, b.Trie)
+	if err != nil {
+		return nil, err
+	}
+	var slots []common.Hash
+	for _, key := range keys {
+		slot := b.GetKey(key)
+		if slot == nil {
+			return nil, fmt.Errorf("missing preimage for storage key %x", key)
+		}
+		slots = append(slots, common.BytesToHash(slot))
+	}
+	return slots, nil
+}
+
+// storageRoot extracts the storage trie root of an RLP encoded account.
+func storageRoot(data []byte) (common.Hash, error) {
+	if len(data) == 0 {
+		return common.Hash{}, nil
+	}
+	var extobject struct {
+		Nonce    uint64
+		Balance  *big.Int
+		Root     common.Hash
+		CodeHash []byte
+	}
+	if err := rlp.Decode(bytes.NewReader(data), &extobject); err != nil {
+		return common.Hash{}, err
+	}
+	return extobject.Root, nil
+}
+
+type modifiedAccounts []ModifiedAccount
+
+func (s modifiedAccounts) Len() int      { return len(s) }
+func (s modifiedAccounts) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s modifiedAccounts) Less(i, j int) bool {
+	return bytes.Compare(s[i].Address[:], s[j].Address[:]) < 0
+}
@@ -0,0 +1,73 @@
+// Copyright 2016 The go-expanse Authors
+// This file is part of the go-expanse library.
+//
+// The go-expanse library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-expanse library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-expanse library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/expanse-project/go-expanse/common"
+	"github.com/expanse-project/go-expanse/ethdb"
+)
+
+func TestModifiedAccounts(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+	state, _ := New(common.Hash{}, db)
+	for i := byte(1); i <= 4; i++ {
+		state.AddBalance(common.BytesToAddress([]byte{i}), big.NewInt(int64(i)))
+	}
+	state.SetState(common.BytesToAddress([]byte{1}), common.BytesToHash([]byte{1}), common.BytesToHash([]byte{1}))
+	from, err := state.Commit()
+	if err != nil {
+		t.Fatalf("failed to commit state: %v", err)
+	}
+
+	state.AddBalance(common.BytesToAddress([]byte{3}), big.NewInt(1))
+	state.AddBalance(common.BytesToAddress([]byte{5}), big.NewInt(1))
+	state.SetState(common.BytesToAddress([]byte{1}), common.BytesToHash([]byte{2}), common.BytesToHash([]byte{2}))
+	to, err := state.Commit()
+	if err != nil {
+		t.Fatalf("failed to commit state: %v", err)
+	}
+
+	accounts, err := ModifiedAccounts(db, from, to, false)
+	if err != nil {
+		t.Fatalf("failed to diff states: %v", err)
+	}
+	want := []ModifiedAccount{
+		{Address: common.BytesToAddress([]byte{1})},
+		{Address: common.BytesToAddress([]byte{3})},
+		{Address: common.BytesToAddress([]byte{5})},
+	}
+	if !reflect.DeepEqual(accounts, want) {
+		t.Errorf("modified accounts mismatch: have %v, want %v", accounts, want)
+	}
+
+	accounts, err = ModifiedAccounts(db, from, to, true)
+	if err != nil {
+		t.Fatalf("failed to diff states: %v", err)
+	}
+	want[0].Storage = []common.Hash{common.BytesToHash([]byte{2})}
+	if !reflect.DeepEqual(accounts, want) {
+		t.Errorf("modified storage mismatch: have %v, want %v", accounts, want)
+	}
+
+	if accounts, _ := ModifiedAccounts(db, to, to, true); len(accounts) != 0 {
+		t.Errorf("identical states reported modifications: %v", accounts)
+	}
+}
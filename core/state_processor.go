@@ -39,18 +39,25 @@ func (p *StateProcessor) Process(block *types.Block, statedb *state.StateDB) (ty
 		header       = block.Header()
 		allLogs      vm.Logs
 		gp           = new(GasPool).AddGas(block.GasLimit())
+		stats        *vm.GasStats
 	)
+	if vm.GasMetrics {
+		stats = vm.NewGasStats()
+	}
 
 	for i, tx := range block.Transactions() {
 		statedb.StartRecord(tx.Hash(), block.Hash(), i)
-		receipt, logs, _, err := ApplyTransaction(p.bc, gp, statedb, header, tx, totalUsedGas)
+		receipt, logs, _, err := applyTransaction(p.bc, gp, statedb, header, tx, totalUsedGas, stats)
 		if err != nil {
 			return nil, nil, totalUsedGas, err
 		}
 		receipts = append(receipts, receipt)
 		allLogs = append(allLogs, logs...)
 	}
-	AccumulateRewards(statedb, header, block.Uncles())
+	p.bc.Engine().Finalize(statedb, header, block.Uncles())
+	if stats != nil {
+		stats.Report()
+	}
 
 	return receipts, allLogs, totalUsedGas, err
 }
@@ -61,7 +68,25 @@ func (p *StateProcessor) Process(block *types.Block, statedb *state.StateDB) (ty
 // ApplyTransactions returns the generated receipts and vm logs during the
 // execution of the state transition phase.
 func ApplyTransaction(bc *BlockChain, gp *GasPool, statedb *state.StateDB, header *types.Header, tx *types.Transaction, usedGas *big.Int) (*types.Receipt, vm.Logs, *big.Int, error) {
-	_, gas, err := ApplyMessage(NewEnv(statedb, bc, tx, header), tx, gp)
+	return applyTransaction(bc, gp, statedb, header, tx, usedGas, nil)
+}
+
+// applyTransaction is ApplyTransaction also accounting the gas usage of the
+// transaction into stats, if not nil.
+func applyTransaction(bc *BlockChain, gp *GasPool, statedb *state.StateDB, header *types.Header, tx *types.Transaction, usedGas *big.Int, stats *vm.GasStats) (*types.Receipt, vm.Logs, *big.Int, error) {
+	// Replay protected transactions are only valid on their own chain once
+	// protection is enabled
+	if _, err := types.MakeSigner(header.Number).Sender(tx); err != nil {
+		return nil, nil, nil, InvalidTxError(err)
+	}
+	env := NewEnv(statedb, bc, tx, header)
+	env.SetGasStats(stats)
+	var tracer *CallTracer
+	if RecordInternalTxs {
+		tracer = NewCallTracer(0)
+		env.SetCallTracer(tracer)
+	}
+	_, gas, err := ApplyMessage(env, tx, gp)
 	if err != nil {
 		return nil, nil, nil, err
 	}
@@ -78,6 +103,9 @@ func ApplyTransaction(bc *BlockChain, gp *GasPool, statedb *state.StateDB, heade
 
 	logs := statedb.GetLogs(tx.Hash())
 	receipt.Logs = logs
+	if tracer != nil {
+		receipt.InternalTxs = internalTxs(tx.Hash(), tracer.Root())
+	}
 	receipt.Bloom = types.CreateBloom(types.Receipts{receipt})
 
 	glog.V(logger.Debug).Infoln(receipt)
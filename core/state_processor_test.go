@@ -0,0 +1,83 @@
+// Copyright 2016 The go-expanse Authors
+// This file is part of the go-expanse library.
+//
+// The go-expanse library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-expanse library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-expanse library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/expanse-project/go-expanse/common"
+	"github.com/expanse-project/go-expanse/core/state"
+	"github.com/expanse-project/go-expanse/core/types"
+	"github.com/expanse-project/go-expanse/core/vm"
+	"github.com/expanse-project/go-expanse/crypto"
+	"github.com/expanse-project/go-expanse/ethdb"
+)
+
+func TestGasStats(t *testing.T) {
+	defer func(enabled bool) { vm.GasMetrics = enabled }(vm.GasMetrics)
+	vm.GasMetrics = true
+
+	var (
+		key, _   = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		addr     = crypto.PubkeyToAddress(key.PublicKey)
+		contract = common.Address{0xaa}
+		db, _    = ethdb.NewMemDatabase()
+	)
+	// The contract sets slot 0 and clears it again
+	genesis, err := WriteGenesisBlock(db, strings.NewReader(fmt.Sprintf(`{
+	"nonce": "0x0000000000000042",
+	"gasLimit": "0x2fefd8",
+	"difficulty": "0x20000",
+	"alloc": {
+		"0x%x": {"balance": "1000000000000000000"},
+		"0x%x": {"code": "6001600055600060005500"}
+	}
+}`, addr, contract)))
+	if err != nil {
+		t.Fatalf("failed to write genesis: %v", err)
+	}
+	tx, _ := types.NewTransaction(0, contract, big.NewInt(0), big.NewInt(100000), big.NewInt(1), nil).SignECDSA(key)
+
+	blockchain, _ := NewBlockChain(db, FakePow{})
+	statedb, _ := state.New(genesis.Root(), db)
+	header := &types.Header{
+		ParentHash: genesis.Hash(),
+		Number:     big.NewInt(1),
+		GasLimit:   genesis.GasLimit(),
+		Difficulty: genesis.Difficulty(),
+		Time:       big.NewInt(10),
+	}
+	stats := vm.NewGasStats()
+	if _, _, _, err := applyTransaction(blockchain, new(GasPool).AddGas(header.GasLimit), statedb, header, tx, new(big.Int), stats); err != nil {
+		t.Fatalf("failed to apply transaction: %v", err)
+	}
+	if stats.SstoreSets != 1 || stats.SstoreClears != 1 {
+		t.Errorf("SSTORE count mismatch: have %d sets and %d clears, want 1 and 1", stats.SstoreSets, stats.SstoreClears)
+	}
+	if gas := stats.OpGas[vm.SSTORE]; gas == nil || gas.Cmp(big.NewInt(25000)) != 0 {
+		t.Errorf("SSTORE gas mismatch: have %v, want 25000", gas)
+	}
+	if gas := stats.OpGas[vm.PUSH1]; gas == nil || gas.Cmp(big.NewInt(12)) != 0 {
+		t.Errorf("PUSH1 gas mismatch: have %v, want 12", gas)
+	}
+	if stats.Refund.Cmp(big.NewInt(15000)) != 0 {
+		t.Errorf("refund mismatch: have %v, want 15000", stats.Refund)
+	}
+}
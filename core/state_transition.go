@@ -275,6 +275,9 @@ func (self *StateTransition) refundGas() {
 	uhalf := remaining.Div(self.gasUsed(), common.Big2)
 	refund := common.BigMin(uhalf, self.state.GetRefund())
 	self.gas.Add(self.gas, refund)
+	if stats := vm.EnvGasStats(self.env); stats != nil {
+		stats.AddRefund(refund)
+	}
 	self.state.AddBalance(sender.Address(), refund.Mul(refund, self.gasPrice))
 
 	// Also return remaining gas to the block gas counter so it is
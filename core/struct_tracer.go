@@ -0,0 +1,123 @@
+// Copyright 2016 The go-expanse Authors
+// This file is part of the go-expanse library.
+//
+// The go-expanse library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-expanse library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-expanse library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/expanse-project/go-expanse/common"
+	"github.com/expanse-project/go-expanse/core/state"
+	"github.com/expanse-project/go-expanse/core/types"
+	"github.com/expanse-project/go-expanse/core/vm"
+)
+
+// ExecutionTrace is the instruction level trace of a transaction, as recorded
+// by a vm.StructLogger.
+type ExecutionTrace struct {
+	TxHash      common.Hash
+	Gas         *big.Int // Gas used by the transaction
+	ReturnValue []byte
+	Error       error // Error the transaction failed with, if any
+	StructLogs  []vm.StructLog
+}
+
+// stateAtTransaction reconstructs the state a block had right before the
+// transaction at the given index was applied, by replaying the transactions
+// in front of it on the state of the parent block. The returned gas pool has
+// the gas of the replayed transactions deducted.
+func stateAtTransaction(bc *BlockChain, block *types.Block, index int) (*state.StateDB, *GasPool, error) {
+	txs := block.Transactions()
+	if index < 0 || index >= len(txs) {
+		return nil, nil, fmt.Errorf("transaction index %d out of range", index)
+	}
+	parent := bc.GetBlock(block.ParentHash())
+	if parent == nil {
+		return nil, nil, ParentError(block.ParentHash())
+	}
+	statedb, err := state.New(parent.Root(), bc.chainDb)
+	if err != nil {
+		return nil, nil, err
+	}
+	var (
+		header  = block.Header()
+		gp      = new(GasPool).AddGas(block.GasLimit())
+		usedGas = new(big.Int)
+	)
+	for i, tx := range txs[:index] {
+		statedb.StartRecord(tx.Hash(), block.Hash(), i)
+		if _, _, _, err := ApplyTransaction(bc, gp, statedb, header, tx, usedGas); err != nil {
+			return nil, nil, err
+		}
+	}
+	return statedb, gp, nil
+}
+
+// traceTransaction applies the transaction at the given index of a block to
+// statedb, tracing every instruction it executes.
+func traceTransaction(bc *BlockChain, statedb *state.StateDB, gp *GasPool, block *types.Block, index int, cfg vm.LogConfig) (*ExecutionTrace, error) {
+	tx := block.Transactions()[index]
+	statedb.StartRecord(tx.Hash(), block.Hash(), index)
+
+	logger := vm.NewStructLogger(cfg)
+	env := NewEnv(statedb, bc, tx, block.Header())
+	env.SetTracer(logger)
+	ret, gas, err := ApplyMessage(env, tx, gp)
+	if err != nil {
+		return nil, err
+	}
+	statedb.IntermediateRoot()
+
+	return &ExecutionTrace{
+		TxHash:      tx.Hash(),
+		Gas:         gas,
+		ReturnValue: ret,
+		Error:       logger.Error(),
+		StructLogs:  logger.StructLogs(),
+	}, nil
+}
+
+// TraceTransaction re-executes the transaction at the given index of a block
+// on top of the state of its parent and returns the instruction level trace
+// of the transaction.
+func TraceTransaction(bc *BlockChain, block *types.Block, index int, cfg vm.LogConfig) (*ExecutionTrace, error) {
+	statedb, gp, err := stateAtTransaction(bc, block, index)
+	if err != nil {
+		return nil, err
+	}
+	return traceTransaction(bc, statedb, gp, block, index, cfg)
+}
+
+// TraceBlock re-executes all transactions of a block on top of the state of
+// its parent and returns the instruction level traces of the transactions,
+// in block order.
+func TraceBlock(bc *BlockChain, block *types.Block, cfg vm.LogConfig) ([]*ExecutionTrace, error) {
+	if len(block.Transactions()) == 0 {
+		return []*ExecutionTrace{}, nil
+	}
+	statedb, gp, err := stateAtTransaction(bc, block, 0)
+	if err != nil {
+		return nil, err
+	}
+	traces := make([]*ExecutionTrace, len(block.Transactions()))
+	for i := range block.Transactions() {
+		if traces[i], err = traceTransaction(bc, statedb, gp, block, i, cfg); err != nil {
+			return nil, err
+		}
+	}
+	return traces, nil
+}
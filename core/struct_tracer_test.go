@@ -0,0 +1,113 @@
+// Copyright 2016 The go-expanse Authors
+// This file is part of the go-expanse library.
+//
+// The go-expanse library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-expanse library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-expanse library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/expanse-project/go-expanse/common"
+	"github.com/expanse-project/go-expanse/core/types"
+	"github.com/expanse-project/go-expanse/core/vm"
+	"github.com/expanse-project/go-expanse/crypto"
+	"github.com/expanse-project/go-expanse/ethdb"
+)
+
+func TestTraceTransaction(t *testing.T) {
+	var (
+		key, _ = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		addr   = crypto.PubkeyToAddress(key.PublicKey)
+		store  = common.Address{0xaa}
+		broken = common.Address{0xbb}
+		db, _  = ethdb.NewMemDatabase()
+	)
+	// The first contract increments slot 1, the second one jumps to nowhere
+	genesis, err := WriteGenesisBlock(db, strings.NewReader(fmt.Sprintf(`{
+	"nonce": "0x0000000000000042",
+	"gasLimit": "0x2fefd8",
+	"difficulty": "0x20000",
+	"alloc": {
+		"0x%x": {"balance": "1000000000000000000"},
+		"0x%x": {"code": "600160015401600155"},
+		"0x%x": {"code": "600056"}
+	}
+}`, addr, store, broken)))
+	if err != nil {
+		t.Fatalf("failed to write genesis: %v", err)
+	}
+	chain, _ := GenerateChain(genesis, db, 1, func(i int, gen *BlockGen) {
+		for _, to := range []common.Address{store, store, broken} {
+			tx, _ := types.NewTransaction(gen.TxNonce(addr), to, big.NewInt(0), big.NewInt(100000), big.NewInt(1), nil).SignECDSA(key)
+			gen.AddTx(tx)
+		}
+	})
+	blockchain, _ := NewBlockChain(db, FakePow{})
+	if i, err := blockchain.InsertChain(chain); err != nil {
+		t.Fatalf("failed to insert block %d: %v", i, err)
+	}
+
+	// The second increment has to see the state left by the first one
+	trace, err := TraceTransaction(blockchain, chain[0], 1, vm.LogConfig{})
+	if err != nil {
+		t.Fatalf("failed to trace: %v", err)
+	}
+	if trace.TxHash != chain[0].Transactions()[1].Hash() || trace.Error != nil {
+		t.Errorf("trace mismatch: have %x (%v)", trace.TxHash, trace.Error)
+	}
+	ops := []vm.OpCode{vm.PUSH1, vm.PUSH1, vm.SLOAD, vm.ADD, vm.PUSH1, vm.SSTORE, vm.STOP}
+	if len(trace.StructLogs) != len(ops) {
+		t.Fatalf("instruction count mismatch: have %d, want %d", len(trace.StructLogs), len(ops))
+	}
+	for i, log := range trace.StructLogs {
+		if log.Op != ops[i] || log.Depth != 1 {
+			t.Errorf("instruction %d mismatch: have %v at depth %d, want %v at depth 1", i, log.Op, log.Depth, ops[i])
+		}
+	}
+	sstore := trace.StructLogs[5]
+	if len(sstore.Stack) != 2 || sstore.Stack[0].Int64() != 2 {
+		t.Errorf("stack mismatch: have %v, want [2 1]", sstore.Stack)
+	}
+	if value := sstore.Storage[common.BigToHash(big.NewInt(1))]; len(sstore.Storage) != 1 || common.BytesToHash(value) != common.BigToHash(big.NewInt(2)) {
+		t.Errorf("storage mismatch: have %x", sstore.Storage)
+	}
+	if len(trace.StructLogs[4].Storage) != 0 {
+		t.Errorf("storage recorded before the SSTORE: %x", trace.StructLogs[4].Storage)
+	}
+	if sstore.GasCost.Cmp(big.NewInt(5000)) != 0 {
+		t.Errorf("SSTORE cost mismatch: have %v, want 5000", sstore.GasCost)
+	}
+
+	// The whole block, without stack and limited to two instructions
+	traces, err := TraceBlock(blockchain, chain[0], vm.LogConfig{DisableStack: true, Limit: 2})
+	if err != nil {
+		t.Fatalf("failed to trace block: %v", err)
+	}
+	if len(traces) != 3 {
+		t.Fatalf("trace count mismatch: have %d, want 3", len(traces))
+	}
+	if len(traces[0].StructLogs) != 2 || traces[0].StructLogs[0].Stack != nil {
+		t.Errorf("config not applied: have %d instructions, stack %v", len(traces[0].StructLogs), traces[0].StructLogs[0].Stack)
+	}
+	if traces[2].Error == nil {
+		t.Errorf("expected failure of the broken contract")
+	}
+	if _, err := TraceTransaction(blockchain, chain[0], 3, vm.LogConfig{}); err == nil {
+		t.Errorf("expected error for transaction index out of range")
+	}
+}
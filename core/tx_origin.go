@@ -0,0 +1,111 @@
+// Copyright 2016 The go-expanse Authors
+// This file is part of the go-expanse library.
+//
+// The go-expanse library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-expanse library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-expanse library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"sync"
+	"time"
+
+	"github.com/expanse-project/go-expanse/metrics"
+)
+
+// maxTxOrigins is the number of origins the pool keeps statistics of. Beyond
+// it, the origin seen least recently is forgotten.
+const maxTxOrigins = 1024
+
+// Transports transactions enter the pool through, other than the names of the
+// RPC transports.
+const (
+	TxOriginLocal = "local" // submitted by the node itself, e.g. payouts
+	TxOriginP2P   = "p2p"   // propagated by a peer
+)
+
+// TxOrigin describes where a transaction entering the pool came from.
+type TxOrigin struct {
+	Transport string // "p2p", "local" or the RPC transport, e.g. "ipc"
+	Remote    string // Id of the peer or address of the RPC client, if known
+}
+
+// TxOriginStats counts the transactions received from an origin.
+type TxOriginStats struct {
+	Accepted uint64    // Transactions admitted into the pool
+	Rejected uint64    // Transactions failing validation
+	LastSeen time.Time // Time of the last transaction
+}
+
+// txOrigins tracks the statistics of the origins transactions are received
+// from, so operators can tell which clients flood the pool with invalid ones.
+type txOrigins struct {
+	mu    sync.Mutex
+	stats map[TxOrigin]*TxOriginStats
+}
+
+// record counts a transaction received from origin, err being the reason it
+// was rejected.
+func (o *txOrigins) record(origin TxOrigin, err error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.stats == nil {
+		o.stats = make(map[TxOrigin]*TxOriginStats)
+	}
+	stats := o.stats[origin]
+	if stats == nil {
+		if len(o.stats) >= maxTxOrigins {
+			o.evict()
+		}
+		stats = new(TxOriginStats)
+		o.stats[origin] = stats
+	}
+	stats.LastSeen = time.Now()
+
+	// Meters are per transport only, remotes are too many to register each
+	name := "txpool/origin/" + origin.Transport
+	if err != nil {
+		stats.Rejected++
+		metrics.NewMeter(name + "/rejected").Mark(1)
+	} else {
+		stats.Accepted++
+		metrics.NewMeter(name + "/accepted").Mark(1)
+	}
+}
+
+// evict forgets the origin seen least recently. The caller must hold mu.
+func (o *txOrigins) evict() {
+	var (
+		oldest TxOrigin
+		last   time.Time
+	)
+	for origin, stats := range o.stats {
+		if last.IsZero() || stats.LastSeen.Before(last) {
+			oldest, last = origin, stats.LastSeen
+		}
+	}
+	delete(o.stats, oldest)
+}
+
+// snapshot returns a copy of the statistics of all origins.
+func (o *txOrigins) snapshot() map[TxOrigin]TxOriginStats {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	stats := make(map[TxOrigin]TxOriginStats, len(o.stats))
+	for origin, s := range o.stats {
+		stats[origin] = *s
+	}
+	return stats
+}
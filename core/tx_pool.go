@@ -17,11 +17,13 @@
 package core
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"math/big"
 	"sort"
 	"sync"
+	"time"
 
 	"github.com/expanse-project/go-expanse/common"
 	"github.com/expanse-project/go-expanse/core/state"
@@ -43,14 +45,48 @@ var (
 	ErrIntrinsicGas       = errors.New("Intrinsic gas too low")
 	ErrGasLimit           = errors.New("Exceeds block gas limit")
 	ErrNegativeValue      = errors.New("Negative value")
+	ErrOversizedData      = errors.New("Transaction exceeds maximum size")
+	ErrNonceReservations  = errors.New("Too many reserved nonces")
 )
 
 const (
-	maxQueued = 64 // max limit of queued txs per address
+	maxQueued   = 64 // max limit of queued txs per address
+	maxReserved = 16 // max limit of reserved nonces per address
+
+	// DefaultTxMaxSize is the largest transaction accepted into the pool unless
+	// configured otherwise through SetMaxTxSize.
+	DefaultTxMaxSize = 32 * 1024
+)
+
+// NonceReservationTimeout is the time after which a nonce reserved through
+// ReserveNonce is handed out again if no transaction has used it.
+var NonceReservationTimeout = 10 * time.Minute
+
+// TxDropReason describes why a transaction was dropped from the pool.
+type TxDropReason string
+
+const (
+	TxReplaced    TxDropReason = "replaced"    // another transaction with the same nonce was included
+	TxUnderpriced TxDropReason = "underpriced" // the gas price is too low to be mined
+	TxInvalidated TxDropReason = "invalidated" // the sender can no longer pay for it, e.g. after a reorg
+	TxQueueLimit  TxDropReason = "queueLimit"  // the sender exceeded the queued transaction limit
+	TxGasLimit    TxDropReason = "gasLimit"    // the gas exceeds the limit of the current block
 )
 
 type stateFn func() (*state.StateDB, error)
 
+// poolChain provides the chain events the pool follows: head changes, which
+// require a reset of the pending state, and transactions dropped from the
+// canonical chain by reorgs, which are added back to the pool.
+type poolChain interface {
+	SubscribeChainHeadEvent(ch chan<- ChainHeadEvent) event.FeedSubscription
+	SubscribeRemovedTransactionEvent(ch chan<- RemovedTransactionEvent) event.FeedSubscription
+}
+
+// minedFn reports whether the transaction with the given hash is included in
+// the canonical chain.
+type minedFn func(hash common.Hash) bool
+
 // TxPool contains all currently known transactions. Transactions
 // enter the pool when they are received from the network or submitted
 // locally. They exit the pool when they are included in the blockchain.
@@ -59,62 +95,125 @@ type stateFn func() (*state.StateDB, error)
 // current state) and future transactions. Transactions move between those
 // two states over time as they are received and processed.
 type TxPool struct {
-	quit         chan bool // Quiting channel
-	currentState stateFn   // The state function which will allow us to do some pre checkes
+	quit         chan struct{} // Quiting channel
+	currentState stateFn       // The state function which will allow us to do some pre checkes
 	pendingState *state.ManagedState
 	gasLimit     func() *big.Int // The current gas limit function callback
+	mined        minedFn         // Tells included transactions apart from replaced ones
 	minGasPrice  *big.Int
-	eventMux     *event.TypeMux
-	events       event.Subscription
+	maxTxSize    uint64 // maximum RLP encoded size of a transaction (0 = no limit)
+	chain        poolChain
+	headCh       chan ChainHeadEvent
+	headSub      event.FeedSubscription
+	rmTxCh       chan RemovedTransactionEvent
+	rmTxSub      event.FeedSubscription
+	txFeed       event.Feed
+	dropFeed     event.Feed
 	mu           sync.RWMutex
 	pending      map[common.Hash]*types.Transaction // processable transactions
 	queue        map[common.Address]map[common.Hash]*types.Transaction
+	reserved     map[common.Address]map[uint64]time.Time // reserved nonces and their expiry
 
 	homestead bool
+	signer    types.Signer // signer of the next block, accepting its transactions
+
+	origins txOrigins // statistics of the origins transactions are received from
 }
 
-func NewTxPool(eventMux *event.TypeMux, currentStateFn stateFn, gasLimitFn func() *big.Int) *TxPool {
+// NewTxPool creates a transaction pool validating transactions against the
+// rules of the block following head, the current head of the chain.
+func NewTxPool(chain poolChain, head *types.Block, currentStateFn stateFn, gasLimitFn func() *big.Int, minedFn minedFn) *TxPool {
 	pool := &TxPool{
 		pending:      make(map[common.Hash]*types.Transaction),
 		queue:        make(map[common.Address]map[common.Hash]*types.Transaction),
-		quit:         make(chan bool),
-		eventMux:     eventMux,
+		reserved:     make(map[common.Address]map[uint64]time.Time),
+		quit:         make(chan struct{}),
+		chain:        chain,
+		headCh:       make(chan ChainHeadEvent, ChainChanSize),
+		rmTxCh:       make(chan RemovedTransactionEvent, ChainChanSize),
 		currentState: currentStateFn,
 		gasLimit:     gasLimitFn,
+		mined:        minedFn,
 		minGasPrice:  new(big.Int),
+		maxTxSize:    DefaultTxMaxSize,
 		pendingState: nil,
-		events:       eventMux.Subscribe(ChainHeadEvent{}, GasPriceChanged{}, RemovedTransactionEvent{}),
 	}
+	pool.setHead(head)
+	pool.headSub = chain.SubscribeChainHeadEvent(pool.headCh)
+	pool.rmTxSub = chain.SubscribeRemovedTransactionEvent(pool.rmTxCh)
 
 	go pool.eventLoop()
 
 	return pool
 }
 
+// SubscribeTxPreEvent registers a subscription of TxPreEvent, which is
+// sent whenever a transaction becomes processable.
+func (pool *TxPool) SubscribeTxPreEvent(ch chan<- TxPreEvent) event.FeedSubscription {
+	return pool.txFeed.Subscribe(ch)
+}
+
+// SubscribeTxDropEvent registers a subscription of TxDropEvent, which is
+// sent whenever a transaction is removed from the pool without having been
+// included in the chain.
+func (pool *TxPool) SubscribeTxDropEvent(ch chan<- TxDropEvent) event.FeedSubscription {
+	return pool.dropFeed.Subscribe(ch)
+}
+
 func (pool *TxPool) eventLoop() {
+	headSub, rmTxSub := pool.headSub, pool.rmTxSub
+	defer func() {
+		headSub.Unsubscribe()
+		rmTxSub.Unsubscribe()
+	}()
+
 	// Track chain events. When a chain events occurs (new chain canon block)
 	// we need to know the new state. The new state will help us determine
 	// the nonces in the managed state
-	for ev := range pool.events.Chan() {
-		switch ev := ev.Data.(type) {
-		case ChainHeadEvent:
+	for {
+		select {
+		case ev := <-pool.headCh:
 			pool.mu.Lock()
-			if ev.Block != nil && params.IsHomestead(ev.Block.Number()) {
-				pool.homestead = true
+			if ev.Block != nil {
+				pool.setHead(ev.Block)
 			}
-
 			pool.resetState()
 			pool.mu.Unlock()
-		case GasPriceChanged:
-			pool.mu.Lock()
-			pool.minGasPrice = ev.Price
-			pool.mu.Unlock()
-		case RemovedTransactionEvent:
-			pool.AddTransactions(ev.Txs)
+		case ev := <-pool.rmTxCh:
+			pool.readdTransactions(ev.Txs)
+
+		// The subscriptions are only dropped if we fell behind. The next head
+		// event resets the pending state anyway.
+		case err := <-headSub.Err():
+			glog.V(logger.Debug).Infoln("resubscribing to chain head events:", err)
+			headSub = pool.chain.SubscribeChainHeadEvent(pool.headCh)
+		case err := <-rmTxSub.Err():
+			glog.V(logger.Debug).Infoln("resubscribing to removed transaction events:", err)
+			rmTxSub = pool.chain.SubscribeRemovedTransactionEvent(pool.rmTxCh)
+
+		case <-pool.quit:
+			return
 		}
 	}
 }
 
+// SetGasPrice sets the minimum gas price of transactions accepted into the
+// pool.
+func (pool *TxPool) SetGasPrice(price *big.Int) {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	pool.minGasPrice = price
+}
+
+// setHead switches the pool to the rules of the block following head.
+func (pool *TxPool) setHead(head *types.Block) {
+	if params.IsHomestead(head.Number()) {
+		pool.homestead = true
+	}
+	pool.signer = types.MakeSigner(new(big.Int).Add(head.Number(), common.Big1))
+}
+
 func (pool *TxPool) resetState() {
 	currentState, err := pool.currentState()
 	if err != nil {
@@ -127,6 +226,7 @@ func (pool *TxPool) resetState() {
 		return
 	}
 	pool.pendingState = managedState
+	pool.expireReservations()
 
 	// validate the pool of pending transactions, this will remove
 	// any transactions that have been included in the block or
@@ -152,7 +252,6 @@ func (pool *TxPool) resetState() {
 
 func (pool *TxPool) Stop() {
 	close(pool.quit)
-	pool.events.Unsubscribe()
 	glog.V(logger.Info).Infoln("Transaction pool stopped")
 }
 
@@ -163,6 +262,106 @@ func (pool *TxPool) State() *state.ManagedState {
 	return pool.pendingState
 }
 
+// Nonce returns the nonce of the next transaction of addr, which is the nonce
+// following its pending transactions. Queued transactions are not accounted,
+// they can't be executed before the nonce gap in front of them is filled.
+func (pool *TxPool) Nonce(addr common.Address) uint64 {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	// init delayed since tx pool could have been started before any state sync
+	if pool.pendingState == nil {
+		pool.resetState()
+		if pool.pendingState == nil {
+			return 0
+		}
+	}
+	return pool.pendingState.GetNonce(addr)
+}
+
+// ReserveNonce returns the lowest nonce of addr which is neither used by a
+// pending or queued transaction nor reserved, and reserves it. The reservation
+// ends when a transaction with the nonce is added to the pool, on ReleaseNonce
+// or after NonceReservationTimeout. At most maxReserved nonces of an address
+// are reserved at a time.
+func (pool *TxPool) ReserveNonce(addr common.Address) (uint64, error) {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	// init delayed since tx pool could have been started before any state sync
+	if pool.pendingState == nil {
+		pool.resetState()
+	}
+	pool.expireReservations()
+
+	reserved := pool.reserved[addr]
+	if len(reserved) >= maxReserved {
+		return 0, ErrNonceReservations
+	}
+	if reserved == nil {
+		reserved = make(map[uint64]time.Time)
+		pool.reserved[addr] = reserved
+	}
+	// Queued transactions may fill a gap in the nonces at any time, so their
+	// nonces are skipped as well.
+	queued := make(map[uint64]bool)
+	for _, tx := range pool.queue[addr] {
+		queued[tx.Nonce()] = true
+	}
+	next := pool.pendingState.GetNonce(addr)
+	for {
+		if _, ok := reserved[next]; !ok && !queued[next] {
+			break
+		}
+		next++
+	}
+	reserved[next] = time.Now().Add(NonceReservationTimeout)
+	return next, nil
+}
+
+// expireReservations drops the reservations of all addresses which expired or
+// whose nonces were used by transactions in the meantime. It assumes that the
+// pool lock is held.
+func (pool *TxPool) expireReservations() {
+	now := time.Now()
+	for addr, reserved := range pool.reserved {
+		var next uint64
+		if pool.pendingState != nil {
+			next = pool.pendingState.GetNonce(addr)
+		}
+		for nonce, expiry := range reserved {
+			if nonce < next || now.After(expiry) {
+				delete(reserved, nonce)
+			}
+		}
+		if len(reserved) == 0 {
+			delete(pool.reserved, addr)
+		}
+	}
+}
+
+// ReleaseNonce ends the reservation of a nonce obtained through ReserveNonce
+// which won't be used. It reports whether the nonce was reserved.
+func (pool *TxPool) ReleaseNonce(addr common.Address, nonce uint64) bool {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	return pool.unreserve(addr, nonce)
+}
+
+// unreserve removes the reservation of a nonce, if any. It assumes that the
+// pool lock is held.
+func (pool *TxPool) unreserve(addr common.Address, nonce uint64) bool {
+	if _, ok := pool.reserved[addr][nonce]; !ok {
+		return false
+	}
+	delete(pool.reserved[addr], nonce)
+	if len(pool.reserved[addr]) == 0 {
+		delete(pool.reserved, addr)
+	}
+	return true
+}
+
 func (pool *TxPool) Stats() (pending int, queued int) {
 	pool.mu.RLock()
 	defer pool.mu.RUnlock()
@@ -174,9 +373,21 @@ func (pool *TxPool) Stats() (pending int, queued int) {
 	return
 }
 
+// OriginStats returns the number of transactions admitted and rejected by the
+// pool per origin they were received from, both invalid and already known ones
+// counting as rejected.
+func (pool *TxPool) OriginStats() map[TxOrigin]TxOriginStats {
+	return pool.origins.snapshot()
+}
+
 // validateTx checks whether a transaction is valid according
 // to the consensus rules.
 func (pool *TxPool) validateTx(tx *types.Transaction) error {
+	// Reject transactions over the pool or consensus size limit before doing
+	// anything expensive, so huge payloads are never propagated
+	if limit := pool.txSizeLimit(); limit > 0 && uint64(tx.Size()) > limit {
+		return ErrOversizedData
+	}
 	// Drop transactions under our own minimal accepted gas price
 	if pool.minGasPrice.Cmp(tx.GasPrice()) > 0 {
 		return ErrCheap
@@ -187,7 +398,10 @@ func (pool *TxPool) validateTx(tx *types.Transaction) error {
 		return err
 	}
 
-	from, err := tx.From()
+	from, err := pool.signer.Sender(tx)
+	if err == types.ErrInvalidChainId {
+		return err
+	}
 	if err != nil {
 		return ErrInvalidSender
 	}
@@ -230,17 +444,31 @@ func (pool *TxPool) validateTx(tx *types.Transaction) error {
 	return nil
 }
 
-// validate and queue transactions.
-func (self *TxPool) add(tx *types.Transaction) error {
-	hash := tx.Hash()
+// SetMaxTxSize sets the maximum RLP encoded size of transactions accepted into
+// the pool, 0 meaning no limit other than the consensus one.
+func (pool *TxPool) SetMaxTxSize(size uint64) {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
 
-	if self.pending[hash] != nil {
-		return fmt.Errorf("Known transaction (%x)", hash[:4])
+	pool.maxTxSize = size
+}
+
+// txSizeLimit returns the effective transaction size limit, which is the
+// stricter of the pool and the consensus limits.
+func (pool *TxPool) txSizeLimit() uint64 {
+	limit := pool.maxTxSize
+	if params.MaxTransactionSize > 0 && (limit == 0 || params.MaxTransactionSize < limit) {
+		limit = params.MaxTransactionSize
 	}
-	err := self.validateTx(tx)
-	if err != nil {
+	return limit
+}
+
+// validate and queue transactions.
+func (self *TxPool) add(tx *types.Transaction) error {
+	if err := self.admit(tx); err != nil {
 		return err
 	}
+	hash := tx.Hash()
 	self.queueTx(hash, tx)
 
 	if glog.V(logger.Debug) {
@@ -260,6 +488,15 @@ func (self *TxPool) add(tx *types.Transaction) error {
 	return nil
 }
 
+// admit checks whether tx would be accepted into the pool, i.e. it isn't
+// pending yet and is valid.
+func (self *TxPool) admit(tx *types.Transaction) error {
+	if hash := tx.Hash(); self.pending[hash] != nil {
+		return fmt.Errorf("Known transaction (%x)", hash[:4])
+	}
+	return self.validateTx(tx)
+}
+
 // queueTx will queue an unknown transaction
 func (self *TxPool) queueTx(hash common.Hash, tx *types.Transaction) {
 	from, _ := tx.From() // already validated
@@ -267,6 +504,7 @@ func (self *TxPool) queueTx(hash common.Hash, tx *types.Transaction) {
 		self.queue[from] = make(map[common.Hash]*types.Transaction)
 	}
 	self.queue[from][hash] = tx
+	self.unreserve(from, tx.Nonce())
 }
 
 // addTx will add a transaction to the pending (processable queue) list of transactions
@@ -282,32 +520,58 @@ func (pool *TxPool) addTx(hash common.Hash, addr common.Address, tx *types.Trans
 		// Increment the nonce on the pending state. This can only happen if
 		// the nonce is +1 to the previous one.
 		pool.pendingState.SetNonce(addr, tx.Nonce()+1)
-		// Notify the subscribers. This event is posted in a goroutine
-		// because it's possible that somewhere during the post "Remove transaction"
-		// gets called which will then wait for the global tx pool lock and deadlock.
-		go pool.eventMux.Post(TxPreEvent{tx})
+		// Notify the subscribers. Feed delivery doesn't block, so this
+		// can't deadlock on subscribers calling back into the pool.
+		pool.txFeed.Send(TxPreEvent{tx})
 	}
 }
 
-// Add queues a single transaction in the pool if it is valid.
+// Add queues a single transaction submitted by the node itself in the pool if
+// it is valid.
 func (self *TxPool) Add(tx *types.Transaction) error {
+	return self.AddFrom(tx, TxOrigin{Transport: TxOriginLocal})
+}
+
+// AddFrom queues a single transaction received from origin in the pool if it
+// is valid, counting it in the statistics of origin.
+func (self *TxPool) AddFrom(tx *types.Transaction, origin TxOrigin) error {
 	self.mu.Lock()
 	defer self.mu.Unlock()
 
-	if err := self.add(tx); err != nil {
+	err := self.add(tx)
+	self.origins.record(origin, err)
+	if err != nil {
 		return err
 	}
 	self.checkQueue()
 	return nil
 }
 
-// AddTransactions attempts to queue all valid transactions in txs.
+// Validate runs the checks of Add on tx without adding it to the pool,
+// returning the reason the pool would reject it.
+func (self *TxPool) Validate(tx *types.Transaction) error {
+	self.mu.RLock()
+	defer self.mu.RUnlock()
+
+	return self.admit(tx)
+}
+
+// AddTransactions attempts to queue all valid transactions in txs, received
+// from a peer which isn't known.
 func (self *TxPool) AddTransactions(txs []*types.Transaction) {
+	self.AddTransactionsFrom(txs, TxOrigin{Transport: TxOriginP2P})
+}
+
+// AddTransactionsFrom attempts to queue all valid transactions in txs, counting
+// them in the statistics of origin.
+func (self *TxPool) AddTransactionsFrom(txs []*types.Transaction, origin TxOrigin) {
 	self.mu.Lock()
 	defer self.mu.Unlock()
 
 	for _, tx := range txs {
-		if err := self.add(tx); err != nil {
+		err := self.add(tx)
+		self.origins.record(origin, err)
+		if err != nil {
 			glog.V(logger.Debug).Infoln("tx error:", err)
 		} else {
 			h := tx.Hash()
@@ -319,6 +583,25 @@ func (self *TxPool) AddTransactions(txs []*types.Transaction) {
 	self.checkQueue()
 }
 
+// readdTransactions queues the transactions of blocks reorganised out of the
+// chain. Transactions which aren't valid on the new chain any more are
+// reported as dropped.
+func (self *TxPool) readdTransactions(txs types.Transactions) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	for _, tx := range txs {
+		if self.pending[tx.Hash()] != nil {
+			continue
+		}
+		if err := self.add(tx); err != nil {
+			glog.V(logger.Debug).Infoln("reorged tx error:", err)
+			self.drop(tx, TxInvalidated)
+		}
+	}
+	self.checkQueue()
+}
+
 // GetTransaction returns a transaction if it is contained in the pool
 // and nil otherwise.
 func (tp *TxPool) GetTransaction(hash common.Hash) *types.Transaction {
@@ -370,6 +653,97 @@ func (self *TxPool) GetQueuedTransactions() types.Transactions {
 	return ret
 }
 
+// TxFilter selects a slice of the transaction pool content.
+type TxFilter struct {
+	From     *common.Address // Only transactions sent by this account, if set
+	MinPrice *big.Int        // Only transactions paying at least this gas price, if set
+	Offset   int             // Number of matching transactions to skip
+	Limit    int             // Maximum number of transactions to return (0 = no limit)
+}
+
+// matches returns whether tx is sent by from and pays the price floor of the filter.
+func (f *TxFilter) matches(tx *types.Transaction, from common.Address) bool {
+	if f.From != nil && *f.From != from {
+		return false
+	}
+	return f.MinPrice == nil || tx.GasPrice().Cmp(f.MinPrice) >= 0
+}
+
+// Content returns the pending and queued transactions matching filter, along
+// with the total number of matching transactions before paginating. The
+// transactions are ordered by sender and nonce, pending ones before queued
+// ones, so consecutive pages don't overlap as long as the pool doesn't change.
+func (self *TxPool) Content(filter TxFilter) (pending, queued types.Transactions, total int) {
+	self.mu.RLock()
+	defer self.mu.RUnlock()
+
+	var matches txsBySender
+	for _, tx := range self.pending {
+		from, _ := tx.From() // already validated
+		if filter.matches(tx, from) {
+			matches = append(matches, txWithSender{tx, from})
+		}
+	}
+	sort.Sort(matches)
+	pendings := len(matches)
+
+	var queue txsBySender
+	for from, txs := range self.queue {
+		if filter.From != nil && *filter.From != from {
+			continue
+		}
+		for _, tx := range txs {
+			if filter.matches(tx, from) {
+				queue = append(queue, txWithSender{tx, from})
+			}
+		}
+	}
+	sort.Sort(queue)
+	matches = append(matches, queue...)
+	total = len(matches)
+
+	start, end := filter.Offset, total
+	if start < 0 {
+		start = 0
+	}
+	if start > total {
+		start = total
+	}
+	if filter.Limit > 0 && start+filter.Limit < end {
+		end = start + filter.Limit
+	}
+	for i := start; i < end; i++ {
+		if i < pendings {
+			pending = append(pending, matches[i].tx)
+		} else {
+			queued = append(queued, matches[i].tx)
+		}
+	}
+	return pending, queued, total
+}
+
+// TxGroups holds transactions grouped by sender, the transactions of each
+// sender ordered by nonce.
+type TxGroups map[common.Address]types.Transactions
+
+// GroupedContent is Content with the pending and queued transactions grouped
+// by sender. Pagination applies before grouping, so a page may hold only part
+// of the transactions of a sender.
+func (self *TxPool) GroupedContent(filter TxFilter) (pending, queued TxGroups, total int) {
+	p, q, total := self.Content(filter)
+	return groupBySender(p), groupBySender(q), total
+}
+
+// groupBySender groups transactions ordered by sender and nonce by sender.
+func groupBySender(txs types.Transactions) TxGroups {
+	groups := make(TxGroups)
+	for _, tx := range txs {
+		from, _ := tx.From() // already validated
+		groups[from] = append(groups[from], tx)
+	}
+	return groups
+}
+
 // RemoveTransactions removes all given transactions from the pool.
 func (self *TxPool) RemoveTransactions(txs types.Transactions) {
 	self.mu.Lock()
@@ -379,6 +753,17 @@ func (self *TxPool) RemoveTransactions(txs types.Transactions) {
 	}
 }
 
+// DropTransactions removes all given transactions from the pool and notifies
+// the subscribers of the reason.
+func (self *TxPool) DropTransactions(txs types.Transactions, reason TxDropReason) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	for _, tx := range txs {
+		self.RemoveTx(tx.Hash())
+		self.drop(tx, reason)
+	}
+}
+
 // RemoveTx removes the transaction with the given hash from the pool.
 func (pool *TxPool) RemoveTx(hash common.Hash) {
 	// delete from pending pool
@@ -404,7 +789,10 @@ func (pool *TxPool) checkQueue() {
 		pool.resetState()
 	}
 
-	var promote txQueue
+	var (
+		promote  txQueue
+		gasLimit = pool.gasLimit()
+	)
 	for address, txs := range pool.queue {
 		currentState, err := pool.currentState()
 		if err != nil {
@@ -420,11 +808,21 @@ func (pool *TxPool) checkQueue() {
 		promote = promote[:0]
 		for hash, tx := range txs {
 			// Drop processed or out of fund transactions
-			if tx.Nonce() < trueNonce || balance.Cmp(tx.Cost()) < 0 {
+			if past := tx.Nonce() < trueNonce; past || balance.Cmp(tx.Cost()) < 0 {
 				if glog.V(logger.Core) {
 					glog.Infof("removed tx (%v) from pool queue: low tx nonce or out of funds\n", tx)
 				}
 				delete(txs, hash)
+				pool.dropInvalid(hash, tx, past)
+				continue
+			}
+			// Drop transactions which can't fit into a block any more
+			if gasLimit.Cmp(tx.Gas()) < 0 {
+				if glog.V(logger.Core) {
+					glog.Infof("removed tx (%v) from pool queue: exceeds block gas limit\n", tx)
+				}
+				delete(txs, hash)
+				pool.drop(tx, TxGasLimit)
 				continue
 			}
 			// Collect the remaining transactions for the next pass.
@@ -442,6 +840,7 @@ func (pool *TxPool) checkQueue() {
 					}
 					for _, drop := range promote[i+maxQueued:] {
 						delete(txs, drop.hash)
+						pool.drop(drop.Transaction, TxQueueLimit)
 					}
 				}
 				break
@@ -462,17 +861,21 @@ func (pool *TxPool) checkQueue() {
 }
 
 // validatePool removes invalid and processed transactions from the main pool.
-// If a transaction is removed for being invalid (e.g. out of funds), all sub-
-// sequent (Still valid) transactions are moved back into the future queue. This
-// is important to prevent a drained account from DOSing the network with non
-// executable transactions.
+// If a transaction is removed for being invalid (e.g. out of funds or above the
+// block gas limit), all subsequent (still valid) transactions are demoted back
+// into the future queue. This is important to prevent a drained account from
+// DOSing the network with non executable transactions, and the miner from
+// repeatedly attempting transactions which can't be included.
 func (pool *TxPool) validatePool() {
 	state, err := pool.currentState()
 	if err != nil {
 		glog.V(logger.Info).Infoln("failed to get current state: %v", err)
 		return
 	}
-	balanceCache := make(map[common.Address]*big.Int)
+	var (
+		balanceCache = make(map[common.Address]*big.Int)
+		gasLimit     = pool.gasLimit()
+	)
 
 	// Clean up the pending pool, accumulating invalid nonces
 	gaps := make(map[common.Address]uint64)
@@ -486,18 +889,31 @@ func (pool *TxPool) validatePool() {
 			balance = state.GetBalance(sender)
 			balanceCache[sender] = balance
 		}
-		if past := state.GetNonce(sender) > tx.Nonce(); past || balance.Cmp(tx.Cost()) < 0 {
+		past := state.GetNonce(sender) > tx.Nonce()
+		switch {
+		case past || balance.Cmp(tx.Cost()) < 0:
 			// Remove an already past it invalidated transaction
 			if glog.V(logger.Core) {
 				glog.Infof("removed tx (%v) from pool: low tx nonce or out of funds\n", tx)
 			}
 			delete(pool.pending, hash)
+			pool.dropInvalid(hash, tx, past)
 
-			// Track the smallest invalid nonce to postpone subsequent transactions
-			if !past {
-				if prev, ok := gaps[sender]; !ok || tx.Nonce() < prev {
-					gaps[sender] = tx.Nonce()
-				}
+		case gasLimit.Cmp(tx.Gas()) < 0:
+			// Remove a transaction the block gas limit was lowered below
+			if glog.V(logger.Core) {
+				glog.Infof("removed tx (%v) from pool: exceeds block gas limit\n", tx)
+			}
+			delete(pool.pending, hash)
+			pool.drop(tx, TxGasLimit)
+
+		default:
+			continue
+		}
+		// Track the smallest invalid nonce to postpone subsequent transactions
+		if !past {
+			if prev, ok := gaps[sender]; !ok || tx.Nonce() < prev {
+				gaps[sender] = tx.Nonce()
 			}
 		}
 	}
@@ -516,6 +932,24 @@ func (pool *TxPool) validatePool() {
 	}
 }
 
+// drop notifies the subscribers that tx was removed from the pool without being
+// included in the chain.
+func (pool *TxPool) drop(tx *types.Transaction, reason TxDropReason) {
+	pool.dropFeed.Send(TxDropEvent{tx, reason})
+}
+
+// dropInvalid notifies the subscribers of a transaction removed because the
+// current state doesn't permit it any more. Transactions with a past nonce
+// are only dropped if they were replaced rather than included in the chain.
+func (pool *TxPool) dropInvalid(hash common.Hash, tx *types.Transaction, past bool) {
+	switch {
+	case !past:
+		pool.drop(tx, TxInvalidated)
+	case !pool.mined(hash):
+		pool.drop(tx, TxReplaced)
+	}
+}
+
 type txQueue []txQueueEntry
 
 type txQueueEntry struct {
@@ -527,3 +961,20 @@ type txQueueEntry struct {
 func (q txQueue) Len() int           { return len(q) }
 func (q txQueue) Swap(i, j int)      { q[i], q[j] = q[j], q[i] }
 func (q txQueue) Less(i, j int) bool { return q[i].Nonce() < q[j].Nonce() }
+
+type txWithSender struct {
+	tx   *types.Transaction
+	from common.Address
+}
+
+// txsBySender sorts transactions by sender and nonce.
+type txsBySender []txWithSender
+
+func (s txsBySender) Len() int      { return len(s) }
+func (s txsBySender) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s txsBySender) Less(i, j int) bool {
+	if c := bytes.Compare(s[i].from[:], s[j].from[:]); c != 0 {
+		return c < 0
+	}
+	return s[i].tx.Nonce() < s[j].tx.Nonce()
+}
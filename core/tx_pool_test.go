@@ -18,8 +18,10 @@ package core
 
 import (
 	"crypto/ecdsa"
+	"fmt"
 	"math/big"
 	"testing"
+	"time"
 
 	"github.com/expanse-project/go-expanse/common"
 	"github.com/expanse-project/go-expanse/core/state"
@@ -27,6 +29,7 @@ import (
 	"github.com/expanse-project/go-expanse/crypto"
 	"github.com/expanse-project/go-expanse/ethdb"
 	"github.com/expanse-project/go-expanse/event"
+	"github.com/expanse-project/go-expanse/params"
 )
 
 func transaction(nonce uint64, gaslimit *big.Int, key *ecdsa.PrivateKey) *types.Transaction {
@@ -34,17 +37,58 @@ func transaction(nonce uint64, gaslimit *big.Int, key *ecdsa.PrivateKey) *types.
 	return tx
 }
 
+// testHead is the chain head the test pools are created at.
+var testHead = types.NewBlock(&types.Header{Number: new(big.Int)}, nil, nil, nil)
+
+// testBlockChain provides the chain feeds followed by the test pools.
+type testBlockChain struct {
+	chainHeadFeed event.Feed
+	rmTxFeed      event.Feed
+}
+
+func (bc *testBlockChain) SubscribeChainHeadEvent(ch chan<- ChainHeadEvent) event.FeedSubscription {
+	return bc.chainHeadFeed.Subscribe(ch)
+}
+
+func (bc *testBlockChain) SubscribeRemovedTransactionEvent(ch chan<- RemovedTransactionEvent) event.FeedSubscription {
+	return bc.rmTxFeed.Subscribe(ch)
+}
+
 func setupTxPool() (*TxPool, *ecdsa.PrivateKey) {
 	db, _ := ethdb.NewMemDatabase()
 	statedb, _ := state.New(common.Hash{}, db)
 
-	var m event.TypeMux
 	key, _ := crypto.GenerateKey()
-	newPool := NewTxPool(&m, func() (*state.StateDB, error) { return statedb, nil }, func() *big.Int { return big.NewInt(1000000) })
+	newPool := NewTxPool(new(testBlockChain), testHead, func() (*state.StateDB, error) { return statedb, nil }, func() *big.Int { return big.NewInt(1000000) }, func(common.Hash) bool { return false })
 	newPool.resetState()
 	return newPool, key
 }
 
+// Tests that a new pool accepts the transactions of the block following the
+// current head, before the first chain head event.
+func TestTxPoolHeadSigner(t *testing.T) {
+	defer func(chainId, eip155 *big.Int) {
+		params.ChainId, params.EIP155Block = chainId, eip155
+	}(params.ChainId, params.EIP155Block)
+	params.ChainId, params.EIP155Block = big.NewInt(2), big.NewInt(11)
+
+	statedb := func() (*state.StateDB, error) {
+		db, _ := ethdb.NewMemDatabase()
+		return state.New(common.Hash{}, db)
+	}
+	gasLimit := func() *big.Int { return big.NewInt(1000000) }
+	mined := func(common.Hash) bool { return false }
+
+	for number, eip155 := range map[int64]bool{9: false, 10: true, 11: true} {
+		head := types.NewBlock(&types.Header{Number: big.NewInt(number)}, nil, nil, nil)
+		pool := NewTxPool(new(testBlockChain), head, statedb, gasLimit, mined)
+		if _, ok := pool.signer.(types.EIP155Signer); ok != eip155 {
+			t.Errorf("head #%d: signer mismatch: have %#v, replay protection expected %v", number, pool.signer, eip155)
+		}
+		pool.Stop()
+	}
+}
+
 func TestInvalidTransactions(t *testing.T) {
 	pool, key := setupTxPool()
 
@@ -74,6 +118,38 @@ func TestInvalidTransactions(t *testing.T) {
 	}
 }
 
+func TestValidateTransaction(t *testing.T) {
+	pool, key := setupTxPool()
+
+	tx := transaction(0, big.NewInt(100000), key)
+	if err := pool.Validate(tx); err != ErrNonExistentAccount {
+		t.Errorf("error mismatch: have %v, want %v", err, ErrNonExistentAccount)
+	}
+	from, _ := tx.From()
+	currentState, _ := pool.currentState()
+	currentState.AddBalance(from, big.NewInt(1000000))
+
+	if err := pool.Validate(tx); err != nil {
+		t.Fatalf("valid transaction rejected: %v", err)
+	}
+	if pending, queued := pool.Stats(); pending+queued != 0 {
+		t.Fatalf("validated transaction added to the pool: %d pending, %d queued", pending, queued)
+	}
+	if err := pool.Add(tx); err != nil {
+		t.Fatalf("failed to add transaction: %v", err)
+	}
+	if err := pool.Validate(tx); err == nil {
+		t.Errorf("known transaction validated")
+	}
+	// Transactions replay protected for another chain are told apart from
+	// ones with an invalid signature
+	pool.signer = types.NewEIP155Signer(big.NewInt(2))
+	tx, _ = types.SignTx(types.NewTransaction(1, common.Address{}, big.NewInt(100), big.NewInt(100000), big.NewInt(1), nil), types.NewEIP155Signer(big.NewInt(3)), key)
+	if err := pool.Validate(tx); err != types.ErrInvalidChainId {
+		t.Errorf("error mismatch: have %v, want %v", err, types.ErrInvalidChainId)
+	}
+}
+
 func TestTransactionQueue(t *testing.T) {
 	pool, key := setupTxPool()
 	tx := transaction(0, big.NewInt(100), key)
@@ -160,6 +236,33 @@ func TestNegativeValue(t *testing.T) {
 	}
 }
 
+// Tests that transactions over the pool or consensus size limits are rejected.
+func TestOversizedData(t *testing.T) {
+	defer func(old uint64) { params.MaxTransactionSize = old }(params.MaxTransactionSize)
+
+	pool, key := setupTxPool()
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	currentState, _ := pool.currentState()
+	currentState.AddBalance(from, big.NewInt(1000000000))
+
+	data := make([]byte, 1024)
+	tx, _ := types.NewTransaction(0, common.Address{}, big.NewInt(0), big.NewInt(100000), big.NewInt(1), data).SignECDSA(key)
+
+	pool.SetMaxTxSize(1024)
+	if err := pool.Add(tx); err != ErrOversizedData {
+		t.Error("expected", ErrOversizedData, "got", err)
+	}
+	pool.SetMaxTxSize(0)
+	params.MaxTransactionSize = 1024
+	if err := pool.Add(tx); err != ErrOversizedData {
+		t.Error("expected", ErrOversizedData, "got", err)
+	}
+	params.MaxTransactionSize = 0
+	if err := pool.Add(tx); err != nil {
+		t.Error("expected no error, got", err)
+	}
+}
+
 func TestTransactionChainFork(t *testing.T) {
 	pool, key := setupTxPool()
 	addr := crypto.PubkeyToAddress(key.PublicKey)
@@ -257,10 +360,22 @@ func TestRemovedTxEvent(t *testing.T) {
 	from, _ := tx.From()
 	currentState, _ := pool.currentState()
 	currentState.AddBalance(from, big.NewInt(1000000000000))
-	pool.eventMux.Post(RemovedTransactionEvent{types.Transactions{tx}})
-	pool.eventMux.Post(ChainHeadEvent{nil})
-	if len(pool.pending) != 1 {
-		t.Error("expected 1 pending tx, got", len(pool.pending))
+	chain := pool.chain.(*testBlockChain)
+	chain.rmTxFeed.Send(RemovedTransactionEvent{types.Transactions{tx}})
+	chain.chainHeadFeed.Send(ChainHeadEvent{nil})
+
+	// The events are handled asynchronously, wait for the pool to catch up.
+	var pending int
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		pool.mu.RLock()
+		pending = len(pool.pending)
+		pool.mu.RUnlock()
+		if pending == 1 {
+			break
+		}
+	}
+	if pending != 1 {
+		t.Error("expected 1 pending tx, got", pending)
 	}
 }
 
@@ -318,6 +433,124 @@ func TestTransactionDropping(t *testing.T) {
 	}
 }
 
+// Tests that transactions removed from the pool without being included in the
+// chain are reported to the subscribers along with the reason.
+func TestTransactionDropEvents(t *testing.T) {
+	pool, key := setupTxPool()
+	account, _ := transaction(0, big.NewInt(0), key).From()
+
+	state, _ := pool.currentState()
+	state.AddBalance(account, big.NewInt(1000))
+
+	events := make(chan TxDropEvent, 16)
+	sub := pool.SubscribeTxDropEvent(events)
+	defer sub.Unsubscribe()
+
+	var (
+		mined    = transaction(0, big.NewInt(100), key)
+		replaced = transaction(0, big.NewInt(200), key)
+		invalid  = transaction(1, big.NewInt(500), key)
+		cheap    = transaction(2, big.NewInt(100), key)
+	)
+	pool.mined = func(hash common.Hash) bool { return hash == mined.Hash() }
+	pool.addTx(mined.Hash(), account, mined)
+	pool.addTx(replaced.Hash(), account, replaced)
+	pool.addTx(invalid.Hash(), account, invalid)
+	pool.addTx(cheap.Hash(), account, cheap)
+
+	// Include the first transaction and reduce the balance below the cost of the second
+	state.SetNonce(account, 1)
+	state.AddBalance(account, big.NewInt(-500))
+	pool.resetState()
+	pool.DropTransactions(types.Transactions{cheap}, TxUnderpriced)
+
+	want := map[common.Hash]TxDropReason{
+		replaced.Hash(): TxReplaced,
+		invalid.Hash():  TxInvalidated,
+		cheap.Hash():    TxUnderpriced,
+	}
+	for len(want) > 0 {
+		select {
+		case ev := <-events:
+			reason, ok := want[ev.Tx.Hash()]
+			if !ok {
+				t.Fatalf("unexpected drop event for %x: %s", ev.Tx.Hash(), ev.Reason)
+			}
+			if ev.Reason != reason {
+				t.Errorf("drop reason mismatch for %x: have %s, want %s", ev.Tx.Hash(), ev.Reason, reason)
+			}
+			delete(want, ev.Tx.Hash())
+		case <-time.After(time.Second):
+			t.Fatalf("missing drop events: %v", want)
+		}
+	}
+	select {
+	case ev := <-events:
+		t.Errorf("unexpected drop event for %x: %s", ev.Tx.Hash(), ev.Reason)
+	case <-time.After(10 * time.Millisecond):
+	}
+}
+
+// Tests that lowering the block gas limit drops the pending and queued
+// transactions above it and demotes the pending ones after them.
+func TestTransactionGasLimitDemotion(t *testing.T) {
+	pool, key := setupTxPool()
+	account, _ := transaction(0, big.NewInt(0), key).From()
+
+	state, _ := pool.currentState()
+	state.AddBalance(account, big.NewInt(1000000))
+
+	drops := make(chan TxDropEvent, 16)
+	dropSub := pool.SubscribeTxDropEvent(drops)
+	defer dropSub.Unsubscribe()
+
+	var (
+		small  = transaction(0, big.NewInt(100), key)
+		large  = transaction(1, big.NewInt(50000), key)
+		next   = transaction(2, big.NewInt(100), key)
+		future = transaction(4, big.NewInt(50000), key)
+	)
+	pool.addTx(small.Hash(), account, small)
+	pool.addTx(large.Hash(), account, large)
+	pool.addTx(next.Hash(), account, next)
+	pool.queueTx(future.Hash(), future)
+
+	pool.gasLimit = func() *big.Int { return big.NewInt(1000) }
+	pool.resetState()
+
+	if _, ok := pool.pending[small.Hash()]; !ok {
+		t.Errorf("transaction within the gas limit missing: %v", small)
+	}
+	if _, ok := pool.pending[large.Hash()]; ok {
+		t.Errorf("transaction above the gas limit still pending: %v", large)
+	}
+	if _, ok := pool.pending[next.Hash()]; ok {
+		t.Errorf("transaction after the dropped one still pending: %v", next)
+	}
+	if _, ok := pool.queue[account][next.Hash()]; !ok {
+		t.Errorf("transaction after the dropped one not demoted: %v", next)
+	}
+	if _, ok := pool.queue[account][future.Hash()]; ok {
+		t.Errorf("queued transaction above the gas limit present: %v", future)
+	}
+	if nonce := pool.State().GetNonce(account); nonce != 1 {
+		t.Errorf("pending nonce mismatch: have %d, want 1", nonce)
+	}
+
+	want := map[common.Hash]bool{large.Hash(): true, future.Hash(): true}
+	for len(want) > 0 {
+		select {
+		case ev := <-drops:
+			if !want[ev.Tx.Hash()] || ev.Reason != TxGasLimit {
+				t.Fatalf("unexpected drop event for %x: %s", ev.Tx.Hash(), ev.Reason)
+			}
+			delete(want, ev.Tx.Hash())
+		case <-time.After(time.Second):
+			t.Fatalf("missing drop events: %v", want)
+		}
+	}
+}
+
 // Tests that if a transaction is dropped from the current pending pool (e.g. out
 // of fund), all consecutive (still valid, but not executable) transactions are
 // postponed back into the future queue to prevent broadcating them.
@@ -524,3 +757,221 @@ func benchmarkCheckQueue(b *testing.B, size int) {
 		pool.checkQueue()
 	}
 }
+
+func TestNonceReservation(t *testing.T) {
+	pool, key := setupTxPool()
+	account, _ := transaction(0, big.NewInt(0), key).From()
+
+	state, _ := pool.currentState()
+	state.AddBalance(account, big.NewInt(1000000))
+
+	for i := uint64(0); i < 3; i++ {
+		if nonce, _ := pool.ReserveNonce(account); nonce != i {
+			t.Fatalf("reservation %d: nonce mismatch: have %d, want %d", i, nonce, i)
+		}
+	}
+	// A released nonce is handed out again before later ones.
+	if !pool.ReleaseNonce(account, 1) {
+		t.Fatalf("expected nonce 1 to be reserved")
+	}
+	if pool.ReleaseNonce(account, 1) {
+		t.Fatalf("expected nonce 1 to be released")
+	}
+	if nonce, _ := pool.ReserveNonce(account); nonce != 1 {
+		t.Fatalf("nonce mismatch after release: have %d, want 1", nonce)
+	}
+	// Adding a transaction with a reserved nonce ends its reservation.
+	if err := pool.Add(transaction(0, big.NewInt(100000), key)); err != nil {
+		t.Fatalf("failed to add transaction: %v", err)
+	}
+	if pool.ReleaseNonce(account, 0) {
+		t.Errorf("expected reservation of nonce 0 to end with its transaction")
+	}
+	if nonce, _ := pool.ReserveNonce(account); nonce != 3 {
+		t.Errorf("nonce mismatch: have %d, want 3", nonce)
+	}
+	// Expired reservations are handed out again.
+	defer func(timeout time.Duration) { NonceReservationTimeout = timeout }(NonceReservationTimeout)
+	NonceReservationTimeout = 0
+	pool.ReleaseNonce(account, 2)
+	if nonce, _ := pool.ReserveNonce(account); nonce != 2 {
+		t.Errorf("nonce mismatch: have %d, want 2", nonce)
+	}
+	if nonce, _ := pool.ReserveNonce(account); nonce != 2 {
+		t.Errorf("nonce mismatch after expiry: have %d, want 2", nonce)
+	}
+	// Expired reservations of other addresses are dropped too.
+	other := common.Address{1}
+	pool.ReserveNonce(other)
+	pool.ReserveNonce(account)
+	if _, ok := pool.reserved[other]; ok {
+		t.Errorf("expired reservation of other address kept")
+	}
+}
+
+func TestNonceReservationLimit(t *testing.T) {
+	pool, key := setupTxPool()
+	account, _ := transaction(0, big.NewInt(0), key).From()
+
+	for i := uint64(0); i < maxReserved; i++ {
+		if _, err := pool.ReserveNonce(account); err != nil {
+			t.Fatalf("reservation %d failed: %v", i, err)
+		}
+	}
+	if _, err := pool.ReserveNonce(account); err != ErrNonceReservations {
+		t.Fatalf("reservation beyond the limit: have %v, want %v", err, ErrNonceReservations)
+	}
+	// Releasing a nonce makes room for another reservation
+	pool.ReleaseNonce(account, 3)
+	if nonce, err := pool.ReserveNonce(account); err != nil || nonce != 3 {
+		t.Errorf("reservation after release: have %d (err %v), want 3", nonce, err)
+	}
+}
+
+func TestNonceReservationSkipsQueued(t *testing.T) {
+	pool, key := setupTxPool()
+	account, _ := transaction(0, big.NewInt(0), key).From()
+
+	state, _ := pool.currentState()
+	state.AddBalance(account, big.NewInt(1000000))
+
+	// Submit a burst of transactions with a gap, leaving the later ones queued.
+	for _, nonce := range []uint64{0, 2, 3} {
+		if err := pool.Add(transaction(nonce, big.NewInt(100000), key)); err != nil {
+			t.Fatalf("failed to add transaction %d: %v", nonce, err)
+		}
+	}
+	if nonce, _ := pool.ReserveNonce(account); nonce != 1 {
+		t.Fatalf("nonce mismatch: have %d, want 1", nonce)
+	}
+	if nonce, _ := pool.ReserveNonce(account); nonce != 4 {
+		t.Fatalf("nonce mismatch: have %d, want 4 after the queued transactions", nonce)
+	}
+}
+
+func TestTransactionPoolContent(t *testing.T) {
+	pool, key := setupTxPool()
+	account, _ := transaction(0, big.NewInt(0), key).From()
+	other, _ := crypto.GenerateKey()
+	otherAccount := crypto.PubkeyToAddress(other.PublicKey)
+
+	state, _ := pool.currentState()
+	state.AddBalance(account, big.NewInt(1000000))
+	state.AddBalance(otherAccount, big.NewInt(1000000))
+
+	// Five pending and two queued transactions of one account, one expensive
+	// pending transaction of another
+	for _, nonce := range []uint64{0, 1, 2, 3, 4, 10, 11} {
+		if err := pool.Add(transaction(nonce, big.NewInt(100000), key)); err != nil {
+			t.Fatalf("tx %d: failed to add transaction: %v", nonce, err)
+		}
+	}
+	tx, _ := types.NewTransaction(0, common.Address{}, big.NewInt(100), big.NewInt(100000), big.NewInt(5), nil).SignECDSA(other)
+	if err := pool.Add(tx); err != nil {
+		t.Fatalf("failed to add transaction: %v", err)
+	}
+	pool.checkQueue()
+
+	pending, queued, total := pool.Content(TxFilter{})
+	if len(pending) != 6 || len(queued) != 2 || total != 8 {
+		t.Fatalf("content mismatch: have %d pending, %d queued, %d total, want 6, 2, 8", len(pending), len(queued), total)
+	}
+	// Filter by sender and paginate over the pending and queued boundary
+	pending, queued, total = pool.Content(TxFilter{From: &account, Offset: 3, Limit: 3})
+	if total != 7 || len(pending) != 2 || len(queued) != 1 {
+		t.Fatalf("page mismatch: have %d pending, %d queued, %d total, want 2, 1, 7", len(pending), len(queued), total)
+	}
+	if pending[0].Nonce() != 3 || pending[1].Nonce() != 4 || queued[0].Nonce() != 10 {
+		t.Errorf("page order mismatch: have nonces %d, %d, %d, want 3, 4, 10", pending[0].Nonce(), pending[1].Nonce(), queued[0].Nonce())
+	}
+	// Filter by price floor
+	pending, queued, total = pool.Content(TxFilter{MinPrice: big.NewInt(2)})
+	if total != 1 || len(pending) != 1 || pending[0].Hash() != tx.Hash() {
+		t.Errorf("price filter mismatch: have %d pending, %d queued, %d total, want the expensive transaction", len(pending), len(queued), total)
+	}
+	// Offsets beyond the end return nothing
+	if pending, queued, total = pool.Content(TxFilter{Offset: 100}); len(pending)+len(queued) != 0 || total != 8 {
+		t.Errorf("offset beyond end: have %d transactions, %d total", len(pending)+len(queued), total)
+	}
+	// Grouping by sender keeps the nonce order
+	groups, queue, total := pool.GroupedContent(TxFilter{})
+	if len(groups) != 2 || len(groups[account]) != 5 || len(groups[otherAccount]) != 1 || len(queue[account]) != 2 || total != 8 {
+		t.Fatalf("grouped content mismatch: have %d senders, %d pending and %d queued of the account", len(groups), len(groups[account]), len(queue[account]))
+	}
+	for i, tx := range groups[account] {
+		if tx.Nonce() != uint64(i) {
+			t.Errorf("grouped nonce mismatch at %d: have %d", i, tx.Nonce())
+		}
+	}
+}
+
+// Tests that the pool nonce of an account follows its pending transactions,
+// skipping the queued ones behind a nonce gap.
+func TestTransactionPoolNonce(t *testing.T) {
+	pool, key := setupTxPool()
+	account, _ := transaction(0, big.NewInt(0), key).From()
+
+	state, _ := pool.currentState()
+	state.AddBalance(account, big.NewInt(1000000))
+	state.SetNonce(account, 2)
+	pool.resetState()
+
+	if nonce := pool.Nonce(account); nonce != 2 {
+		t.Fatalf("nonce mismatch without transactions: have %d, want 2", nonce)
+	}
+	for _, nonce := range []uint64{2, 3, 4, 6} {
+		if err := pool.Add(transaction(nonce, big.NewInt(100000), key)); err != nil {
+			t.Fatalf("tx %d: failed to add transaction: %v", nonce, err)
+		}
+	}
+	if nonce := pool.Nonce(account); nonce != 5 {
+		t.Errorf("nonce mismatch: have %d, want 5", nonce)
+	}
+	if nonce := pool.Nonce(common.Address{0x01}); nonce != 0 {
+		t.Errorf("nonce of unknown account mismatch: have %d, want 0", nonce)
+	}
+}
+
+// Tests that transactions are counted per origin, rejected ones included.
+func TestTransactionPoolOriginStats(t *testing.T) {
+	pool, key := setupTxPool()
+	account, _ := transaction(0, big.NewInt(0), key).From()
+
+	state, _ := pool.currentState()
+	state.AddBalance(account, big.NewInt(1000000))
+	pool.resetState()
+
+	client := TxOrigin{Transport: "rpc", Remote: "10.0.0.1:4000"}
+	peer := TxOrigin{Transport: TxOriginP2P, Remote: "peer"}
+
+	if err := pool.AddFrom(transaction(0, big.NewInt(100000), key), client); err != nil {
+		t.Fatalf("failed to add transaction: %v", err)
+	}
+	if err := pool.AddFrom(transaction(1, big.NewInt(1), key), client); err == nil {
+		t.Fatalf("transaction with intrinsic gas too low accepted")
+	}
+	pool.AddTransactionsFrom([]*types.Transaction{transaction(1, big.NewInt(100000), key), transaction(2, big.NewInt(100000), key)}, peer)
+	pool.Add(transaction(3, big.NewInt(100000), key))
+
+	stats := pool.OriginStats()
+	for origin, want := range map[TxOrigin][2]uint64{
+		client:                             {1, 1},
+		peer:                               {2, 0},
+		TxOrigin{Transport: TxOriginLocal}: {1, 0},
+	} {
+		have := stats[origin]
+		if have.Accepted != want[0] || have.Rejected != want[1] {
+			t.Errorf("origin %v: have %d accepted %d rejected, want %d accepted %d rejected", origin, have.Accepted, have.Rejected, want[0], want[1])
+		}
+	}
+	if len(stats) != 3 {
+		t.Errorf("origin count mismatch: have %d, want 3", len(stats))
+	}
+	// Only a limited number of origins is tracked, the oldest being forgotten
+	for i := 0; i < maxTxOrigins; i++ {
+		pool.AddFrom(transaction(0, big.NewInt(1), key), TxOrigin{Transport: "ws", Remote: fmt.Sprint(i)})
+	}
+	if stats := pool.OriginStats(); len(stats) != maxTxOrigins {
+		t.Errorf("origin count mismatch after flood: have %d, want %d", len(stats), maxTxOrigins)
+	}
+}
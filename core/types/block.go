@@ -29,7 +29,7 @@ import (
 	"time"
 
 	"github.com/expanse-project/go-expanse/common"
-	"github.com/expanse-project/go-expanse/crypto/sha3"
+	"github.com/expanse-project/go-expanse/crypto"
 	"github.com/expanse-project/go-expanse/rlp"
 )
 
@@ -111,7 +111,8 @@ func (h *Header) UnmarshalJSON(data []byte) error {
 }
 
 func rlpHash(x interface{}) (h common.Hash) {
-	hw := sha3.NewKeccak256()
+	hw := crypto.GetKeccak256()
+	defer crypto.PutKeccak256(hw)
 	rlp.Encode(hw, x)
 	hw.Sum(h[:0])
 	return h
@@ -264,23 +265,84 @@ func (b *Block) ValidateFields() error {
 	return nil
 }
 
+// DecodeRLP decodes the block in its extblock encoding. Transactions are read
+// from the stream one at a time, so decoding a block from a reader (e.g. during
+// chain import) doesn't require its encoding to be held in memory.
 func (b *Block) DecodeRLP(s *rlp.Stream) error {
-	var eb extblock
-	_, size, _ := s.Kind()
-	if err := s.Decode(&eb); err != nil {
+	size, err := s.List()
+	if err != nil {
 		return err
 	}
-	b.header, b.uncles, b.transactions = eb.Header, eb.Uncles, eb.Txs
+	var header *Header
+	if err := s.Decode(&header); err != nil {
+		return err
+	}
+	if _, err := s.List(); err != nil {
+		return err
+	}
+	txs := []*Transaction{}
+	for s.MoreDataInList() {
+		tx := new(Transaction)
+		if err := s.Decode(tx); err != nil {
+			return err
+		}
+		txs = append(txs, tx)
+	}
+	if err := s.ListEnd(); err != nil {
+		return err
+	}
+	var uncles []*Header
+	if err := s.Decode(&uncles); err != nil {
+		return err
+	}
+	if err := s.ListEnd(); err != nil {
+		return err
+	}
+	b.header, b.uncles, b.transactions = header, uncles, txs
 	b.size.Store(common.StorageSize(rlp.ListSize(size)))
 	return nil
 }
 
+// EncodeRLP writes the block in its extblock encoding. Transactions are encoded
+// straight to w one at a time instead of encoding the whole block into a buffer
+// first, which keeps chain export and block propagation from holding a second
+// copy of large blocks.
 func (b *Block) EncodeRLP(w io.Writer) error {
-	return rlp.Encode(w, extblock{
-		Header: b.header,
-		Txs:    b.transactions,
-		Uncles: b.uncles,
-	})
+	header, err := rlp.EncodeToBytes(b.header)
+	if err != nil {
+		return err
+	}
+	uncles, err := rlp.EncodeToBytes(b.uncles)
+	if err != nil {
+		return err
+	}
+	var txsize uint64
+	for _, tx := range b.transactions {
+		txsize += uint64(tx.Size())
+	}
+	lw, err := rlp.NewListWriter(w, uint64(len(header))+rlp.ListSize(txsize)+uint64(len(uncles)))
+	if err != nil {
+		return err
+	}
+	if err := lw.Encode(rlp.RawValue(header)); err != nil {
+		return err
+	}
+	txw, err := lw.List(txsize)
+	if err != nil {
+		return err
+	}
+	for _, tx := range b.transactions {
+		if err := txw.Encode(tx); err != nil {
+			return err
+		}
+	}
+	if err := txw.Close(); err != nil {
+		return err
+	}
+	if err := lw.Encode(rlp.RawValue(uncles)); err != nil {
+		return err
+	}
+	return lw.Close()
 }
 
 // [deprecated by eth/63]
@@ -364,6 +426,17 @@ func (b *Block) WithMiningResult(nonce uint64, mixDigest common.Hash) *Block {
 	}
 }
 
+// WithSeal returns a new block with the data from b but the header replaced
+// with the sealed header.
+func (b *Block) WithSeal(header *Header) *Block {
+	cpy := *header
+	return &Block{
+		header:       &cpy,
+		transactions: b.transactions,
+		uncles:       b.uncles,
+	}
+}
+
 // WithBody returns a new block with the given transaction and uncle contents.
 func (b *Block) WithBody(transactions []*Transaction, uncles []*Header) *Block {
 	block := &Block{
@@ -38,6 +38,21 @@ type Receipt struct {
 	TxHash          common.Hash
 	ContractAddress common.Address
 	GasUsed         *big.Int
+
+	// Value transfers of internal calls, only if recorded and never stored
+	// with the receipt itself
+	InternalTxs []*InternalTx
+}
+
+// InternalTx is a value transfer made by a message call or contract creation
+// within a transaction, as opposed to by the transaction itself.
+type InternalTx struct {
+	TxHash common.Hash
+	Type   string // CALL or CREATE
+	From   common.Address
+	To     common.Address
+	Value  *big.Int
+	Depth  uint
 }
 
 // NewReceipt creates a barebone transaction receipt, copying the init fields.
@@ -33,7 +33,10 @@ import (
 	"github.com/expanse-project/go-expanse/rlp"
 )
 
-var ErrInvalidSig = errors.New("invalid v, r, s values")
+var (
+	ErrInvalidSig     = errors.New("invalid v, r, s values")
+	ErrInvalidChainId = errors.New("invalid chain id for signer")
+)
 
 type Transaction struct {
 	data txdata
@@ -49,8 +52,7 @@ type txdata struct {
 	Recipient       *common.Address `rlp:"nil"` // nil means contract creation
 	Amount          *big.Int
 	Payload         []byte
-	V               byte     // signature
-	R, S            *big.Int // signature
+	V, R, S         *big.Int // signature, V encodes the chain id of replay protected ones
 }
 
 func NewContractCreation(nonce uint64, amount, gasLimit, gasPrice *big.Int, data []byte) *Transaction {
@@ -64,6 +66,7 @@ func NewContractCreation(nonce uint64, amount, gasLimit, gasPrice *big.Int, data
 		GasLimit:     new(big.Int).Set(gasLimit),
 		Price:        new(big.Int).Set(gasPrice),
 		Payload:      data,
+		V:            new(big.Int),
 		R:            new(big.Int),
 		S:            new(big.Int),
 	}}
@@ -80,6 +83,7 @@ func NewTransaction(nonce uint64, to common.Address, amount, gasLimit, gasPrice
 		Amount:       new(big.Int),
 		GasLimit:     new(big.Int),
 		Price:        new(big.Int),
+		V:            new(big.Int),
 		R:            new(big.Int),
 		S:            new(big.Int),
 	}
@@ -114,6 +118,22 @@ func (tx *Transaction) GasPrice() *big.Int { return new(big.Int).Set(tx.data.Pri
 func (tx *Transaction) Value() *big.Int    { return new(big.Int).Set(tx.data.Amount) }
 func (tx *Transaction) Nonce() uint64      { return tx.data.AccountNonce }
 
+// Protected returns whether the signature of tx is replay protected, i.e. only
+// valid on the chain with the id encoded in V (EIP-155).
+func (tx *Transaction) Protected() bool {
+	return tx.data.V.Cmp(big.NewInt(35)) >= 0
+}
+
+// ChainId returns the chain id a replay protected transaction was signed for,
+// or nil if tx isn't protected.
+func (tx *Transaction) ChainId() *big.Int {
+	if !tx.Protected() {
+		return nil
+	}
+	id := new(big.Int).Sub(tx.data.V, big.NewInt(35))
+	return id.Rsh(id, 1)
+}
+
 func (tx *Transaction) To() *common.Address {
 	if tx.data.Recipient == nil {
 		return nil
@@ -134,8 +154,9 @@ func (tx *Transaction) Hash() common.Hash {
 	return v
 }
 
-// SigHash returns the hash to be signed by the sender.
-// It does not uniquely identify the transaction.
+// SigHash returns the hash to be signed by the sender of an unprotected
+// transaction. It does not uniquely identify the transaction. Replay protected
+// transactions sign the hash of their Signer instead.
 func (tx *Transaction) SigHash() common.Hash {
 	return rlpHash([]interface{}{
 		tx.data.AccountNonce,
@@ -197,7 +218,16 @@ func doFrom(tx *Transaction, homestead bool) (common.Address, error) {
 	if from := tx.from.Load(); from != nil {
 		return from.(common.Address), nil
 	}
-	pubkey, err := tx.publicKey(homestead)
+	var (
+		pubkey []byte
+		err    error
+	)
+	if tx.Protected() {
+		// Replay protection came after homestead, its rules always apply
+		pubkey, err = tx.publicKey(NewEIP155Signer(tx.ChainId()).Hash(tx), tx.ChainId(), true)
+	} else {
+		pubkey, err = tx.publicKey(tx.SigHash(), nil, homestead)
+	}
 	if err != nil {
 		return common.Address{}, err
 	}
@@ -214,12 +244,19 @@ func (tx *Transaction) Cost() *big.Int {
 	return total
 }
 
-func (tx *Transaction) SignatureValues() (v byte, r *big.Int, s *big.Int) {
-	return tx.data.V, new(big.Int).Set(tx.data.R), new(big.Int).Set(tx.data.S)
+func (tx *Transaction) SignatureValues() (v, r, s *big.Int) {
+	return new(big.Int).Set(tx.data.V), new(big.Int).Set(tx.data.R), new(big.Int).Set(tx.data.S)
 }
 
-func (tx *Transaction) publicKey(homestead bool) ([]byte, error) {
-	if !crypto.ValidateSignatureValues(tx.data.V, tx.data.R, tx.data.S, homestead) {
+// publicKey recovers the public key which signed hash, with V encoding the
+// given chain id, or being 27 or 28 if chainId is nil.
+func (tx *Transaction) publicKey(hash common.Hash, chainId *big.Int, homestead bool) ([]byte, error) {
+	v := new(big.Int).Set(tx.data.V)
+	if chainId != nil {
+		v.Sub(v, new(big.Int).Lsh(chainId, 1))
+		v.Sub(v, big.NewInt(8))
+	}
+	if v.BitLen() > 8 || !crypto.ValidateSignatureValues(byte(v.Uint64()), tx.data.R, tx.data.S, homestead) {
 		return nil, ErrInvalidSig
 	}
 
@@ -228,10 +265,9 @@ func (tx *Transaction) publicKey(homestead bool) ([]byte, error) {
 	sig := make([]byte, 65)
 	copy(sig[32-len(r):32], r)
 	copy(sig[64-len(s):64], s)
-	sig[64] = tx.data.V - 27
+	sig[64] = byte(v.Uint64() - 27)
 
 	// recover the public key from the signature
-	hash := tx.SigHash()
 	pub, err := crypto.Ecrecover(hash[:], sig)
 	if err != nil {
 		glog.V(logger.Error).Infof("Could not get pubkey from signature: ", err)
@@ -243,6 +279,8 @@ func (tx *Transaction) publicKey(homestead bool) ([]byte, error) {
 	return pub, nil
 }
 
+// WithSignature returns a copy of tx carrying sig, a signature of SigHash, as
+// an unprotected signature.
 func (tx *Transaction) WithSignature(sig []byte) (*Transaction, error) {
 	if len(sig) != 65 {
 		panic(fmt.Sprintf("wrong size for signature: got %d, want 65", len(sig)))
@@ -250,7 +288,7 @@ func (tx *Transaction) WithSignature(sig []byte) (*Transaction, error) {
 	cpy := &Transaction{data: tx.data}
 	cpy.data.R = new(big.Int).SetBytes(sig[:32])
 	cpy.data.S = new(big.Int).SetBytes(sig[32:64])
-	cpy.data.V = sig[64] + 27
+	cpy.data.V = big.NewInt(int64(sig[64]) + 27)
 	return cpy, nil
 }
 
@@ -369,49 +407,82 @@ func (s *TxByPrice) Pop() interface{} {
 	return x
 }
 
-// SortByPriceAndNonce sorts the transactions by price in such a way that the
-// nonce orderings within a single account are maintained.
+// TransactionsByPriceAndNonce represents a set of transactions that can return
+// transactions in a profit-maximising sorted order, while supporting removing
+// entire batches of transactions for non-executable accounts.
 //
 // Note, this is not as trivial as it seems from the first look as there are three
 // different criteria that need to be taken into account (price, nonce, account
 // match), which cannot be done with any plain sorting method, as certain items
 // cannot be compared without context.
 //
-// This method first sorts the separates the list of transactions into individual
-// sender accounts and sorts them by nonce. After the account nonce ordering is
-// satisfied, the results are merged back together by price, always comparing only
-// the head transaction from each account. This is done via a heap to keep it fast.
-func SortByPriceAndNonce(txs []*Transaction) {
+// The transactions are first separated into individual sender accounts and
+// sorted by nonce. After the account nonce ordering is satisfied, the accounts
+// are merged together by price, always comparing only the head transaction from
+// each account. This is done via a heap to keep it fast.
+type TransactionsByPriceAndNonce struct {
+	txs   map[common.Address]Transactions // Per account nonce-sorted list of transactions
+	heads TxByPrice                       // Next transaction for each unique account (price heap)
+}
+
+// NewTransactionsByPriceAndNonce creates a transaction set that can retrieve
+// price sorted transactions in a nonce-honouring way.
+func NewTransactionsByPriceAndNonce(txs Transactions) *TransactionsByPriceAndNonce {
 	// Separate the transactions by account and sort by nonce
-	byNonce := make(map[common.Address][]*Transaction)
+	byNonce := make(map[common.Address]Transactions)
 	for _, tx := range txs {
 		acc, _ := tx.From() // we only sort valid txs so this cannot fail
 		byNonce[acc] = append(byNonce[acc], tx)
 	}
-	for _, accTxs := range byNonce {
-		sort.Sort(TxByNonce(accTxs))
-	}
 	// Initialize a price based heap with the head transactions
-	byPrice := make(TxByPrice, 0, len(byNonce))
+	heads := make(TxByPrice, 0, len(byNonce))
 	for acc, accTxs := range byNonce {
-		byPrice = append(byPrice, accTxs[0])
+		sort.Sort(TxByNonce(accTxs))
+		heads = append(heads, accTxs[0])
 		byNonce[acc] = accTxs[1:]
 	}
-	heap.Init(&byPrice)
+	heap.Init(&heads)
+
+	return &TransactionsByPriceAndNonce{
+		txs:   byNonce,
+		heads: heads,
+	}
+}
+
+// Peek returns the next transaction by price, or nil if the set is exhausted.
+func (t *TransactionsByPriceAndNonce) Peek() *Transaction {
+	if len(t.heads) == 0 {
+		return nil
+	}
+	return t.heads[0]
+}
+
+// Shift replaces the current best head with the next one from the same account.
+func (t *TransactionsByPriceAndNonce) Shift() {
+	acc, _ := t.heads[0].From() // we only sort valid txs so this cannot fail
+	if txs, ok := t.txs[acc]; ok && len(txs) > 0 {
+		t.heads[0], t.txs[acc] = txs[0], txs[1:]
+		heap.Fix(&t.heads, 0)
+	} else {
+		heap.Pop(&t.heads)
+	}
+}
+
+// Pop removes the best transaction, *not* replacing it with the next one from
+// the same account. This should be used when a transaction cannot be executed
+// and hence all subsequent ones should be discarded from the same account.
+func (t *TransactionsByPriceAndNonce) Pop() {
+	heap.Pop(&t.heads)
+}
+
+// SortByPriceAndNonce sorts the transactions by price in such a way that the
+// nonce orderings within a single account are maintained.
+func SortByPriceAndNonce(txs []*Transaction) {
+	set := NewTransactionsByPriceAndNonce(txs)
 
-	// Merge by replacing the best with the next from the same account
 	txs = txs[:0]
-	for len(byPrice) > 0 {
-		// Retrieve the next best transaction by price
-		best := heap.Pop(&byPrice).(*Transaction)
-
-		// Push in its place the next transaction from the same account
-		acc, _ := best.From() // we only sort valid txs so this cannot fail
-		if accTxs, ok := byNonce[acc]; ok && len(accTxs) > 0 {
-			heap.Push(&byPrice, accTxs[0])
-			byNonce[acc] = accTxs[1:]
-		}
-		// Accumulate the best priced transaction
-		txs = append(txs, best)
+	for tx := set.Peek(); tx != nil; tx = set.Peek() {
+		txs = append(txs, tx)
+		set.Shift()
 	}
 }
@@ -0,0 +1,153 @@
+// Copyright 2016 The go-expanse Authors
+// This file is part of the go-expanse library.
+//
+// The go-expanse library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-expanse library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-expanse library. If not, see <http://www.gnu.org/licenses/>.
+
+package types
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/expanse-project/go-expanse/common"
+	"github.com/expanse-project/go-expanse/crypto"
+	"github.com/expanse-project/go-expanse/params"
+)
+
+// ErrUnexpectedProtection is returned for replay protected transactions in
+// blocks before replay protection is enabled.
+var ErrUnexpectedProtection = errors.New("replay protected transaction before EIP-155")
+
+// Signer is the transaction signature scheme of a range of blocks. It decides
+// what a sender signs and which signatures are valid.
+type Signer interface {
+	// Hash returns the hash a sender signs.
+	Hash(tx *Transaction) common.Hash
+
+	// WithSignature returns a copy of tx carrying sig, a signature of the hash
+	// of the signer.
+	WithSignature(tx *Transaction, sig []byte) (*Transaction, error)
+
+	// Sender returns the address which signed tx, failing if the signature
+	// isn't valid under the rules of the signer.
+	Sender(tx *Transaction) (common.Address, error)
+}
+
+// MakeSigner returns the signer of the block with the given number.
+func MakeSigner(number *big.Int) Signer {
+	switch {
+	case params.IsEIP155(number):
+		return NewEIP155Signer(params.ChainId)
+	case params.IsHomestead(number):
+		return HomesteadSigner{}
+	default:
+		return FrontierSigner{}
+	}
+}
+
+// SignTx signs tx with the given key under the rules of signer.
+func SignTx(tx *Transaction, signer Signer, prv *ecdsa.PrivateKey) (*Transaction, error) {
+	h := signer.Hash(tx)
+	sig, err := crypto.Sign(h[:], prv)
+	if err != nil {
+		return nil, err
+	}
+	return signer.WithSignature(tx, sig)
+}
+
+// FrontierSigner is the signer of the blocks before homestead, accepting
+// unprotected signatures with s values in the full range.
+type FrontierSigner struct{}
+
+func (s FrontierSigner) Hash(tx *Transaction) common.Hash { return tx.SigHash() }
+
+func (s FrontierSigner) WithSignature(tx *Transaction, sig []byte) (*Transaction, error) {
+	return tx.WithSignature(sig)
+}
+
+func (s FrontierSigner) Sender(tx *Transaction) (common.Address, error) {
+	if tx.Protected() {
+		return common.Address{}, ErrUnexpectedProtection
+	}
+	return tx.FromFrontier()
+}
+
+// HomesteadSigner is the signer of the blocks from homestead on, accepting
+// unprotected signatures with s values in the lower half of the range.
+type HomesteadSigner struct{}
+
+func (s HomesteadSigner) Hash(tx *Transaction) common.Hash { return tx.SigHash() }
+
+func (s HomesteadSigner) WithSignature(tx *Transaction, sig []byte) (*Transaction, error) {
+	return tx.WithSignature(sig)
+}
+
+func (s HomesteadSigner) Sender(tx *Transaction) (common.Address, error) {
+	if tx.Protected() {
+		return common.Address{}, ErrUnexpectedProtection
+	}
+	return tx.From()
+}
+
+// EIP155Signer is the signer of the blocks with replay protection. It signs
+// the chain id along with the transaction and encodes it in V, so signatures
+// can't be replayed on other chains. Unprotected homestead signatures remain
+// valid.
+type EIP155Signer struct {
+	chainId, chainIdMul *big.Int
+}
+
+// NewEIP155Signer creates a signer for the chain with the given id.
+func NewEIP155Signer(chainId *big.Int) EIP155Signer {
+	if chainId == nil {
+		chainId = new(big.Int)
+	}
+	return EIP155Signer{
+		chainId:    chainId,
+		chainIdMul: new(big.Int).Lsh(chainId, 1),
+	}
+}
+
+func (s EIP155Signer) Hash(tx *Transaction) common.Hash {
+	return rlpHash([]interface{}{
+		tx.data.AccountNonce,
+		tx.data.Price,
+		tx.data.GasLimit,
+		tx.data.Recipient,
+		tx.data.Amount,
+		tx.data.Payload,
+		s.chainId, uint(0), uint(0),
+	})
+}
+
+func (s EIP155Signer) WithSignature(tx *Transaction, sig []byte) (*Transaction, error) {
+	if len(sig) != 65 {
+		panic(fmt.Sprintf("wrong size for signature: got %d, want 65", len(sig)))
+	}
+	cpy := &Transaction{data: tx.data}
+	cpy.data.R = new(big.Int).SetBytes(sig[:32])
+	cpy.data.S = new(big.Int).SetBytes(sig[32:64])
+	cpy.data.V = new(big.Int).SetInt64(int64(sig[64]) + 35)
+	cpy.data.V.Add(cpy.data.V, s.chainIdMul)
+	return cpy, nil
+}
+
+func (s EIP155Signer) Sender(tx *Transaction) (common.Address, error) {
+	if tx.Protected() && tx.ChainId().Cmp(s.chainId) != 0 {
+		return common.Address{}, ErrInvalidChainId
+	}
+	return tx.From()
+}
@@ -0,0 +1,91 @@
+// Copyright 2016 The go-expanse Authors
+// This file is part of the go-expanse library.
+//
+// The go-expanse library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-expanse library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-expanse library. If not, see <http://www.gnu.org/licenses/>.
+
+package types
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/expanse-project/go-expanse/common"
+	"github.com/expanse-project/go-expanse/crypto"
+	"github.com/expanse-project/go-expanse/params"
+	"github.com/expanse-project/go-expanse/rlp"
+)
+
+func TestEIP155Signing(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	addr := crypto.PubkeyToAddress(key.PublicKey)
+
+	signer := NewEIP155Signer(big.NewInt(2))
+	tx, err := SignTx(NewTransaction(0, common.Address{1}, new(big.Int), big.NewInt(21000), new(big.Int), nil), signer, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !tx.Protected() {
+		t.Fatalf("signed transaction not protected")
+	}
+	if id := tx.ChainId(); id == nil || id.Cmp(big.NewInt(2)) != 0 {
+		t.Errorf("chain id mismatch: have %v, want 2", id)
+	}
+	// The sender survives the wire encoding
+	enc, _ := rlp.EncodeToBytes(tx)
+	decoded := new(Transaction)
+	if err := rlp.DecodeBytes(enc, decoded); err != nil {
+		t.Fatalf("failed to decode transaction: %v", err)
+	}
+	if from, err := signer.Sender(decoded); err != nil || from != addr {
+		t.Errorf("sender mismatch: have %x (%v), want %x", from, err, addr)
+	}
+	// Other chains and unprotected signers reject it
+	if _, err := NewEIP155Signer(big.NewInt(1)).Sender(decoded); err != ErrInvalidChainId {
+		t.Errorf("other chain: error mismatch: have %v, want %v", err, ErrInvalidChainId)
+	}
+	if _, err := (HomesteadSigner{}).Sender(decoded); err != ErrUnexpectedProtection {
+		t.Errorf("homestead: error mismatch: have %v, want %v", err, ErrUnexpectedProtection)
+	}
+	// Unprotected signatures remain valid with replay protection
+	legacy, _ := SignTx(NewTransaction(0, common.Address{1}, new(big.Int), big.NewInt(21000), new(big.Int), nil), HomesteadSigner{}, key)
+	if legacy.Protected() || legacy.ChainId() != nil {
+		t.Errorf("unprotected transaction reported as protected")
+	}
+	if from, err := signer.Sender(legacy); err != nil || from != addr {
+		t.Errorf("unprotected sender mismatch: have %x (%v), want %x", from, err, addr)
+	}
+}
+
+func TestMakeSigner(t *testing.T) {
+	defer func(homestead, chainId, eip155 *big.Int) {
+		params.HomesteadBlock, params.ChainId, params.EIP155Block = homestead, chainId, eip155
+	}(params.HomesteadBlock, params.ChainId, params.EIP155Block)
+
+	params.HomesteadBlock, params.ChainId, params.EIP155Block = big.NewInt(10), big.NewInt(2), big.NewInt(20)
+
+	if _, ok := MakeSigner(big.NewInt(9)).(FrontierSigner); !ok {
+		t.Errorf("block 9: have %T, want FrontierSigner", MakeSigner(big.NewInt(9)))
+	}
+	if _, ok := MakeSigner(big.NewInt(19)).(HomesteadSigner); !ok {
+		t.Errorf("block 19: have %T, want HomesteadSigner", MakeSigner(big.NewInt(19)))
+	}
+	if signer, ok := MakeSigner(big.NewInt(20)).(EIP155Signer); !ok || signer.chainId.Cmp(params.ChainId) != 0 {
+		t.Errorf("block 20: have %#v, want EIP155Signer for chain 2", MakeSigner(big.NewInt(20)))
+	}
+	// Without a chain id there is nothing to protect with
+	params.ChainId = nil
+	if _, ok := MakeSigner(big.NewInt(20)).(HomesteadSigner); !ok {
+		t.Errorf("no chain id: have %T, want HomesteadSigner", MakeSigner(big.NewInt(20)))
+	}
+}
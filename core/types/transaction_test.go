@@ -174,3 +174,48 @@ func TestTransactionPriceNonceSort(t *testing.T) {
 		}
 	}
 }
+
+// Tests that popping a transaction drops all remaining transactions of its
+// account, while the others are still returned in price order.
+func TestTransactionPriceNonceSortPop(t *testing.T) {
+	keys := make([]*ecdsa.PrivateKey, 3)
+	for i := 0; i < len(keys); i++ {
+		keys[i], _ = crypto.GenerateKey()
+	}
+	// The first account pays the most, but only for its first transaction
+	txs := Transactions{}
+	for i, key := range keys {
+		for nonce := 0; nonce < 3; nonce++ {
+			price := big.NewInt(int64(10 - i))
+			if i == 0 && nonce > 0 {
+				price = big.NewInt(1)
+			}
+			tx, _ := NewTransaction(uint64(nonce), common.Address{}, big.NewInt(100), big.NewInt(100), price, nil).SignECDSA(key)
+			txs = append(txs, tx)
+		}
+	}
+	set := NewTransactionsByPriceAndNonce(txs)
+
+	dropped, _ := set.Peek().From()
+	if want := crypto.PubkeyToAddress(keys[0].PublicKey); dropped != want {
+		t.Fatalf("best sender mismatch: have %x, want %x", dropped, want)
+	}
+	set.Pop()
+
+	var prev *Transaction
+	count := 0
+	for tx := set.Peek(); tx != nil; tx = set.Peek() {
+		if from, _ := tx.From(); from == dropped {
+			t.Errorf("tx #%d from popped account %x returned", count, from[:4])
+		}
+		if prev != nil && prev.GasPrice().Cmp(tx.GasPrice()) < 0 {
+			t.Errorf("tx #%d: price ordering violated: %v before %v", count, prev.GasPrice(), tx.GasPrice())
+		}
+		prev = tx
+		count++
+		set.Shift()
+	}
+	if count != 6 {
+		t.Errorf("transaction count mismatch: have %d, want %d", count, 6)
+	}
+}
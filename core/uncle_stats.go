@@ -0,0 +1,143 @@
+// Copyright 2016 The go-expanse Authors
+// This file is part of the go-expanse library.
+//
+// The go-expanse library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-expanse library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-expanse library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/expanse-project/go-expanse/common"
+	"github.com/expanse-project/go-expanse/core/types"
+)
+
+// propagationCacheLimit is the number of recently imported blocks whose
+// propagation delay is remembered for uncle statistics.
+const propagationCacheLimit = 4096
+
+// MinerStats counts the canonical blocks and uncles mined by an address.
+type MinerStats struct {
+	Blocks int
+	Uncles int
+}
+
+// DelayStats summarises the estimated propagation delays of a set of blocks,
+// that is the time between a block's timestamp and its arrival at this node.
+// Timestamps have a one second granularity so the delays are estimates.
+type DelayStats struct {
+	Samples int
+	Mean    time.Duration
+	Median  time.Duration
+	Max     time.Duration
+}
+
+// UncleStats holds the uncle rate, miner distribution and propagation delays
+// of a range of canonical blocks.
+type UncleStats struct {
+	From, To    uint64
+	Blocks      int
+	Uncles      int
+	Miners      map[common.Address]*MinerStats
+	BlockDelays DelayStats // delays of the canonical blocks in the range
+	UncleDelays DelayStats // delays of the included uncles received as blocks
+}
+
+// UncleRate returns the number of uncles included per canonical block.
+func (s *UncleStats) UncleRate() float64 {
+	if s.Blocks == 0 {
+		return 0
+	}
+	return float64(s.Uncles) / float64(s.Blocks)
+}
+
+// recordPropagation remembers how long after its timestamp a block arrived.
+// Blocks not received from the network (e.g. mined locally) are skipped.
+func (self *BlockChain) recordPropagation(block *types.Block) {
+	if block.ReceivedAt.IsZero() {
+		return
+	}
+	delay := block.ReceivedAt.Sub(time.Unix(block.Time().Int64(), 0))
+	if delay < 0 {
+		delay = 0
+	}
+	self.propDelays.Add(block.Hash(), delay)
+}
+
+// UncleStats gathers the uncle statistics of the canonical blocks from..to.
+// Propagation delays are only known for blocks imported since startup.
+func (self *BlockChain) UncleStats(from, to uint64) (*UncleStats, error) {
+	if from > to {
+		return nil, fmt.Errorf("invalid range: #%d after #%d", from, to)
+	}
+	stats := &UncleStats{
+		From:   from,
+		To:     to,
+		Miners: make(map[common.Address]*MinerStats),
+	}
+	miner := func(addr common.Address) *MinerStats {
+		if stats.Miners[addr] == nil {
+			stats.Miners[addr] = new(MinerStats)
+		}
+		return stats.Miners[addr]
+	}
+	var blockDelays, uncleDelays []time.Duration
+	for num := from; num <= to; num++ {
+		block := self.GetBlockByNumber(num)
+		if block == nil {
+			return nil, fmt.Errorf("block #%d not found", num)
+		}
+		stats.Blocks++
+		miner(block.Coinbase()).Blocks++
+		if delay, ok := self.propDelays.Get(block.Hash()); ok {
+			blockDelays = append(blockDelays, delay.(time.Duration))
+		}
+		for _, uncle := range block.Uncles() {
+			stats.Uncles++
+			miner(uncle.Coinbase).Uncles++
+			if delay, ok := self.propDelays.Get(uncle.Hash()); ok {
+				uncleDelays = append(uncleDelays, delay.(time.Duration))
+			}
+		}
+	}
+	stats.BlockDelays = summariseDelays(blockDelays)
+	stats.UncleDelays = summariseDelays(uncleDelays)
+	return stats, nil
+}
+
+type durations []time.Duration
+
+func (d durations) Len() int           { return len(d) }
+func (d durations) Less(i, j int) bool { return d[i] < d[j] }
+func (d durations) Swap(i, j int)      { d[i], d[j] = d[j], d[i] }
+
+func summariseDelays(delays []time.Duration) DelayStats {
+	if len(delays) == 0 {
+		return DelayStats{}
+	}
+	sort.Sort(durations(delays))
+
+	var total time.Duration
+	for _, delay := range delays {
+		total += delay
+	}
+	return DelayStats{
+		Samples: len(delays),
+		Mean:    total / time.Duration(len(delays)),
+		Median:  delays[len(delays)/2],
+		Max:     delays[len(delays)-1],
+	}
+}
@@ -0,0 +1,77 @@
+// Copyright 2016 The go-expanse Authors
+// This file is part of the go-expanse library.
+//
+// The go-expanse library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-expanse library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-expanse library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"testing"
+	"time"
+
+	"github.com/expanse-project/go-expanse/common"
+	"github.com/expanse-project/go-expanse/ethdb"
+)
+
+func TestUncleStats(t *testing.T) {
+	var (
+		db, _   = ethdb.NewMemDatabase()
+		genesis = WriteGenesisBlockForTesting(db)
+		miner   = common.Address{0x01}
+		uncler  = common.Address{0x02}
+	)
+	chain, _ := GenerateChain(genesis, db, 4, func(i int, gen *BlockGen) {
+		gen.SetCoinbase(miner)
+		if i == 3 {
+			// Block 4 includes a sibling of block 2, mined by someone else
+			uncle := gen.PrevBlock(1).Header()
+			uncle.Extra = []byte("foo")
+			uncle.Coinbase = uncler
+			gen.AddUncle(uncle)
+		}
+	})
+	chain[1].ReceivedAt = time.Unix(chain[1].Time().Int64(), 0).Add(1500 * time.Millisecond)
+	chain[2].ReceivedAt = time.Unix(chain[2].Time().Int64(), 0).Add(500 * time.Millisecond)
+
+	blockchain, _ := NewBlockChain(db, FakePow{})
+	if i, err := blockchain.InsertChain(chain); err != nil {
+		t.Fatalf("failed to insert block %d: %v", i, err)
+	}
+	stats, err := blockchain.UncleStats(1, 4)
+	if err != nil {
+		t.Fatalf("failed to gather stats: %v", err)
+	}
+	if stats.Blocks != 4 || stats.Uncles != 1 {
+		t.Errorf("counts mismatch: have %d blocks %d uncles, want 4 blocks 1 uncle", stats.Blocks, stats.Uncles)
+	}
+	if rate := stats.UncleRate(); rate != 0.25 {
+		t.Errorf("uncle rate mismatch: have %v, want 0.25", rate)
+	}
+	if m := stats.Miners[miner]; m == nil || m.Blocks != 4 || m.Uncles != 0 {
+		t.Errorf("miner stats mismatch: have %+v", m)
+	}
+	if m := stats.Miners[uncler]; m == nil || m.Blocks != 0 || m.Uncles != 1 {
+		t.Errorf("uncle miner stats mismatch: have %+v", m)
+	}
+	want := DelayStats{Samples: 2, Mean: time.Second, Median: 1500 * time.Millisecond, Max: 1500 * time.Millisecond}
+	if stats.BlockDelays != want {
+		t.Errorf("block delays mismatch: have %+v, want %+v", stats.BlockDelays, want)
+	}
+	if stats.UncleDelays.Samples != 0 {
+		t.Errorf("unexpected uncle delay samples: %d", stats.UncleDelays.Samples)
+	}
+	if _, err := blockchain.UncleStats(3, 5); err == nil {
+		t.Errorf("expected error for range beyond the head")
+	}
+}
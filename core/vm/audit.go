@@ -0,0 +1,61 @@
+// Copyright 2016 The go-expanse Authors
+// This file is part of the go-expanse library.
+//
+// The go-expanse library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-expanse library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-expanse library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"math/big"
+	"sync"
+
+	"github.com/expanse-project/go-expanse/metrics"
+	gometrics "github.com/rcrowley/go-metrics"
+)
+
+// PrecompileAudit enables the gas metering audit mode of the VM. When set, every
+// invocation of a precompiled contract and of the SHA3 (Keccak-256) opcode is
+// counted and the gas charged for it is recorded into the metrics system, so the
+// pricing of these operations can be evaluated against live network usage.
+//
+// Note, the metrics system itself needs to be enabled for anything to be recorded.
+var PrecompileAudit bool
+
+// auditMeters is the pair of meters tracking a single audited operation.
+type auditMeters struct {
+	calls gometrics.Meter // Number of invocations of the operation
+	gas   gometrics.Meter // Total gas charged for the invocations
+}
+
+var (
+	auditLock  sync.Mutex
+	auditIndex = make(map[string]*auditMeters)
+)
+
+// auditGas records a single invocation of the named operation, charging gas.
+func auditGas(name string, gas *big.Int) {
+	auditLock.Lock()
+	meters, ok := auditIndex[name]
+	if !ok {
+		meters = &auditMeters{
+			calls: metrics.NewMeter("vm/audit/" + name + "/calls"),
+			gas:   metrics.NewMeter("vm/audit/" + name + "/gas"),
+		}
+		auditIndex[name] = meters
+	}
+	auditLock.Unlock()
+
+	meters.calls.Mark(1)
+	meters.gas.Mark(gas.Int64())
+}
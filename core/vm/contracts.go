@@ -28,8 +28,9 @@ import (
 
 // PrecompiledAccount represents a native ethereum contract
 type PrecompiledAccount struct {
-	Gas func(l int) *big.Int
-	fn  func(in []byte) []byte
+	Gas  func(l int) *big.Int
+	fn   func(in []byte) []byte
+	name string // Name used when auditing the contract's gas usage
 }
 
 // Call calls the native function
@@ -47,28 +48,28 @@ func PrecompiledContracts() map[string]*PrecompiledAccount {
 		// ECRECOVER
 		string(common.LeftPadBytes([]byte{1}, 20)): &PrecompiledAccount{func(l int) *big.Int {
 			return params.EcrecoverGas
-		}, ecrecoverFunc},
+		}, ecrecoverFunc, "ecrecover"},
 
 		// SHA256
 		string(common.LeftPadBytes([]byte{2}, 20)): &PrecompiledAccount{func(l int) *big.Int {
 			n := big.NewInt(int64(l+31) / 32)
 			n.Mul(n, params.Sha256WordGas)
 			return n.Add(n, params.Sha256Gas)
-		}, sha256Func},
+		}, sha256Func, "sha256"},
 
 		// RIPEMD160
 		string(common.LeftPadBytes([]byte{3}, 20)): &PrecompiledAccount{func(l int) *big.Int {
 			n := big.NewInt(int64(l+31) / 32)
 			n.Mul(n, params.Ripemd160WordGas)
 			return n.Add(n, params.Ripemd160Gas)
-		}, ripemd160Func},
+		}, ripemd160Func, "ripemd160"},
 
 		string(common.LeftPadBytes([]byte{4}, 20)): &PrecompiledAccount{func(l int) *big.Int {
 			n := big.NewInt(int64(l+31) / 32)
 			n.Mul(n, params.IdentityWordGas)
 
 			return n.Add(n, params.IdentityGas)
-		}, memCpy},
+		}, memCpy, "identity"},
 	}
 }
 
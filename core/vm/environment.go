@@ -112,6 +112,7 @@ type StructLog struct {
 	Memory  []byte
 	Stack   []*big.Int
 	Storage map[common.Hash][]byte
+	Depth   int
 	Err     error
 }
 
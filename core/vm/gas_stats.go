@@ -0,0 +1,126 @@
+// Copyright 2016 The go-expanse Authors
+// This file is part of the go-expanse library.
+//
+// The go-expanse library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-expanse library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-expanse library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"math/big"
+	"sync"
+
+	"github.com/expanse-project/go-expanse/common"
+	"github.com/expanse-project/go-expanse/metrics"
+	gometrics "github.com/rcrowley/go-metrics"
+)
+
+// GasMetrics enables the gas accounting of processed blocks. When set, the gas
+// refunded to senders, the number of SSTOREs setting and clearing storage slots
+// and the gas charged per opcode are summed up over every processed block and
+// recorded into the metrics system. Dividing by the number of accounted blocks
+// gives the per block averages.
+//
+// Note, only the byte code VM does the accounting, code run by the JIT is not
+// included. The gas charged for the CALL family of opcodes contains the gas
+// forwarded to the callee. The metrics system itself needs to be enabled for
+// anything to be recorded.
+var GasMetrics bool
+
+var (
+	gasBlocksMeter   = metrics.NewMeter("vm/gas/blocks")
+	gasRefundMeter   = metrics.NewMeter("vm/gas/refunds")
+	sstoreSetMeter   = metrics.NewMeter("vm/gas/sstore/sets")
+	sstoreClearMeter = metrics.NewMeter("vm/gas/sstore/clears")
+
+	opGasLock   sync.Mutex
+	opGasMeters = make(map[OpCode]gometrics.Meter)
+)
+
+// GasAccountant is implemented by environments collecting the gas accounting
+// of the code they execute.
+type GasAccountant interface {
+	GasStats() *GasStats
+}
+
+// GasStats is the gas accounting of the code executed within a block.
+type GasStats struct {
+	Refund       *big.Int            // Gas refunded to the senders
+	SstoreSets   uint64              // SSTOREs of non-zero values into empty slots
+	SstoreClears uint64              // SSTOREs of zero into non-empty slots
+	OpGas        map[OpCode]*big.Int // Gas charged per executed opcode
+}
+
+// NewGasStats creates an empty gas accounting.
+func NewGasStats() *GasStats {
+	return &GasStats{
+		Refund: new(big.Int),
+		OpGas:  make(map[OpCode]*big.Int),
+	}
+}
+
+// EnvGasStats returns the gas accounting collected by env, or nil if gas
+// metrics are disabled or the environment doesn't do any accounting.
+func EnvGasStats(env Environment) *GasStats {
+	if !GasMetrics {
+		return nil
+	}
+	if acc, ok := env.(GasAccountant); ok {
+		return acc.GasStats()
+	}
+	return nil
+}
+
+// AddRefund accounts gas refunded to a sender.
+func (s *GasStats) AddRefund(gas *big.Int) {
+	s.Refund.Add(s.Refund, gas)
+}
+
+// chargeOp accounts the gas charged for executing op.
+func (s *GasStats) chargeOp(op OpCode, gas *big.Int) {
+	if total, ok := s.OpGas[op]; ok {
+		total.Add(total, gas)
+	} else {
+		s.OpGas[op] = new(big.Int).Set(gas)
+	}
+}
+
+// countSstore accounts an SSTORE overwriting the value old of a slot with val.
+func (s *GasStats) countSstore(old, val common.Hash) {
+	switch {
+	case common.EmptyHash(old) && !common.EmptyHash(val):
+		s.SstoreSets++
+	case !common.EmptyHash(old) && common.EmptyHash(val):
+		s.SstoreClears++
+	}
+}
+
+// Report records the accounting of a processed block into the metrics system.
+func (s *GasStats) Report() {
+	gasBlocksMeter.Mark(1)
+	gasRefundMeter.Mark(s.Refund.Int64())
+	sstoreSetMeter.Mark(int64(s.SstoreSets))
+	sstoreClearMeter.Mark(int64(s.SstoreClears))
+
+	opGasLock.Lock()
+	defer opGasLock.Unlock()
+
+	for op, gas := range s.OpGas {
+		meter, ok := opGasMeters[op]
+		if !ok {
+			meter = metrics.NewMeter("vm/gas/ops/" + op.String())
+			opGasMeters[op] = meter
+		}
+		meter.Mark(gas.Int64())
+	}
+}
@@ -71,6 +71,9 @@ func (instr instruction) do(program *Program, pc *uint64, env Environment, contr
 	if !contract.UseGas(cost) {
 		return nil, OutOfGasError
 	}
+	if PrecompileAudit && instr.op == SHA3 {
+		auditGas("sha3", cost)
+	}
 	// Resize the memory calculated previously
 	memory.Resize(newMemSize.Uint64())
 
@@ -96,7 +99,8 @@ func (instr instruction) do(program *Program, pc *uint64, env Environment, contr
 		}
 	case RETURN:
 		offset, size := stack.pop(), stack.pop()
-		return memory.GetPtr(offset.Int64(), size.Int64()), nil
+		// Copy the return data, the memory is reused by other executions.
+		return memory.Get(offset.Int64(), size.Int64()), nil
 	default:
 		if instr.fn == nil {
 			return nil, fmt.Errorf("Invalid opcode 0x%x", instr.op)
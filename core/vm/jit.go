@@ -304,7 +304,12 @@ func CompileProgram(program *Program) (err error) {
 // RunProgram runs the program given the enviroment and contract and returns an
 // error if the execution failed (non-consensus)
 func RunProgram(program *Program, env Environment, contract *Contract, input []byte) ([]byte, error) {
-	return runProgram(program, 0, NewMemory(), newstack(), env, contract, input)
+	mem, stack := newPooledMemory(), newPooledStack()
+	defer func() {
+		mem.release()
+		stack.release()
+	}()
+	return runProgram(program, 0, mem, stack, env, contract, input)
 }
 
 func runProgram(program *Program, pcstart uint64, mem *Memory, stack *stack, env Environment, contract *Contract, input []byte) ([]byte, error) {
@@ -4,7 +4,7 @@ import (
 	"math/big"
 	"testing"
 
-	"github.com/ethereum/go-ethereum/params"
+	"github.com/expanse-project/go-expanse/params"
 )
 
 func TestInit(t *testing.T) {
@@ -36,6 +36,10 @@ type Log struct {
 	TxIndex     uint
 	BlockHash   common.Hash
 	Index       uint
+
+	// Removed is set when the block holding the log was reorganised out of
+	// the canonical chain. It isn't stored.
+	Removed bool
 }
 
 func NewLog(address common.Address, topics []common.Hash, data []byte, number uint64) *Log {
@@ -69,3 +73,38 @@ type Logs []*Log
 // content of a log, as opposed to only the consensus fields originally (by hiding
 // the rlp interface methods).
 type LogForStorage Log
+
+// EncodeRLP implements rlp.Encoder, and flattens the consensus and derived
+// fields of a log into an RLP stream.
+func (l *LogForStorage) EncodeRLP(w io.Writer) error {
+	return rlp.Encode(w, []interface{}{l.Address, l.Topics, l.Data, l.BlockNumber, l.TxHash, l.TxIndex, l.BlockHash, l.Index})
+}
+
+// DecodeRLP implements rlp.Decoder, and loads the consensus and derived fields
+// of a log from an RLP stream.
+func (l *LogForStorage) DecodeRLP(s *rlp.Stream) error {
+	var log struct {
+		Address     common.Address
+		Topics      []common.Hash
+		Data        []byte
+		BlockNumber uint64
+		TxHash      common.Hash
+		TxIndex     uint
+		BlockHash   common.Hash
+		Index       uint
+	}
+	if err := s.Decode(&log); err != nil {
+		return err
+	}
+	*l = LogForStorage{
+		Address:     log.Address,
+		Topics:      log.Topics,
+		Data:        log.Data,
+		BlockNumber: log.BlockNumber,
+		TxHash:      log.TxHash,
+		TxIndex:     log.TxIndex,
+		BlockHash:   log.BlockHash,
+		Index:       log.Index,
+	}
+	return nil
+}
@@ -16,7 +16,19 @@
 
 package vm
 
-import "fmt"
+import (
+	"fmt"
+	"sync"
+)
+
+// maxPooledMemory is the largest backing store that is recycled between
+// executions. Bigger memories are rare and left to the garbage collector.
+const maxPooledMemory = 64 * 1024
+
+// memoryPool holds memories released by finished executions.
+var memoryPool = sync.Pool{
+	New: func() interface{} { return new(Memory) },
+}
 
 // Memory implements a simple memory model for the ethereum virtual machine.
 type Memory struct {
@@ -27,6 +39,22 @@ func NewMemory() *Memory {
 	return &Memory{nil}
 }
 
+// newPooledMemory returns an empty memory, reusing the backing store of a
+// previously released one if available.
+func newPooledMemory() *Memory {
+	return memoryPool.Get().(*Memory)
+}
+
+// release returns the memory to the pool. The memory and any slices obtained
+// through GetPtr or Data must not be used afterwards.
+func (m *Memory) release() {
+	if cap(m.store) > maxPooledMemory {
+		return
+	}
+	m.store = m.store[:0]
+	memoryPool.Put(m)
+}
+
 // Set sets offset + size to value
 func (m *Memory) Set(offset, size uint64, value []byte) {
 	// length of store may never be less than offset + size.
@@ -23,6 +23,8 @@ import (
 	"github.com/expanse-project/go-expanse/accounts/abi"
 	"github.com/expanse-project/go-expanse/common"
 	"github.com/expanse-project/go-expanse/core/vm"
+	"github.com/expanse-project/go-expanse/metrics"
+	gometrics "github.com/rcrowley/go-metrics"
 )
 
 func TestDefaults(t *testing.T) {
@@ -86,6 +88,76 @@ func TestRestoreDefaults(t *testing.T) {
 	}
 }
 
+func TestReturnDataNotShared(t *testing.T) {
+	code := func(v byte) []byte {
+		return []byte{
+			byte(vm.PUSH1), v,
+			byte(vm.PUSH1), 0,
+			byte(vm.MSTORE),
+			byte(vm.PUSH1), 32,
+			byte(vm.PUSH1), 0,
+			byte(vm.RETURN),
+		}
+	}
+	ret, _, err := Execute(code(0x2a), nil, nil)
+	if err != nil {
+		t.Fatal("didn't expect error", err)
+	}
+	// Run a second execution, reusing the pooled memory of the first one.
+	if _, _, err := Execute(code(0x17), nil, nil); err != nil {
+		t.Fatal("didn't expect error", err)
+	}
+	if len(ret) != 32 || ret[31] != 0x2a {
+		t.Errorf("return data modified by later execution: %x", ret)
+	}
+}
+
+func TestPrecompileAudit(t *testing.T) {
+	metrics.Enabled, vm.PrecompileAudit = true, true
+	defer func() { metrics.Enabled, vm.PrecompileAudit = false, false }()
+
+	// Hash 32 bytes of memory with SHA3, then feed them to the RIPEMD160 precompile
+	code := []byte{
+		byte(vm.PUSH1), 32,
+		byte(vm.PUSH1), 0,
+		byte(vm.SHA3),
+		byte(vm.POP),
+		byte(vm.PUSH1), 32, // out size
+		byte(vm.PUSH1), 0, // out offset
+		byte(vm.PUSH1), 32, // in size
+		byte(vm.PUSH1), 0, // in offset
+		byte(vm.PUSH1), 0, // value
+		byte(vm.PUSH1), 3, // ripemd160
+		byte(vm.PUSH2), 0xff, 0xff, // gas
+		byte(vm.CALL),
+	}
+	if _, _, err := Execute(code, nil, nil); err != nil {
+		t.Fatal("didn't expect error", err)
+	}
+	tests := []struct {
+		name  string
+		count int64
+	}{
+		{"vm/audit/sha3/calls", 1},
+		{"vm/audit/sha3/gas", 39}, // 30 + 6 per word + 3 memory expansion
+		{"vm/audit/ripemd160/calls", 1},
+		{"vm/audit/ripemd160/gas", 720},
+	}
+	for _, tt := range tests {
+		meter, ok := gometrics.DefaultRegistry.Get(tt.name).(gometrics.Meter)
+		if !ok {
+			t.Errorf("%s: meter not registered", tt.name)
+			continue
+		}
+		if count := meter.Count(); count != tt.count {
+			t.Errorf("%s: count mismatch: have %d, want %d", tt.name, count, tt.count)
+		}
+	}
+	if meter := gometrics.DefaultRegistry.Get("vm/audit/sha256/calls"); meter != nil {
+		t.Errorf("unexpected sha256 meter: %v", meter)
+	}
+}
+
 func BenchmarkCall(b *testing.B) {
 	var definition = `[{"constant":true,"inputs":[],"name":"seller","outputs":[{"name":"","type":"address"}],"type":"function"},{"constant":false,"inputs":[],"name":"abort","outputs":[],"type":"function"},{"constant":true,"inputs":[],"name":"value","outputs":[{"name":"","type":"uint256"}],"type":"function"},{"constant":false,"inputs":[],"name":"refund","outputs":[],"type":"function"},{"constant":true,"inputs":[],"name":"buyer","outputs":[{"name":"","type":"address"}],"type":"function"},{"constant":false,"inputs":[],"name":"confirmReceived","outputs":[],"type":"function"},{"constant":true,"inputs":[],"name":"state","outputs":[{"name":"","type":"uint8"}],"type":"function"},{"constant":false,"inputs":[],"name":"confirmPurchase","outputs":[],"type":"function"},{"inputs":[],"type":"constructor"},{"anonymous":false,"inputs":[],"name":"Aborted","type":"event"},{"anonymous":false,"inputs":[],"name":"PurchaseConfirmed","type":"event"},{"anonymous":false,"inputs":[],"name":"ItemReceived","type":"event"},{"anonymous":false,"inputs":[],"name":"Refunded","type":"event"}]`
 
@@ -19,8 +19,14 @@ package vm
 import (
 	"fmt"
 	"math/big"
+	"sync"
 )
 
+// stackPool holds stacks released by finished executions.
+var stackPool = sync.Pool{
+	New: func() interface{} { return &stack{data: make([]*big.Int, 0, 1024)} },
+}
+
 // stack is an object for basic stack operations. Items popped to the stack are
 // expected to be changed and modified. stack does not take care of adding newly
 // initialised objects.
@@ -32,6 +38,22 @@ func newstack() *stack {
 	return &stack{}
 }
 
+// newPooledStack returns an empty stack, reusing the backing slice of a
+// previously released one if available.
+func newPooledStack() *stack {
+	return stackPool.Get().(*stack)
+}
+
+// release returns the stack to the pool. The stack must not be used afterwards.
+func (st *stack) release() {
+	// Drop the references to the items so they can be collected.
+	for i := range st.data {
+		st.data[i] = nil
+	}
+	st.data = st.data[:0]
+	stackPool.Put(st)
+}
+
 func (st *stack) Data() []*big.Int {
 	return st.data
 }
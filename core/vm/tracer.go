@@ -0,0 +1,128 @@
+// Copyright 2016 The go-expanse Authors
+// This file is part of the go-expanse library.
+//
+// The go-expanse library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-expanse library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-expanse library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"math/big"
+
+	"github.com/expanse-project/go-expanse/common"
+)
+
+// Tracer is called by the byte code VM before every instruction it executes,
+// and once more with the error if the execution of a contract fails. memory,
+// stack and gas belong to the VM and are only valid during the call, tracers
+// have to copy whatever they keep. cost is nil if the execution failed before
+// the cost of the instruction was known.
+type Tracer interface {
+	CaptureState(env Environment, pc uint64, op OpCode, gas, cost *big.Int, memory []byte, stack []*big.Int, contract *Contract, depth int, err error)
+}
+
+// TracingEnvironment is implemented by environments tracing the instructions
+// of the code they execute.
+type TracingEnvironment interface {
+	Tracer() Tracer
+}
+
+// EnvTracer returns the tracer of env, or nil if the environment doesn't trace
+// the code it executes.
+func EnvTracer(env Environment) Tracer {
+	if te, ok := env.(TracingEnvironment); ok {
+		return te.Tracer()
+	}
+	return nil
+}
+
+// LogConfig configures what a StructLogger records of every instruction.
+type LogConfig struct {
+	DisableMemory  bool // Don't record the memory
+	DisableStack   bool // Don't record the stack
+	DisableStorage bool // Don't record the storage changes
+	Limit          int  // Maximum number of instructions to record, 0 means unlimited
+	MemoryLimit    int  // Maximum number of memory bytes to record over all instructions, 0 means unlimited
+}
+
+// StructLogger is a Tracer recording a StructLog of every executed instruction.
+// The storage of a log holds the slots of the executing contract changed by an
+// SSTORE so far, with their new values, rather than the full storage. Once the
+// memory limit is spent, instructions are recorded without their memory.
+type StructLogger struct {
+	cfg LogConfig
+
+	logs    []StructLog
+	changed map[common.Address]map[common.Hash][]byte
+	memory  int // memory bytes recorded so far
+	err     error
+}
+
+// NewStructLogger creates a struct logger recording according to cfg.
+func NewStructLogger(cfg LogConfig) *StructLogger {
+	return &StructLogger{
+		cfg:     cfg,
+		changed: make(map[common.Address]map[common.Hash][]byte),
+	}
+}
+
+// CaptureState implements Tracer.
+func (l *StructLogger) CaptureState(env Environment, pc uint64, op OpCode, gas, cost *big.Int, memory []byte, stack []*big.Int, contract *Contract, depth int, err error) {
+	if err != nil && depth == 1 {
+		l.err = err
+	}
+	if l.cfg.Limit != 0 && len(l.logs) >= l.cfg.Limit {
+		return
+	}
+	log := StructLog{Pc: pc, Op: op, Gas: new(big.Int).Set(gas), Depth: depth, Err: err}
+	if cost != nil {
+		log.GasCost = new(big.Int).Set(cost)
+	}
+	if !l.cfg.DisableMemory && (l.cfg.MemoryLimit == 0 || l.memory+len(memory) <= l.cfg.MemoryLimit) {
+		log.Memory = common.CopyBytes(memory)
+		l.memory += len(memory)
+	}
+	if !l.cfg.DisableStack {
+		log.Stack = make([]*big.Int, len(stack))
+		for i, item := range stack {
+			log.Stack[i] = new(big.Int).Set(item)
+		}
+	}
+	if !l.cfg.DisableStorage {
+		address := contract.Address()
+		changed := l.changed[address]
+		// Record the slot written by an SSTORE about to be executed
+		if op == SSTORE && err == nil && len(stack) >= 2 {
+			if changed == nil {
+				changed = make(map[common.Hash][]byte)
+				l.changed[address] = changed
+			}
+			changed[common.BigToHash(stack[len(stack)-1])] = common.BigToHash(stack[len(stack)-2]).Bytes()
+		}
+		log.Storage = make(map[common.Hash][]byte, len(changed))
+		for slot, value := range changed {
+			log.Storage[slot] = value
+		}
+	}
+	l.logs = append(l.logs, log)
+}
+
+// StructLogs returns the recorded instructions.
+func (l *StructLogger) StructLogs() []StructLog {
+	return l.logs
+}
+
+// Error returns the error the outermost contract failed with, if any.
+func (l *StructLogger) Error() error {
+	return l.err
+}
@@ -0,0 +1,44 @@
+// Copyright 2016 The go-expanse Authors
+// This file is part of the go-expanse library.
+//
+// The go-expanse library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-expanse library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-expanse library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"math/big"
+	"testing"
+)
+
+// Tests that the memory recorded by a StructLogger is capped by its limit.
+func TestStructLoggerMemoryLimit(t *testing.T) {
+	logger := NewStructLogger(LogConfig{DisableStorage: true, MemoryLimit: 100})
+
+	memory := make([]byte, 64)
+	for i := 0; i < 3; i++ {
+		logger.CaptureState(nil, uint64(i), MSTORE, big.NewInt(100), big.NewInt(3), memory, nil, nil, 1, nil)
+	}
+	logs := logger.StructLogs()
+	if len(logs) != 3 {
+		t.Fatalf("instruction count mismatch: have %d, want 3", len(logs))
+	}
+	if len(logs[0].Memory) != len(memory) {
+		t.Errorf("first instruction memory mismatch: have %d bytes, want %d", len(logs[0].Memory), len(memory))
+	}
+	for i, log := range logs[1:] {
+		if log.Memory != nil {
+			t.Errorf("instruction %d: memory recorded beyond the limit", i+1)
+		}
+	}
+}
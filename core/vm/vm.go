@@ -30,7 +30,9 @@ import (
 
 // Vm is an EVM and implements VirtualMachine
 type Vm struct {
-	env Environment
+	env    Environment
+	stats  *GasStats // gas accounting, nil if disabled
+	tracer Tracer    // instruction tracing, nil if disabled
 }
 
 // New returns a new Vm
@@ -38,7 +40,7 @@ func New(env Environment) *Vm {
 	// init the jump table. Also prepares the homestead changes
 	jumpTable.init(env.BlockNumber())
 
-	return &Vm{env: env}
+	return &Vm{env: env, stats: EnvGasStats(env), tracer: EnvTracer(env)}
 }
 
 // Run loops and evaluates the contract's code with the given input data
@@ -61,7 +63,8 @@ func (self *Vm) Run(contract *Contract, input []byte) (ret []byte, err error) {
 		codehash = crypto.Sha3Hash(contract.Code) // codehash is used when doing jump dest caching
 		program  *Program
 	)
-	if EnableJit {
+	// Traced code always runs in the byte code VM, the JIT can't be traced.
+	if EnableJit && self.tracer == nil {
 		// If the JIT is enabled check the status of the JIT program,
 		// if it doesn't exist compile a new program in a seperate
 		// goroutine or wait for compilation to finish if the JIT is
@@ -98,10 +101,10 @@ func (self *Vm) Run(contract *Contract, input []byte) (ret []byte, err error) {
 		code       = contract.Code
 		instrCount = 0
 
-		op      OpCode          // current opcode
-		mem     = NewMemory()   // bound memory
-		stack   = newstack()    // local stack
-		statedb = self.env.Db() // current state
+		op      OpCode              // current opcode
+		mem     = newPooledMemory() // bound memory
+		stack   = newPooledStack()  // local stack
+		statedb = self.env.Db()     // current state
 		// For optimisation reason we're using uint64 as the program counter.
 		// It's theoretically possible to go above 2^64. The YP defines the PC to be uint256. Pratically much less so feasible.
 		pc = uint64(0) // program counter
@@ -124,6 +127,12 @@ func (self *Vm) Run(contract *Contract, input []byte) (ret []byte, err error) {
 	)
 	contract.Input = input
 
+	// Hand the memory and stack back to their pools once the execution is done.
+	defer func() {
+		mem.release()
+		stack.release()
+	}()
+
 	// User defer pattern to check for an error and, based on the error being nil or not, use all gas and return.
 	defer func() {
 		if err != nil {
@@ -164,6 +173,16 @@ func (self *Vm) Run(contract *Contract, input []byte) (ret []byte, err error) {
 		if !contract.UseGas(cost) {
 			return nil, OutOfGasError
 		}
+		if PrecompileAudit && op == SHA3 {
+			auditGas("sha3", cost)
+		}
+		if self.stats != nil {
+			self.stats.chargeOp(op, cost)
+			if op == SSTORE {
+				loc := common.BigToHash(stack.data[stack.len()-1])
+				self.stats.countSstore(statedb.GetState(contract.Address(), loc), common.BigToHash(stack.data[stack.len()-2]))
+			}
+		}
 
 		// Resize the memory calculated previously
 		mem.Resize(newMemSize.Uint64())
@@ -194,7 +213,8 @@ func (self *Vm) Run(contract *Contract, input []byte) (ret []byte, err error) {
 					}
 				case RETURN:
 					offset, size := stack.pop(), stack.pop()
-					ret := mem.GetPtr(offset.Int64(), size.Int64())
+					// Copy the return data, the memory is reused by other executions.
+					ret := mem.Get(offset.Int64(), size.Int64())
 
 					return ret, nil
 				case SUICIDE:
@@ -353,6 +373,9 @@ func calculateGasAndSize(env Environment, contract *Contract, caller ContractRef
 func (self *Vm) RunPrecompiled(p *PrecompiledAccount, input []byte, contract *Contract) (ret []byte, err error) {
 	gas := p.Gas(len(input))
 	if contract.UseGas(gas) {
+		if PrecompileAudit {
+			auditGas(p.name, gas)
+		}
 		ret = p.Call(input)
 
 		return ret, nil
@@ -379,7 +402,10 @@ func (self *Vm) log(pc uint64, op OpCode, gas, cost *big.Int, memory *Memory, st
 				storage[common.BytesToHash(k)] = v
 			})
 		*/
-		self.env.AddStructLog(StructLog{pc, op, new(big.Int).Set(gas), cost, mem, stck, storage, err})
+		self.env.AddStructLog(StructLog{pc, op, new(big.Int).Set(gas), cost, mem, stck, storage, self.env.Depth(), err})
+	}
+	if self.tracer != nil {
+		self.tracer.CaptureState(self.env, pc, op, gas, cost, memory.Data(), stack.Data(), contract, self.env.Depth(), err)
 	}
 }
 
@@ -25,18 +25,30 @@ import (
 	"github.com/expanse-project/go-expanse/core/vm"
 )
 
+// ChainContext supports retrieving the ancestor blocks a VM environment needs
+// to resolve the BLOCKHASH of recent blocks.
+type ChainContext interface {
+	GetBlock(hash common.Hash) *types.Block
+}
+
 type VMEnv struct {
 	state  *state.StateDB
 	header *types.Header
 	msg    Message
 	depth  int
-	chain  *BlockChain
+	chain  ChainContext
 	typ    vm.Type
 	// structured logging
 	logs []vm.StructLog
+	// call tree recording, nil if disabled
+	calls *CallTracer
+	// gas accounting, nil if disabled
+	gas *vm.GasStats
+	// instruction tracing, nil if disabled
+	tracer vm.Tracer
 }
 
-func NewEnv(state *state.StateDB, chain *BlockChain, msg Message, header *types.Header) *VMEnv {
+func NewEnv(state *state.StateDB, chain ChainContext, msg Message, header *types.Header) *VMEnv {
 	return &VMEnv{
 		chain:  chain,
 		state:  state,
@@ -87,19 +99,72 @@ func (self *VMEnv) Transfer(from, to vm.Account, amount *big.Int) {
 	Transfer(from, to, amount)
 }
 
+// SetCallTracer makes the environment record the call tree of the messages
+// it executes into t.
+func (self *VMEnv) SetCallTracer(t *CallTracer) {
+	self.calls = t
+}
+
+// SetGasStats makes the environment account the gas usage of the code it
+// executes into s.
+func (self *VMEnv) SetGasStats(s *vm.GasStats) {
+	self.gas = s
+}
+
+// GasStats implements vm.GasAccountant.
+func (self *VMEnv) GasStats() *vm.GasStats {
+	return self.gas
+}
+
+// SetTracer makes the environment trace the instructions of the code it
+// executes into t.
+func (self *VMEnv) SetTracer(t vm.Tracer) {
+	self.tracer = t
+}
+
+// Tracer implements vm.TracingEnvironment.
+func (self *VMEnv) Tracer() vm.Tracer {
+	return self.tracer
+}
+
 func (self *VMEnv) Call(me vm.ContractRef, addr common.Address, data []byte, gas, price, value *big.Int) ([]byte, error) {
-	return Call(self, me, addr, data, gas, price, value)
+	if self.calls == nil {
+		return Call(self, me, addr, data, gas, price, value)
+	}
+	self.calls.enter("CALL", me.Address(), addr, data, gas, value)
+	ret, err := Call(self, me, addr, data, gas, price, value)
+	self.calls.exit(ret, gas, err)
+	return ret, err
 }
 func (self *VMEnv) CallCode(me vm.ContractRef, addr common.Address, data []byte, gas, price, value *big.Int) ([]byte, error) {
-	return CallCode(self, me, addr, data, gas, price, value)
+	if self.calls == nil {
+		return CallCode(self, me, addr, data, gas, price, value)
+	}
+	self.calls.enter("CALLCODE", me.Address(), addr, data, gas, value)
+	ret, err := CallCode(self, me, addr, data, gas, price, value)
+	self.calls.exit(ret, gas, err)
+	return ret, err
 }
 
 func (self *VMEnv) DelegateCall(me vm.ContractRef, addr common.Address, data []byte, gas, price *big.Int) ([]byte, error) {
-	return DelegateCall(self, me, addr, data, gas, price)
+	if self.calls == nil {
+		return DelegateCall(self, me, addr, data, gas, price)
+	}
+	self.calls.enter("DELEGATECALL", me.Address(), addr, data, gas, me.Value())
+	ret, err := DelegateCall(self, me, addr, data, gas, price)
+	self.calls.exit(ret, gas, err)
+	return ret, err
 }
 
 func (self *VMEnv) Create(me vm.ContractRef, data []byte, gas, price, value *big.Int) ([]byte, common.Address, error) {
-	return Create(self, me, data, gas, price, value)
+	if self.calls == nil {
+		return Create(self, me, data, gas, price, value)
+	}
+	frame := self.calls.enter("CREATE", me.Address(), common.Address{}, data, gas, value)
+	ret, addr, err := Create(self, me, data, gas, price, value)
+	frame.To = addr
+	self.calls.exit(ret, gas, err)
+	return ret, addr, err
 }
 
 func (self *VMEnv) StructLogs() []vm.StructLog {
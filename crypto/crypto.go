@@ -24,10 +24,12 @@ import (
 	"crypto/rand"
 	"crypto/sha256"
 	"fmt"
+	"hash"
 	"io"
 	"io/ioutil"
 	"math/big"
 	"os"
+	"sync"
 
 	"encoding/hex"
 	"encoding/json"
@@ -35,7 +37,6 @@ import (
 
 	"github.com/expanse-project/go-expanse/common"
 	"github.com/expanse-project/go-expanse/crypto/ecies"
-	"github.com/expanse-project/go-expanse/crypto/secp256k1"
 	"github.com/expanse-project/go-expanse/crypto/sha3"
 	"github.com/expanse-project/go-expanse/rlp"
 	"github.com/pborman/uuid"
@@ -43,16 +44,40 @@ import (
 	"golang.org/x/crypto/ripemd160"
 )
 
-var secp256k1n *big.Int
+var (
+	secp256k1n     *big.Int
+	secp256k1halfN *big.Int
+)
 
 func init() {
 	// specify the params for the s256 curve
 	ecies.AddParamsForCurve(S256(), ecies.ECIES_AES128_SHA256)
 	secp256k1n = common.String2Big("0xfffffffffffffffffffffffffffffffebaaedce6af48a03bbfd25e8cd0364141")
+	secp256k1halfN = new(big.Int).Rsh(secp256k1n, 1)
+}
+
+// keccakPool holds Keccak256 states for reuse. Allocating a fresh sponge
+// for every hash is expensive during chain import.
+var keccakPool = sync.Pool{
+	New: func() interface{} { return sha3.NewKeccak256() },
+}
+
+// GetKeccak256 returns an empty Keccak256 hasher from a shared pool.
+// The hasher should be handed back with PutKeccak256 once it is no
+// longer used.
+func GetKeccak256() hash.Hash {
+	return keccakPool.Get().(hash.Hash)
+}
+
+// PutKeccak256 resets h and returns it to the pool.
+func PutKeccak256(h hash.Hash) {
+	h.Reset()
+	keccakPool.Put(h)
 }
 
 func Sha3(data ...[]byte) []byte {
-	d := sha3.NewKeccak256()
+	d := GetKeccak256()
+	defer PutKeccak256(d)
 	for _, b := range data {
 		d.Write(b)
 	}
@@ -60,7 +85,8 @@ func Sha3(data ...[]byte) []byte {
 }
 
 func Sha3Hash(data ...[]byte) (h common.Hash) {
-	d := sha3.NewKeccak256()
+	d := GetKeccak256()
+	defer PutKeccak256(d)
 	for _, b := range data {
 		d.Write(b)
 	}
@@ -89,7 +115,7 @@ func Ripemd160(data []byte) []byte {
 }
 
 func Ecrecover(hash, sig []byte) ([]byte, error) {
-	return secp256k1.RecoverPubkey(hash, sig)
+	return ecrecover(hash, sig)
 }
 
 // New methods using proper ecdsa keys from the stdlib
@@ -178,14 +204,14 @@ func ValidateSignatureValues(v byte, r, s *big.Int, homestead bool) bool {
 	vint := uint32(v)
 	// reject upper range of s values (ECDSA malleability)
 	// see discussion in secp256k1/libsecp256k1/include/secp256k1.h
-	if homestead && s.Cmp(secp256k1.HalfN) > 0 {
+	if homestead && s.Cmp(secp256k1halfN) > 0 {
 		return false
 	}
 	// Frontier: allow s to be in full N range
-	if s.Cmp(secp256k1.N) >= 0 {
+	if s.Cmp(secp256k1n) >= 0 {
 		return false
 	}
-	if r.Cmp(secp256k1.N) < 0 && (vint == 27 || vint == 28) {
+	if r.Cmp(secp256k1n) < 0 && (vint == 27 || vint == 28) {
 		return true
 	} else {
 		return false
@@ -209,7 +235,7 @@ func Sign(hash []byte, prv *ecdsa.PrivateKey) (sig []byte, err error) {
 
 	seckey := common.LeftPadBytes(prv.D.Bytes(), prv.Params().BitSize/8)
 	defer zeroBytes(seckey)
-	sig, err = secp256k1.Sign(hash, seckey)
+	sig, err = sign(hash, seckey)
 	return
 }
 
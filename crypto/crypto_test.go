@@ -28,7 +28,6 @@ import (
 	"time"
 
 	"github.com/expanse-project/go-expanse/common"
-	"github.com/expanse-project/go-expanse/crypto/secp256k1"
 )
 
 var testAddrHex = "970e8128ab834e8eac17ab8e3812f010678cf791"
@@ -49,6 +48,22 @@ func TestSha3Hash(t *testing.T) {
 	checkhash(t, "Sha3-256-array", func(in []byte) []byte { h := Sha3Hash(in); return h[:] }, msg, exp)
 }
 
+func TestKeccak256Pool(t *testing.T) {
+	msg := []byte("abc")
+	exp, _ := hex.DecodeString("4e03657aea45a94fc7d47ba826c8d667c0d1e6e33a64a036ec44f58fa12d6c45")
+	// Leave some state in a pooled hasher and check that it
+	// doesn't leak into the next user.
+	h := GetKeccak256()
+	h.Write([]byte("garbage"))
+	PutKeccak256(h)
+	checkhash(t, "Sha3-256-pooled", func(in []byte) []byte {
+		h := GetKeccak256()
+		defer PutKeccak256(h)
+		h.Write(in)
+		return h.Sum(nil)
+	}, msg, exp)
+}
+
 func TestSha256(t *testing.T) {
 	msg := []byte("abc")
 	exp, _ := hex.DecodeString("ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad")
@@ -74,7 +89,7 @@ func BenchmarkSha3(b *testing.B) {
 
 func Test0Key(t *testing.T) {
 	key := common.Hex2Bytes("0000000000000000000000000000000000000000000000000000000000000000")
-	_, err := secp256k1.GeneratePubKey(key)
+	_, err := generatePubKey(key)
 	if err == nil {
 		t.Errorf("expected error due to zero privkey")
 	}
@@ -239,10 +254,10 @@ func TestPythonIntegration(t *testing.T) {
 	k1 := FromECDSA(k0)
 
 	msg0 := Sha3([]byte("foo"))
-	sig0, _ := secp256k1.Sign(msg0, k1)
+	sig0, _ := sign(msg0, k1)
 
 	msg1 := common.FromHex("00000000000000000000000000000000")
-	sig1, _ := secp256k1.Sign(msg0, k1)
+	sig1, _ := sign(msg0, k1)
 
 	fmt.Printf("msg: %x, privkey: %x sig: %x\n", msg0, k1, sig0)
 	fmt.Printf("msg: %x, privkey: %x sig: %x\n", msg1, k1, sig1)
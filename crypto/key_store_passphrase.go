@@ -94,6 +94,10 @@ func (ks keyStorePassphrase) GetKeyAddresses() (addresses []common.Address, err
 	return getKeyAddresses(ks.keysDirPath)
 }
 
+func (ks keyStorePassphrase) GetKeyFiles() (files []KeyFile, err error) {
+	return getKeyFiles(ks.keysDirPath)
+}
+
 func (ks keyStorePassphrase) StoreKey(key *Key, auth string) (err error) {
 	authArray := []byte(auth)
 	salt := randentropy.GetEntropyCSPRNG(32)
@@ -17,6 +17,7 @@
 package crypto
 
 import (
+	"bytes"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
@@ -24,6 +25,8 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/expanse-project/go-expanse/common"
@@ -33,7 +36,8 @@ type KeyStore interface {
 	// create new key using io.Reader entropy source and optionally using auth string
 	GenerateNewKey(io.Reader, string) (*Key, error)
 	GetKey(common.Address, string) (*Key, error) // get key from addr and auth string
-	GetKeyAddresses() ([]common.Address, error)  // get all addresses
+	GetKeyAddresses() ([]common.Address, error)  // get all addresses, oldest first
+	GetKeyFiles() ([]KeyFile, error)             // get all key files, oldest first
 	StoreKey(*Key, string) error                 // store key optionally using auth string
 	DeleteKey(common.Address, string) error      // delete key by addr and auth string
 	Cleanup(keyAddr common.Address) (err error)
@@ -80,6 +84,10 @@ func (ks keyStorePlain) GetKeyAddresses() (addresses []common.Address, err error
 	return getKeyAddresses(ks.keysDirPath)
 }
 
+func (ks keyStorePlain) GetKeyFiles() (files []KeyFile, err error) {
+	return getKeyFiles(ks.keysDirPath)
+}
+
 func (ks keyStorePlain) Cleanup(keyAddr common.Address) (err error) {
 	return cleanup(ks.keysDirPath, keyAddr)
 }
@@ -192,20 +200,86 @@ func toISO8601(t time.Time) string {
 	return fmt.Sprintf("%04d-%02d-%02dT%02d-%02d-%02d.%09d%s", t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), tz)
 }
 
-func getKeyAddresses(keysDirPath string) (addresses []common.Address, err error) {
+// KeyFile describes the key file backing an account.
+type KeyFile struct {
+	Address common.Address
+	Path    string
+	Created time.Time
+}
+
+// keyFilesByCreation orders key files by creation time, breaking ties by
+// address so the order is the same on every listing.
+type keyFilesByCreation []KeyFile
+
+func (s keyFilesByCreation) Len() int      { return len(s) }
+func (s keyFilesByCreation) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s keyFilesByCreation) Less(i, j int) bool {
+	if !s[i].Created.Equal(s[j].Created) {
+		return s[i].Created.Before(s[j].Created)
+	}
+	return bytes.Compare(s[i].Address[:], s[j].Address[:]) < 0
+}
+
+// keyFileCreated returns the creation time encoded into a key file name by
+// keyFileName, falling back to the modification time for legacy key files.
+func keyFileCreated(fileInfo os.FileInfo) time.Time {
+	parts := strings.Split(fileInfo.Name(), "--")
+	if len(parts) == 3 && parts[0] == "UTC" {
+		if created, err := time.Parse("2006-01-02T15-04-05.999999999Z", parts[1]); err == nil {
+			return created
+		}
+	}
+	return fileInfo.ModTime()
+}
+
+func getKeyFiles(keysDirPath string) ([]KeyFile, error) {
 	fileInfos, err := ioutil.ReadDir(keysDirPath)
 	if err != nil {
 		return nil, err
 	}
+	index := make(map[common.Address]int)
+
+	var files []KeyFile
 	for _, fileInfo := range fileInfos {
 		filename := fileInfo.Name()
-		if len(filename) >= 40 {
-			addr := filename[len(filename)-40 : len(filename)]
-			address, err := hex.DecodeString(addr)
-			if err == nil {
-				addresses = append(addresses, common.BytesToAddress(address))
+		if len(filename) < 40 {
+			continue
+		}
+		address, err := hex.DecodeString(filename[len(filename)-40:])
+		if err != nil {
+			continue
+		}
+		file := KeyFile{
+			Address: common.BytesToAddress(address),
+			Path:    filepath.Join(keysDirPath, filename),
+			Created: keyFileCreated(fileInfo),
+		}
+		if fileInfo.IsDir() {
+			file.Path = filepath.Join(file.Path, filename)
+		}
+		// A key rewritten by an update keeps its original creation time, but
+		// is read from the newest file like getKeyFilePath does
+		if i, ok := index[file.Address]; ok {
+			if file.Created.After(files[i].Created) {
+				file.Created = files[i].Created
 			}
+			files[i] = file
+			continue
 		}
+		index[file.Address] = len(files)
+		files = append(files, file)
+	}
+	sort.Sort(keyFilesByCreation(files))
+	return files, nil
+}
+
+func getKeyAddresses(keysDirPath string) (addresses []common.Address, err error) {
+	files, err := getKeyFiles(keysDirPath)
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		addresses = append(addresses, file.Address)
 	}
-	return addresses, err
+	return addresses, nil
 }
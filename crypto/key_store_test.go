@@ -19,9 +19,13 @@ package crypto
 import (
 	"encoding/hex"
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/expanse-project/go-expanse/common"
 	"github.com/expanse-project/go-expanse/crypto/randentropy"
@@ -105,6 +109,55 @@ func TestKeyStorePassphraseDecryptionFail(t *testing.T) {
 	}
 }
 
+func TestKeyFileOrdering(t *testing.T) {
+	dir, err := ioutil.TempDir("", "exp-keystore-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	legacy := "cccccccccccccccccccccccccccccccccccccccc"
+	files := []string{
+		"UTC--2016-02-01T10-00-00.000000000Z--aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa",
+		"UTC--2016-01-01T10-00-00.000000000Z--bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb",
+		"UTC--2016-03-01T10-00-00.000000000Z--bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb",
+		"UTC--2016-02-01T10-00-00.000000000Z--1111111111111111111111111111111111111111",
+		filepath.Join(legacy, legacy),
+		"README",
+	}
+	for _, file := range files {
+		path := filepath.Join(dir, file)
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, nil, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	old := time.Date(2015, 7, 30, 0, 0, 0, 0, time.UTC)
+	if err := os.Chtimes(filepath.Join(dir, legacy), old, old); err != nil {
+		t.Fatal(err)
+	}
+	want := []KeyFile{
+		{common.HexToAddress(legacy), filepath.Join(dir, legacy, legacy), old},
+		{common.HexToAddress("bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb"), filepath.Join(dir, files[2]), time.Date(2016, 1, 1, 10, 0, 0, 0, time.UTC)},
+		{common.HexToAddress("1111111111111111111111111111111111111111"), filepath.Join(dir, files[3]), time.Date(2016, 2, 1, 10, 0, 0, 0, time.UTC)},
+		{common.HexToAddress("aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"), filepath.Join(dir, files[0]), time.Date(2016, 2, 1, 10, 0, 0, 0, time.UTC)},
+	}
+	have, err := NewKeyStorePlain(dir).GetKeyFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(have) != len(want) {
+		t.Fatalf("key file count mismatch: have %d, want %d", len(have), len(want))
+	}
+	for i := range want {
+		if have[i].Address != want[i].Address || have[i].Path != want[i].Path || !have[i].Created.Equal(want[i].Created) {
+			t.Errorf("key file %d mismatch: have %+v, want %+v", i, have[i], want[i])
+		}
+	}
+}
+
 func TestImportPreSaleKey(t *testing.T) {
 	// file content of a presale key file generated with:
 	// python pyethsaletool.py genwallet
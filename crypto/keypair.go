@@ -20,7 +20,6 @@ import (
 	"strings"
 
 	"github.com/expanse-project/go-expanse/common"
-	"github.com/expanse-project/go-expanse/crypto/secp256k1"
 )
 
 type KeyPair struct {
@@ -33,13 +32,13 @@ type KeyPair struct {
 }
 
 func GenerateNewKeyPair() *KeyPair {
-	_, prv := secp256k1.GenerateKeyPair()
+	_, prv := generateKeyPair()
 	keyPair, _ := NewKeyPairFromSec(prv) // swallow error, this one cannot err
 	return keyPair
 }
 
 func NewKeyPairFromSec(seckey []byte) (*KeyPair, error) {
-	pubkey, err := secp256k1.GeneratePubKey(seckey)
+	pubkey, err := generatePubKey(seckey)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,41 @@
+// Copyright 2014 The go-ethereum Authors && Copyright 2015 go-expanse Authors
+// This file is part of the go-expanse library.
+//
+// The go-expanse library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-expanse library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-expanse library. If not, see <http://www.gnu.org/licenses/>.
+
+// +build !nocgo
+
+package crypto
+
+import "github.com/expanse-project/go-expanse/crypto/secp256k1"
+
+// The default signature implementation is backed by libsecp256k1.
+// Build with -tags nocgo to use the slower pure Go implementation
+// in signature_nocgo.go instead.
+
+func ecrecover(hash, sig []byte) ([]byte, error) {
+	return secp256k1.RecoverPubkey(hash, sig)
+}
+
+func sign(hash, seckey []byte) ([]byte, error) {
+	return secp256k1.Sign(hash, seckey)
+}
+
+func generateKeyPair() (pubkey, seckey []byte) {
+	return secp256k1.GenerateKeyPair()
+}
+
+func generatePubKey(seckey []byte) ([]byte, error) {
+	return secp256k1.GeneratePubKey(seckey)
+}
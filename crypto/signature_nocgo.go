@@ -0,0 +1,191 @@
+// Copyright 2014 The go-ethereum Authors && Copyright 2015 go-expanse Authors
+// This file is part of the go-expanse library.
+//
+// The go-expanse library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-expanse library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-expanse library. If not, see <http://www.gnu.org/licenses/>.
+
+// +build nocgo
+
+package crypto
+
+import (
+	"crypto/elliptic"
+	"errors"
+	"math/big"
+
+	"github.com/expanse-project/go-expanse/common"
+	"github.com/expanse-project/go-expanse/crypto/randentropy"
+)
+
+// This file contains a pure Go implementation of recoverable ECDSA
+// signatures over secp256k1. It is selected with -tags nocgo and allows
+// cross compiling without a C toolchain. It is considerably slower than
+// libsecp256k1 and does not run in constant time.
+
+var (
+	errInvalidMsgLen       = errors.New("invalid message length for signature recovery")
+	errInvalidSignatureLen = errors.New("invalid signature length")
+	errInvalidRecoveryID   = errors.New("invalid signature recovery id")
+	errInvalidSignature    = errors.New("invalid signature values")
+	errInvalidSeckey       = errors.New("invalid seckey")
+)
+
+func ecrecover(hash, sig []byte) ([]byte, error) {
+	if len(hash) != 32 {
+		return nil, errInvalidMsgLen
+	}
+	if len(sig) != 65 {
+		return nil, errInvalidSignatureLen
+	}
+	recid := sig[64]
+	if recid >= 4 {
+		return nil, errInvalidRecoveryID
+	}
+	curve := S256()
+	r := new(big.Int).SetBytes(sig[:32])
+	s := new(big.Int).SetBytes(sig[32:64])
+	if r.Sign() == 0 || s.Sign() == 0 || r.Cmp(curve.N) >= 0 || s.Cmp(curve.N) >= 0 {
+		return nil, errInvalidSignature
+	}
+
+	// Reconstruct the nonce point R from its x coordinate.
+	rx := new(big.Int).Set(r)
+	if recid&2 != 0 {
+		rx.Add(rx, curve.N)
+		if rx.Cmp(curve.P) >= 0 {
+			return nil, errInvalidSignature
+		}
+	}
+	ry, err := decompressY(rx, recid&1 == 1)
+	if err != nil {
+		return nil, err
+	}
+
+	// Q = r^-1 * (s*R - e*G)
+	rinv := new(big.Int).ModInverse(r, curve.N)
+	u1 := new(big.Int).SetBytes(hash)
+	u1.Neg(u1)
+	u1.Mul(u1, rinv)
+	u1.Mod(u1, curve.N)
+	u2 := new(big.Int).Mul(s, rinv)
+	u2.Mod(u2, curve.N)
+
+	qx, qy := curve.ScalarMult(rx, ry, u2.Bytes())
+	if u1.Sign() != 0 {
+		gx, gy := curve.ScalarBaseMult(u1.Bytes())
+		switch {
+		case gx.Cmp(qx) != 0:
+			qx, qy = curve.Add(qx, qy, gx, gy)
+		case gy.Cmp(qy) == 0:
+			qx, qy = curve.Double(qx, qy)
+		default:
+			return nil, errors.New("Failed to recover public key")
+		}
+	}
+	return elliptic.Marshal(curve, qx, qy), nil
+}
+
+// decompressY computes the y coordinate of the curve point with the given x
+// coordinate and parity.
+func decompressY(x *big.Int, odd bool) (*big.Int, error) {
+	curve := S256()
+	// y^2 = x^3 + 7
+	c := new(big.Int).Mul(x, x)
+	c.Mul(c, x)
+	c.Add(c, curve.B)
+	c.Mod(c, curve.P)
+	// P = 3 mod 4, so the square root is c^((P+1)/4).
+	e := new(big.Int).Add(curve.P, common.Big1)
+	e.Rsh(e, 2)
+	y := new(big.Int).Exp(c, e, curve.P)
+	if new(big.Int).Exp(y, common.Big2, curve.P).Cmp(c) != 0 {
+		return nil, errInvalidSignature
+	}
+	if (y.Bit(0) == 1) != odd {
+		y.Sub(curve.P, y)
+	}
+	return y, nil
+}
+
+func sign(hash, seckey []byte) ([]byte, error) {
+	if len(hash) != 32 {
+		return nil, errInvalidMsgLen
+	}
+	d, err := parseSeckey(seckey)
+	if err != nil {
+		return nil, errors.New("Invalid secret key")
+	}
+	curve := S256()
+	e := new(big.Int).SetBytes(hash)
+	for {
+		k := new(big.Int).SetBytes(randentropy.GetEntropyCSPRNG(32))
+		if k.Sign() == 0 || k.Cmp(curve.N) >= 0 {
+			continue
+		}
+		rx, ry := curve.ScalarBaseMult(k.Bytes())
+		r := new(big.Int).Mod(rx, curve.N)
+		if r.Sign() == 0 {
+			continue
+		}
+		recid := byte(ry.Bit(0))
+		if rx.Cmp(curve.N) >= 0 {
+			recid |= 2
+		}
+		// s = k^-1 * (e + r*d)
+		s := new(big.Int).Mul(r, d)
+		s.Add(s, e)
+		s.Mul(s, new(big.Int).ModInverse(k, curve.N))
+		s.Mod(s, curve.N)
+		if s.Sign() == 0 {
+			continue
+		}
+		// Only produce low-S signatures, like libsecp256k1 does.
+		if s.Cmp(secp256k1halfN) > 0 {
+			s.Sub(curve.N, s)
+			recid ^= 1
+		}
+		sig := make([]byte, 65)
+		copy(sig[32-len(r.Bytes()):32], r.Bytes())
+		copy(sig[64-len(s.Bytes()):64], s.Bytes())
+		sig[64] = recid
+		return sig, nil
+	}
+}
+
+func generateKeyPair() (pubkey, seckey []byte) {
+	for {
+		seckey = randentropy.GetEntropyCSPRNG(32)
+		if pubkey, err := generatePubKey(seckey); err == nil {
+			return pubkey, seckey
+		}
+	}
+}
+
+func generatePubKey(seckey []byte) ([]byte, error) {
+	if _, err := parseSeckey(seckey); err != nil {
+		return nil, err
+	}
+	x, y := S256().ScalarBaseMult(seckey)
+	return elliptic.Marshal(S256(), x, y), nil
+}
+
+func parseSeckey(seckey []byte) (*big.Int, error) {
+	if len(seckey) != 32 {
+		return nil, errors.New("priv key is not 32 bytes")
+	}
+	d := new(big.Int).SetBytes(seckey)
+	if d.Sign() == 0 || d.Cmp(S256().N) >= 0 {
+		return nil, errInvalidSeckey
+	}
+	return d, nil
+}
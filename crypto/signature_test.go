@@ -0,0 +1,83 @@
+// Copyright 2014 The go-ethereum Authors && Copyright 2015 go-expanse Authors
+// This file is part of the go-expanse library.
+//
+// The go-expanse library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-expanse library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-expanse library. If not, see <http://www.gnu.org/licenses/>.
+
+package crypto
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/expanse-project/go-expanse/common"
+)
+
+// These tests run against whichever signature implementation is selected
+// by build tags. Run them with -tags nocgo to check the pure Go version.
+
+var (
+	testmsg    = Sha3([]byte("foo"))
+	testsig    = common.FromHex("7b89ae1220ab5d8a27776213e3c4ce945d1222ff8567f1d4552091265ef8c42e08d0e6cb2b4bfca51e9b2d6a1d75029b97d65de97f253f6092adad20c42e38b600")
+	testpubkey = common.FromHex("047db227d7094ce215c3a0f57e1bcc732551fe351f94249471934567e0f5dc1bf795962b8cccb87a2eb56b29fbe37d614e2f4c3c45b789ae4f1f51f4cb21972ffd")
+)
+
+func TestEcrecoverVector(t *testing.T) {
+	pubkey, err := Ecrecover(testmsg, testsig)
+	if err != nil {
+		t.Fatalf("recover error: %s", err)
+	}
+	if !bytes.Equal(pubkey, testpubkey) {
+		t.Errorf("pubkey mismatch: want: %x have: %x", testpubkey, pubkey)
+	}
+}
+
+func TestEcrecoverInvalid(t *testing.T) {
+	tests := []struct {
+		msg, sig []byte
+	}{
+		{testmsg[:31], testsig},
+		{testmsg, testsig[:64]},
+		{testmsg, append(common.CopyBytes(testsig[:64]), 4)},
+	}
+	for i, test := range tests {
+		if _, err := Ecrecover(test.msg, test.sig); err == nil {
+			t.Errorf("test %d: expected error", i)
+		}
+	}
+}
+
+func TestSignRecoverRoundTrip(t *testing.T) {
+	for i := 0; i < 20; i++ {
+		key, err := GenerateKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		msg := Sha3([]byte{byte(i)})
+		sig, err := Sign(msg, key)
+		if err != nil {
+			t.Fatalf("sign error: %s", err)
+		}
+		r, s := common.BytesToBig(sig[:32]), common.BytesToBig(sig[32:64])
+		if !ValidateSignatureValues(sig[64]+27, r, s, true) {
+			t.Errorf("signature %x is not valid", sig)
+		}
+		pubkey, err := Ecrecover(msg, sig)
+		if err != nil {
+			t.Fatalf("recover error: %s", err)
+		}
+		if want := FromECDSAPub(&key.PublicKey); !bytes.Equal(pubkey, want) {
+			t.Errorf("pubkey mismatch: want: %x have: %x", want, pubkey)
+		}
+	}
+}
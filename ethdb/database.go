@@ -30,6 +30,7 @@ import (
 	"github.com/syndtr/goleveldb/leveldb/errors"
 	"github.com/syndtr/goleveldb/leveldb/iterator"
 	"github.com/syndtr/goleveldb/leveldb/opt"
+	"github.com/syndtr/goleveldb/leveldb/util"
 
 	gometrics "github.com/rcrowley/go-metrics"
 )
@@ -140,6 +141,16 @@ func (self *LDBDatabase) NewIterator() iterator.Iterator {
 	return self.db.NewIterator(nil, nil)
 }
 
+// PrefixSize returns the approximate disk usage of the entries whose keys start
+// with prefix. Recently written entries may not be accounted for yet.
+func (self *LDBDatabase) PrefixSize(prefix []byte) (uint64, error) {
+	sizes, err := self.db.SizeOf([]util.Range{*util.BytesPrefix(prefix)})
+	if err != nil {
+		return 0, err
+	}
+	return sizes.Sum(), nil
+}
+
 func (self *LDBDatabase) Close() {
 	// Stop the metrics collection to avoid internal database races
 	self.quitLock.Lock()
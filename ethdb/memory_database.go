@@ -19,22 +19,101 @@ package ethdb
 import (
 	"errors"
 	"fmt"
+	"strings"
 	"sync"
 
 	"github.com/expanse-project/go-expanse/common"
 )
 
+// maxMemLayers is the number of frozen layers a memory database may stack up
+// through snapshots before they are flattened into a single one to keep lookups
+// cheap.
+const maxMemLayers = 16
+
+// memLayer is a single level of a copy-on-write memory database. Only the top
+// layer of a database is ever modified, all parents are frozen and may be shared
+// between snapshots and forked databases.
+type memLayer struct {
+	parent  *memLayer
+	depth   int
+	data    map[string][]byte
+	deleted map[string]struct{} // keys removed from the parent layers
+}
+
+func newMemLayer(parent *memLayer) *memLayer {
+	l := &memLayer{
+		parent:  parent,
+		data:    make(map[string][]byte),
+		deleted: make(map[string]struct{}),
+	}
+	if parent != nil {
+		l.depth = parent.depth + 1
+	}
+	return l
+}
+
+// get looks up a key starting from this layer and descending into its parents.
+func (l *memLayer) get(key string) ([]byte, bool) {
+	for ; l != nil; l = l.parent {
+		if entry, ok := l.data[key]; ok {
+			return entry, true
+		}
+		if _, ok := l.deleted[key]; ok {
+			return nil, false
+		}
+	}
+	return nil, false
+}
+
+// put sets a key in this layer, overriding any deletion marker.
+func (l *memLayer) put(key string, value []byte) {
+	l.data[key] = value
+	delete(l.deleted, key)
+}
+
+// remove deletes a key from this layer and masks it in the parents.
+func (l *memLayer) remove(key string) {
+	delete(l.data, key)
+	if l.parent != nil {
+		l.deleted[key] = struct{}{}
+	}
+}
+
+// flatten returns all live entries visible from this layer.
+func (l *memLayer) flatten() map[string][]byte {
+	if l.parent == nil {
+		return l.data
+	}
+	entries := make(map[string][]byte)
+	for key, val := range l.parent.flatten() {
+		entries[key] = val
+	}
+	for key := range l.deleted {
+		delete(entries, key)
+	}
+	for key, val := range l.data {
+		entries[key] = val
+	}
+	return entries
+}
+
 /*
  * This is a test memory database. Do not use for any production it does not get persisted
  */
 type MemDatabase struct {
-	db   map[string][]byte
+	db   *memLayer
 	lock sync.RWMutex
 }
 
+// MemSnapshot is an immutable point-in-time view of a memory database, which can
+// be used to restore the database to or to fork new databases from.
+type MemSnapshot struct {
+	layer *memLayer
+}
+
 func NewMemDatabase() (*MemDatabase, error) {
 	return &MemDatabase{
-		db: make(map[string][]byte),
+		db: newMemLayer(nil),
 	}, nil
 }
 
@@ -42,14 +121,11 @@ func (db *MemDatabase) Put(key []byte, value []byte) error {
 	db.lock.Lock()
 	defer db.lock.Unlock()
 
-	db.db[string(key)] = common.CopyBytes(value)
+	db.db.put(string(key), common.CopyBytes(value))
 	return nil
 }
 
 func (db *MemDatabase) Set(key []byte, value []byte) {
-	db.lock.Lock()
-	defer db.lock.Unlock()
-
 	db.Put(key, value)
 }
 
@@ -57,7 +133,7 @@ func (db *MemDatabase) Get(key []byte) ([]byte, error) {
 	db.lock.RLock()
 	defer db.lock.RUnlock()
 
-	if entry, ok := db.db[string(key)]; ok {
+	if entry, ok := db.db.get(string(key)); ok {
 		return entry, nil
 	}
 	return nil, errors.New("not found")
@@ -68,12 +144,63 @@ func (db *MemDatabase) Keys() [][]byte {
 	defer db.lock.RUnlock()
 
 	keys := [][]byte{}
-	for key, _ := range db.db {
+	for key, _ := range db.db.flatten() {
 		keys = append(keys, []byte(key))
 	}
 	return keys
 }
 
+// PrefixSize returns the total size of the keys and values of the entries
+// whose keys start with prefix.
+func (db *MemDatabase) PrefixSize(prefix []byte) (uint64, error) {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+
+	var size uint64
+	for key, value := range db.db.flatten() {
+		if strings.HasPrefix(key, string(prefix)) {
+			size += uint64(len(key) + len(value))
+		}
+	}
+	return size, nil
+}
+
+// Snapshot freezes the current contents of the database and returns a handle
+// to them. Taking a snapshot does not copy any data: subsequent writes go into
+// a fresh layer on top of the frozen one.
+func (db *MemDatabase) Snapshot() *MemSnapshot {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	frozen := db.db
+	if frozen.depth >= maxMemLayers {
+		frozen = &memLayer{data: frozen.flatten(), deleted: make(map[string]struct{})}
+	}
+	db.db = newMemLayer(frozen)
+	return &MemSnapshot{layer: frozen}
+}
+
+// Restore reverts the database to the contents it had when snap was taken,
+// discarding all modifications made since.
+func (db *MemDatabase) Restore(snap *MemSnapshot) {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	db.db = newMemLayer(snap.layer)
+}
+
+// Fork creates a new, independent memory database sharing the current contents
+// of db. Modifications made to either database are not visible in the other.
+func (db *MemDatabase) Fork() *MemDatabase {
+	return db.Snapshot().Database()
+}
+
+// Database creates a new memory database initialised with the contents of the
+// snapshot.
+func (snap *MemSnapshot) Database() *MemDatabase {
+	return &MemDatabase{db: newMemLayer(snap.layer)}
+}
+
 /*
 func (db *MemDatabase) GetKeys() []*common.Key {
 	data, _ := db.Get([]byte("KeyRing"))
@@ -86,7 +213,7 @@ func (db *MemDatabase) Delete(key []byte) error {
 	db.lock.Lock()
 	defer db.lock.Unlock()
 
-	delete(db.db, string(key))
+	db.db.remove(string(key))
 	return nil
 }
 
@@ -94,7 +221,7 @@ func (db *MemDatabase) Print() {
 	db.lock.RLock()
 	defer db.lock.RUnlock()
 
-	for key, val := range db.db {
+	for key, val := range db.db.flatten() {
 		fmt.Printf("%x(%d): ", key, len(key))
 		node := common.NewValueFromBytes(val)
 		fmt.Printf("%q\n", node.Val)
@@ -140,7 +267,7 @@ func (b *memBatch) Write() error {
 	defer b.db.lock.Unlock()
 
 	for _, kv := range b.writes {
-		b.db.db[string(kv.k)] = kv.v
+		b.db.db.put(string(kv.k), kv.v)
 	}
 	return nil
 }
@@ -0,0 +1,105 @@
+// Copyright 2016 The go-expanse Authors
+// This file is part of the go-expanse library.
+//
+// The go-expanse library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-expanse library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-expanse library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethdb
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+func TestMemDatabaseSnapshotRestore(t *testing.T) {
+	db, _ := NewMemDatabase()
+	db.Put([]byte("a"), []byte("1"))
+	db.Put([]byte("b"), []byte("2"))
+
+	snap := db.Snapshot()
+	db.Put([]byte("a"), []byte("3"))
+	db.Delete([]byte("b"))
+	db.Put([]byte("c"), []byte("4"))
+
+	if val, _ := db.Get([]byte("a")); !bytes.Equal(val, []byte("3")) {
+		t.Errorf("a mismatch after snapshot: have %q, want %q", val, "3")
+	}
+	if _, err := db.Get([]byte("b")); err == nil {
+		t.Errorf("b still present after deletion")
+	}
+	if len(db.Keys()) != 2 {
+		t.Errorf("key count mismatch: have %d, want %d", len(db.Keys()), 2)
+	}
+
+	db.Restore(snap)
+	for key, want := range map[string]string{"a": "1", "b": "2"} {
+		if val, err := db.Get([]byte(key)); err != nil || !bytes.Equal(val, []byte(want)) {
+			t.Errorf("%s mismatch after restore: have %q (%v), want %q", key, val, err, want)
+		}
+	}
+	if _, err := db.Get([]byte("c")); err == nil {
+		t.Errorf("c present after restore")
+	}
+}
+
+func TestMemDatabaseFork(t *testing.T) {
+	db, _ := NewMemDatabase()
+	db.Put([]byte("a"), []byte("1"))
+
+	fork := db.Fork()
+	fork.Put([]byte("a"), []byte("2"))
+	db.Put([]byte("b"), []byte("3"))
+
+	batch := fork.NewBatch()
+	batch.Put([]byte("c"), []byte("4"))
+	batch.Write()
+
+	if val, _ := db.Get([]byte("a")); !bytes.Equal(val, []byte("1")) {
+		t.Errorf("fork write leaked into origin: have %q, want %q", val, "1")
+	}
+	if _, err := fork.Get([]byte("b")); err == nil {
+		t.Errorf("origin write leaked into fork")
+	}
+	if _, err := db.Get([]byte("c")); err == nil {
+		t.Errorf("fork batch leaked into origin")
+	}
+	if val, _ := fork.Get([]byte("c")); !bytes.Equal(val, []byte("4")) {
+		t.Errorf("batch write mismatch: have %q, want %q", val, "4")
+	}
+}
+
+func TestMemDatabaseSnapshotFlatten(t *testing.T) {
+	db, _ := NewMemDatabase()
+
+	snaps := make([]*MemSnapshot, 0, 2*maxMemLayers)
+	for i := 0; i < 2*maxMemLayers; i++ {
+		db.Put([]byte(fmt.Sprintf("key%d", i)), []byte{byte(i)})
+		if i > 0 {
+			db.Delete([]byte(fmt.Sprintf("key%d", i-1)))
+		}
+		snaps = append(snaps, db.Snapshot())
+	}
+	if db.db.depth > maxMemLayers+1 {
+		t.Errorf("layers not flattened: depth %d", db.db.depth)
+	}
+	for i, snap := range snaps {
+		view := snap.Database()
+		if keys := view.Keys(); len(keys) != 1 {
+			t.Errorf("snapshot %d: key count mismatch: have %d, want 1", i, len(keys))
+		}
+		if val, err := view.Get([]byte(fmt.Sprintf("key%d", i))); err != nil || !bytes.Equal(val, []byte{byte(i)}) {
+			t.Errorf("snapshot %d: value mismatch: have %x (%v)", i, val, err)
+		}
+	}
+}
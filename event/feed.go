@@ -0,0 +1,188 @@
+// Copyright 2016 The go-expanse Authors
+// This file is part of the go-expanse library.
+//
+// The go-expanse library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-expanse library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-expanse library. If not, see <http://www.gnu.org/licenses/>.
+
+package event
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+)
+
+// feedBufferSize is the number of values buffered for each subscriber.
+const feedBufferSize = 256
+
+var (
+	// ErrFeedOverflow is sent on the error channel of a subscription that
+	// was dropped because its receiver couldn't keep up with the feed.
+	ErrFeedOverflow = errors.New("event: subscriber too slow, feed buffer overflow")
+
+	errBadChannel = errors.New("event: Subscribe argument does not have sendable channel type")
+)
+
+// FeedSubscription represents a stream of values delivered by a Feed.
+type FeedSubscription interface {
+	// Err returns a channel which receives at most one error and is
+	// closed when the subscription ends, either because Unsubscribe
+	// was called or because the subscriber fell too far behind.
+	Err() <-chan error
+
+	// Unsubscribe stops delivery of values. The subscribed channel
+	// is not closed. Unsubscribe can be called more than once.
+	Unsubscribe()
+}
+
+// Feed implements one-to-many subscriptions where the carrier of events
+// is a channel of a single, fixed type. Values sent to a Feed are
+// delivered to all subscribed channels.
+//
+// Unlike TypeMux, Send never blocks: every subscriber has its own buffer
+// and a subscriber whose buffer fills up is dropped with ErrFeedOverflow
+// instead of stalling the sender and all other subscribers.
+//
+// The zero value is ready to use. The element type is fixed by the first
+// call to Send or Subscribe, later calls with another type panic.
+type Feed struct {
+	mu    sync.Mutex
+	etype reflect.Type
+	subs  map[*feedSub]struct{}
+}
+
+// feedTypeError is the panic value for type mismatches.
+type feedTypeError struct {
+	got, want reflect.Type
+	op        string
+}
+
+func (e feedTypeError) Error() string {
+	return "event: wrong type in " + e.op + " got " + e.got.String() + ", want " + e.want.String()
+}
+
+// Subscribe adds a channel to the feed. Future sends will be delivered on
+// the channel until the subscription is cancelled. The channel must have
+// the feed's element type and must be sendable.
+func (f *Feed) Subscribe(channel interface{}) FeedSubscription {
+	chanval := reflect.ValueOf(channel)
+	chantyp := chanval.Type()
+	if chantyp.Kind() != reflect.Chan || chantyp.ChanDir()&reflect.SendDir == 0 {
+		panic(errBadChannel)
+	}
+	sub := &feedSub{
+		feed:    f,
+		channel: chanval,
+		queue:   make(chan reflect.Value, feedBufferSize),
+		quit:    make(chan struct{}),
+		err:     make(chan error, 1),
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !f.typecheck(chantyp.Elem()) {
+		panic(feedTypeError{op: "Subscribe", got: chantyp, want: reflect.ChanOf(reflect.SendDir, f.etype)})
+	}
+	if f.subs == nil {
+		f.subs = make(map[*feedSub]struct{})
+	}
+	f.subs[sub] = struct{}{}
+	go sub.loop()
+	return sub
+}
+
+// Send queues value for delivery to all subscribed channels and returns
+// the number of subscribers it was queued for. It does not wait for the
+// subscribers to receive the value.
+func (f *Feed) Send(value interface{}) (nsent int) {
+	rvalue := reflect.ValueOf(value)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !f.typecheck(rvalue.Type()) {
+		panic(feedTypeError{op: "Send", got: rvalue.Type(), want: f.etype})
+	}
+	for sub := range f.subs {
+		select {
+		case sub.queue <- rvalue:
+			nsent++
+		default:
+			delete(f.subs, sub)
+			sub.close(ErrFeedOverflow)
+		}
+	}
+	return nsent
+}
+
+// typecheck sets the element type of the feed if it isn't set yet and
+// reports whether typ matches it. The caller must hold f.mu.
+func (f *Feed) typecheck(typ reflect.Type) bool {
+	if f.etype == nil {
+		f.etype = typ
+		return true
+	}
+	return f.etype == typ
+}
+
+func (f *Feed) remove(sub *feedSub) {
+	f.mu.Lock()
+	delete(f.subs, sub)
+	f.mu.Unlock()
+}
+
+type feedSub struct {
+	feed    *Feed
+	channel reflect.Value
+	queue   chan reflect.Value
+	quit    chan struct{}
+	err     chan error
+	once    sync.Once
+}
+
+func (sub *feedSub) Err() <-chan error {
+	return sub.err
+}
+
+func (sub *feedSub) Unsubscribe() {
+	sub.feed.remove(sub)
+	sub.close(nil)
+}
+
+func (sub *feedSub) close(err error) {
+	sub.once.Do(func() {
+		if err != nil {
+			sub.err <- err
+		}
+		close(sub.err)
+		close(sub.quit)
+	})
+}
+
+// loop forwards queued values to the subscribed channel.
+func (sub *feedSub) loop() {
+	cases := []reflect.SelectCase{
+		{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(sub.quit)},
+		{Dir: reflect.SelectSend, Chan: sub.channel},
+	}
+	for {
+		select {
+		case v := <-sub.queue:
+			cases[1].Send = v
+			if chosen, _, _ := reflect.Select(cases); chosen == 0 {
+				return
+			}
+		case <-sub.quit:
+			return
+		}
+	}
+}
@@ -0,0 +1,114 @@
+// Copyright 2016 The go-expanse Authors
+// This file is part of the go-expanse library.
+//
+// The go-expanse library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-expanse library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-expanse library. If not, see <http://www.gnu.org/licenses/>.
+
+package event
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestFeed(t *testing.T) {
+	var feed Feed
+	var done, subscribed sync.WaitGroup
+	subscriber := func(i int) {
+		defer done.Done()
+
+		ch := make(chan int)
+		sub := feed.Subscribe(ch)
+		timeout := time.NewTimer(2 * time.Second)
+		subscribed.Done()
+
+		select {
+		case v := <-ch:
+			if v != 1 {
+				t.Errorf("%d: received value %d, want 1", i, v)
+			}
+		case <-timeout.C:
+			t.Errorf("%d: receive timeout", i)
+		}
+		sub.Unsubscribe()
+		select {
+		case _, ok := <-sub.Err():
+			if ok {
+				t.Errorf("%d: error channel not closed after unsubscribe", i)
+			}
+		case <-timeout.C:
+			t.Errorf("%d: unsubscribe timeout", i)
+		}
+	}
+
+	const n = 100
+	subscribed.Add(n)
+	done.Add(n)
+	for i := 0; i < n; i++ {
+		go subscriber(i)
+	}
+	subscribed.Wait()
+	if nsent := feed.Send(1); nsent != n {
+		t.Errorf("first send delivered %d times, want %d", nsent, n)
+	}
+	done.Wait()
+	if nsent := feed.Send(2); nsent != 0 {
+		t.Errorf("second send delivered %d times, want 0", nsent)
+	}
+}
+
+func TestFeedSlowSubscriber(t *testing.T) {
+	var feed Feed
+	fast, slow := make(chan int), make(chan int)
+	fastSub, slowSub := feed.Subscribe(fast), feed.Subscribe(slow)
+	defer fastSub.Unsubscribe()
+
+	// Only the fast subscriber reads. Sending must not block and the
+	// slow subscriber must be dropped once its buffer is full.
+	for i := 0; i < feedBufferSize+2; i++ {
+		feed.Send(i)
+		select {
+		case v := <-fast:
+			if v != i {
+				t.Fatalf("fast subscriber received %d, want %d", v, i)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("fast subscriber receive timeout at %d", i)
+		}
+	}
+	select {
+	case err := <-slowSub.Err():
+		if err != ErrFeedOverflow {
+			t.Errorf("wrong error for slow subscriber: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("slow subscriber wasn't dropped")
+	}
+}
+
+func TestFeedTypeMismatch(t *testing.T) {
+	var feed Feed
+	feed.Send(1)
+
+	checkPanic := func(op string, f func()) {
+		defer func() {
+			if _, ok := recover().(feedTypeError); !ok {
+				t.Errorf("%s with wrong type did not panic with feedTypeError", op)
+			}
+		}()
+		f()
+	}
+	checkPanic("Send", func() { feed.Send("foo") })
+	checkPanic("Subscribe", func() { feed.Subscribe(make(chan string)) })
+}
@@ -0,0 +1,132 @@
+// Copyright 2016 The go-expanse Authors
+// This file is part of the go-expanse library.
+//
+// The go-expanse library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-expanse library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-expanse library. If not, see <http://www.gnu.org/licenses/>.
+
+package exp
+
+import (
+	"math/big"
+
+	"github.com/expanse-project/go-expanse/common"
+	"github.com/expanse-project/go-expanse/core"
+	"github.com/expanse-project/go-expanse/core/state"
+	"github.com/expanse-project/go-expanse/core/types"
+	"github.com/expanse-project/go-expanse/core/vm"
+	"github.com/expanse-project/go-expanse/event"
+	"github.com/expanse-project/go-expanse/miner"
+)
+
+// DefaultTxGas is the gas of transactions sent without one, unless configured
+// otherwise.
+var DefaultTxGas = big.NewInt(90000)
+
+// ApiBackend implements the backend of the RPC APIs (xeth.Backend) on top of a
+// full node.
+type ApiBackend struct {
+	*Expanse
+	gpo *GasPriceOracle
+}
+
+// ApiBackend returns a new API backend with its own gas price oracle.
+func (s *Expanse) ApiBackend() *ApiBackend {
+	return &ApiBackend{
+		Expanse: s,
+		gpo:     NewGasPriceOracle(s),
+	}
+}
+
+func (b *ApiBackend) CurrentBlock() *types.Block             { return b.blockchain.CurrentBlock() }
+func (b *ApiBackend) GetBlock(hash common.Hash) *types.Block { return b.blockchain.GetBlock(hash) }
+func (b *ApiBackend) GetBlockByNumber(number uint64) *types.Block {
+	return b.blockchain.GetBlockByNumber(number)
+}
+func (b *ApiBackend) GetHeader(hash common.Hash) *types.Header { return b.blockchain.GetHeader(hash) }
+func (b *ApiBackend) GetTd(hash common.Hash) *big.Int          { return b.blockchain.GetTd(hash) }
+func (b *ApiBackend) GetBlockReceipts(hash common.Hash) types.Receipts {
+	return b.blockchain.GetBlockReceipts(hash)
+}
+func (b *ApiBackend) GasLimit() *big.Int { return b.blockchain.GasLimit() }
+
+func (b *ApiBackend) SubscribeChainEvent(ch chan<- core.ChainEvent) event.FeedSubscription {
+	return b.blockchain.SubscribeChainEvent(ch)
+}
+func (b *ApiBackend) SubscribeChainHeadEvent(ch chan<- core.ChainHeadEvent) event.FeedSubscription {
+	return b.blockchain.SubscribeChainHeadEvent(ch)
+}
+func (b *ApiBackend) SubscribeLogsEvent(ch chan<- vm.Logs) event.FeedSubscription {
+	return b.blockchain.SubscribeLogsEvent(ch)
+}
+func (b *ApiBackend) SubscribeRemovedLogsEvent(ch chan<- core.RemovedLogsEvent) event.FeedSubscription {
+	return b.blockchain.SubscribeRemovedLogsEvent(ch)
+}
+
+func (b *ApiBackend) PendingBlock() *types.Block   { return b.miner.PendingBlock() }
+func (b *ApiBackend) PendingState() *state.StateDB { return b.miner.PendingState() }
+func (b *ApiBackend) Pending() (*types.Block, *state.StateDB) {
+	return b.miner.Pending()
+}
+
+func (b *ApiBackend) SendTx(tx *types.Transaction, origin core.TxOrigin) error {
+	return b.txPool.AddFrom(tx, origin)
+}
+func (b *ApiBackend) ValidateTx(tx *types.Transaction) error {
+	return b.txPool.Validate(tx)
+}
+func (b *ApiBackend) GetPoolTransaction(hash common.Hash) *types.Transaction {
+	return b.txPool.GetTransaction(hash)
+}
+func (b *ApiBackend) PendingNonce(addr common.Address) uint64 { return b.txPool.Nonce(addr) }
+func (b *ApiBackend) ReserveNonce(addr common.Address) (uint64, error) {
+	return b.txPool.ReserveNonce(addr)
+}
+func (b *ApiBackend) ReleaseNonce(addr common.Address, nonce uint64) bool {
+	return b.txPool.ReleaseNonce(addr, nonce)
+}
+
+// SuggestPrice returns the gas price of transactions sent without one, which is
+// either configured for the node or suggested by the gas price oracle.
+func (b *ApiBackend) SuggestPrice() *big.Int {
+	if b.txGasPrice != nil {
+		return new(big.Int).Set(b.txGasPrice)
+	}
+	return b.gpo.SuggestPrice()
+}
+
+// DefaultGas returns the gas of transactions sent without one. It is bounded
+// by the configured cap and the current block gas limit.
+func (b *ApiBackend) DefaultGas() *big.Int {
+	gas := DefaultTxGas
+	if b.txGas != nil {
+		gas = b.txGas
+	}
+	if b.txGasCap != nil && gas.Cmp(b.txGasCap) > 0 {
+		gas = b.txGasCap
+	}
+	if limit := b.blockchain.GasLimit(); gas.Cmp(limit) > 0 {
+		gas = limit
+	}
+	return new(big.Int).Set(gas)
+}
+
+func (b *ApiBackend) SubscribeTxPreEvent(ch chan<- core.TxPreEvent) event.FeedSubscription {
+	return b.txPool.SubscribeTxPreEvent(ch)
+}
+func (b *ApiBackend) SubscribeTxDropEvent(ch chan<- core.TxDropEvent) event.FeedSubscription {
+	return b.txPool.SubscribeTxDropEvent(ch)
+}
+
+func (b *ApiBackend) HashRate() int64                   { return b.miner.HashRate() }
+func (b *ApiBackend) RegisterAgent(agent miner.Agent)   { b.miner.Register(agent) }
+func (b *ApiBackend) UnregisterAgent(agent miner.Agent) { b.miner.Unregister(agent) }
@@ -0,0 +1,70 @@
+// Copyright 2016 The go-expanse Authors
+// This file is part of the go-expanse library.
+//
+// The go-expanse library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-expanse library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-expanse library. If not, see <http://www.gnu.org/licenses/>.
+
+package exp
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/expanse-project/go-expanse/core"
+	"github.com/expanse-project/go-expanse/ethdb"
+)
+
+func newTestApiBackend(t *testing.T) *ApiBackend {
+	db, _ := ethdb.NewMemDatabase()
+	core.WriteGenesisBlockForTesting(db)
+	blockchain, err := core.NewBlockChain(db, new(core.FakePow))
+	if err != nil {
+		t.Fatalf("failed to create blockchain: %v", err)
+	}
+	return &ApiBackend{Expanse: &Expanse{blockchain: blockchain}}
+}
+
+func TestApiBackendDefaultGas(t *testing.T) {
+	b := newTestApiBackend(t)
+	limit := b.blockchain.GasLimit()
+
+	if gas := b.DefaultGas(); gas.Cmp(DefaultTxGas) != 0 {
+		t.Errorf("unconfigured gas mismatch: have %v, want %v", gas, DefaultTxGas)
+	}
+	b.txGas = big.NewInt(200000)
+	if gas := b.DefaultGas(); gas.Cmp(b.txGas) != 0 {
+		t.Errorf("configured gas mismatch: have %v, want %v", gas, b.txGas)
+	}
+	b.txGasCap = big.NewInt(150000)
+	if gas := b.DefaultGas(); gas.Cmp(b.txGasCap) != 0 {
+		t.Errorf("capped gas mismatch: have %v, want %v", gas, b.txGasCap)
+	}
+	b.txGas, b.txGasCap = new(big.Int).Add(limit, big.NewInt(1)), nil
+	if gas := b.DefaultGas(); gas.Cmp(limit) != 0 {
+		t.Errorf("block limited gas mismatch: have %v, want %v", gas, limit)
+	}
+	// Callers may modify the returned value
+	b.DefaultGas().SetInt64(0)
+	if b.txGas.Sign() == 0 {
+		t.Errorf("configured gas modified through returned value")
+	}
+}
+
+func TestApiBackendFixedGasPrice(t *testing.T) {
+	b := newTestApiBackend(t)
+	b.txGasPrice = big.NewInt(42)
+
+	if price := b.SuggestPrice(); price.Cmp(b.txGasPrice) != 0 {
+		t.Errorf("gas price mismatch: have %v, want %v", price, b.txGasPrice)
+	}
+}
@@ -28,11 +28,12 @@ import (
 	"path/filepath"
 	"regexp"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 	
-	"github.com/expanse-project/ethash"
 	"github.com/expanse-project/go-expanse/accounts"
+	"github.com/expanse-project/go-expanse/clique"
 	"github.com/expanse-project/go-expanse/common"
 	"github.com/expanse-project/go-expanse/common/compiler"
 	"github.com/expanse-project/go-expanse/common/httpclient"
@@ -42,6 +43,8 @@ import (
 	"github.com/expanse-project/go-expanse/core/vm"
 	"github.com/expanse-project/go-expanse/crypto"
 	"github.com/expanse-project/go-expanse/exp/downloader"
+	"github.com/expanse-project/go-expanse/exp/journal"
+	"github.com/expanse-project/go-expanse/exp/notify"
 	"github.com/expanse-project/go-expanse/ethdb"
 	"github.com/expanse-project/go-expanse/event"
 	"github.com/expanse-project/go-expanse/logger"
@@ -50,6 +53,8 @@ import (
 	"github.com/expanse-project/go-expanse/p2p"
 	"github.com/expanse-project/go-expanse/p2p/discover"
 	"github.com/expanse-project/go-expanse/p2p/nat"
+	"github.com/expanse-project/go-expanse/params"
+	"github.com/expanse-project/go-expanse/pow/ethash"
 	"github.com/expanse-project/go-expanse/rlp"
 	"github.com/expanse-project/go-expanse/whisper"
 )
@@ -97,6 +102,7 @@ type Config struct {
 	GenesisFile  string
 	GenesisBlock *types.Block // used by block tests
 	FastSync     bool
+	Checkpoint   *downloader.Checkpoint // trusted block the synced chain must contain
 	Olympic      bool
 
 	BlockChainVersion  int
@@ -109,8 +115,15 @@ type Config struct {
 	VmDebug   bool
 	NatSpec   bool
 	DocRoot   string
+	DocHosts  []string // remote hosts contract info may be fetched from
+	Webhooks  []notify.Hook
 	AutoDAG   bool
+
+	JournalPath  string // file or named pipe the chain progress is journaled to
+	JournalDepth uint64 // confirmations after which the journal marks blocks final
+
 	PowTest   bool
+	Clique    bool // seal by proof-of-authority with the settings of the genesis
 	ExtraData []byte
 
 	MaxPeers        int
@@ -118,6 +131,10 @@ type Config struct {
 	Discovery       bool
 	Port            string
 
+	HandshakeTimeout      time.Duration // encryption handshake deadline (0 = default)
+	ProtoHandshakeTimeout time.Duration // protocol handshake deadline (0 = default)
+	PeerReadTimeout       time.Duration // deadline for reading a message from a peer (0 = default)
+
 	// Space-separated list of discovery node URLs
 	BootNodes string
 
@@ -131,7 +148,12 @@ type Config struct {
 
 	Etherbase      common.Address
 	GasPrice       *big.Int
+	TxMaxSize      uint64   // maximum size of transactions accepted into the pool (0 = no limit)
+	TxGas          *big.Int // gas of transactions sent without one (nil = DefaultTxGas)
+	TxGasCap       *big.Int // upper bound of the default gas (nil = block gas limit only)
+	TxGasPrice     *big.Int // gas price of transactions sent without one (nil = gas price oracle)
 	MinerThreads   int
+	MinerPayouts   []miner.Payout // shares of mined block rewards paid out from the etherbase
 	AccountManager *accounts.Manager
 	SolcPath       string
 
@@ -239,6 +261,7 @@ type Expanse struct {
 	accountManager  *accounts.Manager
 	whisper         *whisper.Whisper
 	pow             *ethash.Ethash
+	clique          *clique.Clique
 	protocolManager *ProtocolManager
 	SolcPath        string
 	solc            *compiler.Solidity
@@ -250,11 +273,19 @@ type Expanse struct {
 	GpobaseStepUp           int
 	GpobaseCorrectionFactor int
 
+	txGas      *big.Int
+	txGasCap   *big.Int
+	txGasPrice *big.Int
+
 	httpclient *httpclient.HTTPClient
 
 	net      *p2p.Server
 	eventMux *event.TypeMux
 	miner    *miner.Miner
+	notifier *notify.Notifier
+	journal  *journal.Journal
+
+	staticLock sync.Mutex // Protects the static node list in the data directory
 
 	// logger logger.LogSystem
 
@@ -294,10 +325,7 @@ func New(config *Config) (*Expanse, error) {
 	if db, ok := chainDb.(*ethdb.LDBDatabase); ok {
 		db.Meter("eth/db/chaindata/")
 	}
-	if err := upgradeChainDatabase(chainDb); err != nil {
-		return nil, err
-	}
-	if err := addMipmapBloomBins(chainDb); err != nil {
+	if err := MigrateChainDatabase(chainDb); err != nil {
 		return nil, err
 	}
 
@@ -313,7 +341,6 @@ func New(config *Config) (*Expanse, error) {
 	}
 
 	nodeDb := filepath.Join(config.DataDir, "nodes")
-	glog.V(logger.Info).Infof("Protocol Versions: %v, Network Id: %v", ProtocolVersions, config.NetworkId)
 
 	if len(config.GenesisFile) > 0 {
 		fr, err := os.Open(config.GenesisFile)
@@ -352,6 +379,18 @@ func New(config *Config) (*Expanse, error) {
 		core.WriteCanonicalHash(chainDb, config.GenesisBlock.Hash(), config.GenesisBlock.NumberU64())
 		core.WriteHeadBlockHash(chainDb, config.GenesisBlock.Hash())
 	}
+	// Private chains may configure protocol parameters in their genesis.
+	chainConfig := core.GetChainConfig(chainDb, core.GetCanonicalHash(chainDb, 0))
+	if chainConfig != nil {
+		chainConfig.Apply()
+	}
+	// The network id is resolved once, the handshake and net_version both
+	// report this one.
+	networkId := chainConfig.Network(config.NetworkId)
+	if networkId != config.NetworkId {
+		glog.V(logger.Warn).Infof("Network id %d of the genesis overrides %d", networkId, config.NetworkId)
+	}
+	glog.V(logger.Info).Infof("Protocol Versions: %v, Network Id: %v, Chain Id: %v", ProtocolVersions, networkId, params.ChainId)
 
 	if !config.SkipBcVersionCheck {
 		b, _ := chainDb.Get([]byte("BlockchainVersion"))
@@ -372,7 +411,7 @@ func New(config *Config) (*Expanse, error) {
 		DataDir:                 config.DataDir,
 		etherbase:               config.Etherbase,
 		clientVersion:           config.Name, // TODO should separate from Name
-		netVersionId:            config.NetworkId,
+		netVersionId:            networkId,
 		NatSpec:                 config.NatSpec,
 		MinerThreads:            config.MinerThreads,
 		SolcPath:                config.SolcPath,
@@ -384,6 +423,9 @@ func New(config *Config) (*Expanse, error) {
 		GpobaseStepDown:         config.GpobaseStepDown,
 		GpobaseStepUp:           config.GpobaseStepUp,
 		GpobaseCorrectionFactor: config.GpobaseCorrectionFactor,
+		txGas:                   config.TxGas,
+		txGasCap:                config.TxGasCap,
+		txGasPrice:              config.TxGasPrice,
 		httpclient:              httpclient.New(config.DocRoot),
 	}
 
@@ -396,23 +438,49 @@ func New(config *Config) (*Expanse, error) {
 	} else {
 		exp.pow = ethash.New()
 	}
+	for _, host := range config.DocHosts {
+		exp.httpclient.AllowHost(host)
+	}
 	//genesis := core.GenesisBlock(uint64(config.GenesisNonce), stateDb)
-	exp.blockchain, err = core.NewBlockChain(chainDb, exp.pow, exp.EventMux())
+	if config.Clique {
+		exp.clique = clique.New(clique.GenesisConfig(chainDb), chainDb)
+		exp.blockchain, err = core.NewBlockChainWithEngine(chainDb, exp.clique)
+	} else {
+		exp.blockchain, err = core.NewBlockChain(chainDb, exp.pow)
+	}
 	if err != nil {
 		if err == core.ErrNoGenesis {
 			return nil, fmt.Errorf(`Genesis block not found. Please supply a genesis block with the "--genesis /path/to/file" argument`)
 		}
 		return nil, err
 	}
-	newPool := core.NewTxPool(exp.EventMux(), exp.blockchain.State, exp.blockchain.GasLimit)
+	exp.notifier = notify.New(chainDb, exp.blockchain)
+	for _, hook := range config.Webhooks {
+		if err := exp.notifier.Register(hook.URL, hook.Addresses); err != nil {
+			return nil, fmt.Errorf("webhook %s: %v", hook.URL, err)
+		}
+	}
+	if config.JournalPath != "" {
+		exp.journal = journal.New(config.JournalPath, chainDb, exp.blockchain, exp.blockchain.CurrentBlock().Header(), config.JournalDepth)
+	}
+	mined := func(hash common.Hash) bool {
+		tx, _, _, _ := core.GetTransaction(chainDb, hash)
+		return tx != nil
+	}
+	newPool := core.NewTxPool(exp.blockchain, exp.blockchain.CurrentBlock(), exp.blockchain.State, exp.blockchain.GasLimit, mined)
+	newPool.SetMaxTxSize(config.TxMaxSize)
 	exp.txPool = newPool
 
-	if exp.protocolManager, err = NewProtocolManager(config.FastSync, config.NetworkId, exp.eventMux, exp.txPool, exp.pow, exp.blockchain, chainDb); err != nil {
-		return nil, err
-	}
-	exp.miner = miner.New(exp, exp.EventMux(), exp.pow)
+	exp.miner = miner.New(exp, exp.EventMux(), exp.blockchain.Engine())
 	exp.miner.SetGasPrice(config.GasPrice)
 	exp.miner.SetExtra(config.ExtraData)
+	if err := exp.miner.SetPayouts(config.MinerPayouts); err != nil {
+		return nil, err
+	}
+	if exp.protocolManager, err = NewProtocolManager(config.FastSync, networkId, exp.eventMux, exp.txPool, exp.miner, exp.blockchain, chainDb); err != nil {
+		return nil, err
+	}
+	exp.protocolManager.downloader.SetCheckpoint(config.Checkpoint)
 
 	if config.Shh {
 		exp.whisper = whisper.New()
@@ -440,6 +508,10 @@ func New(config *Config) (*Expanse, error) {
 		StaticNodes:     config.parseNodes(staticNodes),
 		TrustedNodes:    config.parseNodes(trustedNodes),
 		NodeDatabase:    nodeDb,
+
+		HandshakeTimeout:         config.HandshakeTimeout,
+		ProtocolHandshakeTimeout: config.ProtoHandshakeTimeout,
+		ReadTimeout:              config.PeerReadTimeout,
 	}
 	if len(config.Port) > 0 {
 		exp.net.ListenAddr = ":" + config.Port
@@ -479,10 +551,25 @@ func (self *Expanse) SetEtherbase(etherbase common.Address) {
 }
 
 
+// authorizeSigner makes eb the signer of the proof-of-authority engine, if
+// enabled. Its account needs to be unlocked for sealing blocks.
+func (s *Expanse) authorizeSigner(eb common.Address) {
+	if s.clique == nil {
+		return
+	}
+	s.clique.Authorize(eb, func(signer common.Address, hash []byte) ([]byte, error) {
+		return s.accountManager.Sign(accounts.Account{Address: signer}, hash)
+	})
+}
+
 func (s *Expanse) StopMining()         { s.miner.Stop() }
 func (s *Expanse) IsMining() bool      { return s.miner.Mining() }
 func (s *Expanse) Miner() *miner.Miner { return s.miner }
 
+// Clique returns the proof-of-authority engine, nil if the chain is sealed by
+// proof-of-work.
+func (s *Expanse) Clique() *clique.Clique { return s.clique }
+
 // func (s *Expanse) Logger() logger.LogSystem             { return s.logger }
 func (s *Expanse) Name() string                       { return s.net.Name }
 func (s *Expanse) AccountManager() *accounts.Manager  { return s.accountManager }
@@ -501,6 +588,8 @@ func (s *Expanse) EthVersion() int                    { return int(s.protocolMan
 func (s *Expanse) NetVersion() int                    { return s.netVersionId }
 func (s *Expanse) ShhVersion() int                    { return s.shhVersionId }
 func (s *Expanse) Downloader() *downloader.Downloader { return s.protocolManager.downloader }
+func (s *Expanse) NetworkHeads() *NetworkHeads        { return s.protocolManager.NetworkHeads() }
+func (s *Expanse) Notifier() *notify.Notifier         { return s.notifier }
 
 // Start the ethereum
 func (s *Expanse) Start() error {
@@ -539,13 +628,66 @@ func (s *Expanse) StartForTest() {
 
 // AddPeer connects to the given node and maintains the connection until the
 // server is shut down. If the connection fails for any reason, the server will
-// attempt to reconnect the peer.
+// attempt to reconnect the peer. The node is added to the static node list in
+// the data directory too, so the connection is restored after a restart.
 func (self *Expanse) AddPeer(nodeURL string) error {
 	n, err := discover.ParseNode(nodeURL)
 	if err != nil {
 		return fmt.Errorf("invalid node URL: %v", err)
 	}
 	self.net.AddPeer(n)
+	return self.updateStaticNodes(n, true)
+}
+
+// RemovePeer disconnects from the given node, stops reconnecting to it and
+// removes it from the static node list in the data directory.
+func (self *Expanse) RemovePeer(nodeURL string) error {
+	n, err := discover.ParseNode(nodeURL)
+	if err != nil {
+		return fmt.Errorf("invalid node URL: %v", err)
+	}
+	self.net.RemovePeer(n)
+	return self.updateStaticNodes(n, false)
+}
+
+// updateStaticNodes adds node to or removes it from the static node list in the
+// data directory. Entries for the same node ID are replaced, other entries are
+// left as they are.
+func (self *Expanse) updateStaticNodes(node *discover.Node, add bool) error {
+	if self.DataDir == "" {
+		return nil
+	}
+	self.staticLock.Lock()
+	defer self.staticLock.Unlock()
+
+	path := filepath.Join(self.DataDir, staticNodes)
+	var urls []string
+	if blob, err := ioutil.ReadFile(path); err == nil {
+		if err := json.Unmarshal(blob, &urls); err != nil {
+			return fmt.Errorf("failed to load %s: %v", path, err)
+		}
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+	kept := make([]string, 0, len(urls)+1)
+	for _, url := range urls {
+		if n, err := discover.ParseNode(url); err == nil && n.ID == node.ID {
+			continue
+		}
+		kept = append(kept, url)
+	}
+	if add {
+		kept = append(kept, node.String())
+	} else if len(kept) == len(urls) {
+		return nil
+	}
+	blob, err := json.MarshalIndent(kept, "", "\t")
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(path, blob, 0644); err != nil {
+		return fmt.Errorf("failed to save %s: %v", path, err)
+	}
 	return nil
 }
 
@@ -554,6 +696,10 @@ func (s *Expanse) Stop() {
 	s.blockchain.Stop()
 	s.protocolManager.Stop()
 	s.txPool.Stop()
+	s.notifier.Stop()
+	if s.journal != nil {
+		s.journal.Stop()
+	}
 	s.eventMux.Stop()
 	if s.whisper != nil {
 		s.whisper.Stop()
@@ -663,6 +809,22 @@ func dagFiles(epoch uint64) (string, string) {
 	return dag, "full-R" + dag
 }
 
+// chainMigrations are the changes of the chain database layout, run at startup
+// on databases which haven't seen them yet. New ones are only ever appended,
+// their number is the schema version of the database.
+//
+// The upgrades predating the migrations detect themselves whether they were
+// applied, as databases created before versioning need them checked.
+var chainMigrations = []core.Migration{
+	{Name: "split blocks into headers and bodies", Migrate: upgradeChainDatabase},
+	{Name: "index logs in mipmap bloom bins", Migrate: addMipmapBloomBins},
+}
+
+// MigrateChainDatabase runs the chain database migrations not applied yet.
+func MigrateChainDatabase(db ethdb.Database) error {
+	return core.MigrateDatabase(db, chainMigrations)
+}
+
 func saveBlockchainVersion(db ethdb.Database, bcVersion int) {
 	d, _ := db.Get([]byte("BlockchainVersion"))
 	blockchainVersion := common.NewValue(d).Uint()
@@ -1,7 +1,10 @@
 package exp
 
 import (
+	"io/ioutil"
 	"math/big"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/expanse-project/go-expanse/common"
@@ -9,6 +12,7 @@ import (
 	"github.com/expanse-project/go-expanse/core/types"
 	"github.com/expanse-project/go-expanse/core/vm"
 	"github.com/expanse-project/go-expanse/ethdb"
+	"github.com/expanse-project/go-expanse/p2p/discover"
 )
 
 func TestMipmapUpgrade(t *testing.T) {
@@ -65,3 +69,40 @@ func TestMipmapUpgrade(t *testing.T) {
 		t.Error("setting-mipmap-version not written to database")
 	}
 }
+
+func TestUpdateStaticNodes(t *testing.T) {
+	dir, err := ioutil.TempDir("", "static-nodes")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var (
+		exp    = &Expanse{DataDir: dir}
+		config = &Config{DataDir: dir}
+		a      = discover.MustParseNode("enode://a979fb575495b8d6db44f750317d0f4622bf4c2aa3365d6af7c284339968eef29b69ad0dce72a4d8db5ebb4968de0e3bec910127f134779fbcb0cb6d3331163c@52.16.188.185:30303")
+		b      = discover.MustParseNode("enode://de471bccee3d042261d52e9bff31458daecc406142b401d4cd848f677479f73104b9fdeb090af9583d3391b7f10cb2ba9e26865dd5fca4fcdc0fb1e3b723c786@54.94.239.50:30303")
+	)
+	// Removing from a missing list doesn't create one
+	if err := exp.updateStaticNodes(a, false); err != nil {
+		t.Fatalf("failed to remove node: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, staticNodes)); !os.IsNotExist(err) {
+		t.Fatalf("static node list created by a removal: %v", err)
+	}
+	// Added nodes are listed once, removed ones dropped
+	for _, node := range []*discover.Node{a, b, a} {
+		if err := exp.updateStaticNodes(node, true); err != nil {
+			t.Fatalf("failed to add node: %v", err)
+		}
+	}
+	if nodes := config.parseNodes(staticNodes); len(nodes) != 2 || nodes[0].ID != b.ID || nodes[1].ID != a.ID {
+		t.Fatalf("static nodes mismatch: have %v, want [%v %v]", nodes, b, a)
+	}
+	if err := exp.updateStaticNodes(b, false); err != nil {
+		t.Fatalf("failed to remove node: %v", err)
+	}
+	if nodes := config.parseNodes(staticNodes); len(nodes) != 1 || nodes[0].ID != a.ID {
+		t.Fatalf("static nodes mismatch: have %v, want [%v]", nodes, a)
+	}
+}
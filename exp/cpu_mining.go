@@ -41,6 +41,7 @@ func (s *Expanse) StartMining(threads int, gpus string) error {
 	}
 
 	// CPU mining
+	s.authorizeSigner(eb)
 	go s.miner.Start(eb, threads)
 	return nil
 }
@@ -92,12 +92,14 @@ var (
 	errCancelStateFetch   = errors.New("state data download canceled (requested)")
 	errCancelProcessing   = errors.New("processing canceled (requested)")
 	errNoSyncActive       = errors.New("no sync active")
+	errNoCheckpoint       = errors.New("peer chain doesn't reach the checkpoint")
 )
 
 type Downloader struct {
-	mode   SyncMode       // Synchronisation mode defining the strategy used (per sync cycle)
-	noFast bool           // Flag to disable fast syncing in case of a security error
-	mux    *event.TypeMux // Event multiplexer to announce sync operation events
+	mode       SyncMode       // Synchronisation mode defining the strategy used (per sync cycle)
+	noFast     bool           // Flag to disable fast syncing in case of a security error
+	checkpoint *Checkpoint    // Trusted block the synced chain must contain (nil if none)
+	mux        *event.TypeMux // Event multiplexer to announce sync operation events
 
 	queue *queue   // Scheduler for selecting the hashes to download
 	peers *peerSet // Set of active peers from which download can proceed
@@ -194,6 +196,14 @@ func New(stateDb ethdb.Database, mux *event.TypeMux, hasHeader headerCheckFn, ha
 	}
 }
 
+// SetCheckpoint configures a trusted block that every synchronised chain must
+// pass through. Peers feeding a chain with a different block at the checkpoint
+// height are dropped, and fast sync uses the checkpoint as its pivot point. It
+// must be called before synchronisation starts.
+func (d *Downloader) SetCheckpoint(checkpoint *Checkpoint) {
+	d.checkpoint = checkpoint
+}
+
 // Progress retrieves the synchronisation boundaries, specifically the origin
 // block where synchronisation started at (may have failed/suspended); the block
 // or header sync is currently at; and the latest known block which the sync targets.
@@ -213,6 +223,16 @@ func (d *Downloader) Progress() (uint64, uint64, uint64) {
 	return d.syncStatsChainOrigin, current, d.syncStatsChainHeight
 }
 
+// StateProgress retrieves the number of state trie entries pulled by the current
+// or last fast sync, and the number of entries known to exist so far. The known
+// count grows as the pulled entries reveal the rest of the state trie.
+func (d *Downloader) StateProgress() (uint64, uint64) {
+	d.syncStatsLock.RLock()
+	defer d.syncStatsLock.RUnlock()
+
+	return d.syncStatsStateDone, d.syncStatsStateTotal
+}
+
 // Synchronising returns whether the downloader is currently retrieving blocks.
 func (d *Downloader) Synchronising() bool {
 	return atomic.LoadInt32(&d.synchronising) > 0
@@ -324,13 +344,13 @@ func (d *Downloader) synchronise(id string, hash common.Hash, td *big.Int, mode
 // syncWithPeer starts a block synchronization based on the hash chain from the
 // specified peer and head hash.
 func (d *Downloader) syncWithPeer(p *peer, hash common.Hash, td *big.Int) (err error) {
-	d.mux.Post(StartEvent{})
+	d.mux.Post(StartEvent{Peer: p.id, Head: hash, Td: td})
 	defer func() {
 		// reset on error
 		if err != nil {
-			d.mux.Post(FailedEvent{err})
+			d.mux.Post(FailedEvent{Peer: p.id, Head: hash, Err: err})
 		} else {
-			d.mux.Post(DoneEvent{})
+			d.mux.Post(DoneEvent{Peer: p.id, Head: hash})
 		}
 	}()
 
@@ -349,6 +369,9 @@ func (d *Downloader) syncWithPeer(p *peer, hash common.Hash, td *big.Int) (err e
 		if err != nil {
 			return err
 		}
+		if err := d.checkpointReachable(origin, latest); err != nil {
+			return err
+		}
 		d.syncStatsLock.Lock()
 		if d.syncStatsChainHeight <= origin || d.syncStatsChainOrigin > origin {
 			d.syncStatsChainOrigin = origin
@@ -377,6 +400,9 @@ func (d *Downloader) syncWithPeer(p *peer, hash common.Hash, td *big.Int) (err e
 		if err != nil {
 			return err
 		}
+		if err := d.checkpointReachable(origin, latest); err != nil {
+			return err
+		}
 		d.syncStatsLock.Lock()
 		if d.syncStatsChainHeight <= origin || d.syncStatsChainOrigin > origin {
 			d.syncStatsChainOrigin = origin
@@ -390,13 +416,18 @@ func (d *Downloader) syncWithPeer(p *peer, hash common.Hash, td *big.Int) (err e
 		case LightSync:
 			pivot = latest
 		case FastSync:
-			// Calculate the new fast/slow sync pivot point
-			pivotOffset, err := rand.Int(rand.Reader, big.NewInt(int64(fsPivotInterval)))
-			if err != nil {
-				panic(fmt.Sprintf("Failed to access crypto random source: %v", err))
-			}
-			if latest > uint64(fsMinFullBlocks)+pivotOffset.Uint64() {
-				pivot = latest - uint64(fsMinFullBlocks) - pivotOffset.Uint64()
+			if d.checkpoint != nil && origin < d.checkpoint.Number {
+				// Pivot on the trusted checkpoint if the sync passes through it
+				pivot = d.checkpoint.Number
+			} else {
+				// Calculate the new fast/slow sync pivot point
+				pivotOffset, err := rand.Int(rand.Reader, big.NewInt(int64(fsPivotInterval)))
+				if err != nil {
+					panic(fmt.Sprintf("Failed to access crypto random source: %v", err))
+				}
+				if latest > uint64(fsMinFullBlocks)+pivotOffset.Uint64() {
+					pivot = latest - uint64(fsMinFullBlocks) - pivotOffset.Uint64()
+				}
 			}
 			// If the point is below the origin, move origin back to ensure state download
 			if pivot < origin {
@@ -426,6 +457,22 @@ func (d *Downloader) syncWithPeer(p *peer, hash common.Hash, td *big.Int) (err e
 	}
 }
 
+// checkpointReachable ensures that a sync from origin up to the peer's latest
+// block passes through the configured checkpoint, if it isn't already part of
+// the local chain.
+func (d *Downloader) checkpointReachable(origin, latest uint64) error {
+	if d.checkpoint == nil || origin >= d.checkpoint.Number || latest >= d.checkpoint.Number {
+		return nil
+	}
+	return errNoCheckpoint
+}
+
+// checkpointMismatch checks whether the given hash, found at the given height
+// of a remote chain, contradicts the configured checkpoint.
+func (d *Downloader) checkpointMismatch(number uint64, hash common.Hash) bool {
+	return d.checkpoint != nil && d.checkpoint.Number == number && d.checkpoint.Hash != hash
+}
+
 // spawnSync runs d.process and all given fetcher functions to completion in
 // separate goroutines, returning the first error that appears.
 func (d *Downloader) spawnSync(fetchers ...func() error) error {
@@ -717,6 +764,12 @@ func (d *Downloader) fetchHashes61(p *peer, td *big.Int, from uint64) error {
 			// Otherwise insert all the new hashes, aborting in case of junk
 			glog.V(logger.Detail).Infof("%v: scheduling %d hashes from #%d", p, len(hashes), from)
 
+			for i, hash := range hashes {
+				if d.checkpointMismatch(from+uint64(i), hash) {
+					glog.V(logger.Debug).Infof("%v: checkpoint mismatch at #%d [%x…]", p, from+uint64(i), hash[:4])
+					return errInvalidChain
+				}
+			}
 			inserts := d.queue.Schedule61(hashes, true)
 			if len(inserts) != len(hashes) {
 				glog.V(logger.Debug).Infof("%v: stale hashes", p)
@@ -1185,6 +1238,13 @@ func (d *Downloader) fetchHeaders(p *peer, td *big.Int, from uint64) error {
 			// Otherwise insert all the new headers, aborting in case of junk
 			glog.V(logger.Detail).Infof("%v: schedule %d headers from #%d", p, len(headers), from)
 
+			for _, header := range headers {
+				if d.checkpointMismatch(header.Number.Uint64(), header.Hash()) {
+					glog.V(logger.Debug).Infof("%v: checkpoint mismatch at #%d [%x…]", p, header.Number, header.Hash().Bytes()[:4])
+					return errInvalidChain
+				}
+			}
+
 			if d.mode == FastSync || d.mode == LightSync {
 				// Collect the yet unknown headers to mark them as uncertain
 				unknown := make([]*types.Header, 0, len(headers))
@@ -1327,7 +1387,8 @@ func (d *Downloader) fetchNodeData() error {
 					return
 				}
 				// Processing succeeded, notify state fetcher of continuation
-				if d.queue.PendingNodeData() > 0 {
+				pending := d.queue.PendingNodeData()
+				if pending > 0 {
 					select {
 					case d.stateWakeCh <- true:
 					default:
@@ -1337,6 +1398,7 @@ func (d *Downloader) fetchNodeData() error {
 				d.syncStatsLock.Lock()
 				defer d.syncStatsLock.Unlock()
 				d.syncStatsStateDone += uint64(delivered)
+				d.syncStatsStateTotal = d.syncStatsStateDone + uint64(pending)
 				glog.V(logger.Info).Infof("imported %d state entries in %v: processed %d in total", delivered, time.Since(start), d.syncStatsStateDone)
 			})
 		}
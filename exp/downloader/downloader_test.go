@@ -716,6 +716,35 @@ func testCanonicalSynchronisation(t *testing.T, protocol int, mode SyncMode) {
 	assertOwnChain(t, tester, targetBlocks+1)
 }
 
+// Tests that a synchronisation posts events naming the peer and its head when
+// it starts and completes.
+func TestSyncEvents(t *testing.T) {
+	t.Parallel()
+
+	hashes, headers, blocks, receipts := makeChain(blockCacheLimit-15, 0, genesis, nil)
+
+	tester := newTester()
+	tester.newPeer("peer", 62, hashes, headers, blocks, receipts)
+
+	sub := tester.downloader.mux.Subscribe(StartEvent{}, DoneEvent{}, FailedEvent{})
+	defer sub.Unsubscribe()
+
+	errc := make(chan error, 1)
+	go func() { errc <- tester.sync("peer", nil, FullSync) }()
+
+	ev := <-sub.Chan()
+	if start, ok := ev.Data.(StartEvent); !ok || start.Peer != "peer" || start.Head != hashes[0] || start.Td == nil {
+		t.Fatalf("start event mismatch: have %+v, want peer %q and head %x", ev.Data, "peer", hashes[0])
+	}
+	ev = <-sub.Chan()
+	if done, ok := ev.Data.(DoneEvent); !ok || done.Peer != "peer" || done.Head != hashes[0] {
+		t.Fatalf("done event mismatch: have %+v, want peer %q and head %x", ev.Data, "peer", hashes[0])
+	}
+	if err := <-errc; err != nil {
+		t.Fatalf("failed to synchronise blocks: %v", err)
+	}
+}
+
 // Tests that if a large batch of blocks are being downloaded, it is throttled
 // until the cached blocks are retrieved.
 func TestThrottling61(t *testing.T)     { testThrottling(t, 61, FullSync) }
@@ -1619,3 +1648,68 @@ func testDeliverHeadersHang(t *testing.T, protocol int, mode SyncMode) {
 		}
 	}
 }
+
+// Tests that a configured checkpoint is enforced during synchronisation and
+// that fast sync pivots on it.
+func TestCheckpointSynchronisation61(t *testing.T)      { testCheckpointSynchronisation(t, 61, FullSync) }
+func TestCheckpointSynchronisation62(t *testing.T)      { testCheckpointSynchronisation(t, 62, FullSync) }
+func TestCheckpointSynchronisation63Full(t *testing.T)  { testCheckpointSynchronisation(t, 63, FullSync) }
+func TestCheckpointSynchronisation63Fast(t *testing.T)  { testCheckpointSynchronisation(t, 63, FastSync) }
+func TestCheckpointSynchronisation64Full(t *testing.T)  { testCheckpointSynchronisation(t, 64, FullSync) }
+func TestCheckpointSynchronisation64Fast(t *testing.T)  { testCheckpointSynchronisation(t, 64, FastSync) }
+func TestCheckpointSynchronisation64Light(t *testing.T) { testCheckpointSynchronisation(t, 64, LightSync) }
+
+func testCheckpointSynchronisation(t *testing.T, protocol int, mode SyncMode) {
+	t.Parallel()
+
+	targetBlocks := blockCacheLimit - 15
+	hashes, headers, blocks, receipts := makeChain(targetBlocks, 0, genesis, nil)
+	number := uint64(targetBlocks / 2)
+	hash := hashes[len(hashes)-1-int(number)]
+
+	// A chain with a different block at the checkpoint must be rejected
+	tester := newTester()
+	tester.downloader.SetCheckpoint(&Checkpoint{Number: number, Hash: common.Hash{0xff}})
+	tester.newPeer("peer", protocol, hashes, headers, blocks, receipts)
+	if err := tester.sync("peer", nil, mode); err != errInvalidChain {
+		t.Errorf("mismatching checkpoint: sync error mismatch: have %v, want %v", err, errInvalidChain)
+	}
+	if head := tester.headHeader().Number.Uint64(); head >= number {
+		t.Errorf("mismatching checkpoint: chain imported past the checkpoint: head %d", head)
+	}
+
+	// A chain not reaching the checkpoint must be rejected
+	tester = newTester()
+	tester.downloader.SetCheckpoint(&Checkpoint{Number: uint64(targetBlocks + 1), Hash: hash})
+	tester.newPeer("peer", protocol, hashes, headers, blocks, receipts)
+	if err := tester.sync("peer", nil, mode); err != errNoCheckpoint {
+		t.Errorf("unreached checkpoint: sync error mismatch: have %v, want %v", err, errNoCheckpoint)
+	}
+	assertOwnChain(t, tester, 1)
+
+	// A chain passing through the checkpoint must be accepted
+	tester = newTester()
+	tester.downloader.SetCheckpoint(&Checkpoint{Number: number, Hash: hash})
+	tester.newPeer("peer", protocol, hashes, headers, blocks, receipts)
+	if err := tester.sync("peer", nil, mode); err != nil {
+		t.Fatalf("failed to synchronise blocks: %v", err)
+	}
+	if mode != FastSync {
+		assertOwnChain(t, tester, targetBlocks+1)
+		return
+	}
+	// Fast sync must have pivoted on the checkpoint, retrieving receipts up to it
+	if pivot := tester.downloader.queue.FastSyncPivot(); pivot != number {
+		t.Errorf("fast sync pivot mismatch: have %d, want %d", pivot, number)
+	}
+	if bs := len(tester.ownBlocks); bs != targetBlocks+1 {
+		t.Errorf("synchronised blocks mismatch: have %d, want %d", bs, targetBlocks+1)
+	}
+	if rs := len(tester.ownReceipts); rs != int(number)+1 {
+		t.Errorf("synchronised receipts mismatch: have %d, want %d", rs, number+1)
+	}
+	// The complete state of the pivot block must have been pulled
+	if pulled, known := tester.downloader.StateProgress(); pulled == 0 || pulled != known {
+		t.Errorf("state progress mismatch: have %d of %d known entries pulled, want all of a non-empty state", pulled, known)
+	}
+}
@@ -16,6 +16,29 @@
 
 package downloader
 
-type DoneEvent struct{}
-type StartEvent struct{}
-type FailedEvent struct{ Err error }
+import (
+	"math/big"
+
+	"github.com/expanse-project/go-expanse/common"
+)
+
+// StartEvent is posted when a synchronisation with a peer starts, towards the
+// head the peer announced.
+type StartEvent struct {
+	Peer string      // Identifier of the peer synchronised with
+	Head common.Hash // Hash of the head block of the peer
+	Td   *big.Int    // Total difficulty of the head block of the peer
+}
+
+// DoneEvent is posted when a synchronisation completed successfully.
+type DoneEvent struct {
+	Peer string      // Identifier of the peer synchronised with
+	Head common.Hash // Hash of the head block of the peer
+}
+
+// FailedEvent is posted when a synchronisation failed or was cancelled.
+type FailedEvent struct {
+	Peer string      // Identifier of the peer synchronised with
+	Head common.Hash // Hash of the head block of the peer
+	Err  error       // Reason the synchronisation failed
+}
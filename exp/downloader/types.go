@@ -24,6 +24,13 @@ import (
 	"github.com/expanse-project/go-expanse/core/types"
 )
 
+// Checkpoint is a trusted block, identified by its number and hash, which the
+// synchronised chain must contain.
+type Checkpoint struct {
+	Number uint64
+	Hash   common.Hash
+}
+
 // headerCheckFn is a callback type for verifying a header's presence in the local chain.
 type headerCheckFn func(common.Hash) bool
 
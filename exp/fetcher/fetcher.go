@@ -133,6 +133,9 @@ type Fetcher struct {
 	queues map[string]int          // Per peer block counts to prevent memory exhaustion
 	queued map[common.Hash]*inject // Set of already queued blocks (to dedup imports)
 
+	// Propagation latency tracking
+	seen sightings // First announcements and arrivals of recent blocks
+
 	// Callbacks
 	getBlock       blockRetrievalFn   // Retrieves a block from the local chain
 	validateBlock  blockValidatorFn   // Checks if a block's headers have a valid proof of work
@@ -167,6 +170,7 @@ func New(getBlock blockRetrievalFn, validateBlock blockValidatorFn, broadcastBlo
 		queue:          prque.New(),
 		queues:         make(map[string]int),
 		queued:         make(map[common.Hash]*inject),
+		seen:           make(sightings),
 		getBlock:       getBlock,
 		validateBlock:  validateBlock,
 		broadcastBlock: broadcastBlock,
@@ -322,6 +326,8 @@ func (f *Fetcher) loop() {
 				f.forgetHash(hash)
 			}
 		}
+		f.seen.expire(time.Now())
+
 		// Import any queued blocks that could potentially fit
 		height := f.chainHeight()
 		for !f.queue.Empty() {
@@ -370,6 +376,8 @@ func (f *Fetcher) loop() {
 					break
 				}
 			}
+			f.seen.announce(notification.hash, notification.time)
+
 			// All is well, schedule the announce if block's not yet downloading
 			if _, ok := f.fetching[notification.hash]; ok {
 				break
@@ -715,6 +723,12 @@ func (f *Fetcher) enqueue(peer string, block *types.Block) {
 		f.forgetHash(hash)
 		return
 	}
+	arrived := block.ReceivedAt
+	if arrived.IsZero() {
+		arrived = time.Now()
+	}
+	f.seen.arrive(block, arrived)
+
 	// Schedule the block for future importing
 	if _, ok := f.queued[hash]; !ok {
 		op := &inject{
@@ -751,15 +765,20 @@ func (f *Fetcher) insert(peer string, block *types.Block) {
 			return
 		}
 		// Quickly validate the header and propagate the block if it passes
-		switch err := f.validateBlock(block, parent); err {
-		case nil:
+		switch err := f.validateBlock(block, parent); {
+		case err == nil:
 			// All ok, quickly propagate to our peers
 			propBroadcastOutTimer.UpdateSince(block.ReceivedAt)
 			go f.broadcastBlock(block, true)
 
-		case core.BlockFutureErr:
+		case err == core.BlockFutureErr:
 			// Weird future block, don't fail, but neither propagate
 
+		case core.IsFutureBlockErr(err):
+			// Clock skew, not a misbehaving peer; report it and drop the block
+			glog.V(logger.Warn).Infof("Peer %s: block #%d [%x…] rejected: %v", peer, block.NumberU64(), hash[:4], err)
+			return
+
 		default:
 			// Something went very wrong, drop the peer
 			glog.V(logger.Debug).Infof("Peer %s: block #%d [%x…] verification failed: %v", peer, block.NumberU64(), hash[:4], err)
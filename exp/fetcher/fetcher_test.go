@@ -877,3 +877,47 @@ func TestBlockMemoryExhaustionAttack(t *testing.T) {
 	}
 	verifyImportDone(t, imported)
 }
+
+// Tests that only the first announcement and arrival of a block are recorded,
+// and that sightings are forgotten once they expire.
+func TestBlockSightings(t *testing.T) {
+	hashes, blocks := makeChain(2, 0, genesis)
+	block := blocks[hashes[0]]
+
+	seen := make(sightings)
+	start := time.Now()
+
+	seen.announce(block.Hash(), start)
+	seen.announce(block.Hash(), start.Add(time.Second))
+	seen.arrive(block, start.Add(2*time.Second))
+	seen.arrive(block, start.Add(3*time.Second))
+
+	sight := seen[block.Hash()]
+	if sight == nil {
+		t.Fatalf("block sighting not recorded")
+	}
+	if !sight.announced.Equal(start) {
+		t.Errorf("announcement time mismatch: have %v, want %v", sight.announced, start)
+	}
+	if want := start.Add(2 * time.Second); !sight.arrived.Equal(want) {
+		t.Errorf("arrival time mismatch: have %v, want %v", sight.arrived, want)
+	}
+	if sight.mined.Unix() != block.Time().Int64() {
+		t.Errorf("mining time mismatch: have %v, want %v", sight.mined.Unix(), block.Time())
+	}
+	// A block arriving before its announcement was first seen on arrival
+	early := blocks[hashes[1]]
+	seen.arrive(early, start.Add(time.Second))
+	seen.announce(early.Hash(), start.Add(2*time.Second))
+	if first := seen[early.Hash()].first(); !first.Equal(start.Add(time.Second)) {
+		t.Errorf("first sighting mismatch: have %v, want %v", first, start.Add(time.Second))
+	}
+	// Sightings are dropped once the first one is old enough
+	seen.expire(start.Add(sightingTTL + time.Second))
+	if _, ok := seen[block.Hash()]; ok {
+		t.Errorf("expired sighting retained")
+	}
+	if _, ok := seen[early.Hash()]; !ok {
+		t.Errorf("live sighting dropped")
+	}
+}
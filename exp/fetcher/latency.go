@@ -0,0 +1,109 @@
+// Copyright 2016 The go-expanse Authors
+// This file is part of the go-expanse library.
+//
+// The go-expanse library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-expanse library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-expanse library. If not, see <http://www.gnu.org/licenses/>.
+
+// Contains the tracking of block propagation latencies.
+
+package fetcher
+
+import (
+	"time"
+
+	"github.com/expanse-project/go-expanse/common"
+	"github.com/expanse-project/go-expanse/core/types"
+	"github.com/rcrowley/go-metrics"
+)
+
+// sightingTTL is the time after which a block sighting is forgotten. Blocks
+// announced or delivered later than this are counted as seen for the first time.
+const sightingTTL = time.Minute
+
+// sighting is the time a block was first heard of on the network.
+type sighting struct {
+	mined     time.Time // Timestamp of the block header, zero until the block arrived
+	announced time.Time // First announcement of the block hash, zero if not announced yet
+	arrived   time.Time // First arrival of the full block, zero if not arrived yet
+}
+
+// first returns the time the block was first heard of, by announcement or in full.
+func (s *sighting) first() time.Time {
+	if s.announced.IsZero() || (!s.arrived.IsZero() && s.arrived.Before(s.announced)) {
+		return s.arrived
+	}
+	return s.announced
+}
+
+// sightings tracks the first announcement and full arrival of blocks, measuring
+// how long blocks take to reach the local node once mined:
+//   - prop/latency/announces: from the block timestamp to its first announcement
+//   - prop/latency/blocks:    from the block timestamp to its first full arrival
+//   - prop/latency/fetches:   from the first announcement to the full arrival
+//
+// The block timestamp has a resolution of a second and relies on the clock of
+// the miner, so the first two are only meaningful over many blocks.
+type sightings map[common.Hash]*sighting
+
+// announce records the announcement of a block hash at the given time.
+func (s sightings) announce(hash common.Hash, at time.Time) {
+	seen := s[hash]
+	if seen == nil {
+		seen = new(sighting)
+		s[hash] = seen
+	}
+	if !seen.announced.IsZero() {
+		return
+	}
+	seen.announced = at
+	if !seen.arrived.IsZero() {
+		observe(propAnnounceLatencyTimer, seen.mined, at)
+	}
+}
+
+// arrive records the arrival of a full block at the given time.
+func (s sightings) arrive(block *types.Block, at time.Time) {
+	hash := block.Hash()
+
+	seen := s[hash]
+	if seen == nil {
+		seen = new(sighting)
+		s[hash] = seen
+	}
+	if !seen.arrived.IsZero() {
+		return
+	}
+	seen.mined, seen.arrived = time.Unix(block.Time().Int64(), 0), at
+	observe(propBlockLatencyTimer, seen.mined, at)
+	if !seen.announced.IsZero() {
+		observe(propAnnounceLatencyTimer, seen.mined, seen.announced)
+		observe(propFetchLatencyTimer, seen.announced, at)
+	}
+}
+
+// expire drops the sightings first seen more than sightingTTL before now.
+func (s sightings) expire(now time.Time) {
+	for hash, seen := range s {
+		if now.Sub(seen.first()) > sightingTTL {
+			delete(s, hash)
+		}
+	}
+}
+
+// observe updates the timer with the time elapsed from since to until, unless
+// the clocks involved put until before since.
+func observe(timer metrics.Timer, since, until time.Time) {
+	if d := until.Sub(since); d >= 0 {
+		timer.Update(d)
+	}
+}
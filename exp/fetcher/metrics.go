@@ -33,6 +33,10 @@ var (
 	propBroadcastDropMeter = metrics.NewMeter("eth/fetcher/prop/broadcasts/drop")
 	propBroadcastDOSMeter  = metrics.NewMeter("eth/fetcher/prop/broadcasts/dos")
 
+	propAnnounceLatencyTimer = metrics.NewTimer("eth/fetcher/prop/latency/announces")
+	propBlockLatencyTimer    = metrics.NewTimer("eth/fetcher/prop/latency/blocks")
+	propFetchLatencyTimer    = metrics.NewTimer("eth/fetcher/prop/latency/fetches")
+
 	blockFetchMeter  = metrics.NewMeter("eth/fetcher/fetch/blocks")
 	headerFetchMeter = metrics.NewMeter("eth/fetcher/fetch/headers")
 	bodyFetchMeter   = metrics.NewMeter("eth/fetcher/fetch/bodies")
@@ -0,0 +1,92 @@
+// Copyright 2016 The go-expanse Authors
+// This file is part of the go-expanse library.
+//
+// The go-expanse library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-expanse library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-expanse library. If not, see <http://www.gnu.org/licenses/>.
+
+package filters
+
+import (
+	"github.com/expanse-project/go-expanse/common"
+	"github.com/expanse-project/go-expanse/core"
+	"github.com/expanse-project/go-expanse/ethdb"
+)
+
+// ConfirmationStatus describes a change in the confirmation state of a
+// transaction tracked by a confirmation filter.
+type ConfirmationStatus string
+
+const (
+	// TxConfirmed is reported when the block including the transaction got
+	// the requested number of blocks on top of it.
+	TxConfirmed ConfirmationStatus = "confirmed"
+
+	// TxReorged is reported when a block previously reported as confirmed
+	// was removed from the canonical chain.
+	TxReorged ConfirmationStatus = "reorged"
+)
+
+// ConfirmationEvent is collected by a confirmation filter whenever the tracked
+// transaction changes its confirmation status.
+type ConfirmationEvent struct {
+	Status        ConfirmationStatus
+	TxHash        common.Hash
+	BlockHash     common.Hash
+	BlockNumber   uint64
+	Confirmations uint64
+}
+
+// confirmationTracker follows a single transaction through the canonical chain.
+type confirmationTracker struct {
+	db       ethdb.Database
+	tx       common.Hash
+	required uint64
+
+	block  common.Hash // block in which the transaction was reported confirmed
+	number uint64
+}
+
+// update re-evaluates the status of the transaction against the chain with the
+// given head and returns the resulting status changes.
+func (t *confirmationTracker) update(head uint64) []ConfirmationEvent {
+	var events []ConfirmationEvent
+
+	if t.block != (common.Hash{}) {
+		if t.number <= head && core.GetCanonicalHash(t.db, t.number) == t.block {
+			return nil
+		}
+		events = append(events, ConfirmationEvent{
+			Status:      TxReorged,
+			TxHash:      t.tx,
+			BlockHash:   t.block,
+			BlockNumber: t.number,
+		})
+		t.block, t.number = common.Hash{}, 0
+	}
+	// The transaction might have been included in the new canonical chain.
+	tx, hash, number, _ := core.GetTransaction(t.db, t.tx)
+	if tx == nil || number > head || head-number < t.required {
+		return events
+	}
+	if core.GetCanonicalHash(t.db, number) != hash {
+		return events
+	}
+	t.block, t.number = hash, number
+	return append(events, ConfirmationEvent{
+		Status:        TxConfirmed,
+		TxHash:        t.tx,
+		BlockHash:     hash,
+		BlockNumber:   number,
+		Confirmations: head - number,
+	})
+}
@@ -17,7 +17,11 @@
 package filters
 
 import (
+	"context"
+	"errors"
 	"math"
+	"runtime"
+	"sync"
 
 	"github.com/expanse-project/go-expanse/common"
 	"github.com/expanse-project/go-expanse/core"
@@ -26,6 +30,18 @@ import (
 	"github.com/expanse-project/go-expanse/ethdb"
 )
 
+// logBatchSize is the number of blocks a single worker scans at a time when
+// a log search is split up for concurrent processing.
+const logBatchSize = 256
+
+// ErrInvalidRange is returned for searches whose begin block is after their
+// end block.
+var ErrInvalidRange = errors.New("invalid block range: begin block after end block")
+
+// logWorkers is the maximum number of goroutines scanning block ranges for a
+// single log search.
+var logWorkers = runtime.NumCPU()
+
 type AccountChange struct {
 	Address, StateAddress []byte
 }
@@ -40,6 +56,7 @@ type Filter struct {
 	BlockCallback       func(*types.Block, vm.Logs)
 	TransactionCallback func(*types.Transaction)
 	LogsCallback        func(vm.Logs)
+	DropCallback        func(*types.Transaction, core.TxDropReason)
 }
 
 // Create a new filter which uses a bloom filter on blocks to figure out whether a particular block
@@ -69,30 +86,52 @@ func (self *Filter) SetTopics(topics [][]common.Hash) {
 
 // Run filters logs with the current parameters set
 func (self *Filter) Find() vm.Logs {
+	logs, _ := self.FindContext(context.Background())
+	return logs
+}
+
+// FindContext is like Find but stops searching and returns the context's
+// error once ctx is done. The end of the range is capped at the current head,
+// so the work done stays proportional to the chain and not to the request.
+func (self *Filter) FindContext(ctx context.Context) (vm.Logs, error) {
+	if self.begin >= 0 && self.end >= 0 && self.begin > self.end {
+		return nil, ErrInvalidRange
+	}
 	latestBlock := core.GetBlock(self.db, core.GetHeadBlockHash(self.db))
+	if latestBlock == nil {
+		return nil, nil
+	}
+	head := latestBlock.NumberU64()
+
 	var beginBlockNo uint64 = uint64(self.begin)
-	if self.begin == -1 {
-		beginBlockNo = latestBlock.NumberU64()
+	if self.begin < 0 {
+		beginBlockNo = head
 	}
 	var endBlockNo uint64 = uint64(self.end)
-	if self.end == -1 {
-		endBlockNo = latestBlock.NumberU64()
+	if self.end < 0 || endBlockNo > head {
+		endBlockNo = head
+	}
+	if beginBlockNo > endBlockNo {
+		return nil, nil
 	}
 
 	// if no addresses are present we can't make use of fast search which
 	// uses the mipmap bloom filters to check for fast inclusion and uses
 	// higher range probability in order to ensure at least a false positive
 	if len(self.addresses) == 0 {
-		return self.getLogs(beginBlockNo, endBlockNo)
+		return self.getLogs(ctx, beginBlockNo, endBlockNo)
 	}
-	return self.mipFind(beginBlockNo, endBlockNo, 0)
+	return self.mipFind(ctx, beginBlockNo, endBlockNo, 0)
 }
 
-func (self *Filter) mipFind(start, end uint64, depth int) (logs vm.Logs) {
+func (self *Filter) mipFind(ctx context.Context, start, end uint64, depth int) (logs vm.Logs, err error) {
 	level := core.MIPMapLevels[depth]
 	// normalise numerator so we can work in level specific batches and
 	// work with the proper range checks
 	for num := start / level * level; num <= end; num += level {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
 		// find addresses in bloom filters
 		bloom := core.GetMipmapBloom(self.db, num, level)
 		for _, addr := range self.addresses {
@@ -102,11 +141,16 @@ func (self *Filter) mipFind(start, end uint64, depth int) (logs vm.Logs) {
 				// normalised values.
 				start := uint64(math.Max(float64(num), float64(start)))
 				end := uint64(math.Min(float64(num+level-1), float64(end)))
+				var found vm.Logs
 				if depth+1 == len(core.MIPMapLevels) {
-					logs = append(logs, self.getLogs(start, end)...)
+					found, err = self.getLogs(ctx, start, end)
 				} else {
-					logs = append(logs, self.mipFind(start, end, depth+1)...)
+					found, err = self.mipFind(ctx, start, end, depth+1)
+				}
+				if err != nil {
+					return nil, err
 				}
+				logs = append(logs, found...)
 				// break so we don't check the same range for each
 				// possible address. Checks on multiple addresses
 				// are handled further down the stack.
@@ -115,18 +159,93 @@ func (self *Filter) mipFind(start, end uint64, depth int) (logs vm.Logs) {
 		}
 	}
 
-	return logs
+	return logs, nil
+}
+
+// getLogs returns the matching logs in the range [start, end]. Large ranges are
+// split into batches that are scanned concurrently, the results are merged in
+// block order.
+func (self *Filter) getLogs(ctx context.Context, start, end uint64) (vm.Logs, error) {
+	if end < start {
+		return nil, nil
+	}
+	batches := (end-start)/logBatchSize + 1
+	if batches == 1 || logWorkers <= 1 {
+		logs, _, err := self.getLogsBatch(ctx, start, end)
+		return logs, err
+	}
+
+	type batchResult struct {
+		logs    vm.Logs
+		missing bool
+		err     error
+	}
+	var (
+		results = make([]batchResult, batches)
+		next    = make(chan uint64)
+		wg      sync.WaitGroup
+	)
+	workers := logWorkers
+	if uint64(workers) > batches {
+		workers = int(batches)
+	}
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for n := range next {
+				from := start + n*logBatchSize
+				to := from + logBatchSize - 1
+				if to > end {
+					to = end
+				}
+				res := &results[n]
+				res.logs, res.missing, res.err = self.getLogsBatch(ctx, from, to)
+			}
+		}()
+	}
+feed:
+	for n := uint64(0); n < batches; n++ {
+		select {
+		case next <- n:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(next)
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	var logs vm.Logs
+	for _, res := range results {
+		if res.err != nil {
+			return nil, res.err
+		}
+		logs = append(logs, res.logs...)
+		// blocks after a missing one are not part of the canonical chain yet
+		if res.missing {
+			break
+		}
+	}
+	return logs, nil
 }
 
-func (self *Filter) getLogs(start, end uint64) (logs vm.Logs) {
+// getLogsBatch scans the blocks in [start, end] sequentially. missing is set if
+// the scan stopped early because a block was not found.
+func (self *Filter) getLogsBatch(ctx context.Context, start, end uint64) (logs vm.Logs, missing bool, err error) {
 	var block *types.Block
 
 	for i := start; i <= end; i++ {
+		if err := ctx.Err(); err != nil {
+			return nil, false, err
+		}
 		hash := core.GetCanonicalHash(self.db, i)
 		if hash != (common.Hash{}) {
 			block = core.GetBlock(self.db, hash)
 		} else { // block not found
-			return logs
+			return logs, true, nil
 		}
 
 		// Use bloom filtering to see if this block is interesting given the
@@ -144,7 +263,7 @@ func (self *Filter) getLogs(start, end uint64) (logs vm.Logs) {
 		}
 	}
 
-	return logs
+	return logs, false, nil
 }
 
 func includes(addresses []common.Address, a common.Address) bool {
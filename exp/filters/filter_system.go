@@ -19,58 +19,173 @@
 package filters
 
 import (
+	"crypto/rand"
+	"encoding/binary"
 	"sync"
 	"time"
 
+	"github.com/expanse-project/go-expanse/common"
 	"github.com/expanse-project/go-expanse/core"
+	"github.com/expanse-project/go-expanse/core/types"
 	"github.com/expanse-project/go-expanse/core/vm"
 	"github.com/expanse-project/go-expanse/event"
+	"github.com/expanse-project/go-expanse/logger"
+	"github.com/expanse-project/go-expanse/logger/glog"
 )
 
+// FilterTimeout is the time after which a filter installed for polling is
+// uninstalled if its changes haven't been retrieved.
+var FilterTimeout = 5 * time.Minute
+
+// MaxFilterLogs is the number of logs, and MaxFilterHashes the number of any
+// other changes, an installed filter retains between polls. Once exceeded,
+// the oldest changes are discarded and the next poll reports the overflow.
+var (
+	MaxFilterLogs   = 10000
+	MaxFilterHashes = 4096
+)
+
+// Type determines the kind of events a polled filter collects.
+type Type byte
+
+const (
+	UnknownFilter      Type = iota
+	BlockFilter             // hashes of new chain blocks
+	TransactionFilter       // hashes of new pending transactions
+	LogFilter               // logs matching the filter criteria
+	ConfirmationFilter      // confirmation status changes of a transaction
+	DroppedTxFilter         // transactions dropped from the pool
+)
+
+// installed holds the events collected by a filter installed for polling
+// until they are retrieved.
+type installed struct {
+	typ      Type
+	logs     vm.Logs
+	hashes   []common.Hash
+	events   []ConfirmationEvent
+	drops    []core.TxDropEvent
+	deadline *time.Timer
+
+	discarded int // changes dropped because of the retention limits
+}
+
+// trim returns the number of changes to drop from a buffer holding have
+// changes so it retains at most limit, counting them as discarded.
+func (inst *installed) trim(have, limit int) int {
+	if limit <= 0 || have <= limit {
+		return 0
+	}
+	inst.discarded += have - limit
+	return have - limit
+}
+
 // FilterSystem manages filters that filter specific events such as
 // block, transaction and log events. The Filtering system can be used to listen
 // for specific LOG events fired by the EVM (Ethereum Virtual Machine).
 type FilterSystem struct {
-	filterMu sync.RWMutex
-	filterId int
-	filters  map[int]*Filter
-	created  map[int]time.Time
-	sub      event.Subscription
+	filterMu  sync.RWMutex
+	filters   map[int]*Filter
+	created   map[int]time.Time
+	chainCh   chan core.ChainEvent
+	chainSub  event.FeedSubscription
+	logsCh    chan vm.Logs
+	logsSub   event.FeedSubscription
+	rmLogsCh  chan core.RemovedLogsEvent
+	rmLogsSub event.FeedSubscription
+	txCh      chan core.TxPreEvent
+	txSub     event.FeedSubscription
+	dropCh    chan core.TxDropEvent
+	dropSub   event.FeedSubscription
+	quit      chan struct{}
+
+	installMu sync.Mutex
+	installed map[int]*installed
+}
+
+// ChainEventSource is the source of new block and log events, usually the
+// block chain.
+type ChainEventSource interface {
+	SubscribeChainEvent(ch chan<- core.ChainEvent) event.FeedSubscription
+	SubscribeLogsEvent(ch chan<- vm.Logs) event.FeedSubscription
+	SubscribeRemovedLogsEvent(ch chan<- core.RemovedLogsEvent) event.FeedSubscription
+}
+
+// TxEventSource is the source of pending and dropped transaction events, usually
+// the transaction pool.
+type TxEventSource interface {
+	SubscribeTxPreEvent(ch chan<- core.TxPreEvent) event.FeedSubscription
+	SubscribeTxDropEvent(ch chan<- core.TxDropEvent) event.FeedSubscription
 }
 
 // NewFilterSystem returns a newly allocated filter manager
-func NewFilterSystem(mux *event.TypeMux) *FilterSystem {
+func NewFilterSystem(chain ChainEventSource, txpool TxEventSource) *FilterSystem {
 	fs := &FilterSystem{
-		filters: make(map[int]*Filter),
-		created: make(map[int]time.Time),
-	}
-	fs.sub = mux.Subscribe(
-		//core.PendingBlockEvent{},
-		core.ChainEvent{},
-		core.TxPreEvent{},
-		vm.Logs(nil),
-	)
-	go fs.filterLoop()
+		filters:   make(map[int]*Filter),
+		created:   make(map[int]time.Time),
+		chainCh:   make(chan core.ChainEvent, core.ChainChanSize),
+		logsCh:    make(chan vm.Logs, core.ChainChanSize),
+		rmLogsCh:  make(chan core.RemovedLogsEvent, core.ChainChanSize),
+		txCh:      make(chan core.TxPreEvent, core.TxChanSize),
+		dropCh:    make(chan core.TxDropEvent, core.TxChanSize),
+		quit:      make(chan struct{}),
+		installed: make(map[int]*installed),
+	}
+	fs.chainSub = chain.SubscribeChainEvent(fs.chainCh)
+	fs.logsSub = chain.SubscribeLogsEvent(fs.logsCh)
+	fs.rmLogsSub = chain.SubscribeRemovedLogsEvent(fs.rmLogsCh)
+	fs.txSub = txpool.SubscribeTxPreEvent(fs.txCh)
+	fs.dropSub = txpool.SubscribeTxDropEvent(fs.dropCh)
+	go fs.filterLoop(chain)
+	go fs.txLoop(txpool)
 	return fs
 }
 
-// Stop quits the filter loop required for polling events
+// Stop quits the filter loop required for polling events and removes all
+// filters, so that a connection's filters are gone once it is closed.
 func (fs *FilterSystem) Stop() {
-	fs.sub.Unsubscribe()
+	close(fs.quit)
+
+	fs.installMu.Lock()
+	for id, inst := range fs.installed {
+		inst.deadline.Stop()
+		delete(fs.installed, id)
+	}
+	fs.installMu.Unlock()
+
+	fs.filterMu.Lock()
+	fs.filters = make(map[int]*Filter)
+	fs.created = make(map[int]time.Time)
+	fs.filterMu.Unlock()
 }
 
-// Add adds a filter to the filter manager
+// Add adds a filter to the filter manager. Filter ids are random so that they
+// can't be guessed by other clients of a shared endpoint.
 func (fs *FilterSystem) Add(filter *Filter) (id int) {
 	fs.filterMu.Lock()
 	defer fs.filterMu.Unlock()
-	id = fs.filterId
+
+	for {
+		id = newFilterId()
+		if _, exists := fs.filters[id]; !exists {
+			break
+		}
+	}
 	fs.filters[id] = filter
 	fs.created[id] = time.Now()
-	fs.filterId++
 
 	return id
 }
 
+// newFilterId returns a random, non-negative filter id.
+func newFilterId() int {
+	var b [8]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic("can't read random filter id: " + err.Error())
+	}
+	return int(uint(binary.BigEndian.Uint64(b[:])) >> 1)
+}
+
 // Remove removes a filter by filter id
 func (fs *FilterSystem) Remove(id int) {
 	fs.filterMu.Lock()
@@ -88,40 +203,293 @@ func (fs *FilterSystem) Get(id int) *Filter {
 	return fs.filters[id]
 }
 
-// filterLoop waits for specific events from ethereum and fires their handlers
-// when the filter matches the requirements.
-func (fs *FilterSystem) filterLoop() {
-	for event := range fs.sub.Chan() {
-		switch ev := event.Data.(type) {
-		case core.ChainEvent:
+// Install adds filter to the filter manager and collects the events of the
+// given type that it matches until they are retrieved through LogChanges or
+// HashChanges. Filters that aren't polled within FilterTimeout are uninstalled
+// automatically. Install overwrites the callbacks of filter.
+func (fs *FilterSystem) Install(typ Type, filter *Filter) int {
+	inst := &installed{typ: typ}
+	switch typ {
+	case BlockFilter:
+		filter.BlockCallback = func(block *types.Block, logs vm.Logs) {
+			fs.installMu.Lock()
+			inst.hashes = append(inst.hashes, block.Hash())
+			inst.hashes = inst.hashes[inst.trim(len(inst.hashes), MaxFilterHashes):]
+			fs.installMu.Unlock()
+		}
+	case TransactionFilter:
+		filter.TransactionCallback = func(tx *types.Transaction) {
+			fs.installMu.Lock()
+			inst.hashes = append(inst.hashes, tx.Hash())
+			inst.hashes = inst.hashes[inst.trim(len(inst.hashes), MaxFilterHashes):]
+			fs.installMu.Unlock()
+		}
+	case LogFilter:
+		filter.LogsCallback = func(logs vm.Logs) {
+			fs.installMu.Lock()
+			inst.logs = append(inst.logs, logs...)
+			inst.logs = inst.logs[inst.trim(len(inst.logs), MaxFilterLogs):]
+			fs.installMu.Unlock()
+		}
+	case DroppedTxFilter:
+		filter.DropCallback = func(tx *types.Transaction, reason core.TxDropReason) {
+			fs.installMu.Lock()
+			inst.drops = append(inst.drops, core.TxDropEvent{Tx: tx, Reason: reason})
+			inst.drops = inst.drops[inst.trim(len(inst.drops), MaxFilterHashes):]
+			fs.installMu.Unlock()
+		}
+	}
+
+	return fs.install(inst, filter)
+}
+
+// InstallConfirmation adds filter to the filter manager and tracks the given
+// transaction through the canonical chain. An event is collected when the
+// block including the transaction has the requested number of blocks on top of
+// it and again when that block is reorganised out of the chain. The events can
+// be retrieved through ConfirmationChanges.
+func (fs *FilterSystem) InstallConfirmation(filter *Filter, tx common.Hash, confirmations uint64) int {
+	tracker := &confirmationTracker{db: filter.db, tx: tx, required: confirmations}
+	inst := &installed{typ: ConfirmationFilter}
+
+	// The transaction may already be confirmed.
+	if head := core.GetHeader(filter.db, core.GetHeadBlockHash(filter.db)); head != nil {
+		inst.events = tracker.update(head.Number.Uint64())
+	}
+	filter.BlockCallback = func(block *types.Block, logs vm.Logs) {
+		events := tracker.update(block.NumberU64())
+		if len(events) > 0 {
+			fs.installMu.Lock()
+			inst.events = append(inst.events, events...)
+			inst.events = inst.events[inst.trim(len(inst.events), MaxFilterHashes):]
+			fs.installMu.Unlock()
+		}
+	}
+	return fs.install(inst, filter)
+}
+
+func (fs *FilterSystem) install(inst *installed, filter *Filter) int {
+	// the callbacks run with filterMu held, add before taking installMu
+	// to keep the lock order consistent.
+	id := fs.Add(filter)
+
+	fs.installMu.Lock()
+	defer fs.installMu.Unlock()
+
+	inst.deadline = time.AfterFunc(FilterTimeout, func() { fs.Uninstall(id) })
+	fs.installed[id] = inst
+
+	return id
+}
+
+// Uninstall removes a filter added with Install. It reports whether the
+// filter was installed.
+func (fs *FilterSystem) Uninstall(id int) bool {
+	fs.installMu.Lock()
+	inst, ok := fs.installed[id]
+	if ok {
+		inst.deadline.Stop()
+		delete(fs.installed, id)
+	}
+	fs.installMu.Unlock()
+
+	if ok {
+		fs.Remove(id)
+	}
+	return ok
+}
+
+// Type returns the type of the installed filter with the given id or
+// UnknownFilter if there is no such filter.
+func (fs *FilterSystem) Type(id int) Type {
+	fs.installMu.Lock()
+	defer fs.installMu.Unlock()
+
+	if inst, ok := fs.installed[id]; ok {
+		return inst.typ
+	}
+	return UnknownFilter
+}
+
+// Discarded returns the number of changes an installed filter dropped because
+// of the retention limits since the last call. The changes still retained are
+// returned by the next poll.
+func (fs *FilterSystem) Discarded(id int) int {
+	fs.installMu.Lock()
+	defer fs.installMu.Unlock()
+
+	inst, ok := fs.installed[id]
+	if !ok {
+		return 0
+	}
+	inst.deadline.Reset(FilterTimeout)
+	discarded := inst.discarded
+	inst.discarded = 0
+	return discarded
+}
+
+// LogChanges returns the logs collected by an installed log filter since the
+// last call and resets its deadline.
+func (fs *FilterSystem) LogChanges(id int) vm.Logs {
+	fs.installMu.Lock()
+	defer fs.installMu.Unlock()
+
+	inst, ok := fs.installed[id]
+	if !ok || inst.typ != LogFilter {
+		return nil
+	}
+	inst.deadline.Reset(FilterTimeout)
+	logs := inst.logs
+	inst.logs = nil
+	return logs
+}
+
+// HashChanges returns the hashes collected by an installed block or
+// transaction filter since the last call and resets its deadline.
+func (fs *FilterSystem) HashChanges(id int) []common.Hash {
+	fs.installMu.Lock()
+	defer fs.installMu.Unlock()
+
+	inst, ok := fs.installed[id]
+	if !ok || (inst.typ != BlockFilter && inst.typ != TransactionFilter) {
+		return nil
+	}
+	inst.deadline.Reset(FilterTimeout)
+	hashes := inst.hashes
+	inst.hashes = nil
+	return hashes
+}
+
+// ConfirmationChanges returns the events collected by an installed
+// confirmation filter since the last call and resets its deadline.
+func (fs *FilterSystem) ConfirmationChanges(id int) []ConfirmationEvent {
+	fs.installMu.Lock()
+	defer fs.installMu.Unlock()
+
+	inst, ok := fs.installed[id]
+	if !ok || inst.typ != ConfirmationFilter {
+		return nil
+	}
+	inst.deadline.Reset(FilterTimeout)
+	events := inst.events
+	inst.events = nil
+	return events
+}
+
+// DropChanges returns the transactions collected by an installed dropped
+// transaction filter since the last call and resets its deadline.
+func (fs *FilterSystem) DropChanges(id int) []core.TxDropEvent {
+	fs.installMu.Lock()
+	defer fs.installMu.Unlock()
+
+	inst, ok := fs.installed[id]
+	if !ok || inst.typ != DroppedTxFilter {
+		return nil
+	}
+	inst.deadline.Reset(FilterTimeout)
+	drops := inst.drops
+	inst.drops = nil
+	return drops
+}
+
+// filterLoop fires the block and log handlers of filters for new chain
+// blocks and for the logs added to and removed from the canonical chain.
+func (fs *FilterSystem) filterLoop(chain ChainEventSource) {
+	chainSub, logsSub, rmLogsSub := fs.chainSub, fs.logsSub, fs.rmLogsSub
+	for {
+		select {
+		case ev := <-fs.chainCh:
+			now := time.Now()
 			fs.filterMu.RLock()
 			for id, filter := range fs.filters {
-				if filter.BlockCallback != nil && fs.created[id].Before(event.Time) {
+				if filter.BlockCallback != nil && fs.created[id].Before(now) {
 					filter.BlockCallback(ev.Block, ev.Logs)
 				}
 			}
 			fs.filterMu.RUnlock()
 
-		case core.TxPreEvent:
+		case logs := <-fs.logsCh:
+			fs.filterLogs(logs)
+
+		case ev := <-fs.rmLogsCh:
+			fs.filterLogs(ev.Logs)
+
+		case err := <-chainSub.Err():
+			// The subscriptions are dropped if we fell behind.
+			glog.V(logger.Debug).Infoln("resubscribing to chain events:", err)
+			chainSub = chain.SubscribeChainEvent(fs.chainCh)
+
+		case err := <-logsSub.Err():
+			glog.V(logger.Debug).Infoln("resubscribing to log events:", err)
+			logsSub = chain.SubscribeLogsEvent(fs.logsCh)
+
+		case err := <-rmLogsSub.Err():
+			glog.V(logger.Debug).Infoln("resubscribing to removed log events:", err)
+			rmLogsSub = chain.SubscribeRemovedLogsEvent(fs.rmLogsCh)
+
+		case <-fs.quit:
+			chainSub.Unsubscribe()
+			logsSub.Unsubscribe()
+			rmLogsSub.Unsubscribe()
+			return
+		}
+	}
+}
+
+// filterLogs fires the log handlers of filters for the matching logs.
+func (fs *FilterSystem) filterLogs(logs vm.Logs) {
+	now := time.Now()
+	fs.filterMu.RLock()
+	defer fs.filterMu.RUnlock()
+
+	for id, filter := range fs.filters {
+		if filter.LogsCallback != nil && fs.created[id].Before(now) {
+			if msgs := filter.FilterLogs(logs); len(msgs) > 0 {
+				filter.LogsCallback(msgs)
+			}
+		}
+	}
+}
+
+// txLoop fires the transaction handlers of filters for new pending and
+// dropped transactions.
+func (fs *FilterSystem) txLoop(txpool TxEventSource) {
+	sub, dropSub := fs.txSub, fs.dropSub
+	for {
+		select {
+		case ev := <-fs.txCh:
+			now := time.Now()
 			fs.filterMu.RLock()
 			for id, filter := range fs.filters {
-				if filter.TransactionCallback != nil && fs.created[id].Before(event.Time) {
+				if filter.TransactionCallback != nil && fs.created[id].Before(now) {
 					filter.TransactionCallback(ev.Tx)
 				}
 			}
 			fs.filterMu.RUnlock()
 
-		case vm.Logs:
+		case ev := <-fs.dropCh:
+			now := time.Now()
 			fs.filterMu.RLock()
 			for id, filter := range fs.filters {
-				if filter.LogsCallback != nil && fs.created[id].Before(event.Time) {
-					msgs := filter.FilterLogs(ev)
-					if len(msgs) > 0 {
-						filter.LogsCallback(msgs)
-					}
+				if filter.DropCallback != nil && fs.created[id].Before(now) {
+					filter.DropCallback(ev.Tx, ev.Reason)
 				}
 			}
 			fs.filterMu.RUnlock()
+
+		case err := <-sub.Err():
+			// The subscription is dropped if we fell behind.
+			glog.V(logger.Debug).Infoln("resubscribing to transaction events:", err)
+			sub = txpool.SubscribeTxPreEvent(fs.txCh)
+
+		case err := <-dropSub.Err():
+			glog.V(logger.Debug).Infoln("resubscribing to dropped transaction events:", err)
+			dropSub = txpool.SubscribeTxDropEvent(fs.dropCh)
+
+		case <-fs.quit:
+			sub.Unsubscribe()
+			dropSub.Unsubscribe()
+			return
 		}
 	}
 }
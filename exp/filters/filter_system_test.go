@@ -0,0 +1,343 @@
+// Copyright 2015 The go-expanse Authors
+// This file is part of the go-expanse library.
+//
+// The go-expanse library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-expanse library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-expanse library. If not, see <http://www.gnu.org/licenses/>.
+
+package filters
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/expanse-project/go-expanse/common"
+	"github.com/expanse-project/go-expanse/core"
+	"github.com/expanse-project/go-expanse/core/state"
+	"github.com/expanse-project/go-expanse/core/types"
+	"github.com/expanse-project/go-expanse/core/vm"
+	"github.com/expanse-project/go-expanse/ethdb"
+	"github.com/expanse-project/go-expanse/event"
+)
+
+// testHead is the chain head the test transaction pools are created at.
+var testHead = types.NewBlock(&types.Header{Number: new(big.Int)}, nil, nil, nil)
+
+// testChain provides the chain events followed by the test filter systems and
+// transaction pools.
+type testChain struct {
+	chainFeed     event.Feed
+	chainHeadFeed event.Feed
+	logsFeed      event.Feed
+	rmLogsFeed    event.Feed
+	rmTxFeed      event.Feed
+}
+
+func (c *testChain) SubscribeChainEvent(ch chan<- core.ChainEvent) event.FeedSubscription {
+	return c.chainFeed.Subscribe(ch)
+}
+
+func (c *testChain) SubscribeChainHeadEvent(ch chan<- core.ChainHeadEvent) event.FeedSubscription {
+	return c.chainHeadFeed.Subscribe(ch)
+}
+
+func (c *testChain) SubscribeLogsEvent(ch chan<- vm.Logs) event.FeedSubscription {
+	return c.logsFeed.Subscribe(ch)
+}
+
+func (c *testChain) SubscribeRemovedLogsEvent(ch chan<- core.RemovedLogsEvent) event.FeedSubscription {
+	return c.rmLogsFeed.Subscribe(ch)
+}
+
+func (c *testChain) SubscribeRemovedTransactionEvent(ch chan<- core.RemovedTransactionEvent) event.FeedSubscription {
+	return c.rmTxFeed.Subscribe(ch)
+}
+
+func newTestFilterSystem(t *testing.T) (*FilterSystem, *testChain, ethdb.Database) {
+	db, _ := ethdb.NewMemDatabase()
+	statedb, err := state.New(common.Hash{}, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	chain := new(testChain)
+	txpool := core.NewTxPool(chain, testHead, func() (*state.StateDB, error) { return statedb, nil }, func() *big.Int { return big.NewInt(1000000) }, func(common.Hash) bool { return false })
+	return NewFilterSystem(chain, txpool), chain, db
+}
+
+func TestInstalledFilterChanges(t *testing.T) {
+	fs, chain, db := newTestFilterSystem(t)
+	defer fs.Stop()
+
+	var (
+		addr  = common.BytesToAddress([]byte("addr"))
+		block = types.NewBlock(&types.Header{Number: big.NewInt(1)}, nil, nil, nil)
+	)
+	blockId := fs.Install(BlockFilter, New(db))
+	logFilter := New(db)
+	logFilter.SetAddresses([]common.Address{addr})
+	logId := fs.Install(LogFilter, logFilter)
+
+	if typ := fs.Type(blockId); typ != BlockFilter {
+		t.Errorf("expected block filter type, got %d", typ)
+	}
+	if typ := fs.Type(logId); typ != LogFilter {
+		t.Errorf("expected log filter type, got %d", typ)
+	}
+
+	time.Sleep(time.Millisecond)
+	chain.chainFeed.Send(core.ChainEvent{Block: block, Hash: block.Hash()})
+	chain.logsFeed.Send(vm.Logs{&vm.Log{Address: addr}, &vm.Log{Address: common.Address{}}})
+
+	// events are delivered asynchronously
+	var (
+		hashes []common.Hash
+		logs   vm.Logs
+	)
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline) && (len(hashes) == 0 || len(logs) == 0); {
+		hashes = append(hashes, fs.HashChanges(blockId)...)
+		logs = append(logs, fs.LogChanges(logId)...)
+		time.Sleep(10 * time.Millisecond)
+	}
+	if len(hashes) != 1 || hashes[0] != block.Hash() {
+		t.Errorf("expected block hash %x, got %x", block.Hash(), hashes)
+	}
+	if len(logs) != 1 || logs[0].Address != addr {
+		t.Errorf("expected 1 log for %x, got %v", addr, logs)
+	}
+	// changes are only returned once
+	if hashes := fs.HashChanges(blockId); len(hashes) != 0 {
+		t.Errorf("expected no new hashes, got %x", hashes)
+	}
+	// the wrong kind of changes are never returned
+	if logs := fs.LogChanges(blockId); logs != nil {
+		t.Errorf("expected no logs for block filter, got %v", logs)
+	}
+
+	if !fs.Uninstall(blockId) {
+		t.Error("expected block filter to be uninstalled")
+	}
+	if fs.Uninstall(blockId) {
+		t.Error("expected block filter to be uninstalled only once")
+	}
+	if fs.Get(blockId) != nil {
+		t.Error("expected uninstalled filter to be removed")
+	}
+}
+
+func TestInstalledFilterRemovedLogs(t *testing.T) {
+	fs, chain, db := newTestFilterSystem(t)
+	defer fs.Stop()
+
+	addr := common.BytesToAddress([]byte("addr"))
+	filter := New(db)
+	filter.SetAddresses([]common.Address{addr})
+	id := fs.Install(LogFilter, filter)
+
+	time.Sleep(time.Millisecond)
+	chain.rmLogsFeed.Send(core.RemovedLogsEvent{Logs: vm.Logs{&vm.Log{Address: addr, Removed: true}, &vm.Log{Removed: true}}})
+
+	var logs vm.Logs
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline) && len(logs) == 0; {
+		logs = append(logs, fs.LogChanges(id)...)
+		time.Sleep(10 * time.Millisecond)
+	}
+	if len(logs) != 1 || logs[0].Address != addr || !logs[0].Removed {
+		t.Errorf("expected 1 removed log for %x, got %v", addr, logs)
+	}
+}
+
+func TestInstalledFilterRetentionLimit(t *testing.T) {
+	defer func(limit int) { MaxFilterLogs = limit }(MaxFilterLogs)
+	MaxFilterLogs = 3
+
+	fs, chain, db := newTestFilterSystem(t)
+	defer fs.Stop()
+
+	id := fs.Install(LogFilter, New(db))
+	time.Sleep(time.Millisecond)
+
+	var logs vm.Logs
+	for i := 0; i < 5; i++ {
+		logs = append(logs, &vm.Log{Index: uint(i)})
+	}
+	chain.logsFeed.Send(logs)
+
+	var discarded int
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline) && discarded == 0; {
+		discarded = fs.Discarded(id)
+		time.Sleep(10 * time.Millisecond)
+	}
+	if discarded != 2 {
+		t.Fatalf("discarded mismatch: have %d, want 2", discarded)
+	}
+	if discarded := fs.Discarded(id); discarded != 0 {
+		t.Errorf("discarded not reset: have %d", discarded)
+	}
+	retained := fs.LogChanges(id)
+	if len(retained) != 3 || retained[0].Index != 2 || retained[2].Index != 4 {
+		t.Errorf("expected the 3 newest logs to be retained, got %v", retained)
+	}
+}
+
+func TestInstalledFilterTimeout(t *testing.T) {
+	defer func(timeout time.Duration) { FilterTimeout = timeout }(FilterTimeout)
+	FilterTimeout = 100 * time.Millisecond
+
+	fs, _, db := newTestFilterSystem(t)
+	defer fs.Stop()
+
+	polled := fs.Install(TransactionFilter, New(db))
+	abandoned := fs.Install(TransactionFilter, New(db))
+	for i := 0; i < 4; i++ {
+		time.Sleep(FilterTimeout / 2)
+		fs.HashChanges(polled)
+	}
+	if fs.Type(abandoned) != UnknownFilter || fs.Get(abandoned) != nil {
+		t.Error("expected abandoned filter to be uninstalled")
+	}
+	if fs.Type(polled) != TransactionFilter {
+		t.Error("expected polled filter to remain installed")
+	}
+}
+
+func TestInstalledFilterIds(t *testing.T) {
+	fs, _, db := newTestFilterSystem(t)
+
+	ids := make(map[int]bool)
+	for i := 0; i < 16; i++ {
+		id := fs.Install(BlockFilter, New(db))
+		if id < 0 || ids[id] {
+			t.Fatalf("invalid or duplicate filter id %d", id)
+		}
+		ids[id] = true
+	}
+	if ids[0] && ids[1] {
+		t.Error("expected filter ids not to be sequential")
+	}
+
+	fs.Stop()
+	for id := range ids {
+		if fs.Type(id) != UnknownFilter || fs.Get(id) != nil {
+			t.Fatalf("expected filter %d to be removed on stop", id)
+		}
+	}
+}
+
+func TestConfirmationTracker(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+
+	tx := types.NewTransaction(0, common.Address{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), nil)
+	include := func(number int64, extra string) *types.Block {
+		block := types.NewBlock(&types.Header{Number: big.NewInt(number), Extra: []byte(extra)}, []*types.Transaction{tx}, nil, nil)
+		core.WriteTransactions(db, block)
+		core.WriteCanonicalHash(db, block.Hash(), block.NumberU64())
+		return block
+	}
+	tracker := &confirmationTracker{db: db, tx: tx.Hash(), required: 2}
+
+	if events := tracker.update(1); len(events) != 0 {
+		t.Fatalf("expected no events for unknown transaction, got %v", events)
+	}
+	block := include(1, "a")
+	if events := tracker.update(2); len(events) != 0 {
+		t.Fatalf("expected no events before enough confirmations, got %v", events)
+	}
+	events := tracker.update(3)
+	if len(events) != 1 || events[0].Status != TxConfirmed || events[0].BlockHash != block.Hash() || events[0].Confirmations != 2 {
+		t.Fatalf("expected confirmation in block %x, got %v", block.Hash(), events)
+	}
+	if events := tracker.update(4); len(events) != 0 {
+		t.Fatalf("expected confirmation to be reported only once, got %v", events)
+	}
+
+	// Replace the including block, the transaction ends up one block later.
+	core.WriteCanonicalHash(db, common.Hash{1}, 1)
+	core.DeleteTransaction(db, tx.Hash())
+	events = tracker.update(4)
+	if len(events) != 1 || events[0].Status != TxReorged || events[0].BlockHash != block.Hash() {
+		t.Fatalf("expected block %x to be reported reorged, got %v", block.Hash(), events)
+	}
+	reincluded := include(2, "b")
+	events = tracker.update(4)
+	if len(events) != 1 || events[0].Status != TxConfirmed || events[0].BlockHash != reincluded.Hash() {
+		t.Fatalf("expected confirmation in block %x, got %v", reincluded.Hash(), events)
+	}
+}
+
+func TestInstalledConfirmationFilter(t *testing.T) {
+	fs, chain, db := newTestFilterSystem(t)
+	defer fs.Stop()
+
+	tx := types.NewTransaction(0, common.Address{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), nil)
+	block := types.NewBlock(&types.Header{Number: big.NewInt(1)}, []*types.Transaction{tx}, nil, nil)
+	head := types.NewBlock(&types.Header{Number: big.NewInt(2)}, nil, nil, nil)
+	core.WriteTransactions(db, block)
+	core.WriteCanonicalHash(db, block.Hash(), 1)
+	core.WriteHeader(db, head.Header())
+	core.WriteHeadBlockHash(db, head.Hash())
+
+	// Already confirmed transactions are reported right away.
+	id := fs.InstallConfirmation(New(db), tx.Hash(), 1)
+	if typ := fs.Type(id); typ != ConfirmationFilter {
+		t.Errorf("expected confirmation filter type, got %d", typ)
+	}
+	events := fs.ConfirmationChanges(id)
+	if len(events) != 1 || events[0].Status != TxConfirmed {
+		t.Fatalf("expected confirmation event, got %v", events)
+	}
+
+	// Orphaning the block is reported on the next chain event.
+	core.WriteCanonicalHash(db, common.Hash{1}, 1)
+	core.DeleteTransaction(db, tx.Hash())
+	time.Sleep(time.Millisecond)
+	chain.chainFeed.Send(core.ChainEvent{Block: head, Hash: head.Hash()})
+
+	events = nil
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline) && len(events) == 0; {
+		events = fs.ConfirmationChanges(id)
+		time.Sleep(10 * time.Millisecond)
+	}
+	if len(events) != 1 || events[0].Status != TxReorged || events[0].BlockHash != block.Hash() {
+		t.Fatalf("expected reorg event for block %x, got %v", block.Hash(), events)
+	}
+}
+
+func TestInstalledDroppedTxFilter(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+	statedb, _ := state.New(common.Hash{}, db)
+	chain := new(testChain)
+	txpool := core.NewTxPool(chain, testHead, func() (*state.StateDB, error) { return statedb, nil }, func() *big.Int { return big.NewInt(1000000) }, func(common.Hash) bool { return false })
+	fs := NewFilterSystem(chain, txpool)
+	defer fs.Stop()
+
+	id := fs.Install(DroppedTxFilter, New(db))
+	if typ := fs.Type(id); typ != DroppedTxFilter {
+		t.Errorf("expected dropped transaction filter type, got %d", typ)
+	}
+
+	tx := types.NewTransaction(0, common.Address{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), nil)
+	time.Sleep(time.Millisecond)
+	txpool.DropTransactions(types.Transactions{tx}, core.TxUnderpriced)
+
+	var drops []core.TxDropEvent
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline) && len(drops) == 0; {
+		drops = fs.DropChanges(id)
+		time.Sleep(10 * time.Millisecond)
+	}
+	if len(drops) != 1 || drops[0].Tx.Hash() != tx.Hash() || drops[0].Reason != core.TxUnderpriced {
+		t.Fatalf("expected underpriced drop of %x, got %v", tx.Hash(), drops)
+	}
+	if drops := fs.DropChanges(id); len(drops) != 0 {
+		t.Errorf("expected no new drops, got %v", drops)
+	}
+}
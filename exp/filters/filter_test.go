@@ -1,7 +1,9 @@
 package filters
 
 import (
+	"context"
 	"io/ioutil"
+	"math"
 	"math/big"
 	"os"
 	"testing"
@@ -263,4 +265,50 @@ func TestFilters(t *testing.T) {
 	if len(logs) != 0 {
 		t.Error("expected 0 log, got", len(logs))
 	}
+
+	// Searches spanning several batches must return the logs in block order,
+	// also if the range extends past the head of the chain.
+	defer func(n int) { logWorkers = n }(logWorkers)
+	logWorkers = 4
+	for _, end := range []int64{-1, 5000} {
+		filter = New(db)
+		filter.SetTopics([][]common.Hash{[]common.Hash{hash1, hash2, hash3, hash4}})
+		filter.SetBeginBlock(0)
+		filter.SetEndBlock(end)
+		logs = filter.Find()
+		if len(logs) != 4 {
+			t.Fatalf("end %d: expected 4 log, got %d", end, len(logs))
+		}
+		for i, hash := range []common.Hash{hash1, hash2, hash3, hash4} {
+			if logs[i].Topics[0] != hash {
+				t.Errorf("end %d: expected log[%d].Topics[0] to be %x, got %x", end, i, hash, logs[i].Topics[0])
+			}
+		}
+	}
+
+	// Ranges must not be sized by the requested end, but by the head
+	filter = New(db)
+	filter.SetTopics([][]common.Hash{[]common.Hash{hash1}})
+	filter.SetBeginBlock(0)
+	filter.SetEndBlock(math.MaxInt64)
+	if logs, err := filter.FindContext(context.Background()); err != nil || len(logs) != 1 {
+		t.Errorf("unbounded end: expected 1 log, got %d (err %v)", len(logs), err)
+	}
+	filter = New(db)
+	filter.SetBeginBlock(10)
+	filter.SetEndBlock(5)
+	if _, err := filter.FindContext(context.Background()); err != ErrInvalidRange {
+		t.Errorf("inverted range: expected ErrInvalidRange, got %v", err)
+	}
+
+	// A cancelled context must abort the search.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	filter = New(db)
+	filter.SetTopics([][]common.Hash{[]common.Hash{hash1, hash2}})
+	filter.SetBeginBlock(0)
+	filter.SetEndBlock(-1)
+	if _, err := filter.FindContext(ctx); err != context.Canceled {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
 }
@@ -101,15 +101,22 @@ func (self *GasPriceOracle) processPastBlocks(chain *core.BlockChain) {
 }
 
 func (self *GasPriceOracle) listenLoop() {
-	events := self.exp.EventMux().Subscribe(core.ChainEvent{}, core.ChainSplitEvent{})
-	defer events.Unsubscribe()
-
-	for event := range events.Chan() {
-		switch event := event.Data.(type) {
-		case core.ChainEvent:
-			self.processBlock(event.Block)
-		case core.ChainSplitEvent:
-			self.processBlock(event.Block)
+	chain := self.exp.BlockChain()
+	chainCh := make(chan core.ChainEvent, core.ChainChanSize)
+	sub := chain.SubscribeChainEvent(chainCh)
+	defer func() { sub.Unsubscribe() }()
+
+	for {
+		select {
+		case ev := <-chainCh:
+			self.processBlock(ev.Block)
+		case err := <-sub.Err():
+			// The subscription is only dropped if we fell behind, a few
+			// skipped blocks hardly change the price estimate.
+			glog.V(logger.Debug).Infoln("resubscribing to chain events:", err)
+			sub = chain.SubscribeChainEvent(chainCh)
+		case <-self.exp.shutdownChan:
+			return
 		}
 	}
 }
@@ -166,7 +173,7 @@ func (self *GasPriceOracle) processBlock(block *types.Block) {
 func (self *GasPriceOracle) lowestPrice(block *types.Block) *big.Int {
 	gasUsed := big.NewInt(0)
 
-	receipts := core.GetBlockReceipts(self.exp.ChainDb(), block.Hash())
+	receipts := self.exp.BlockChain().GetBlockReceipts(block.Hash())
 	if len(receipts) > 0 {
 		if cgu := receipts[len(receipts)-1].CumulativeGasUsed; cgu != nil {
 			gasUsed = receipts[len(receipts)-1].CumulativeGasUsed
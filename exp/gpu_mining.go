@@ -27,6 +27,7 @@ import (
 
 	"github.com/expanse-project/ethash"
 	"github.com/expanse-project/go-expanse/common"
+	"github.com/expanse-project/go-expanse/core"
 	"github.com/expanse-project/go-expanse/core/types"
 	"github.com/expanse-project/go-expanse/logger"
 	"github.com/expanse-project/go-expanse/logger/glog"
@@ -43,6 +44,9 @@ func (s *Expanse) StartMining(threads int, gpus string) error {
 
 	// GPU mining
 	if gpus != "" {
+		if s.clique != nil {
+			return fmt.Errorf("GPU mining not possible with proof-of-authority")
+		}
 		var ids []int
 		for _, s := range strings.Split(gpus, ",") {
 			i, err := strconv.Atoi(s)
@@ -57,12 +61,13 @@ func (s *Expanse) StartMining(threads int, gpus string) error {
 
 		// TODO: re-creating miner is a bit ugly
 		cl := ethash.NewCL(ids)
-		s.miner = miner.New(s, s.EventMux(), cl)
+		s.miner = miner.New(s, s.EventMux(), core.NewPowEngine(cl))
 		go s.miner.Start(eb, len(ids))
 		return nil
 	}
 
 	// CPU mining
+	s.authorizeSigner(eb)
 	go s.miner.Start(eb, threads)
 	return nil
 }
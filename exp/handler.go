@@ -35,7 +35,7 @@ import (
 	"github.com/expanse-project/go-expanse/logger/glog"
 	"github.com/expanse-project/go-expanse/p2p"
 	"github.com/expanse-project/go-expanse/p2p/discover"
-	"github.com/expanse-project/go-expanse/pow"
+	"github.com/expanse-project/go-expanse/params"
 	"github.com/expanse-project/go-expanse/rlp"
 )
 
@@ -60,6 +60,7 @@ type ProtocolManager struct {
 
 	fastSync   bool
 	txpool     txPool
+	miner      blockMiner
 	blockchain *core.BlockChain
 	chaindb    ethdb.Database
 
@@ -70,8 +71,10 @@ type ProtocolManager struct {
 	SubProtocols []p2p.Protocol
 
 	eventMux      *event.TypeMux
-	txSub         event.Subscription
-	minedBlockSub event.Subscription
+	txCh          chan core.TxPreEvent
+	txSub         event.FeedSubscription
+	minedCh       chan core.NewMinedBlockEvent
+	minedBlockSub event.FeedSubscription
 
 	// channels for fetcher, syncer, txsyncLoop
 	newPeerCh chan *peer
@@ -86,7 +89,7 @@ type ProtocolManager struct {
 
 // NewProtocolManager returns a new ethereum sub protocol manager. The Ethereum sub protocol manages peers capable
 // with the ethereum network.
-func NewProtocolManager(fastSync bool, networkId int, mux *event.TypeMux, txpool txPool, pow pow.PoW, blockchain *core.BlockChain, chaindb ethdb.Database) (*ProtocolManager, error) {
+func NewProtocolManager(fastSync bool, networkId int, mux *event.TypeMux, txpool txPool, miner blockMiner, blockchain *core.BlockChain, chaindb ethdb.Database) (*ProtocolManager, error) {
 	// Figure out whether to allow fast sync or not
 	if fastSync && blockchain.CurrentBlock().NumberU64() > 0 {
 		glog.V(logger.Info).Infof("blockchain not empty, fast sync disabled")
@@ -98,6 +101,7 @@ func NewProtocolManager(fastSync bool, networkId int, mux *event.TypeMux, txpool
 		fastSync:   fastSync,
 		eventMux:   mux,
 		txpool:     txpool,
+		miner:      miner,
 		blockchain: blockchain,
 		chaindb:    chaindb,
 		peers:      newPeerSet(),
@@ -146,7 +150,7 @@ func NewProtocolManager(fastSync bool, networkId int, mux *event.TypeMux, txpool
 		manager.removePeer)
 
 	validator := func(block *types.Block, parent *types.Block) error {
-		return core.ValidateHeader(pow, block.Header(), parent.Header(), true, false)
+		return core.ValidateHeader(blockchain.Engine(), block.Header(), parent.Header(), true, false)
 	}
 	heighter := func() uint64 {
 		return blockchain.CurrentBlock().NumberU64()
@@ -177,10 +181,12 @@ func (pm *ProtocolManager) removePeer(id string) {
 
 func (pm *ProtocolManager) Start() {
 	// broadcast transactions
-	pm.txSub = pm.eventMux.Subscribe(core.TxPreEvent{})
+	pm.txCh = make(chan core.TxPreEvent, core.TxChanSize)
+	pm.txSub = pm.txpool.SubscribeTxPreEvent(pm.txCh)
 	go pm.txBroadcastLoop()
 	// broadcast mined blocks
-	pm.minedBlockSub = pm.eventMux.Subscribe(core.NewMinedBlockEvent{})
+	pm.minedCh = make(chan core.NewMinedBlockEvent, core.ChainChanSize)
+	pm.minedBlockSub = pm.miner.SubscribeNewMinedBlockEvent(pm.minedCh)
 	go pm.minedBroadcastLoop()
 
 	// start sync handlers
@@ -222,6 +228,10 @@ func (pm *ProtocolManager) handle(p *peer) error {
 	if rw, ok := p.rw.(*meteredMsgReadWriter); ok {
 		rw.Init(p.version)
 	}
+	// The status only carries the head hash, resolve its number if known locally
+	if header := pm.blockchain.GetHeader(p.Head()); header != nil {
+		p.SetHead(header.Hash(), header.Number.Uint64())
+	}
 	// Register the peer locally
 	glog.V(logger.Detail).Infof("%v: adding peer", p)
 	if err := pm.peers.Register(p); err != nil {
@@ -336,12 +346,9 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 			bytes  common.StorageSize
 			blocks []*types.Block
 		)
-		for len(blocks) < downloader.MaxBlockFetch && bytes < softResponseLimit {
+		for len(blocks) < downloader.MaxBlockFetch && bytes < softResponseLimit && msgStream.MoreDataInList() {
 			//Retrieve the hash of the next block
-			err := msgStream.Decode(&hash)
-			if err == rlp.EOL {
-				break
-			} else if err != nil {
+			if err := msgStream.Decode(&hash); err != nil {
 				return errResp(ErrDecode, "msg %v: %v", msg, err)
 			}
 			// Retrieve the requested block, stopping if enough was found
@@ -463,11 +470,9 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 			bytes  int
 			bodies []rlp.RawValue
 		)
-		for bytes < softResponseLimit && len(bodies) < downloader.MaxBlockFetch {
+		for bytes < softResponseLimit && len(bodies) < downloader.MaxBlockFetch && msgStream.MoreDataInList() {
 			// Retrieve the hash of the next block
-			if err := msgStream.Decode(&hash); err == rlp.EOL {
-				break
-			} else if err != nil {
+			if err := msgStream.Decode(&hash); err != nil {
 				return errResp(ErrDecode, "msg %v: %v", msg, err)
 			}
 			// Retrieve the requested block body, stopping if enough was found
@@ -512,11 +517,9 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 			bytes int
 			data  [][]byte
 		)
-		for bytes < softResponseLimit && len(data) < downloader.MaxStateFetch {
+		for bytes < softResponseLimit && len(data) < downloader.MaxStateFetch && msgStream.MoreDataInList() {
 			// Retrieve the hash of the next state entry
-			if err := msgStream.Decode(&hash); err == rlp.EOL {
-				break
-			} else if err != nil {
+			if err := msgStream.Decode(&hash); err != nil {
 				return errResp(ErrDecode, "msg %v: %v", msg, err)
 			}
 			// Retrieve the requested state entry, stopping if enough was found
@@ -550,11 +553,9 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 			bytes    int
 			receipts []rlp.RawValue
 		)
-		for bytes < softResponseLimit && len(receipts) < downloader.MaxReceiptFetch {
+		for bytes < softResponseLimit && len(receipts) < downloader.MaxReceiptFetch && msgStream.MoreDataInList() {
 			// Retrieve the hash of the next block
-			if err := msgStream.Decode(&hash); err == rlp.EOL {
-				break
-			} else if err != nil {
+			if err := msgStream.Decode(&hash); err != nil {
 				return errResp(ErrDecode, "msg %v: %v", msg, err)
 			}
 			// Retrieve the requested block's receipts, skipping if unknown to us
@@ -615,8 +616,9 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 		// Mark the hashes as present at the remote node
 		for _, block := range announces {
 			p.MarkBlock(block.Hash)
-			p.SetHead(block.Hash)
+			p.SetHead(block.Hash, block.Number)
 		}
+		pm.updateLag()
 		// Schedule all the unknown hashes for retrieval
 		unknown := make([]announce, 0, len(announces))
 		for _, block := range announces {
@@ -645,7 +647,8 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 
 		// Mark the peer as owning the block and schedule it for import
 		p.MarkBlock(request.Block.Hash())
-		p.SetHead(request.Block.Hash())
+		p.SetHead(request.Block.Hash(), request.Block.NumberU64())
+		pm.updateLag()
 
 		pm.fetcher.Enqueue(p.id, request.Block)
 
@@ -671,7 +674,7 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 			}
 			p.MarkTransaction(tx.Hash())
 		}
-		pm.txpool.AddTransactions(txs)
+		pm.txpool.AddTransactionsFrom(txs, core.TxOrigin{Transport: core.TxOriginP2P, Remote: p.id})
 
 	default:
 		return errResp(ErrInvalidMsgCode, "%v", msg.Code)
@@ -729,21 +732,47 @@ func (pm *ProtocolManager) BroadcastTx(hash common.Hash, tx *types.Transaction)
 
 // Mined broadcast loop
 func (self *ProtocolManager) minedBroadcastLoop() {
-	// automatically stops if unsubscribe
-	for obj := range self.minedBlockSub.Chan() {
-		switch ev := obj.Data.(type) {
-		case core.NewMinedBlockEvent:
+	sub := self.minedBlockSub
+	for {
+		select {
+		case ev := <-self.minedCh:
 			self.BroadcastBlock(ev.Block, true)  // First propagate block to peers
 			self.BroadcastBlock(ev.Block, false) // Only then announce to the rest
+
+		// Err() channel will be closed when unsubscribing.
+		case err := <-sub.Err():
+			if err == nil {
+				return
+			}
+			glog.V(logger.Debug).Infoln("resubscribing to mined block events:", err)
+			sub = self.miner.SubscribeNewMinedBlockEvent(self.minedCh)
+
+		case <-self.quitSync:
+			sub.Unsubscribe()
+			return
 		}
 	}
 }
 
 func (self *ProtocolManager) txBroadcastLoop() {
-	// automatically stops if unsubscribe
-	for obj := range self.txSub.Chan() {
-		event := obj.Data.(core.TxPreEvent)
-		self.BroadcastTx(event.Tx.Hash(), event.Tx)
+	sub := self.txSub
+	for {
+		select {
+		case event := <-self.txCh:
+			self.BroadcastTx(event.Tx.Hash(), event.Tx)
+
+		// Err() channel will be closed when unsubscribing.
+		case err := <-sub.Err():
+			if err == nil {
+				return
+			}
+			glog.V(logger.Debug).Infoln("resubscribing to transaction events:", err)
+			sub = self.txpool.SubscribeTxPreEvent(self.txCh)
+
+		case <-self.quitSync:
+			sub.Unsubscribe()
+			return
+		}
 	}
 }
 
@@ -754,14 +783,30 @@ type EthNodeInfo struct {
 	Difficulty *big.Int `json:"difficulty"` // Total difficulty of the host's blockchain
 	Genesis    string   `json:"genesis"`    // SHA3 hash of the host's genesis block
 	Head       string   `json:"head"`       // SHA3 hash of the host's best owned block
+	Number     *big.Int `json:"number"`     // Number of the host's best owned block
+	Versions   []uint   `json:"versions"`   // Sub-protocol versions enabled on the host
+	Config     struct {
+		HomesteadBlock *big.Int `json:"homesteadBlock"`        // Block number of the Homestead transition
+		ChainId        *big.Int `json:"chainId,omitempty"`     // Chain identifier for replay protected signatures
+		EIP155Block    *big.Int `json:"eip155Block,omitempty"` // Block number from which replay protected signatures are valid
+	} `json:"config"`
 }
 
 // NodeInfo retrieves some protocol metadata about the running host node.
 func (self *ProtocolManager) NodeInfo() *EthNodeInfo {
-	return &EthNodeInfo{
+	head := self.blockchain.CurrentBlock()
+	info := &EthNodeInfo{
 		Network:    self.networkId,
-		Difficulty: self.blockchain.GetTd(self.blockchain.CurrentBlock().Hash()),
+		Difficulty: self.blockchain.GetTd(head.Hash()),
 		Genesis:    fmt.Sprintf("%x", self.blockchain.Genesis().Hash()),
-		Head:       fmt.Sprintf("%x", self.blockchain.CurrentBlock().Hash()),
+		Head:       fmt.Sprintf("%x", head.Hash()),
+		Number:     head.Number(),
+	}
+	for _, proto := range self.SubProtocols {
+		info.Versions = append(info.Versions, proto.Version)
 	}
+	info.Config.HomesteadBlock = params.HomesteadBlock
+	info.Config.ChainId = params.ChainId
+	info.Config.EIP155Block = params.EIP155Block
+	return info
 }
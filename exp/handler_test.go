@@ -5,6 +5,7 @@ import (
 	"math/big"
 	"math/rand"
 	"testing"
+	"time"
 
 	"github.com/expanse-project/go-expanse/common"
 	"github.com/expanse-project/go-expanse/core"
@@ -545,3 +546,43 @@ func testGetReceipt(t *testing.T, protocol int) {
 		t.Errorf("receipts mismatch: %v", err)
 	}
 }
+
+// Tests that the heads advertised by peers are tracked, both from the status
+// handshake and from later announcements.
+func TestNetworkHeads62(t *testing.T) { testNetworkHeads(t, 62) }
+func TestNetworkHeads63(t *testing.T) { testNetworkHeads(t, 63) }
+
+func testNetworkHeads(t *testing.T, protocol int) {
+	pm := newTestProtocolManagerMust(t, false, 5, nil, nil)
+
+	// A peer on the same head resolves its head number from the local chain
+	synced, _ := newTestPeer("synced", protocol, pm, true)
+	defer synced.close()
+
+	// A peer announcing a higher block puts the local chain behind
+	ahead, _ := newTestPeer("ahead", protocol, pm, true)
+	defer ahead.close()
+
+	hash := common.Hash{0x01}
+	if err := p2p.Send(ahead.app, NewBlockHashesMsg, newBlockHashesData{{Hash: hash, Number: 12}}); err != nil {
+		t.Fatalf("failed to announce block: %v", err)
+	}
+	var heads *NetworkHeads
+	for start := time.Now(); time.Since(start) < time.Second; time.Sleep(10 * time.Millisecond) {
+		if heads = pm.NetworkHeads(); heads.Highest == 12 {
+			break
+		}
+	}
+	if heads.Local != 5 || heads.Highest != 12 || heads.Behind != 7 {
+		t.Fatalf("heads mismatch: have local %d, highest %d, behind %d, want 5, 12, 7", heads.Local, heads.Highest, heads.Behind)
+	}
+	if len(heads.Peers) != 2 {
+		t.Fatalf("peer count mismatch: have %d, want 2", len(heads.Peers))
+	}
+	if heads.Peers[0].ID != ahead.id || heads.Peers[0].Head != hash {
+		t.Errorf("highest peer mismatch: have %s [%x], want %s [%x]", heads.Peers[0].ID, heads.Peers[0].Head, ahead.id, hash)
+	}
+	if heads.Peers[1].ID != synced.id || heads.Peers[1].Number != 5 {
+		t.Errorf("synced peer mismatch: have %s #%d, want %s #5", heads.Peers[1].ID, heads.Peers[1].Number, synced.id)
+	}
+}
@@ -0,0 +1,72 @@
+// Copyright 2016 The go-expanse Authors
+// This file is part of the go-expanse library.
+//
+// The go-expanse library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-expanse library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-expanse library. If not, see <http://www.gnu.org/licenses/>.
+
+package exp
+
+import "github.com/expanse-project/go-expanse/metrics"
+
+// NetworkHeads summarises the heads advertised by the connected peers against
+// the local head. If the local head stalls while the peers advance, Behind
+// grows; if the whole network stalls, Highest stops advancing too.
+type NetworkHeads struct {
+	Local   uint64     `json:"local"`   // Number of the local head block
+	Highest uint64     `json:"highest"` // Highest head number advertised by a peer
+	Median  uint64     `json:"median"`  // Median head number of the peers with a known head number
+	Behind  uint64     `json:"behind"`  // Number of blocks the local head trails Highest by
+	Peers   []PeerHead `json:"peers"`   // Heads of the individual peers, highest first
+}
+
+// NetworkHeads returns the heads advertised by the connected peers.
+func (pm *ProtocolManager) NetworkHeads() *NetworkHeads {
+	heads := &NetworkHeads{
+		Local: pm.blockchain.CurrentBlock().NumberU64(),
+		Peers: pm.peers.Heads(),
+	}
+	known := 0
+	for _, head := range heads.Peers {
+		if head.Number > 0 {
+			known++
+		}
+	}
+	if known > 0 {
+		// Peers are sorted highest first, the unknown ones last
+		heads.Highest = heads.Peers[0].Number
+		heads.Median = heads.Peers[known/2].Number
+	}
+	if heads.Highest > heads.Local {
+		heads.Behind = heads.Highest - heads.Local
+	}
+	return heads
+}
+
+// updateLag updates the gauge of the number of blocks the local chain is
+// behind the network.
+func (pm *ProtocolManager) updateLag() {
+	if !metrics.Enabled {
+		return
+	}
+	var highest uint64
+	for _, head := range pm.peers.Heads() {
+		if head.Number > highest {
+			highest = head.Number
+		}
+	}
+	behind := int64(0)
+	if local := pm.blockchain.CurrentBlock().NumberU64(); highest > local {
+		behind = int64(highest - local)
+	}
+	syncBehindGauge.Update(behind)
+}
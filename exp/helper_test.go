@@ -34,13 +34,13 @@ func newTestProtocolManager(fastSync bool, blocks int, generator func(int, *core
 		pow           = new(core.FakePow)
 		db, _         = ethdb.NewMemDatabase()
 		genesis       = core.WriteGenesisBlockForTesting(db, core.GenesisAccount{testBankAddress, testBankFunds})
-		blockchain, _ = core.NewBlockChain(db, pow, evmux)
+		blockchain, _ = core.NewBlockChain(db, pow)
 	)
 	chain, _ := core.GenerateChain(genesis, db, blocks, generator)
 	if _, err := blockchain.InsertChain(chain); err != nil {
 		panic(err)
 	}
-	pm, err := NewProtocolManager(fastSync, NetworkId, evmux, &testTxPool{added: newtx}, pow, blockchain, db)
+	pm, err := NewProtocolManager(fastSync, NetworkId, evmux, &testTxPool{added: newtx}, new(testMiner), blockchain, db)
 	if err != nil {
 		return nil, err
 	}
@@ -62,15 +62,16 @@ func newTestProtocolManagerMust(t *testing.T, fastSync bool, blocks int, generat
 
 // testTxPool is a fake, helper transaction pool for testing purposes
 type testTxPool struct {
-	pool  []*types.Transaction        // Collection of all transactions
-	added chan<- []*types.Transaction // Notification channel for new transactions
+	pool   []*types.Transaction        // Collection of all transactions
+	added  chan<- []*types.Transaction // Notification channel for new transactions
+	txFeed event.Feed                  // Feed of new pending transactions (never sent on)
 
 	lock sync.RWMutex // Protects the transaction pool
 }
 
-// AddTransactions appends a batch of transactions to the pool, and notifies any
-// listeners if the addition channel is non nil
-func (p *testTxPool) AddTransactions(txs []*types.Transaction) {
+// AddTransactionsFrom appends a batch of transactions to the pool, and notifies
+// any listeners if the addition channel is non nil
+func (p *testTxPool) AddTransactionsFrom(txs []*types.Transaction, origin core.TxOrigin) {
 	p.lock.Lock()
 	defer p.lock.Unlock()
 
@@ -91,6 +92,22 @@ func (p *testTxPool) GetTransactions() types.Transactions {
 	return txs
 }
 
+// SubscribeTxPreEvent subscribes to new pending transactions. The test pool
+// doesn't send any, transactions are propagated by the initial sync only.
+func (p *testTxPool) SubscribeTxPreEvent(ch chan<- core.TxPreEvent) event.FeedSubscription {
+	return p.txFeed.Subscribe(ch)
+}
+
+// testMiner is a fake, helper miner for testing purposes, never sealing blocks.
+type testMiner struct {
+	minedFeed event.Feed // Feed of mined blocks (never sent on)
+}
+
+// SubscribeNewMinedBlockEvent subscribes to the blocks mined locally.
+func (m *testMiner) SubscribeNewMinedBlockEvent(ch chan<- core.NewMinedBlockEvent) event.FeedSubscription {
+	return m.minedFeed.Subscribe(ch)
+}
+
 // newTestTransaction create a new dummy transaction.
 func newTestTransaction(from *crypto.Key, nonce uint64, datasize int) *types.Transaction {
 	tx := types.NewTransaction(nonce, common.Address{}, big.NewInt(0), big.NewInt(100000), big.NewInt(0), make([]byte, datasize))
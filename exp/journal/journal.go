@@ -0,0 +1,241 @@
+// Copyright 2016 The go-expanse Authors
+// This file is part of the go-expanse library.
+//
+// The go-expanse library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-expanse library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-expanse library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package journal writes the progress of the canonical chain to a file or
+// named pipe, one JSON entry per line, for consumption by external indexers.
+package journal
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/expanse-project/go-expanse/common"
+	"github.com/expanse-project/go-expanse/common/hexutil"
+	"github.com/expanse-project/go-expanse/core"
+	"github.com/expanse-project/go-expanse/core/types"
+	"github.com/expanse-project/go-expanse/ethdb"
+	"github.com/expanse-project/go-expanse/event"
+	"github.com/expanse-project/go-expanse/logger"
+	"github.com/expanse-project/go-expanse/logger/glog"
+)
+
+const (
+	queueSize     = 1024 // entries buffered before new ones are dropped
+	maxReorgDepth = 1024 // maximum number of new canonical blocks journaled at once
+)
+
+// WriteTimeout is the time a reader of a named pipe has to accept an entry
+// before the journal drops it and reopens the pipe.
+var WriteTimeout = 5 * time.Second
+
+// Entry types.
+const (
+	HeadEntry      = "head"      // block became part of the canonical chain
+	ReorgEntry     = "reorg"     // blocks above the given one were dropped from the canonical chain
+	FinalizedEntry = "finalized" // block is buried under the configured number of blocks
+)
+
+type Entry struct {
+	Type   string         `json:"type"`
+	Number hexutil.Uint64 `json:"number"`
+	Hash   common.Hash    `json:"hash"`
+
+	// Set for head entries
+	ParentHash *common.Hash    `json:"parentHash,omitempty"`
+	Timestamp  *hexutil.Uint64 `json:"timestamp,omitempty"`
+
+	// Set for reorg entries, the previous head of the chain
+	OldNumber *hexutil.Uint64 `json:"oldNumber,omitempty"`
+	OldHash   *common.Hash    `json:"oldHash,omitempty"`
+}
+
+// headChain is the source of the chain head events journaled.
+type headChain interface {
+	SubscribeChainHeadEvent(ch chan<- core.ChainHeadEvent) event.FeedSubscription
+}
+
+type Journal struct {
+	path   string
+	db     ethdb.Database
+	depth  uint64 // confirmations after which a block is marked final, 0 to disable
+	chain  headChain
+	headCh chan core.ChainHeadEvent
+	sub    event.FeedSubscription
+	queue  chan *Entry
+	quit   chan struct{}
+
+	head      *types.Header // last canonical head journaled
+	nextFinal uint64        // first block number not yet marked final
+
+	wg sync.WaitGroup
+}
+
+// New creates a journal appending to the file or named pipe at path, starting
+// with the given head of the canonical chain. The file is only opened once
+// there's an entry to write, named pipes without a reader miss the entries.
+func New(path string, db ethdb.Database, chain headChain, head *types.Header, depth uint64) *Journal {
+	j := &Journal{
+		path:   path,
+		db:     db,
+		depth:  depth,
+		chain:  chain,
+		headCh: make(chan core.ChainHeadEvent, core.ChainChanSize),
+		queue:  make(chan *Entry, queueSize),
+		quit:   make(chan struct{}),
+	}
+	j.sub = chain.SubscribeChainHeadEvent(j.headCh)
+	if number := head.Number.Uint64(); depth > 0 && number >= depth {
+		j.nextFinal = number - depth
+	}
+	j.add(head)
+
+	j.wg.Add(2)
+	go j.loop()
+	go j.writeLoop()
+	return j
+}
+
+// Stop ends journaling, flushing the entries still queued.
+func (j *Journal) Stop() {
+	close(j.quit)
+	j.wg.Wait()
+}
+
+func (j *Journal) loop() {
+	defer j.wg.Done()
+	defer close(j.queue)
+	defer func() { j.sub.Unsubscribe() }()
+
+	for {
+		select {
+		case ev := <-j.headCh:
+			j.update(ev.Block.Header())
+		case err := <-j.sub.Err():
+			// The subscription is only dropped if we fell behind, the next
+			// head journals the skipped blocks.
+			glog.V(logger.Debug).Infof("Journal: resubscribing to chain head events: %v", err)
+			j.sub = j.chain.SubscribeChainHeadEvent(j.headCh)
+		case <-j.quit:
+			return
+		}
+	}
+}
+
+// update journals the change of the canonical head to header, including any
+// blocks skipped since the last head and the dropping of reorged ones.
+func (j *Journal) update(header *types.Header) {
+	if header.Hash() == j.head.Hash() {
+		return
+	}
+	if header.ParentHash == j.head.Hash() {
+		j.add(header)
+		return
+	}
+	ancestor := core.FindCommonAncestor(j.db, j.head, header)
+	if ancestor == nil {
+		glog.V(logger.Warn).Infof("Journal: no common ancestor of #%d [%x…] and #%d [%x…]", j.head.Number, j.head.Hash().Bytes()[:4], header.Number, header.Hash().Bytes()[:4])
+		j.add(header)
+		return
+	}
+	if ancestor.Hash() != j.head.Hash() {
+		oldNumber, oldHash := hexutil.Uint64(j.head.Number.Uint64()), j.head.Hash()
+		j.push(&Entry{
+			Type:      ReorgEntry,
+			Number:    hexutil.Uint64(ancestor.Number.Uint64()),
+			Hash:      ancestor.Hash(),
+			OldNumber: &oldNumber,
+			OldHash:   &oldHash,
+		})
+	}
+	var added []*types.Header
+	for h := header; h != nil && h.Hash() != ancestor.Hash(); h = core.GetHeader(j.db, h.ParentHash) {
+		if len(added) == maxReorgDepth {
+			glog.V(logger.Warn).Infof("Journal: skipping blocks below #%d, more than %d new", h.Number, maxReorgDepth)
+			break
+		}
+		added = append(added, h)
+	}
+	for i := len(added) - 1; i >= 0; i-- {
+		j.add(added[i])
+	}
+}
+
+// add journals header as the new canonical head, marking the blocks it
+// buried deep enough as final.
+func (j *Journal) add(header *types.Header) {
+	parent, timestamp := header.ParentHash, hexutil.Uint64(header.Time.Uint64())
+	j.push(&Entry{
+		Type:       HeadEntry,
+		Number:     hexutil.Uint64(header.Number.Uint64()),
+		Hash:       header.Hash(),
+		ParentHash: &parent,
+		Timestamp:  &timestamp,
+	})
+	j.head = header
+
+	if number := header.Number.Uint64(); j.depth > 0 && number >= j.depth {
+		for ; j.nextFinal <= number-j.depth; j.nextFinal++ {
+			hash := core.GetCanonicalHash(j.db, j.nextFinal)
+			if hash == (common.Hash{}) {
+				break
+			}
+			j.push(&Entry{Type: FinalizedEntry, Number: hexutil.Uint64(j.nextFinal), Hash: hash})
+		}
+	}
+}
+
+func (j *Journal) push(entry *Entry) {
+	select {
+	case j.queue <- entry:
+	default:
+		glog.V(logger.Warn).Infof("Journal: queue full, dropping %s entry for block #%d", entry.Type, entry.Number)
+	}
+}
+
+func (j *Journal) writeLoop() {
+	defer j.wg.Done()
+
+	var out *os.File
+	defer func() {
+		if out != nil {
+			out.Close()
+		}
+	}()
+	for entry := range j.queue {
+		line, err := json.Marshal(entry)
+		if err != nil {
+			glog.V(logger.Error).Infof("Journal: failed to encode entry: %v", err)
+			continue
+		}
+		if out == nil {
+			// Opening without blocking fails on named pipes lacking a reader
+			// instead of stalling the journal until one appears.
+			if out, err = os.OpenFile(j.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE|syscall.O_NONBLOCK, 0644); err != nil {
+				glog.V(logger.Debug).Infof("Journal: failed to open %s: %v", j.path, err)
+				continue
+			}
+		}
+		out.SetWriteDeadline(time.Now().Add(WriteTimeout))
+		if _, err := out.Write(append(line, '\n')); err != nil {
+			glog.V(logger.Debug).Infof("Journal: failed to write %s: %v", j.path, err)
+			out.Close()
+			out = nil
+		}
+	}
+}
@@ -0,0 +1,148 @@
+// Copyright 2016 The go-expanse Authors
+// This file is part of the go-expanse library.
+//
+// The go-expanse library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-expanse library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-expanse library. If not, see <http://www.gnu.org/licenses/>.
+
+package journal
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/expanse-project/go-expanse/common"
+	"github.com/expanse-project/go-expanse/core"
+	"github.com/expanse-project/go-expanse/core/types"
+	"github.com/expanse-project/go-expanse/ethdb"
+	"github.com/expanse-project/go-expanse/event"
+)
+
+// testChain provides the chain head events followed by the test journals.
+type testChain struct {
+	headFeed event.Feed
+}
+
+func (c *testChain) SubscribeChainHeadEvent(ch chan<- core.ChainHeadEvent) event.FeedSubscription {
+	return c.headFeed.Subscribe(ch)
+}
+
+// readJournal returns the entries of the journal at path, in short form.
+func readJournal(t *testing.T, path string) []string {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		t.Fatalf("failed to open journal: %v", err)
+	}
+	defer file.Close()
+
+	var entries []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			t.Fatalf("invalid entry %s: %v", scanner.Text(), err)
+		}
+		desc := fmt.Sprintf("%s %d %x", entry.Type, entry.Number, entry.Hash)
+		if entry.Type == ReorgEntry {
+			desc += fmt.Sprintf(" from %d %x", *entry.OldNumber, *entry.OldHash)
+		}
+		entries = append(entries, desc)
+	}
+	return entries
+}
+
+// waitJournal waits for the journal at path to contain n entries. Chain events
+// are delivered asynchronously, so the journal needs a moment to catch up.
+func waitJournal(t *testing.T, path string, n int) {
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if len(readJournal(t, path)) >= n {
+			return
+		}
+	}
+}
+
+// writeBlock stores a canonical block on top of parent.
+func writeBlock(db ethdb.Database, parent *types.Block, extra string) *types.Block {
+	header := &types.Header{
+		ParentHash: parent.Hash(),
+		Number:     new(big.Int).Add(parent.Number(), common.Big1),
+		Extra:      []byte(extra),
+	}
+	block := types.NewBlock(header, nil, nil, nil)
+	core.WriteBlock(db, block)
+	core.WriteCanonicalHash(db, block.Hash(), block.NumberU64())
+	return block
+}
+
+func TestJournal(t *testing.T) {
+	dir, err := ioutil.TempDir("", "journal-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "chain.journal")
+
+	db, _ := ethdb.NewMemDatabase()
+	chain := new(testChain)
+	genesis := writeBlock(db, types.NewBlock(&types.Header{Number: big.NewInt(-1)}, nil, nil, nil), "genesis")
+	b1 := writeBlock(db, genesis, "old")
+	b2 := writeBlock(db, b1, "old")
+	b3 := writeBlock(db, b2, "old")
+
+	j := New(path, db, chain, genesis.Header(), 2)
+	for _, block := range []*types.Block{b1, b2, b3} {
+		chain.headFeed.Send(core.ChainHeadEvent{Block: block})
+	}
+	waitJournal(t, path, 6)
+
+	// Reorganise to a longer fork, announcing only its head
+	f2 := writeBlock(db, b1, "new")
+	f3 := writeBlock(db, f2, "new")
+	f4 := writeBlock(db, f3, "new")
+	chain.headFeed.Send(core.ChainHeadEvent{Block: f4})
+
+	want := []string{
+		fmt.Sprintf("head 0 %x", genesis.Hash()),
+		fmt.Sprintf("head 1 %x", b1.Hash()),
+		fmt.Sprintf("head 2 %x", b2.Hash()),
+		fmt.Sprintf("finalized 0 %x", genesis.Hash()),
+		fmt.Sprintf("head 3 %x", b3.Hash()),
+		fmt.Sprintf("finalized 1 %x", b1.Hash()),
+		fmt.Sprintf("reorg 1 %x from 3 %x", b1.Hash(), b3.Hash()),
+		fmt.Sprintf("head 2 %x", f2.Hash()),
+		fmt.Sprintf("head 3 %x", f3.Hash()),
+		fmt.Sprintf("head 4 %x", f4.Hash()),
+		fmt.Sprintf("finalized 2 %x", f2.Hash()),
+	}
+	waitJournal(t, path, len(want))
+	j.Stop()
+
+	have := readJournal(t, path)
+	if len(have) != len(want) {
+		t.Fatalf("entry count mismatch: have %d, want %d\n%q", len(have), len(want), have)
+	}
+	for i := range want {
+		if have[i] != want[i] {
+			t.Errorf("entry %d mismatch:\nhave %s\nwant %s", i, have[i], want[i])
+		}
+	}
+}
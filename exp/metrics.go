@@ -62,6 +62,8 @@ var (
 	miscInTrafficMeter        = metrics.NewMeter("eth/misc/in/traffic")
 	miscOutPacketsMeter       = metrics.NewMeter("eth/misc/out/packets")
 	miscOutTrafficMeter       = metrics.NewMeter("eth/misc/out/traffic")
+
+	syncBehindGauge = metrics.NewGauge("eth/sync/behind") // blocks between the local head and the highest peer head
 )
 
 // meteredMsgReadWriter is a wrapper around a p2p.MsgReadWriter, capable of
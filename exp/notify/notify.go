@@ -0,0 +1,411 @@
+// Copyright 2016 The go-expanse Authors
+// This file is part of the go-expanse library.
+//
+// The go-expanse library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-expanse library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-expanse library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package notify implements webhook notifications for watched addresses.
+//
+// Operators register callback URLs together with the addresses they are
+// interested in. For every block added to the canonical chain, the transactions
+// sent from or to a watched address and the logs emitted by it are POSTed to the
+// registered URL as JSON. When blocks are removed from the canonical chain by a
+// reorganisation, their previously reported contents are sent again with the
+// removed flag set.
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/expanse-project/go-expanse/common"
+	"github.com/expanse-project/go-expanse/common/hexutil"
+	"github.com/expanse-project/go-expanse/core"
+	"github.com/expanse-project/go-expanse/core/types"
+	"github.com/expanse-project/go-expanse/ethdb"
+	"github.com/expanse-project/go-expanse/event"
+	"github.com/expanse-project/go-expanse/logger"
+	"github.com/expanse-project/go-expanse/logger/glog"
+)
+
+const (
+	queueSize     = 256  // notifications buffered per hook before new ones are dropped
+	maxReorgDepth = 1024 // maximum number of blocks walked back to find a common ancestor
+)
+
+var (
+	// RequestTimeout is the time a webhook endpoint has to answer a request.
+	RequestTimeout = 10 * time.Second
+
+	// RetryDelay is the delay before the first redelivery of a failed
+	// notification. It doubles with every further attempt.
+	RetryDelay = time.Second
+
+	// MaxRetries is the number of redeliveries of a failed notification
+	// before it is dropped.
+	MaxRetries = 3
+)
+
+var errInvalidURL = errors.New("webhook URL must be an absolute http or https URL")
+
+// Hook is a webhook registration.
+type Hook struct {
+	URL       string           `json:"url"`
+	Addresses []common.Address `json:"addresses"`
+}
+
+// Notification is the payload POSTed to webhooks for a block containing
+// transactions or logs of watched addresses.
+type Notification struct {
+	BlockHash    common.Hash       `json:"blockHash"`
+	BlockNumber  hexutil.Uint64    `json:"blockNumber"`
+	Removed      bool              `json:"removed"`
+	Transactions []TxNotification  `json:"transactions"`
+	Logs         []LogNotification `json:"logs"`
+}
+
+// TxNotification describes a transaction sent from or to a watched address.
+type TxNotification struct {
+	Hash  common.Hash     `json:"hash"`
+	From  common.Address  `json:"from"`
+	To    *common.Address `json:"to"`
+	Value *hexutil.Big    `json:"value"`
+}
+
+// LogNotification describes a log emitted by a watched address.
+type LogNotification struct {
+	Address  common.Address `json:"address"`
+	Topics   []common.Hash  `json:"topics"`
+	Data     hexutil.Bytes  `json:"data"`
+	TxHash   common.Hash    `json:"transactionHash"`
+	LogIndex hexutil.Uint64 `json:"logIndex"`
+}
+
+// hook is a registered webhook with its delivery queue.
+type hook struct {
+	url   string
+	addrs map[common.Address]struct{}
+	queue chan *Notification
+	quit  chan struct{}
+}
+
+// blockChain is the source of the chain events processed by the notifier.
+type blockChain interface {
+	SubscribeChainEvent(ch chan<- core.ChainEvent) event.FeedSubscription
+}
+
+// Notifier follows the canonical chain and notifies the registered webhooks
+// about the blocks touching their addresses.
+type Notifier struct {
+	db      ethdb.Database
+	chain   blockChain
+	chainCh chan core.ChainEvent
+	sub     event.FeedSubscription
+	quit    chan struct{}
+	client  *http.Client
+
+	mu    sync.RWMutex
+	hooks map[string]*hook
+
+	head *types.Header // last block processed
+	wg   sync.WaitGroup
+}
+
+// New creates a notifier processing the chain events of chain.
+func New(db ethdb.Database, chain blockChain) *Notifier {
+	n := &Notifier{
+		db:      db,
+		chain:   chain,
+		chainCh: make(chan core.ChainEvent, core.ChainChanSize),
+		quit:    make(chan struct{}),
+		client:  &http.Client{Timeout: RequestTimeout},
+		hooks:   make(map[string]*hook),
+	}
+	n.sub = chain.SubscribeChainEvent(n.chainCh)
+	n.wg.Add(1)
+	go n.loop()
+	return n
+}
+
+// Stop terminates the notifier. Pending notifications are discarded.
+func (n *Notifier) Stop() {
+	close(n.quit)
+	n.wg.Wait()
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	for rawurl, h := range n.hooks {
+		close(h.quit)
+		delete(n.hooks, rawurl)
+	}
+}
+
+// Register adds a webhook for the given addresses. Registering a URL again
+// replaces its addresses.
+func (n *Notifier) Register(rawurl string, addrs []common.Address) error {
+	if u, err := url.Parse(rawurl); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errInvalidURL
+	}
+	set := make(map[common.Address]struct{}, len(addrs))
+	for _, addr := range addrs {
+		set[addr] = struct{}{}
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if h, ok := n.hooks[rawurl]; ok {
+		h.addrs = set
+		return nil
+	}
+	h := &hook{
+		url:   rawurl,
+		addrs: set,
+		queue: make(chan *Notification, queueSize),
+		quit:  make(chan struct{}),
+	}
+	n.hooks[rawurl] = h
+	go h.loop(n.client)
+
+	glog.V(logger.Info).Infof("Registered webhook %s for %d addresses", rawurl, len(addrs))
+	return nil
+}
+
+// Unregister removes a webhook. It reports whether the webhook was registered.
+func (n *Notifier) Unregister(rawurl string) bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	h, ok := n.hooks[rawurl]
+	if ok {
+		close(h.quit)
+		delete(n.hooks, rawurl)
+	}
+	return ok
+}
+
+// Hooks returns the registered webhooks ordered by URL.
+func (n *Notifier) Hooks() []Hook {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+
+	hooks := make([]Hook, 0, len(n.hooks))
+	for _, h := range n.hooks {
+		addrs := make([]common.Address, 0, len(h.addrs))
+		for addr := range h.addrs {
+			addrs = append(addrs, addr)
+		}
+		sort.Sort(addressesByHex(addrs))
+		hooks = append(hooks, Hook{URL: h.url, Addresses: addrs})
+	}
+	sort.Sort(hooksByURL(hooks))
+	return hooks
+}
+
+func (n *Notifier) loop() {
+	defer n.wg.Done()
+	defer func() { n.sub.Unsubscribe() }()
+
+	for {
+		select {
+		case ev := <-n.chainCh:
+			n.update(ev.Block)
+		case err := <-n.sub.Err():
+			// The subscription is only dropped if we fell behind, the next
+			// block notifies about the skipped ones.
+			glog.V(logger.Debug).Infof("Webhooks: resubscribing to chain events: %v", err)
+			n.sub = n.chain.SubscribeChainEvent(n.chainCh)
+		case <-n.quit:
+			return
+		}
+	}
+}
+
+// update processes the new canonical head block.
+func (n *Notifier) update(block *types.Block) {
+	removed, added := n.diff(block)
+	for _, b := range removed {
+		n.notify(b, true)
+	}
+	for _, b := range added {
+		n.notify(b, false)
+	}
+	n.head = block.Header()
+}
+
+// diff returns the blocks dropped from and added to the canonical chain when
+// the head moves from the last processed block to block. Removed blocks are
+// returned newest first, added ones oldest first.
+func (n *Notifier) diff(block *types.Block) (removed, added []*types.Block) {
+	if n.head == nil || block.ParentHash() == n.head.Hash() {
+		return nil, []*types.Block{block}
+	}
+	var (
+		oldHeader = n.head
+		newHeader = block.Header()
+		newChain  []*types.Header
+	)
+	for i := 0; oldHeader.Hash() != newHeader.Hash(); i++ {
+		if i == maxReorgDepth {
+			glog.V(logger.Warn).Infof("Webhooks: no common ancestor of #%d [%x…] and #%d [%x…] within %d blocks", n.head.Number, n.head.Hash().Bytes()[:4], block.Number(), block.Hash().Bytes()[:4], maxReorgDepth)
+			return nil, []*types.Block{block}
+		}
+		if oldHeader.Number.Cmp(newHeader.Number) >= 0 {
+			if b := core.GetBlock(n.db, oldHeader.Hash()); b != nil {
+				removed = append(removed, b)
+			}
+			oldHeader = core.GetHeader(n.db, oldHeader.ParentHash)
+		} else {
+			newChain = append(newChain, newHeader)
+			newHeader = core.GetHeader(n.db, newHeader.ParentHash)
+		}
+		if oldHeader == nil || newHeader == nil {
+			return removed, []*types.Block{block}
+		}
+	}
+	for i := len(newChain) - 1; i >= 0; i-- {
+		if b := core.GetBlock(n.db, newChain[i].Hash()); b != nil {
+			added = append(added, b)
+		} else if newChain[i].Hash() == block.Hash() {
+			added = append(added, block)
+		}
+	}
+	return removed, added
+}
+
+// notify queues a notification for every webhook watching an address touched
+// by block.
+func (n *Notifier) notify(block *types.Block, removed bool) {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+
+	if len(n.hooks) == 0 {
+		return
+	}
+	receipts := core.GetBlockReceipts(n.db, block.Hash())
+	for _, h := range n.hooks {
+		note := h.match(block, receipts)
+		if note == nil {
+			continue
+		}
+		note.Removed = removed
+		select {
+		case h.queue <- note:
+		default:
+			glog.V(logger.Warn).Infof("Webhook %s: queue full, dropping notification for block #%d", h.url, block.Number())
+		}
+	}
+}
+
+// match collects the transactions and logs of block relevant to the hook. It
+// returns nil if there are none.
+func (h *hook) match(block *types.Block, receipts types.Receipts) *Notification {
+	var (
+		txs  []TxNotification
+		logs []LogNotification
+	)
+	for _, tx := range block.Transactions() {
+		from, _ := tx.From()
+		_, watchFrom := h.addrs[from]
+		watchTo := false
+		if to := tx.To(); to != nil {
+			_, watchTo = h.addrs[*to]
+		}
+		if watchFrom || watchTo {
+			txs = append(txs, TxNotification{Hash: tx.Hash(), From: from, To: tx.To(), Value: (*hexutil.Big)(tx.Value())})
+		}
+	}
+	for _, receipt := range receipts {
+		for _, log := range receipt.Logs {
+			if _, ok := h.addrs[log.Address]; ok {
+				logs = append(logs, LogNotification{Address: log.Address, Topics: log.Topics, Data: log.Data, TxHash: log.TxHash, LogIndex: hexutil.Uint64(log.Index)})
+			}
+		}
+	}
+	if len(txs) == 0 && len(logs) == 0 {
+		return nil
+	}
+	return &Notification{
+		BlockHash:    block.Hash(),
+		BlockNumber:  hexutil.Uint64(block.NumberU64()),
+		Transactions: txs,
+		Logs:         logs,
+	}
+}
+
+// loop delivers the queued notifications of the hook in order.
+func (h *hook) loop(client *http.Client) {
+	for {
+		select {
+		case note := <-h.queue:
+			h.deliver(client, note)
+		case <-h.quit:
+			return
+		}
+	}
+}
+
+// deliver POSTs a notification, retrying with increasing delays on failure.
+func (h *hook) deliver(client *http.Client, note *Notification) {
+	payload, err := json.Marshal(note)
+	if err != nil {
+		glog.V(logger.Error).Infof("Webhook %s: can't encode notification: %v", h.url, err)
+		return
+	}
+	for attempt := 0; ; attempt++ {
+		if err = post(client, h.url, payload); err == nil {
+			return
+		}
+		if attempt == MaxRetries {
+			glog.V(logger.Warn).Infof("Webhook %s: dropping notification for block #%d: %v", h.url, note.BlockNumber, err)
+			return
+		}
+		glog.V(logger.Debug).Infof("Webhook %s: delivery failed, retrying: %v", h.url, err)
+		select {
+		case <-time.After(RetryDelay << uint(attempt)):
+		case <-h.quit:
+			return
+		}
+	}
+}
+
+func post(client *http.Client, endpoint string, payload []byte) error {
+	resp, err := client.Post(endpoint, "application/json", bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected response status %s", resp.Status)
+	}
+	return nil
+}
+
+type hooksByURL []Hook
+
+func (s hooksByURL) Len() int           { return len(s) }
+func (s hooksByURL) Less(i, j int) bool { return s[i].URL < s[j].URL }
+func (s hooksByURL) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+
+type addressesByHex []common.Address
+
+func (s addressesByHex) Len() int           { return len(s) }
+func (s addressesByHex) Less(i, j int) bool { return bytes.Compare(s[i][:], s[j][:]) < 0 }
+func (s addressesByHex) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
@@ -0,0 +1,195 @@
+// Copyright 2016 The go-expanse Authors
+// This file is part of the go-expanse library.
+//
+// The go-expanse library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-expanse library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-expanse library. If not, see <http://www.gnu.org/licenses/>.
+
+package notify
+
+import (
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/expanse-project/go-expanse/common"
+	"github.com/expanse-project/go-expanse/core"
+	"github.com/expanse-project/go-expanse/core/types"
+	"github.com/expanse-project/go-expanse/core/vm"
+	"github.com/expanse-project/go-expanse/crypto"
+	"github.com/expanse-project/go-expanse/ethdb"
+	"github.com/expanse-project/go-expanse/event"
+)
+
+var (
+	testKey, _ = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+	testAddr   = crypto.PubkeyToAddress(testKey.PublicKey)
+)
+
+// testChain provides the chain events followed by the test notifiers.
+type testChain struct {
+	chainFeed event.Feed
+}
+
+func (c *testChain) SubscribeChainEvent(ch chan<- core.ChainEvent) event.FeedSubscription {
+	return c.chainFeed.Subscribe(ch)
+}
+
+// testServer records the notifications POSTed to it.
+func testServer(t *testing.T) (*httptest.Server, chan *Notification) {
+	notes := make(chan *Notification, 16)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		note := new(Notification)
+		if err := json.NewDecoder(r.Body).Decode(note); err != nil {
+			t.Errorf("invalid notification: %v", err)
+		}
+		notes <- note
+	}))
+	return srv, notes
+}
+
+func waitNotification(t *testing.T, notes chan *Notification) *Notification {
+	select {
+	case note := <-notes:
+		return note
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for notification")
+	}
+	return nil
+}
+
+// writeBlock stores a block on top of parent, along with receipts carrying logs
+// emitted by the given addresses.
+func writeBlock(db ethdb.Database, parent *types.Block, extra string, txs []*types.Transaction, logAddrs ...common.Address) *types.Block {
+	var receipts types.Receipts
+	if len(logAddrs) > 0 {
+		receipt := types.NewReceipt(nil, new(big.Int))
+		for i, addr := range logAddrs {
+			receipt.Logs = append(receipt.Logs, &vm.Log{Address: addr, Index: uint(i)})
+		}
+		receipts = append(receipts, receipt)
+	}
+	header := &types.Header{
+		ParentHash: parent.Hash(),
+		Number:     new(big.Int).Add(parent.Number(), common.Big1),
+		Extra:      []byte(extra),
+	}
+	block := types.NewBlock(header, txs, nil, receipts)
+	core.WriteBlock(db, block)
+	core.WriteBlockReceipts(db, block.Hash(), receipts)
+	return block
+}
+
+func TestNotifyReorg(t *testing.T) {
+	srv, notes := testServer(t)
+	defer srv.Close()
+
+	db, _ := ethdb.NewMemDatabase()
+	n := New(db, new(testChain))
+	defer n.Stop()
+	if err := n.Register(srv.URL, []common.Address{testAddr}); err != nil {
+		t.Fatal(err)
+	}
+
+	tx, _ := types.NewTransaction(0, common.Address{1}, big.NewInt(1), big.NewInt(21000), big.NewInt(1), nil).SignECDSA(testKey)
+	genesis := writeBlock(db, types.NewBlock(&types.Header{Number: big.NewInt(-1)}, nil, nil, nil), "genesis", nil)
+	b1 := writeBlock(db, genesis, "old", []*types.Transaction{tx})
+	b2 := writeBlock(db, b1, "old", nil, common.Address{2})
+
+	n.update(genesis)
+	n.update(b1)
+	n.update(b2)
+	note := waitNotification(t, notes)
+	if note.BlockHash != b1.Hash() || note.Removed || len(note.Transactions) != 1 || note.Transactions[0].From != testAddr {
+		t.Fatalf("unexpected notification for block 1: %+v", note)
+	}
+
+	// Reorganise to a longer fork containing a log of the watched address.
+	f1 := writeBlock(db, genesis, "new", nil)
+	f2 := writeBlock(db, f1, "new", nil, testAddr)
+	f3 := writeBlock(db, f2, "new", nil)
+	n.update(f3)
+
+	note = waitNotification(t, notes)
+	if note.BlockHash != b1.Hash() || !note.Removed {
+		t.Fatalf("expected removal of block 1, got %+v", note)
+	}
+	note = waitNotification(t, notes)
+	if note.BlockHash != f2.Hash() || note.Removed || len(note.Logs) != 1 || note.Logs[0].Address != testAddr {
+		t.Fatalf("unexpected notification for fork block 2: %+v", note)
+	}
+	select {
+	case note := <-notes:
+		t.Fatalf("unexpected notification: %+v", note)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestNotifyRetry(t *testing.T) {
+	defer func(delay time.Duration) { RetryDelay = delay }(RetryDelay)
+	RetryDelay = time.Millisecond
+
+	var requests int32
+	delivered := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) < 3 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		close(delivered)
+	}))
+	defer srv.Close()
+
+	db, _ := ethdb.NewMemDatabase()
+	n := New(db, new(testChain))
+	defer n.Stop()
+	n.Register(srv.URL, []common.Address{testAddr})
+
+	genesis := writeBlock(db, types.NewBlock(&types.Header{Number: big.NewInt(-1)}, nil, nil, nil), "genesis", nil, testAddr)
+	n.update(genesis)
+
+	select {
+	case <-delivered:
+	case <-time.After(time.Second):
+		t.Fatalf("notification not delivered after %d attempts", atomic.LoadInt32(&requests))
+	}
+}
+
+func TestRegister(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+	n := New(db, new(testChain))
+	defer n.Stop()
+
+	for _, url := range []string{"", "ftp://example.com", "example.com/hook", "http://"} {
+		if err := n.Register(url, nil); err != errInvalidURL {
+			t.Errorf("%q: expected invalid URL error, got %v", url, err)
+		}
+	}
+	n.Register("http://b.example.com", []common.Address{{2}, {1}})
+	n.Register("http://a.example.com", nil)
+	n.Register("http://b.example.com", []common.Address{{3}})
+
+	hooks := n.Hooks()
+	if len(hooks) != 2 || hooks[0].URL != "http://a.example.com" || hooks[1].URL != "http://b.example.com" {
+		t.Fatalf("unexpected hooks: %v", hooks)
+	}
+	if len(hooks[1].Addresses) != 1 || hooks[1].Addresses[0] != (common.Address{3}) {
+		t.Errorf("expected re-registration to replace addresses, got %x", hooks[1].Addresses)
+	}
+	if !n.Unregister("http://a.example.com") || n.Unregister("http://a.example.com") {
+		t.Error("expected hook to be unregistered exactly once")
+	}
+}
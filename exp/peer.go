@@ -20,6 +20,7 @@ import (
 	"errors"
 	"fmt"
 	"math/big"
+	"sort"
 	"sync"
 	"time"
 
@@ -51,6 +52,7 @@ type PeerInfo struct {
 	Version    int      `json:"version"`    // Ethereum protocol version negotiated
 	Difficulty *big.Int `json:"difficulty"` // Total difficulty of the peer's blockchain
 	Head       string   `json:"head"`       // SHA3 hash of the peer's best owned block
+	Number     uint64   `json:"number"`     // Number of the peer's best owned block, 0 if unknown
 }
 
 type peer struct {
@@ -59,10 +61,12 @@ type peer struct {
 	*p2p.Peer
 	rw p2p.MsgReadWriter
 
-	version int // Protocol version negotiated
-	head    common.Hash
-	td      *big.Int
-	lock    sync.RWMutex
+	version  int // Protocol version negotiated
+	head     common.Hash
+	number   uint64    // Number of the head block, 0 if not known yet
+	headSeen time.Time // Time the head was last updated
+	td       *big.Int
+	lock     sync.RWMutex
 
 	knownTxs    *set.Set // Set of transaction hashes known to be known by this peer
 	knownBlocks *set.Set // Set of block hashes known to be known by this peer
@@ -87,6 +91,7 @@ func (p *peer) Info() *PeerInfo {
 		Version:    p.version,
 		Difficulty: p.Td(),
 		Head:       fmt.Sprintf("%x", p.Head()),
+		Number:     p.HeadNumber(),
 	}
 }
 
@@ -99,12 +104,26 @@ func (p *peer) Head() (hash common.Hash) {
 	return hash
 }
 
-// SetHead updates the head (most recent) hash of the peer.
-func (p *peer) SetHead(hash common.Hash) {
+// SetHead updates the head (most recent) hash and number of the peer. A zero
+// number, as announced by eth/61 peers, keeps the previously known number.
+func (p *peer) SetHead(hash common.Hash, number uint64) {
 	p.lock.Lock()
 	defer p.lock.Unlock()
 
 	copy(p.head[:], hash[:])
+	if number > 0 {
+		p.number = number
+	}
+	p.headSeen = time.Now()
+}
+
+// HeadNumber retrieves the number of the head block of the peer, or 0 if it
+// isn't known yet.
+func (p *peer) HeadNumber() uint64 {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	return p.number
 }
 
 // Td retrieves the current total difficulty of a peer.
@@ -204,7 +223,7 @@ func (p *peer) SendBlockBodies(bodies []*blockBody) error {
 // SendBlockBodiesRLP sends a batch of block contents to the remote peer from
 // an already RLP encoded format.
 func (p *peer) SendBlockBodiesRLP(bodies []rlp.RawValue) error {
-	return p2p.Send(p.rw, BlockBodiesMsg, bodies)
+	return p2p.SendRawList(p.rw, BlockBodiesMsg, bodies)
 }
 
 // SendNodeDataRLP sends a batch of arbitrary internal data, corresponding to the
@@ -216,7 +235,7 @@ func (p *peer) SendNodeData(data [][]byte) error {
 // SendReceiptsRLP sends a batch of transaction receipts, corresponding to the
 // ones requested from an already RLP encoded format.
 func (p *peer) SendReceiptsRLP(receipts []rlp.RawValue) error {
-	return p2p.Send(p.rw, ReceiptsMsg, receipts)
+	return p2p.SendRawList(p.rw, ReceiptsMsg, receipts)
 }
 
 // RequestHashes fetches a batch of hashes from a peer, starting at from, going
@@ -312,7 +331,7 @@ func (p *peer) Handshake(network int, td *big.Int, head common.Hash, genesis com
 			return p2p.DiscReadTimeout
 		}
 	}
-	p.td, p.head = status.TD, status.CurrentBlock
+	p.td, p.head, p.headSeen = status.TD, status.CurrentBlock, time.Now()
 	return nil
 }
 
@@ -452,3 +471,39 @@ func (ps *peerSet) BestPeer() *peer {
 	}
 	return bestPeer
 }
+
+// PeerHead is the head block last advertised by a peer.
+type PeerHead struct {
+	ID      string      `json:"id"`
+	Head    common.Hash `json:"head"`
+	Number  uint64      `json:"number"` // 0 if not known yet
+	Td      *big.Int    `json:"td"`
+	Updated time.Time   `json:"updated"`
+}
+
+// Heads retrieves the heads advertised by the peers, highest first.
+func (ps *peerSet) Heads() []PeerHead {
+	ps.lock.RLock()
+	defer ps.lock.RUnlock()
+
+	heads := make([]PeerHead, 0, len(ps.peers))
+	for _, p := range ps.peers {
+		p.lock.RLock()
+		heads = append(heads, PeerHead{
+			ID:      p.id,
+			Head:    p.head,
+			Number:  p.number,
+			Td:      new(big.Int).Set(p.td),
+			Updated: p.headSeen,
+		})
+		p.lock.RUnlock()
+	}
+	sort.Sort(headsByNumber(heads))
+	return heads
+}
+
+type headsByNumber []PeerHead
+
+func (h headsByNumber) Len() int           { return len(h) }
+func (h headsByNumber) Less(i, j int) bool { return h[i].Number > h[j].Number }
+func (h headsByNumber) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
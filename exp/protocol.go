@@ -22,7 +22,9 @@ import (
 	"math/big"
 
 	"github.com/expanse-project/go-expanse/common"
+	"github.com/expanse-project/go-expanse/core"
 	"github.com/expanse-project/go-expanse/core/types"
+	"github.com/expanse-project/go-expanse/event"
 	"github.com/expanse-project/go-expanse/rlp"
 )
 
@@ -109,12 +111,23 @@ var errorToString = map[int]string{
 }
 
 type txPool interface {
-	// AddTransactions should add the given transactions to the pool.
-	AddTransactions([]*types.Transaction)
+	// AddTransactionsFrom should add the given transactions received from
+	// origin to the pool.
+	AddTransactionsFrom([]*types.Transaction, core.TxOrigin)
 
 	// GetTransactions should return pending transactions.
 	// The slice should be modifiable by the caller.
 	GetTransactions() types.Transactions
+
+	// SubscribeTxPreEvent should return an event subscription of
+	// TxPreEvent and send events to the given channel.
+	SubscribeTxPreEvent(chan<- core.TxPreEvent) event.FeedSubscription
+}
+
+type blockMiner interface {
+	// SubscribeNewMinedBlockEvent should return an event subscription of
+	// NewMinedBlockEvent and send events to the given channel.
+	SubscribeNewMinedBlockEvent(chan<- core.NewMinedBlockEvent) event.FeedSubscription
 }
 
 type chainManager interface {
@@ -24,6 +24,7 @@ import (
 	"time"
 
 	"github.com/expanse-project/go-expanse/common"
+	"github.com/expanse-project/go-expanse/core"
 	"github.com/expanse-project/go-expanse/core/types"
 	"github.com/expanse-project/go-expanse/crypto"
 	"github.com/expanse-project/go-expanse/p2p"
@@ -133,7 +134,7 @@ func testSendTransactions(t *testing.T, protocol int) {
 	for nonce := range alltxs {
 		alltxs[nonce] = newTestTransaction(testAccount, uint64(nonce), txsize)
 	}
-	pm.txpool.AddTransactions(alltxs)
+	pm.txpool.AddTransactionsFrom(alltxs, core.TxOrigin{Transport: core.TxOriginLocal})
 
 	// Connect several peers. They should all receive the pending transactions.
 	var wg sync.WaitGroup
@@ -147,6 +147,7 @@ func (pm *ProtocolManager) syncer() {
 		case <-forceSync:
 			// Force a sync even if not enough peers are present
 			go pm.synchronise(pm.peers.BestPeer())
+			pm.updateLag()
 
 		case <-pm.quitSync:
 			return
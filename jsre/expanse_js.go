@@ -2660,14 +2660,18 @@ AllSolidityEvents.prototype.decode = function (data) {
     data.data = data.data || '';
     data.topics = data.topics || [];
 
+    if (data.topics.length === 0) { // anonymous event, nothing to match against
+        return formatters.outputLogFormatter(data);
+    }
+
     var eventTopic = data.topics[0].slice(2);
     var match = this._json.filter(function (j) {
-        return eventTopic === sha3(utils.transformToFullName(j));
+        return !j.anonymous && eventTopic === sha3(utils.transformToFullName(j));
     })[0];
 
     if (!match) { // cannot find matching event?
         console.warn('cannot find event for log');
-        return data;
+        return formatters.outputLogFormatter(data);
     }
 
     var event = new SolidityEvent(match, this._address);
@@ -3112,6 +3116,21 @@ var sha3 = require('../utils/sha3');
 var Filter = require('./filter');
 var watches = require('./methods/watches');
 
+/**
+ * Indexed params of dynamic type (strings, bytes and arrays) are stored in
+ * the topics as the sha3 of their value, so they can only be decoded as bytes32
+ *
+ * @method indexedType
+ * @param {String} type
+ * @return {String} type that should be used to decode the topic
+ */
+var indexedType = function (type) {
+    if (type === 'string' || type === 'bytes' || type.slice(-1) === ']') {
+        return 'bytes32';
+    }
+    return type;
+};
+
 /**
  * This prototype should be used to create event filters
  */
@@ -3228,7 +3247,7 @@ SolidityEvent.prototype.decode = function (data) {
 
     var argTopics = this._anonymous ? data.topics : data.topics.slice(1);
     var indexedData = argTopics.map(function (topics) { return topics.slice(2); }).join("");
-    var indexedParams = coder.decodeParams(this.types(true), indexedData);
+    var indexedParams = coder.decodeParams(this.types(true).map(indexedType), indexedData);
 
     var notIndexedData = data.data.slice(2);
     var notIndexedParams = coder.decodeParams(this.types(false), notIndexedData);
@@ -3285,7 +3304,7 @@ SolidityEvent.prototype.attachToContract = function (contract) {
     if (!contract[displayName]) {
         contract[displayName] = execute;
     }
-    contract[displayName][this.typeName()] = this.execute.bind(this, contract);
+    contract[displayName][this.typeName()] = execute;
 };
 
 module.exports = SolidityEvent;
@@ -3952,13 +3971,93 @@ SolidityFunction.prototype.call = function () {
 SolidityFunction.prototype.sendTransaction = function () {
     var args = Array.prototype.slice.call(arguments).filter(function (a) {return a !== undefined; });
     var callback = this.extractCallback(args);
+
+    if (!callback) {
+        return web3.exp.sendTransaction(this.buildTransaction.apply(this, args));
+    }
+
+    args.push(function (error, payload) {
+        if (error) {
+            return callback(error);
+        }
+        web3.exp.sendTransaction(payload, callback);
+    });
+    this.buildTransaction.apply(this, args);
+};
+
+/**
+ * Should be used to build the transaction invoking the solidity function
+ * without sending it. If no gas is given in the options, the gas needed is
+ * estimated first, so failing estimates are reported before sending.
+ *
+ * @method buildTransaction
+ * @param {...Object} Contract function arguments
+ * @param {function} If the last argument is a function, the transaction will
+ *   be built asynchronously
+ * @return {Object} transaction object with the encoded call data
+ */
+SolidityFunction.prototype.buildTransaction = function () {
+    var args = Array.prototype.slice.call(arguments).filter(function (a) {return a !== undefined; });
+    var callback = this.extractCallback(args);
     var payload = this.toPayload(args);
 
+    if (payload.gas !== undefined) {
+        if (!callback) {
+            return payload;
+        }
+        return callback(null, payload);
+    }
+    if (!callback) {
+        payload.gas = web3.exp.estimateGas(payload);
+        return payload;
+    }
+    web3.exp.estimateGas(payload, function (error, gas) {
+        if (error) {
+            return callback(error);
+        }
+        payload.gas = gas;
+        callback(null, payload);
+    });
+};
+
+/**
+ * Should be used to send a transaction to solidity function, returning the
+ * decoded value the function is expected to return along with the hash. The
+ * value is obtained by calling the function against the pending state just
+ * before sending, so it may differ from the value of the mined transaction.
+ *
+ * @method transact
+ * @param {...Object} Contract function arguments
+ * @return {Object} transaction hash, gas and decoded return value
+ */
+SolidityFunction.prototype.transact = function () {
+    var args = Array.prototype.slice.call(arguments).filter(function (a) {return a !== undefined; });
+    var callback = this.extractCallback(args);
+    var self = this;
+
     if (!callback) {
-        return web3.exp.sendTransaction(payload);
+        var payload = this.buildTransaction.apply(this, args);
+        var result = this.unpackOutput(web3.exp.call(payload, 'pending'));
+        return {transactionHash: web3.exp.sendTransaction(payload), gas: payload.gas, result: result};
     }
 
-    web3.exp.sendTransaction(payload, callback);
+    args.push(function (error, payload) {
+        if (error) {
+            return callback(error);
+        }
+        web3.exp.call(payload, 'pending', function (error, output) {
+            if (error) {
+                return callback(error);
+            }
+            web3.exp.sendTransaction(payload, function (error, hash) {
+                if (error) {
+                    return callback(error);
+                }
+                callback(null, {transactionHash: hash, gas: payload.gas, result: self.unpackOutput(output)});
+            });
+        });
+    });
+    this.buildTransaction.apply(this, args);
 };
 
 /**
@@ -3968,7 +4067,7 @@ SolidityFunction.prototype.sendTransaction = function () {
  * @param {Object} options
  */
 SolidityFunction.prototype.estimateGas = function () {
-    var args = Array.prototype.slice.call(arguments);
+    var args = Array.prototype.slice.call(arguments).filter(function (a) {return a !== undefined; });
     var callback = this.extractCallback(args);
     var payload = this.toPayload(args);
 
@@ -4048,6 +4147,8 @@ SolidityFunction.prototype.attachToContract = function (contract) {
     execute.call = this.call.bind(this);
     execute.sendTransaction = this.sendTransaction.bind(this);
     execute.estimateGas = this.estimateGas.bind(this);
+    execute.buildTransaction = this.buildTransaction.bind(this);
+    execute.transact = this.transact.bind(this);
     var displayName = this.displayName();
     if (!contract[displayName]) {
         contract[displayName] = execute;
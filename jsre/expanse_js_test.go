@@ -0,0 +1,157 @@
+// Copyright 2015 The go-expanse Authors
+// This file is part of the go-expanse library.
+//
+// The go-expanse library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-expanse library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-expanse library. If not, see <http://www.gnu.org/licenses/>.
+
+package jsre
+
+import "testing"
+
+func TestWeb3EventDecoding(t *testing.T) {
+	jsre := New("")
+	defer jsre.Stop(false)
+
+	if err := jsre.Compile("bignumber.js", BigNumber_JS); err != nil {
+		t.Fatalf("cannot load bignumber.js: %v", err)
+	}
+	if err := jsre.Compile("expanse.js", Web3_JS); err != nil {
+		t.Fatalf("cannot load expanse.js: %v", err)
+	}
+	// a provider answering the filter calls with a single canned Deposit log
+	_, err := jsre.Run(`
+		var web3 = require('web3');
+		var abi = [{"type": "event", "name": "Deposit", "anonymous": false, "inputs": [
+			{"name": "from", "type": "address", "indexed": true},
+			{"name": "tag", "type": "string", "indexed": true},
+			{"name": "value", "type": "uint256", "indexed": false}]}];
+		var log = {
+			address: "0x1234567890123456789012345678901234567890",
+			topics: [
+				"0x" + web3.sha3("Deposit(address,string,uint256)"),
+				"0x000000000000000000000000abcdefabcdefabcdefabcdefabcdefabcdefabcd",
+				"0x1111111111111111111111111111111111111111111111111111111111111111"],
+			data: "0x000000000000000000000000000000000000000000000000000000000000002a",
+			blockNumber: "0x1", transactionIndex: "0x0", logIndex: "0x0"
+		};
+		var respond = function (payload) {
+			var result = payload.method === "eth_getFilterLogs" ? [log] : "0x1";
+			return {jsonrpc: "2.0", id: payload.id, result: result};
+		};
+		web3.setProvider({
+			send: respond,
+			sendAsync: function (payload, cb) { cb(null, respond(payload)); }
+		});
+		var token = web3.exp.contract(abi).at(log.address);
+		var decoded = token.Deposit().get()[0];
+		var all = token.allEvents().get()[0];
+	`)
+	if err != nil {
+		t.Fatalf("cannot set up contract filter: %v", err)
+	}
+
+	tests := map[string]string{
+		"decoded.event":                 "Deposit",
+		"decoded.args.from":             "0xabcdefabcdefabcdefabcdefabcdefabcdefabcd",
+		"decoded.args.tag":              "0x1111111111111111111111111111111111111111111111111111111111111111",
+		"decoded.args.value.toString()": "42",
+		"decoded.blockNumber":           "1",
+		"all.event":                     "Deposit",
+		"all.args.value.toString()":     "42",
+	}
+	for expr, want := range tests {
+		val, err := jsre.Run(expr)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", expr, err)
+			continue
+		}
+		if got, _ := val.ToString(); got != want {
+			t.Errorf("%s: got %q, want %q", expr, got, want)
+		}
+	}
+}
+
+func TestWeb3ContractTransact(t *testing.T) {
+	jsre := New("")
+	defer jsre.Stop(false)
+
+	if err := jsre.Compile("bignumber.js", BigNumber_JS); err != nil {
+		t.Fatalf("cannot load bignumber.js: %v", err)
+	}
+	if err := jsre.Compile("expanse.js", Web3_JS); err != nil {
+		t.Fatalf("cannot load expanse.js: %v", err)
+	}
+	// a provider recording the sent transactions, estimating 0x5208 gas and
+	// answering calls with the number 7
+	_, err := jsre.Run(`
+		var web3 = require('web3');
+		var abi = [{"type": "function", "name": "set", "constant": false,
+			"inputs": [{"name": "value", "type": "uint256"}],
+			"outputs": [{"name": "old", "type": "uint256"}]}];
+		var sent = [];
+		var respond = function (payload) {
+			var result;
+			switch (payload.method) {
+			case "eth_estimateGas":
+				result = "0x5208";
+				break;
+			case "eth_call":
+				result = "0x0000000000000000000000000000000000000000000000000000000000000007";
+				break;
+			case "eth_sendTransaction":
+				sent.push(payload.params[0]);
+				result = "0x01";
+				break;
+			}
+			return {jsonrpc: "2.0", id: payload.id, result: result};
+		};
+		web3.setProvider({
+			send: respond,
+			sendAsync: function (payload, cb) { cb(null, respond(payload)); }
+		});
+		var from = "0xabcdefabcdefabcdefabcdefabcdefabcdefabcd";
+		var contract = web3.exp.contract(abi).at("0x1234567890123456789012345678901234567890");
+
+		var built = contract.set.buildTransaction(42, {from: from});
+		var res = contract.set.transact(42, {from: from});
+		contract.set(1, {from: from, gas: 100000});
+		var async;
+		contract.set.transact(2, {from: from}, function (err, r) { async = r; });
+	`)
+	if err != nil {
+		t.Fatalf("cannot send contract transactions: %v", err)
+	}
+
+	tests := map[string]string{
+		"built.data":              "0x60fe47b1000000000000000000000000000000000000000000000000000000000000002a",
+		"built.gas":               "21000",
+		"res.transactionHash":     "0x01",
+		"res.gas":                 "21000",
+		"res.result.toString()":   "7",
+		"sent.length":             "3",
+		"sent[0].gas":             "0x5208",
+		"sent[1].gas":             "0x186a0",
+		"async.result.toString()": "7",
+		"sent[2].data.substr(-2)": "02",
+	}
+	for expr, want := range tests {
+		val, err := jsre.Run(expr)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", expr, err)
+			continue
+		}
+		if got, _ := val.ToString(); got != want {
+			t.Errorf("%s: got %q, want %q", expr, got, want)
+		}
+	}
+}
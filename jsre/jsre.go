@@ -14,7 +14,6 @@
 // You should have received a copy of the GNU Lesser General Public License
 // along with the go-expanse library. If not, see <http://www.gnu.org/licenses/>.
 
-// Package jsre provides execution environment for JavaScript.
 package jsre
 
 import (
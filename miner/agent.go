@@ -21,10 +21,9 @@ import (
 
 	"sync/atomic"
 
-	"github.com/expanse-project/go-expanse/common"
+	"github.com/expanse-project/go-expanse/core"
 	"github.com/expanse-project/go-expanse/logger"
 	"github.com/expanse-project/go-expanse/logger/glog"
-	"github.com/expanse-project/go-expanse/pow"
 )
 
 type CpuAgent struct {
@@ -35,23 +34,23 @@ type CpuAgent struct {
 	quitCurrentOp chan struct{}
 	returnCh      chan<- *Result
 
-	index int
-	pow   pow.PoW
+	index  int
+	engine core.Engine
 
 	isMining int32 // isMining indicates whether the agent is currently mining
 }
 
-func NewCpuAgent(index int, pow pow.PoW) *CpuAgent {
+func NewCpuAgent(index int, engine core.Engine) *CpuAgent {
 	miner := &CpuAgent{
-		pow:   pow,
-		index: index,
+		engine: engine,
+		index:  index,
 	}
 
 	return miner
 }
 
 func (self *CpuAgent) Work() chan<- *Work            { return self.workCh }
-func (self *CpuAgent) Pow() pow.PoW                  { return self.pow }
+func (self *CpuAgent) Engine() core.Engine           { return self.engine }
 func (self *CpuAgent) SetReturnCh(ch chan<- *Result) { self.returnCh = ch }
 
 func (self *CpuAgent) Stop() {
@@ -118,9 +117,7 @@ func (self *CpuAgent) mine(work *Work, stop <-chan struct{}) {
 	glog.V(logger.Debug).Infof("(re)started agent[%d]. mining...\n", self.index)
 
 	// Mine
-	nonce, mixDigest := self.pow.Search(work.Block, stop, self.index)
-	if nonce != 0 {
-		block := work.Block.WithMiningResult(nonce, common.BytesToHash(mixDigest))
+	if block := self.engine.Seal(work.Block, stop, self.index); block != nil {
 		self.returnCh <- &Result{work, block}
 	} else {
 		self.returnCh <- nil
@@ -128,5 +125,5 @@ func (self *CpuAgent) mine(work *Work, stop <-chan struct{}) {
 }
 
 func (self *CpuAgent) GetHashRate() int64 {
-	return self.pow.GetHashrate()
+	return self.engine.Hashrate()
 }
@@ -31,7 +31,6 @@ import (
 	"github.com/expanse-project/go-expanse/logger"
 	"github.com/expanse-project/go-expanse/logger/glog"
 	"github.com/expanse-project/go-expanse/params"
-	"github.com/expanse-project/go-expanse/pow"
 )
 
 type Miner struct {
@@ -45,14 +44,14 @@ type Miner struct {
 	coinbase common.Address
 	mining   int32
 	exp      core.Backend
-	pow      pow.PoW
+	engine   core.Engine
 
 	canStart    int32 // can start indicates whether we can start the mining operation
 	shouldStart int32 // should start indicates whether we should start after sync
 }
 
-func New(exp core.Backend, mux *event.TypeMux, pow pow.PoW) *Miner {
-	miner := &Miner{exp: exp, mux: mux, pow: pow, worker: newWorker(common.Address{}, exp), canStart: 1}
+func New(exp core.Backend, mux *event.TypeMux, engine core.Engine) *Miner {
+	miner := &Miner{exp: exp, mux: mux, engine: engine, worker: newWorker(common.Address{}, exp), canStart: 1}
 	go miner.update()
 
 	return miner
@@ -90,6 +89,12 @@ out:
 	}
 }
 
+// SubscribeNewMinedBlockEvent registers a subscription of NewMinedBlockEvent,
+// sent for every block sealed by the miner.
+func (self *Miner) SubscribeNewMinedBlockEvent(ch chan<- core.NewMinedBlockEvent) event.FeedSubscription {
+	return self.worker.minedFeed.Subscribe(ch)
+}
+
 func (m *Miner) SetGasPrice(price *big.Int) {
 	// FIXME block tests set a nil gas price. Quick dirty fix
 	if price == nil {
@@ -113,7 +118,7 @@ func (self *Miner) Start(coinbase common.Address, threads int) {
 	atomic.StoreInt32(&self.mining, 1)
 
 	for i := 0; i < threads; i++ {
-		self.worker.register(NewCpuAgent(i, self.pow))
+		self.worker.register(NewCpuAgent(i, self.engine))
 	}
 
 	glog.V(logger.Info).Infof("Starting mining operation (CPU=%d TOT=%d)\n", threads, len(self.worker.agents))
@@ -145,7 +150,7 @@ func (self *Miner) Mining() bool {
 }
 
 func (self *Miner) HashRate() (tot int64) {
-	tot += self.pow.GetHashrate()
+	tot += self.engine.Hashrate()
 	// do we care this might race? is it worth we're rewriting some
 	// aspects of the worker/locking up agents so we can get an accurate
 	// hashrate?
@@ -164,6 +169,19 @@ func (self *Miner) SetExtra(extra []byte) error {
 	return nil
 }
 
+// SetPayouts configures the shares of the rewards of mined blocks which are
+// transferred from the coinbase to other addresses.
+func (self *Miner) SetPayouts(payouts []Payout) error {
+	if err := validatePayouts(payouts); err != nil {
+		return err
+	}
+	self.worker.mu.Lock()
+	defer self.worker.mu.Unlock()
+
+	self.worker.payouts = payouts
+	return nil
+}
+
 func (self *Miner) PendingState() *state.StateDB {
 	return self.worker.pendingState()
 }
@@ -172,6 +190,13 @@ func (self *Miner) PendingBlock() *types.Block {
 	return self.worker.pendingBlock()
 }
 
+// Pending returns the block being mined along with a copy of its state. Both
+// are a consistent snapshot, unlike separate PendingBlock and PendingState
+// calls between which the miner may have moved on.
+func (self *Miner) Pending() (*types.Block, *state.StateDB) {
+	return self.worker.pending()
+}
+
 func (self *Miner) SetEtherbase(addr common.Address) {
 	self.coinbase = addr
 	self.worker.setEtherbase(addr)
@@ -0,0 +1,165 @@
+// Copyright 2016 The go-expanse Authors
+// This file is part of the go-expanse library.
+//
+// The go-expanse library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-expanse library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-expanse library. If not, see <http://www.gnu.org/licenses/>.
+
+package miner
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/expanse-project/go-expanse/accounts"
+	"github.com/expanse-project/go-expanse/common"
+	"github.com/expanse-project/go-expanse/core"
+	"github.com/expanse-project/go-expanse/core/state"
+	"github.com/expanse-project/go-expanse/core/types"
+	"github.com/expanse-project/go-expanse/ethdb"
+	"github.com/expanse-project/go-expanse/logger"
+	"github.com/expanse-project/go-expanse/logger/glog"
+	"github.com/expanse-project/go-expanse/params"
+)
+
+// payoutConfirmations is the number of blocks a mined block needs on top of it
+// in the canonical chain before its reward is paid out. Blocks reorged out
+// before are never paid for.
+const payoutConfirmations = 12
+
+// Payout is a share of the rewards of mined blocks which is transferred from
+// the coinbase to another address once the block is confirmed.
+type Payout struct {
+	Address common.Address
+	Percent uint64
+}
+
+// validatePayouts checks that every share is positive and that together they
+// don't exceed the whole reward.
+func validatePayouts(payouts []Payout) error {
+	var total uint64
+	for _, payout := range payouts {
+		if payout.Percent == 0 || payout.Percent > 100 {
+			return fmt.Errorf("payout to %x: share of %d%% out of range", payout.Address, payout.Percent)
+		}
+		total += payout.Percent
+	}
+	if total > 100 {
+		return fmt.Errorf("payout shares add up to %d%%", total)
+	}
+	return nil
+}
+
+// blockReward returns the amount credited to the coinbase for mining block,
+// which is the reward granted by the consensus engine and the fees of the
+// included transactions. The engine reward is measured by finalizing the block
+// on an empty state, so engines without rewards, like proof-of-authority, pay
+// out nothing but the fees.
+func blockReward(engine core.Engine, block *types.Block, receipts types.Receipts) (*big.Int, error) {
+	db, _ := ethdb.NewMemDatabase()
+	statedb, err := state.New(common.Hash{}, db)
+	if err != nil {
+		return nil, err
+	}
+	engine.Finalize(statedb, block.Header(), block.Uncles())
+	reward := new(big.Int).Set(statedb.GetBalance(block.Coinbase()))
+
+	for i, tx := range block.Transactions() {
+		if i < len(receipts) && receipts[i].GasUsed != nil {
+			reward.Add(reward, new(big.Int).Mul(receipts[i].GasUsed, tx.GasPrice()))
+		}
+	}
+	return reward, nil
+}
+
+// schedulePayout queues a mined block to be paid out once it's confirmed.
+func (self *worker) schedulePayout(block *types.Block) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	if len(self.payouts) > 0 && block.Coinbase() == self.coinbase {
+		self.unpaid = append(self.unpaid, block)
+	}
+}
+
+// confirmPayouts pays out the queued blocks which gained payoutConfirmations
+// blocks on top of them with head, and drops those which left the canonical
+// chain meanwhile.
+func (self *worker) confirmPayouts(head *types.Block) {
+	self.mu.Lock()
+	var confirmed []*types.Block
+	for len(self.unpaid) > 0 && self.unpaid[0].NumberU64()+payoutConfirmations <= head.NumberU64() {
+		block := self.unpaid[0]
+		self.unpaid = self.unpaid[1:]
+
+		if hash := core.GetCanonicalHash(self.chainDb, block.NumberU64()); hash != block.Hash() {
+			glog.V(logger.Info).Infof("Mined block #%d (%x) left the canonical chain, not paying out", block.NumberU64(), block.Hash().Bytes()[:4])
+			continue
+		}
+		confirmed = append(confirmed, block)
+	}
+	self.mu.Unlock()
+
+	for _, block := range confirmed {
+		self.payout(block)
+	}
+}
+
+// payout transfers the configured shares of the reward of a confirmed mined
+// block from the coinbase, which needs to be unlocked. The transfers pay the
+// gas price of the miner, so they are included by the miner itself.
+func (self *worker) payout(block *types.Block) {
+	self.mu.Lock()
+	payouts, price := self.payouts, new(big.Int).Set(self.gasPrice)
+	self.mu.Unlock()
+
+	if len(payouts) == 0 {
+		return
+	}
+	reward, err := blockReward(self.chain.Engine(), block, core.GetBlockReceipts(self.chainDb, block.Hash()))
+	if err != nil {
+		glog.V(logger.Error).Infof("payout of block #%d failed: %v", block.NumberU64(), err)
+		return
+	}
+	if reward.Sign() == 0 {
+		return
+	}
+	var (
+		coinbase = block.Coinbase()
+		pool     = self.exp.TxPool()
+		am       = self.exp.AccountManager()
+		signer   = types.MakeSigner(new(big.Int).Add(self.chain.CurrentBlock().Number(), common.Big1))
+	)
+	for _, payout := range payouts {
+		value := new(big.Int).Mul(reward, new(big.Int).SetUint64(payout.Percent))
+		value.Div(value, big.NewInt(100))
+
+		nonce, err := pool.ReserveNonce(coinbase)
+		if err != nil {
+			glog.V(logger.Error).Infof("payout of block #%d to %x failed: %v", block.NumberU64(), payout.Address, err)
+			continue
+		}
+		tx := types.NewTransaction(nonce, payout.Address, value, params.TxGas, price, nil)
+		sig, err := am.Sign(accounts.Account{Address: coinbase}, signer.Hash(tx).Bytes())
+		if err == nil {
+			if tx, err = signer.WithSignature(tx, sig); err == nil {
+				err = pool.Add(tx)
+			}
+		}
+		if err != nil {
+			pool.ReleaseNonce(coinbase, nonce)
+			glog.V(logger.Error).Infof("payout of block #%d to %x failed: %v", block.NumberU64(), payout.Address, err)
+			continue
+		}
+		glog.V(logger.Info).Infof("Paid %v wei (%d%%) of block #%d to %x (tx %x)", value, payout.Percent, block.NumberU64(), payout.Address, tx.Hash().Bytes()[:4])
+	}
+}
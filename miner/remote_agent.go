@@ -18,22 +18,46 @@ package miner
 
 import (
 	"errors"
+	"fmt"
 	"math/big"
 	"sync"
 	"sync/atomic"
 	"time"
 
-	"github.com/expanse-project/ethash"
 	"github.com/expanse-project/go-expanse/common"
 	"github.com/expanse-project/go-expanse/logger"
 	"github.com/expanse-project/go-expanse/logger/glog"
+	"github.com/expanse-project/go-expanse/pow/ethash"
 )
 
+// ExtraNonceSize is the number of leading nonce bytes leased to remote workers
+// as their extraNonce, 0 disabling the leases. Workers only search nonces which
+// start with their extraNonce, so the workers behind a proxy don't hash the
+// same nonces twice. Setting it is not thread safe, it should be done on
+// startup.
+var ExtraNonceSize = 0
+
+// ErrNoWork is returned when work is requested before any was prepared.
+var ErrNoWork = errors.New("No work available yet, don't panic.")
+
+// MaxExtraNonceSize is the largest supported ExtraNonceSize, which still leaves
+// workers a search space of 2^32 nonces.
+const MaxExtraNonceSize = 4
+
+// extraNonceTimeout is the time after which the extraNonce of a worker which
+// didn't ask for work is leased to others.
+const extraNonceTimeout = time.Minute
+
 type hashrate struct {
 	ping time.Time
 	rate uint64
 }
 
+type extraNonce struct {
+	ping  time.Time
+	value uint64
+}
+
 type RemoteAgent struct {
 	mu sync.Mutex
 
@@ -44,6 +68,9 @@ type RemoteAgent struct {
 	currentWork *Work
 	work        map[common.Hash]*Work
 
+	extraNonces    map[common.Hash]*extraNonce // extraNonces leased to workers by id
+	nextExtraNonce uint64                      // extraNonce to try leasing next
+
 	hashrateMu sync.RWMutex
 	hashrate   map[common.Hash]hashrate
 
@@ -52,8 +79,9 @@ type RemoteAgent struct {
 
 func NewRemoteAgent() *RemoteAgent {
 	return &RemoteAgent{
-		work:     make(map[common.Hash]*Work),
-		hashrate: make(map[common.Hash]hashrate),
+		work:        make(map[common.Hash]*Work),
+		extraNonces: make(map[common.Hash]*extraNonce),
+		hashrate:    make(map[common.Hash]hashrate),
 	}
 }
 
@@ -107,6 +135,33 @@ func (a *RemoteAgent) GetWork() ([3]string, error) {
 	a.mu.Lock()
 	defer a.mu.Unlock()
 
+	return a.getWork()
+}
+
+// GetWorkFor returns the current work package extended by the extraNonce leased
+// to the worker with the given id, which is renewed on every call.
+func (a *RemoteAgent) GetWorkFor(id common.Hash) ([4]string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	var res [4]string
+
+	work, err := a.getWork()
+	if err != nil {
+		return res, err
+	}
+	nonce, err := a.leaseExtraNonce(id)
+	if err != nil {
+		return res, err
+	}
+	copy(res[:], work[:])
+	res[3] = fmt.Sprintf("0x%0*x", 2*ExtraNonceSize, nonce)
+
+	return res, nil
+}
+
+// getWork assembles the current work package. It assumes that the lock is held.
+func (a *RemoteAgent) getWork() ([3]string, error) {
 	var res [3]string
 
 	if a.currentWork != nil {
@@ -125,7 +180,35 @@ func (a *RemoteAgent) GetWork() ([3]string, error) {
 		a.work[block.HashNoNonce()] = a.currentWork
 		return res, nil
 	}
-	return res, errors.New("No work available yet, don't panic.")
+	return res, ErrNoWork
+}
+
+// leaseExtraNonce returns the extraNonce of a worker, leasing it the next free
+// one if it has none. It assumes that the lock is held.
+func (a *RemoteAgent) leaseExtraNonce(id common.Hash) (uint64, error) {
+	if ExtraNonceSize <= 0 || ExtraNonceSize > MaxExtraNonceSize {
+		return 0, errors.New("extraNonce leasing disabled")
+	}
+	if lease, ok := a.extraNonces[id]; ok {
+		lease.ping = time.Now()
+		return lease.value, nil
+	}
+	space := uint64(1) << uint(8*ExtraNonceSize)
+	if uint64(len(a.extraNonces)) >= space {
+		return 0, errors.New("all extraNonces leased")
+	}
+	used := make(map[uint64]bool, len(a.extraNonces))
+	for _, lease := range a.extraNonces {
+		used[lease.value] = true
+	}
+	value := a.nextExtraNonce % space
+	for used[value] {
+		value = (value + 1) % space
+	}
+	a.nextExtraNonce = value + 1
+	a.extraNonces[id] = &extraNonce{ping: time.Now(), value: value}
+
+	return value, nil
 }
 
 // Returns true or false, but does not indicate if the PoW was correct
@@ -168,6 +251,11 @@ out:
 					delete(a.work, hash)
 				}
 			}
+			for id, lease := range a.extraNonces {
+				if time.Since(lease.ping) > extraNonceTimeout {
+					delete(a.extraNonces, id)
+				}
+			}
 			a.mu.Unlock()
 
 			a.hashrateMu.Lock()
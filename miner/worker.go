@@ -33,7 +33,6 @@ import (
 	"github.com/expanse-project/go-expanse/event"
 	"github.com/expanse-project/go-expanse/logger"
 	"github.com/expanse-project/go-expanse/logger/glog"
-	"github.com/expanse-project/go-expanse/pow"
 	"gopkg.in/fatih/set.v0"
 )
 
@@ -91,11 +90,10 @@ type Result struct {
 type worker struct {
 	mu sync.Mutex
 
-	agents map[Agent]struct{}
-	recv   chan *Result
-	mux    *event.TypeMux
-	quit   chan struct{}
-	pow    pow.PoW
+	agents    map[Agent]struct{}
+	recv      chan *Result
+	minedFeed event.Feed // NewMinedBlockEvent of the blocks sealed by the agents
+	quit      chan struct{}
 
 	exp     core.Backend
 	chain   *core.BlockChain
@@ -105,6 +103,8 @@ type worker struct {
 	coinbase common.Address
 	gasPrice *big.Int
 	extra    []byte
+	payouts  []Payout
+	unpaid   []*types.Block // mined blocks awaiting confirmation to be paid out
 
 	currentMu sync.Mutex
 	current   *Work
@@ -125,7 +125,6 @@ type worker struct {
 func newWorker(coinbase common.Address, exp core.Backend) *worker {
 	worker := &worker{
 		exp:            exp,
-		mux:            exp.EventMux(),
 		chainDb:        exp.ChainDb(),
 		recv:           make(chan *Result, resultQueueSize),
 		gasPrice:       new(big.Int),
@@ -152,16 +151,31 @@ func (self *worker) setEtherbase(addr common.Address) {
 	self.coinbase = addr
 }
 
+// pendingState returns a copy of the state of the pending block. The worker
+// keeps applying transactions to its own state, so it is never handed out.
 func (self *worker) pendingState() *state.StateDB {
 	self.currentMu.Lock()
 	defer self.currentMu.Unlock()
-	return self.current.state
+	return self.current.state.Copy()
 }
 
 func (self *worker) pendingBlock() *types.Block {
 	self.currentMu.Lock()
 	defer self.currentMu.Unlock()
+	return self.currentBlock()
+}
 
+// pending returns the pending block along with a copy of its state, taken
+// together so both reflect the same set of transactions.
+func (self *worker) pending() (*types.Block, *state.StateDB) {
+	self.currentMu.Lock()
+	defer self.currentMu.Unlock()
+	return self.currentBlock(), self.current.state.Copy()
+}
+
+// currentBlock returns the block being worked on. The caller must hold
+// currentMu.
+func (self *worker) currentBlock() *types.Block {
 	if atomic.LoadInt32(&self.mining) == 0 {
 		return types.NewBlock(
 			self.current.header,
@@ -219,34 +233,48 @@ func (self *worker) unregister(agent Agent) {
 }
 
 func (self *worker) update() {
-	eventSub := self.mux.Subscribe(core.ChainHeadEvent{}, core.ChainSideEvent{}, core.TxPreEvent{})
-	defer eventSub.Unsubscribe()
+	headCh := make(chan core.ChainHeadEvent, core.ChainChanSize)
+	headSub := self.chain.SubscribeChainHeadEvent(headCh)
+	sideCh := make(chan core.ChainSideEvent, core.ChainChanSize)
+	sideSub := self.chain.SubscribeChainSideEvent(sideCh)
+	txCh := make(chan core.TxPreEvent, core.TxChanSize)
+	txSub := self.exp.TxPool().SubscribeTxPreEvent(txCh)
+	defer func() {
+		headSub.Unsubscribe()
+		sideSub.Unsubscribe()
+		txSub.Unsubscribe()
+	}()
 
-	eventCh := eventSub.Chan()
 	for {
 		select {
-		case event, ok := <-eventCh:
-			if !ok {
-				// Event subscription closed, set the channel to nil to stop spinning
-				eventCh = nil
-				continue
-			}
-			// A real event arrived, process interesting content
-			switch ev := event.Data.(type) {
-			case core.ChainHeadEvent:
-				self.commitNewWork()
-			case core.ChainSideEvent:
-				self.uncleMu.Lock()
-				self.possibleUncles[ev.Block.Hash()] = ev.Block
-				self.uncleMu.Unlock()
-			case core.TxPreEvent:
-				// Apply transaction to the pending state if we're not mining
-				if atomic.LoadInt32(&self.mining) == 0 {
-					self.currentMu.Lock()
-					self.current.commitTransactions(types.Transactions{ev.Tx}, self.gasPrice, self.chain)
-					self.currentMu.Unlock()
-				}
+		case ev := <-headCh:
+			self.commitNewWork()
+			go self.confirmPayouts(ev.Block)
+		case ev := <-sideCh:
+			self.uncleMu.Lock()
+			self.possibleUncles[ev.Block.Hash()] = ev.Block
+			self.uncleMu.Unlock()
+		case ev := <-txCh:
+			// Apply transaction to the pending state if we're not mining
+			if atomic.LoadInt32(&self.mining) == 0 {
+				self.currentMu.Lock()
+				txs := types.NewTransactionsByPriceAndNonce(types.Transactions{ev.Tx})
+				self.current.commitTransactions(txs, self.gasPrice, self.chain)
+				self.currentMu.Unlock()
 			}
+		case err := <-txSub.Err():
+			// The subscription is only dropped if we fell behind. Pending
+			// transactions are picked up with the next block anyway.
+			glog.V(logger.Debug).Infoln("resubscribing to transaction events:", err)
+			txSub = self.exp.TxPool().SubscribeTxPreEvent(txCh)
+		case err := <-headSub.Err():
+			// New work is committed with the next head that gets through.
+			glog.V(logger.Debug).Infoln("resubscribing to chain head events:", err)
+			headSub = self.chain.SubscribeChainHeadEvent(headCh)
+		case err := <-sideSub.Err():
+			// Missed side blocks are only lost as uncle candidates.
+			glog.V(logger.Debug).Infoln("resubscribing to chain side events:", err)
+			sideSub = self.chain.SubscribeChainSideEvent(sideCh)
 		case <-self.quit:
 			return
 		}
@@ -281,7 +309,7 @@ func (self *worker) wait() {
 					glog.V(logger.Error).Infoln("mining err", err)
 					continue
 				}
-				go self.mux.Post(core.NewMinedBlockEvent{block})
+				self.minedFeed.Send(core.NewMinedBlockEvent{block})
 			} else {
 				work.state.Commit()
 				parent := self.chain.GetBlock(block.ParentHash())
@@ -290,8 +318,8 @@ func (self *worker) wait() {
 					continue
 				}
 
-				auxValidator := self.exp.BlockChain().AuxValidator()
-				if err := core.ValidateHeader(auxValidator, block.Header(), parent.Header(), true, false); err != nil && err != core.BlockFutureErr {
+				engine := self.chain.Engine()
+				if err := core.ValidateHeader(engine, block.Header(), parent.Header(), true, false); err != nil && err != core.BlockFutureErr {
 					glog.V(logger.Error).Infoln("Invalid header on mined block:", err)
 					continue
 				}
@@ -324,15 +352,20 @@ func (self *worker) wait() {
 
 				// broadcast before waiting for validation
 				go func(block *types.Block, logs vm.Logs, receipts []*types.Receipt) {
-					self.mux.Post(core.NewMinedBlockEvent{block})
-					self.mux.Post(core.ChainEvent{block, block.Hash(), logs})
+					self.minedFeed.Send(core.NewMinedBlockEvent{block})
+					var canonLogs vm.Logs
 					if stat == core.CanonStatTy {
-						self.mux.Post(core.ChainHeadEvent{block})
-						self.mux.Post(logs)
+						canonLogs = logs
 					}
+					self.chain.PostChainEvents([]interface{}{core.ChainEvent{block, block.Hash(), logs}}, canonLogs)
 					if err := core.WriteBlockReceipts(self.chainDb, block.Hash(), receipts); err != nil {
 						glog.V(logger.Warn).Infoln("error writing block receipts:", err)
 					}
+					if core.RecordInternalTxs {
+						if err := core.WriteInternalTxs(self.chainDb, block.Hash(), receipts); err != nil {
+							glog.V(logger.Warn).Infoln("error writing internal transactions:", err)
+						}
+					}
 				}(block, work.state.Logs(), work.receipts)
 			}
 
@@ -346,6 +379,9 @@ func (self *worker) wait() {
 				work.localMinedBlocks = newLocalMinedBlock(block.Number().Uint64(), work.localMinedBlocks)
 			}
 			glog.V(logger.Info).Infof("🔨  Mined %sblock (#%v / %x). %s", stale, block.Number(), block.Hash().Bytes()[:4], confirm)
+			if stale == "" {
+				self.schedulePayout(block)
+			}
 
 			self.commitNewWork()
 		}
@@ -415,7 +451,7 @@ func (w *worker) setGasPrice(p *big.Int) {
 	const pct = int64(90)
 	w.gasPrice = gasprice(p, pct)
 
-	w.mux.Post(core.GasPriceChanged{w.gasPrice})
+	w.exp.TxPool().SetGasPrice(w.gasPrice)
 }
 
 func (self *worker) isBlockLocallyMined(current *Work, deepBlockNum uint64) bool {
@@ -475,7 +511,6 @@ func (self *worker) commitNewWork() {
 	header := &types.Header{
 		ParentHash: parent.Hash(),
 		Number:     num.Add(num, common.Big1),
-		Difficulty: core.CalcDifficulty(uint64(tstamp), parent.Time().Uint64(), parent.Number(), parent.Difficulty()),
 		GasLimit:   core.CalcGasLimit(parent),
 		GasUsed:    new(big.Int),
 		Coinbase:   self.coinbase,
@@ -483,6 +518,11 @@ func (self *worker) commitNewWork() {
 		Time:       big.NewInt(tstamp),
 	}
 
+	if err := self.chain.Engine().Prepare(header, parent.Header()); err != nil {
+		glog.V(logger.Error).Infoln("Failed to prepare header for mining:", err)
+		return
+	}
+
 	previous := self.current
 	// Could potentially happen if starting to mine in an odd state.
 	err := self.makeCurrent(parent, header)
@@ -492,44 +532,9 @@ func (self *worker) commitNewWork() {
 	}
 	work := self.current
 
-	/* //approach 1
-	transactions := self.eth.TxPool().GetTransactions()
-	sort.Sort(types.TxByNonce(transactions))
-	*/
-
-	//approach 2
-	transactions := self.exp.TxPool().GetTransactions()
-	types.SortByPriceAndNonce(transactions)
-
-	/* // approach 3
-	// commit transactions for this run.
-	txPerOwner := make(map[common.Address]types.Transactions)
-	// Sort transactions by owner
-	for _, tx := range self.exp.TxPool().GetTransactions() {
-		from, _ := tx.From() // we can ignore the sender error
-		txPerOwner[from] = append(txPerOwner[from], tx)
-	}
-	var (
-		singleTxOwner types.Transactions
-		multiTxOwner  types.Transactions
-	)
-	// Categorise transactions by
-	// 1. 1 owner tx per block
-	// 2. multi txs owner per block
-	for _, txs := range txPerOwner {
-		if len(txs) == 1 {
-			singleTxOwner = append(singleTxOwner, txs[0])
-		} else {
-			multiTxOwner = append(multiTxOwner, txs...)
-		}
-	}
-	sort.Sort(types.TxByPrice(singleTxOwner))
-	sort.Sort(types.TxByNonce(multiTxOwner))
-	transactions := append(singleTxOwner, multiTxOwner...)
-	*/
-
-	work.commitTransactions(transactions, self.gasPrice, self.chain)
-	self.exp.TxPool().RemoveTransactions(work.lowGasTxs)
+	txs := types.NewTransactionsByPriceAndNonce(self.exp.TxPool().GetTransactions())
+	work.commitTransactions(txs, self.gasPrice, self.chain)
+	self.exp.TxPool().DropTransactions(work.lowGasTxs, core.TxUnderpriced)
 
 	// compute uncles for the new block.
 	var (
@@ -557,7 +562,7 @@ func (self *worker) commitNewWork() {
 
 	if atomic.LoadInt32(&self.mining) == 1 {
 		// commit state root after all state transitions.
-		core.AccumulateRewards(work.state, header, uncles)
+		self.chain.Engine().Finalize(work.state, header, uncles)
 		header.Root = work.state.IntermediateRoot()
 	}
 
@@ -583,13 +588,23 @@ func (self *worker) commitUncle(work *Work, uncle *types.Header) error {
 	if work.family.Has(hash) {
 		return core.UncleError(fmt.Sprintf("Uncle already in family (%x)", hash))
 	}
+	if parent := self.chain.GetHeader(uncle.ParentHash); parent != nil {
+		if err := self.chain.Engine().VerifyHeader(uncle, parent, true); err != nil {
+			return core.UncleError("Uncle rejected by consensus engine: %v", err)
+		}
+	}
 	work.uncles.Add(uncle.Hash())
 	return nil
 }
 
-func (env *Work) commitTransactions(transactions types.Transactions, gasPrice *big.Int, bc *core.BlockChain) {
+func (env *Work) commitTransactions(txs *types.TransactionsByPriceAndNonce, gasPrice *big.Int, bc *core.BlockChain) {
 	gp := new(core.GasPool).AddGas(env.header.GasLimit)
-	for _, tx := range transactions {
+	for {
+		// Retrieve the next transaction and abort if all done
+		tx := txs.Peek()
+		if tx == nil {
+			break
+		}
 		// Error may be ignored here. The error has already been checked
 		// during transaction acceptance is the transaction pool.
 		from, _ := tx.From()
@@ -613,15 +628,17 @@ func (env *Work) commitTransactions(transactions types.Transactions, gasPrice *b
 			if !env.ownedAccounts.Has(from) {
 				env.lowGasTxs = append(env.lowGasTxs, tx)
 			}
+			txs.Shift()
 			continue
 		}
 
-		// Move on to the next transaction when the transactor is in ignored transactions set
+		// Move on to the next account when the transactor is in ignored transactions set
 		// This may occur when a transaction hits the gas limit. When a gas limit is hit and
 		// the transaction is processed (that could potentially be included in the block) it
 		// will throw a nonce error because the previous transaction hasn't been processed.
 		// Therefor we need to ignore any transaction after the ignored one.
 		if env.ignoredTransactors.Has(from) {
+			txs.Pop()
 			continue
 		}
 
@@ -633,16 +650,21 @@ func (env *Work) commitTransactions(transactions types.Transactions, gasPrice *b
 			// ignore the transactor so no nonce errors will be thrown for this account
 			// next time the worker is run, they'll be picked up again.
 			env.ignoredTransactors.Add(from)
+			txs.Pop()
 
 			glog.V(logger.Detail).Infof("Gas limit reached for (%x) in this block. Continue to try smaller txs\n", from[:4])
 		case err != nil:
+			// the remaining transactions of the account would fail on their
+			// nonce, so skip them without dropping them from the pool
 			env.remove.Add(tx.Hash())
+			txs.Pop()
 
 			if glog.V(logger.Detail) {
 				glog.Infof("TX (%x) failed, will be removed: %v\n", tx.Hash().Bytes()[:4], err)
 			}
 		default:
 			env.tcount++
+			txs.Shift()
 		}
 	}
 }
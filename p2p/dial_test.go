@@ -406,6 +406,18 @@ func TestDialStateStaticDial(t *testing.T) {
 	})
 }
 
+// This test checks that removed static nodes are no longer dialed.
+func TestDialStateRemoveStatic(t *testing.T) {
+	state := newDialState([]*discover.Node{{ID: uintID(1)}, {ID: uintID(2)}}, fakeTable{}, 0)
+	state.removeStatic(&discover.Node{ID: uintID(2)})
+
+	new := state.newTasks(0, nil, time.Time{})
+	want := []task{&dialTask{staticDialedConn, &discover.Node{ID: uintID(1)}}}
+	if !sametasks(new, want) {
+		t.Errorf("new tasks mismatch:\ngot %v\nwant %v", spew.Sdump(new), spew.Sdump(want))
+	}
+}
+
 // This test checks that past dials are not retried for some time.
 func TestDialStateCache(t *testing.T) {
 	wantStatic := []*discover.Node{
@@ -24,6 +24,7 @@ import (
 	"crypto/rand"
 	"encoding/binary"
 	"os"
+	"sort"
 	"sync"
 	"time"
 
@@ -40,9 +41,13 @@ import (
 )
 
 var (
-	nodeDBNilNodeID      = NodeID{}       // Special node ID to use as a nil element.
-	nodeDBNodeExpiration = 24 * time.Hour // Time after which an unseen node should be dropped.
-	nodeDBCleanupCycle   = time.Hour      // Time period for running the expiration task.
+	nodeDBNilNodeID    = NodeID{}  // Special node ID to use as a nil element.
+	nodeDBCleanupCycle = time.Hour // Time period for running the expiration task.
+)
+
+var (
+	NodeDBExpiration = 24 * time.Hour // Time after which an unseen node should be dropped.
+	NodeDBMaxNodes   = 20000          // Maximum number of nodes kept, the least recently seen are dropped first.
 )
 
 // nodeDB stores all nodes we know about.
@@ -243,14 +248,37 @@ func (db *nodeDB) expirer() {
 }
 
 // expireNodes iterates over the database and deletes all nodes that have not
-// been seen (i.e. received a pong from) for some alloted time.
+// been seen (i.e. received a pong from) for some alloted time, as well as the
+// least recently seen ones beyond the maximum node count.
 func (db *nodeDB) expireNodes() error {
-	threshold := time.Now().Add(-nodeDBNodeExpiration)
+	_, err := db.pruneNodes(time.Now().Add(-NodeDBExpiration), NodeDBMaxNodes)
+	return err
+}
+
+// seenNode is a node id along with the time it was last seen.
+type seenNode struct {
+	id   NodeID
+	seen time.Time
+}
 
+type nodesBySeen []seenNode
+
+func (n nodesBySeen) Len() int           { return len(n) }
+func (n nodesBySeen) Less(i, j int) bool { return n[i].seen.Before(n[j].seen) }
+func (n nodesBySeen) Swap(i, j int)      { n[i], n[j] = n[j], n[i] }
+
+// pruneNodes deletes all nodes not seen after threshold, then the least
+// recently seen ones until at most max nodes remain (0 = no limit). Self is
+// always deleted. It returns the number of deleted nodes.
+func (db *nodeDB) pruneNodes(threshold time.Time, max int) (int, error) {
 	// Find discovered nodes that are older than the allowance
 	it := db.lvl.NewIterator(nil, nil)
 	defer it.Release()
 
+	var (
+		deleted int
+		kept    nodesBySeen
+	)
 	for it.Next() {
 		// Skip the item if not a discovery node
 		id, field := splitKey(it.Key())
@@ -260,13 +288,27 @@ func (db *nodeDB) expireNodes() error {
 		// Skip the node if not expired yet (and not self)
 		if bytes.Compare(id[:], db.self[:]) != 0 {
 			if seen := db.lastPong(id); seen.After(threshold) {
+				kept = append(kept, seenNode{id, seen})
 				continue
 			}
 		}
 		// Otherwise delete all associated information
-		db.deleteNode(id)
+		if err := db.deleteNode(id); err != nil {
+			return deleted, err
+		}
+		deleted++
 	}
-	return nil
+	// Drop the least recently seen nodes beyond the limit
+	if max > 0 && len(kept) > max {
+		sort.Sort(kept)
+		for _, node := range kept[:len(kept)-max] {
+			if err := db.deleteNode(node.id); err != nil {
+				return deleted, err
+			}
+			deleted++
+		}
+	}
+	return deleted, nil
 }
 
 // lastPing retrieves the time of the last ping packet send to a remote node,
@@ -367,3 +409,61 @@ func (db *nodeDB) close() {
 	close(db.quit)
 	db.lvl.Close()
 }
+
+// NodeDBStats summarises the contents of a node database.
+type NodeDBStats struct {
+	Nodes   int       // Number of known nodes
+	Expired int       // Number of nodes not seen within NodeDBExpiration
+	Oldest  time.Time // Time the least recently seen node was last seen
+	Newest  time.Time // Time the most recently seen node was last seen
+}
+
+// InspectNodeDB summarises the persistent node database at path. The database
+// must not be in use by a running node.
+func InspectNodeDB(path string) (*NodeDBStats, error) {
+	db, err := newPersistentNodeDB(path, Version, NodeID{})
+	if err != nil {
+		return nil, err
+	}
+	defer db.close()
+
+	var (
+		stats     = new(NodeDBStats)
+		threshold = time.Now().Add(-NodeDBExpiration)
+	)
+	it := db.lvl.NewIterator(nil, nil)
+	defer it.Release()
+
+	for it.Next() {
+		id, field := splitKey(it.Key())
+		if field != nodeDBDiscoverRoot {
+			continue
+		}
+		seen := db.lastPong(id)
+		if stats.Nodes == 0 || seen.Before(stats.Oldest) {
+			stats.Oldest = seen
+		}
+		if seen.After(stats.Newest) {
+			stats.Newest = seen
+		}
+		if !seen.After(threshold) {
+			stats.Expired++
+		}
+		stats.Nodes++
+	}
+	return stats, nil
+}
+
+// PruneNodeDB deletes the expired nodes from the persistent node database at
+// path, as well as the least recently seen ones beyond NodeDBMaxNodes. It
+// returns the number of deleted nodes. The database must not be in use by a
+// running node.
+func PruneNodeDB(path string) (int, error) {
+	db, err := newPersistentNodeDB(path, Version, NodeID{})
+	if err != nil {
+		return 0, err
+	}
+	defer db.close()
+
+	return db.pruneNodes(time.Now().Add(-NodeDBExpiration), NodeDBMaxNodes)
+}
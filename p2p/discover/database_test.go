@@ -309,7 +309,7 @@ var nodeDBExpirationNodes = []struct {
 			42786,
 			42786,
 		),
-		pong: time.Now().Add(-nodeDBNodeExpiration + time.Minute),
+		pong: time.Now().Add(-NodeDBExpiration + time.Minute),
 		exp:  false,
 	}, {
 		node: newNode(
@@ -318,7 +318,7 @@ var nodeDBExpirationNodes = []struct {
 			42786,
 			42786,
 		),
-		pong: time.Now().Add(-nodeDBNodeExpiration - time.Minute),
+		pong: time.Now().Add(-NodeDBExpiration - time.Minute),
 		exp:  true,
 	},
 }
@@ -378,3 +378,70 @@ func TestNodeDBSelfExpiration(t *testing.T) {
 		t.Errorf("self not evacuated")
 	}
 }
+
+func TestNodeDBSizeLimit(t *testing.T) {
+	db, _ := newNodeDB("", Version, NodeID{})
+	defer db.close()
+
+	// Insert a few nodes seen at increasing times
+	now := time.Now()
+	var ids []NodeID
+	for i := 0; i < 5; i++ {
+		var id NodeID
+		id[0] = byte(i + 1)
+		ids = append(ids, id)
+
+		if err := db.updateNode(newNode(id, net.IP{127, 0, 0, byte(i + 1)}, 30303, 30303)); err != nil {
+			t.Fatalf("node %d: failed to insert: %v", i, err)
+		}
+		if err := db.updateLastPong(id, now.Add(time.Duration(i)*time.Minute)); err != nil {
+			t.Fatalf("node %d: failed to update pong: %v", i, err)
+		}
+	}
+	// Limit the database to three nodes, the least recently seen should go
+	deleted, err := db.pruneNodes(time.Time{}, 3)
+	if err != nil {
+		t.Fatalf("failed to prune nodes: %v", err)
+	}
+	if deleted != 2 {
+		t.Errorf("deleted count mismatch: have %d, want 2", deleted)
+	}
+	for i, id := range ids {
+		if node := db.node(id); (node == nil) != (i < 2) {
+			t.Errorf("node %d: pruning mismatch: have %v, want deleted %v", i, node, i < 2)
+		}
+	}
+}
+
+func TestNodeDBInspectPrune(t *testing.T) {
+	root, err := ioutil.TempDir("", "nodedb-")
+	if err != nil {
+		t.Fatalf("failed to create temporary data folder: %v", err)
+	}
+	defer os.RemoveAll(root)
+	path := filepath.Join(root, "database")
+
+	db, err := newNodeDB(path, Version, NodeID{})
+	if err != nil {
+		t.Fatalf("failed to create persistent database: %v", err)
+	}
+	for _, seed := range nodeDBExpirationNodes {
+		db.updateNode(seed.node)
+		db.updateLastPong(seed.node.ID, seed.pong)
+	}
+	db.close()
+
+	stats, err := InspectNodeDB(path)
+	if err != nil {
+		t.Fatalf("failed to inspect database: %v", err)
+	}
+	if stats.Nodes != 2 || stats.Expired != 1 {
+		t.Errorf("stats mismatch: have %d nodes, %d expired, want 2, 1", stats.Nodes, stats.Expired)
+	}
+	if deleted, err := PruneNodeDB(path); err != nil || deleted != 1 {
+		t.Errorf("prune mismatch: have %d deleted, err %v, want 1 deleted", deleted, err)
+	}
+	if stats, _ = InspectNodeDB(path); stats.Nodes != 1 || stats.Expired != 0 {
+		t.Errorf("stats after pruning mismatch: have %d nodes, %d expired, want 1, 0", stats.Nodes, stats.Expired)
+	}
+}
@@ -97,6 +97,14 @@ func newTable(t transport, ourID NodeID, ourAddr *net.UDPAddr, nodeDBPath string
 		glog.V(logger.Warn).Infoln("Failed to open node database:", err)
 		db, _ = newNodeDB("", Version, ourID)
 	}
+	// Enforce the size limit right away, a large database slows down startup.
+	// Expired nodes are kept until bootstrapping succeeded, they may be the
+	// only seeds available.
+	if deleted, err := db.pruneNodes(time.Time{}, NodeDBMaxNodes); err != nil {
+		glog.V(logger.Warn).Infoln("Failed to prune node database:", err)
+	} else if deleted > 0 {
+		glog.V(logger.Info).Infof("Pruned %d least recently seen nodes from the node database", deleted)
+	}
 	tab := &Table{
 		net:        t,
 		db:         db,
@@ -413,7 +421,7 @@ func (tab *Table) bond(pinged bool, id NodeID, addr *net.UDPAddr, tcpPort uint16
 	// If the node is unknown (non-bonded) or failed (remotely unknown), bond from scratch
 	var result error
 	age := time.Since(tab.db.lastPong(id))
-	if node == nil || fails > 0 || age > nodeDBNodeExpiration {
+	if node == nil || fails > 0 || age > NodeDBExpiration {
 		glog.V(logger.Detail).Infof("Bonding %x: known=%t, fails=%d age=%v", id[:8], node != nil, fails, age)
 
 		tab.bondmu.Lock()
@@ -0,0 +1,94 @@
+// Copyright 2016 The go-expanse Authors
+// This file is part of the go-expanse library.
+//
+// The go-expanse library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-expanse library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-expanse library. If not, see <http://www.gnu.org/licenses/>.
+
+package discover
+
+import (
+	"net"
+	"time"
+)
+
+// maxThrottledSources is the number of source IPs a throttle keeps track of.
+// Once it is reached, idle sources are forgotten first.
+const maxThrottledSources = 1024
+
+// throttle limits how often packets from the same source IP are answered.
+// Every source gets a token bucket holding up to burst tokens and regaining
+// rate tokens per second, answering a packet takes one token.
+type throttle struct {
+	rate    float64
+	burst   float64
+	sources map[string]*tokenBucket
+	now     func() time.Time
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func newThrottle(rate, burst float64) *throttle {
+	return &throttle{
+		rate:    rate,
+		burst:   burst,
+		sources: make(map[string]*tokenBucket),
+		now:     time.Now,
+	}
+}
+
+// allow reports whether a packet from ip may be answered, taking a token from
+// the bucket of ip if so.
+func (t *throttle) allow(ip net.IP) bool {
+	now := t.now()
+	key := string(ip.To16())
+
+	b := t.sources[key]
+	if b == nil {
+		if len(t.sources) >= maxThrottledSources {
+			t.prune(now)
+		}
+		b = &tokenBucket{tokens: t.burst, last: now}
+		t.sources[key] = b
+	}
+	b.tokens += now.Sub(b.last).Seconds() * t.rate
+	if b.tokens > t.burst {
+		b.tokens = t.burst
+	}
+	b.last = now
+
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// prune forgets the sources whose buckets refilled completely, as they are
+// indistinguishable from new ones. If all sources are active, an arbitrary
+// one is dropped to bound the memory use.
+func (t *throttle) prune(now time.Time) {
+	for key, b := range t.sources {
+		if b.tokens+now.Sub(b.last).Seconds()*t.rate >= t.burst {
+			delete(t.sources, key)
+		}
+	}
+	for key := range t.sources {
+		if len(t.sources) < maxThrottledSources {
+			break
+		}
+		delete(t.sources, key)
+	}
+}
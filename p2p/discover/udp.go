@@ -22,6 +22,7 @@ import (
 	"crypto/ecdsa"
 	"errors"
 	"fmt"
+	"math/rand"
 	"net"
 	"time"
 
@@ -44,6 +45,7 @@ var (
 	errTimeout          = errors.New("RPC timeout")
 	errClockWarp        = errors.New("reply deadline too far in the future")
 	errClosed           = errors.New("socket closed")
+	errThrottled        = errors.New("too many requests")
 )
 
 // Timeouts
@@ -53,6 +55,13 @@ const (
 	expiration  = 20 * time.Second
 )
 
+// Findnode throttling
+const (
+	findnodeRate   = 1  // findnode requests answered per second and source IP
+	findnodeBurst  = 10 // findnode requests answered at once per source IP
+	findnodeJitter = 4  // maximum number of closest nodes randomly left out of a response
+)
+
 // RPC packet types
 const (
 	pingPacket = iota + 1 // zero is 'reserved'
@@ -158,6 +167,9 @@ type udp struct {
 	closing chan struct{}
 	nat     nat.Interface
 
+	findnodeLimit  *throttle // answered findnode requests per source, only used by readLoop
+	findnodeJitter int       // maximum number of closest nodes left out of a response
+
 	*Table
 }
 
@@ -220,6 +232,9 @@ func newUDP(priv *ecdsa.PrivateKey, c conn, natm nat.Interface, nodeDBPath strin
 		closing:    make(chan struct{}),
 		gotreply:   make(chan reply),
 		addpending: make(chan *pending),
+
+		findnodeLimit:  newThrottle(findnodeRate, findnodeBurst),
+		findnodeJitter: findnodeJitter,
 	}
 	realaddr := c.LocalAddr().(*net.UDPAddr)
 	if natm != nil {
@@ -569,9 +584,20 @@ func (req *findnode) handle(t *udp, from *net.UDPAddr, fromID NodeID, mac []byte
 		// (which is a much bigger packet than findnode) to the victim.
 		return errUnknownNode
 	}
+	if !t.findnodeLimit.allow(from.IP) {
+		// Even bonded nodes don't need answers more often than this, the
+		// limit keeps the node from being a cheap traffic amplifier.
+		return errThrottled
+	}
+	// Leave out a few of the farthest nodes at random, so the size of the
+	// response isn't predictable.
+	results := bucketSize
+	if t.findnodeJitter > 0 {
+		results -= rand.Intn(t.findnodeJitter + 1)
+	}
 	target := crypto.Sha3Hash(req.Target[:])
 	t.mutex.Lock()
-	closest := t.closest(target, bucketSize).entries
+	closest := t.closest(target, results).entries
 	t.mutex.Unlock()
 
 	p := neighbors{Expiration: uint64(time.Now().Add(expiration).Unix())}
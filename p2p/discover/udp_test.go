@@ -281,6 +281,7 @@ func TestUDP_findnodeTimeout(t *testing.T) {
 func TestUDP_findnode(t *testing.T) {
 	test := newUDPTest(t)
 	defer test.table.Close()
+	test.udp.findnodeJitter = 0
 
 	// put a few nodes into the table. their exact
 	// distribution shouldn't matter much, altough we need to
@@ -320,6 +321,54 @@ func TestUDP_findnode(t *testing.T) {
 	waitNeighbors(expected.entries[maxNeighbors:])
 }
 
+func TestUDP_findnodeThrottle(t *testing.T) {
+	test := newUDPTest(t)
+	defer test.table.Close()
+
+	// Put a single node into the table, so every response is a single packet.
+	test.table.stuff([]*Node{nodeAtDistance(test.table.self.sha, 200)})
+	test.table.db.updateNode(newNode(
+		PubkeyID(&test.remotekey.PublicKey),
+		test.remoteaddr.IP,
+		uint16(test.remoteaddr.Port),
+		99,
+	))
+	now := time.Now()
+	test.udp.findnodeLimit.now = func() time.Time { return now }
+
+	// A burst of requests is answered, the ones beyond it aren't.
+	for i := 0; i < findnodeBurst; i++ {
+		test.packetIn(nil, findnodePacket, &findnode{Target: testTarget, Expiration: futureExp})
+		test.waitPacketOut(func(p *neighbors) {})
+	}
+	test.packetIn(errThrottled, findnodePacket, &findnode{Target: testTarget, Expiration: futureExp})
+
+	// Requests are answered again once the bucket refilled.
+	now = now.Add(time.Second / findnodeRate)
+	test.packetIn(nil, findnodePacket, &findnode{Target: testTarget, Expiration: futureExp})
+	test.waitPacketOut(func(p *neighbors) {})
+	test.packetIn(errThrottled, findnodePacket, &findnode{Target: testTarget, Expiration: futureExp})
+}
+
+func TestThrottlePrune(t *testing.T) {
+	th := newThrottle(1, 1)
+	now := time.Now()
+	th.now = func() time.Time { return now }
+
+	for i := 0; i < maxThrottledSources+10; i++ {
+		th.allow(net.IP{10, 0, byte(i >> 8), byte(i)})
+	}
+	if len(th.sources) > maxThrottledSources {
+		t.Fatalf("too many tracked sources: have %d, want at most %d", len(th.sources), maxThrottledSources)
+	}
+	// Idle sources are forgotten first
+	now = now.Add(time.Minute)
+	th.allow(net.IP{10, 1, 0, 0})
+	if len(th.sources) != 1 {
+		t.Errorf("idle sources not pruned: have %d sources, want 1", len(th.sources))
+	}
+}
+
 func TestUDP_findnodeMultiReply(t *testing.T) {
 	test := newUDPTest(t)
 	defer test.table.Close()
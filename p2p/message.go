@@ -30,6 +30,18 @@ import (
 	"github.com/expanse-project/go-expanse/rlp"
 )
 
+// msgDecodeLimits restricts the structure of decoded message payloads.
+// The limits are far above anything the protocols send (transactions and
+// contract code stay well below a megabyte, no request or response carries
+// more than a few hundred items) and only guard the decoder against
+// maliciously crafted input, e.g. a payload of millions of empty lists
+// decoding into as many allocated values.
+var msgDecodeLimits = rlp.Limits{
+	MaxStringSize: 4 * 1024 * 1024,
+	MaxListElems:  32 * 1024,
+	MaxListDepth:  64,
+}
+
 // Msg defines the structure of a p2p message.
 //
 // Note that a Msg can only be sent once since the Payload reader is
@@ -50,6 +62,7 @@ type Msg struct {
 // For the decoding rules, please see package rlp.
 func (msg Msg) Decode(val interface{}) error {
 	s := rlp.NewStream(msg.Payload, uint64(msg.Size))
+	s.SetLimits(msgDecodeLimits)
 	if err := s.Decode(val); err != nil {
 		return newPeerError(errInvalidMsg, "(code %x) (size %d) %v", msg.Code, msg.Size, err)
 	}
@@ -97,6 +110,36 @@ func Send(w MsgWriter, msgcode uint64, data interface{}) error {
 	return w.WriteMsg(Msg{Code: msgcode, Size: uint32(size), Payload: r})
 }
 
+// SendRawList writes a message with the given code whose payload is an RLP
+// list of the already encoded elems. The payload is written through to w as
+// it is read instead of being encoded into a single buffer first.
+func SendRawList(w MsgWriter, msgcode uint64, elems []rlp.RawValue) error {
+	var size uint64
+	for _, elem := range elems {
+		size += uint64(len(elem))
+	}
+	r, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(writeRawList(pw, size, elems))
+	}()
+	err := w.WriteMsg(Msg{Code: msgcode, Size: uint32(rlp.ListSize(size)), Payload: r})
+	r.Close() // unblocks the writer if the payload wasn't consumed
+	return err
+}
+
+func writeRawList(w io.Writer, size uint64, elems []rlp.RawValue) error {
+	lw, err := rlp.NewListWriter(w, size)
+	if err != nil {
+		return err
+	}
+	for _, elem := range elems {
+		if err := lw.Encode(elem); err != nil {
+			return err
+		}
+	}
+	return lw.Close()
+}
+
 // SendItems writes an RLP with the given code and data elements.
 // For a call such as:
 //
@@ -25,6 +25,8 @@ import (
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/expanse-project/go-expanse/rlp"
 )
 
 func ExampleMsgPipe() {
@@ -80,6 +82,26 @@ loop:
 }
 
 // This test should panic if concurrent close isn't implemented correctly.
+func TestSendRawList(t *testing.T) {
+	rw1, rw2 := MsgPipe()
+	defer rw1.Close()
+
+	content := []interface{}{"foo", uint(1), []uint{2, 3}}
+	elems := make([]rlp.RawValue, len(content))
+	for i, val := range content {
+		elems[i], _ = rlp.EncodeToBytes(val)
+	}
+	errc := make(chan error, 1)
+	go func() { errc <- SendRawList(rw1, 3, elems) }()
+
+	if err := ExpectMsg(rw2, 3, content); err != nil {
+		t.Error(err)
+	}
+	if err := <-errc; err != nil {
+		t.Errorf("SendRawList error: %v", err)
+	}
+}
+
 func TestMsgPipeConcurrentClose(t *testing.T) {
 	rw1, _ := MsgPipe()
 	for i := 0; i < 10; i++ {
@@ -150,3 +172,38 @@ func unhex(str string) []byte {
 	}
 	return b
 }
+
+func TestMsgDecodeLimits(t *testing.T) {
+	encode := func(val interface{}) Msg {
+		payload, err := rlp.EncodeToBytes(val)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return Msg{Code: 1, Size: uint32(len(payload)), Payload: bytes.NewReader(payload)}
+	}
+	tests := []struct {
+		val  interface{}
+		want string
+	}{
+		{make([]byte, msgDecodeLimits.MaxStringSize), ""},
+		{make([]byte, msgDecodeLimits.MaxStringSize+1), "string size exceeds limit"},
+		{make([]uint, msgDecodeLimits.MaxListElems), ""},
+		{make([]uint, msgDecodeLimits.MaxListElems+1), "exceeds element limit"},
+	}
+	for i, test := range tests {
+		var out interface{}
+		switch test.val.(type) {
+		case []byte:
+			out = new([]byte)
+		case []uint:
+			out = new([]uint)
+		}
+		err := encode(test.val).Decode(out)
+		switch {
+		case test.want == "" && err != nil:
+			t.Errorf("test %d: unexpected error: %v", i, err)
+		case test.want != "" && (err == nil || !strings.Contains(err.Error(), test.want)):
+			t.Errorf("test %d: error mismatch: got %v, want %v", i, err, test.want)
+		}
+	}
+}
@@ -22,6 +22,7 @@ import (
 	"net"
 
 	"github.com/expanse-project/go-expanse/metrics"
+	gometrics "github.com/rcrowley/go-metrics"
 )
 
 var (
@@ -29,8 +30,31 @@ var (
 	ingressTrafficMeter = metrics.NewMeter("p2p/InboundTraffic")
 	egressConnectMeter  = metrics.NewMeter("p2p/OutboundConnects")
 	egressTrafficMeter  = metrics.NewMeter("p2p/OutboundTraffic")
+
+	handshakeTimeoutMeter      = metrics.NewMeter("p2p/handshake/failed/timeout")    // either handshake timed out
+	handshakeDisconnectMeter   = metrics.NewMeter("p2p/handshake/failed/disconnect") // remote side disconnected with a reason
+	handshakeIdentityMeter     = metrics.NewMeter("p2p/handshake/failed/identity")   // remote identity didn't match
+	handshakeRejectedMeter     = metrics.NewMeter("p2p/handshake/failed/rejected")   // rejected locally, e.g. too many peers
+	encHandshakeFailureMeter   = metrics.NewMeter("p2p/handshake/failed/enc")        // other encryption handshake errors
+	protoHandshakeFailureMeter = metrics.NewMeter("p2p/handshake/failed/proto")      // other protocol handshake errors
 )
 
+// markHandshakeFailure meters a failed handshake by its cause, falling back to
+// the meter of the handshake phase if the cause is not a common one.
+func markHandshakeFailure(phase gometrics.Meter, err error) {
+	switch err := err.(type) {
+	case net.Error:
+		if err.Timeout() {
+			handshakeTimeoutMeter.Mark(1)
+			return
+		}
+	case DiscReason:
+		handshakeDisconnectMeter.Mark(1)
+		return
+	}
+	phase.Mark(1)
+}
+
 // meteredConn is a wrapper around a network TCP connection that meters both the
 // inbound and outbound network traffic.
 type meteredConn struct {
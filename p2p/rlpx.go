@@ -37,7 +37,6 @@ import (
 
 	"github.com/expanse-project/go-expanse/crypto"
 	"github.com/expanse-project/go-expanse/crypto/ecies"
-	"github.com/expanse-project/go-expanse/crypto/secp256k1"
 	"github.com/expanse-project/go-expanse/crypto/sha3"
 	"github.com/expanse-project/go-expanse/p2p/discover"
 	"github.com/expanse-project/go-expanse/rlp"
@@ -59,8 +58,8 @@ const (
 	encAuthMsgLen  = authMsgLen + eciesOverhead  // size of encrypted pre-EIP-8 initiator handshake
 	encAuthRespLen = authRespLen + eciesOverhead // size of encrypted pre-EIP-8 handshake reply
 
-	// total timeout for encryption handshake and protocol
-	// handshake in both directions.
+	// default timeouts for the encryption handshake and the protocol
+	// handshake, each in both directions.
 	handshakeTimeout = 5 * time.Second
 
 	// This is the timeout for sending the disconnect reason.
@@ -69,31 +68,51 @@ const (
 	discWriteTimeout = 1 * time.Second
 )
 
+// rlpxTimeouts are the deadlines of the different phases of an rlpx connection.
+type rlpxTimeouts struct {
+	encHandshake   time.Duration // encryption handshake
+	protoHandshake time.Duration // protocol handshake, after the encryption handshake
+	read           time.Duration // reading a complete message
+	write          time.Duration // writing a complete message
+}
+
+var defaultRLPxTimeouts = rlpxTimeouts{
+	encHandshake:   handshakeTimeout,
+	protoHandshake: handshakeTimeout,
+	read:           frameReadTimeout,
+	write:          frameWriteTimeout,
+}
+
 // rlpx is the transport protocol used by actual (non-test) connections.
 // It wraps the frame encoder with locks and read/write deadlines.
 type rlpx struct {
-	fd net.Conn
+	fd       net.Conn
+	timeouts rlpxTimeouts
 
 	rmu, wmu sync.Mutex
 	rw       *rlpxFrameRW
 }
 
 func newRLPX(fd net.Conn) transport {
-	fd.SetDeadline(time.Now().Add(handshakeTimeout))
-	return &rlpx{fd: fd}
+	return newRLPXWithTimeouts(fd, defaultRLPxTimeouts)
+}
+
+func newRLPXWithTimeouts(fd net.Conn, timeouts rlpxTimeouts) transport {
+	fd.SetDeadline(time.Now().Add(timeouts.encHandshake))
+	return &rlpx{fd: fd, timeouts: timeouts}
 }
 
 func (t *rlpx) ReadMsg() (Msg, error) {
 	t.rmu.Lock()
 	defer t.rmu.Unlock()
-	t.fd.SetReadDeadline(time.Now().Add(frameReadTimeout))
+	t.fd.SetReadDeadline(time.Now().Add(t.timeouts.read))
 	return t.rw.ReadMsg()
 }
 
 func (t *rlpx) WriteMsg(msg Msg) error {
 	t.wmu.Lock()
 	defer t.wmu.Unlock()
-	t.fd.SetWriteDeadline(time.Now().Add(frameWriteTimeout))
+	t.fd.SetWriteDeadline(time.Now().Add(t.timeouts.write))
 	return t.rw.WriteMsg(msg)
 }
 
@@ -119,6 +138,8 @@ func (t *rlpx) doProtoHandshake(our *protoHandshake) (their *protoHandshake, err
 	// returning the handshake read error. If the remote side
 	// disconnects us early with a valid reason, we should return it
 	// as the error so it can be tracked elsewhere.
+	t.fd.SetDeadline(time.Now().Add(t.timeouts.protoHandshake))
+
 	werr := make(chan error, 1)
 	go func() { werr <- Send(t.rw, handshakeMsg, our) }()
 	if their, err = readProtocolHandshake(t.rw, our); err != nil {
@@ -401,7 +422,7 @@ func (h *encHandshake) handleAuthMsg(msg *authMsgV4, prv *ecdsa.PrivateKey) erro
 		return err
 	}
 	signedMsg := xor(token, h.initNonce)
-	remoteRandomPub, err := secp256k1.RecoverPubkey(signedMsg, msg.Signature[:])
+	remoteRandomPub, err := crypto.Ecrecover(signedMsg, msg.Signature[:])
 	if err != nil {
 		return err
 	}
@@ -217,6 +217,39 @@ func TestProtocolHandshake(t *testing.T) {
 	wg.Wait()
 }
 
+func TestHandshakeTimeout(t *testing.T) {
+	prv, _ := crypto.GenerateKey()
+	fd0, fd1 := net.Pipe()
+	defer fd1.Close()
+
+	// The remote side never answers, the handshake has to give up in time.
+	timeouts := defaultRLPxTimeouts
+	timeouts.encHandshake = 50 * time.Millisecond
+	rlpx := newRLPXWithTimeouts(fd0, timeouts)
+	defer rlpx.close(nil)
+
+	start := time.Now()
+	_, err := rlpx.doEncHandshake(prv, nil)
+	if nerr, ok := err.(net.Error); !ok || !nerr.Timeout() {
+		t.Fatalf("expected timeout error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("handshake timed out too late: %v", elapsed)
+	}
+}
+
+func TestServerTimeouts(t *testing.T) {
+	srv := &Server{}
+	if timeouts := srv.timeouts(); timeouts != defaultRLPxTimeouts {
+		t.Errorf("default timeouts mismatch: have %+v, want %+v", timeouts, defaultRLPxTimeouts)
+	}
+	srv = &Server{HandshakeTimeout: time.Minute, ProtocolHandshakeTimeout: 2 * time.Minute, ReadTimeout: 3 * time.Minute}
+	want := rlpxTimeouts{encHandshake: time.Minute, protoHandshake: 2 * time.Minute, read: 3 * time.Minute, write: frameWriteTimeout}
+	if timeouts := srv.timeouts(); timeouts != want {
+		t.Errorf("configured timeouts mismatch: have %+v, want %+v", timeouts, want)
+	}
+}
+
 func TestProtocolHandshakeErrors(t *testing.T) {
 	our := &protoHandshake{Version: 3, Caps: []Cap{{"foo", 2}, {"bar", 3}}, Name: "quux"}
 	tests := []struct {
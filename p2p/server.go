@@ -121,6 +121,19 @@ type Server struct {
 	// If NoDial is true, the server will not dial any peers.
 	NoDial bool
 
+	// HandshakeTimeout is the maximum time allowed for the encryption handshake
+	// of a new connection. Zero defaults to 5 seconds.
+	HandshakeTimeout time.Duration
+
+	// ProtocolHandshakeTimeout is the maximum time allowed for the protocol
+	// handshake following the encryption handshake. Zero defaults to 5 seconds.
+	ProtocolHandshakeTimeout time.Duration
+
+	// ReadTimeout is the maximum time allowed for reading a complete message,
+	// which is effectively the time a connection may be idle. Zero defaults to
+	// 30 seconds.
+	ReadTimeout time.Duration
+
 	// Hooks for testing. These are useful because we can inhibit
 	// the whole protocol stack.
 	newTransport func(net.Conn) transport
@@ -140,6 +153,7 @@ type Server struct {
 
 	quit          chan struct{}
 	addstatic     chan *discover.Node
+	removestatic  chan *discover.Node
 	posthandshake chan *conn
 	addpeer       chan *conn
 	delpeer       chan *Peer
@@ -255,6 +269,15 @@ func (srv *Server) AddPeer(node *discover.Node) {
 	}
 }
 
+// RemovePeer disconnects from the given node and stops maintaining the
+// connection to it, undoing an earlier AddPeer.
+func (srv *Server) RemovePeer(node *discover.Node) {
+	select {
+	case srv.removestatic <- node:
+	case <-srv.quit:
+	}
+}
+
 // Self returns the local node's endpoint information.
 func (srv *Server) Self() *discover.Node {
 	srv.lock.Lock()
@@ -299,6 +322,21 @@ func (srv *Server) Stop() {
 	srv.loopWG.Wait()
 }
 
+// timeouts returns the connection timeouts configured for the server.
+func (srv *Server) timeouts() rlpxTimeouts {
+	timeouts := defaultRLPxTimeouts
+	if srv.HandshakeTimeout > 0 {
+		timeouts.encHandshake = srv.HandshakeTimeout
+	}
+	if srv.ProtocolHandshakeTimeout > 0 {
+		timeouts.protoHandshake = srv.ProtocolHandshakeTimeout
+	}
+	if srv.ReadTimeout > 0 {
+		timeouts.read = srv.ReadTimeout
+	}
+	return timeouts
+}
+
 // Start starts running the server.
 // Servers can not be re-used after stopping.
 func (srv *Server) Start() (err error) {
@@ -315,7 +353,8 @@ func (srv *Server) Start() (err error) {
 		return fmt.Errorf("Server.PrivateKey must be set to a non-nil key")
 	}
 	if srv.newTransport == nil {
-		srv.newTransport = newRLPX
+		timeouts := srv.timeouts()
+		srv.newTransport = func(fd net.Conn) transport { return newRLPXWithTimeouts(fd, timeouts) }
 	}
 	if srv.Dialer == nil {
 		srv.Dialer = &net.Dialer{Timeout: defaultDialTimeout}
@@ -325,6 +364,7 @@ func (srv *Server) Start() (err error) {
 	srv.delpeer = make(chan *Peer)
 	srv.posthandshake = make(chan *conn)
 	srv.addstatic = make(chan *discover.Node)
+	srv.removestatic = make(chan *discover.Node)
 	srv.peerOp = make(chan peerOpFunc)
 	srv.peerOpDone = make(chan struct{})
 
@@ -390,6 +430,7 @@ type dialer interface {
 	newTasks(running int, peers map[discover.NodeID]*Peer, now time.Time) []task
 	taskDone(task, time.Time)
 	addStatic(*discover.Node)
+	removeStatic(*discover.Node)
 }
 
 func (srv *Server) run(dialstate dialer) {
@@ -454,6 +495,14 @@ running:
 			// it will keep the node connected.
 			glog.V(logger.Detail).Infoln("<-addstatic:", n)
 			dialstate.addStatic(n)
+		case n := <-srv.removestatic:
+			// This channel is used by RemovePeer to drop a node
+			// from the static peer list and disconnect it.
+			glog.V(logger.Detail).Infoln("<-removestatic:", n)
+			dialstate.removeStatic(n)
+			if p, ok := peers[n.ID]; ok {
+				p.Disconnect(DiscRequested)
+			}
 		case op := <-srv.peerOp:
 			// This channel is used by Peers and PeerCount.
 			op(peers)
@@ -611,6 +660,7 @@ func (srv *Server) setupConn(fd net.Conn, flags connFlag, dialDest *discover.Nod
 	var err error
 	if c.id, err = c.doEncHandshake(srv.PrivateKey, dialDest); err != nil {
 		glog.V(logger.Debug).Infof("%v faild enc handshake: %v", c, err)
+		markHandshakeFailure(encHandshakeFailureMeter, err)
 		c.close(err)
 		return
 	}
@@ -618,10 +668,12 @@ func (srv *Server) setupConn(fd net.Conn, flags connFlag, dialDest *discover.Nod
 	if dialDest != nil && c.id != dialDest.ID {
 		c.close(DiscUnexpectedIdentity)
 		glog.V(logger.Debug).Infof("%v dialed identity mismatch, want %x", c, dialDest.ID[:8])
+		handshakeIdentityMeter.Mark(1)
 		return
 	}
 	if err := srv.checkpoint(c, srv.posthandshake); err != nil {
 		glog.V(logger.Debug).Infof("%v failed checkpoint posthandshake: %v", c, err)
+		handshakeRejectedMeter.Mark(1)
 		c.close(err)
 		return
 	}
@@ -629,17 +681,20 @@ func (srv *Server) setupConn(fd net.Conn, flags connFlag, dialDest *discover.Nod
 	phs, err := c.doProtoHandshake(srv.ourHandshake)
 	if err != nil {
 		glog.V(logger.Debug).Infof("%v failed proto handshake: %v", c, err)
+		markHandshakeFailure(protoHandshakeFailureMeter, err)
 		c.close(err)
 		return
 	}
 	if phs.ID != c.id {
 		glog.V(logger.Debug).Infof("%v wrong proto handshake identity: %x", c, phs.ID[:8])
+		handshakeIdentityMeter.Mark(1)
 		c.close(DiscUnexpectedIdentity)
 		return
 	}
 	c.caps, c.name = phs.Caps, phs.Name
 	if err := srv.checkpoint(c, srv.addpeer); err != nil {
 		glog.V(logger.Debug).Infof("%v failed checkpoint addpeer: %v", c, err)
+		handshakeRejectedMeter.Mark(1)
 		c.close(err)
 		return
 	}
@@ -700,8 +755,14 @@ type NodeInfo struct {
 		Discovery int `json:"discovery"` // UDP listening port for discovery protocol
 		Listener  int `json:"listener"`  // TCP listening port for RLPx
 	} `json:"ports"`
-	ListenAddr string                 `json:"listenAddr"`
-	Protocols  map[string]interface{} `json:"protocols"`
+	ListenAddr string `json:"listenAddr"`
+	Network    struct {
+		LocalAddress    string `json:"localAddress"`    // Local endpoint of the TCP listener
+		ExternalAddress string `json:"externalAddress"` // Endpoint advertised to remote peers after NAT mapping
+		NAT             string `json:"nat"`             // Port mapping mechanism in use, if any
+	} `json:"network"`
+	Caps      []string               `json:"caps"` // Sub-protocols enabled on this node
+	Protocols map[string]interface{} `json:"protocols"`
 }
 
 // Info gathers and returns a collection of metadata known about the host.
@@ -720,8 +781,21 @@ func (srv *Server) NodeInfo() *NodeInfo {
 	info.Ports.Discovery = int(node.UDP)
 	info.Ports.Listener = int(node.TCP)
 
+	// Gather the addresses the node is reachable on
+	srv.lock.Lock()
+	if srv.listener != nil {
+		info.Network.LocalAddress = srv.listener.Addr().String()
+	}
+	srv.lock.Unlock()
+	if node.TCP != 0 {
+		info.Network.ExternalAddress = (&net.TCPAddr{IP: node.IP, Port: int(node.TCP)}).String()
+	}
+	if srv.NAT != nil {
+		info.Network.NAT = srv.NAT.String()
+	}
 	// Gather all the running protocol infos (only once per protocol type)
 	for _, proto := range srv.Protocols {
+		info.Caps = append(info.Caps, proto.cap().String())
 		if _, ok := info.Protocols[proto.Name]; !ok {
 			nodeInfo := interface{}("unknown")
 			if query := proto.NodeInfo; query != nil {
@@ -119,6 +119,41 @@ func TestServerListen(t *testing.T) {
 	}
 }
 
+func TestServerNodeInfo(t *testing.T) {
+	srv := &Server{
+		Name:       "test",
+		MaxPeers:   10,
+		ListenAddr: "127.0.0.1:0",
+		PrivateKey: newkey(),
+		Protocols: []Protocol{
+			{Name: "foo", Version: 1},
+			{Name: "foo", Version: 2},
+			{Name: "bar", Version: 1, NodeInfo: func() interface{} { return "bar info" }},
+		},
+	}
+	if err := srv.Start(); err != nil {
+		t.Fatalf("could not start server: %v", err)
+	}
+	defer srv.Stop()
+
+	info := srv.NodeInfo()
+	if info.Network.LocalAddress != srv.ListenAddr {
+		t.Errorf("local address mismatch: have %s, want %s", info.Network.LocalAddress, srv.ListenAddr)
+	}
+	if info.Network.ExternalAddress != srv.ListenAddr {
+		t.Errorf("external address mismatch: have %s, want %s", info.Network.ExternalAddress, srv.ListenAddr)
+	}
+	if info.Network.NAT != "" {
+		t.Errorf("unexpected NAT mechanism: %s", info.Network.NAT)
+	}
+	if want := []string{"foo/1", "foo/2", "bar/1"}; !reflect.DeepEqual(info.Caps, want) {
+		t.Errorf("caps mismatch: have %v, want %v", info.Caps, want)
+	}
+	if info.Protocols["bar"] != "bar info" || info.Protocols["foo"] != "unknown" {
+		t.Errorf("protocol infos mismatch: %v", info.Protocols)
+	}
+}
+
 func TestServerDial(t *testing.T) {
 	// run a one-shot TCP server to handle the connection.
 	listener, err := net.Listen("tcp", "127.0.0.1:0")
@@ -248,6 +283,8 @@ func (tg taskgen) taskDone(t task, now time.Time) {
 }
 func (tg taskgen) addStatic(*discover.Node) {
 }
+func (tg taskgen) removeStatic(*discover.Node) {
+}
 
 type testTask struct {
 	index  int
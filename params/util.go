@@ -22,6 +22,11 @@ var (
 	TestNetHomesteadBlock = big.NewInt(1000)    // testnet homestead block
 	MainNetHomesteadBlock = big.NewInt(200000)   // mainnet homestead block
 	HomesteadBlock        = MainNetHomesteadBlock // homestead block used to check against
+
+	ChainId     *big.Int // chain identifier for replay protected signatures (nil = not configured)
+	EIP155Block *big.Int // first block accepting replay protected signatures (nil = not scheduled)
+
+	MaxTransactionSize uint64 = 0 // maximum RLP encoded size of a transaction in a block (0 = no limit), set by the genesis config
 )
 
 func IsHomestead(blockNumber *big.Int) bool {
@@ -31,3 +36,13 @@ func IsHomestead(blockNumber *big.Int) bool {
 	}
 	return blockNumber.Cmp(HomesteadBlock) >= 0
 }
+
+// IsEIP155 returns whether replay protected transaction signatures (EIP-155)
+// are valid in the block with the given number. Unprotected signatures remain
+// valid after the fork, so clients can move to protected ones in their time.
+func IsEIP155(blockNumber *big.Int) bool {
+	if blockNumber == nil || ChainId == nil || EIP155Block == nil {
+		return false
+	}
+	return blockNumber.Cmp(EIP155Block) >= 0
+}
@@ -0,0 +1,221 @@
+// Copyright 2016 The go-expanse Authors
+// This file is part of the go-expanse library.
+//
+// The go-expanse library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-expanse library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-expanse library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethash
+
+import (
+	"encoding/binary"
+	"hash"
+
+	"github.com/expanse-project/go-expanse/common"
+	"github.com/expanse-project/go-expanse/crypto"
+	"github.com/expanse-project/go-expanse/crypto/sha3"
+)
+
+const (
+	revision           = 23      // revision of the algorithm, part of cache file names
+	epochLength        = 30000   // blocks per epoch
+	maxEpoch           = 2048    // epochs the algorithm is defined for
+	datasetInitBytes   = 1 << 30 // bytes in dataset at genesis
+	datasetGrowthBytes = 1 << 23 // dataset growth per epoch
+	cacheInitBytes     = 1 << 24 // bytes in cache at genesis
+	cacheGrowthBytes   = 1 << 17 // cache growth per epoch
+	mixBytes           = 128     // width of mix
+	hashBytes          = 64      // hash length in bytes
+	hashWords          = 16      // number of 32 bit ints in a hash
+	datasetParents     = 256     // number of parents of each dataset element
+	cacheRounds        = 3       // number of rounds in cache production
+	loopAccesses       = 64      // number of accesses in hashimoto loop
+
+	cacheSizeForTesting = 1024      // cache size of testing instances
+	dagSizeForTesting   = 32 * 1024 // dataset size of testing instances
+)
+
+// cacheSize calculates the size of the verification cache of the epoch the
+// block belongs to, the largest multiple of hashBytes below the linear growth
+// limit whose number of items is prime.
+func cacheSize(block uint64) uint64 {
+	size := cacheInitBytes + cacheGrowthBytes*(block/epochLength) - hashBytes
+	for !isPrime(size / hashBytes) {
+		size -= 2 * hashBytes
+	}
+	return size
+}
+
+// datasetSize calculates the size of the full dataset (DAG) of the epoch the
+// block belongs to, in the same way as cacheSize.
+func datasetSize(block uint64) uint64 {
+	size := datasetInitBytes + datasetGrowthBytes*(block/epochLength) - mixBytes
+	for !isPrime(size / mixBytes) {
+		size -= 2 * mixBytes
+	}
+	return size
+}
+
+// isPrime checks n for primality by trial division, which is exact and fast
+// enough for the item counts of caches and datasets.
+func isPrime(n uint64) bool {
+	if n < 2 {
+		return false
+	}
+	if n%2 == 0 {
+		return n == 2
+	}
+	for i := uint64(3); i*i <= n; i += 2 {
+		if n%i == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// seedHash is the seed of the cache and dataset of an epoch, the Keccak-256
+// hash of 32 zero bytes applied once per epoch.
+func seedHash(epoch uint64) (seed common.Hash) {
+	for ; epoch > 0; epoch-- {
+		seed = crypto.Sha3Hash(seed[:])
+	}
+	return seed
+}
+
+// hasher is a reusable Keccak-512 hash function writing its output into dest,
+// which must have room for hashBytes.
+type hasher func(dest []byte, data []byte)
+
+func makeHasher(h hash.Hash) hasher {
+	return func(dest []byte, data []byte) {
+		h.Reset()
+		h.Write(data)
+		h.Sum(dest[:0])
+	}
+}
+
+// generateCache creates a verification cache of size bytes from the seed: a
+// sequential chain of Keccak-512 hashes, mixed by a few rounds of RandMemoHash.
+// The cache is returned in its little endian byte form, as stored on disk.
+func generateCache(size uint64, seed common.Hash) []byte {
+	keccak512 := makeHasher(sha3.NewKeccak512())
+
+	cache := make([]byte, size)
+	keccak512(cache, seed[:])
+	for offset := uint64(hashBytes); offset < size; offset += hashBytes {
+		keccak512(cache[offset:], cache[offset-hashBytes:offset])
+	}
+	rows := int(size / hashBytes)
+	temp := make([]byte, hashBytes)
+	for i := 0; i < cacheRounds; i++ {
+		for j := 0; j < rows; j++ {
+			var (
+				src = ((j - 1 + rows) % rows) * hashBytes
+				dst = j * hashBytes
+				xor = int(binary.LittleEndian.Uint32(cache[dst:])%uint32(rows)) * hashBytes
+			)
+			for k := 0; k < hashBytes; k++ {
+				temp[k] = cache[src+k] ^ cache[xor+k]
+			}
+			keccak512(cache[dst:], temp)
+		}
+	}
+	return cache
+}
+
+// cacheWords converts a cache from its byte form into the 32 bit words the
+// dataset generation operates on.
+func cacheWords(cache []byte) []uint32 {
+	words := make([]uint32, len(cache)/4)
+	for i := range words {
+		words[i] = binary.LittleEndian.Uint32(cache[i*4:])
+	}
+	return words
+}
+
+// fnv is the FNV-1 inspired mixing function of the algorithm, a non-associative
+// substitute for XOR.
+func fnv(a, b uint32) uint32 {
+	return a*0x01000193 ^ b
+}
+
+// fnvHash mixes data into mix using fnv, word by word.
+func fnvHash(mix []uint32, data []uint32) {
+	for i := 0; i < len(mix); i++ {
+		mix[i] = mix[i]*0x01000193 ^ data[i]
+	}
+}
+
+// generateDatasetItem computes the dataset item of the given index from the
+// cache, writing its words into item.
+func generateDatasetItem(cache []uint32, index uint32, keccak512 hasher, item []uint32) {
+	rows := uint32(len(cache) / hashWords)
+
+	mix := make([]byte, hashBytes)
+	for i := 0; i < hashWords; i++ {
+		binary.LittleEndian.PutUint32(mix[i*4:], cache[(index%rows)*hashWords+uint32(i)])
+	}
+	binary.LittleEndian.PutUint32(mix, binary.LittleEndian.Uint32(mix)^index)
+	keccak512(mix, mix)
+
+	for i := range item[:hashWords] {
+		item[i] = binary.LittleEndian.Uint32(mix[i*4:])
+	}
+	for i := uint32(0); i < datasetParents; i++ {
+		parent := fnv(index^i, item[i%hashWords]) % rows
+		fnvHash(item[:hashWords], cache[parent*hashWords:])
+	}
+	for i, word := range item[:hashWords] {
+		binary.LittleEndian.PutUint32(mix[i*4:], word)
+	}
+	keccak512(mix, mix)
+	for i := range item[:hashWords] {
+		item[i] = binary.LittleEndian.Uint32(mix[i*4:])
+	}
+}
+
+// hashimotoLight computes the mix digest and the result of the proof of work
+// for a header hash and nonce, generating the dataset items it accesses from
+// the cache on the fly. datasetSize is the size of the full dataset in bytes.
+func hashimotoLight(datasetSize uint64, cache []uint32, hash common.Hash, nonce uint64) (digest common.Hash, result common.Hash) {
+	keccak512 := makeHasher(sha3.NewKeccak512())
+	rows := uint32(datasetSize / mixBytes)
+
+	// Combine the header and the nonce into the seed of the mix
+	seed := make([]byte, hashBytes)
+	copy(seed, hash[:])
+	binary.LittleEndian.PutUint64(seed[32:], nonce)
+	keccak512(seed, seed[:40])
+	seedHead := binary.LittleEndian.Uint32(seed)
+
+	mix := make([]uint32, mixBytes/4)
+	for i := range mix {
+		mix[i] = binary.LittleEndian.Uint32(seed[i%hashWords*4:])
+	}
+	// Mix in random dataset items, two of them per access
+	temp := make([]uint32, len(mix))
+	for i := 0; i < loopAccesses; i++ {
+		parent := fnv(uint32(i)^seedHead, mix[i%len(mix)]) % rows
+		for j := uint32(0); j < mixBytes/hashBytes; j++ {
+			generateDatasetItem(cache, 2*parent+j, keccak512, temp[j*hashWords:])
+		}
+		fnvHash(mix, temp)
+	}
+	// Compress the mix into the digest
+	for i := 0; i < len(mix); i += 4 {
+		mix[i/4] = fnv(fnv(fnv(mix[i], mix[i+1]), mix[i+2]), mix[i+3])
+	}
+	for i, word := range mix[:len(mix)/4] {
+		binary.LittleEndian.PutUint32(digest[i*4:], word)
+	}
+	return digest, crypto.Sha3Hash(seed, digest[:])
+}
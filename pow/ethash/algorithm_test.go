@@ -0,0 +1,107 @@
+// Copyright 2016 The go-expanse Authors
+// This file is part of the go-expanse library.
+//
+// The go-expanse library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-expanse library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-expanse library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethash
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/expanse-project/go-expanse/common"
+)
+
+// Tests that cache and dataset sizes match the tables of the C implementation.
+func TestSizes(t *testing.T) {
+	tests := []struct {
+		epoch   uint64
+		cache   uint64
+		dataset uint64
+	}{
+		{0, 16776896, 1073739904},
+		{1, 16907456, 1082130304},
+		{4, 17301056, 1107293056},
+		{2047, 285081536, 18245220736},
+	}
+	for _, tt := range tests {
+		if size := cacheSize(tt.epoch * epochLength); size != tt.cache {
+			t.Errorf("epoch %d: cache size mismatch: have %d, want %d", tt.epoch, size, tt.cache)
+		}
+		if size := datasetSize(tt.epoch*epochLength + epochLength - 1); size != tt.dataset {
+			t.Errorf("epoch %d: dataset size mismatch: have %d, want %d", tt.epoch, size, tt.dataset)
+		}
+	}
+}
+
+type testBlock struct {
+	difficulty  *big.Int
+	hashNoNonce common.Hash
+	nonce       uint64
+	mixDigest   common.Hash
+	number      uint64
+}
+
+func (b *testBlock) Difficulty() *big.Int     { return b.difficulty }
+func (b *testBlock) HashNoNonce() common.Hash { return b.hashNoNonce }
+func (b *testBlock) Nonce() uint64            { return b.nonce }
+func (b *testBlock) MixDigest() common.Hash   { return b.mixDigest }
+func (b *testBlock) NumberU64() uint64        { return b.number }
+
+// Blocks of the proof of concept nine testnet, one per epoch.
+var validBlocks = []*testBlock{
+	{
+		number:      22,
+		hashNoNonce: common.HexToHash("372eca2454ead349c3df0ab5d00b0b706b23e49d469387db91811cee0358fc6d"),
+		difficulty:  big.NewInt(132416),
+		nonce:       0x495732e0ed7a801c,
+		mixDigest:   common.HexToHash("2f74cdeb198af0b9abe65d22d372e22fb2d474371774a9583c1cc427a07939f5"),
+	},
+	{
+		number:      30001,
+		hashNoNonce: common.HexToHash("7e44356ee3441623bc72a683fd3708fdf75e971bbe294f33e539eedad4b92b34"),
+		difficulty:  big.NewInt(1532671),
+		nonce:       0x318df1c8adef7e5e,
+		mixDigest:   common.HexToHash("144b180aad09ae3c81fb07be92c8e6351b5646dda80e6844ae1b697e55ddde84"),
+	},
+	{
+		number:      60000,
+		hashNoNonce: common.HexToHash("5fc898f16035bf5ac9c6d9077ae1e3d5fc1ecc3c9fd5bee8bb00e810fdacbaa0"),
+		difficulty:  big.NewInt(2467358),
+		nonce:       0x50377003e5d830ca,
+		mixDigest:   common.HexToHash("ab546a5b73c452ae86dadd36f0ed83a6745226717d3798832d1b20b489e82063"),
+	},
+}
+
+func TestVerifyValid(t *testing.T) {
+	light := new(Light)
+	for i, block := range validBlocks {
+		if !light.Verify(block) {
+			t.Errorf("block %d (%x) did not validate", i, block.hashNoNonce[:6])
+		}
+		invalid := *block
+		invalid.nonce++
+		if light.Verify(&invalid) {
+			t.Errorf("block %d (%x) validated with wrong nonce", i, block.hashNoNonce[:6])
+		}
+	}
+}
+
+func TestVerifyInvalidDifficulty(t *testing.T) {
+	block := *validBlocks[0]
+	block.difficulty = new(big.Int)
+	if new(Light).Verify(&block) {
+		t.Errorf("block with zero difficulty validated")
+	}
+}
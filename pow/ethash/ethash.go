@@ -0,0 +1,248 @@
+// Copyright 2016 The go-expanse Authors
+// This file is part of the go-expanse library.
+//
+// The go-expanse library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-expanse library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-expanse library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package ethash implements the ethash proof of work. Verification is done in
+// pure Go; nonce searching uses the DAG of the C implementation, or computes the
+// dataset items from the cache in builds with the nocgo tag.
+package ethash
+
+import (
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"math/rand"
+	"os"
+	"os/user"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/expanse-project/go-expanse/common"
+	"github.com/expanse-project/go-expanse/logger"
+	"github.com/expanse-project/go-expanse/logger/glog"
+	"github.com/expanse-project/go-expanse/pow"
+)
+
+var (
+	// CachesInMem is the number of verification caches kept in memory by
+	// Light, the least recently used ones being dropped first.
+	CachesInMem = 2
+
+	// CachesOnDisk is the number of verification caches kept in CacheDir,
+	// the oldest files being removed first.
+	CachesOnDisk = 3
+
+	// CacheDir is the directory verification caches are stored in, so they
+	// don't need to be generated again after a restart. Empty disables it.
+	CacheDir = ""
+
+	// DefaultDir is the directory DAG files are stored in.
+	DefaultDir = defaultDir()
+)
+
+var (
+	maxUint256  = new(big.Int).Exp(big.NewInt(2), big.NewInt(256), big.NewInt(0))
+	sharedLight = new(Light)
+)
+
+func defaultDir() string {
+	home := os.Getenv("HOME")
+	if user, err := user.Current(); err == nil {
+		home = user.HomeDir
+	}
+	if runtime.GOOS == "windows" {
+		return filepath.Join(home, "AppData", "Ethash")
+	}
+	return filepath.Join(home, ".ethash")
+}
+
+// cache is the verification cache of an epoch, generated on first use.
+type cache struct {
+	epoch uint64
+	test  bool
+	dir   string    // directory the cache is stored in, empty if none
+	used  time.Time // last time the cache was used, protected by Light.mu
+
+	gen   sync.Once // ensures cache is only generated once.
+	words []uint32
+}
+
+// generate creates the actual cache, loading it from disk if it was stored
+// before. It can be called from multiple goroutines, the first call generates
+// the cache and subsequent calls wait until it is done.
+func (c *cache) generate() {
+	c.gen.Do(func() {
+		started := time.Now()
+		seed := seedHash(c.epoch)
+		size := cacheSize(c.epoch * epochLength)
+		if c.test {
+			size = cacheSizeForTesting
+		}
+		path := ""
+		if c.dir != "" && !c.test {
+			path = filepath.Join(c.dir, fmt.Sprintf("cache-R%d-%x", revision, seed[:8]))
+			if data, err := ioutil.ReadFile(path); err == nil && uint64(len(data)) == size {
+				c.words = cacheWords(data)
+				glog.V(logger.Debug).Infof("Loaded cache for epoch %d from %s", c.epoch, path)
+				return
+			}
+		}
+		glog.V(logger.Debug).Infof("Generating cache for epoch %d (%x)", c.epoch, seed)
+		data := generateCache(size, seed)
+		c.words = cacheWords(data)
+		glog.V(logger.Debug).Infof("Done generating cache for epoch %d, it took %v", c.epoch, time.Since(started))
+
+		if path != "" {
+			if err := storeCache(path, data); err != nil {
+				glog.V(logger.Warn).Infof("Failed to store cache for epoch %d: %v", c.epoch, err)
+			}
+			pruneCaches(c.dir, CachesOnDisk)
+		}
+	})
+}
+
+// storeCache writes the contents of a cache to path, atomically replacing any
+// previous file.
+func storeCache(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp := fmt.Sprintf("%s.%d", path, rand.Int())
+	if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// pruneCaches removes all but the limit most recently written caches of dir.
+func pruneCaches(dir string, limit int) {
+	files, err := filepath.Glob(filepath.Join(dir, "cache-R*"))
+	if err != nil || len(files) <= limit {
+		return
+	}
+	infos := make([]os.FileInfo, 0, len(files))
+	for _, file := range files {
+		if info, err := os.Stat(file); err == nil {
+			infos = append(infos, info)
+		}
+	}
+	for len(infos) > limit {
+		oldest := 0
+		for i, info := range infos {
+			if info.ModTime().Before(infos[oldest].ModTime()) {
+				oldest = i
+			}
+		}
+		os.Remove(filepath.Join(dir, infos[oldest].Name()))
+		infos = append(infos[:oldest], infos[oldest+1:]...)
+	}
+}
+
+// Light implements the Verify half of the proof of work.
+// It uses small in-memory caches to verify the nonces
+// found by Full.
+type Light struct {
+	test   bool              // if set use a smaller cache size
+	mu     sync.Mutex        // protects caches and future
+	caches map[uint64]*cache // caches kept in memory, by epoch
+	future *cache            // cache of the next epoch, generated in the background
+}
+
+// Verify checks whether the block's nonce is valid.
+func (l *Light) Verify(block pow.Block) bool {
+	blockNum := block.NumberU64()
+	if blockNum >= epochLength*maxEpoch {
+		glog.V(logger.Debug).Infof("block number %d too high, limit is %d", blockNum, epochLength*maxEpoch)
+		return false
+	}
+	// Cannot happen if the header difficulty is validated before the proof of
+	// work, but can if they are checked in parallel.
+	difficulty := block.Difficulty()
+	if difficulty.Cmp(common.Big0) <= 0 {
+		glog.V(logger.Debug).Infof("invalid block difficulty")
+		return false
+	}
+	digest, result := l.compute(blockNum, block.HashNoNonce(), block.Nonce())
+
+	// avoid mixdigest malleability as it's not included in a block's "hashNononce"
+	if block.MixDigest() != digest {
+		return false
+	}
+	target := new(big.Int).Div(maxUint256, difficulty)
+	return result.Big().Cmp(target) <= 0
+}
+
+// compute runs hashimoto for a header hash and nonce on the cache of the
+// block's epoch.
+func (l *Light) compute(blockNum uint64, hash common.Hash, nonce uint64) (common.Hash, common.Hash) {
+	c := l.getCache(blockNum)
+	size := datasetSize(blockNum)
+	if l.test {
+		size = dagSizeForTesting
+	}
+	return hashimotoLight(size, c.words, hash, nonce)
+}
+
+func (l *Light) getCache(blockNum uint64) *cache {
+	epoch := blockNum / epochLength
+
+	l.mu.Lock()
+	if l.caches == nil {
+		l.caches = make(map[uint64]*cache)
+	}
+	c := l.caches[epoch]
+	if c == nil {
+		// Make room by dropping the least recently used caches.
+		for len(l.caches) > 0 && len(l.caches) >= CachesInMem {
+			var evict *cache
+			for _, cached := range l.caches {
+				if evict == nil || cached.used.Before(evict.used) {
+					evict = cached
+				}
+			}
+			delete(l.caches, evict.epoch)
+		}
+		if l.future != nil && l.future.epoch == epoch {
+			c, l.future = l.future, nil
+		} else {
+			c = &cache{epoch: epoch, test: l.test, dir: CacheDir}
+		}
+		l.caches[epoch] = c
+	}
+	c.used = time.Now()
+
+	// Generate the cache of the next epoch in the background, so verification
+	// doesn't stall on the epoch boundary.
+	if next := epoch + 1; l.caches[next] == nil && (l.future == nil || l.future.epoch != next) && next < maxEpoch {
+		l.future = &cache{epoch: next, test: l.test, dir: CacheDir}
+		go l.future.generate()
+	}
+	l.mu.Unlock()
+
+	// Wait for the cache to finish generating.
+	c.generate()
+	return c
+}
+
+// GetSeedHash returns the seed of the cache and DAG of the block's epoch.
+func GetSeedHash(blockNum uint64) ([]byte, error) {
+	if blockNum >= epochLength*maxEpoch {
+		return nil, fmt.Errorf("block number too high, limit is %d", epochLength*maxEpoch)
+	}
+	seed := seedHash(blockNum / epochLength)
+	return seed[:], nil
+}
@@ -0,0 +1,60 @@
+// Copyright 2016 The go-expanse Authors
+// This file is part of the go-expanse library.
+//
+// The go-expanse library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-expanse library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-expanse library. If not, see <http://www.gnu.org/licenses/>.
+
+// +build !nocgo
+
+package ethash
+
+import libethash "github.com/expanse-project/ethash"
+
+// Ethash combines block verification with Light and nonce searching with the
+// DAG based Full of the C implementation into a single proof of work.
+type Ethash struct {
+	*Light
+	*libethash.Full
+}
+
+// New creates an instance of the proof of work.
+// A single instance of Light is shared across all instances
+// created with New.
+func New() *Ethash {
+	full := libethash.New().Full
+	full.Dir = DefaultDir
+	return &Ethash{sharedLight, full}
+}
+
+// NewForTesting creates a proof of work for use in unit tests.
+// It uses a smaller DAG and cache size to keep test times low.
+// DAG files are stored in a temporary directory.
+//
+// Nonces found by a testing instance are not verifiable with a
+// regular-size cache.
+func NewForTesting() (*Ethash, error) {
+	pow, err := libethash.NewForTesting()
+	if err != nil {
+		return nil, err
+	}
+	return &Ethash{&Light{test: true}, pow.Full}, nil
+}
+
+// MakeDAG pre-generates a DAG file for the given block number in the
+// given directory. If dir is the empty string, DefaultDir is used.
+func MakeDAG(blockNum uint64, dir string) error {
+	if dir == "" {
+		dir = DefaultDir
+	}
+	return libethash.MakeDAG(blockNum, dir)
+}
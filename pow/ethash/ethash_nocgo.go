@@ -0,0 +1,120 @@
+// Copyright 2016 The go-expanse Authors
+// This file is part of the go-expanse library.
+//
+// The go-expanse library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-expanse library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-expanse library. If not, see <http://www.gnu.org/licenses/>.
+
+// +build nocgo
+
+package ethash
+
+import (
+	"errors"
+	"math/big"
+	"math/rand"
+	"sync/atomic"
+	"time"
+
+	"github.com/expanse-project/go-expanse/pow"
+)
+
+var errNoDAG = errors.New("DAG generation is not supported by builds without cgo")
+
+// Full implements the Search half of the proof of work without a DAG,
+// computing the dataset items of every nonce from the verification cache.
+// It is far slower than the DAG based search of cgo builds, which makes it
+// usable on low difficulty networks only.
+type Full struct {
+	light    *Light
+	turbo    bool
+	hashRate int32
+}
+
+func (pow *Full) Search(block pow.Block, stop <-chan struct{}, index int) (nonce uint64, mixDigest []byte) {
+	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+	diff := block.Difficulty()
+
+	i := int64(0)
+	start := time.Now().UnixNano()
+	previousHashrate := int32(0)
+
+	nonce = uint64(r.Int63())
+	hash := block.HashNoNonce()
+	target := new(big.Int).Div(maxUint256, diff)
+	for {
+		select {
+		case <-stop:
+			atomic.AddInt32(&pow.hashRate, -previousHashrate)
+			return 0, nil
+		default:
+			i++
+
+			// light hashing is slow, update the hash rate every 2^8 nonces
+			if i == 2 || ((i % (1 << 8)) == 0) {
+				elapsed := time.Now().UnixNano() - start
+				hashes := (float64(1e9) / float64(elapsed)) * float64(i)
+				hashrateDiff := int32(hashes) - previousHashrate
+				previousHashrate = int32(hashes)
+				atomic.AddInt32(&pow.hashRate, hashrateDiff)
+			}
+
+			digest, result := pow.light.compute(block.NumberU64(), hash, nonce)
+			if result.Big().Cmp(target) <= 0 {
+				atomic.AddInt32(&pow.hashRate, -previousHashrate)
+				return nonce, digest.Bytes()
+			}
+			nonce += 1
+		}
+
+		if !pow.turbo {
+			time.Sleep(20 * time.Microsecond)
+		}
+	}
+}
+
+func (pow *Full) GetHashrate() int64 {
+	return int64(atomic.LoadInt32(&pow.hashRate))
+}
+
+func (pow *Full) Turbo(on bool) {
+	pow.turbo = on
+}
+
+// Ethash combines block verification with Light and
+// nonce searching with Full into a single proof of work.
+type Ethash struct {
+	*Light
+	*Full
+}
+
+// New creates an instance of the proof of work.
+// A single instance of Light is shared across all instances
+// created with New.
+func New() *Ethash {
+	return &Ethash{sharedLight, &Full{light: sharedLight, turbo: true}}
+}
+
+// NewForTesting creates a proof of work for use in unit tests.
+// It uses a smaller DAG and cache size to keep test times low.
+//
+// Nonces found by a testing instance are not verifiable with a
+// regular-size cache.
+func NewForTesting() (*Ethash, error) {
+	light := &Light{test: true}
+	return &Ethash{light, &Full{light: light}}, nil
+}
+
+// MakeDAG would pre-generate a DAG file, which needs the C implementation.
+func MakeDAG(blockNum uint64, dir string) error {
+	return errNoDAG
+}
@@ -0,0 +1,82 @@
+// Copyright 2016 The go-expanse Authors
+// This file is part of the go-expanse library.
+//
+// The go-expanse library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-expanse library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-expanse library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethash
+
+import (
+	"io/ioutil"
+	"math/big"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/expanse-project/go-expanse/common"
+)
+
+// Tests that nonces found by Full verify with Light. With cgo this checks the
+// DAG based search of the C implementation against the Go verifier.
+func TestSearchVerify(t *testing.T) {
+	pow, err := NewForTesting()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pow.Turbo(true)
+
+	for _, number := range []uint64{1, epochLength + 1} {
+		block := &testBlock{number: number, difficulty: big.NewInt(10), hashNoNonce: common.BytesToHash([]byte{byte(number)})}
+		nonce, digest := pow.Search(block, nil, 0)
+		block.nonce = nonce
+		block.mixDigest = common.BytesToHash(digest)
+
+		// Verify the block concurrently to check for data races.
+		var wg sync.WaitGroup
+		wg.Add(10)
+		for i := 0; i < 10; i++ {
+			go func() {
+				defer wg.Done()
+				if !pow.Verify(block) {
+					t.Errorf("block %d could not be verified", number)
+				}
+			}()
+		}
+		wg.Wait()
+	}
+}
+
+// Tests that caches are stored on disk, loaded from there and pruned.
+func TestCacheDisk(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ethash-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for epoch := uint64(0); epoch < 3; epoch++ {
+		c := &cache{epoch: epoch, dir: dir}
+		c.generate()
+		loaded := &cache{epoch: epoch, dir: dir}
+		loaded.generate()
+		if len(loaded.words) != len(c.words) || loaded.words[len(c.words)-1] != c.words[len(c.words)-1] {
+			t.Fatalf("epoch %d: loaded cache mismatch", epoch)
+		}
+	}
+	defer func(old int) { CachesOnDisk = old }(CachesOnDisk)
+	CachesOnDisk = 1
+	pruneCaches(dir, CachesOnDisk)
+	if files, _ := ioutil.ReadDir(dir); len(files) != 1 {
+		t.Errorf("stored caches mismatch: have %d, want 1", len(files))
+	}
+}
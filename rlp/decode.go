@@ -155,6 +155,8 @@ func wrapStreamError(err error, typ reflect.Type) error {
 		return &decodeError{msg: "input string too long", typ: typ}
 	case errNotAtEOL:
 		return &decodeError{msg: "input list has too many elements", typ: typ}
+	case ErrTooManyElems:
+		return &decodeError{msg: "input list exceeds element limit", typ: typ}
 	}
 	return err
 }
@@ -318,6 +320,9 @@ func decodeListSlice(s *Stream, val reflect.Value, elemdec decoder) error {
 func decodeSliceElems(s *Stream, val reflect.Value, elemdec decoder) error {
 	i := 0
 	for ; ; i++ {
+		if max := s.limits.MaxListElems; max > 0 && uint64(i) >= max && s.MoreDataInList() {
+			return wrapStreamError(ErrTooManyElems, val.Type())
+		}
 		// grow slice if necessary
 		if i >= val.Cap() {
 			newcap := val.Cap() + val.Cap()/2
@@ -564,6 +569,12 @@ var (
 	ErrElemTooLarge   = errors.New("rlp: element is larger than containing list")
 	ErrValueTooLarge  = errors.New("rlp: value size exceeds available input length")
 
+	// These errors are reported if the input exceeds one of the
+	// limits configured using Stream.SetLimits.
+	ErrStringTooLarge = errors.New("rlp: string size exceeds limit")
+	ErrTooManyElems   = errors.New("rlp: list element count exceeds limit")
+	ErrListTooDeep    = errors.New("rlp: list nesting depth exceeds limit")
+
 	// This error is reported by DecodeBytes if the slice contains
 	// additional data after the first RLP value.
 	ErrMoreThanOneValue = errors.New("rlp: input contains more than one value")
@@ -608,6 +619,17 @@ type Stream struct {
 	byteval byte   // value of single byte in type tag
 	kinderr error  // error from last readKind
 	stack   []listpos
+	limits  Limits
+}
+
+// Limits restricts the values accepted by a Stream beyond the input
+// length limit. Decoders for untrusted input, e.g. network messages,
+// should set them to bound the amount of memory a single crafted
+// value can make the decoder allocate. A zero field means no limit.
+type Limits struct {
+	MaxStringSize uint64 // maximum size of a single string
+	MaxListElems  uint64 // maximum number of elements decoded into a slice
+	MaxListDepth  int    // maximum nesting depth of lists
 }
 
 type listpos struct{ pos, size uint64 }
@@ -689,6 +711,10 @@ func (s *Stream) Raw() ([]byte, error) {
 		return nil, err
 	}
 	if kind == String {
+		// Reject cases where single byte encoding should have been used.
+		if size == 1 && buf[start] < 128 {
+			return nil, ErrCanonSize
+		}
 		puthead(buf, 0x80, 0xB8, size)
 	} else {
 		puthead(buf, 0xC0, 0xF7, size)
@@ -765,12 +791,33 @@ func (s *Stream) List() (size uint64, err error) {
 	if kind != List {
 		return 0, ErrExpectedList
 	}
+	if max := s.limits.MaxListDepth; max > 0 && len(s.stack) >= max {
+		return 0, ErrListTooDeep
+	}
 	s.stack = append(s.stack, listpos{0, size})
 	s.kind = -1
 	s.size = 0
 	return size, nil
 }
 
+// SetLimits configures additional restrictions for values read from
+// the stream. The limits are kept across calls to Reset.
+func (s *Stream) SetLimits(limits Limits) {
+	s.limits = limits
+}
+
+// MoreDataInList reports whether the current list contains more
+// elements that have not been read yet. It can be used to decode
+// list elements one at a time without knowing their count in advance.
+// Outside of a list, MoreDataInList always returns false.
+func (s *Stream) MoreDataInList() bool {
+	if len(s.stack) == 0 {
+		return false
+	}
+	tos := s.stack[len(s.stack)-1]
+	return tos.pos < tos.size
+}
+
 // ListEnd returns to the enclosing list.
 // The input reader must be positioned at the end of a list.
 func (s *Stream) ListEnd() error {
@@ -896,6 +943,11 @@ func (s *Stream) Kind() (kind Kind, size uint64, err error) {
 				}
 			}
 		}
+		if s.kinderr == nil && s.kind == String {
+			if max := s.limits.MaxStringSize; max > 0 && s.size > max {
+				s.kinderr = ErrStringTooLarge
+			}
+		}
 	}
 	// Note: this might return a sticky error generated
 	// by an earlier call to readKind.
@@ -92,6 +92,13 @@ func TestStreamErrors(t *testing.T) {
 			return NewStream(bytes.NewReader(b), limit)
 		}
 	}
+	withLimits := func(limits Limits) func([]byte) *Stream {
+		return func(b []byte) *Stream {
+			s := NewStream(newPlainReader(b), 0)
+			s.SetLimits(limits)
+			return s
+		}
+	}
 
 	type calls []string
 	tests := []struct {
@@ -128,6 +135,8 @@ func TestStreamErrors(t *testing.T) {
 		{"8101", calls{"Bytes"}, nil, ErrCanonSize},
 		{"817F", calls{"Bytes"}, nil, ErrCanonSize},
 		{"8180", calls{"Bytes"}, nil, nil},
+		{"8101", calls{"Raw"}, nil, ErrCanonSize},
+		{"8180", calls{"Raw"}, nil, nil},
 		{"B800", calls{"Kind"}, withoutInputLimit, ErrCanonSize},
 		{"B90000", calls{"Kind"}, withoutInputLimit, ErrCanonSize},
 		{"B90055", calls{"Kind"}, withoutInputLimit, ErrCanonSize},
@@ -155,6 +164,14 @@ func TestStreamErrors(t *testing.T) {
 		{"BFFFFFFFFFFFFFFFFFFF", calls{"Bytes"}, nil, ErrValueTooLarge},
 		{"C801", calls{"List"}, nil, ErrValueTooLarge},
 
+		// Configured limits.
+		{"83010203", calls{"Bytes"}, withLimits(Limits{MaxStringSize: 2}), ErrStringTooLarge},
+		{"83010203", calls{"Raw"}, withLimits(Limits{MaxStringSize: 2}), ErrStringTooLarge},
+		{"BFFFFFFFFFFFFFFFFF", calls{"Bytes"}, withLimits(Limits{MaxStringSize: 1024}), ErrStringTooLarge},
+		{"82010203", calls{"Bytes"}, withLimits(Limits{MaxStringSize: 2}), nil},
+		{"C3C2C0", calls{"List", "List", "List"}, withLimits(Limits{MaxListDepth: 2}), ErrListTooDeep},
+		{"C2C1C0", calls{"List", "List", "List"}, withLimits(Limits{MaxListDepth: 3}), nil},
+
 		// Test for list element size check overflow.
 		{"CD04040404FFFFFFFFFFFFFFFFFF0303", calls{"List", "Uint", "Uint", "Uint", "Uint", "List"}, nil, ErrElemTooLarge},
 
@@ -255,6 +272,53 @@ func TestStreamList(t *testing.T) {
 	}
 }
 
+func TestStreamMoreDataInList(t *testing.T) {
+	s := NewStream(bytes.NewReader(unhex("C3010203")), 0)
+	if s.MoreDataInList() {
+		t.Fatal("MoreDataInList returned true outside of a list")
+	}
+	if _, err := s.List(); err != nil {
+		t.Fatalf("List error: %v", err)
+	}
+
+	var vals []uint64
+	for s.MoreDataInList() {
+		v, err := s.Uint()
+		if err != nil {
+			t.Fatalf("Uint error: %v", err)
+		}
+		vals = append(vals, v)
+	}
+	if !reflect.DeepEqual(vals, []uint64{1, 2, 3}) {
+		t.Errorf("decoded wrong elements, got %v, want [1 2 3]", vals)
+	}
+	if err := s.ListEnd(); err != nil {
+		t.Fatalf("ListEnd error: %v", err)
+	}
+	if s.MoreDataInList() {
+		t.Fatal("MoreDataInList returned true after ListEnd")
+	}
+}
+
+func TestDecodeListElemsLimit(t *testing.T) {
+	input := unhex("C3010203")
+
+	var ok []uint
+	s := NewStream(bytes.NewReader(input), 0)
+	s.SetLimits(Limits{MaxListElems: 3})
+	if err := s.Decode(&ok); err != nil {
+		t.Fatalf("unexpected error at limit: %v", err)
+	}
+
+	var tooMany []uint
+	s = NewStream(bytes.NewReader(input), 0)
+	s.SetLimits(Limits{MaxListElems: 2})
+	err := s.Decode(&tooMany)
+	if want := "rlp: input list exceeds element limit for []uint"; err == nil || err.Error() != want {
+		t.Errorf("error mismatch: got %v, want %q", err, want)
+	}
+}
+
 func TestStreamRaw(t *testing.T) {
 	s := NewStream(bytes.NewReader(unhex("C58401010101")), 0)
 	s.List()
@@ -17,6 +17,7 @@
 package rlp
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"math/big"
@@ -31,6 +32,15 @@ var (
 	EmptyList   = []byte{0xC0}
 )
 
+var (
+	// ErrListOverflow is returned by ListWriter if an element does
+	// not fit into the remaining declared content size of the list.
+	ErrListOverflow = errors.New("rlp: element exceeds declared list size")
+	// ErrListIncomplete is returned by ListWriter.Close if less
+	// content than declared has been written.
+	ErrListIncomplete = errors.New("rlp: list content shorter than declared size")
+)
+
 // Encoder is implemented by types that require custom
 // encoding rules or want to encode private fields.
 type Encoder interface {
@@ -158,6 +168,65 @@ func puthead(buf []byte, smalltag, largetag byte, size uint64) int {
 	}
 }
 
+// ListWriter writes the elements of an RLP list to an io.Writer as
+// they are encoded, without holding the whole list in memory. Since
+// the list header contains the total size of the list content, that
+// size must be known before the first element is written.
+//
+// ListWriter is not safe for concurrent use.
+type ListWriter struct {
+	w         io.Writer
+	remaining uint64
+}
+
+// NewListWriter writes the header of a list whose elements take up
+// contentSize bytes in total and returns a writer for the elements.
+func NewListWriter(w io.Writer, contentSize uint64) (*ListWriter, error) {
+	head := make([]byte, 9)
+	n := puthead(head, 0xC0, 0xF7, contentSize)
+	if _, err := w.Write(head[:n]); err != nil {
+		return nil, err
+	}
+	return &ListWriter{w: w, remaining: contentSize}, nil
+}
+
+// Encode writes the RLP encoding of val as the next list element.
+// Please see the documentation of Encode for the encoding rules.
+func (lw *ListWriter) Encode(val interface{}) error {
+	eb := encbufPool.Get().(*encbuf)
+	defer encbufPool.Put(eb)
+	eb.reset()
+	if err := eb.encode(val); err != nil {
+		return err
+	}
+	size := uint64(eb.size())
+	if size > lw.remaining {
+		return ErrListOverflow
+	}
+	lw.remaining -= size
+	return eb.toWriter(lw.w)
+}
+
+// List starts a nested list whose elements take up contentSize bytes
+// as the next list element. The elements of the nested list must be
+// written completely before writing further elements of lw.
+func (lw *ListWriter) List(contentSize uint64) (*ListWriter, error) {
+	if ListSize(contentSize) > lw.remaining {
+		return nil, ErrListOverflow
+	}
+	lw.remaining -= ListSize(contentSize)
+	return NewListWriter(lw.w, contentSize)
+}
+
+// Close verifies that all of the declared list content has been
+// written. It does not close the underlying writer.
+func (lw *ListWriter) Close() error {
+	if lw.remaining != 0 {
+		return ErrListIncomplete
+	}
+	return nil
+}
+
 // encbufs are pooled.
 var encbufPool = sync.Pool{
 	New: func() interface{} { return &encbuf{sizebuf: make([]byte, 9)} },
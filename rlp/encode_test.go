@@ -317,6 +317,72 @@ func TestEncodeToReaderPiecewise(t *testing.T) {
 	})
 }
 
+func TestListWriter(t *testing.T) {
+	elems := []interface{}{uint(1), "foo", []uint{2, 3}}
+
+	var size uint64
+	for _, elem := range elems {
+		n, _, err := EncodeToReader(elem)
+		if err != nil {
+			t.Fatalf("EncodeToReader error: %v", err)
+		}
+		size += uint64(n)
+	}
+	buf := new(bytes.Buffer)
+	lw, err := NewListWriter(buf, size)
+	if err != nil {
+		t.Fatalf("NewListWriter error: %v", err)
+	}
+	for _, elem := range elems {
+		if err := lw.Encode(elem); err != nil {
+			t.Fatalf("Encode error: %v", err)
+		}
+	}
+	if err := lw.Close(); err != nil {
+		t.Fatalf("Close error: %v", err)
+	}
+	want, _ := EncodeToBytes(elems)
+	if !bytes.Equal(buf.Bytes(), want) {
+		t.Errorf("output mismatch:\ngot  %X\nwant %X", buf.Bytes(), want)
+	}
+
+	// nested lists count towards the enclosing list
+	buf.Reset()
+	lw, _ = NewListWriter(buf, 1+ListSize(2))
+	lw.Encode(uint(1))
+	nested, err := lw.List(2)
+	if err != nil {
+		t.Fatalf("List error: %v", err)
+	}
+	nested.Encode(uint(2))
+	nested.Encode(uint(3))
+	if err := nested.Close(); err != nil {
+		t.Fatalf("nested Close error: %v", err)
+	}
+	if err := lw.Close(); err != nil {
+		t.Fatalf("Close error: %v", err)
+	}
+	want, _ = EncodeToBytes([]interface{}{uint(1), []uint{2, 3}})
+	if !bytes.Equal(buf.Bytes(), want) {
+		t.Errorf("nested output mismatch:\ngot  %X\nwant %X", buf.Bytes(), want)
+	}
+	if _, err := lw.List(0); err != ErrListOverflow {
+		t.Errorf("oversized nested list: got error %v, want %v", err, ErrListOverflow)
+	}
+
+	// declared sizes must be respected
+	lw, _ = NewListWriter(new(bytes.Buffer), 2)
+	if err := lw.Encode("foo"); err != ErrListOverflow {
+		t.Errorf("oversized element: got error %v, want %v", err, ErrListOverflow)
+	}
+	if err := lw.Encode(uint(1)); err != nil {
+		t.Fatalf("Encode error: %v", err)
+	}
+	if err := lw.Close(); err != ErrListIncomplete {
+		t.Errorf("incomplete list: got error %v, want %v", err, ErrListIncomplete)
+	}
+}
+
 // This is a regression test verifying that encReader
 // returns its encbuf to the pool only once.
 func TestEncodeToReaderReturnToPool(t *testing.T) {
@@ -20,7 +20,10 @@ import (
 	"fmt"
 	"io"
 	"math/big"
+	"net"
 	"os"
+	"path/filepath"
+	"sync"
 	"time"
 
 	"github.com/expanse-project/go-expanse/common"
@@ -46,9 +49,15 @@ const (
 )
 
 var (
+	// XEth instances serving the listeners started through the admin API, by
+	// transport. They are stopped together with the listener.
+	listenerMu    sync.Mutex
+	listenerXeths = make(map[string]*xeth.XEth)
+
 	// mapping between methods and handlers
 	AdminMapping = map[string]adminhandler{
 		"admin_addPeer":            (*adminApi).AddPeer,
+		"admin_removePeer":         (*adminApi).RemovePeer,
 		"admin_peers":              (*adminApi).Peers,
 		"admin_nodeInfo":           (*adminApi).NodeInfo,
 		"admin_exportChain":        (*adminApi).ExportChain,
@@ -56,8 +65,13 @@ var (
 		"admin_verbosity":          (*adminApi).Verbosity,
 		"admin_setSolc":            (*adminApi).SetSolc,
 		"admin_datadir":            (*adminApi).DataDir,
+		"admin_chainSyncStatus":    (*adminApi).ChainSyncStatus,
+		"admin_networkHeads":       (*adminApi).NetworkHeads,
+		"admin_databaseSize":       (*adminApi).DatabaseSize,
 		"admin_startRPC":           (*adminApi).StartRPC,
 		"admin_stopRPC":            (*adminApi).StopRPC,
+		"admin_startWS":            (*adminApi).StartWS,
+		"admin_stopWS":             (*adminApi).StopWS,
 		"admin_setGlobalRegistrar": (*adminApi).SetGlobalRegistrar,
 		"admin_setHashReg":         (*adminApi).SetHashReg,
 		"admin_setUrlHint":         (*adminApi).SetUrlHint,
@@ -71,6 +85,9 @@ var (
 		"admin_sleepBlocks":        (*adminApi).SleepBlocks,
 		"admin_sleep":              (*adminApi).Sleep,
 		"admin_enableUserAgent":    (*adminApi).EnableUserAgent,
+		"admin_addWebhook":         (*adminApi).AddWebhook,
+		"admin_removeWebhook":      (*adminApi).RemoveWebhook,
+		"admin_webhooks":           (*adminApi).Webhooks,
 	}
 )
 
@@ -136,6 +153,19 @@ func (self *adminApi) AddPeer(req *shared.Request) (interface{}, error) {
 	return false, err
 }
 
+func (self *adminApi) RemovePeer(req *shared.Request) (interface{}, error) {
+	args := new(AddPeerArgs)
+	if err := self.coder.Decode(req.Params, &args); err != nil {
+		return nil, shared.NewDecodeParamError(err.Error())
+	}
+
+	err := self.expanse.RemovePeer(args.Url)
+	if err == nil {
+		return true, nil
+	}
+	return false, err
+}
+
 func (self *adminApi) Peers(req *shared.Request) (interface{}, error) {
 	return self.expanse.Network().PeersInfo(), nil
 }
@@ -144,10 +174,74 @@ func (self *adminApi) NodeInfo(req *shared.Request) (interface{}, error) {
 	return self.expanse.Network().NodeInfo(), nil
 }
 
+// NetworkHeads reports the heads advertised by the connected peers, telling a
+// stalled local chain apart from a stalled network.
+func (self *adminApi) NetworkHeads(req *shared.Request) (interface{}, error) {
+	return self.expanse.NetworkHeads(), nil
+}
+
 func (self *adminApi) DataDir(req *shared.Request) (interface{}, error) {
 	return self.expanse.DataDir, nil
 }
 
+// ChainSyncStatus reports the progress of the downloader along with the heads
+// of the local chain.
+func (self *adminApi) ChainSyncStatus(req *shared.Request) (interface{}, error) {
+	origin, current, height := self.expanse.Downloader().Progress()
+	pulled, known := self.expanse.Downloader().StateProgress()
+	chain := self.expanse.BlockChain()
+	return &ChainSyncStatusRes{
+		Syncing:       self.expanse.Downloader().Synchronising(),
+		StartingBlock: origin,
+		CurrentBlock:  current,
+		HighestBlock:  height,
+		PulledStates:  pulled,
+		KnownStates:   known,
+		HeadHeader:    chain.CurrentHeader().Number.Uint64(),
+		HeadFastBlock: chain.CurrentFastBlock().NumberU64(),
+		HeadBlock:     chain.CurrentBlock().NumberU64(),
+		Peers:         self.expanse.Network().PeerCount(),
+	}, nil
+}
+
+// DatabaseSize reports the disk usage of the chain database, in total and by
+// the kind of data stored.
+func (self *adminApi) DatabaseSize(req *shared.Request) (interface{}, error) {
+	db, ok := self.expanse.ChainDb().(core.PrefixSizer)
+	if !ok {
+		return nil, shared.NewNotAvailableError(req.Method, "chain database size can't be inspected")
+	}
+	tables, err := core.DatabaseSizes(db)
+	if err != nil {
+		return nil, err
+	}
+	path := filepath.Join(self.expanse.DataDir, "chaindata")
+	total, err := dirSize(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewDatabaseSizeRes(path, total, tables), nil
+}
+
+// dirSize returns the total size of the files in dir and its subdirectories.
+// A missing directory, as used by in-memory databases, has zero size.
+func dirSize(dir string) (uint64, error) {
+	var size uint64
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			size += uint64(info.Size())
+		}
+		return nil
+	})
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	return size, err
+}
+
 func hasAllBlocks(chain *core.BlockChain, bs []*types.Block) bool {
 	for _, b := range bs {
 		if !chain.HasBlock(b.Hash()) {
@@ -253,12 +347,13 @@ func (self *adminApi) StartRPC(req *shared.Request) (interface{}, error) {
 		CorsDomain:    args.CorsDomain,
 	}
 
-	apis, err := ParseApiString(args.Apis, self.codec, self.xeth, self.expanse)
-	if err != nil {
-		return false, err
-	}
-
-	err = comms.StartHttp(cfg, self.codec, Merge(apis...))
+	err := self.startListener("rpc", func(x *xeth.XEth) error {
+		apis, err := ParseApiString(args.Apis, self.codec, x, self.expanse)
+		if err != nil {
+			return err
+		}
+		return comms.StartHttp(cfg, self.codec, Merge(apis...))
+	})
 	if err == nil {
 		return true, nil
 	}
@@ -267,9 +362,83 @@ func (self *adminApi) StartRPC(req *shared.Request) (interface{}, error) {
 
 func (self *adminApi) StopRPC(req *shared.Request) (interface{}, error) {
 	comms.StopHttp()
+	stopListenerXeth("rpc")
+	return true, nil
+}
+
+func (self *adminApi) StartWS(req *shared.Request) (interface{}, error) {
+	args := new(StartWSArgs)
+	if err := self.coder.Decode(req.Params, &args); err != nil {
+		return nil, shared.NewDecodeParamError(err.Error())
+	}
+
+	cfg := comms.WsConfig{
+		ListenAddress: args.ListenAddress,
+		ListenPort:    args.ListenPort,
+		Origins:       args.Origins,
+		MaxFrameSize:  args.MaxFrameSize,
+		Compression:   args.Compression,
+	}
+
+	err := self.startListener("ws", func(x *xeth.XEth) error {
+		// Validate the modules up front, the APIs are created per connection
+		if _, err := ParseApiNames(args.Apis); err != nil {
+			return err
+		}
+		return comms.StartWs(cfg, self.codec, func(net.Conn) (comms.Stopper, shared.ExpanseApi, error) {
+			session := x.Session()
+			apis, err := ParseApiString(args.Apis, self.codec, session, self.expanse)
+			if err != nil {
+				session.Stop()
+				return nil, nil, err
+			}
+			return session, Merge(apis...), nil
+		})
+	})
+	if err == nil {
+		return true, nil
+	}
+	return false, err
+}
+
+func (self *adminApi) StopWS(req *shared.Request) (interface{}, error) {
+	comms.StopWs()
+	stopListenerXeth("ws")
 	return true, nil
 }
 
+// startListener starts a listener serving its APIs through its own XEth, as the
+// XEth of the calling connection is torn down when it disconnects.
+func (self *adminApi) startListener(transport string, start func(*xeth.XEth) error) error {
+	listenerMu.Lock()
+	defer listenerMu.Unlock()
+
+	// A running listener keeps its XEth, starting it again is a no-op
+	x, running := listenerXeths[transport]
+	if !running {
+		x = self.xeth.Fork(transport)
+	}
+	if err := start(x); err != nil {
+		if !running {
+			x.Stop()
+		}
+		return err
+	}
+	listenerXeths[transport] = x
+	return nil
+}
+
+// stopListenerXeth stops the XEth of a listener started through the admin API.
+func stopListenerXeth(transport string) {
+	listenerMu.Lock()
+	defer listenerMu.Unlock()
+
+	if x := listenerXeths[transport]; x != nil {
+		x.Stop()
+		delete(listenerXeths, transport)
+	}
+}
+
 func (self *adminApi) SleepBlocks(req *shared.Request) (interface{}, error) {
 	args := new(SleepBlocksArgs)
 	if err := self.coder.Decode(req.Params, &args); err != nil {
@@ -468,3 +637,32 @@ func (self *adminApi) EnableUserAgent(req *shared.Request) (interface{}, error)
 	}
 	return true, nil
 }
+
+func (self *adminApi) AddWebhook(req *shared.Request) (interface{}, error) {
+	args := new(AddWebhookArgs)
+	if err := self.coder.Decode(req.Params, &args); err != nil {
+		return nil, shared.NewDecodeParamError(err.Error())
+	}
+
+	addrs := make([]common.Address, len(args.Addresses))
+	for i, addr := range args.Addresses {
+		addrs[i] = common.HexToAddress(addr)
+	}
+	if err := self.expanse.Notifier().Register(args.Url, addrs); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (self *adminApi) RemoveWebhook(req *shared.Request) (interface{}, error) {
+	args := new(RemoveWebhookArgs)
+	if err := self.coder.Decode(req.Params, &args); err != nil {
+		return nil, shared.NewDecodeParamError(err.Error())
+	}
+
+	return self.expanse.Notifier().Unregister(args.Url), nil
+}
+
+func (self *adminApi) Webhooks(req *shared.Request) (interface{}, error) {
+	return self.expanse.Notifier().Hooks(), nil
+}
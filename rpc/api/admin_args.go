@@ -19,7 +19,9 @@ package api
 import (
 	"encoding/json"
 
+	"github.com/expanse-project/go-expanse/common"
 	"github.com/expanse-project/go-expanse/common/compiler"
+	"github.com/expanse-project/go-expanse/rpc/comms"
 	"github.com/expanse-project/go-expanse/rpc/shared"
 )
 
@@ -46,6 +48,65 @@ func (args *AddPeerArgs) UnmarshalJSON(b []byte) (err error) {
 	return nil
 }
 
+type AddWebhookArgs struct {
+	Url       string
+	Addresses []string
+}
+
+func (args *AddWebhookArgs) UnmarshalJSON(b []byte) (err error) {
+	var obj []interface{}
+	if err := json.Unmarshal(b, &obj); err != nil {
+		return shared.NewDecodeParamError(err.Error())
+	}
+
+	if len(obj) < 2 {
+		return shared.NewInsufficientParamsError(len(obj), 2)
+	}
+
+	urlstr, ok := obj[0].(string)
+	if !ok {
+		return shared.NewInvalidTypeError("url", "not a string")
+	}
+	args.Url = urlstr
+
+	addrs, ok := obj[1].([]interface{})
+	if !ok {
+		return shared.NewInvalidTypeError("addresses", "not an array")
+	}
+	for _, addr := range addrs {
+		addrstr, ok := addr.(string)
+		if !ok || len(common.FromHex(addrstr)) != len(common.Address{}) {
+			return shared.NewValidationError("addresses", "must contain addresses only")
+		}
+		args.Addresses = append(args.Addresses, addrstr)
+	}
+
+	return nil
+}
+
+type RemoveWebhookArgs struct {
+	Url string
+}
+
+func (args *RemoveWebhookArgs) UnmarshalJSON(b []byte) (err error) {
+	var obj []interface{}
+	if err := json.Unmarshal(b, &obj); err != nil {
+		return shared.NewDecodeParamError(err.Error())
+	}
+
+	if len(obj) < 1 {
+		return shared.NewInsufficientParamsError(len(obj), 1)
+	}
+
+	urlstr, ok := obj[0].(string)
+	if !ok {
+		return shared.NewInvalidTypeError("url", "not a string")
+	}
+	args.Url = urlstr
+
+	return nil
+}
+
 type ImportExportChainArgs struct {
 	Filename string
 }
@@ -128,7 +189,7 @@ func (args *StartRPCArgs) UnmarshalJSON(b []byte) (err error) {
 
 	args.ListenAddress = "127.0.0.1"
 	args.ListenPort = 9656
-	args.Apis = "net,exp,web3"
+	args.Apis = comms.DefaultWsApis
 
 	if len(obj) >= 1 && obj[0] != nil {
 		if addr, ok := obj[0].(string); ok {
@@ -165,6 +226,76 @@ func (args *StartRPCArgs) UnmarshalJSON(b []byte) (err error) {
 	return nil
 }
 
+type StartWSArgs struct {
+	ListenAddress string
+	ListenPort    uint
+	Origins       string
+	Apis          string
+	Compression   bool
+	MaxFrameSize  int
+}
+
+func (args *StartWSArgs) UnmarshalJSON(b []byte) (err error) {
+	var obj []interface{}
+	if err := json.Unmarshal(b, &obj); err != nil {
+		return shared.NewDecodeParamError(err.Error())
+	}
+
+	args.ListenAddress = "127.0.0.1"
+	args.ListenPort = 9657
+	args.Apis = comms.DefaultWsApis
+
+	if len(obj) >= 1 && obj[0] != nil {
+		if addr, ok := obj[0].(string); ok {
+			args.ListenAddress = addr
+		} else {
+			return shared.NewInvalidTypeError("listenAddress", "not a string")
+		}
+	}
+
+	if len(obj) >= 2 && obj[1] != nil {
+		if port, ok := obj[1].(float64); ok && port >= 0 && port <= 64*1024 {
+			args.ListenPort = uint(port)
+		} else {
+			return shared.NewInvalidTypeError("listenPort", "not a valid port number")
+		}
+	}
+
+	if len(obj) >= 3 && obj[2] != nil {
+		if origins, ok := obj[2].(string); ok {
+			args.Origins = origins
+		} else {
+			return shared.NewInvalidTypeError("origins", "not a string")
+		}
+	}
+
+	if len(obj) >= 4 && obj[3] != nil {
+		if apis, ok := obj[3].(string); ok {
+			args.Apis = apis
+		} else {
+			return shared.NewInvalidTypeError("apis", "not a string")
+		}
+	}
+
+	if len(obj) >= 5 && obj[4] != nil {
+		if compression, ok := obj[4].(bool); ok {
+			args.Compression = compression
+		} else {
+			return shared.NewInvalidTypeError("compression", "not a boolean")
+		}
+	}
+
+	if len(obj) >= 6 && obj[5] != nil {
+		if size, ok := obj[5].(float64); ok && size >= 0 {
+			args.MaxFrameSize = int(size)
+		} else {
+			return shared.NewInvalidTypeError("maxFrameSize", "not a valid frame size")
+		}
+	}
+
+	return nil
+}
+
 type SleepArgs struct {
 	S int
 }
@@ -488,3 +619,35 @@ func (args *HttpGetArgs) UnmarshalJSON(b []byte) (err error) {
 
 	return nil
 }
+
+type ChainSyncStatusRes struct {
+	Syncing       bool   `json:"syncing"`
+	StartingBlock uint64 `json:"startingBlock"`
+	CurrentBlock  uint64 `json:"currentBlock"`
+	HighestBlock  uint64 `json:"highestBlock"`
+	PulledStates  uint64 `json:"pulledStates"` // state trie entries pulled by fast sync
+	KnownStates   uint64 `json:"knownStates"`  // state trie entries known to exist so far
+	HeadHeader    uint64 `json:"headHeader"`
+	HeadFastBlock uint64 `json:"headFastBlock"`
+	HeadBlock     uint64 `json:"headBlock"`
+	Peers         int    `json:"peers"`
+}
+
+type DatabaseSizeRes struct {
+	Path   string            `json:"path"`
+	Total  uint64            `json:"total"`
+	Other  uint64            `json:"other"` // state trie, transaction lookups and metadata
+	Tables map[string]uint64 `json:"tables"`
+}
+
+func NewDatabaseSizeRes(path string, total uint64, tables map[string]uint64) *DatabaseSizeRes {
+	res := &DatabaseSizeRes{Path: path, Total: total, Other: total, Tables: tables}
+	for _, size := range tables {
+		if size > res.Other {
+			res.Other = 0
+			break
+		}
+		res.Other -= size
+	}
+	return res
+}
@@ -27,6 +27,18 @@ web3._extend({
 			params: 1,
 			inputFormatter: [null]
 		}),
+		new web3._extend.Method({
+			name: 'addWebhook',
+			call: 'admin_addWebhook',
+			params: 2,
+			inputFormatter: [null, null]
+		}),
+		new web3._extend.Method({
+			name: 'removeWebhook',
+			call: 'admin_removeWebhook',
+			params: 1,
+			inputFormatter: [null]
+		}),
 		new web3._extend.Method({
 			name: 'exportChain',
 			call: 'admin_exportChain',
@@ -69,6 +81,18 @@ web3._extend({
 			params: 0,
 			inputFormatter: []
 		}),
+		new web3._extend.Method({
+			name: 'startWS',
+			call: 'admin_startWS',
+			params: 4,
+			inputFormatter: [null, null, null, null]
+		}),
+		new web3._extend.Method({
+			name: 'stopWS',
+			call: 'admin_stopWS',
+			params: 0,
+			inputFormatter: []
+		}),
 		new web3._extend.Method({
 			name: 'setGlobalRegistrar',
 			call: 'admin_setGlobalRegistrar',
@@ -93,6 +117,12 @@ web3._extend({
 			params: 2,
 			inputFormatter: [null,null]
 		}),
+		new web3._extend.Method({
+			name: 'removePeer',
+			call: 'admin_removePeer',
+			params: 1,
+			inputFormatter: [null]
+		}),
 		new web3._extend.Method({
 			name: 'register',
 			call: 'admin_register',
@@ -143,6 +173,22 @@ web3._extend({
 		new web3._extend.Property({
 			name: 'datadir',
 			getter: 'admin_datadir'
+		}),
+		new web3._extend.Property({
+			name: 'chainSyncStatus',
+			getter: 'admin_chainSyncStatus'
+		}),
+		new web3._extend.Property({
+			name: 'networkHeads',
+			getter: 'admin_networkHeads'
+		}),
+		new web3._extend.Property({
+			name: 'databaseSize',
+			getter: 'admin_databaseSize'
+		}),
+		new web3._extend.Property({
+			name: 'webhooks',
+			getter: 'admin_webhooks'
 		})
 	]
 });
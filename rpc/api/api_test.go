@@ -20,10 +20,19 @@ import (
 	"testing"
 
 	"encoding/json"
+	"math/big"
+	"reflect"
 	"strconv"
+	"strings"
+	"time"
 
+	"github.com/expanse-project/go-expanse/common"
 	"github.com/expanse-project/go-expanse/common/compiler"
+	"github.com/expanse-project/go-expanse/core"
+	"github.com/expanse-project/go-expanse/core/types"
+	"github.com/expanse-project/go-expanse/crypto"
 	"github.com/expanse-project/go-expanse/exp"
+	"github.com/expanse-project/go-expanse/params"
 	"github.com/expanse-project/go-expanse/rpc/codec"
 	"github.com/expanse-project/go-expanse/rpc/shared"
 	"github.com/expanse-project/go-expanse/xeth"
@@ -64,6 +73,24 @@ func TestParseApiString(t *testing.T) {
 
 }
 
+func TestParseApiNames(t *testing.T) {
+	names, err := ParseApiNames(" Exp, personal ,eth,debug")
+	if err != nil {
+		t.Fatalf("failed to parse API list: %v", err)
+	}
+	if want := []string{"exp", "personal", "exp", "debug"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("names mismatch: have %v, want %v", names, want)
+	}
+	for _, apistr := range []string{"", " ", "exp,,net", "exp,", "exp,bogus"} {
+		if _, err := ParseApiNames(apistr); err == nil {
+			t.Errorf("%q: expected an error", apistr)
+		}
+	}
+	if _, err := ParseApiNames("exp,bogus"); err == nil || !strings.Contains(err.Error(), shared.AllApis) {
+		t.Errorf("unknown module error should list the available modules, got %v", err)
+	}
+}
+
 const solcVersion = "0.9.23"
 
 func TestCompileSolidity(t *testing.T) {
@@ -93,7 +120,7 @@ func TestCompileSolidity(t *testing.T) {
 	expSource := source
 
 	exp := &exp.Expanse{}
-	xeth := xeth.NewTest(exp, nil)
+	xeth := xeth.NewTest(exp.ApiBackend(), nil)
 	api := NewEthApi(xeth, exp, codec.JSON)
 
 	var rpcRequest shared.Request
@@ -168,3 +195,177 @@ func TestCompileSolidity(t *testing.T) {
 		t.Errorf("Expected %s got %s", expDeveloperDoc, string(devdoc))
 	}
 }
+
+func TestBlockGasStats(t *testing.T) {
+	txs := []*types.Transaction{
+		types.NewTransaction(0, common.Address{}, big.NewInt(0), big.NewInt(50000), big.NewInt(2), nil),
+		types.NewTransaction(1, common.Address{}, big.NewInt(0), big.NewInt(50000), big.NewInt(3), nil),
+	}
+	receipts := types.Receipts{
+		types.NewReceipt(nil, big.NewInt(21000)),
+		types.NewReceipt(nil, big.NewInt(51000)),
+	}
+	header := &types.Header{Number: big.NewInt(7), GasLimit: big.NewInt(3141592), GasUsed: big.NewInt(51000)}
+	block := types.NewBlock(header, txs, nil, receipts)
+
+	stats, err := blockGasStats(block, receipts)
+	if err != nil {
+		t.Fatalf("failed to compute gas stats: %v", err)
+	}
+	if fees := stats.Fees.ToInt(); fees.Cmp(big.NewInt(21000*2+30000*3)) != 0 {
+		t.Errorf("fees mismatch: have %v, want %v", fees, 21000*2+30000*3)
+	}
+	if stats.Transactions != 2 {
+		t.Errorf("transaction count mismatch: have %d, want %d", stats.Transactions, 2)
+	}
+
+	res := newGasStatsRes(block.Number(), block.Number())
+	res.add(stats)
+	res.add(stats)
+	if used := res.GasUsed.ToInt(); used.Cmp(big.NewInt(102000)) != 0 {
+		t.Errorf("total gas used mismatch: have %v, want %v", used, 102000)
+	}
+	if res.Transactions != 4 || len(res.Blocks) != 2 {
+		t.Errorf("totals mismatch: have %d transactions in %d blocks, want 4 in 2", res.Transactions, len(res.Blocks))
+	}
+
+	if _, err := blockGasStats(block, receipts[:1]); err == nil {
+		t.Errorf("expected error for missing receipts")
+	}
+}
+
+func TestUncleStatsRes(t *testing.T) {
+	stats := &core.UncleStats{
+		From:   1,
+		To:     4,
+		Blocks: 4,
+		Uncles: 1,
+		Miners: map[common.Address]*core.MinerStats{
+			common.Address{0x02}: {Blocks: 1, Uncles: 1},
+			common.Address{0x01}: {Blocks: 3},
+		},
+		BlockDelays: core.DelayStats{Samples: 2, Mean: 1250 * time.Millisecond, Median: 1500 * time.Millisecond, Max: 1500 * time.Millisecond},
+	}
+	res := newUncleStatsRes(stats)
+	if res.UncleRate != 0.25 {
+		t.Errorf("uncle rate mismatch: have %v, want 0.25", res.UncleRate)
+	}
+	if len(res.Miners) != 2 || res.Miners[0].Blocks != 3 || res.Miners[1].Uncles != 1 {
+		t.Errorf("miners not ordered by mined blocks: %+v %+v", res.Miners[0], res.Miners[1])
+	}
+	if res.BlockDelays.Mean != 1250 || res.BlockDelays.Max != 1500 {
+		t.Errorf("delay mismatch: have %+v, want mean 1250ms max 1500ms", res.BlockDelays)
+	}
+}
+
+func TestBlockResFields(t *testing.T) {
+	header := &types.Header{
+		Number:     big.NewInt(1),
+		Difficulty: big.NewInt(131072),
+		GasLimit:   big.NewInt(3141592),
+		GasUsed:    big.NewInt(0),
+		Time:       big.NewInt(0),
+		MixDigest:  common.HexToHash("0x01"),
+	}
+	block := types.NewBlock(header, nil, nil, nil)
+
+	for _, fullTx := range []bool{false, true} {
+		blob, err := json.Marshal(NewBlockRes(block, big.NewInt(262144), fullTx))
+		if err != nil {
+			t.Fatalf("failed to encode block: %v", err)
+		}
+		var fields map[string]interface{}
+		if err := json.Unmarshal(blob, &fields); err != nil {
+			t.Fatalf("failed to decode block: %v", err)
+		}
+		for _, name := range []string{"size", "totalDifficulty", "logsBloom", "sha3Uncles", "receiptsRoot", "receiptRoot", "mixHash"} {
+			if _, ok := fields[name]; !ok {
+				t.Errorf("fullTx %v: missing field %q", fullTx, name)
+			}
+		}
+		if fields["mixHash"] != header.MixDigest.Hex() {
+			t.Errorf("fullTx %v: mixHash mismatch: have %v, want %v", fullTx, fields["mixHash"], header.MixDigest.Hex())
+		}
+		if fields["receiptRoot"] != fields["receiptsRoot"] {
+			t.Errorf("fullTx %v: receiptRoot %v differs from receiptsRoot %v", fullTx, fields["receiptRoot"], fields["receiptsRoot"])
+		}
+		if fields["totalDifficulty"] != "0x40000" {
+			t.Errorf("fullTx %v: totalDifficulty mismatch: have %v, want %v", fullTx, fields["totalDifficulty"], "0x40000")
+		}
+	}
+}
+
+func TestDatabaseSizeRes(t *testing.T) {
+	res := NewDatabaseSizeRes("chaindata", 100, map[string]uint64{"blocks": 30, "receipts": 20})
+	if res.Other != 50 {
+		t.Errorf("other size mismatch: have %d, want 50", res.Other)
+	}
+	// Table sizes are approximate and may exceed the size on disk.
+	res = NewDatabaseSizeRes("chaindata", 40, map[string]uint64{"blocks": 30, "receipts": 20})
+	if res.Other != 0 {
+		t.Errorf("other size mismatch: have %d, want 0", res.Other)
+	}
+}
+
+func TestTxPoolInspectRes(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	to := common.Address{0x01}
+
+	transfer, _ := types.NewTransaction(3, to, big.NewInt(5), big.NewInt(21000), big.NewInt(2), nil).SignECDSA(key)
+	create, _ := types.NewContractCreation(4, big.NewInt(0), big.NewInt(90000), big.NewInt(2), nil).SignECDSA(key)
+
+	res := newTxPoolInspectRes(core.TxGroups{from: {transfer}}, core.TxGroups{from: {create}}, 2)
+	if have, want := res.Pending[from.Hex()]["3"], to.Hex()+": 5 wei + 21000 gas × 2 wei"; have != want {
+		t.Errorf("pending summary mismatch: have %q, want %q", have, want)
+	}
+	if have, want := res.Queued[from.Hex()]["4"], "contract creation: 0 wei + 90000 gas × 2 wei"; have != want {
+		t.Errorf("queued summary mismatch: have %q, want %q", have, want)
+	}
+
+	content := newTxPoolContentRes(core.TxGroups{from: {transfer}}, core.TxGroups{}, 1)
+	if tx := content.Pending[from.Hex()]["3"]; tx == nil || len(content.Queued) != 0 || content.Total != 1 {
+		t.Errorf("content mismatch: have %+v", content)
+	}
+}
+
+func TestTxPoolOriginsRes(t *testing.T) {
+	res := newTxPoolOriginsRes(map[core.TxOrigin]core.TxOriginStats{
+		{Transport: "p2p", Remote: "peer"}:     {Accepted: 10},
+		{Transport: "rpc", Remote: "10.0.0.1"}: {Accepted: 1, Rejected: 50},
+		{Transport: "ipc"}:                     {Rejected: 2},
+		{Transport: "rpc", Remote: "10.0.0.2"}: {Rejected: 2, LastSeen: time.Unix(100, 0)},
+	})
+	want := []string{"rpc 10.0.0.1", "ipc ", "rpc 10.0.0.2", "p2p peer"}
+	if len(res) != len(want) {
+		t.Fatalf("origin count mismatch: have %d, want %d", len(res), len(want))
+	}
+	for i, origin := range want {
+		if have := res[i].Transport + " " + res[i].Remote; have != origin {
+			t.Errorf("origin %d mismatch: have %q, want %q", i, have, origin)
+		}
+	}
+	if res[2].LastSeen != 100 || res[0].Rejected != 50 {
+		t.Errorf("origin stats mismatch: have %+v, %+v", res[0], res[2])
+	}
+}
+
+func TestChainId(t *testing.T) {
+	defer func(chainId *big.Int) { params.ChainId = chainId }(params.ChainId)
+
+	api := NewEthApi(xeth.NewTest(nil, nil), nil, codec.JSON)
+	for _, method := range []string{"eth_chainId", "exp_chainId"} {
+		params.ChainId = nil
+		if res, err := api.Execute(&shared.Request{Method: method}); err != nil || res != nil {
+			t.Errorf("%s without chain id: have %v (%v), want nil", method, res, err)
+		}
+		params.ChainId = big.NewInt(7)
+		res, err := api.Execute(&shared.Request{Method: method})
+		if err != nil {
+			t.Fatalf("%s failed: %v", method, err)
+		}
+		if enc, _ := json.Marshal(res); string(enc) != `"0x7"` {
+			t.Errorf("%s mismatch: have %s, want \"0x7\"", method, enc)
+		}
+	}
+}
@@ -23,6 +23,7 @@ import (
 	"math/big"
 	"testing"
 
+	"github.com/expanse-project/go-expanse/common"
 	"github.com/expanse-project/go-expanse/rpc/shared"
 )
 
@@ -264,6 +265,16 @@ func TestGetBalanceArgsBlockInvalid(t *testing.T) {
 	}
 }
 
+func TestGetBalanceArgsBlockHexInvalid(t *testing.T) {
+	input := `["0x407d73d8a49eeb85d32cf465507dd71d507100c1", "0x01"]`
+
+	args := new(GetBalanceArgs)
+	str := ExpectValidationError(json.Unmarshal([]byte(input), &args))
+	if len(str) > 0 {
+		t.Error(str)
+	}
+}
+
 func TestGetBalanceArgsAddressInvalid(t *testing.T) {
 	input := `[-9, "latest"]`
 
@@ -294,6 +305,18 @@ func TestGetBlockByHashArgs(t *testing.T) {
 	}
 }
 
+func TestGetBlockByHashArgsStrict(t *testing.T) {
+	StrictArgs = true
+	defer func() { StrictArgs = false }()
+
+	input := `["0xe670ec64341771606e55d6b4ca35a1a6b75ee3d5145a99d05921026d1527331", true]`
+	args := new(GetBlockByHashArgs)
+	str := ExpectValidationError(json.Unmarshal([]byte(input), &args))
+	if len(str) > 0 {
+		t.Error(str)
+	}
+}
+
 func TestGetBlockByHashArgsEmpty(t *testing.T) {
 	input := `[]`
 
@@ -627,6 +650,19 @@ func TestNewTxArgsBlockGaspriceMissing(t *testing.T) {
 
 }
 
+func TestNewTxArgsValueHexInvalid(t *testing.T) {
+	input := `[{
+	"from": "0xb60e8dd61c5d32be8058bb8eb970870f07233155",
+  "value": "0xzz"
+	}]`
+
+	args := new(NewTxArgs)
+	str := ExpectValidationError(json.Unmarshal([]byte(input), &args))
+	if len(str) > 0 {
+		t.Error(str)
+	}
+}
+
 func TestNewTxArgsValueMissing(t *testing.T) {
 	input := `[{
 	"from": "0xb60e8dd61c5d32be8058bb8eb970870f07233155",
@@ -2288,6 +2324,18 @@ func TestBlockNumIndexArgsWord(t *testing.T) {
 	}
 }
 
+func TestBlockNumIndexArgsStrict(t *testing.T) {
+	StrictArgs = true
+	defer func() { StrictArgs = false }()
+
+	input := `["0x29a", "-0x1"]`
+	args := new(BlockNumIndexArgs)
+	str := ExpectValidationError(json.Unmarshal([]byte(input), &args))
+	if len(str) > 0 {
+		t.Error(str)
+	}
+}
+
 func TestBlockNumIndexArgsEmpty(t *testing.T) {
 	input := `[]`
 
@@ -2388,6 +2436,45 @@ func TestHashIndexArgsInvalidIndex(t *testing.T) {
 	}
 }
 
+func TestHashIndexArgsStrict(t *testing.T) {
+	StrictArgs = true
+	defer func() { StrictArgs = false }()
+
+	valid := `["0xc6ef2fc5426d6ad6fd9e2a26abeab0aa2411b7ab17f30a99d3cb96aed1d1055b", "0x1"]`
+	if err := json.Unmarshal([]byte(valid), new(HashIndexArgs)); err != nil {
+		t.Error(err)
+	}
+	for _, input := range []string{
+		`["0xc6ef2fc5426d6ad6fd9e2a26abeab0aa2411b7ab17f30a99d3cb96aed1d1055", "0x1"]`,
+		`["c6ef2fc5426d6ad6fd9e2a26abeab0aa2411b7ab17f30a99d3cb96aed1d1055b", "0x1"]`,
+		`["0xzzef2fc5426d6ad6fd9e2a26abeab0aa2411b7ab17f30a99d3cb96aed1d1055b", "0x1"]`,
+		`["0xc6ef2fc5426d6ad6fd9e2a26abeab0aa2411b7ab17f30a99d3cb96aed1d1055b", "0xz"]`,
+		`["0xc6ef2fc5426d6ad6fd9e2a26abeab0aa2411b7ab17f30a99d3cb96aed1d1055b", "-1"]`,
+	} {
+		str := ExpectValidationError(json.Unmarshal([]byte(input), new(HashIndexArgs)))
+		if len(str) > 0 {
+			t.Errorf("%s: %s", input, str)
+		}
+	}
+}
+
+func TestHashArgsStrict(t *testing.T) {
+	input := `["0x1234"]`
+
+	args := new(HashArgs)
+	if err := json.Unmarshal([]byte(input), &args); err != nil {
+		t.Error(err)
+	}
+
+	StrictArgs = true
+	defer func() { StrictArgs = false }()
+
+	str := ExpectValidationError(json.Unmarshal([]byte(input), &args))
+	if len(str) > 0 {
+		t.Error(str)
+	}
+}
+
 func TestHashArgs(t *testing.T) {
 	input := `["0xc6ef2fc5426d6ad6fd9e2a26abeab0aa2411b7ab17f30a99d3cb96aed1d1055b"]`
 	expected := new(HashIndexArgs)
@@ -2433,6 +2520,42 @@ func TestHashArgsInvalidHash(t *testing.T) {
 	}
 }
 
+func TestGetWorkArgs(t *testing.T) {
+	input := `["0x59daa26581d0acd1fce254fb7e85952f4c09d0915afd33d3886cd914bc7d283c"]`
+
+	args := new(GetWorkArgs)
+	if err := json.Unmarshal([]byte(input), &args); err != nil {
+		t.Error(err)
+	}
+
+	if args.Id != "0x59daa26581d0acd1fce254fb7e85952f4c09d0915afd33d3886cd914bc7d283c" {
+		t.Errorf("Id should be %v but is %v", "0x59daa26581d0acd1fce254fb7e85952f4c09d0915afd33d3886cd914bc7d283c", args.Id)
+	}
+}
+
+func TestGetWorkArgsEmpty(t *testing.T) {
+	input := `[]`
+
+	args := new(GetWorkArgs)
+	if err := json.Unmarshal([]byte(input), &args); err != nil {
+		t.Error(err)
+	}
+
+	if len(args.Id) != 0 {
+		t.Errorf("Id should be empty but is %v", args.Id)
+	}
+}
+
+func TestGetWorkArgsInvalid(t *testing.T) {
+	input := `[1]`
+
+	args := new(GetWorkArgs)
+	str := ExpectInvalidTypeError(json.Unmarshal([]byte(input), &args))
+	if len(str) > 0 {
+		t.Error(str)
+	}
+}
+
 func TestSubmitWorkArgs(t *testing.T) {
 	input := `["0x0000000000000001", "0x1234567890abcdef1234567890abcdef", "0xD1GE5700000000000000000000000000"]`
 	expected := new(SubmitWorkArgs)
@@ -2647,3 +2770,942 @@ func TestDataArgsInvalid(t *testing.T) {
 		t.Error(str)
 	}
 }
+
+func TestRegistrarNameArgs(t *testing.T) {
+	input := `["0xd46e8dd67c5d32be8058bb8eb970870f07244567", "expanse"]`
+
+	args := new(RegistrarNameArgs)
+	if err := json.Unmarshal([]byte(input), &args); err != nil {
+		t.Error(err)
+	}
+	if args.Sender != "0xd46e8dd67c5d32be8058bb8eb970870f07244567" {
+		t.Errorf("Sender should be %v but is %v", "0xd46e8dd67c5d32be8058bb8eb970870f07244567", args.Sender)
+	}
+	if args.Name != "expanse" {
+		t.Errorf("Name should be %v but is %v", "expanse", args.Name)
+	}
+}
+
+func TestRegistrarNameArgsInvalid(t *testing.T) {
+	tests := []struct {
+		input  string
+		expect func(error) string
+	}{
+		{`{}`, ExpectDecodeParamError},
+		{`["0xd46e8dd67c5d32be8058bb8eb970870f07244567"]`, ExpectInsufficientParamsError},
+		{`[13, "expanse"]`, ExpectInvalidTypeError},
+		{`["0xd46e8dd67c5d32be8058bb8eb970870f07244567", false]`, ExpectInvalidTypeError},
+	}
+	for i, test := range tests {
+		args := new(RegistrarNameArgs)
+		if str := test.expect(json.Unmarshal([]byte(test.input), args)); len(str) > 0 {
+			t.Errorf("test %d: %s", i, str)
+		}
+	}
+}
+
+func TestRegistrarSetAddressArgs(t *testing.T) {
+	input := `["0xd46e8dd67c5d32be8058bb8eb970870f07244567", "expanse", "0xb60e8dd61c5d32be8058bb8eb970870f07233155"]`
+
+	args := new(RegistrarSetAddressArgs)
+	if err := json.Unmarshal([]byte(input), &args); err != nil {
+		t.Error(err)
+	}
+	if args.Name != "expanse" {
+		t.Errorf("Name should be %v but is %v", "expanse", args.Name)
+	}
+	if args.Address != "0xb60e8dd61c5d32be8058bb8eb970870f07233155" {
+		t.Errorf("Address should be %v but is %v", "0xb60e8dd61c5d32be8058bb8eb970870f07233155", args.Address)
+	}
+
+	input = `["0xd46e8dd67c5d32be8058bb8eb970870f07244567", "expanse"]`
+	str := ExpectInsufficientParamsError(json.Unmarshal([]byte(input), new(RegistrarSetAddressArgs)))
+	if len(str) > 0 {
+		t.Error(str)
+	}
+}
+
+func TestRegistrarSetHashArgs(t *testing.T) {
+	input := `["0xd46e8dd67c5d32be8058bb8eb970870f07244567", "0x1234", "http://example.com/info.json"]`
+
+	args := new(RegistrarSetHashArgs)
+	if err := json.Unmarshal([]byte(input), &args); err != nil {
+		t.Error(err)
+	}
+	if args.Key != "0x1234" {
+		t.Errorf("Key should be %v but is %v", "0x1234", args.Key)
+	}
+	if args.Value != "http://example.com/info.json" {
+		t.Errorf("Value should be %v but is %v", "http://example.com/info.json", args.Value)
+	}
+
+	input = `["0xd46e8dd67c5d32be8058bb8eb970870f07244567", "0x1234", 5]`
+	str := ExpectInvalidTypeError(json.Unmarshal([]byte(input), new(RegistrarSetHashArgs)))
+	if len(str) > 0 {
+		t.Error(str)
+	}
+}
+
+func TestRegistrarHashArgs(t *testing.T) {
+	args := new(RegistrarHashArgs)
+	if err := json.Unmarshal([]byte(`["0x1234"]`), &args); err != nil {
+		t.Error(err)
+	}
+	if args.Hash != "0x1234" {
+		t.Errorf("Hash should be %v but is %v", "0x1234", args.Hash)
+	}
+
+	str := ExpectInsufficientParamsError(json.Unmarshal([]byte(`[]`), new(RegistrarHashArgs)))
+	if len(str) > 0 {
+		t.Error(str)
+	}
+}
+
+func TestGetConfirmedBalanceArgs(t *testing.T) {
+	input := `["0x407d73d8a49eeb85d32cf465507dd71d507100c1", "0xc"]`
+	expected := new(GetConfirmedBalanceArgs)
+	expected.Address = "0x407d73d8a49eeb85d32cf465507dd71d507100c1"
+	expected.Confirmations = 12
+
+	args := new(GetConfirmedBalanceArgs)
+	if err := json.Unmarshal([]byte(input), &args); err != nil {
+		t.Error(err)
+	}
+
+	if args.Address != expected.Address {
+		t.Errorf("Address should be %v but is %v", expected.Address, args.Address)
+	}
+
+	if args.Confirmations != expected.Confirmations {
+		t.Errorf("Confirmations should be %v but is %v", expected.Confirmations, args.Confirmations)
+	}
+}
+
+func TestGetConfirmedBalanceArgsConfirmationsMissing(t *testing.T) {
+	input := `["0x407d73d8a49eeb85d32cf465507dd71d507100c1"]`
+
+	args := new(GetConfirmedBalanceArgs)
+	str := ExpectInsufficientParamsError(json.Unmarshal([]byte(input), &args))
+	if len(str) > 0 {
+		t.Error(str)
+	}
+}
+
+func TestGetConfirmedBalanceArgsConfirmationsNegative(t *testing.T) {
+	input := `["0x407d73d8a49eeb85d32cf465507dd71d507100c1", -1]`
+
+	args := new(GetConfirmedBalanceArgs)
+	str := ExpectValidationError(json.Unmarshal([]byte(input), &args))
+	if len(str) > 0 {
+		t.Error(str)
+	}
+}
+
+func TestConfirmationFilterArgs(t *testing.T) {
+	input := `["0xd5d82b6addc9a01293cd3d4b6a0e4d9a5ede1c1e15c7ba2d6e7be10f0e8a8b3f", 6]`
+
+	args := new(ConfirmationFilterArgs)
+	if err := json.Unmarshal([]byte(input), &args); err != nil {
+		t.Error(err)
+	}
+
+	if args.Hash != "0xd5d82b6addc9a01293cd3d4b6a0e4d9a5ede1c1e15c7ba2d6e7be10f0e8a8b3f" {
+		t.Errorf("Hash should be %v but is %v", "0xd5d82b6addc9a01293cd3d4b6a0e4d9a5ede1c1e15c7ba2d6e7be10f0e8a8b3f", args.Hash)
+	}
+
+	if args.Confirmations != 6 {
+		t.Errorf("Confirmations should be %v but is %v", 6, args.Confirmations)
+	}
+}
+
+func TestConfirmationFilterArgsInvalidHash(t *testing.T) {
+	input := `[7, 6]`
+
+	args := new(ConfirmationFilterArgs)
+	str := ExpectInvalidTypeError(json.Unmarshal([]byte(input), &args))
+	if len(str) > 0 {
+		t.Error(str)
+	}
+}
+
+func TestAddWebhookArgs(t *testing.T) {
+	input := `["http://localhost:8080/hook", ["0x407d73d8a49eeb85d32cf465507dd71d507100c1"]]`
+
+	args := new(AddWebhookArgs)
+	if err := json.Unmarshal([]byte(input), &args); err != nil {
+		t.Error(err)
+	}
+
+	if args.Url != "http://localhost:8080/hook" {
+		t.Errorf("Url should be %v but is %v", "http://localhost:8080/hook", args.Url)
+	}
+
+	if len(args.Addresses) != 1 || args.Addresses[0] != "0x407d73d8a49eeb85d32cf465507dd71d507100c1" {
+		t.Errorf("Addresses should be %v but is %v", []string{"0x407d73d8a49eeb85d32cf465507dd71d507100c1"}, args.Addresses)
+	}
+}
+
+func TestAddWebhookArgsInvalidAddress(t *testing.T) {
+	input := `["http://localhost:8080/hook", ["0x407d73"]]`
+
+	args := new(AddWebhookArgs)
+	str := ExpectValidationError(json.Unmarshal([]byte(input), &args))
+	if len(str) > 0 {
+		t.Error(str)
+	}
+}
+
+func TestModifiedAccountsArgs(t *testing.T) {
+	input := `["0x29a", "latest", true]`
+
+	args := new(ModifiedAccountsArgs)
+	if err := json.Unmarshal([]byte(input), &args); err != nil {
+		t.Error(err)
+	}
+
+	if args.Start != 666 {
+		t.Errorf("Start should be %v but is %v", 666, args.Start)
+	}
+
+	if !args.HasEnd || args.End != -1 {
+		t.Errorf("End should be %v but is %v (set: %v)", -1, args.End, args.HasEnd)
+	}
+
+	if !args.IncludeStorage {
+		t.Errorf("IncludeStorage should be %v but is %v", true, args.IncludeStorage)
+	}
+}
+
+func TestModifiedAccountsArgsSingleBlock(t *testing.T) {
+	input := `["0x29a"]`
+
+	args := new(ModifiedAccountsArgs)
+	if err := json.Unmarshal([]byte(input), &args); err != nil {
+		t.Error(err)
+	}
+
+	if args.HasEnd {
+		t.Errorf("End should not be set but is %v", args.End)
+	}
+
+	if args.IncludeStorage {
+		t.Errorf("IncludeStorage should be %v but is %v", false, args.IncludeStorage)
+	}
+}
+
+func TestModifiedAccountsArgsEmpty(t *testing.T) {
+	input := `[]`
+
+	args := new(ModifiedAccountsArgs)
+	str := ExpectInsufficientParamsError(json.Unmarshal([]byte(input), &args))
+	if len(str) > 0 {
+		t.Error(str)
+	}
+}
+
+func TestModifiedAccountsArgsInvalidStorage(t *testing.T) {
+	input := `["0x29a", null, "yes"]`
+
+	args := new(ModifiedAccountsArgs)
+	str := ExpectInvalidTypeError(json.Unmarshal([]byte(input), &args))
+	if len(str) > 0 {
+		t.Error(str)
+	}
+}
+
+func TestTdArgsHash(t *testing.T) {
+	input := `["0x0000000000000000000000000000000000000000000000000000000000001234"]`
+
+	args := new(TdArgs)
+	if err := json.Unmarshal([]byte(input), &args); err != nil {
+		t.Error(err)
+	}
+
+	if !args.ByHash || args.Hash != common.HexToHash("0x1234") {
+		t.Errorf("Hash should be %x but is %x (set: %v)", common.HexToHash("0x1234"), args.Hash, args.ByHash)
+	}
+}
+
+func TestTdArgsNumber(t *testing.T) {
+	input := `["0x29a"]`
+
+	args := new(TdArgs)
+	if err := json.Unmarshal([]byte(input), &args); err != nil {
+		t.Error(err)
+	}
+
+	if args.ByHash || args.Number != 666 {
+		t.Errorf("Number should be %v but is %v (hash: %v)", 666, args.Number, args.ByHash)
+	}
+}
+
+func TestTdArgsEmpty(t *testing.T) {
+	input := `[]`
+
+	args := new(TdArgs)
+	str := ExpectInsufficientParamsError(json.Unmarshal([]byte(input), &args))
+	if len(str) > 0 {
+		t.Error(str)
+	}
+}
+
+func TestCompareForksArgs(t *testing.T) {
+	input := `["0x0000000000000000000000000000000000000000000000000000000000000001", "0x0000000000000000000000000000000000000000000000000000000000000002"]`
+
+	args := new(CompareForksArgs)
+	if err := json.Unmarshal([]byte(input), &args); err != nil {
+		t.Error(err)
+	}
+
+	if args.A != common.HexToHash("0x01") {
+		t.Errorf("A should be %x but is %x", common.HexToHash("0x01"), args.A)
+	}
+	if args.B != common.HexToHash("0x02") {
+		t.Errorf("B should be %x but is %x", common.HexToHash("0x02"), args.B)
+	}
+}
+
+func TestCompareForksArgsInsufficient(t *testing.T) {
+	input := `["0x0000000000000000000000000000000000000000000000000000000000000001"]`
+
+	args := new(CompareForksArgs)
+	str := ExpectInsufficientParamsError(json.Unmarshal([]byte(input), &args))
+	if len(str) > 0 {
+		t.Error(str)
+	}
+}
+
+func TestCompareForksArgsInvalidHash(t *testing.T) {
+	input := `["0x0000000000000000000000000000000000000000000000000000000000000001", "0x1234"]`
+
+	args := new(CompareForksArgs)
+	str := ExpectInvalidTypeError(json.Unmarshal([]byte(input), &args))
+	if len(str) > 0 {
+		t.Error(str)
+	}
+}
+
+func TestGasStatsArgs(t *testing.T) {
+	input := `["0x1", "0x10"]`
+
+	args := new(GasStatsArgs)
+	if err := json.Unmarshal([]byte(input), &args); err != nil {
+		t.Error(err)
+	}
+
+	if args.FromBlock != 1 {
+		t.Errorf("FromBlock should be %v but is %v", 1, args.FromBlock)
+	}
+
+	if args.ToBlock != 16 {
+		t.Errorf("ToBlock should be %v but is %v", 16, args.ToBlock)
+	}
+}
+
+func TestGasStatsArgsDefaultTo(t *testing.T) {
+	input := `["0x1"]`
+
+	args := new(GasStatsArgs)
+	if err := json.Unmarshal([]byte(input), &args); err != nil {
+		t.Error(err)
+	}
+
+	if args.ToBlock != -1 {
+		t.Errorf("ToBlock should be %v but is %v", -1, args.ToBlock)
+	}
+}
+
+func TestGasStatsArgsEmpty(t *testing.T) {
+	input := `[]`
+
+	args := new(GasStatsArgs)
+	str := ExpectInsufficientParamsError(json.Unmarshal([]byte(input), &args))
+	if len(str) > 0 {
+		t.Error(str)
+	}
+}
+
+func TestGasStatsArgsPending(t *testing.T) {
+	input := `["0x1", "pending"]`
+
+	args := new(GasStatsArgs)
+	str := ExpectValidationError(json.Unmarshal([]byte(input), &args))
+	if len(str) > 0 {
+		t.Error(str)
+	}
+}
+
+func TestReserveNonceArgs(t *testing.T) {
+	input := `["0x407d73d8a49eeb85d32cf465507dd71d507100c1"]`
+
+	args := new(ReserveNonceArgs)
+	if err := json.Unmarshal([]byte(input), &args); err != nil {
+		t.Fatal(err)
+	}
+	if args.Address != "0x407d73d8a49eeb85d32cf465507dd71d507100c1" {
+		t.Errorf("Address should be %v but is %v", "0x407d73d8a49eeb85d32cf465507dd71d507100c1", args.Address)
+	}
+
+	str := ExpectInsufficientParamsError(json.Unmarshal([]byte(`[]`), &args))
+	if len(str) > 0 {
+		t.Error(str)
+	}
+}
+
+func TestReleaseNonceArgs(t *testing.T) {
+	input := `["0x407d73d8a49eeb85d32cf465507dd71d507100c1", "0x2a"]`
+
+	args := new(ReleaseNonceArgs)
+	if err := json.Unmarshal([]byte(input), &args); err != nil {
+		t.Fatal(err)
+	}
+	if args.Nonce != 42 {
+		t.Errorf("Nonce should be %v but is %v", 42, args.Nonce)
+	}
+
+	str := ExpectInsufficientParamsError(json.Unmarshal([]byte(`["0x407d73d8a49eeb85d32cf465507dd71d507100c1"]`), &args))
+	if len(str) > 0 {
+		t.Error(str)
+	}
+	str = ExpectValidationError(json.Unmarshal([]byte(`["0x407d73d8a49eeb85d32cf465507dd71d507100c1", -1]`), &args))
+	if len(str) > 0 {
+		t.Error(str)
+	}
+}
+
+func TestStartWSArgs(t *testing.T) {
+	args := new(StartWSArgs)
+	if err := json.Unmarshal([]byte(`[]`), &args); err != nil {
+		t.Fatal(err)
+	}
+	if args.ListenAddress != "127.0.0.1" || args.ListenPort != 9657 || args.Origins != "" || args.Apis != "net,exp,web3" {
+		t.Errorf("Defaults mismatch: %+v", args)
+	}
+
+	input := `["0.0.0.0", 8000, "http://localhost", "exp"]`
+	if err := json.Unmarshal([]byte(input), &args); err != nil {
+		t.Fatal(err)
+	}
+	if args.ListenAddress != "0.0.0.0" || args.ListenPort != 8000 || args.Origins != "http://localhost" || args.Apis != "exp" {
+		t.Errorf("Arguments mismatch: %+v", args)
+	}
+	if args.Compression || args.MaxFrameSize != 0 {
+		t.Errorf("Options defaults mismatch: %+v", args)
+	}
+
+	input = `["0.0.0.0", 8000, "*", "exp", true, 65536]`
+	if err := json.Unmarshal([]byte(input), &args); err != nil {
+		t.Fatal(err)
+	}
+	if !args.Compression || args.MaxFrameSize != 65536 {
+		t.Errorf("Options mismatch: %+v", args)
+	}
+
+	str := ExpectInvalidTypeError(json.Unmarshal([]byte(`["127.0.0.1", 70000]`), &args))
+	if len(str) > 0 {
+		t.Error(str)
+	}
+	str = ExpectInvalidTypeError(json.Unmarshal([]byte(`["127.0.0.1", 8000, "", "exp", "yes"]`), &args))
+	if len(str) > 0 {
+		t.Error(str)
+	}
+	str = ExpectInvalidTypeError(json.Unmarshal([]byte(`["127.0.0.1", 8000, "", "exp", false, -1]`), &args))
+	if len(str) > 0 {
+		t.Error(str)
+	}
+}
+
+func TestUncleStatsArgs(t *testing.T) {
+	input := `["0x1", "0x10"]`
+
+	args := new(UncleStatsArgs)
+	if err := json.Unmarshal([]byte(input), &args); err != nil {
+		t.Error(err)
+	}
+
+	if args.FromBlock != 1 {
+		t.Errorf("FromBlock should be %v but is %v", 1, args.FromBlock)
+	}
+
+	if args.ToBlock != 16 {
+		t.Errorf("ToBlock should be %v but is %v", 16, args.ToBlock)
+	}
+}
+
+func TestUncleStatsArgsDefaultTo(t *testing.T) {
+	input := `["0x1"]`
+
+	args := new(UncleStatsArgs)
+	if err := json.Unmarshal([]byte(input), &args); err != nil {
+		t.Error(err)
+	}
+
+	if args.ToBlock != -1 {
+		t.Errorf("ToBlock should be %v but is %v", -1, args.ToBlock)
+	}
+}
+
+func TestUncleStatsArgsPending(t *testing.T) {
+	input := `["pending"]`
+
+	args := new(UncleStatsArgs)
+	str := ExpectValidationError(json.Unmarshal([]byte(input), &args))
+	if len(str) > 0 {
+		t.Error(str)
+	}
+}
+
+func TestNetworkHashrateArgs(t *testing.T) {
+	input := `["0x64", "0x10"]`
+
+	args := new(NetworkHashrateArgs)
+	if err := json.Unmarshal([]byte(input), &args); err != nil {
+		t.Error(err)
+	}
+
+	if args.Blocks != 100 {
+		t.Errorf("Blocks should be %v but is %v", 100, args.Blocks)
+	}
+
+	if args.BlockNumber != 16 {
+		t.Errorf("BlockNumber should be %v but is %v", 16, args.BlockNumber)
+	}
+}
+
+func TestNetworkHashrateArgsDefaults(t *testing.T) {
+	input := `[]`
+
+	args := new(NetworkHashrateArgs)
+	if err := json.Unmarshal([]byte(input), &args); err != nil {
+		t.Error(err)
+	}
+
+	if args.Blocks != 0 {
+		t.Errorf("Blocks should be %v but is %v", 0, args.Blocks)
+	}
+
+	if args.BlockNumber != -1 {
+		t.Errorf("BlockNumber should be %v but is %v", -1, args.BlockNumber)
+	}
+}
+
+func TestNetworkHashrateArgsPending(t *testing.T) {
+	input := `["0x64", "pending"]`
+
+	args := new(NetworkHashrateArgs)
+	str := ExpectValidationError(json.Unmarshal([]byte(input), &args))
+	if len(str) > 0 {
+		t.Error(str)
+	}
+}
+
+func TestInternalTxsArgs(t *testing.T) {
+	input := `["0xd46e8dd67c5d32be8058bb8eb970870f07244567", "0x1", "0x10"]`
+
+	args := new(InternalTxsArgs)
+	if err := json.Unmarshal([]byte(input), &args); err != nil {
+		t.Error(err)
+	}
+
+	if args.Address != "0xd46e8dd67c5d32be8058bb8eb970870f07244567" {
+		t.Errorf("Address should be %v but is %v", "0xd46e8dd67c5d32be8058bb8eb970870f07244567", args.Address)
+	}
+
+	if args.FromBlock != 1 {
+		t.Errorf("FromBlock should be %v but is %v", 1, args.FromBlock)
+	}
+
+	if args.ToBlock != 16 {
+		t.Errorf("ToBlock should be %v but is %v", 16, args.ToBlock)
+	}
+}
+
+func TestInternalTxsArgsDefaultTo(t *testing.T) {
+	input := `["0xd46e8dd67c5d32be8058bb8eb970870f07244567", "0x1"]`
+
+	args := new(InternalTxsArgs)
+	if err := json.Unmarshal([]byte(input), &args); err != nil {
+		t.Error(err)
+	}
+
+	if args.ToBlock != -1 {
+		t.Errorf("ToBlock should be %v but is %v", -1, args.ToBlock)
+	}
+}
+
+func TestInternalTxsArgsPending(t *testing.T) {
+	input := `["0xd46e8dd67c5d32be8058bb8eb970870f07244567", "0x1", "pending"]`
+
+	args := new(InternalTxsArgs)
+	str := ExpectValidationError(json.Unmarshal([]byte(input), &args))
+	if len(str) > 0 {
+		t.Error(str)
+	}
+}
+
+func TestUnlockAccountArgs(t *testing.T) {
+	input := `["0xd46e8dd67c5d32be8058bb8eb970870f07244567", "secret", 60]`
+
+	args := new(UnlockAccountArgs)
+	if err := json.Unmarshal([]byte(input), &args); err != nil {
+		t.Error(err)
+	}
+
+	if args.Passphrase == nil || *args.Passphrase != "secret" {
+		t.Errorf("Passphrase should be %v but is %v", "secret", args.Passphrase)
+	}
+
+	if args.Duration != 60 {
+		t.Errorf("Duration should be %v but is %v", 60, args.Duration)
+	}
+}
+
+func TestUnlockAccountArgsDefaultDuration(t *testing.T) {
+	input := `["0xd46e8dd67c5d32be8058bb8eb970870f07244567", "secret", null]`
+
+	args := new(UnlockAccountArgs)
+	if err := json.Unmarshal([]byte(input), &args); err != nil {
+		t.Error(err)
+	}
+
+	if args.Duration != DefaultUnlockDuration {
+		t.Errorf("Duration should be %v but is %v", DefaultUnlockDuration, args.Duration)
+	}
+}
+
+func TestUnlockAccountArgsInvalidDuration(t *testing.T) {
+	input := `["0xd46e8dd67c5d32be8058bb8eb970870f07244567", "secret", -1]`
+
+	args := new(UnlockAccountArgs)
+	str := ExpectValidationError(json.Unmarshal([]byte(input), &args))
+	if len(str) > 0 {
+		t.Error(str)
+	}
+}
+
+func TestLockAccountArgs(t *testing.T) {
+	input := `["0xd46e8dd67c5d32be8058bb8eb970870f07244567"]`
+
+	args := new(LockAccountArgs)
+	if err := json.Unmarshal([]byte(input), &args); err != nil {
+		t.Error(err)
+	}
+
+	if args.Address != "0xd46e8dd67c5d32be8058bb8eb970870f07244567" {
+		t.Errorf("Address should be %v but is %v", "0xd46e8dd67c5d32be8058bb8eb970870f07244567", args.Address)
+	}
+}
+
+func TestLockAccountArgsNotString(t *testing.T) {
+	input := `[1]`
+
+	args := new(LockAccountArgs)
+	str := ExpectInvalidTypeError(json.Unmarshal([]byte(input), &args))
+	if len(str) > 0 {
+		t.Error(str)
+	}
+}
+
+func TestTraceCallsArgs(t *testing.T) {
+	input := `["0x0000000000000000000000000000000000000000000000000000000000000001", "0x20"]`
+
+	args := new(TraceCallsArgs)
+	if err := json.Unmarshal([]byte(input), &args); err != nil {
+		t.Error(err)
+	}
+
+	if args.Hash != common.BigToHash(big.NewInt(1)) {
+		t.Errorf("Hash should be %x but is %x", common.BigToHash(big.NewInt(1)), args.Hash)
+	}
+
+	if args.Limit != 32 {
+		t.Errorf("Limit should be %v but is %v", 32, args.Limit)
+	}
+}
+
+func TestTraceCallsArgsDefaultLimit(t *testing.T) {
+	input := `["0x0000000000000000000000000000000000000000000000000000000000000001"]`
+
+	args := new(TraceCallsArgs)
+	if err := json.Unmarshal([]byte(input), &args); err != nil {
+		t.Error(err)
+	}
+
+	if args.Limit != DefaultTraceDataLimit {
+		t.Errorf("Limit should be %v but is %v", DefaultTraceDataLimit, args.Limit)
+	}
+}
+
+func TestTraceCallsArgsLimitTooLarge(t *testing.T) {
+	input := `["0x0000000000000000000000000000000000000000000000000000000000000001", 1000000]`
+
+	args := new(TraceCallsArgs)
+	str := ExpectValidationError(json.Unmarshal([]byte(input), &args))
+	if len(str) > 0 {
+		t.Error(str)
+	}
+}
+
+func TestTraceTransactionArgs(t *testing.T) {
+	input := `["0x0000000000000000000000000000000000000000000000000000000000000001", {"disableStack": true, "limit": 10}]`
+
+	args := new(TraceTransactionArgs)
+	if err := json.Unmarshal([]byte(input), &args); err != nil {
+		t.Error(err)
+	}
+
+	if args.Hash != common.BigToHash(big.NewInt(1)) {
+		t.Errorf("Hash should be %x but is %x", common.BigToHash(big.NewInt(1)), args.Hash)
+	}
+
+	if !args.Config.DisableStack || args.Config.DisableMemory || args.Config.DisableStorage {
+		t.Errorf("Config flags mismatch: %+v", args.Config)
+	}
+
+	if args.Config.Limit != 10 {
+		t.Errorf("Limit should be %v but is %v", 10, args.Config.Limit)
+	}
+}
+
+func TestTraceTransactionArgsDefaults(t *testing.T) {
+	input := `["0x0000000000000000000000000000000000000000000000000000000000000001"]`
+
+	args := new(TraceTransactionArgs)
+	if err := json.Unmarshal([]byte(input), &args); err != nil {
+		t.Error(err)
+	}
+
+	if args.Config.Limit != MaxTraceStructLogs {
+		t.Errorf("Limit should be %v but is %v", MaxTraceStructLogs, args.Config.Limit)
+	}
+
+	if args.Config.MemoryLimit != MaxTraceMemory {
+		t.Errorf("MemoryLimit should be %v but is %v", MaxTraceMemory, args.Config.MemoryLimit)
+	}
+}
+
+func TestTraceTransactionArgsInvalidOptions(t *testing.T) {
+	input := `["0x0000000000000000000000000000000000000000000000000000000000000001", {"disableMemory": 1}]`
+
+	args := new(TraceTransactionArgs)
+	str := ExpectInvalidTypeError(json.Unmarshal([]byte(input), &args))
+	if len(str) > 0 {
+		t.Error(str)
+	}
+
+	input = `["0x0000000000000000000000000000000000000000000000000000000000000001", {"limit": 0}]`
+	str = ExpectValidationError(json.Unmarshal([]byte(input), &args))
+	if len(str) > 0 {
+		t.Error(str)
+	}
+}
+
+func TestTraceBlockByNumberArgs(t *testing.T) {
+	input := `["0x2a", {"disableStorage": true}]`
+
+	args := new(TraceBlockByNumberArgs)
+	if err := json.Unmarshal([]byte(input), &args); err != nil {
+		t.Error(err)
+	}
+
+	if args.BlockNumber != 42 {
+		t.Errorf("BlockNumber should be %v but is %v", 42, args.BlockNumber)
+	}
+
+	if !args.Config.DisableStorage {
+		t.Errorf("DisableStorage should be set")
+	}
+}
+
+func TestTraceBlockByHashArgs(t *testing.T) {
+	input := `["0x0000000000000000000000000000000000000000000000000000000000000002", null]`
+
+	args := new(TraceBlockByHashArgs)
+	if err := json.Unmarshal([]byte(input), &args); err != nil {
+		t.Error(err)
+	}
+
+	if args.Hash != common.BigToHash(big.NewInt(2)) {
+		t.Errorf("Hash should be %x but is %x", common.BigToHash(big.NewInt(2)), args.Hash)
+	}
+
+	if args.Config.Limit != MaxTraceStructLogs {
+		t.Errorf("Limit should be %v but is %v", MaxTraceStructLogs, args.Config.Limit)
+	}
+}
+
+func TestCliqueProposeArgs(t *testing.T) {
+	input := `["0xd46e8dd67c5d32be8058bb8eb970870f07244567", true]`
+
+	args := new(CliqueProposeArgs)
+	if err := json.Unmarshal([]byte(input), &args); err != nil {
+		t.Error(err)
+	}
+
+	if args.Address != "0xd46e8dd67c5d32be8058bb8eb970870f07244567" {
+		t.Errorf("Address should be %v but is %v", "0xd46e8dd67c5d32be8058bb8eb970870f07244567", args.Address)
+	}
+	if !args.Authorize {
+		t.Errorf("Authorize should be true")
+	}
+}
+
+func TestCliqueProposeArgsInsufficient(t *testing.T) {
+	input := `["0xd46e8dd67c5d32be8058bb8eb970870f07244567"]`
+
+	args := new(CliqueProposeArgs)
+	str := ExpectInsufficientParamsError(json.Unmarshal([]byte(input), &args))
+	if len(str) > 0 {
+		t.Error(str)
+	}
+}
+
+func TestCliqueProposeArgsInvalidAddress(t *testing.T) {
+	input := `["0xd46e8dd67c5d32be8058bb8eb970870f072445", false]`
+
+	args := new(CliqueProposeArgs)
+	str := ExpectValidationError(json.Unmarshal([]byte(input), &args))
+	if len(str) > 0 {
+		t.Error(str)
+	}
+}
+
+func TestCliqueProposeArgsInvalidAuthorize(t *testing.T) {
+	input := `["0xd46e8dd67c5d32be8058bb8eb970870f07244567", "yes"]`
+
+	args := new(CliqueProposeArgs)
+	str := ExpectInvalidTypeError(json.Unmarshal([]byte(input), &args))
+	if len(str) > 0 {
+		t.Error(str)
+	}
+}
+
+func TestCliqueBlockArgs(t *testing.T) {
+	input := `["0x10"]`
+
+	args := new(CliqueBlockArgs)
+	if err := json.Unmarshal([]byte(input), &args); err != nil {
+		t.Error(err)
+	}
+
+	if args.BlockNumber != 16 {
+		t.Errorf("BlockNumber should be %d but is %d", 16, args.BlockNumber)
+	}
+}
+
+func TestCliqueBlockArgsPending(t *testing.T) {
+	input := `["pending"]`
+
+	args := new(CliqueBlockArgs)
+	str := ExpectValidationError(json.Unmarshal([]byte(input), &args))
+	if len(str) > 0 {
+		t.Error(str)
+	}
+}
+
+func TestTxPoolContentArgs(t *testing.T) {
+	input := `[{"from": "0xd46e8dd67c5d32be8058bb8eb970870f07244567", "minGasPrice": "0x3b9aca00", "offset": 100, "limit": "0x32"}]`
+
+	args := new(TxPoolContentArgs)
+	if err := json.Unmarshal([]byte(input), &args); err != nil {
+		t.Fatal(err)
+	}
+	if args.From == nil || *args.From != common.HexToAddress("0xd46e8dd67c5d32be8058bb8eb970870f07244567") {
+		t.Errorf("From should be %v but is %v", "0xd46e8dd67c5d32be8058bb8eb970870f07244567", args.From)
+	}
+	if args.MinPrice == nil || args.MinPrice.Cmp(big.NewInt(1000000000)) != 0 {
+		t.Errorf("MinPrice should be %v but is %v", 1000000000, args.MinPrice)
+	}
+	if args.Offset != 100 || args.Limit != 50 {
+		t.Errorf("Offset and Limit should be 100 and 50 but are %d and %d", args.Offset, args.Limit)
+	}
+}
+
+func TestTxPoolContentArgsEmpty(t *testing.T) {
+	input := `[]`
+
+	args := new(TxPoolContentArgs)
+	if err := json.Unmarshal([]byte(input), &args); err != nil {
+		t.Fatal(err)
+	}
+	if args.From != nil || args.MinPrice != nil || args.Offset != 0 || args.Limit != 0 {
+		t.Errorf("expected no filter, got %+v", args)
+	}
+}
+
+func TestTxPoolContentArgsInvalidLimit(t *testing.T) {
+	input := `[{"limit": -1}]`
+
+	args := new(TxPoolContentArgs)
+	str := ExpectValidationError(json.Unmarshal([]byte(input), &args))
+	if len(str) > 0 {
+		t.Error(str)
+	}
+}
+
+func TestTxPoolContentArgsInvalidFrom(t *testing.T) {
+	input := `[{"from": 5}]`
+
+	args := new(TxPoolContentArgs)
+	str := ExpectInvalidTypeError(json.Unmarshal([]byte(input), &args))
+	if len(str) > 0 {
+		t.Error(str)
+	}
+}
+
+func TestSubscribeArgs(t *testing.T) {
+	args := new(SubscribeArgs)
+	if err := json.Unmarshal([]byte(`["newHeads"]`), &args); err != nil {
+		t.Fatal(err)
+	}
+	if args.Kind != "newHeads" {
+		t.Errorf("Kind should be newHeads but is %s", args.Kind)
+	}
+
+	args = new(SubscribeArgs)
+	input := `["logs", {"address": "0xd5677cf67b5aa051bb40496e68ad359eb97cfbf8", "topics": [null, "0x5a5a"]}]`
+	if err := json.Unmarshal([]byte(input), &args); err != nil {
+		t.Fatal(err)
+	}
+	if len(args.Address) != 1 || args.Address[0] != "0xd5677cf67b5aa051bb40496e68ad359eb97cfbf8" {
+		t.Errorf("Address mismatch: %v", args.Address)
+	}
+	if len(args.Topics) != 2 || len(args.Topics[1]) != 1 || args.Topics[1][0] != "0x5a5a" {
+		t.Errorf("Topics mismatch: %v", args.Topics)
+	}
+
+	str := ExpectInsufficientParamsError(json.Unmarshal([]byte(`[]`), &args))
+	if len(str) > 0 {
+		t.Error(str)
+	}
+	args = new(SubscribeArgs)
+	if err := json.Unmarshal([]byte(`["syncing"]`), &args); err != nil {
+		t.Fatal(err)
+	}
+	if args.Kind != "syncing" {
+		t.Errorf("Kind should be syncing but is %s", args.Kind)
+	}
+	str = ExpectValidationError(json.Unmarshal([]byte(`["blocks"]`), &args))
+	if len(str) > 0 {
+		t.Error(str)
+	}
+	str = ExpectInvalidTypeError(json.Unmarshal([]byte(`[1]`), &args))
+	if len(str) > 0 {
+		t.Error(str)
+	}
+}
+
+func TestUnsubscribeArgs(t *testing.T) {
+	args := new(UnsubscribeArgs)
+	if err := json.Unmarshal([]byte(`["0x1234"]`), &args); err != nil {
+		t.Fatal(err)
+	}
+	if args.Id != "0x1234" {
+		t.Errorf("Id should be 0x1234 but is %s", args.Id)
+	}
+
+	str := ExpectInvalidTypeError(json.Unmarshal([]byte(`[1]`), &args))
+	if len(str) > 0 {
+		t.Error(str)
+	}
+}
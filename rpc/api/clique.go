@@ -0,0 +1,179 @@
+// Copyright 2016 The go-expanse Authors
+// This file is part of the go-expanse library.
+//
+// The go-expanse library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-expanse library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-expanse library. If not, see <http://www.gnu.org/licenses/>.
+
+package api
+
+import (
+	"fmt"
+
+	"github.com/expanse-project/go-expanse/clique"
+	"github.com/expanse-project/go-expanse/common"
+	"github.com/expanse-project/go-expanse/exp"
+	"github.com/expanse-project/go-expanse/rpc/codec"
+	"github.com/expanse-project/go-expanse/rpc/shared"
+	"github.com/expanse-project/go-expanse/xeth"
+)
+
+const (
+	CliqueApiVersion = "1.0"
+)
+
+var (
+	// mapping between methods and handlers
+	cliqueMapping = map[string]cliquehandler{
+		"clique_getSigners":  (*cliqueApi).GetSigners,
+		"clique_getSnapshot": (*cliqueApi).GetSnapshot,
+		"clique_proposals":   (*cliqueApi).Proposals,
+		"clique_propose":     (*cliqueApi).Propose,
+		"clique_discard":     (*cliqueApi).Discard,
+	}
+)
+
+// clique callback handler
+type cliquehandler func(*cliqueApi, *shared.Request) (interface{}, error)
+
+// clique api provider
+type cliqueApi struct {
+	xeth    *xeth.XEth
+	expanse *exp.Expanse
+	methods map[string]cliquehandler
+	codec   codec.ApiCoder
+}
+
+// create a new clique api instance
+func NewCliqueApi(xeth *xeth.XEth, exp *exp.Expanse, coder codec.Codec) *cliqueApi {
+	return &cliqueApi{
+		xeth:    xeth,
+		expanse: exp,
+		methods: cliqueMapping,
+		codec:   coder.New(nil),
+	}
+}
+
+// collection with supported methods
+func (self *cliqueApi) Methods() []string {
+	methods := make([]string, len(self.methods))
+	i := 0
+	for k := range self.methods {
+		methods[i] = k
+		i++
+	}
+	return methods
+}
+
+// Execute given request
+func (self *cliqueApi) Execute(req *shared.Request) (interface{}, error) {
+	if callback, ok := self.methods[req.Method]; ok {
+		return callback(self, req)
+	}
+
+	return nil, shared.NewNotImplementedError(req.Method)
+}
+
+func (self *cliqueApi) Name() string {
+	return shared.CliqueApiName
+}
+
+func (self *cliqueApi) ApiVersion() string {
+	return CliqueApiVersion
+}
+
+// engine returns the proof-of-authority engine of the node.
+func (self *cliqueApi) engine() (*clique.Clique, error) {
+	if engine := self.expanse.Clique(); engine != nil {
+		return engine, nil
+	}
+	return nil, fmt.Errorf("proof-of-authority not enabled (--clique)")
+}
+
+// snapshot returns the signer voting snapshot at the requested block.
+func (self *cliqueApi) snapshot(req *shared.Request) (*clique.Snapshot, error) {
+	engine, err := self.engine()
+	if err != nil {
+		return nil, err
+	}
+	args := &CliqueBlockArgs{BlockNumber: -1}
+	if len(req.Params) > 0 {
+		if err := self.codec.Decode(req.Params, &args); err != nil {
+			return nil, shared.NewDecodeParamError(err.Error())
+		}
+	}
+	block := self.xeth.EthBlockByNumber(args.BlockNumber)
+	if block == nil {
+		return nil, fmt.Errorf("block #%d not found", args.BlockNumber)
+	}
+	return engine.Snapshot(block.Header())
+}
+
+// GetSigners returns the signers authorized at a block, the latest by default.
+func (self *cliqueApi) GetSigners(req *shared.Request) (interface{}, error) {
+	snap, err := self.snapshot(req)
+	if err != nil {
+		return nil, err
+	}
+	return newCliqueSnapshotRes(snap).Signers, nil
+}
+
+// GetSnapshot returns the state of the signer voting at a block, the latest by
+// default.
+func (self *cliqueApi) GetSnapshot(req *shared.Request) (interface{}, error) {
+	snap, err := self.snapshot(req)
+	if err != nil {
+		return nil, err
+	}
+	return newCliqueSnapshotRes(snap), nil
+}
+
+// Proposals returns the accounts the local signer votes on, true meaning adding.
+func (self *cliqueApi) Proposals(req *shared.Request) (interface{}, error) {
+	engine, err := self.engine()
+	if err != nil {
+		return nil, err
+	}
+	proposals := make(map[string]bool)
+	for address, authorize := range engine.Proposals() {
+		proposals[address.Hex()] = authorize
+	}
+	return proposals, nil
+}
+
+// Propose makes the local signer vote on adding or removing an account.
+func (self *cliqueApi) Propose(req *shared.Request) (interface{}, error) {
+	engine, err := self.engine()
+	if err != nil {
+		return nil, err
+	}
+	args := new(CliqueProposeArgs)
+	if err := self.codec.Decode(req.Params, &args); err != nil {
+		return nil, shared.NewDecodeParamError(err.Error())
+	}
+	engine.Propose(common.HexToAddress(args.Address), args.Authorize)
+	return true, nil
+}
+
+// Discard drops the proposal on an account.
+func (self *cliqueApi) Discard(req *shared.Request) (interface{}, error) {
+	engine, err := self.engine()
+	if err != nil {
+		return nil, err
+	}
+	args := new(CliqueDiscardArgs)
+	if err := self.codec.Decode(req.Params, &args); err != nil {
+		return nil, shared.NewDecodeParamError(err.Error())
+	}
+	engine.Discard(common.HexToAddress(args.Address))
+	return true, nil
+}
@@ -0,0 +1,104 @@
+// Copyright 2016 The go-expanse Authors
+// This file is part of the go-expanse library.
+//
+// The go-expanse library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-expanse library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-expanse library. If not, see <http://www.gnu.org/licenses/>.
+
+package api
+
+import (
+	"encoding/json"
+
+	"github.com/expanse-project/go-expanse/common"
+	"github.com/expanse-project/go-expanse/rpc/shared"
+)
+
+// cliqueAddress decodes the account parameter of the clique methods.
+func cliqueAddress(raw interface{}) (string, error) {
+	addstr, ok := raw.(string)
+	if !ok {
+		return "", shared.NewInvalidTypeError("address", "not a string")
+	}
+	if len(common.FromHex(addstr)) != len(common.Address{}) {
+		return "", shared.NewValidationError("address", "not a 20 byte hex address")
+	}
+	return addstr, nil
+}
+
+type CliqueBlockArgs struct {
+	BlockNumber int64
+}
+
+func (args *CliqueBlockArgs) UnmarshalJSON(b []byte) (err error) {
+	var obj []interface{}
+	if err := json.Unmarshal(b, &obj); err != nil {
+		return shared.NewDecodeParamError(err.Error())
+	}
+
+	args.BlockNumber = -1
+	if len(obj) > 0 && obj[0] != nil {
+		if err := blockHeight(obj[0], &args.BlockNumber); err != nil {
+			return err
+		}
+	}
+	if args.BlockNumber == -2 {
+		return shared.NewValidationError("blockNumber", "pending block is not sealed yet")
+	}
+
+	return nil
+}
+
+type CliqueProposeArgs struct {
+	Address   string
+	Authorize bool
+}
+
+func (args *CliqueProposeArgs) UnmarshalJSON(b []byte) (err error) {
+	var obj []interface{}
+	if err := json.Unmarshal(b, &obj); err != nil {
+		return shared.NewDecodeParamError(err.Error())
+	}
+
+	if len(obj) < 2 {
+		return shared.NewInsufficientParamsError(len(obj), 2)
+	}
+
+	if args.Address, err = cliqueAddress(obj[0]); err != nil {
+		return err
+	}
+	authorize, ok := obj[1].(bool)
+	if !ok {
+		return shared.NewInvalidTypeError("authorize", "not a boolean")
+	}
+	args.Authorize = authorize
+
+	return nil
+}
+
+type CliqueDiscardArgs struct {
+	Address string
+}
+
+func (args *CliqueDiscardArgs) UnmarshalJSON(b []byte) (err error) {
+	var obj []interface{}
+	if err := json.Unmarshal(b, &obj); err != nil {
+		return shared.NewDecodeParamError(err.Error())
+	}
+
+	if len(obj) < 1 {
+		return shared.NewInsufficientParamsError(len(obj), 1)
+	}
+
+	args.Address, err = cliqueAddress(obj[0])
+	return err
+}
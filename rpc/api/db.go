@@ -17,6 +17,7 @@
 package api
 
 import (
+	"github.com/expanse-project/go-expanse/common/hexutil"
 	"github.com/expanse-project/go-expanse/exp"
 	"github.com/expanse-project/go-expanse/rpc/codec"
 	"github.com/expanse-project/go-expanse/rpc/shared"
@@ -124,7 +125,7 @@ func (self *dbApi) GetHex(req *shared.Request) (interface{}, error) {
 	}
 
 	if res, err := self.xeth.DbGet([]byte(args.Database + args.Key)); err == nil {
-		return newHexData(res), nil
+		return hexutil.Bytes(res), nil
 	} else {
 		return nil, err
 	}
@@ -21,11 +21,12 @@ import (
 	"strings"
 	"time"
 
-	"github.com/expanse-project/ethash"
 	"github.com/expanse-project/go-expanse/core"
 	"github.com/expanse-project/go-expanse/core/state"
+	"github.com/expanse-project/go-expanse/core/types"
 	"github.com/expanse-project/go-expanse/core/vm"
 	"github.com/expanse-project/go-expanse/exp"
+	"github.com/expanse-project/go-expanse/pow/ethash"
 	"github.com/expanse-project/go-expanse/rlp"
 	"github.com/expanse-project/go-expanse/rpc/codec"
 	"github.com/expanse-project/go-expanse/rpc/shared"
@@ -35,6 +36,20 @@ import (
 
 const (
 	DebugApiVersion = "1.0"
+
+	// DefaultTraceDataLimit is the number of input and output bytes kept per
+	// call by debug_traceTransactionCalls, enough for a method id and two
+	// arguments.
+	DefaultTraceDataLimit = 68
+	// MaxTraceDataLimit bounds the data limit a request may ask for.
+	MaxTraceDataLimit = 4096
+	// MaxTraceStructLogs is the number of instructions traced per transaction
+	// by the debug_trace* methods, unless a lower limit is requested.
+	MaxTraceStructLogs = 100000
+	// MaxTraceMemory is the number of memory bytes recorded over all traced
+	// instructions of a transaction, later instructions are traced without
+	// their memory.
+	MaxTraceMemory = 16 * 1024 * 1024
 )
 
 var (
@@ -47,6 +62,14 @@ var (
 		"debug_seedHash":     (*debugApi).SeedHash,
 		"debug_setHead":      (*debugApi).SetHead,
 		"debug_metrics":      (*debugApi).Metrics,
+		"debug_getTd":        (*debugApi).GetTd,
+		"debug_compareForks": (*debugApi).CompareForks,
+
+		"debug_getModifiedAccountsByNumber": (*debugApi).GetModifiedAccountsByNumber,
+		"debug_traceTransactionCalls":       (*debugApi).TraceTransactionCalls,
+		"debug_traceTransaction":            (*debugApi).TraceTransaction,
+		"debug_traceBlockByNumber":          (*debugApi).TraceBlockByNumber,
+		"debug_traceBlockByHash":            (*debugApi).TraceBlockByHash,
 	}
 )
 
@@ -142,6 +165,176 @@ func (self *debugApi) GetBlockRlp(req *shared.Request) (interface{}, error) {
 	return fmt.Sprintf("%x", encoded), err
 }
 
+// GetModifiedAccountsByNumber returns the accounts modified between two blocks,
+// or by a single block if no end block is given.
+func (self *debugApi) GetModifiedAccountsByNumber(req *shared.Request) (interface{}, error) {
+	args := new(ModifiedAccountsArgs)
+	if err := self.codec.Decode(req.Params, &args); err != nil {
+		return nil, shared.NewDecodeParamError(err.Error())
+	}
+
+	start := self.xeth.EthBlockByNumber(args.Start)
+	if start == nil {
+		return nil, fmt.Errorf("block #%d not found", args.Start)
+	}
+	end := start
+	if args.HasEnd {
+		if end = self.xeth.EthBlockByNumber(args.End); end == nil {
+			return nil, fmt.Errorf("block #%d not found", args.End)
+		}
+	} else {
+		if start = self.expanse.BlockChain().GetBlock(end.ParentHash()); start == nil {
+			return nil, fmt.Errorf("parent of block #%d not found", end.NumberU64())
+		}
+	}
+	accounts, err := state.ModifiedAccounts(self.expanse.ChainDb(), start.Root(), end.Root(), args.IncludeStorage)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]*ModifiedAccountRes, len(accounts))
+	for i, account := range accounts {
+		res[i] = NewModifiedAccountRes(account)
+	}
+	return res, nil
+}
+
+// GetTd returns the total difficulty of a block, identified by hash or number.
+func (self *debugApi) GetTd(req *shared.Request) (interface{}, error) {
+	args := new(TdArgs)
+	if err := self.codec.Decode(req.Params, &args); err != nil {
+		return nil, shared.NewDecodeParamError(err.Error())
+	}
+	blockchain := self.expanse.BlockChain()
+
+	var header *types.Header
+	if args.ByHash {
+		if header = blockchain.GetHeader(args.Hash); header == nil {
+			return nil, fmt.Errorf("block %x not found", args.Hash)
+		}
+	} else {
+		block := self.xeth.EthBlockByNumber(args.Number)
+		if block == nil {
+			return nil, fmt.Errorf("block #%d not found", args.Number)
+		}
+		header = block.Header()
+	}
+	td := blockchain.GetTd(header.Hash())
+	if td == nil {
+		return nil, fmt.Errorf("total difficulty of block %x not found", header.Hash())
+	}
+	return NewTdRes(header, td), nil
+}
+
+// CompareForks compares the cumulative difficulty two chain heads added on
+// top of their common ancestor.
+func (self *debugApi) CompareForks(req *shared.Request) (interface{}, error) {
+	args := new(CompareForksArgs)
+	if err := self.codec.Decode(req.Params, &args); err != nil {
+		return nil, shared.NewDecodeParamError(err.Error())
+	}
+	blockchain := self.expanse.BlockChain()
+
+	a, b := blockchain.GetHeader(args.A), blockchain.GetHeader(args.B)
+	if a == nil {
+		return nil, fmt.Errorf("block %x not found", args.A)
+	}
+	if b == nil {
+		return nil, fmt.Errorf("block %x not found", args.B)
+	}
+	ancestor := core.FindCommonAncestor(self.expanse.ChainDb(), a, b)
+	if ancestor == nil {
+		return nil, fmt.Errorf("no common ancestor of %x and %x", args.A, args.B)
+	}
+	ancestorTd, aTd, bTd := blockchain.GetTd(ancestor.Hash()), blockchain.GetTd(a.Hash()), blockchain.GetTd(b.Hash())
+	if ancestorTd == nil || aTd == nil || bTd == nil {
+		return nil, fmt.Errorf("total difficulty of fork not found")
+	}
+	return NewCompareForksRes(ancestor, a, b, ancestorTd, aTd, bTd), nil
+}
+
+// TraceTransactionCalls re-executes a transaction and returns its call tree,
+// without the per instruction logs of a full trace.
+func (self *debugApi) TraceTransactionCalls(req *shared.Request) (interface{}, error) {
+	args := new(TraceCallsArgs)
+	if err := self.codec.Decode(req.Params, &args); err != nil {
+		return nil, shared.NewDecodeParamError(err.Error())
+	}
+	blockchain := self.expanse.BlockChain()
+
+	tx, blockHash, _, index := core.GetTransaction(self.expanse.ChainDb(), args.Hash)
+	if tx == nil {
+		return nil, fmt.Errorf("transaction %x not found", args.Hash)
+	}
+	block := blockchain.GetBlock(blockHash)
+	if block == nil {
+		return nil, fmt.Errorf("block %x not found", blockHash)
+	}
+	root, err := core.TraceCalls(blockchain, block, int(index), args.Limit)
+	if err != nil {
+		return nil, err
+	}
+	return NewCallFrameRes(root), nil
+}
+
+func (self *debugApi) TraceTransaction(req *shared.Request) (interface{}, error) {
+	args := new(TraceTransactionArgs)
+	if err := self.codec.Decode(req.Params, &args); err != nil {
+		return nil, shared.NewDecodeParamError(err.Error())
+	}
+	blockchain := self.expanse.BlockChain()
+
+	tx, blockHash, _, index := core.GetTransaction(self.expanse.ChainDb(), args.Hash)
+	if tx == nil {
+		return nil, fmt.Errorf("transaction %x not found", args.Hash)
+	}
+	block := blockchain.GetBlock(blockHash)
+	if block == nil {
+		return nil, fmt.Errorf("block %x not found", blockHash)
+	}
+	trace, err := core.TraceTransaction(blockchain, block, int(index), args.Config)
+	if err != nil {
+		return nil, err
+	}
+	return NewExecutionTraceRes(trace), nil
+}
+
+func (self *debugApi) TraceBlockByNumber(req *shared.Request) (interface{}, error) {
+	args := new(TraceBlockByNumberArgs)
+	if err := self.codec.Decode(req.Params, &args); err != nil {
+		return nil, shared.NewDecodeParamError(err.Error())
+	}
+	block := self.xeth.EthBlockByNumber(args.BlockNumber)
+	if block == nil {
+		return nil, fmt.Errorf("block #%d not found", args.BlockNumber)
+	}
+	return self.traceBlock(block, args.Config)
+}
+
+func (self *debugApi) TraceBlockByHash(req *shared.Request) (interface{}, error) {
+	args := new(TraceBlockByHashArgs)
+	if err := self.codec.Decode(req.Params, &args); err != nil {
+		return nil, shared.NewDecodeParamError(err.Error())
+	}
+	block := self.expanse.BlockChain().GetBlock(args.Hash)
+	if block == nil {
+		return nil, fmt.Errorf("block %x not found", args.Hash)
+	}
+	return self.traceBlock(block, args.Config)
+}
+
+// traceBlock re-executes the transactions of block and returns their traces.
+func (self *debugApi) traceBlock(block *types.Block, cfg vm.LogConfig) (interface{}, error) {
+	traces, err := core.TraceBlock(self.expanse.BlockChain(), block, cfg)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]*ExecutionTraceRes, len(traces))
+	for i, trace := range traces {
+		res[i] = NewExecutionTraceRes(trace)
+	}
+	return res, nil
+}
+
 func (self *debugApi) SetHead(req *shared.Request) (interface{}, error) {
 	args := new(BlockNumArg)
 	if err := self.codec.Decode(req.Params, &args); err != nil {
@@ -174,7 +367,7 @@ func (self *debugApi) ProcessBlock(req *shared.Request) (interface{}, error) {
 		processor  = blockchain.Processor()
 	)
 
-	err := core.ValidateHeader(blockchain.AuxValidator(), block.Header(), blockchain.GetHeader(block.ParentHash()), true, false)
+	err := core.ValidateHeader(blockchain.Engine(), block.Header(), blockchain.GetHeader(block.ParentHash()), true, false)
 	if err != nil {
 		return false, err
 	}
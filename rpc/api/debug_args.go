@@ -22,6 +22,8 @@ import (
 	"math/big"
 	"reflect"
 
+	"github.com/expanse-project/go-expanse/common"
+	"github.com/expanse-project/go-expanse/core/vm"
 	"github.com/expanse-project/go-expanse/rpc/shared"
 )
 
@@ -85,3 +87,228 @@ func (args *MetricsArgs) UnmarshalJSON(b []byte) (err error) {
 	}
 	return nil
 }
+
+type ModifiedAccountsArgs struct {
+	Start          int64
+	End            int64
+	HasEnd         bool
+	IncludeStorage bool
+}
+
+func (args *ModifiedAccountsArgs) UnmarshalJSON(b []byte) (err error) {
+	var obj []interface{}
+	if err := json.Unmarshal(b, &obj); err != nil {
+		return shared.NewDecodeParamError(err.Error())
+	}
+	if len(obj) < 1 {
+		return shared.NewInsufficientParamsError(len(obj), 1)
+	}
+	if len(obj) > 3 {
+		return fmt.Errorf("modifiedAccountsArgs needs 1, 2, 3 arguments")
+	}
+	if err := blockHeight(obj[0], &args.Start); err != nil {
+		return err
+	}
+	if len(obj) >= 2 && obj[1] != nil {
+		if err := blockHeight(obj[1], &args.End); err != nil {
+			return err
+		}
+		args.HasEnd = true
+	}
+	if len(obj) >= 3 && obj[2] != nil {
+		if value, ok := obj[2].(bool); !ok {
+			return shared.NewInvalidTypeError("includeStorage", "not a bool")
+		} else {
+			args.IncludeStorage = value
+		}
+	}
+	return nil
+}
+
+// blockHash parses a 0x prefixed 32 byte hex string into a block hash.
+func blockHash(raw interface{}, hash *common.Hash) error {
+	str, ok := raw.(string)
+	if !ok {
+		return shared.NewInvalidTypeError("hash", "not a string")
+	}
+	if err := hash.UnmarshalText([]byte(str)); err != nil {
+		return shared.NewInvalidTypeError("hash", err.Error())
+	}
+	return nil
+}
+
+type TdArgs struct {
+	Hash   common.Hash
+	Number int64
+	ByHash bool
+}
+
+func (args *TdArgs) UnmarshalJSON(b []byte) (err error) {
+	var obj []interface{}
+	if err := json.Unmarshal(b, &obj); err != nil {
+		return shared.NewDecodeParamError(err.Error())
+	}
+	if len(obj) < 1 {
+		return shared.NewInsufficientParamsError(len(obj), 1)
+	}
+	if str, ok := obj[0].(string); ok && len(str) == 2+2*len(args.Hash) {
+		args.ByHash = true
+		return blockHash(obj[0], &args.Hash)
+	}
+	return blockHeight(obj[0], &args.Number)
+}
+
+type CompareForksArgs struct {
+	A common.Hash
+	B common.Hash
+}
+
+func (args *CompareForksArgs) UnmarshalJSON(b []byte) (err error) {
+	var obj []interface{}
+	if err := json.Unmarshal(b, &obj); err != nil {
+		return shared.NewDecodeParamError(err.Error())
+	}
+	if len(obj) < 2 {
+		return shared.NewInsufficientParamsError(len(obj), 2)
+	}
+	if err := blockHash(obj[0], &args.A); err != nil {
+		return err
+	}
+	return blockHash(obj[1], &args.B)
+}
+
+type TraceCallsArgs struct {
+	Hash  common.Hash
+	Limit int
+}
+
+func (args *TraceCallsArgs) UnmarshalJSON(b []byte) (err error) {
+	var obj []interface{}
+	if err := json.Unmarshal(b, &obj); err != nil {
+		return shared.NewDecodeParamError(err.Error())
+	}
+	if len(obj) < 1 {
+		return shared.NewInsufficientParamsError(len(obj), 1)
+	}
+	if err := blockHash(obj[0], &args.Hash); err != nil {
+		return err
+	}
+	args.Limit = DefaultTraceDataLimit
+	if len(obj) > 1 && obj[1] != nil {
+		limit, err := numString(obj[1])
+		if err != nil {
+			return err
+		}
+		if limit.Sign() < 0 || limit.Int64() > MaxTraceDataLimit {
+			return shared.NewValidationError("limit", fmt.Sprintf("must be between 0 and %d", MaxTraceDataLimit))
+		}
+		args.Limit = int(limit.Int64())
+	}
+	return nil
+}
+
+// traceConfig parses the optional options object of the debug_trace* methods
+// into cfg. The number of instructions traced per transaction defaults to
+// MaxTraceStructLogs, the memory they record is capped at MaxTraceMemory.
+func traceConfig(raw interface{}, cfg *vm.LogConfig) error {
+	cfg.Limit = MaxTraceStructLogs
+	cfg.MemoryLimit = MaxTraceMemory
+	if raw == nil {
+		return nil
+	}
+	opts, ok := raw.(map[string]interface{})
+	if !ok {
+		return shared.NewInvalidTypeError("options", "not an object")
+	}
+	for name, flag := range map[string]*bool{
+		"disableMemory":  &cfg.DisableMemory,
+		"disableStack":   &cfg.DisableStack,
+		"disableStorage": &cfg.DisableStorage,
+	} {
+		if opts[name] == nil {
+			continue
+		}
+		value, ok := opts[name].(bool)
+		if !ok {
+			return shared.NewInvalidTypeError(name, "not a bool")
+		}
+		*flag = value
+	}
+	if opts["limit"] != nil {
+		limit, err := numString(opts["limit"])
+		if err != nil {
+			return err
+		}
+		if limit.Sign() <= 0 || limit.Int64() > MaxTraceStructLogs {
+			return shared.NewValidationError("limit", fmt.Sprintf("must be between 1 and %d", MaxTraceStructLogs))
+		}
+		cfg.Limit = int(limit.Int64())
+	}
+	return nil
+}
+
+type TraceTransactionArgs struct {
+	Hash   common.Hash
+	Config vm.LogConfig
+}
+
+func (args *TraceTransactionArgs) UnmarshalJSON(b []byte) (err error) {
+	var obj []interface{}
+	if err := json.Unmarshal(b, &obj); err != nil {
+		return shared.NewDecodeParamError(err.Error())
+	}
+	if len(obj) < 1 {
+		return shared.NewInsufficientParamsError(len(obj), 1)
+	}
+	if err := blockHash(obj[0], &args.Hash); err != nil {
+		return err
+	}
+	if len(obj) > 1 {
+		return traceConfig(obj[1], &args.Config)
+	}
+	return traceConfig(nil, &args.Config)
+}
+
+type TraceBlockByNumberArgs struct {
+	BlockNumber int64
+	Config      vm.LogConfig
+}
+
+func (args *TraceBlockByNumberArgs) UnmarshalJSON(b []byte) (err error) {
+	var obj []interface{}
+	if err := json.Unmarshal(b, &obj); err != nil {
+		return shared.NewDecodeParamError(err.Error())
+	}
+	if len(obj) < 1 {
+		return shared.NewInsufficientParamsError(len(obj), 1)
+	}
+	if err := blockHeight(obj[0], &args.BlockNumber); err != nil {
+		return err
+	}
+	if len(obj) > 1 {
+		return traceConfig(obj[1], &args.Config)
+	}
+	return traceConfig(nil, &args.Config)
+}
+
+type TraceBlockByHashArgs struct {
+	Hash   common.Hash
+	Config vm.LogConfig
+}
+
+func (args *TraceBlockByHashArgs) UnmarshalJSON(b []byte) (err error) {
+	var obj []interface{}
+	if err := json.Unmarshal(b, &obj); err != nil {
+		return shared.NewDecodeParamError(err.Error())
+	}
+	if len(obj) < 1 {
+		return shared.NewInsufficientParamsError(len(obj), 1)
+	}
+	if err := blockHash(obj[0], &args.Hash); err != nil {
+		return err
+	}
+	if len(obj) > 1 {
+		return traceConfig(obj[1], &args.Config)
+	}
+	return traceConfig(nil, &args.Config)
+}
@@ -20,12 +20,18 @@ import (
 	"bytes"
 	"encoding/json"
 	"math/big"
+	"strings"
 
 	"fmt"
 
 	"github.com/expanse-project/go-expanse/common"
+	"github.com/expanse-project/go-expanse/common/hexutil"
 	"github.com/expanse-project/go-expanse/common/natspec"
+	"github.com/expanse-project/go-expanse/core"
+	"github.com/expanse-project/go-expanse/core/types"
+	"github.com/expanse-project/go-expanse/core/vm"
 	"github.com/expanse-project/go-expanse/exp"
+	"github.com/expanse-project/go-expanse/miner"
 	"github.com/expanse-project/go-expanse/rlp"
 	"github.com/expanse-project/go-expanse/rpc/codec"
 	"github.com/expanse-project/go-expanse/rpc/shared"
@@ -37,6 +43,34 @@ const (
 	EthApiVersion = "1.0"
 )
 
+// MaxGasStatsBlocks is the maximum number of blocks eth_getGasStats aggregates
+// over in a single request.
+var MaxGasStatsBlocks uint64 = 1024
+
+// MaxUncleStatsBlocks is the maximum number of blocks eth_getUncleStats
+// aggregates over in a single request.
+var MaxUncleStatsBlocks uint64 = 10000
+
+// DefaultHashrateBlocks is the number of blocks eth_getNetworkHashrate estimates
+// the hashrate over if the request doesn't specify a window.
+var DefaultHashrateBlocks uint64 = 100
+
+// MaxHashrateBlocks is the largest window eth_getNetworkHashrate estimates the
+// hashrate over in a single request.
+var MaxHashrateBlocks uint64 = 10000
+
+// MaxInternalTxsBlocks is the maximum number of blocks eth_getInternalTransactions
+// searches in a single request.
+var MaxInternalTxsBlocks uint64 = 10000
+
+// SubscriptionBuffer is the number of events buffered per subscription before
+// further ones are dropped.
+var SubscriptionBuffer = 1024
+
+// StrictArgs makes the eth api reject malformed hashes and out of range
+// indices with a validation error instead of answering them with null.
+var StrictArgs = false
+
 // exp api provider
 // See https://github.com/expanse-project/wiki/wiki/JSON-RPC
 type ethApi struct {
@@ -54,7 +88,9 @@ var (
 		"eth_accounts":                            (*ethApi).Accounts,
 		"eth_blockNumber":                         (*ethApi).BlockNumber,
 		"eth_getBalance":                          (*ethApi).GetBalance,
+		"eth_getConfirmedBalance":                 (*ethApi).GetConfirmedBalance,
 		"eth_protocolVersion":                     (*ethApi).ProtocolVersion,
+		"eth_chainId":                             (*ethApi).ChainId,
 		"eth_coinbase":                            (*ethApi).Coinbase,
 		"eth_mining":                              (*ethApi).IsMining,
 		"eth_syncing":                             (*ethApi).IsSyncing,
@@ -73,6 +109,7 @@ var (
 		"eth_sign":                                (*ethApi).Sign,
 		"eth_sendRawTransaction":                  (*ethApi).SubmitTransaction,
 		"eth_submitTransaction":                   (*ethApi).SubmitTransaction,
+		"eth_validateRawTransaction":              (*ethApi).ValidateRawTransaction,
 		"eth_sendTransaction":                     (*ethApi).SendTransaction,
 		"eth_signTransaction":                     (*ethApi).SignTransaction,
 		"eth_transact":                            (*ethApi).SendTransaction,
@@ -91,10 +128,14 @@ var (
 		"eth_newFilter":                           (*ethApi).NewFilter,
 		"eth_newBlockFilter":                      (*ethApi).NewBlockFilter,
 		"eth_newPendingTransactionFilter":         (*ethApi).NewPendingTransactionFilter,
+		"eth_newConfirmationFilter":               (*ethApi).NewConfirmationFilter,
+		"eth_newDroppedTransactionFilter":         (*ethApi).NewDroppedTransactionFilter,
 		"eth_uninstallFilter":                     (*ethApi).UninstallFilter,
 		"eth_getFilterChanges":                    (*ethApi).GetFilterChanges,
 		"eth_getFilterLogs":                       (*ethApi).GetFilterLogs,
 		"eth_getLogs":                             (*ethApi).GetLogs,
+		"eth_subscribe":                           (*ethApi).Subscribe,
+		"eth_unsubscribe":                         (*ethApi).Unsubscribe,
 		"eth_hashrate":                            (*ethApi).Hashrate,
 		"eth_getWork":                             (*ethApi).GetWork,
 		"eth_submitWork":                          (*ethApi).SubmitWork,
@@ -102,10 +143,18 @@ var (
 		"eth_resend":                              (*ethApi).Resend,
 		"eth_pendingTransactions":                 (*ethApi).PendingTransactions,
 		"eth_getTransactionReceipt":               (*ethApi).GetTransactionReceipt,
+		"eth_getGasStats":                         (*ethApi).GetGasStats,
+		"eth_getUncleStats":                       (*ethApi).GetUncleStats,
+		"eth_getInternalTransactions":             (*ethApi).GetInternalTransactions,
+		"eth_getNetworkHashrate":                  (*ethApi).GetNetworkHashrate,
+		"eth_reserveNonce":                        (*ethApi).ReserveNonce,
+		"eth_releaseNonce":                        (*ethApi).ReleaseNonce,
 		"exp_accounts":                            (*ethApi).Accounts,
 		"exp_blockNumber":                         (*ethApi).BlockNumber,
 		"exp_getBalance":                          (*ethApi).GetBalance,
+		"exp_getConfirmedBalance":                 (*ethApi).GetConfirmedBalance,
 		"exp_protocolVersion":                     (*ethApi).ProtocolVersion,
+		"exp_chainId":                             (*ethApi).ChainId,
 		"exp_coinbase":                            (*ethApi).Coinbase,
 		"exp_mining":                              (*ethApi).IsMining,
 		"exp_syncing":                             (*ethApi).IsSyncing,
@@ -122,6 +171,7 @@ var (
 		"exp_getCode":                             (*ethApi).GetData,
 		"exp_sign":                                (*ethApi).Sign,
 		"exp_sendRawTransaction":                  (*ethApi).SendTransaction,
+		"exp_validateRawTransaction":              (*ethApi).ValidateRawTransaction,
 		"exp_sendTransaction":                     (*ethApi).SendTransaction,
 		"exp_transact":                            (*ethApi).SendTransaction,
 		"exp_estimateGas":                         (*ethApi).EstimateGas,
@@ -139,10 +189,14 @@ var (
 		"exp_newFilter":                           (*ethApi).NewFilter,
 		"exp_newBlockFilter":                      (*ethApi).NewBlockFilter,
 		"exp_newPendingTransactionFilter":         (*ethApi).NewPendingTransactionFilter,
+		"exp_newConfirmationFilter":               (*ethApi).NewConfirmationFilter,
+		"exp_newDroppedTransactionFilter":         (*ethApi).NewDroppedTransactionFilter,
 		"exp_uninstallFilter":                     (*ethApi).UninstallFilter,
 		"exp_getFilterChanges":                    (*ethApi).GetFilterChanges,
 		"exp_getFilterLogs":                       (*ethApi).GetFilterLogs,
 		"exp_getLogs":                             (*ethApi).GetLogs,
+		"exp_subscribe":                           (*ethApi).Subscribe,
+		"exp_unsubscribe":                         (*ethApi).Unsubscribe,
 		"exp_hashrate":                            (*ethApi).Hashrate,
 		"exp_getWork":                             (*ethApi).GetWork,
 		"exp_submitWork":                          (*ethApi).SubmitWork,
@@ -150,6 +204,12 @@ var (
 		"exp_resend":                              (*ethApi).Resend,
 		"exp_pendingTransactions":                 (*ethApi).PendingTransactions,
 		"exp_getTransactionReceipt":               (*ethApi).GetTransactionReceipt,
+		"exp_getGasStats":                         (*ethApi).GetGasStats,
+		"exp_getUncleStats":                       (*ethApi).GetUncleStats,
+		"exp_getInternalTransactions":             (*ethApi).GetInternalTransactions,
+		"exp_getNetworkHashrate":                  (*ethApi).GetNetworkHashrate,
+		"exp_reserveNonce":                        (*ethApi).ReserveNonce,
+		"exp_releaseNonce":                        (*ethApi).ReleaseNonce,
 	}
 )
 
@@ -191,12 +251,12 @@ func (self *ethApi) Accounts(req *shared.Request) (interface{}, error) {
 }
 
 func (self *ethApi) Hashrate(req *shared.Request) (interface{}, error) {
-	return newHexNum(self.xeth.HashRate()), nil
+	return hexutil.Uint64(self.xeth.HashRate()), nil
 }
 
 func (self *ethApi) BlockNumber(req *shared.Request) (interface{}, error) {
 	num := self.xeth.CurrentBlock().Number()
-	return newHexNum(num.Bytes()), nil
+	return (*hexutil.Big)(num), nil
 }
 
 func (self *ethApi) GetBalance(req *shared.Request) (interface{}, error) {
@@ -205,15 +265,56 @@ func (self *ethApi) GetBalance(req *shared.Request) (interface{}, error) {
 		return nil, shared.NewDecodeParamError(err.Error())
 	}
 
+	if args.BlockNumber == -2 {
+		return common.ToHex(self.xeth.PendingBalance(args.Address).Bytes()), nil
+	}
 	return self.xeth.AtStateNum(args.BlockNumber).BalanceAt(args.Address), nil
 }
 
+// GetConfirmedBalance returns the balance of an address at the block which has
+// the requested number of confirmations on top of it, together with the number
+// and hash of that block so the caller can detect later reorganisations.
+func (self *ethApi) GetConfirmedBalance(req *shared.Request) (interface{}, error) {
+	args := new(GetConfirmedBalanceArgs)
+	if err := self.codec.Decode(req.Params, &args); err != nil {
+		return nil, shared.NewDecodeParamError(err.Error())
+	}
+
+	// Reject confirmations beyond the head up front, the block may still be
+	// missing if the chain is reorganised to a shorter one meanwhile.
+	if height := self.xeth.CurrentBlock().NumberU64(); args.Confirmations > height {
+		return nil, shared.NewValidationError("confirmations", fmt.Sprintf("exceeds the current chain height %d", height))
+	}
+	block := self.xeth.ConfirmedBlock(args.Confirmations)
+	if block == nil {
+		return nil, shared.NewValidationError("confirmations", "exceeds the current chain height")
+	}
+	state := self.xeth.AtBlock(block)
+	if state == nil {
+		return nil, fmt.Errorf("state of block %x not available", block.Hash())
+	}
+	return &ConfirmedBalanceRes{
+		Balance:     (*hexutil.Big)(state.State().State().GetBalance(common.HexToAddress(args.Address))),
+		BlockNumber: (*hexutil.Big)(block.Number()),
+		BlockHash:   block.Hash().Bytes(),
+	}, nil
+}
+
 func (self *ethApi) ProtocolVersion(req *shared.Request) (interface{}, error) {
 	return self.xeth.EthVersion(), nil
 }
 
+// ChainId returns the chain id transactions are signed with for replay
+// protection, or null if the chain doesn't configure one.
+func (self *ethApi) ChainId(req *shared.Request) (interface{}, error) {
+	if id := self.xeth.ChainId(); id != nil {
+		return (*hexutil.Big)(id), nil
+	}
+	return nil, nil
+}
+
 func (self *ethApi) Coinbase(req *shared.Request) (interface{}, error) {
-	return newHexData(self.xeth.Coinbase()), nil
+	return hexutil.Bytes(common.FromHex(self.xeth.Coinbase())), nil
 }
 
 func (self *ethApi) IsMining(req *shared.Request) (interface{}, error) {
@@ -222,18 +323,21 @@ func (self *ethApi) IsMining(req *shared.Request) (interface{}, error) {
 
 func (self *ethApi) IsSyncing(req *shared.Request) (interface{}, error) {
 	origin, current, height := self.expanse.Downloader().Progress()
+	pulled, known := self.expanse.Downloader().StateProgress()
 	if current < height {
 		return map[string]interface{}{
-			"startingBlock": newHexNum(big.NewInt(int64(origin)).Bytes()),
-			"currentBlock":  newHexNum(big.NewInt(int64(current)).Bytes()),
-			"highestBlock":  newHexNum(big.NewInt(int64(height)).Bytes()),
+			"startingBlock": hexutil.Uint64(origin),
+			"currentBlock":  hexutil.Uint64(current),
+			"highestBlock":  hexutil.Uint64(height),
+			"pulledStates":  hexutil.Uint64(pulled),
+			"knownStates":   hexutil.Uint64(known),
 		}, nil
 	}
 	return false, nil
 }
 
 func (self *ethApi) GasPrice(req *shared.Request) (interface{}, error) {
-	return newHexNum(self.xeth.DefaultGasPrice().Bytes()), nil
+	return (*hexutil.Big)(self.xeth.DefaultGasPrice()), nil
 }
 
 func (self *ethApi) GetStorage(req *shared.Request) (interface{}, error) {
@@ -260,18 +364,57 @@ func (self *ethApi) GetTransactionCount(req *shared.Request) (interface{}, error
 		return nil, shared.NewDecodeParamError(err.Error())
 	}
 
+	// The pending count includes all pending transactions of the pool, not
+	// only those included in the pending block
+	if args.BlockNumber == -2 {
+		return fmt.Sprintf("%#x", self.xeth.PendingTxCount(args.Address)), nil
+	}
 	count := self.xeth.AtStateNum(args.BlockNumber).TxCountAt(args.Address)
 	return fmt.Sprintf("%#x", count), nil
 }
 
+// ReserveNonce reserves the next unused nonce of an account, so that services
+// sharing the account can't pick the same one. Only the nonces of accounts
+// managed by the node can be reserved, others could be blocked by anyone.
+func (self *ethApi) ReserveNonce(req *shared.Request) (interface{}, error) {
+	args := new(ReserveNonceArgs)
+	if err := self.codec.Decode(req.Params, &args); err != nil {
+		return nil, shared.NewDecodeParamError(err.Error())
+	}
+
+	addr := common.HexToAddress(args.Address)
+	if !self.expanse.AccountManager().HasAccount(addr) {
+		return nil, fmt.Errorf("account %x is not managed by this node", addr)
+	}
+	nonce, err := self.expanse.TxPool().ReserveNonce(addr)
+	if err != nil {
+		return nil, err
+	}
+	return hexutil.Uint64(nonce), nil
+}
+
+// ReleaseNonce hands a reserved nonce which won't be used back to the pool.
+func (self *ethApi) ReleaseNonce(req *shared.Request) (interface{}, error) {
+	args := new(ReleaseNonceArgs)
+	if err := self.codec.Decode(req.Params, &args); err != nil {
+		return nil, shared.NewDecodeParamError(err.Error())
+	}
+
+	addr := common.HexToAddress(args.Address)
+	if !self.expanse.AccountManager().HasAccount(addr) {
+		return nil, fmt.Errorf("account %x is not managed by this node", addr)
+	}
+	return self.expanse.TxPool().ReleaseNonce(addr, args.Nonce), nil
+}
+
 func (self *ethApi) GetBlockTransactionCountByHash(req *shared.Request) (interface{}, error) {
 	args := new(HashArgs)
 	if err := self.codec.Decode(req.Params, &args); err != nil {
 		return nil, shared.NewDecodeParamError(err.Error())
 	}
-	block := self.xeth.EthBlockByHash(args.Hash)
+	block, err := self.blockByHash(args.Hash)
 	if block == nil {
-		return nil, nil
+		return nil, err
 	}
 	return fmt.Sprintf("%#x", len(block.Transactions())), nil
 }
@@ -282,9 +425,9 @@ func (self *ethApi) GetBlockTransactionCountByNumber(req *shared.Request) (inter
 		return nil, shared.NewDecodeParamError(err.Error())
 	}
 
-	block := self.xeth.EthBlockByNumber(args.BlockNumber)
+	block, err := self.blockByNumber(args.BlockNumber)
 	if block == nil {
-		return nil, nil
+		return nil, err
 	}
 	return fmt.Sprintf("%#x", len(block.Transactions())), nil
 }
@@ -295,9 +438,9 @@ func (self *ethApi) GetUncleCountByBlockHash(req *shared.Request) (interface{},
 		return nil, shared.NewDecodeParamError(err.Error())
 	}
 
-	block := self.xeth.EthBlockByHash(args.Hash)
+	block, err := self.blockByHash(args.Hash)
 	if block == nil {
-		return nil, nil
+		return nil, err
 	}
 	return fmt.Sprintf("%#x", len(block.Uncles())), nil
 }
@@ -308,9 +451,9 @@ func (self *ethApi) GetUncleCountByBlockNumber(req *shared.Request) (interface{}
 		return nil, shared.NewDecodeParamError(err.Error())
 	}
 
-	block := self.xeth.EthBlockByNumber(args.BlockNumber)
+	block, err := self.blockByNumber(args.BlockNumber)
 	if block == nil {
-		return nil, nil
+		return nil, err
 	}
 	return fmt.Sprintf("%#x", len(block.Uncles())), nil
 }
@@ -320,8 +463,11 @@ func (self *ethApi) GetData(req *shared.Request) (interface{}, error) {
 	if err := self.codec.Decode(req.Params, &args); err != nil {
 		return nil, shared.NewDecodeParamError(err.Error())
 	}
+	if args.BlockNumber == -2 {
+		return hexutil.Bytes(self.xeth.PendingCode(args.Address)), nil
+	}
 	v := self.xeth.AtStateNum(args.BlockNumber).CodeAtBytes(args.Address)
-	return newHexData(v), nil
+	return hexutil.Bytes(v), nil
 }
 
 func (self *ethApi) Sign(req *shared.Request) (interface{}, error) {
@@ -342,13 +488,27 @@ func (self *ethApi) SubmitTransaction(req *shared.Request) (interface{}, error)
 		return nil, shared.NewDecodeParamError(err.Error())
 	}
 
-	v, err := self.xeth.PushTx(args.Data)
+	v, err := self.xeth.PushTxFromRemote(shared.RemoteFromContext(req.Context()), args.Data)
 	if err != nil {
 		return nil, err
 	}
 	return v, nil
 }
 
+// ValidateRawTransaction runs the checks of the transaction pool on a raw
+// transaction without sending it, reporting why it would be rejected.
+func (self *ethApi) ValidateRawTransaction(req *shared.Request) (interface{}, error) {
+	args := new(NewDataArgs)
+	if err := self.codec.Decode(req.Params, &args); err != nil {
+		return nil, shared.NewDecodeParamError(err.Error())
+	}
+	tx, err := self.xeth.DecodeTx(args.Data)
+	if err != nil {
+		return nil, shared.NewValidationError("data", err.Error())
+	}
+	return NewValidateTxRes(tx, self.xeth.ValidateTx(tx)), nil
+}
+
 // JsonTransaction is returned as response by the JSON RPC. It contains the
 // signed RLP encoded transaction as Raw and the signed transaction object as Tx.
 type JsonTransaction struct {
@@ -407,7 +567,7 @@ func (self *ethApi) SendTransaction(req *shared.Request) (interface{}, error) {
 	if args.GasPrice != nil {
 		price = args.GasPrice.String()
 	}
-	v, err := self.xeth.Transact(args.From, args.To, nonce, args.Value.String(), gas, price, args.Data)
+	v, err := self.xeth.TransactFromRemote(shared.RemoteFromContext(req.Context()), args.From, args.To, nonce, args.Value.String(), gas, price, args.Data)
 	if err != nil {
 		return nil, err
 	}
@@ -421,8 +581,15 @@ func (self *ethApi) GetNatSpec(req *shared.Request) (interface{}, error) {
 	}
 
 	var jsontx = fmt.Sprintf(`{"params":[{"to":"%s","data": "%s"}]}`, args.To, args.Data)
-	notice := natspec.GetNotice(self.xeth, jsontx, self.expanse.HTTPClient())
-
+	notice, err := natspec.FetchNotice(self.xeth, jsontx, self.expanse.HTTPClient())
+	if err != nil {
+		// Contracts without NatSpec get the fallback notice, broken or
+		// misbehaving expressions are reported as such.
+		if _, ok := err.(*natspec.EvalError); ok {
+			return nil, shared.NewEvaluationError(err.Error())
+		}
+		return nil, err
+	}
 	return notice, nil
 }
 
@@ -434,9 +601,9 @@ func (self *ethApi) EstimateGas(req *shared.Request) (interface{}, error) {
 
 	// TODO unwrap the parent method's ToHex call
 	if len(gas) == 0 {
-		return newHexNum(0), nil
+		return hexutil.Uint64(0), nil
 	} else {
-		return newHexNum(common.String2Big(gas)), err
+		return (*hexutil.Big)(common.String2Big(gas)), err
 	}
 }
 
@@ -448,9 +615,9 @@ func (self *ethApi) Call(req *shared.Request) (interface{}, error) {
 
 	// TODO unwrap the parent method's ToHex call
 	if v == "0x0" {
-		return newHexData([]byte{}), nil
+		return hexutil.Bytes{}, nil
 	} else {
-		return newHexData(common.FromHex(v)), nil
+		return hexutil.Bytes(common.FromHex(v)), nil
 	}
 }
 
@@ -464,7 +631,7 @@ func (self *ethApi) doCall(params json.RawMessage) (string, string, error) {
 		return "", "", err
 	}
 
-	return self.xeth.AtStateNum(args.BlockNumber).Call(args.From, args.To, args.Value.String(), args.Gas.String(), args.GasPrice.String(), args.Data)
+	return self.xeth.CallAt(args.BlockNumber, args.From, args.To, args.Value.String(), args.Gas.String(), args.GasPrice.String(), args.Data)
 }
 
 func (self *ethApi) GetBlockByHash(req *shared.Request) (interface{}, error) {
@@ -472,11 +639,15 @@ func (self *ethApi) GetBlockByHash(req *shared.Request) (interface{}, error) {
 	if err := self.codec.Decode(req.Params, &args); err != nil {
 		return nil, shared.NewDecodeParamError(err.Error())
 	}
-	block := self.xeth.EthBlockByHash(args.BlockHash)
+	block, err := self.blockByHash(args.BlockHash)
 	if block == nil {
-		return nil, nil
+		return nil, err
+	}
+	td, err := self.blockTd(block)
+	if err != nil {
+		return nil, err
 	}
-	return NewBlockRes(block, self.xeth.Td(block.Hash()), args.IncludeTxs), nil
+	return NewBlockRes(block, td, args.IncludeTxs), nil
 }
 
 func (self *ethApi) GetBlockByNumber(req *shared.Request) (interface{}, error) {
@@ -485,11 +656,59 @@ func (self *ethApi) GetBlockByNumber(req *shared.Request) (interface{}, error) {
 		return nil, shared.NewDecodeParamError(err.Error())
 	}
 
-	block := self.xeth.EthBlockByNumber(args.BlockNumber)
+	block, err := self.blockByNumber(args.BlockNumber)
 	if block == nil {
-		return nil, nil
+		return nil, err
+	}
+	td, err := self.blockTd(block)
+	if err != nil {
+		return nil, err
+	}
+	return NewBlockRes(block, td, args.IncludeTxs), nil
+}
+
+// indexError returns the error for a transaction or uncle index beyond the
+// end of its block, which is only reported in strict mode.
+func indexError() error {
+	if StrictArgs {
+		return shared.NewValidationError("index", "out of range")
+	}
+	return nil
+}
+
+// blockByHash returns the block with the given hash, nil if it is unknown. A
+// block whose header is stored without its body means the database is
+// inconsistent, which is reported as an internal error.
+func (self *ethApi) blockByHash(hash string) (*types.Block, error) {
+	block := self.xeth.EthBlockByHash(hash)
+	if block == nil && self.xeth.EthHeaderByHash(hash) != nil {
+		return nil, shared.NewInternalError(fmt.Sprintf("body of block %s not found", hash))
+	}
+	return block, nil
+}
+
+// blockByNumber returns the block with the given number, nil if the chain is
+// not that long yet. A canonical block missing below the head is reported as
+// an internal error.
+func (self *ethApi) blockByNumber(number int64) (*types.Block, error) {
+	block := self.xeth.EthBlockByNumber(number)
+	if block == nil && number >= 0 && uint64(number) <= self.xeth.CurrentBlock().NumberU64() {
+		return nil, shared.NewInternalError(fmt.Sprintf("canonical block %d not found", number))
+	}
+	return block, nil
+}
+
+// blockTd returns the total difficulty of block, deriving it from the parent
+// for blocks which aren't stored yet, such as the pending one. A known block
+// without a total difficulty is reported as an internal error.
+func (self *ethApi) blockTd(block *types.Block) (*big.Int, error) {
+	if td := self.xeth.Td(block.Hash()); td != nil {
+		return td, nil
+	}
+	if td := self.xeth.Td(block.ParentHash()); td != nil {
+		return new(big.Int).Add(td, block.Difficulty()), nil
 	}
-	return NewBlockRes(block, self.xeth.Td(block.Hash()), args.IncludeTxs), nil
+	return nil, shared.NewInternalError(fmt.Sprintf("total difficulty of block %x not found", block.Hash()))
 }
 
 func (self *ethApi) GetTransactionByHash(req *shared.Request) (interface{}, error) {
@@ -499,13 +718,18 @@ func (self *ethApi) GetTransactionByHash(req *shared.Request) (interface{}, erro
 	}
 
 	tx, bhash, bnum, txi := self.xeth.EthTransactionByHash(args.Hash)
+	// A mined transaction whose block is missing means the database is
+	// inconsistent, which mustn't be reported as the transaction being unknown.
+	if tx != nil && bhash != (common.Hash{}) && self.xeth.EthHeaderByHash(bhash.Hex()) == nil {
+		return nil, shared.NewInternalError(fmt.Sprintf("block %x of transaction %s not found", bhash, args.Hash))
+	}
 	if tx != nil {
 		v := NewTransactionRes(tx)
 		// if the blockhash is 0, assume this is a pending transaction
 		if bytes.Compare(bhash.Bytes(), bytes.Repeat([]byte{0}, 32)) != 0 {
-			v.BlockHash = newHexData(bhash)
-			v.BlockNumber = newHexNum(bnum)
-			v.TxIndex = newHexNum(txi)
+			v.BlockHash = newBytes(bhash.Bytes())
+			v.BlockNumber = (*hexutil.Big)(new(big.Int).SetUint64(bnum))
+			v.TxIndex = newUint64(txi)
 		}
 		return v, nil
 	}
@@ -518,16 +742,15 @@ func (self *ethApi) GetTransactionByBlockHashAndIndex(req *shared.Request) (inte
 		return nil, shared.NewDecodeParamError(err.Error())
 	}
 
-	raw := self.xeth.EthBlockByHash(args.Hash)
+	raw, err := self.blockByHash(args.Hash)
 	if raw == nil {
-		return nil, nil
+		return nil, err
 	}
 	block := NewBlockRes(raw, self.xeth.Td(raw.Hash()), true)
 	if args.Index >= int64(len(block.Transactions)) || args.Index < 0 {
-		return nil, nil
-	} else {
-		return block.Transactions[args.Index], nil
+		return nil, indexError()
 	}
+	return block.Transactions[args.Index], nil
 }
 
 func (self *ethApi) GetTransactionByBlockNumberAndIndex(req *shared.Request) (interface{}, error) {
@@ -536,14 +759,13 @@ func (self *ethApi) GetTransactionByBlockNumberAndIndex(req *shared.Request) (in
 		return nil, shared.NewDecodeParamError(err.Error())
 	}
 
-	raw := self.xeth.EthBlockByNumber(args.BlockNumber)
+	raw, err := self.blockByNumber(args.BlockNumber)
 	if raw == nil {
-		return nil, nil
+		return nil, err
 	}
 	block := NewBlockRes(raw, self.xeth.Td(raw.Hash()), true)
 	if args.Index >= int64(len(block.Transactions)) || args.Index < 0 {
-		// return NewValidationError("Index", "does not exist")
-		return nil, nil
+		return nil, indexError()
 	}
 	return block.Transactions[args.Index], nil
 }
@@ -554,14 +776,13 @@ func (self *ethApi) GetUncleByBlockHashAndIndex(req *shared.Request) (interface{
 		return nil, shared.NewDecodeParamError(err.Error())
 	}
 
-	raw := self.xeth.EthBlockByHash(args.Hash)
+	raw, err := self.blockByHash(args.Hash)
 	if raw == nil {
-		return nil, nil
+		return nil, err
 	}
 	block := NewBlockRes(raw, self.xeth.Td(raw.Hash()), false)
 	if args.Index >= int64(len(block.Uncles)) || args.Index < 0 {
-		// return NewValidationError("Index", "does not exist")
-		return nil, nil
+		return nil, indexError()
 	}
 	return block.Uncles[args.Index], nil
 }
@@ -572,16 +793,15 @@ func (self *ethApi) GetUncleByBlockNumberAndIndex(req *shared.Request) (interfac
 		return nil, shared.NewDecodeParamError(err.Error())
 	}
 
-	raw := self.xeth.EthBlockByNumber(args.BlockNumber)
+	raw, err := self.blockByNumber(args.BlockNumber)
 	if raw == nil {
-		return nil, nil
+		return nil, err
 	}
 	block := NewBlockRes(raw, self.xeth.Td(raw.Hash()), true)
 	if args.Index >= int64(len(block.Uncles)) || args.Index < 0 {
-		return nil, nil
-	} else {
-		return block.Uncles[args.Index], nil
+		return nil, indexError()
 	}
+	return block.Uncles[args.Index], nil
 }
 
 func (self *ethApi) GetCompilers(req *shared.Request) (interface{}, error) {
@@ -618,15 +838,32 @@ func (self *ethApi) NewFilter(req *shared.Request) (interface{}, error) {
 	}
 
 	id := self.xeth.NewLogFilter(args.Earliest, args.Latest, args.Skip, args.Max, args.Address, args.Topics)
-	return newHexNum(big.NewInt(int64(id)).Bytes()), nil
+	return hexutil.Uint64(id), nil
 }
 
 func (self *ethApi) NewBlockFilter(req *shared.Request) (interface{}, error) {
-	return newHexNum(self.xeth.NewBlockFilter()), nil
+	return hexutil.Uint64(self.xeth.NewBlockFilter()), nil
 }
 
 func (self *ethApi) NewPendingTransactionFilter(req *shared.Request) (interface{}, error) {
-	return newHexNum(self.xeth.NewTransactionFilter()), nil
+	return hexutil.Uint64(self.xeth.NewTransactionFilter()), nil
+}
+
+// NewConfirmationFilter installs a filter reporting when a transaction reaches
+// the requested number of confirmations and when its block is later removed
+// from the canonical chain.
+func (self *ethApi) NewConfirmationFilter(req *shared.Request) (interface{}, error) {
+	args := new(ConfirmationFilterArgs)
+	if err := self.codec.Decode(req.Params, &args); err != nil {
+		return nil, shared.NewDecodeParamError(err.Error())
+	}
+	return hexutil.Uint64(self.xeth.NewConfirmationFilter(args.Hash, args.Confirmations)), nil
+}
+
+// NewDroppedTransactionFilter installs a filter reporting transactions removed
+// from the pool without being included in the chain, along with the reason.
+func (self *ethApi) NewDroppedTransactionFilter(req *shared.Request) (interface{}, error) {
+	return hexutil.Uint64(self.xeth.NewDroppedTxFilter()), nil
 }
 
 func (self *ethApi) UninstallFilter(req *shared.Request) (interface{}, error) {
@@ -637,11 +874,93 @@ func (self *ethApi) UninstallFilter(req *shared.Request) (interface{}, error) {
 	return self.xeth.UninstallFilter(args.Id), nil
 }
 
+// Subscribe creates a subscription pushing new chain heads, the hashes of new
+// pending transactions, matching logs or the start and end of synchronisations
+// to the client as they happen. It is
+// only available over connections able to carry notifications, like IPC and
+// WebSocket. Events are dropped if the client doesn't keep up with them.
+func (self *ethApi) Subscribe(req *shared.Request) (interface{}, error) {
+	notifier, ok := shared.NotifierFromContext(req.Context())
+	if !ok {
+		return nil, shared.NewNotAvailableError(req.Method, "notifications are not supported over this transport")
+	}
+	args := new(SubscribeArgs)
+	if err := self.codec.Decode(req.Params, &args); err != nil {
+		return nil, shared.NewDecodeParamError(err.Error())
+	}
+
+	events := make(chan interface{}, SubscriptionBuffer)
+	push := func(result interface{}) {
+		select {
+		case events <- result:
+		default:
+		}
+	}
+	var subscribe func() (unsubscribe func())
+	switch args.Kind {
+	case "newHeads":
+		subscribe = func() func() {
+			return self.xeth.SubscribeBlocks(func(block *types.Block) { push(NewUncleRes(block.Header())) })
+		}
+	case "newPendingTransactions":
+		subscribe = func() func() {
+			return self.xeth.SubscribePendingTransactions(func(tx *types.Transaction) { push(tx.Hash().Hex()) })
+		}
+	case "logs":
+		subscribe = func() func() {
+			return self.xeth.SubscribeLogs(args.Address, args.Topics, func(logs vm.Logs) {
+				for _, log := range logs {
+					push(NewLogRes(log))
+				}
+			})
+		}
+	case "syncing":
+		subscribe = func() func() {
+			return self.xeth.SubscribeSyncing(func(ev interface{}) { push(NewSyncEventRes(ev)) })
+		}
+	}
+	// Notifications use the namespace the client subscribed with
+	namespace := req.Method[:strings.IndexByte(req.Method, '_')]
+	return notifier.Subscribe(namespace, func(sub *shared.Subscription) {
+		unsubscribe := subscribe()
+		defer unsubscribe()
+
+		for {
+			select {
+			case result := <-events:
+				if err := sub.Notify(result); err != nil {
+					return
+				}
+			case <-sub.Quit():
+				return
+			}
+		}
+	})
+}
+
+// Unsubscribe cancels a subscription created on the same connection.
+func (self *ethApi) Unsubscribe(req *shared.Request) (interface{}, error) {
+	notifier, ok := shared.NotifierFromContext(req.Context())
+	if !ok {
+		return nil, shared.NewNotAvailableError(req.Method, "notifications are not supported over this transport")
+	}
+	args := new(UnsubscribeArgs)
+	if err := self.codec.Decode(req.Params, &args); err != nil {
+		return nil, shared.NewDecodeParamError(err.Error())
+	}
+	return notifier.Unsubscribe(args.Id), nil
+}
+
 func (self *ethApi) GetFilterChanges(req *shared.Request) (interface{}, error) {
 	args := new(FilterIdArgs)
 	if err := self.codec.Decode(req.Params, &args); err != nil {
 		return nil, shared.NewDecodeParamError(err.Error())
 	}
+	// Report lost changes before returning the retained ones, so clients
+	// can resynchronise before continuing to poll.
+	if discarded := self.xeth.FilterChangesDiscarded(args.Id); discarded > 0 {
+		return nil, shared.NewFilterOverflowError(discarded)
+	}
 
 	switch self.xeth.GetFilterType(args.Id) {
 	case xeth.BlockFilterTy:
@@ -650,6 +969,10 @@ func (self *ethApi) GetFilterChanges(req *shared.Request) (interface{}, error) {
 		return NewHashesRes(self.xeth.TransactionFilterChanged(args.Id)), nil
 	case xeth.LogFilterTy:
 		return NewLogsRes(self.xeth.LogFilterChanged(args.Id)), nil
+	case xeth.ConfirmationFilterTy:
+		return NewConfirmationsRes(self.xeth.ConfirmationFilterChanged(args.Id)), nil
+	case xeth.DroppedTxFilterTy:
+		return NewDroppedTransactionsRes(self.xeth.DroppedTxFilterChanged(args.Id)), nil
 	default:
 		return []string{}, nil // reply empty string slice
 	}
@@ -661,7 +984,11 @@ func (self *ethApi) GetFilterLogs(req *shared.Request) (interface{}, error) {
 		return nil, shared.NewDecodeParamError(err.Error())
 	}
 
-	return NewLogsRes(self.xeth.Logs(args.Id)), nil
+	logs, err := self.xeth.Logs(req.Context(), args.Id)
+	if err != nil {
+		return nil, err
+	}
+	return NewLogsRes(logs), nil
 }
 
 func (self *ethApi) GetLogs(req *shared.Request) (interface{}, error) {
@@ -669,11 +996,31 @@ func (self *ethApi) GetLogs(req *shared.Request) (interface{}, error) {
 	if err := self.codec.Decode(req.Params, &args); err != nil {
 		return nil, shared.NewDecodeParamError(err.Error())
 	}
-	return NewLogsRes(self.xeth.AllLogs(args.Earliest, args.Latest, args.Skip, args.Max, args.Address, args.Topics)), nil
+	logs, err := self.xeth.AllLogs(req.Context(), args.Earliest, args.Latest, args.Skip, args.Max, args.Address, args.Topics)
+	if err != nil {
+		return nil, err
+	}
+	return NewLogsRes(logs), nil
 }
 
 func (self *ethApi) GetWork(req *shared.Request) (interface{}, error) {
+	// Plain getWork clients may not send any parameters at all
+	args := new(GetWorkArgs)
+	if len(req.Params) > 0 {
+		if err := self.codec.Decode(req.Params, &args); err != nil {
+			return nil, shared.NewDecodeParamError(err.Error())
+		}
+	}
 	self.xeth.SetMining(true, 0)
+
+	// Workers identifying themselves get their own extraNonce, if enabled
+	if len(args.Id) > 0 && miner.ExtraNonceSize > 0 {
+		ret, err := self.xeth.RemoteMining().GetWorkFor(common.HexToHash(args.Id))
+		if err == miner.ErrNoWork {
+			return nil, shared.NewNotReadyError("mining work")
+		}
+		return ret, err
+	}
 	ret, err := self.xeth.RemoteMining().GetWork()
 	if err != nil {
 		return nil, shared.NewNotReadyError("mining work")
@@ -710,8 +1057,8 @@ func (self *ethApi) Resend(req *shared.Request) (interface{}, error) {
 	pending := self.expanse.TxPool().GetTransactions()
 	for _, p := range pending {
 		if pFrom, err := p.FromFrontier(); err == nil && pFrom == from && p.SigHash() == args.Tx.tx.SigHash() {
-			self.expanse.TxPool().RemoveTx(common.HexToHash(args.Tx.Hash))
-			return self.xeth.Transact(args.Tx.From, args.Tx.To, args.Tx.Nonce, args.Tx.Value, args.GasLimit, args.GasPrice, args.Tx.Data)
+			self.expanse.TxPool().DropTransactions(types.Transactions{p}, core.TxReplaced)
+			return self.xeth.TransactFromRemote(shared.RemoteFromContext(req.Context()), args.Tx.From, args.Tx.To, args.Tx.Nonce, args.Tx.Value, args.GasLimit, args.GasPrice, args.Tx.Data)
 		}
 	}
 
@@ -753,17 +1100,156 @@ func (self *ethApi) GetTransactionReceipt(req *shared.Request) (interface{}, err
 	txhash := common.BytesToHash(common.FromHex(args.Hash))
 	tx, bhash, bnum, txi := self.xeth.EthTransactionByHash(args.Hash)
 	rec := self.xeth.GetTxReceipt(txhash)
-	// We could have an error of "not found". Should disambiguate
-	// if err != nil {
-	// 	return err, nil
-	// }
+	// A mined transaction without a receipt means the database is inconsistent,
+	// which mustn't be reported as the transaction simply being unknown.
+	if tx != nil && rec == nil && bhash != (common.Hash{}) {
+		return nil, shared.NewInternalError(fmt.Sprintf("receipt of mined transaction %x not found", txhash))
+	}
 	if rec != nil && tx != nil {
 		v := NewReceiptRes(rec)
-		v.BlockHash = newHexData(bhash)
-		v.BlockNumber = newHexNum(bnum)
-		v.TransactionIndex = newHexNum(txi)
+		v.BlockHash = newBytes(bhash.Bytes())
+		v.BlockNumber = (*hexutil.Big)(new(big.Int).SetUint64(bnum))
+		v.TransactionIndex = newUint64(txi)
 		return v, nil
 	}
 
 	return nil, nil
 }
+
+// GetGasStats returns the gas usage, gas limit, fees and transaction counts of
+// each block in the requested range, together with their totals.
+func (self *ethApi) GetGasStats(req *shared.Request) (interface{}, error) {
+	args := new(GasStatsArgs)
+	if err := self.codec.Decode(req.Params, &args); err != nil {
+		return nil, shared.NewDecodeParamError(err.Error())
+	}
+
+	from := self.xeth.EthBlockByNumber(args.FromBlock)
+	if from == nil {
+		return nil, fmt.Errorf("block #%d not found", args.FromBlock)
+	}
+	to := self.xeth.EthBlockByNumber(args.ToBlock)
+	if to == nil {
+		return nil, fmt.Errorf("block #%d not found", args.ToBlock)
+	}
+	if from.NumberU64() > to.NumberU64() {
+		return nil, shared.NewValidationError("fromBlock", "is after toBlock")
+	}
+	if to.NumberU64()-from.NumberU64() >= MaxGasStatsBlocks {
+		return nil, shared.NewValidationError("toBlock", fmt.Sprintf("range exceeds %d blocks", MaxGasStatsBlocks))
+	}
+
+	res := newGasStatsRes(from.Number(), to.Number())
+	for num := from.NumberU64(); num <= to.NumberU64(); num++ {
+		block := self.expanse.BlockChain().GetBlockByNumber(num)
+		if block == nil {
+			return nil, fmt.Errorf("block #%d not found", num)
+		}
+		stats, err := blockGasStats(block, self.xeth.GetBlockReceipts(block.Hash()))
+		if err != nil {
+			return nil, err
+		}
+		res.add(stats)
+	}
+	return res, nil
+}
+
+// GetUncleStats returns the uncle rate, the distribution of blocks and uncles
+// over miners and the estimated block propagation delays of the requested range.
+func (self *ethApi) GetUncleStats(req *shared.Request) (interface{}, error) {
+	args := new(UncleStatsArgs)
+	if err := self.codec.Decode(req.Params, &args); err != nil {
+		return nil, shared.NewDecodeParamError(err.Error())
+	}
+
+	from := self.xeth.EthBlockByNumber(args.FromBlock)
+	if from == nil {
+		return nil, fmt.Errorf("block #%d not found", args.FromBlock)
+	}
+	to := self.xeth.EthBlockByNumber(args.ToBlock)
+	if to == nil {
+		return nil, fmt.Errorf("block #%d not found", args.ToBlock)
+	}
+	if from.NumberU64() > to.NumberU64() {
+		return nil, shared.NewValidationError("fromBlock", "is after toBlock")
+	}
+	if to.NumberU64()-from.NumberU64() >= MaxUncleStatsBlocks {
+		return nil, shared.NewValidationError("toBlock", fmt.Sprintf("range exceeds %d blocks", MaxUncleStatsBlocks))
+	}
+
+	stats, err := self.expanse.BlockChain().UncleStats(from.NumberU64(), to.NumberU64())
+	if err != nil {
+		return nil, err
+	}
+	return newUncleStatsRes(stats), nil
+}
+
+// GetNetworkHashrate estimates the network hashrate from the difficulties and
+// timestamps of a window of canonical blocks ending at the requested block.
+func (self *ethApi) GetNetworkHashrate(req *shared.Request) (interface{}, error) {
+	args := &NetworkHashrateArgs{BlockNumber: -1}
+	if len(req.Params) > 0 {
+		if err := self.codec.Decode(req.Params, &args); err != nil {
+			return nil, shared.NewDecodeParamError(err.Error())
+		}
+	}
+	window := args.Blocks
+	if window == 0 {
+		window = DefaultHashrateBlocks
+	}
+	if window > MaxHashrateBlocks {
+		return nil, shared.NewValidationError("blocks", fmt.Sprintf("window exceeds %d blocks", MaxHashrateBlocks))
+	}
+
+	block := self.xeth.EthBlockByNumber(args.BlockNumber)
+	if block == nil {
+		return nil, fmt.Errorf("block #%d not found", args.BlockNumber)
+	}
+	estimate, err := self.expanse.BlockChain().EstimateHashrate(block.NumberU64(), window)
+	if err != nil {
+		return nil, err
+	}
+	return newHashrateRes(estimate), nil
+}
+
+// GetInternalTransactions returns the recorded value transfers of internal calls
+// from or to an address within the requested range of canonical blocks.
+func (self *ethApi) GetInternalTransactions(req *shared.Request) (interface{}, error) {
+	args := new(InternalTxsArgs)
+	if err := self.codec.Decode(req.Params, &args); err != nil {
+		return nil, shared.NewDecodeParamError(err.Error())
+	}
+	if !core.RecordInternalTxs {
+		return nil, fmt.Errorf("internal transactions are not recorded (start with --internaltxs)")
+	}
+
+	from := self.xeth.EthBlockByNumber(args.FromBlock)
+	if from == nil {
+		return nil, fmt.Errorf("block #%d not found", args.FromBlock)
+	}
+	to := self.xeth.EthBlockByNumber(args.ToBlock)
+	if to == nil {
+		return nil, fmt.Errorf("block #%d not found", args.ToBlock)
+	}
+	if from.NumberU64() > to.NumberU64() {
+		return nil, shared.NewValidationError("fromBlock", "is after toBlock")
+	}
+	if to.NumberU64()-from.NumberU64() >= MaxInternalTxsBlocks {
+		return nil, shared.NewValidationError("toBlock", fmt.Sprintf("range exceeds %d blocks", MaxInternalTxsBlocks))
+	}
+
+	var (
+		addr = common.HexToAddress(args.Address)
+		db   = self.expanse.ChainDb()
+		res  = []*InternalTxRes{}
+	)
+	for num := from.NumberU64(); num <= to.NumberU64(); num++ {
+		hash := core.GetCanonicalHash(db, num)
+		for _, tx := range core.GetInternalTxs(db, hash) {
+			if tx.From == addr || tx.To == addr {
+				res = append(res, NewInternalTxRes(tx, num, hash))
+			}
+		}
+	}
+	return res, nil
+}
@@ -24,8 +24,12 @@ import (
 	"strings"
 
 	"github.com/expanse-project/go-expanse/common"
+	"github.com/expanse-project/go-expanse/common/hexutil"
+	"github.com/expanse-project/go-expanse/core"
 	"github.com/expanse-project/go-expanse/core/types"
 	"github.com/expanse-project/go-expanse/core/vm"
+	"github.com/expanse-project/go-expanse/exp/downloader"
+	"github.com/expanse-project/go-expanse/exp/filters"
 	"github.com/expanse-project/go-expanse/rpc/shared"
 )
 
@@ -66,6 +70,177 @@ func (args *GetBalanceArgs) UnmarshalJSON(b []byte) (err error) {
 	return nil
 }
 
+type GetConfirmedBalanceArgs struct {
+	Address       string
+	Confirmations uint64
+}
+
+func (args *GetConfirmedBalanceArgs) UnmarshalJSON(b []byte) (err error) {
+	var obj []interface{}
+	if err := json.Unmarshal(b, &obj); err != nil {
+		return shared.NewDecodeParamError(err.Error())
+	}
+
+	if len(obj) < 2 {
+		return shared.NewInsufficientParamsError(len(obj), 2)
+	}
+
+	addstr, ok := obj[0].(string)
+	if !ok {
+		return shared.NewInvalidTypeError("address", "not a string")
+	}
+	args.Address = addstr
+
+	num, err := numString(obj[1])
+	if err != nil {
+		return err
+	}
+	if num.Sign() < 0 || num.BitLen() > 64 {
+		return shared.NewValidationError("confirmations", "must be a non-negative integer")
+	}
+	args.Confirmations = num.Uint64()
+
+	return nil
+}
+
+type GasStatsArgs struct {
+	FromBlock int64
+	ToBlock   int64
+}
+
+func (args *GasStatsArgs) UnmarshalJSON(b []byte) (err error) {
+	var obj []interface{}
+	if err := json.Unmarshal(b, &obj); err != nil {
+		return shared.NewDecodeParamError(err.Error())
+	}
+
+	if len(obj) < 1 {
+		return shared.NewInsufficientParamsError(len(obj), 1)
+	}
+
+	if err := blockHeight(obj[0], &args.FromBlock); err != nil {
+		return err
+	}
+
+	args.ToBlock = -1
+	if len(obj) > 1 && obj[1] != nil {
+		if err := blockHeight(obj[1], &args.ToBlock); err != nil {
+			return err
+		}
+	}
+	if args.FromBlock == -2 || args.ToBlock == -2 {
+		return shared.NewValidationError("blockNumber", "pending block has no receipts")
+	}
+
+	return nil
+}
+
+type UncleStatsArgs struct {
+	FromBlock int64
+	ToBlock   int64
+}
+
+func (args *UncleStatsArgs) UnmarshalJSON(b []byte) (err error) {
+	var obj []interface{}
+	if err := json.Unmarshal(b, &obj); err != nil {
+		return shared.NewDecodeParamError(err.Error())
+	}
+
+	if len(obj) < 1 {
+		return shared.NewInsufficientParamsError(len(obj), 1)
+	}
+
+	if err := blockHeight(obj[0], &args.FromBlock); err != nil {
+		return err
+	}
+
+	args.ToBlock = -1
+	if len(obj) > 1 && obj[1] != nil {
+		if err := blockHeight(obj[1], &args.ToBlock); err != nil {
+			return err
+		}
+	}
+	if args.FromBlock == -2 || args.ToBlock == -2 {
+		return shared.NewValidationError("blockNumber", "pending block has no uncles")
+	}
+
+	return nil
+}
+
+type NetworkHashrateArgs struct {
+	Blocks      uint64
+	BlockNumber int64
+}
+
+func (args *NetworkHashrateArgs) UnmarshalJSON(b []byte) (err error) {
+	var obj []interface{}
+	if err := json.Unmarshal(b, &obj); err != nil {
+		return shared.NewDecodeParamError(err.Error())
+	}
+
+	if len(obj) > 0 && obj[0] != nil {
+		num, err := numString(obj[0])
+		if err != nil {
+			return err
+		}
+		if num.Sign() < 0 || !num.IsUint64() {
+			return shared.NewValidationError("blocks", "out of range")
+		}
+		args.Blocks = num.Uint64()
+	}
+
+	args.BlockNumber = -1
+	if len(obj) > 1 && obj[1] != nil {
+		if err := blockHeight(obj[1], &args.BlockNumber); err != nil {
+			return err
+		}
+	}
+	if args.BlockNumber == -2 {
+		return shared.NewValidationError("blockNumber", "pending block has no timestamp yet")
+	}
+
+	return nil
+}
+
+type InternalTxsArgs struct {
+	Address   string
+	FromBlock int64
+	ToBlock   int64
+}
+
+func (args *InternalTxsArgs) UnmarshalJSON(b []byte) (err error) {
+	var obj []interface{}
+	if err := json.Unmarshal(b, &obj); err != nil {
+		return shared.NewDecodeParamError(err.Error())
+	}
+
+	if len(obj) < 2 {
+		return shared.NewInsufficientParamsError(len(obj), 2)
+	}
+
+	addstr, ok := obj[0].(string)
+	if !ok {
+		return shared.NewInvalidTypeError("address", "not a string")
+	}
+	args.Address = addstr
+
+	if err := blockHeight(obj[1], &args.FromBlock); err != nil {
+		return err
+	}
+
+	args.ToBlock = -1
+	if len(obj) > 2 && obj[2] != nil {
+		if err := blockHeight(obj[2], &args.ToBlock); err != nil {
+			return err
+		}
+	}
+	if args.FromBlock == -2 || args.ToBlock == -2 {
+		return shared.NewValidationError("blockNumber", "pending block has no internal transactions")
+	}
+
+	return nil
+}
+
 type GetStorageArgs struct {
 	Address     string
 	BlockNumber int64
@@ -169,6 +344,62 @@ func (args *GetTxCountArgs) UnmarshalJSON(b []byte) (err error) {
 	return nil
 }
 
+type ReserveNonceArgs struct {
+	Address string
+}
+
+func (args *ReserveNonceArgs) UnmarshalJSON(b []byte) (err error) {
+	var obj []interface{}
+	if err := json.Unmarshal(b, &obj); err != nil {
+		return shared.NewDecodeParamError(err.Error())
+	}
+
+	if len(obj) < 1 {
+		return shared.NewInsufficientParamsError(len(obj), 1)
+	}
+
+	addstr, ok := obj[0].(string)
+	if !ok {
+		return shared.NewInvalidTypeError("address", "not a string")
+	}
+	args.Address = addstr
+
+	return nil
+}
+
+type ReleaseNonceArgs struct {
+	Address string
+	Nonce   uint64
+}
+
+func (args *ReleaseNonceArgs) UnmarshalJSON(b []byte) (err error) {
+	var obj []interface{}
+	if err := json.Unmarshal(b, &obj); err != nil {
+		return shared.NewDecodeParamError(err.Error())
+	}
+
+	if len(obj) < 2 {
+		return shared.NewInsufficientParamsError(len(obj), 2)
+	}
+
+	addstr, ok := obj[0].(string)
+	if !ok {
+		return shared.NewInvalidTypeError("address", "not a string")
+	}
+	args.Address = addstr
+
+	nonce, err := numString(obj[1])
+	if err != nil {
+		return err
+	}
+	if nonce.Sign() < 0 || nonce.BitLen() > 64 {
+		return shared.NewValidationError("nonce", "out of range")
+	}
+	args.Nonce = nonce.Uint64()
+
+	return nil
+}
+
 type SubmitHashRateArgs struct {
 	Id   string
 	Rate uint64
@@ -195,7 +426,11 @@ func (args *SubmitHashRateArgs) UnmarshalJSON(b []byte) (err error) {
 		return shared.NewInvalidTypeError("rate", "not a string")
 	}
 
-	args.Rate = common.String2Big(arg1).Uint64()
+	rate, err := numString(arg1)
+	if err != nil {
+		return err
+	}
+	args.Rate = rate.Uint64()
 
 	return nil
 }
@@ -218,6 +453,9 @@ func (args *HashArgs) UnmarshalJSON(b []byte) (err error) {
 	if !ok {
 		return shared.NewInvalidTypeError("hash", "not a string")
 	}
+	if err := strictHash("hash", arg0); err != nil {
+		return err
+	}
 	args.Hash = arg0
 
 	return nil
@@ -563,14 +801,52 @@ func (args *HashIndexArgs) UnmarshalJSON(b []byte) (err error) {
 	if !ok {
 		return shared.NewInvalidTypeError("hash", "not a string")
 	}
+	if err := strictHash("hash", arg0); err != nil {
+		return err
+	}
 	args.Hash = arg0
 
 	arg1, ok := obj[1].(string)
 	if !ok {
 		return shared.NewInvalidTypeError("index", "not a string")
 	}
-	args.Index = common.Big(arg1).Int64()
+	if err := strictIndex(arg1); err != nil {
+		return err
+	}
+	index, err := numString(arg1)
+	if err != nil {
+		return err
+	}
+	args.Index = index.Int64()
+
+	return nil
+}
+
+// strictHash checks in strict mode that hash is a 0x prefixed, 32 byte hex
+// string, so malformed hashes aren't silently looked up as the zero hash.
+func strictHash(name, hash string) error {
+	if !StrictArgs {
+		return nil
+	}
+	var h common.Hash
+	if err := h.UnmarshalText([]byte(hash)); err != nil {
+		return shared.NewValidationError(name, err.Error())
+	}
+	return nil
+}
 
+// strictIndex checks in strict mode that index is a valid, non negative number.
+func strictIndex(index string) error {
+	if !StrictArgs {
+		return nil
+	}
+	n, ok := new(big.Int).SetString(strings.TrimPrefix(index, "0x"), 16)
+	if !strings.HasPrefix(index, "0x") {
+		n, ok = new(big.Int).SetString(index, 10)
+	}
+	if !ok || n.Sign() < 0 || n.BitLen() > 63 {
+		return shared.NewValidationError("index", "not a valid index")
+	}
 	return nil
 }
 
@@ -597,6 +873,9 @@ func (args *BlockNumIndexArgs) UnmarshalJSON(b []byte) (err error) {
 	if arg1, err = numString(obj[1]); err != nil {
 		return err
 	}
+	if StrictArgs && (arg1.Sign() < 0 || arg1.BitLen() > 63) {
+		return shared.NewValidationError("index", "out of range")
+	}
 	args.Index = arg1.Int64()
 
 	return nil
@@ -622,10 +901,11 @@ func (args *GetBlockByHashArgs) UnmarshalJSON(b []byte) (err error) {
 	if !ok {
 		return shared.NewInvalidTypeError("blockHash", "not a string")
 	}
+	if err := strictHash("blockHash", argstr); err != nil {
+		return err
+	}
 	args.BlockHash = argstr
 
-	args.IncludeTxs = obj[1].(bool)
-
 	if inclTx, ok := obj[1].(bool); ok {
 		args.IncludeTxs = inclTx
 		return nil
@@ -834,30 +1114,128 @@ func (args *FilterIdArgs) UnmarshalJSON(b []byte) (err error) {
 	return nil
 }
 
+type SubscribeArgs struct {
+	Kind    string
+	Address []string
+	Topics  [][]string
+}
+
+func (args *SubscribeArgs) UnmarshalJSON(b []byte) (err error) {
+	var obj []json.RawMessage
+	if err := json.Unmarshal(b, &obj); err != nil {
+		return shared.NewDecodeParamError(err.Error())
+	}
+
+	if len(obj) < 1 {
+		return shared.NewInsufficientParamsError(len(obj), 1)
+	}
+
+	if err := json.Unmarshal(obj[0], &args.Kind); err != nil {
+		return shared.NewInvalidTypeError("kind", "not a string")
+	}
+	switch args.Kind {
+	case "newHeads", "newPendingTransactions", "syncing":
+		return nil
+	case "logs":
+	default:
+		return shared.NewValidationError("kind", "must be newHeads, newPendingTransactions, logs or syncing")
+	}
+
+	// The criteria of a log subscription are those of a log filter
+	if len(obj) < 2 || string(obj[1]) == "null" {
+		return nil
+	}
+	filter := new(BlockFilterArgs)
+	if err := filter.UnmarshalJSON([]byte("[" + string(obj[1]) + "]")); err != nil {
+		return err
+	}
+	args.Address, args.Topics = filter.Address, filter.Topics
+
+	return nil
+}
+
+type UnsubscribeArgs struct {
+	Id string
+}
+
+func (args *UnsubscribeArgs) UnmarshalJSON(b []byte) (err error) {
+	var obj []interface{}
+	if err := json.Unmarshal(b, &obj); err != nil {
+		return shared.NewDecodeParamError(err.Error())
+	}
+
+	if len(obj) < 1 {
+		return shared.NewInsufficientParamsError(len(obj), 1)
+	}
+
+	id, ok := obj[0].(string)
+	if !ok {
+		return shared.NewInvalidTypeError("id", "not a string")
+	}
+	args.Id = id
+
+	return nil
+}
+
+type ConfirmationFilterArgs struct {
+	Hash          string
+	Confirmations uint64
+}
+
+func (args *ConfirmationFilterArgs) UnmarshalJSON(b []byte) (err error) {
+	var obj []interface{}
+	if err := json.Unmarshal(b, &obj); err != nil {
+		return shared.NewDecodeParamError(err.Error())
+	}
+
+	if len(obj) < 2 {
+		return shared.NewInsufficientParamsError(len(obj), 2)
+	}
+
+	arg0, ok := obj[0].(string)
+	if !ok {
+		return shared.NewInvalidTypeError("hash", "not a string")
+	}
+	args.Hash = arg0
+
+	num, err := numString(obj[1])
+	if err != nil {
+		return err
+	}
+	if num.Sign() < 0 || num.BitLen() > 64 {
+		return shared.NewValidationError("confirmations", "must be a non-negative integer")
+	}
+	args.Confirmations = num.Uint64()
+
+	return nil
+}
+
 type LogRes struct {
-	Address          *hexdata   `json:"address"`
-	Topics           []*hexdata `json:"topics"`
-	Data             *hexdata   `json:"data"`
-	BlockNumber      *hexnum    `json:"blockNumber"`
-	LogIndex         *hexnum    `json:"logIndex"`
-	BlockHash        *hexdata   `json:"blockHash"`
-	TransactionHash  *hexdata   `json:"transactionHash"`
-	TransactionIndex *hexnum    `json:"transactionIndex"`
+	Address          hexutil.Bytes   `json:"address"`
+	Topics           []hexutil.Bytes `json:"topics"`
+	Data             hexutil.Bytes   `json:"data"`
+	BlockNumber      hexutil.Uint64  `json:"blockNumber"`
+	LogIndex         hexutil.Uint64  `json:"logIndex"`
+	BlockHash        hexutil.Bytes   `json:"blockHash"`
+	TransactionHash  hexutil.Bytes   `json:"transactionHash"`
+	TransactionIndex hexutil.Uint64  `json:"transactionIndex"`
+	Removed          bool            `json:"removed"`
 }
 
 func NewLogRes(log *vm.Log) LogRes {
 	var l LogRes
-	l.Topics = make([]*hexdata, len(log.Topics))
+	l.Topics = make([]hexutil.Bytes, len(log.Topics))
 	for j, topic := range log.Topics {
-		l.Topics[j] = newHexData(topic)
+		l.Topics[j] = topic.Bytes()
 	}
-	l.Address = newHexData(log.Address)
-	l.Data = newHexData(log.Data)
-	l.BlockNumber = newHexNum(log.BlockNumber)
-	l.LogIndex = newHexNum(log.Index)
-	l.TransactionHash = newHexData(log.TxHash)
-	l.TransactionIndex = newHexNum(log.TxIndex)
-	l.BlockHash = newHexData(log.BlockHash)
+	l.Address = log.Address.Bytes()
+	l.Data = log.Data
+	l.BlockNumber = hexutil.Uint64(log.BlockNumber)
+	l.LogIndex = hexutil.Uint64(log.Index)
+	l.TransactionHash = log.TxHash.Bytes()
+	l.TransactionIndex = hexutil.Uint64(log.TxIndex)
+	l.BlockHash = log.BlockHash.Bytes()
+	l.Removed = log.Removed
 
 	return l
 }
@@ -872,6 +1250,46 @@ func NewLogsRes(logs vm.Logs) (ls []LogRes) {
 	return
 }
 
+// ValidateTxRes is the result of validating a raw transaction, with the reason
+// the transaction pool would reject it if it isn't valid.
+type ValidateTxRes struct {
+	Valid bool        `json:"valid"`
+	Hash  common.Hash `json:"hash"`
+	Error string      `json:"error,omitempty"`
+}
+
+func NewValidateTxRes(tx *types.Transaction, err error) *ValidateTxRes {
+	res := &ValidateTxRes{Valid: err == nil, Hash: tx.Hash()}
+	if err != nil {
+		res.Error = err.Error()
+	}
+	return res
+}
+
+// SyncEventRes is the notification of a syncing subscription, sent when a
+// synchronisation with a peer starts (syncing set) and when it ends, with the
+// error if it failed.
+type SyncEventRes struct {
+	Syncing bool         `json:"syncing"`
+	Peer    string       `json:"peer"`
+	Head    common.Hash  `json:"head"`
+	Td      *hexutil.Big `json:"td,omitempty"`
+	Error   string       `json:"error,omitempty"`
+}
+
+// NewSyncEventRes converts a downloader event into its notification.
+func NewSyncEventRes(ev interface{}) *SyncEventRes {
+	switch ev := ev.(type) {
+	case downloader.StartEvent:
+		return &SyncEventRes{Syncing: true, Peer: ev.Peer, Head: ev.Head, Td: (*hexutil.Big)(ev.Td)}
+	case downloader.DoneEvent:
+		return &SyncEventRes{Peer: ev.Peer, Head: ev.Head}
+	case downloader.FailedEvent:
+		return &SyncEventRes{Peer: ev.Peer, Head: ev.Head, Error: ev.Err.Error()}
+	}
+	return nil
+}
+
 func NewHashesRes(hs []common.Hash) []string {
 	hashes := make([]string, len(hs))
 
@@ -882,6 +1300,69 @@ func NewHashesRes(hs []common.Hash) []string {
 	return hashes
 }
 
+type ConfirmationRes struct {
+	Status          string         `json:"status"`
+	TransactionHash hexutil.Bytes  `json:"transactionHash"`
+	BlockHash       hexutil.Bytes  `json:"blockHash"`
+	BlockNumber     hexutil.Uint64 `json:"blockNumber"`
+	Confirmations   hexutil.Uint64 `json:"confirmations"`
+}
+
+func NewConfirmationsRes(events []filters.ConfirmationEvent) []ConfirmationRes {
+	res := make([]ConfirmationRes, len(events))
+
+	for i, ev := range events {
+		res[i] = ConfirmationRes{
+			Status:          string(ev.Status),
+			TransactionHash: ev.TxHash.Bytes(),
+			BlockHash:       ev.BlockHash.Bytes(),
+			BlockNumber:     hexutil.Uint64(ev.BlockNumber),
+			Confirmations:   hexutil.Uint64(ev.Confirmations),
+		}
+	}
+
+	return res
+}
+
+type DroppedTransactionRes struct {
+	TransactionHash hexutil.Bytes `json:"transactionHash"`
+	Reason          string        `json:"reason"`
+}
+
+func NewDroppedTransactionsRes(events []core.TxDropEvent) []DroppedTransactionRes {
+	res := make([]DroppedTransactionRes, len(events))
+
+	for i, ev := range events {
+		res[i] = DroppedTransactionRes{
+			TransactionHash: ev.Tx.Hash().Bytes(),
+			Reason:          string(ev.Reason),
+		}
+	}
+
+	return res
+}
+
+type GetWorkArgs struct {
+	Id string
+}
+
+func (args *GetWorkArgs) UnmarshalJSON(b []byte) (err error) {
+	var obj []interface{}
+	if err := json.Unmarshal(b, &obj); err != nil {
+		return shared.NewDecodeParamError(err.Error())
+	}
+
+	if len(obj) > 0 && obj[0] != nil {
+		id, ok := obj[0].(string)
+		if !ok {
+			return shared.NewInvalidTypeError("id", "not a string")
+		}
+		args.Id = id
+	}
+
+	return nil
+}
+
 type SubmitWorkArgs struct {
 	Nonce  uint64
 	Header string
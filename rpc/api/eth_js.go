@@ -30,6 +30,68 @@ web3._extend({
 			params: 2,
 			inputFormatter: [web3._extend.utils.toAddress, null]
 		}),
+		new web3._extend.Method({
+			name: 'getConfirmedBalance',
+			call: 'eth_getConfirmedBalance',
+			params: 2,
+			inputFormatter: [web3._extend.utils.toAddress, web3._extend.utils.fromDecimal]
+		}),
+		new web3._extend.Method({
+			name: 'getGasStats',
+			call: 'eth_getGasStats',
+			params: 2,
+			inputFormatter: [web3._extend.formatters.inputBlockNumberFormatter, null]
+		}),
+		new web3._extend.Method({
+			name: 'getInternalTransactions',
+			call: 'eth_getInternalTransactions',
+			params: 3,
+			inputFormatter: [web3._extend.utils.toAddress, web3._extend.formatters.inputBlockNumberFormatter, null]
+		}),
+		new web3._extend.Method({
+			name: 'getNetworkHashrate',
+			call: 'eth_getNetworkHashrate',
+			params: 2,
+			inputFormatter: [null, web3._extend.formatters.inputBlockNumberFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'getUncleStats',
+			call: 'eth_getUncleStats',
+			params: 2,
+			inputFormatter: [web3._extend.formatters.inputBlockNumberFormatter, null]
+		}),
+		new web3._extend.Method({
+			name: 'newConfirmationFilter',
+			call: 'eth_newConfirmationFilter',
+			params: 2,
+			inputFormatter: [null, web3._extend.utils.fromDecimal],
+			outputFormatter: web3._extend.utils.toDecimal
+		}),
+		new web3._extend.Method({
+			name: 'newDroppedTransactionFilter',
+			call: 'eth_newDroppedTransactionFilter',
+			params: 0,
+			outputFormatter: web3._extend.utils.toDecimal
+		}),
+		new web3._extend.Method({
+			name: 'getFilterChanges',
+			call: 'eth_getFilterChanges',
+			params: 1,
+			inputFormatter: [web3._extend.utils.fromDecimal]
+		}),
+		new web3._extend.Method({
+			name: 'reserveNonce',
+			call: 'eth_reserveNonce',
+			params: 1,
+			inputFormatter: [web3._extend.utils.toAddress],
+			outputFormatter: web3._extend.utils.toDecimal
+		}),
+		new web3._extend.Method({
+			name: 'releaseNonce',
+			call: 'eth_releaseNonce',
+			params: 2,
+			inputFormatter: [web3._extend.utils.toAddress, web3._extend.utils.fromDecimal]
+		}),
 		new web3._extend.Method({
 			name: 'resend',
 			call: 'eth_resend',
@@ -53,6 +115,11 @@ web3._extend({
 			call: 'eth_submitTransaction',
 			params: 1,
 			inputFormatter: [web3._extend.formatters.inputTransactionFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'validateRawTransaction',
+			call: 'eth_validateRawTransaction',
+			params: 1
 		})
 	],
 	properties:
@@ -60,6 +127,10 @@ web3._extend({
 		new web3._extend.Property({
 			name: 'pendingTransactions',
 			getter: 'eth_pendingTransactions'
+		}),
+		new web3._extend.Property({
+			name: 'chainId',
+			getter: 'eth_chainId'
 		})
 	]
 });
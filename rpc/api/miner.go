@@ -17,9 +17,9 @@
 package api
 
 import (
-	"github.com/expanse-project/ethash"
 	"github.com/expanse-project/go-expanse/common"
 	"github.com/expanse-project/go-expanse/exp"
+	"github.com/expanse-project/go-expanse/pow/ethash"
 	"github.com/expanse-project/go-expanse/rpc/codec"
 	"github.com/expanse-project/go-expanse/rpc/shared"
 )
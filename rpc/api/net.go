@@ -17,6 +17,7 @@
 package api
 
 import (
+	"github.com/expanse-project/go-expanse/common/hexutil"
 	"github.com/expanse-project/go-expanse/exp"
 	"github.com/expanse-project/go-expanse/rpc/codec"
 	"github.com/expanse-project/go-expanse/rpc/shared"
@@ -87,7 +88,7 @@ func (self *netApi) ApiVersion() string {
 
 // Number of connected peers
 func (self *netApi) PeerCount(req *shared.Request) (interface{}, error) {
-	return newHexNum(self.xeth.PeerCount()), nil
+	return hexutil.Uint64(self.xeth.PeerCount()), nil
 }
 
 func (self *netApi) IsListening(req *shared.Request) (interface{}, error) {
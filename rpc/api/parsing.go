@@ -18,166 +18,46 @@ package api
 
 import (
 	"bytes"
-	"encoding/binary"
-	"encoding/hex"
 	"encoding/json"
+	"fmt"
+	"math"
 	"math/big"
-	"strings"
+	"sort"
+	"strconv"
+	"time"
 
+	"github.com/expanse-project/go-expanse/accounts"
+	"github.com/expanse-project/go-expanse/clique"
 	"github.com/expanse-project/go-expanse/common"
+	"github.com/expanse-project/go-expanse/common/hexutil"
+	"github.com/expanse-project/go-expanse/core"
+	"github.com/expanse-project/go-expanse/core/state"
 	"github.com/expanse-project/go-expanse/core/types"
+	"github.com/expanse-project/go-expanse/core/vm"
 	"github.com/expanse-project/go-expanse/rpc/shared"
 )
 
-type hexdata struct {
-	data  []byte
-	isNil bool
-}
-
-func (d *hexdata) String() string {
-	return "0x" + common.Bytes2Hex(d.data)
-}
-
-func (d *hexdata) MarshalJSON() ([]byte, error) {
-	if d.isNil {
-		return json.Marshal(nil)
-	}
-	return json.Marshal(d.String())
-}
-
-func newHexData(input interface{}) *hexdata {
-	d := new(hexdata)
-
-	if input == nil {
-		d.isNil = true
-		return d
-	}
-	switch input := input.(type) {
-	case []byte:
-		d.data = input
-	case common.Hash:
-		d.data = input.Bytes()
-	case *common.Hash:
-		if input == nil {
-			d.isNil = true
-		} else {
-			d.data = input.Bytes()
-		}
-	case common.Address:
-		d.data = input.Bytes()
-	case *common.Address:
-		if input == nil {
-			d.isNil = true
-		} else {
-			d.data = input.Bytes()
-		}
-	case types.Bloom:
-		d.data = input.Bytes()
-	case *types.Bloom:
-		if input == nil {
-			d.isNil = true
-		} else {
-			d.data = input.Bytes()
-		}
-	case *big.Int:
-		if input == nil {
-			d.isNil = true
-		} else {
-			d.data = input.Bytes()
-		}
-	case int64:
-		d.data = big.NewInt(input).Bytes()
-	case uint64:
-		buff := make([]byte, 8)
-		binary.BigEndian.PutUint64(buff, input)
-		d.data = buff
-	case int:
-		d.data = big.NewInt(int64(input)).Bytes()
-	case uint:
-		d.data = big.NewInt(int64(input)).Bytes()
-	case int8:
-		d.data = big.NewInt(int64(input)).Bytes()
-	case uint8:
-		d.data = big.NewInt(int64(input)).Bytes()
-	case int16:
-		d.data = big.NewInt(int64(input)).Bytes()
-	case uint16:
-		buff := make([]byte, 2)
-		binary.BigEndian.PutUint16(buff, input)
-		d.data = buff
-	case int32:
-		d.data = big.NewInt(int64(input)).Bytes()
-	case uint32:
-		buff := make([]byte, 4)
-		binary.BigEndian.PutUint32(buff, input)
-		d.data = buff
-	case string: // hexstring
-		// aaargh ffs TODO: avoid back-and-forth hex encodings where unneeded
-		bytes, err := hex.DecodeString(strings.TrimPrefix(input, "0x"))
-		if err != nil {
-			d.isNil = true
-		} else {
-			d.data = bytes
-		}
-	default:
-		d.isNil = true
-	}
-
-	return d
-}
-
-type hexnum struct {
-	data  []byte
-	isNil bool
-}
-
-func (d *hexnum) String() string {
-	// Get hex string from bytes
-	out := common.Bytes2Hex(d.data)
-	// Trim leading 0s
-	out = strings.TrimLeft(out, "0")
-	// Output "0x0" when value is 0
-	if len(out) == 0 {
-		out = "0"
-	}
-	return "0x" + out
-}
-
-func (d *hexnum) MarshalJSON() ([]byte, error) {
-	if d.isNil {
-		return json.Marshal(nil)
-	}
-	return json.Marshal(d.String())
-}
-
-func newHexNum(input interface{}) *hexnum {
-	d := new(hexnum)
-
-	d.data = newHexData(input).data
-
-	return d
-}
-
 type BlockRes struct {
 	fullTx bool
 
-	BlockNumber     *hexnum           `json:"number"`
-	BlockHash       *hexdata          `json:"hash"`
-	ParentHash      *hexdata          `json:"parentHash"`
-	Nonce           *hexdata          `json:"nonce"`
-	Sha3Uncles      *hexdata          `json:"sha3Uncles"`
-	LogsBloom       *hexdata          `json:"logsBloom"`
-	TransactionRoot *hexdata          `json:"transactionsRoot"`
-	StateRoot       *hexdata          `json:"stateRoot"`
-	ReceiptRoot     *hexdata          `json:"receiptRoot"`
-	Miner           *hexdata          `json:"miner"`
-	Difficulty      *hexnum           `json:"difficulty"`
-	TotalDifficulty *hexnum           `json:"totalDifficulty"`
-	Size            *hexnum           `json:"size"`
-	ExtraData       *hexdata          `json:"extraData"`
-	GasLimit        *hexnum           `json:"gasLimit"`
-	GasUsed         *hexnum           `json:"gasUsed"`
-	UnixTimestamp   *hexnum           `json:"timestamp"`
+	BlockNumber     *hexutil.Big      `json:"number"`
+	BlockHash       hexutil.Bytes     `json:"hash"`
+	ParentHash      hexutil.Bytes     `json:"parentHash"`
+	Nonce           hexutil.Bytes     `json:"nonce"`
+	MixHash         hexutil.Bytes     `json:"mixHash"`
+	Sha3Uncles      hexutil.Bytes     `json:"sha3Uncles"`
+	LogsBloom       hexutil.Bytes     `json:"logsBloom"`
+	TransactionRoot hexutil.Bytes     `json:"transactionsRoot"`
+	StateRoot       hexutil.Bytes     `json:"stateRoot"`
+	ReceiptRoot     hexutil.Bytes     `json:"receiptsRoot"`
+	Miner           hexutil.Bytes     `json:"miner"`
+	Difficulty      *hexutil.Big      `json:"difficulty"`
+	TotalDifficulty *hexutil.Big      `json:"totalDifficulty"`
+	Size            hexutil.Uint64    `json:"size"`
+	ExtraData       hexutil.Bytes     `json:"extraData"`
+	GasLimit        *hexutil.Big      `json:"gasLimit"`
+	GasUsed         *hexutil.Big      `json:"gasUsed"`
+	UnixTimestamp   *hexutil.Big      `json:"timestamp"`
 	Transactions    []*TransactionRes `json:"transactions"`
 	Uncles          []*UncleRes       `json:"uncles"`
 }
@@ -185,36 +65,40 @@ type BlockRes struct {
 func (b *BlockRes) MarshalJSON() ([]byte, error) {
 	if b.fullTx {
 		var ext struct {
-			BlockNumber     *hexnum           `json:"number"`
-			BlockHash       *hexdata          `json:"hash"`
-			ParentHash      *hexdata          `json:"parentHash"`
-			Nonce           *hexdata          `json:"nonce"`
-			Sha3Uncles      *hexdata          `json:"sha3Uncles"`
-			LogsBloom       *hexdata          `json:"logsBloom"`
-			TransactionRoot *hexdata          `json:"transactionsRoot"`
-			StateRoot       *hexdata          `json:"stateRoot"`
-			ReceiptRoot     *hexdata          `json:"receiptRoot"`
-			Miner           *hexdata          `json:"miner"`
-			Difficulty      *hexnum           `json:"difficulty"`
-			TotalDifficulty *hexnum           `json:"totalDifficulty"`
-			Size            *hexnum           `json:"size"`
-			ExtraData       *hexdata          `json:"extraData"`
-			GasLimit        *hexnum           `json:"gasLimit"`
-			GasUsed         *hexnum           `json:"gasUsed"`
-			UnixTimestamp   *hexnum           `json:"timestamp"`
+			BlockNumber     *hexutil.Big      `json:"number"`
+			BlockHash       hexutil.Bytes     `json:"hash"`
+			ParentHash      hexutil.Bytes     `json:"parentHash"`
+			Nonce           hexutil.Bytes     `json:"nonce"`
+			MixHash         hexutil.Bytes     `json:"mixHash"`
+			Sha3Uncles      hexutil.Bytes     `json:"sha3Uncles"`
+			LogsBloom       hexutil.Bytes     `json:"logsBloom"`
+			TransactionRoot hexutil.Bytes     `json:"transactionsRoot"`
+			StateRoot       hexutil.Bytes     `json:"stateRoot"`
+			ReceiptRoot     hexutil.Bytes     `json:"receiptsRoot"`
+			ReceiptRootOld  hexutil.Bytes     `json:"receiptRoot"` // name used before receiptsRoot, kept for old clients
+			Miner           hexutil.Bytes     `json:"miner"`
+			Difficulty      *hexutil.Big      `json:"difficulty"`
+			TotalDifficulty *hexutil.Big      `json:"totalDifficulty"`
+			Size            hexutil.Uint64    `json:"size"`
+			ExtraData       hexutil.Bytes     `json:"extraData"`
+			GasLimit        *hexutil.Big      `json:"gasLimit"`
+			GasUsed         *hexutil.Big      `json:"gasUsed"`
+			UnixTimestamp   *hexutil.Big      `json:"timestamp"`
 			Transactions    []*TransactionRes `json:"transactions"`
-			Uncles          []*hexdata        `json:"uncles"`
+			Uncles          []hexutil.Bytes   `json:"uncles"`
 		}
 
 		ext.BlockNumber = b.BlockNumber
 		ext.BlockHash = b.BlockHash
 		ext.ParentHash = b.ParentHash
 		ext.Nonce = b.Nonce
+		ext.MixHash = b.MixHash
 		ext.Sha3Uncles = b.Sha3Uncles
 		ext.LogsBloom = b.LogsBloom
 		ext.TransactionRoot = b.TransactionRoot
 		ext.StateRoot = b.StateRoot
 		ext.ReceiptRoot = b.ReceiptRoot
+		ext.ReceiptRootOld = b.ReceiptRoot
 		ext.Miner = b.Miner
 		ext.Difficulty = b.Difficulty
 		ext.TotalDifficulty = b.TotalDifficulty
@@ -224,43 +108,47 @@ func (b *BlockRes) MarshalJSON() ([]byte, error) {
 		ext.GasUsed = b.GasUsed
 		ext.UnixTimestamp = b.UnixTimestamp
 		ext.Transactions = b.Transactions
-		ext.Uncles = make([]*hexdata, len(b.Uncles))
+		ext.Uncles = make([]hexutil.Bytes, len(b.Uncles))
 		for i, u := range b.Uncles {
 			ext.Uncles[i] = u.BlockHash
 		}
 		return json.Marshal(ext)
 	} else {
 		var ext struct {
-			BlockNumber     *hexnum    `json:"number"`
-			BlockHash       *hexdata   `json:"hash"`
-			ParentHash      *hexdata   `json:"parentHash"`
-			Nonce           *hexdata   `json:"nonce"`
-			Sha3Uncles      *hexdata   `json:"sha3Uncles"`
-			LogsBloom       *hexdata   `json:"logsBloom"`
-			TransactionRoot *hexdata   `json:"transactionsRoot"`
-			StateRoot       *hexdata   `json:"stateRoot"`
-			ReceiptRoot     *hexdata   `json:"receiptRoot"`
-			Miner           *hexdata   `json:"miner"`
-			Difficulty      *hexnum    `json:"difficulty"`
-			TotalDifficulty *hexnum    `json:"totalDifficulty"`
-			Size            *hexnum    `json:"size"`
-			ExtraData       *hexdata   `json:"extraData"`
-			GasLimit        *hexnum    `json:"gasLimit"`
-			GasUsed         *hexnum    `json:"gasUsed"`
-			UnixTimestamp   *hexnum    `json:"timestamp"`
-			Transactions    []*hexdata `json:"transactions"`
-			Uncles          []*hexdata `json:"uncles"`
+			BlockNumber     *hexutil.Big    `json:"number"`
+			BlockHash       hexutil.Bytes   `json:"hash"`
+			ParentHash      hexutil.Bytes   `json:"parentHash"`
+			Nonce           hexutil.Bytes   `json:"nonce"`
+			MixHash         hexutil.Bytes   `json:"mixHash"`
+			Sha3Uncles      hexutil.Bytes   `json:"sha3Uncles"`
+			LogsBloom       hexutil.Bytes   `json:"logsBloom"`
+			TransactionRoot hexutil.Bytes   `json:"transactionsRoot"`
+			StateRoot       hexutil.Bytes   `json:"stateRoot"`
+			ReceiptRoot     hexutil.Bytes   `json:"receiptsRoot"`
+			ReceiptRootOld  hexutil.Bytes   `json:"receiptRoot"` // name used before receiptsRoot, kept for old clients
+			Miner           hexutil.Bytes   `json:"miner"`
+			Difficulty      *hexutil.Big    `json:"difficulty"`
+			TotalDifficulty *hexutil.Big    `json:"totalDifficulty"`
+			Size            hexutil.Uint64  `json:"size"`
+			ExtraData       hexutil.Bytes   `json:"extraData"`
+			GasLimit        *hexutil.Big    `json:"gasLimit"`
+			GasUsed         *hexutil.Big    `json:"gasUsed"`
+			UnixTimestamp   *hexutil.Big    `json:"timestamp"`
+			Transactions    []hexutil.Bytes `json:"transactions"`
+			Uncles          []hexutil.Bytes `json:"uncles"`
 		}
 
 		ext.BlockNumber = b.BlockNumber
 		ext.BlockHash = b.BlockHash
 		ext.ParentHash = b.ParentHash
 		ext.Nonce = b.Nonce
+		ext.MixHash = b.MixHash
 		ext.Sha3Uncles = b.Sha3Uncles
 		ext.LogsBloom = b.LogsBloom
 		ext.TransactionRoot = b.TransactionRoot
 		ext.StateRoot = b.StateRoot
 		ext.ReceiptRoot = b.ReceiptRoot
+		ext.ReceiptRootOld = b.ReceiptRoot
 		ext.Miner = b.Miner
 		ext.Difficulty = b.Difficulty
 		ext.TotalDifficulty = b.TotalDifficulty
@@ -269,11 +157,11 @@ func (b *BlockRes) MarshalJSON() ([]byte, error) {
 		ext.GasLimit = b.GasLimit
 		ext.GasUsed = b.GasUsed
 		ext.UnixTimestamp = b.UnixTimestamp
-		ext.Transactions = make([]*hexdata, len(b.Transactions))
+		ext.Transactions = make([]hexutil.Bytes, len(b.Transactions))
 		for i, tx := range b.Transactions {
 			ext.Transactions[i] = tx.Hash
 		}
-		ext.Uncles = make([]*hexdata, len(b.Uncles))
+		ext.Uncles = make([]hexutil.Bytes, len(b.Uncles))
 		for i, u := range b.Uncles {
 			ext.Uncles[i] = u.BlockHash
 		}
@@ -288,31 +176,32 @@ func NewBlockRes(block *types.Block, td *big.Int, fullTx bool) *BlockRes {
 
 	res := new(BlockRes)
 	res.fullTx = fullTx
-	res.BlockNumber = newHexNum(block.Number())
-	res.BlockHash = newHexData(block.Hash())
-	res.ParentHash = newHexData(block.ParentHash())
-	res.Nonce = newHexData(block.Nonce())
-	res.Sha3Uncles = newHexData(block.UncleHash())
-	res.LogsBloom = newHexData(block.Bloom())
-	res.TransactionRoot = newHexData(block.TxHash())
-	res.StateRoot = newHexData(block.Root())
-	res.ReceiptRoot = newHexData(block.ReceiptHash())
-	res.Miner = newHexData(block.Coinbase())
-	res.Difficulty = newHexNum(block.Difficulty())
-	res.TotalDifficulty = newHexNum(td)
-	res.Size = newHexNum(block.Size().Int64())
-	res.ExtraData = newHexData(block.Extra())
-	res.GasLimit = newHexNum(block.GasLimit())
-	res.GasUsed = newHexNum(block.GasUsed())
-	res.UnixTimestamp = newHexNum(block.Time())
+	res.BlockNumber = (*hexutil.Big)(block.Number())
+	res.BlockHash = block.Hash().Bytes()
+	res.ParentHash = block.ParentHash().Bytes()
+	res.Nonce = block.Header().Nonce[:]
+	res.MixHash = block.MixDigest().Bytes()
+	res.Sha3Uncles = block.UncleHash().Bytes()
+	res.LogsBloom = block.Bloom().Bytes()
+	res.TransactionRoot = block.TxHash().Bytes()
+	res.StateRoot = block.Root().Bytes()
+	res.ReceiptRoot = block.ReceiptHash().Bytes()
+	res.Miner = block.Coinbase().Bytes()
+	res.Difficulty = (*hexutil.Big)(block.Difficulty())
+	res.TotalDifficulty = (*hexutil.Big)(td)
+	res.Size = hexutil.Uint64(block.Size())
+	res.ExtraData = block.Extra()
+	res.GasLimit = (*hexutil.Big)(block.GasLimit())
+	res.GasUsed = (*hexutil.Big)(block.GasUsed())
+	res.UnixTimestamp = (*hexutil.Big)(block.Time())
 
 	txs := block.Transactions()
 	res.Transactions = make([]*TransactionRes, len(txs))
 	for i, tx := range txs {
 		res.Transactions[i] = NewTransactionRes(tx)
-		res.Transactions[i].BlockHash = res.BlockHash
+		res.Transactions[i].BlockHash = &res.BlockHash
 		res.Transactions[i].BlockNumber = res.BlockNumber
-		res.Transactions[i].TxIndex = newHexNum(i)
+		res.Transactions[i].TxIndex = newUint64(uint64(i))
 	}
 
 	uncles := block.Uncles()
@@ -325,17 +214,17 @@ func NewBlockRes(block *types.Block, td *big.Int, fullTx bool) *BlockRes {
 }
 
 type TransactionRes struct {
-	Hash        *hexdata `json:"hash"`
-	Nonce       *hexnum  `json:"nonce"`
-	BlockHash   *hexdata `json:"blockHash"`
-	BlockNumber *hexnum  `json:"blockNumber"`
-	TxIndex     *hexnum  `json:"transactionIndex"`
-	From        *hexdata `json:"from"`
-	To          *hexdata `json:"to"`
-	Value       *hexnum  `json:"value"`
-	Gas         *hexnum  `json:"gas"`
-	GasPrice    *hexnum  `json:"gasPrice"`
-	Input       *hexdata `json:"input"`
+	Hash        hexutil.Bytes   `json:"hash"`
+	Nonce       hexutil.Uint64  `json:"nonce"`
+	BlockHash   *hexutil.Bytes  `json:"blockHash"`
+	BlockNumber *hexutil.Big    `json:"blockNumber"`
+	TxIndex     *hexutil.Uint64 `json:"transactionIndex"`
+	From        hexutil.Bytes   `json:"from"`
+	To          *hexutil.Bytes  `json:"to"`
+	Value       *hexutil.Big    `json:"value"`
+	Gas         *hexutil.Big    `json:"gas"`
+	GasPrice    *hexutil.Big    `json:"gasPrice"`
+	Input       hexutil.Bytes   `json:"input"`
 }
 
 func NewTransactionRes(tx *types.Transaction) *TransactionRes {
@@ -344,37 +233,39 @@ func NewTransactionRes(tx *types.Transaction) *TransactionRes {
 	}
 
 	var v = new(TransactionRes)
-	v.Hash = newHexData(tx.Hash())
-	v.Nonce = newHexNum(tx.Nonce())
+	v.Hash = tx.Hash().Bytes()
+	v.Nonce = hexutil.Uint64(tx.Nonce())
 	// v.BlockHash =
 	// v.BlockNumber =
 	// v.TxIndex =
 	from, _ := tx.FromFrontier()
-	v.From = newHexData(from)
-	v.To = newHexData(tx.To())
-	v.Value = newHexNum(tx.Value())
-	v.Gas = newHexNum(tx.Gas())
-	v.GasPrice = newHexNum(tx.GasPrice())
-	v.Input = newHexData(tx.Data())
+	v.From = from.Bytes()
+	if to := tx.To(); to != nil {
+		v.To = newBytes(to.Bytes())
+	}
+	v.Value = (*hexutil.Big)(tx.Value())
+	v.Gas = (*hexutil.Big)(tx.Gas())
+	v.GasPrice = (*hexutil.Big)(tx.GasPrice())
+	v.Input = tx.Data()
 	return v
 }
 
 type UncleRes struct {
-	BlockNumber     *hexnum  `json:"number"`
-	BlockHash       *hexdata `json:"hash"`
-	ParentHash      *hexdata `json:"parentHash"`
-	Nonce           *hexdata `json:"nonce"`
-	Sha3Uncles      *hexdata `json:"sha3Uncles"`
-	ReceiptHash     *hexdata `json:"receiptHash"`
-	LogsBloom       *hexdata `json:"logsBloom"`
-	TransactionRoot *hexdata `json:"transactionsRoot"`
-	StateRoot       *hexdata `json:"stateRoot"`
-	Miner           *hexdata `json:"miner"`
-	Difficulty      *hexnum  `json:"difficulty"`
-	ExtraData       *hexdata `json:"extraData"`
-	GasLimit        *hexnum  `json:"gasLimit"`
-	GasUsed         *hexnum  `json:"gasUsed"`
-	UnixTimestamp   *hexnum  `json:"timestamp"`
+	BlockNumber     *hexutil.Big  `json:"number"`
+	BlockHash       hexutil.Bytes `json:"hash"`
+	ParentHash      hexutil.Bytes `json:"parentHash"`
+	Nonce           hexutil.Bytes `json:"nonce"`
+	Sha3Uncles      hexutil.Bytes `json:"sha3Uncles"`
+	ReceiptHash     hexutil.Bytes `json:"receiptHash"`
+	LogsBloom       hexutil.Bytes `json:"logsBloom"`
+	TransactionRoot hexutil.Bytes `json:"transactionsRoot"`
+	StateRoot       hexutil.Bytes `json:"stateRoot"`
+	Miner           hexutil.Bytes `json:"miner"`
+	Difficulty      *hexutil.Big  `json:"difficulty"`
+	ExtraData       hexutil.Bytes `json:"extraData"`
+	GasLimit        *hexutil.Big  `json:"gasLimit"`
+	GasUsed         *hexutil.Big  `json:"gasUsed"`
+	UnixTimestamp   *hexutil.Big  `json:"timestamp"`
 }
 
 func NewUncleRes(h *types.Header) *UncleRes {
@@ -383,21 +274,21 @@ func NewUncleRes(h *types.Header) *UncleRes {
 	}
 
 	var v = new(UncleRes)
-	v.BlockNumber = newHexNum(h.Number)
-	v.BlockHash = newHexData(h.Hash())
-	v.ParentHash = newHexData(h.ParentHash)
-	v.Sha3Uncles = newHexData(h.UncleHash)
-	v.Nonce = newHexData(h.Nonce[:])
-	v.LogsBloom = newHexData(h.Bloom)
-	v.TransactionRoot = newHexData(h.TxHash)
-	v.StateRoot = newHexData(h.Root)
-	v.Miner = newHexData(h.Coinbase)
-	v.Difficulty = newHexNum(h.Difficulty)
-	v.ExtraData = newHexData(h.Extra)
-	v.GasLimit = newHexNum(h.GasLimit)
-	v.GasUsed = newHexNum(h.GasUsed)
-	v.UnixTimestamp = newHexNum(h.Time)
-	v.ReceiptHash = newHexData(h.ReceiptHash)
+	v.BlockNumber = (*hexutil.Big)(h.Number)
+	v.BlockHash = h.Hash().Bytes()
+	v.ParentHash = h.ParentHash.Bytes()
+	v.Sha3Uncles = h.UncleHash.Bytes()
+	v.Nonce = h.Nonce[:]
+	v.LogsBloom = h.Bloom.Bytes()
+	v.TransactionRoot = h.TxHash.Bytes()
+	v.StateRoot = h.Root.Bytes()
+	v.Miner = h.Coinbase.Bytes()
+	v.Difficulty = (*hexutil.Big)(h.Difficulty)
+	v.ExtraData = h.Extra
+	v.GasLimit = (*hexutil.Big)(h.GasLimit)
+	v.GasUsed = (*hexutil.Big)(h.GasUsed)
+	v.UnixTimestamp = (*hexutil.Big)(h.Time)
+	v.ReceiptHash = h.ReceiptHash.Bytes()
 
 	return v
 }
@@ -426,14 +317,14 @@ func NewUncleRes(h *types.Header) *UncleRes {
 // }
 
 type ReceiptRes struct {
-	TransactionHash   *hexdata       `json:"transactionHash"`
-	TransactionIndex  *hexnum        `json:"transactionIndex"`
-	BlockNumber       *hexnum        `json:"blockNumber"`
-	BlockHash         *hexdata       `json:"blockHash"`
-	CumulativeGasUsed *hexnum        `json:"cumulativeGasUsed"`
-	GasUsed           *hexnum        `json:"gasUsed"`
-	ContractAddress   *hexdata       `json:"contractAddress"`
-	Logs              *[]interface{} `json:"logs"`
+	TransactionHash   hexutil.Bytes   `json:"transactionHash"`
+	TransactionIndex  *hexutil.Uint64 `json:"transactionIndex"`
+	BlockNumber       *hexutil.Big    `json:"blockNumber"`
+	BlockHash         *hexutil.Bytes  `json:"blockHash"`
+	CumulativeGasUsed *hexutil.Big    `json:"cumulativeGasUsed"`
+	GasUsed           *hexutil.Big    `json:"gasUsed"`
+	ContractAddress   *hexutil.Bytes  `json:"contractAddress"`
+	Logs              *[]interface{}  `json:"logs"`
 }
 
 func NewReceiptRes(rec *types.Receipt) *ReceiptRes {
@@ -442,15 +333,13 @@ func NewReceiptRes(rec *types.Receipt) *ReceiptRes {
 	}
 
 	var v = new(ReceiptRes)
-	v.TransactionHash = newHexData(rec.TxHash)
-	if rec.GasUsed != nil {
-		v.GasUsed = newHexNum(rec.GasUsed.Bytes())
-	}
-	v.CumulativeGasUsed = newHexNum(rec.CumulativeGasUsed)
+	v.TransactionHash = rec.TxHash.Bytes()
+	v.GasUsed = (*hexutil.Big)(rec.GasUsed)
+	v.CumulativeGasUsed = (*hexutil.Big)(rec.CumulativeGasUsed)
 
 	// If the ContractAddress is 20 0x0 bytes, assume it is not a contract creation
 	if bytes.Compare(rec.ContractAddress.Bytes(), bytes.Repeat([]byte{0}, 20)) != 0 {
-		v.ContractAddress = newHexData(rec.ContractAddress)
+		v.ContractAddress = newBytes(rec.ContractAddress.Bytes())
 	}
 
 	logs := make([]interface{}, len(rec.Logs))
@@ -462,25 +351,389 @@ func NewReceiptRes(rec *types.Receipt) *ReceiptRes {
 	return v
 }
 
+// newBytes returns a pointer to b, for use in optional response fields.
+func newBytes(b []byte) *hexutil.Bytes {
+	hb := hexutil.Bytes(b)
+	return &hb
+}
+
+// newUint64 returns a pointer to i, for use in optional response fields.
+func newUint64(i uint64) *hexutil.Uint64 {
+	hi := hexutil.Uint64(i)
+	return &hi
+}
+
+type ConfirmedBalanceRes struct {
+	Balance     *hexutil.Big  `json:"balance"`
+	BlockNumber *hexutil.Big  `json:"blockNumber"`
+	BlockHash   hexutil.Bytes `json:"blockHash"`
+}
+
+type BlockGasStatsRes struct {
+	BlockNumber  *hexutil.Big   `json:"number"`
+	BlockHash    hexutil.Bytes  `json:"hash"`
+	GasUsed      *hexutil.Big   `json:"gasUsed"`
+	GasLimit     *hexutil.Big   `json:"gasLimit"`
+	Fees         *hexutil.Big   `json:"fees"`
+	Transactions hexutil.Uint64 `json:"transactions"`
+}
+
+// blockGasStats computes the gas and fee statistics of a block from its stored
+// receipts. The gas used by each transaction is derived from the cumulative gas
+// of the receipts, which is part of the consensus encoding.
+func blockGasStats(block *types.Block, receipts types.Receipts) (*BlockGasStatsRes, error) {
+	txs := block.Transactions()
+	if len(receipts) != len(txs) {
+		return nil, fmt.Errorf("receipts of block #%d not available", block.NumberU64())
+	}
+	var (
+		fees = new(big.Int)
+		prev = new(big.Int)
+		used = new(big.Int)
+	)
+	for i, tx := range txs {
+		used.Sub(receipts[i].CumulativeGasUsed, prev)
+		fees.Add(fees, used.Mul(used, tx.GasPrice()))
+		prev = receipts[i].CumulativeGasUsed
+	}
+	return &BlockGasStatsRes{
+		BlockNumber:  (*hexutil.Big)(block.Number()),
+		BlockHash:    block.Hash().Bytes(),
+		GasUsed:      (*hexutil.Big)(block.GasUsed()),
+		GasLimit:     (*hexutil.Big)(block.GasLimit()),
+		Fees:         (*hexutil.Big)(fees),
+		Transactions: hexutil.Uint64(len(txs)),
+	}, nil
+}
+
+type GasStatsRes struct {
+	FromBlock    *hexutil.Big        `json:"fromBlock"`
+	ToBlock      *hexutil.Big        `json:"toBlock"`
+	GasUsed      *hexutil.Big        `json:"gasUsed"`
+	GasLimit     *hexutil.Big        `json:"gasLimit"`
+	Fees         *hexutil.Big        `json:"fees"`
+	Transactions hexutil.Uint64      `json:"transactions"`
+	Blocks       []*BlockGasStatsRes `json:"blocks"`
+}
+
+func newGasStatsRes(from, to *big.Int) *GasStatsRes {
+	return &GasStatsRes{
+		FromBlock: (*hexutil.Big)(from),
+		ToBlock:   (*hexutil.Big)(to),
+		GasUsed:   new(hexutil.Big),
+		GasLimit:  new(hexutil.Big),
+		Fees:      new(hexutil.Big),
+	}
+}
+
+// add accumulates the statistics of a block into the range totals.
+func (res *GasStatsRes) add(block *BlockGasStatsRes) {
+	res.GasUsed.ToInt().Add(res.GasUsed.ToInt(), block.GasUsed.ToInt())
+	res.GasLimit.ToInt().Add(res.GasLimit.ToInt(), block.GasLimit.ToInt())
+	res.Fees.ToInt().Add(res.Fees.ToInt(), block.Fees.ToInt())
+	res.Transactions += block.Transactions
+	res.Blocks = append(res.Blocks, block)
+}
+
+type HashrateRes struct {
+	FromBlock hexutil.Uint64 `json:"fromBlock"`
+	ToBlock   hexutil.Uint64 `json:"toBlock"`
+	Work      *hexutil.Big   `json:"work"`
+	Seconds   hexutil.Uint64 `json:"seconds"`
+	BlockTime float64        `json:"blockTime"`
+	Hashrate  *hexutil.Big   `json:"hashrate"`
+}
+
+func newHashrateRes(estimate *core.HashrateEstimate) *HashrateRes {
+	return &HashrateRes{
+		FromBlock: hexutil.Uint64(estimate.From),
+		ToBlock:   hexutil.Uint64(estimate.To),
+		Work:      (*hexutil.Big)(estimate.Work),
+		Seconds:   hexutil.Uint64(estimate.Seconds),
+		BlockTime: estimate.BlockTime(),
+		Hashrate:  (*hexutil.Big)(estimate.Hashrate()),
+	}
+}
+
+type CliqueVoteRes struct {
+	Signer    hexutil.Bytes  `json:"signer"`
+	Block     hexutil.Uint64 `json:"block"`
+	Address   hexutil.Bytes  `json:"address"`
+	Authorize bool           `json:"authorize"`
+}
+
+type CliqueSnapshotRes struct {
+	Number  hexutil.Uint64          `json:"number"`
+	Hash    hexutil.Bytes           `json:"hash"`
+	Signers []hexutil.Bytes         `json:"signers"`
+	Votes   []*CliqueVoteRes        `json:"votes"`
+	Tally   map[string]clique.Tally `json:"tally"`
+}
+
+func newCliqueSnapshotRes(snap *clique.Snapshot) *CliqueSnapshotRes {
+	res := &CliqueSnapshotRes{
+		Number:  hexutil.Uint64(snap.Number),
+		Hash:    snap.Hash.Bytes(),
+		Signers: make([]hexutil.Bytes, 0, len(snap.Signers)),
+		Votes:   make([]*CliqueVoteRes, len(snap.Votes)),
+		Tally:   make(map[string]clique.Tally),
+	}
+	for _, signer := range snap.SortedSigners() {
+		res.Signers = append(res.Signers, signer.Bytes())
+	}
+	for i, vote := range snap.Votes {
+		res.Votes[i] = &CliqueVoteRes{
+			Signer:    vote.Signer.Bytes(),
+			Block:     hexutil.Uint64(vote.Block),
+			Address:   vote.Address.Bytes(),
+			Authorize: vote.Authorize,
+		}
+	}
+	for address, tally := range snap.Tally {
+		res.Tally[address.Hex()] = tally
+	}
+	return res
+}
+
+// TxPoolContentRes lists the pool transactions by sender address and nonce.
+type TxPoolContentRes struct {
+	Pending map[string]map[string]*TransactionRes `json:"pending"`
+	Queued  map[string]map[string]*TransactionRes `json:"queued"`
+	Total   int                                   `json:"total"`
+}
+
+func newTxPoolContentRes(pending, queued core.TxGroups, total int) *TxPoolContentRes {
+	group := func(groups core.TxGroups) map[string]map[string]*TransactionRes {
+		res := make(map[string]map[string]*TransactionRes, len(groups))
+		for from, txs := range groups {
+			nonces := make(map[string]*TransactionRes, len(txs))
+			for _, tx := range txs {
+				nonces[strconv.FormatUint(tx.Nonce(), 10)] = NewTransactionRes(tx)
+			}
+			res[from.Hex()] = nonces
+		}
+		return res
+	}
+	return &TxPoolContentRes{
+		Pending: group(pending),
+		Queued:  group(queued),
+		Total:   total,
+	}
+}
+
+// TxPoolInspectRes summarises the pool transactions by sender address and
+// nonce in a human readable form.
+type TxPoolInspectRes struct {
+	Pending map[string]map[string]string `json:"pending"`
+	Queued  map[string]map[string]string `json:"queued"`
+	Total   int                          `json:"total"`
+}
+
+func newTxPoolInspectRes(pending, queued core.TxGroups, total int) *TxPoolInspectRes {
+	group := func(groups core.TxGroups) map[string]map[string]string {
+		res := make(map[string]map[string]string, len(groups))
+		for from, txs := range groups {
+			nonces := make(map[string]string, len(txs))
+			for _, tx := range txs {
+				nonces[strconv.FormatUint(tx.Nonce(), 10)] = txSummary(tx)
+			}
+			res[from.Hex()] = nonces
+		}
+		return res
+	}
+	return &TxPoolInspectRes{
+		Pending: group(pending),
+		Queued:  group(queued),
+		Total:   total,
+	}
+}
+
+// TxPoolOriginRes counts the transactions received from a transport and client.
+type TxPoolOriginRes struct {
+	Transport string         `json:"transport"`
+	Remote    string         `json:"remote"`
+	Accepted  hexutil.Uint64 `json:"accepted"`
+	Rejected  hexutil.Uint64 `json:"rejected"`
+	LastSeen  hexutil.Uint64 `json:"lastSeen"`
+}
+
+func newTxPoolOriginsRes(stats map[core.TxOrigin]core.TxOriginStats) []*TxPoolOriginRes {
+	res := make([]*TxPoolOriginRes, 0, len(stats))
+	for origin, s := range stats {
+		res = append(res, &TxPoolOriginRes{
+			Transport: origin.Transport,
+			Remote:    origin.Remote,
+			Accepted:  hexutil.Uint64(s.Accepted),
+			Rejected:  hexutil.Uint64(s.Rejected),
+			LastSeen:  hexutil.Uint64(s.LastSeen.Unix()),
+		})
+	}
+	sort.Sort(txPoolOriginSorter(res))
+	return res
+}
+
+type txPoolOriginSorter []*TxPoolOriginRes
+
+func (s txPoolOriginSorter) Len() int      { return len(s) }
+func (s txPoolOriginSorter) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s txPoolOriginSorter) Less(i, j int) bool {
+	if s[i].Rejected != s[j].Rejected {
+		return s[i].Rejected > s[j].Rejected
+	}
+	if s[i].Transport != s[j].Transport {
+		return s[i].Transport < s[j].Transport
+	}
+	return s[i].Remote < s[j].Remote
+}
+
+// txSummary formats the recipient, value and gas of a transaction.
+func txSummary(tx *types.Transaction) string {
+	to := "contract creation"
+	if tx.To() != nil {
+		to = tx.To().Hex()
+	}
+	return fmt.Sprintf("%s: %v wei + %v gas × %v wei", to, tx.Value(), tx.Gas(), tx.GasPrice())
+}
+
+type InternalTxRes struct {
+	BlockNumber hexutil.Uint64 `json:"blockNumber"`
+	BlockHash   hexutil.Bytes  `json:"blockHash"`
+	TxHash      hexutil.Bytes  `json:"transactionHash"`
+	Type        string         `json:"type"`
+	From        hexutil.Bytes  `json:"from"`
+	To          hexutil.Bytes  `json:"to"`
+	Value       *hexutil.Big   `json:"value"`
+	Depth       hexutil.Uint64 `json:"depth"`
+}
+
+func NewInternalTxRes(tx *types.InternalTx, number uint64, hash common.Hash) *InternalTxRes {
+	return &InternalTxRes{
+		BlockNumber: hexutil.Uint64(number),
+		BlockHash:   hash.Bytes(),
+		TxHash:      tx.TxHash.Bytes(),
+		Type:        tx.Type,
+		From:        tx.From.Bytes(),
+		To:          tx.To.Bytes(),
+		Value:       (*hexutil.Big)(tx.Value),
+		Depth:       hexutil.Uint64(tx.Depth),
+	}
+}
+
+type MinerStatsRes struct {
+	Address hexutil.Bytes  `json:"address"`
+	Blocks  hexutil.Uint64 `json:"blocks"`
+	Uncles  hexutil.Uint64 `json:"uncles"`
+}
+
+// DelayStatsRes holds propagation delay estimates in milliseconds.
+type DelayStatsRes struct {
+	Samples hexutil.Uint64 `json:"samples"`
+	Mean    hexutil.Uint64 `json:"mean"`
+	Median  hexutil.Uint64 `json:"median"`
+	Max     hexutil.Uint64 `json:"max"`
+}
+
+func newDelayStatsRes(stats core.DelayStats) *DelayStatsRes {
+	return &DelayStatsRes{
+		Samples: hexutil.Uint64(stats.Samples),
+		Mean:    hexutil.Uint64(stats.Mean / time.Millisecond),
+		Median:  hexutil.Uint64(stats.Median / time.Millisecond),
+		Max:     hexutil.Uint64(stats.Max / time.Millisecond),
+	}
+}
+
+type UncleStatsRes struct {
+	FromBlock   hexutil.Uint64   `json:"fromBlock"`
+	ToBlock     hexutil.Uint64   `json:"toBlock"`
+	Blocks      hexutil.Uint64   `json:"blocks"`
+	Uncles      hexutil.Uint64   `json:"uncles"`
+	UncleRate   float64          `json:"uncleRate"`
+	Miners      []*MinerStatsRes `json:"miners"`
+	BlockDelays *DelayStatsRes   `json:"blockDelays"`
+	UncleDelays *DelayStatsRes   `json:"uncleDelays"`
+}
+
+// newUncleStatsRes converts the chain's uncle statistics, listing the miners
+// by the number of blocks they mined, most productive first.
+func newUncleStatsRes(stats *core.UncleStats) *UncleStatsRes {
+	res := &UncleStatsRes{
+		FromBlock:   hexutil.Uint64(stats.From),
+		ToBlock:     hexutil.Uint64(stats.To),
+		Blocks:      hexutil.Uint64(stats.Blocks),
+		Uncles:      hexutil.Uint64(stats.Uncles),
+		UncleRate:   stats.UncleRate(),
+		Miners:      make([]*MinerStatsRes, 0, len(stats.Miners)),
+		BlockDelays: newDelayStatsRes(stats.BlockDelays),
+		UncleDelays: newDelayStatsRes(stats.UncleDelays),
+	}
+	for addr, miner := range stats.Miners {
+		res.Miners = append(res.Miners, &MinerStatsRes{
+			Address: addr.Bytes(),
+			Blocks:  hexutil.Uint64(miner.Blocks),
+			Uncles:  hexutil.Uint64(miner.Uncles),
+		})
+	}
+	sort.Sort(minerStatsSorter(res.Miners))
+	return res
+}
+
+type minerStatsSorter []*MinerStatsRes
+
+func (s minerStatsSorter) Len() int      { return len(s) }
+func (s minerStatsSorter) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s minerStatsSorter) Less(i, j int) bool {
+	if s[i].Blocks != s[j].Blocks {
+		return s[i].Blocks > s[j].Blocks
+	}
+	if s[i].Uncles != s[j].Uncles {
+		return s[i].Uncles > s[j].Uncles
+	}
+	return bytes.Compare(s[i].Address, s[j].Address) < 0
+}
+
+type ModifiedAccountRes struct {
+	Address hexutil.Bytes   `json:"address"`
+	Storage []hexutil.Bytes `json:"storage,omitempty"`
+}
+
+func NewModifiedAccountRes(account state.ModifiedAccount) *ModifiedAccountRes {
+	res := &ModifiedAccountRes{Address: account.Address[:]}
+	for _, slot := range account.Storage {
+		res.Storage = append(res.Storage, hexutil.Bytes(slot.Bytes()))
+	}
+	return res
+}
+
+// numString decodes a quantity parameter. Hex strings follow the same
+// canonical 0x-quantity rules as hexutil.Big, plain JSON numbers and decimal
+// strings are still accepted for older clients.
 func numString(raw interface{}) (*big.Int, error) {
-	var number *big.Int
 	// Parse as integer
 	num, ok := raw.(float64)
 	if ok {
-		number = big.NewInt(int64(num))
-		return number, nil
+		return big.NewInt(int64(num)), nil
 	}
 
 	// Parse as string/hexstring
 	str, ok := raw.(string)
-	if ok {
-		number = common.String2Big(str)
-		return number, nil
+	if !ok {
+		return nil, shared.NewInvalidTypeError("", "not a number or string")
 	}
-
-	return nil, shared.NewInvalidTypeError("", "not a number or string")
+	if !common.HasHexPrefix(str) {
+		if number, ok := new(big.Int).SetString(str, 10); ok {
+			return number, nil
+		}
+		return nil, shared.NewValidationError("quantity", "not a decimal or hex number")
+	}
+	number, err := hexutil.DecodeBig(str)
+	if err != nil {
+		return nil, shared.NewValidationError("quantity", err.Error())
+	}
+	return number, nil
 }
 
+// blockHeight decodes a block number parameter, which is either one of the
+// tags earliest, latest and pending or a quantity as decoded by hexutil.Uint64.
 func blockHeight(raw interface{}, number *int64) error {
 	// Parse as integer
 	num, ok := raw.(float64)
@@ -503,11 +756,17 @@ func blockHeight(raw interface{}, number *int64) error {
 	case "pending":
 		*number = -2
 	default:
-		if common.HasHexPrefix(str) {
-			*number = common.String2Big(str).Int64()
-		} else {
+		if !common.HasHexPrefix(str) {
 			return shared.NewInvalidTypeError("blockNumber", "is not a valid string")
 		}
+		height, err := hexutil.DecodeUint64(str)
+		if err != nil {
+			return shared.NewValidationError("blockNumber", err.Error())
+		}
+		if height > math.MaxInt64 {
+			return shared.NewValidationError("blockNumber", "out of range")
+		}
+		*number = int64(height)
 	}
 
 	return nil
@@ -520,3 +779,174 @@ func blockHeightFromJson(msg json.RawMessage, number *int64) error {
 	}
 	return blockHeight(raw, number)
 }
+
+type TdRes struct {
+	Hash   hexutil.Bytes  `json:"hash"`
+	Number hexutil.Uint64 `json:"number"`
+	Td     *hexutil.Big   `json:"totalDifficulty"`
+}
+
+func NewTdRes(header *types.Header, td *big.Int) *TdRes {
+	return &TdRes{
+		Hash:   header.Hash().Bytes(),
+		Number: hexutil.Uint64(header.Number.Uint64()),
+		Td:     (*hexutil.Big)(td),
+	}
+}
+
+type ForkRes struct {
+	TdRes
+	Length     hexutil.Uint64 `json:"length"`
+	Difficulty *hexutil.Big   `json:"difficulty"`
+}
+
+type CompareForksRes struct {
+	Ancestor *TdRes   `json:"ancestor"`
+	A        *ForkRes `json:"a"`
+	B        *ForkRes `json:"b"`
+	Heavier  string   `json:"heavier"`
+}
+
+// NewCompareForksRes summarises the length and difficulty each fork added on
+// top of their common ancestor.
+func NewCompareForksRes(ancestor, a, b *types.Header, ancestorTd, aTd, bTd *big.Int) *CompareForksRes {
+	fork := func(head *types.Header, td *big.Int) *ForkRes {
+		return &ForkRes{
+			TdRes:      *NewTdRes(head, td),
+			Length:     hexutil.Uint64(head.Number.Uint64() - ancestor.Number.Uint64()),
+			Difficulty: (*hexutil.Big)(new(big.Int).Sub(td, ancestorTd)),
+		}
+	}
+	res := &CompareForksRes{
+		Ancestor: NewTdRes(ancestor, ancestorTd),
+		A:        fork(a, aTd),
+		B:        fork(b, bTd),
+		Heavier:  "equal",
+	}
+	switch aTd.Cmp(bTd) {
+	case 1:
+		res.Heavier = "a"
+	case -1:
+		res.Heavier = "b"
+	}
+	return res
+}
+
+type AccountRes struct {
+	Address  string         `json:"address"`
+	KeyFile  string         `json:"keyFile"`
+	Created  hexutil.Uint64 `json:"created"`
+	Unlocked bool           `json:"unlocked"`
+}
+
+func NewAccountRes(detail accounts.AccountDetail) *AccountRes {
+	return &AccountRes{
+		Address:  detail.Address.Hex(),
+		KeyFile:  detail.KeyFile,
+		Created:  hexutil.Uint64(detail.Created.Unix()),
+		Unlocked: detail.Unlocked,
+	}
+}
+
+type CallFrameRes struct {
+	Type    string          `json:"type"`
+	From    hexutil.Bytes   `json:"from"`
+	To      hexutil.Bytes   `json:"to"`
+	Value   *hexutil.Big    `json:"value"`
+	Gas     *hexutil.Big    `json:"gas"`
+	GasUsed *hexutil.Big    `json:"gasUsed"`
+	Input   hexutil.Bytes   `json:"input"`
+	Output  hexutil.Bytes   `json:"output"`
+	Error   string          `json:"error,omitempty"`
+	Calls   []*CallFrameRes `json:"calls,omitempty"`
+}
+
+func NewCallFrameRes(frame *core.CallFrame) *CallFrameRes {
+	res := &CallFrameRes{
+		Type:    frame.Type,
+		From:    frame.From.Bytes(),
+		To:      frame.To.Bytes(),
+		Value:   (*hexutil.Big)(frame.Value),
+		Gas:     (*hexutil.Big)(frame.Gas),
+		GasUsed: (*hexutil.Big)(frame.GasUsed),
+		Input:   frame.Input,
+		Output:  frame.Output,
+	}
+	if frame.Error != nil {
+		res.Error = frame.Error.Error()
+	}
+	for _, call := range frame.Calls {
+		res.Calls = append(res.Calls, NewCallFrameRes(call))
+	}
+	return res
+}
+
+// StructLogRes is an executed instruction of a transaction trace. The stack
+// and memory are listed in 32 byte words, the storage only holds the slots
+// changed by the contract so far.
+type StructLogRes struct {
+	Pc      uint64                   `json:"pc"`
+	Op      string                   `json:"op"`
+	Gas     *hexutil.Big             `json:"gas"`
+	GasCost *hexutil.Big             `json:"gasCost"`
+	Depth   int                      `json:"depth"`
+	Error   string                   `json:"error,omitempty"`
+	Stack   []hexutil.Bytes          `json:"stack,omitempty"`
+	Memory  []hexutil.Bytes          `json:"memory,omitempty"`
+	Storage map[string]hexutil.Bytes `json:"storage,omitempty"`
+}
+
+func NewStructLogRes(log vm.StructLog) *StructLogRes {
+	res := &StructLogRes{
+		Pc:      log.Pc,
+		Op:      log.Op.String(),
+		Gas:     (*hexutil.Big)(log.Gas),
+		GasCost: (*hexutil.Big)(log.GasCost),
+		Depth:   log.Depth,
+	}
+	if log.Err != nil {
+		res.Error = log.Err.Error()
+	}
+	for _, item := range log.Stack {
+		res.Stack = append(res.Stack, common.LeftPadBytes(item.Bytes(), 32))
+	}
+	for i := 0; i < len(log.Memory); i += 32 {
+		end := i + 32
+		if end > len(log.Memory) {
+			end = len(log.Memory)
+		}
+		res.Memory = append(res.Memory, log.Memory[i:end])
+	}
+	if len(log.Storage) > 0 {
+		res.Storage = make(map[string]hexutil.Bytes, len(log.Storage))
+		for slot, value := range log.Storage {
+			res.Storage[slot.Hex()] = common.LeftPadBytes(value, 32)
+		}
+	}
+	return res
+}
+
+type ExecutionTraceRes struct {
+	TxHash      hexutil.Bytes   `json:"transactionHash"`
+	Gas         *hexutil.Big    `json:"gas"`
+	ReturnValue hexutil.Bytes   `json:"returnValue"`
+	Failed      bool            `json:"failed"`
+	Error       string          `json:"error,omitempty"`
+	StructLogs  []*StructLogRes `json:"structLogs"`
+}
+
+func NewExecutionTraceRes(trace *core.ExecutionTrace) *ExecutionTraceRes {
+	res := &ExecutionTraceRes{
+		TxHash:      trace.TxHash.Bytes(),
+		Gas:         (*hexutil.Big)(trace.Gas),
+		ReturnValue: trace.ReturnValue,
+		StructLogs:  make([]*StructLogRes, len(trace.StructLogs)),
+	}
+	if trace.Error != nil {
+		res.Failed, res.Error = true, trace.Error.Error()
+	}
+	for i, log := range trace.StructLogs {
+		res.StructLogs[i] = NewStructLogRes(log)
+	}
+	return res
+}
@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/expanse-project/go-expanse/accounts"
 	"github.com/expanse-project/go-expanse/common"
 	"github.com/expanse-project/go-expanse/exp"
 	"github.com/expanse-project/go-expanse/rpc/codec"
@@ -29,14 +30,23 @@ import (
 
 const (
 	PersonalApiVersion = "1.0"
+
+	// DefaultUnlockDuration is the number of seconds personal_unlockAccount
+	// unlocks an account for if no duration is given. A duration of 0 keeps
+	// the account unlocked until it is locked or the node exits.
+	DefaultUnlockDuration = 300
+	// maxUnlockDuration bounds the duration of an unlock, one year.
+	maxUnlockDuration = 365 * 24 * 60 * 60
 )
 
 var (
 	// mapping between methods and handlers
 	personalMapping = map[string]personalhandler{
-		"personal_listAccounts":  (*personalApi).ListAccounts,
-		"personal_newAccount":    (*personalApi).NewAccount,
-		"personal_unlockAccount": (*personalApi).UnlockAccount,
+		"personal_listAccounts":         (*personalApi).ListAccounts,
+		"personal_listAccountsDetailed": (*personalApi).ListAccountsDetailed,
+		"personal_newAccount":           (*personalApi).NewAccount,
+		"personal_unlockAccount":        (*personalApi).UnlockAccount,
+		"personal_lockAccount":          (*personalApi).LockAccount,
 	}
 )
 
@@ -93,6 +103,20 @@ func (self *personalApi) ListAccounts(req *shared.Request) (interface{}, error)
 	return self.xeth.Accounts(), nil
 }
 
+// ListAccountsDetailed lists the accounts in the same order as ListAccounts,
+// along with their key files and whether they are unlocked.
+func (self *personalApi) ListAccountsDetailed(req *shared.Request) (interface{}, error) {
+	details, err := self.expanse.AccountManager().AccountDetails()
+	if err != nil && err != accounts.ErrNoKeys {
+		return nil, err
+	}
+	res := make([]*AccountRes, len(details))
+	for i, detail := range details {
+		res[i] = NewAccountRes(detail)
+	}
+	return res, nil
+}
+
 func (self *personalApi) NewAccount(req *shared.Request) (interface{}, error) {
 	args := new(NewAccountArgs)
 	if err := self.codec.Decode(req.Params, &args); err != nil {
@@ -137,3 +161,12 @@ func (self *personalApi) UnlockAccount(req *shared.Request) (interface{}, error)
 	err := am.TimedUnlock(addr, *args.Passphrase, time.Duration(args.Duration)*time.Second)
 	return err == nil, err
 }
+
+func (self *personalApi) LockAccount(req *shared.Request) (interface{}, error) {
+	args := new(LockAccountArgs)
+	if err := self.codec.Decode(req.Params, &args); err != nil {
+		return nil, shared.NewDecodeParamError(err.Error())
+	}
+	self.expanse.AccountManager().Lock(common.HexToAddress(args.Address))
+	return true, nil
+}
@@ -18,6 +18,7 @@ package api
 
 import (
 	"encoding/json"
+	"math/big"
 
 	"github.com/expanse-project/go-expanse/rpc/shared"
 )
@@ -55,7 +56,7 @@ func (args *UnlockAccountArgs) UnmarshalJSON(b []byte) (err error) {
 		return shared.NewDecodeParamError(err.Error())
 	}
 
-	args.Duration = 0
+	args.Duration = DefaultUnlockDuration
 
 	if len(obj) < 1 {
 		return shared.NewInsufficientParamsError(len(obj), 1)
@@ -76,9 +77,37 @@ func (args *UnlockAccountArgs) UnmarshalJSON(b []byte) (err error) {
 	}
 
 	if len(obj) >= 3 && obj[2] != nil {
-		if duration, ok := obj[2].(float64); ok {
-			args.Duration = int(duration)
+		duration, err := numString(obj[2])
+		if err != nil {
+			return err
 		}
+		if duration.Sign() < 0 || duration.Cmp(big.NewInt(maxUnlockDuration)) > 0 {
+			return shared.NewValidationError("duration", "out of range")
+		}
+		args.Duration = int(duration.Int64())
+	}
+
+	return nil
+}
+
+type LockAccountArgs struct {
+	Address string
+}
+
+func (args *LockAccountArgs) UnmarshalJSON(b []byte) (err error) {
+	var obj []interface{}
+	if err := json.Unmarshal(b, &obj); err != nil {
+		return shared.NewDecodeParamError(err.Error())
+	}
+
+	if len(obj) < 1 {
+		return shared.NewInsufficientParamsError(len(obj), 1)
+	}
+
+	if addrstr, ok := obj[0].(string); ok {
+		args.Address = addrstr
+	} else {
+		return shared.NewInvalidTypeError("address", "not a string")
 	}
 
 	return nil
@@ -33,6 +33,12 @@ web3._extend({
 			call: 'personal_unlockAccount',
 			params: 3,
 			inputFormatter: [null, null, null]
+		}),
+		new web3._extend.Method({
+			name: 'lockAccount',
+			call: 'personal_lockAccount',
+			params: 1,
+			inputFormatter: [null]
 		})
 	],
 	properties:
@@ -40,6 +46,10 @@ web3._extend({
 		new web3._extend.Property({
 			name: 'listAccounts',
 			getter: 'personal_listAccounts'
+		}),
+		new web3._extend.Property({
+			name: 'listAccountsDetailed',
+			getter: 'personal_listAccountsDetailed'
 		})
 	]
 });
@@ -0,0 +1,204 @@
+// Copyright 2015 The go-expanse Authors
+// This file is part of the go-expanse library.
+//
+// The go-expanse library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-expanse library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-expanse library. If not, see <http://www.gnu.org/licenses/>.
+
+package api
+
+import (
+	"github.com/expanse-project/go-expanse/common"
+	"github.com/expanse-project/go-expanse/common/registrar"
+	"github.com/expanse-project/go-expanse/exp"
+	"github.com/expanse-project/go-expanse/rpc/codec"
+	"github.com/expanse-project/go-expanse/rpc/shared"
+	"github.com/expanse-project/go-expanse/xeth"
+)
+
+const (
+	RegistrarApiVersion = "1.0"
+)
+
+var (
+	// mapping between methods and handlers
+	registrarMapping = map[string]registrarhandler{
+		"registrar_setGlobalRegistrar": (*registrarApi).SetGlobalRegistrar,
+		"registrar_setHashReg":         (*registrarApi).SetHashReg,
+		"registrar_setUrlHint":         (*registrarApi).SetUrlHint,
+		"registrar_reserveName":        (*registrarApi).ReserveName,
+		"registrar_setAddress":         (*registrarApi).SetAddress,
+		"registrar_nameToAddr":         (*registrarApi).NameToAddr,
+		"registrar_setHashToHash":      (*registrarApi).SetHashToHash,
+		"registrar_setUrlToHash":       (*registrarApi).SetUrlToHash,
+		"registrar_hashToHash":         (*registrarApi).HashToHash,
+		"registrar_hashToUrl":          (*registrarApi).HashToUrl,
+	}
+)
+
+// registrar callback handler
+type registrarhandler func(*registrarApi, *shared.Request) (interface{}, error)
+
+// registrar api provider, exposes the GlobalRegistrar, HashReg and UrlHint
+// contracts used for name resolution and contract info (NatSpec) lookups
+type registrarApi struct {
+	xeth    *xeth.XEth
+	expanse *exp.Expanse
+	methods map[string]registrarhandler
+	codec   codec.ApiCoder
+}
+
+// create a new registrar api instance
+func NewRegistrarApi(xeth *xeth.XEth, exp *exp.Expanse, coder codec.Codec) *registrarApi {
+	return &registrarApi{
+		xeth:    xeth,
+		expanse: exp,
+		methods: registrarMapping,
+		codec:   coder.New(nil),
+	}
+}
+
+// collection with supported methods
+func (self *registrarApi) Methods() []string {
+	methods := make([]string, len(self.methods))
+	i := 0
+	for k := range self.methods {
+		methods[i] = k
+		i++
+	}
+	return methods
+}
+
+// Execute given request
+func (self *registrarApi) Execute(req *shared.Request) (interface{}, error) {
+	if callback, ok := self.methods[req.Method]; ok {
+		return callback(self, req)
+	}
+
+	return nil, shared.NewNotImplementedError(req.Method)
+}
+
+func (self *registrarApi) Name() string {
+	return shared.RegistrarApiName
+}
+
+func (self *registrarApi) ApiVersion() string {
+	return RegistrarApiVersion
+}
+
+func (self *registrarApi) SetGlobalRegistrar(req *shared.Request) (interface{}, error) {
+	args := new(SetGlobalRegistrarArgs)
+	if err := self.codec.Decode(req.Params, &args); err != nil {
+		return nil, shared.NewDecodeParamError(err.Error())
+	}
+
+	reg := registrar.New(self.xeth)
+	return reg.SetGlobalRegistrar(args.NameReg, common.HexToAddress(args.ContractAddress))
+}
+
+func (self *registrarApi) SetHashReg(req *shared.Request) (interface{}, error) {
+	args := new(SetHashRegArgs)
+	if err := self.codec.Decode(req.Params, &args); err != nil {
+		return nil, shared.NewDecodeParamError(err.Error())
+	}
+
+	reg := registrar.New(self.xeth)
+	return reg.SetHashReg(args.HashReg, common.HexToAddress(args.Sender))
+}
+
+func (self *registrarApi) SetUrlHint(req *shared.Request) (interface{}, error) {
+	args := new(SetUrlHintArgs)
+	if err := self.codec.Decode(req.Params, &args); err != nil {
+		return nil, shared.NewDecodeParamError(err.Error())
+	}
+
+	reg := registrar.New(self.xeth)
+	return reg.SetUrlHint(args.UrlHint, common.HexToAddress(args.Sender))
+}
+
+func (self *registrarApi) ReserveName(req *shared.Request) (interface{}, error) {
+	args := new(RegistrarNameArgs)
+	if err := self.codec.Decode(req.Params, &args); err != nil {
+		return nil, shared.NewDecodeParamError(err.Error())
+	}
+
+	reg := registrar.New(self.xeth)
+	return reg.ReserveName(common.HexToAddress(args.Sender), args.Name)
+}
+
+func (self *registrarApi) SetAddress(req *shared.Request) (interface{}, error) {
+	args := new(RegistrarSetAddressArgs)
+	if err := self.codec.Decode(req.Params, &args); err != nil {
+		return nil, shared.NewDecodeParamError(err.Error())
+	}
+
+	reg := registrar.New(self.xeth)
+	return reg.SetAddressToName(common.HexToAddress(args.Sender), args.Name, common.HexToAddress(args.Address))
+}
+
+func (self *registrarApi) NameToAddr(req *shared.Request) (interface{}, error) {
+	args := new(RegistrarNameArgs)
+	if err := self.codec.Decode(req.Params, &args); err != nil {
+		return nil, shared.NewDecodeParamError(err.Error())
+	}
+
+	reg := registrar.New(self.xeth)
+	addr, err := reg.NameToAddr(common.HexToAddress(args.Sender), args.Name)
+	if err != nil {
+		return nil, err
+	}
+	return addr.Hex(), nil
+}
+
+func (self *registrarApi) SetHashToHash(req *shared.Request) (interface{}, error) {
+	args := new(RegistrarSetHashArgs)
+	if err := self.codec.Decode(req.Params, &args); err != nil {
+		return nil, shared.NewDecodeParamError(err.Error())
+	}
+
+	reg := registrar.New(self.xeth)
+	return reg.SetHashToHash(common.HexToAddress(args.Sender), common.HexToHash(args.Key), common.HexToHash(args.Value))
+}
+
+func (self *registrarApi) SetUrlToHash(req *shared.Request) (interface{}, error) {
+	args := new(RegistrarSetHashArgs)
+	if err := self.codec.Decode(req.Params, &args); err != nil {
+		return nil, shared.NewDecodeParamError(err.Error())
+	}
+
+	reg := registrar.New(self.xeth)
+	return reg.SetUrlToHash(common.HexToAddress(args.Sender), common.HexToHash(args.Key), args.Value)
+}
+
+func (self *registrarApi) HashToHash(req *shared.Request) (interface{}, error) {
+	args := new(RegistrarHashArgs)
+	if err := self.codec.Decode(req.Params, &args); err != nil {
+		return nil, shared.NewDecodeParamError(err.Error())
+	}
+
+	reg := registrar.New(self.xeth)
+	hash, err := reg.HashToHash(common.HexToHash(args.Hash))
+	if err != nil {
+		return nil, err
+	}
+	return hash.Hex(), nil
+}
+
+func (self *registrarApi) HashToUrl(req *shared.Request) (interface{}, error) {
+	args := new(RegistrarHashArgs)
+	if err := self.codec.Decode(req.Params, &args); err != nil {
+		return nil, shared.NewDecodeParamError(err.Error())
+	}
+
+	reg := registrar.New(self.xeth)
+	return reg.HashToUrl(common.HexToHash(args.Hash))
+}
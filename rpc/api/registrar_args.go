@@ -0,0 +1,152 @@
+// Copyright 2015 The go-expanse Authors
+// This file is part of the go-expanse library.
+//
+// The go-expanse library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-expanse library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-expanse library. If not, see <http://www.gnu.org/licenses/>.
+
+package api
+
+import (
+	"encoding/json"
+
+	"github.com/expanse-project/go-expanse/rpc/shared"
+)
+
+type RegistrarNameArgs struct {
+	Sender string
+	Name   string
+}
+
+func (args *RegistrarNameArgs) UnmarshalJSON(b []byte) (err error) {
+	var obj []interface{}
+	if err := json.Unmarshal(b, &obj); err != nil {
+		return shared.NewDecodeParamError(err.Error())
+	}
+
+	if len(obj) < 2 {
+		return shared.NewInsufficientParamsError(len(obj), 2)
+	}
+
+	if sender, ok := obj[0].(string); ok {
+		args.Sender = sender
+	} else {
+		return shared.NewInvalidTypeError("Sender", "not a string")
+	}
+
+	if name, ok := obj[1].(string); ok {
+		args.Name = name
+	} else {
+		return shared.NewInvalidTypeError("Name", "not a string")
+	}
+
+	return nil
+}
+
+type RegistrarSetAddressArgs struct {
+	Sender  string
+	Name    string
+	Address string
+}
+
+func (args *RegistrarSetAddressArgs) UnmarshalJSON(b []byte) (err error) {
+	var obj []interface{}
+	if err := json.Unmarshal(b, &obj); err != nil {
+		return shared.NewDecodeParamError(err.Error())
+	}
+
+	if len(obj) < 3 {
+		return shared.NewInsufficientParamsError(len(obj), 3)
+	}
+
+	if sender, ok := obj[0].(string); ok {
+		args.Sender = sender
+	} else {
+		return shared.NewInvalidTypeError("Sender", "not a string")
+	}
+
+	if name, ok := obj[1].(string); ok {
+		args.Name = name
+	} else {
+		return shared.NewInvalidTypeError("Name", "not a string")
+	}
+
+	if address, ok := obj[2].(string); ok {
+		args.Address = address
+	} else {
+		return shared.NewInvalidTypeError("Address", "not a string")
+	}
+
+	return nil
+}
+
+// RegistrarSetHashArgs holds the sender and key/value pair for registering
+// either a content hash (HashReg) or a url (UrlHint) to a hash.
+type RegistrarSetHashArgs struct {
+	Sender string
+	Key    string
+	Value  string
+}
+
+func (args *RegistrarSetHashArgs) UnmarshalJSON(b []byte) (err error) {
+	var obj []interface{}
+	if err := json.Unmarshal(b, &obj); err != nil {
+		return shared.NewDecodeParamError(err.Error())
+	}
+
+	if len(obj) < 3 {
+		return shared.NewInsufficientParamsError(len(obj), 3)
+	}
+
+	if sender, ok := obj[0].(string); ok {
+		args.Sender = sender
+	} else {
+		return shared.NewInvalidTypeError("Sender", "not a string")
+	}
+
+	if key, ok := obj[1].(string); ok {
+		args.Key = key
+	} else {
+		return shared.NewInvalidTypeError("Key", "not a string")
+	}
+
+	if value, ok := obj[2].(string); ok {
+		args.Value = value
+	} else {
+		return shared.NewInvalidTypeError("Value", "not a string")
+	}
+
+	return nil
+}
+
+type RegistrarHashArgs struct {
+	Hash string
+}
+
+func (args *RegistrarHashArgs) UnmarshalJSON(b []byte) (err error) {
+	var obj []interface{}
+	if err := json.Unmarshal(b, &obj); err != nil {
+		return shared.NewDecodeParamError(err.Error())
+	}
+
+	if len(obj) < 1 {
+		return shared.NewInsufficientParamsError(len(obj), 1)
+	}
+
+	if hash, ok := obj[0].(string); ok {
+		args.Hash = hash
+	} else {
+		return shared.NewInvalidTypeError("Hash", "not a string")
+	}
+
+	return nil
+}
@@ -0,0 +1,89 @@
+// Copyright 2015 The go-expanse Authors
+// This file is part of the go-expanse library.
+//
+// The go-expanse library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-expanse library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-expanse library. If not, see <http://www.gnu.org/licenses/>.
+
+package api
+
+const Registrar_JS = `
+web3._extend({
+	property: 'registrar',
+	methods:
+	[
+		new web3._extend.Method({
+			name: 'setGlobalRegistrar',
+			call: 'registrar_setGlobalRegistrar',
+			params: 2,
+			inputFormatter: [null,null]
+		}),
+		new web3._extend.Method({
+			name: 'setHashReg',
+			call: 'registrar_setHashReg',
+			params: 2,
+			inputFormatter: [null,null]
+		}),
+		new web3._extend.Method({
+			name: 'setUrlHint',
+			call: 'registrar_setUrlHint',
+			params: 2,
+			inputFormatter: [null,null]
+		}),
+		new web3._extend.Method({
+			name: 'reserveName',
+			call: 'registrar_reserveName',
+			params: 2,
+			inputFormatter: [null,null]
+		}),
+		new web3._extend.Method({
+			name: 'setAddress',
+			call: 'registrar_setAddress',
+			params: 3,
+			inputFormatter: [null,null,null]
+		}),
+		new web3._extend.Method({
+			name: 'nameToAddr',
+			call: 'registrar_nameToAddr',
+			params: 2,
+			inputFormatter: [null,null]
+		}),
+		new web3._extend.Method({
+			name: 'setHashToHash',
+			call: 'registrar_setHashToHash',
+			params: 3,
+			inputFormatter: [null,null,null]
+		}),
+		new web3._extend.Method({
+			name: 'setUrlToHash',
+			call: 'registrar_setUrlToHash',
+			params: 3,
+			inputFormatter: [null,null,null]
+		}),
+		new web3._extend.Method({
+			name: 'hashToHash',
+			call: 'registrar_hashToHash',
+			params: 1,
+			inputFormatter: [null]
+		}),
+		new web3._extend.Method({
+			name: 'hashToUrl',
+			call: 'registrar_hashToUrl',
+			params: 1,
+			inputFormatter: [null]
+		})
+	],
+	properties:
+	[
+	]
+});
+`
@@ -17,8 +17,7 @@
 package api
 
 import (
-	"math/big"
-
+	"github.com/expanse-project/go-expanse/common/hexutil"
 	"github.com/expanse-project/go-expanse/exp"
 	"github.com/expanse-project/go-expanse/rpc/codec"
 	"github.com/expanse-project/go-expanse/rpc/shared"
@@ -52,19 +51,19 @@ type shhhandler func(*shhApi, *shared.Request) (interface{}, error)
 
 // shh api provider
 type shhApi struct {
-	xeth     *xeth.XEth
+	xeth    *xeth.XEth
 	expanse *exp.Expanse
-	methods  map[string]shhhandler
-	codec    codec.ApiCoder
+	methods map[string]shhhandler
+	codec   codec.ApiCoder
 }
 
 // create a new whisper api instance
 func NewShhApi(xeth *xeth.XEth, exp *exp.Expanse, coder codec.Codec) *shhApi {
 	return &shhApi{
-		xeth:     xeth,
+		xeth:    xeth,
 		expanse: exp,
-		methods:  shhMapping,
-		codec:    coder.New(nil),
+		methods: shhMapping,
+		codec:   coder.New(nil),
 	}
 }
 
@@ -154,7 +153,7 @@ func (self *shhApi) NewFilter(req *shared.Request) (interface{}, error) {
 	}
 
 	id := self.xeth.NewWhisperFilter(args.To, args.From, args.Topics)
-	return newHexNum(big.NewInt(int64(id)).Bytes()), nil
+	return hexutil.Uint64(id), nil
 }
 
 func (self *shhApi) UninstallFilter(req *shared.Request) (interface{}, error) {
@@ -30,7 +30,10 @@ const (
 var (
 	// mapping between methods and handlers
 	txpoolMapping = map[string]txpoolhandler{
-		"txpool_status": (*txPoolApi).Status,
+		"txpool_status":  (*txPoolApi).Status,
+		"txpool_content": (*txPoolApi).Content,
+		"txpool_inspect": (*txPoolApi).Inspect,
+		"txpool_origins": (*txPoolApi).Origins,
 	}
 )
 
@@ -90,3 +93,27 @@ func (self *txPoolApi) Status(req *shared.Request) (interface{}, error) {
 		"queued":  queue,
 	}, nil
 }
+
+func (self *txPoolApi) Content(req *shared.Request) (interface{}, error) {
+	args := new(TxPoolContentArgs)
+	if err := self.codec.Decode(req.Params, &args); err != nil {
+		return nil, shared.NewDecodeParamError(err.Error())
+	}
+	pending, queued, total := self.expanse.TxPool().GroupedContent(args.filter())
+	return newTxPoolContentRes(pending, queued, total), nil
+}
+
+func (self *txPoolApi) Inspect(req *shared.Request) (interface{}, error) {
+	args := new(TxPoolContentArgs)
+	if err := self.codec.Decode(req.Params, &args); err != nil {
+		return nil, shared.NewDecodeParamError(err.Error())
+	}
+	pending, queued, total := self.expanse.TxPool().GroupedContent(args.filter())
+	return newTxPoolInspectRes(pending, queued, total), nil
+}
+
+// Origins returns the number of transactions the pool admitted and rejected
+// per transport and client, the origins rejected most often first.
+func (self *txPoolApi) Origins(req *shared.Request) (interface{}, error) {
+	return newTxPoolOriginsRes(self.expanse.TxPool().OriginStats()), nil
+}
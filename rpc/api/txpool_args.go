@@ -0,0 +1,104 @@
+// Copyright 2016 The go-expanse Authors
+// This file is part of the go-expanse library.
+//
+// The go-expanse library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-expanse library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-expanse library. If not, see <http://www.gnu.org/licenses/>.
+
+package api
+
+import (
+	"encoding/json"
+	"math/big"
+
+	"github.com/expanse-project/go-expanse/common"
+	"github.com/expanse-project/go-expanse/core"
+	"github.com/expanse-project/go-expanse/rpc/shared"
+)
+
+type TxPoolContentArgs struct {
+	From     *common.Address
+	MinPrice *big.Int
+	Offset   int
+	Limit    int
+}
+
+// maxTxPoolContentLimit is the largest page of pool content served at once.
+const maxTxPoolContentLimit = 10000
+
+func (args *TxPoolContentArgs) UnmarshalJSON(b []byte) (err error) {
+	var obj []struct {
+		From        interface{} `json:"from"`
+		MinGasPrice interface{} `json:"minGasPrice"`
+		Offset      interface{} `json:"offset"`
+		Limit       interface{} `json:"limit"`
+	}
+	if err := json.Unmarshal(b, &obj); err != nil {
+		return shared.NewDecodeParamError(err.Error())
+	}
+
+	if len(obj) < 1 {
+		return nil
+	}
+	if obj[0].From != nil {
+		addstr, ok := obj[0].From.(string)
+		if !ok {
+			return shared.NewInvalidTypeError("from", "not a string")
+		}
+		if len(common.FromHex(addstr)) != len(common.Address{}) {
+			return shared.NewValidationError("from", "not a 20 byte hex address")
+		}
+		from := common.HexToAddress(addstr)
+		args.From = &from
+	}
+	if obj[0].MinGasPrice != nil {
+		if args.MinPrice, err = numString(obj[0].MinGasPrice); err != nil {
+			return err
+		}
+	}
+	if args.Offset, err = txPoolPageParam("offset", obj[0].Offset); err != nil {
+		return err
+	}
+	if args.Limit, err = txPoolPageParam("limit", obj[0].Limit); err != nil {
+		return err
+	}
+	if args.Limit > maxTxPoolContentLimit {
+		return shared.NewValidationError("limit", "larger than 10000")
+	}
+
+	return nil
+}
+
+// filter returns the pool content selected by the arguments.
+func (args *TxPoolContentArgs) filter() core.TxFilter {
+	return core.TxFilter{
+		From:     args.From,
+		MinPrice: args.MinPrice,
+		Offset:   args.Offset,
+		Limit:    args.Limit,
+	}
+}
+
+// txPoolPageParam decodes an optional, non-negative pagination parameter.
+func txPoolPageParam(name string, raw interface{}) (int, error) {
+	if raw == nil {
+		return 0, nil
+	}
+	num, err := numString(raw)
+	if err != nil {
+		return 0, err
+	}
+	if num.Sign() < 0 || num.Cmp(big.NewInt(1<<31-1)) > 0 {
+		return 0, shared.NewValidationError(name, "out of range")
+	}
+	return int(num.Int64()), nil
+}
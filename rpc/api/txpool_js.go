@@ -21,12 +21,28 @@ web3._extend({
 	property: 'txpool',
 	methods:
 	[
+		new web3._extend.Method({
+			name: 'content',
+			call: 'txpool_content',
+			params: 1,
+			inputFormatter: [null]
+		}),
+		new web3._extend.Method({
+			name: 'inspect',
+			call: 'txpool_inspect',
+			params: 1,
+			inputFormatter: [null]
+		})
 	],
 	properties:
 	[
 		new web3._extend.Property({
 			name: 'status',
 			getter: 'txpool_status'
+		}),
+		new web3._extend.Property({
+			name: 'origins',
+			getter: 'txpool_origins'
 		})
 	]
 });
@@ -32,16 +32,22 @@ var (
 	AutoCompletion = map[string][]string{
 		"admin": []string{
 			"addPeer",
+			"addWebhook",
+			"chainSyncStatus",
+			"databaseSize",
 			"datadir",
 			"enableUserAgent",
 			"exportChain",
 			"getContractInfo",
 			"httpGet",
 			"importChain",
+			"networkHeads",
 			"nodeInfo",
 			"peers",
 			"register",
 			"registerUrl",
+			"removePeer",
+			"removeWebhook",
 			"saveInfo",
 			"setGlobalRegistrar",
 			"setHashReg",
@@ -51,9 +57,19 @@ var (
 			"sleepBlocks",
 			"startNatSpec",
 			"startRPC",
+			"startWS",
 			"stopNatSpec",
 			"stopRPC",
+			"stopWS",
 			"verbosity",
+			"webhooks",
+		},
+		"clique": []string{
+			"discard",
+			"getSigners",
+			"getSnapshot",
+			"proposals",
+			"propose",
 		},
 		"db": []string{
 			"getString",
@@ -62,18 +78,26 @@ var (
 			"putHex",
 		},
 		"debug": []string{
+			"compareForks",
 			"dumpBlock",
 			"getBlockRlp",
+			"getModifiedAccountsByNumber",
+			"getTd",
 			"metrics",
 			"printBlock",
 			"processBlock",
 			"seedHash",
 			"setHead",
+			"traceBlockByHash",
+			"traceBlockByNumber",
+			"traceTransaction",
+			"traceTransactionCalls",
 		},
 		"exp": []string{
 			"accounts",
 			"blockNumber",
 			"call",
+			"chainId",
 			"contract",
 			"coinbase",
 			"compile.lll",
@@ -89,6 +113,11 @@ var (
 			"getBlockTransactionCount",
 			"getBlockUncleCount",
 			"getCode",
+			"getConfirmedBalance",
+			"getGasStats",
+			"getInternalTransactions",
+			"getNetworkHashrate",
+			"getUncleStats",
 			"getNatSpec",
 			"getCompilers",
 			"gasPrice",
@@ -102,11 +131,14 @@ var (
 			"mining",
 			"namereg",
 			"pendingTransactions",
+			"releaseNonce",
 			"resend",
+			"reserveNonce",
 			"sendRawTransaction",
 			"sendTransaction",
 			"sign",
 			"syncing",
+			"validateRawTransaction",
 		},
 		"miner": []string{
 			"hashrate",
@@ -125,9 +157,23 @@ var (
 		},
 		"personal": []string{
 			"listAccounts",
+			"listAccountsDetailed",
+			"lockAccount",
 			"newAccount",
 			"unlockAccount",
 		},
+		"registrar": []string{
+			"setGlobalRegistrar",
+			"setHashReg",
+			"setUrlHint",
+			"reserveName",
+			"setAddress",
+			"nameToAddr",
+			"setHashToHash",
+			"setUrlToHash",
+			"hashToHash",
+			"hashToUrl",
+		},
 		"shh": []string{
 			"post",
 			"newIdentity",
@@ -137,6 +183,9 @@ var (
 			"filter",
 		},
 		"txpool": []string{
+			"content",
+			"inspect",
+			"origins",
 			"status",
 		},
 		"web3": []string{
@@ -154,18 +203,53 @@ var (
 )
 
 // Parse a comma separated API string to individual api's
-func ParseApiString(apistr string, codec codec.Codec, xeth *xeth.XEth, exp *exp.Expanse) ([]shared.ExpanseApi, error) {
+// apiAliases maps alternative module names accepted in API lists to the
+// module they select.
+var apiAliases = map[string]string{
+	"eth": shared.EthApiName,
+}
+
+// ParseApiNames splits a comma separated list of API modules into the names of
+// the modules, lower cased and in the given order. Empty entries and unknown
+// modules are rejected with an error listing the available modules.
+func ParseApiNames(apistr string) ([]string, error) {
 	if len(strings.TrimSpace(apistr)) == 0 {
-		return nil, fmt.Errorf("Empty apistr provided")
+		return nil, fmt.Errorf("no API modules given, available modules: %s", shared.AllApis)
 	}
+	known := make(map[string]bool)
+	for _, name := range strings.Split(shared.AllApis, ",") {
+		known[name] = true
+	}
+	var names []string
+	for _, entry := range strings.Split(apistr, ",") {
+		name := strings.ToLower(strings.TrimSpace(entry))
+		if alias, ok := apiAliases[name]; ok {
+			name = alias
+		}
+		if name == "" {
+			return nil, fmt.Errorf("empty API module in list %q", apistr)
+		}
+		if !known[name] {
+			return nil, fmt.Errorf("unknown API module %q, available modules: %s", strings.TrimSpace(entry), shared.AllApis)
+		}
+		names = append(names, name)
+	}
+	return names, nil
+}
 
-	names := strings.Split(apistr, ",")
+func ParseApiString(apistr string, codec codec.Codec, xeth *xeth.XEth, exp *exp.Expanse) ([]shared.ExpanseApi, error) {
+	names, err := ParseApiNames(apistr)
+	if err != nil {
+		return nil, err
+	}
 	apis := make([]shared.ExpanseApi, len(names))
 
 	for i, name := range names {
-		switch strings.ToLower(strings.TrimSpace(name)) {
+		switch name {
 		case shared.AdminApiName:
 			apis[i] = NewAdminApi(xeth, exp, codec)
+		case shared.CliqueApiName:
+			apis[i] = NewCliqueApi(xeth, exp, codec)
 		case shared.DebugApiName:
 			apis[i] = NewDebugApi(xeth, exp, codec)
 		case shared.DbApiName:
@@ -182,6 +266,8 @@ func ParseApiString(apistr string, codec codec.Codec, xeth *xeth.XEth, exp *exp.
 			apis[i] = NewTxPoolApi(xeth, exp, codec)
 		case shared.PersonalApiName:
 			apis[i] = NewPersonalApi(xeth, exp, codec)
+		case shared.RegistrarApiName:
+			apis[i] = NewRegistrarApi(xeth, exp, codec)
 		case shared.Web3ApiName:
 			apis[i] = NewWeb3Api(xeth, codec)
 		default:
@@ -196,6 +282,8 @@ func Javascript(name string) string {
 	switch strings.ToLower(strings.TrimSpace(name)) {
 	case shared.AdminApiName:
 		return Admin_JS
+	case shared.CliqueApiName:
+		return Clique_JS
 	case shared.DebugApiName:
 		return Debug_JS
 	case shared.DbApiName:
@@ -212,6 +300,8 @@ func Javascript(name string) string {
 		return TxPool_JS
 	case shared.PersonalApiName:
 		return Personal_JS
+	case shared.RegistrarApiName:
+		return Registrar_JS
 	}
 
 	return ""
@@ -19,6 +19,7 @@ package codec
 import (
 	"net"
 	"strconv"
+	"time"
 
 	"github.com/expanse-project/go-expanse/rpc/shared"
 )
@@ -35,6 +36,10 @@ type ApiCoder interface {
 	Recv() (interface{}, error)
 	// Encode response to encoded form in underlying stream
 	WriteResponse(interface{}) error
+	// Encode notification to encoded form in underlying stream
+	WriteNotification(*shared.Notification) error
+	// Set the time to wait for the next request, 0 waits forever
+	SetReadTimeout(time.Duration)
 	// Decode single message from data
 	Decode([]byte, interface{}) error
 	// Encode msg to encoded form
@@ -20,36 +20,62 @@ import (
 	"encoding/json"
 	"fmt"
 	"net"
-	"time"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/expanse-project/go-expanse/rpc/shared"
 )
 
 const (
 	READ_TIMEOUT      = 60 // in seconds
+	WRITE_TIMEOUT     = 60 // in seconds
 	MAX_REQUEST_SIZE  = 1024 * 1024
 	MAX_RESPONSE_SIZE = 1024 * 1024
 )
 
-// Json serialization support
+// Json serialization support, responses and notifications may be written
+// concurrently.
 type JsonCodec struct {
 	c net.Conn
 	d *json.Decoder
+
+	timeoutMu   sync.Mutex
+	readTimeout time.Duration
+	wmu         sync.Mutex
 }
 
 // Create new JSON coder instance
 func NewJsonCoder(conn net.Conn) ApiCoder {
 	return &JsonCodec{
-		c: conn,
-		d: json.NewDecoder(conn),
+		c:           conn,
+		d:           json.NewDecoder(conn),
+		readTimeout: READ_TIMEOUT * time.Second,
+	}
+}
+
+// SetReadTimeout sets the time ReadRequest waits for the next request, 0
+// disables the timeout. Disabling it applies to a pending ReadRequest too.
+func (self *JsonCodec) SetReadTimeout(timeout time.Duration) {
+	self.timeoutMu.Lock()
+	defer self.timeoutMu.Unlock()
+
+	self.readTimeout = timeout
+	if timeout == 0 {
+		self.c.SetReadDeadline(time.Time{})
 	}
 }
 
 // Read incoming request and parse it to RPC request
 func (self *JsonCodec) ReadRequest() (requests []*shared.Request, isBatch bool, err error) {
-	deadline := time.Now().Add(READ_TIMEOUT * time.Second)
-	if err := self.c.SetDeadline(deadline); err != nil {
+	self.timeoutMu.Lock()
+	var deadline time.Time
+	if self.readTimeout > 0 {
+		deadline = time.Now().Add(self.readTimeout)
+	}
+	err = self.c.SetReadDeadline(deadline)
+	self.timeoutMu.Unlock()
+	if err != nil {
 		return nil, false, err
 	}
 
@@ -58,8 +84,12 @@ func (self *JsonCodec) ReadRequest() (requests []*shared.Request, isBatch bool,
 	if err == nil {
 		isBatch = incoming[0] == '['
 		if isBatch {
-			requests = make([]*shared.Request, 0)
-			err = json.Unmarshal(incoming, &requests)
+			// Batches which can't be served at all are answered with a single
+			// error, invalid entries of other batches with their own ones.
+			var decodeErr error
+			if requests, decodeErr = shared.DecodeBatch(incoming); decodeErr != nil {
+				requests, isBatch = []*shared.Request{shared.NewInvalidRequest(nil, decodeErr.Error())}, false
+			}
 		} else {
 			requests = make([]*shared.Request, 1)
 			var singleRequest shared.Request
@@ -123,12 +153,27 @@ func (self *JsonCodec) Encode(msg interface{}) ([]byte, error) {
 
 // Parse JSON data from conn to obj
 func (self *JsonCodec) WriteResponse(res interface{}) error {
-	data, err := json.Marshal(res)
+	return self.write(res)
+}
+
+// WriteNotification sends a subscription notification to the client.
+func (self *JsonCodec) WriteNotification(notification *shared.Notification) error {
+	return self.write(notification)
+}
+
+func (self *JsonCodec) write(msg interface{}) error {
+	data, err := json.Marshal(msg)
 	if err != nil {
 		self.c.Close()
 		return err
 	}
 
+	self.wmu.Lock()
+	defer self.wmu.Unlock()
+
+	if err := self.c.SetWriteDeadline(time.Now().Add(WRITE_TIMEOUT * time.Second)); err != nil {
+		return err
+	}
 	bytesWritten := 0
 
 	for bytesWritten < len(data) {
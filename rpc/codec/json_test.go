@@ -128,6 +128,36 @@ func TestJsonDecoderWithValidBatchRequest(t *testing.T) {
 	}
 }
 
+func TestJsonDecoderWithInvalidBatchEntry(t *testing.T) {
+	reqdata := []byte(`[{"jsonrpc":"2.0","method":"modules","params":[],"id":1},{"jsonrpc":"2.0","method":5,"id":2},{"id":3}] []`)
+	jsonDecoder := NewJsonCoder(newJsonTestConn(reqdata))
+
+	// Invalid entries take their place in the batch without failing it
+	requests, batch, err := jsonDecoder.ReadRequest()
+	if err != nil || !batch {
+		t.Fatalf("Read batch request failed - %v (batch %v)", err, batch)
+	}
+	if len(requests) != 3 {
+		t.Fatalf("Expected to get three requests but got %d", len(requests))
+	}
+	if requests[0].Err() != nil {
+		t.Errorf("Valid entry reported invalid - %v", requests[0].Err())
+	}
+	for i, req := range requests[1:] {
+		if req.Err() == nil {
+			t.Errorf("Invalid entry %d not reported", i+1)
+		}
+		if req.Id != float64(i+2) {
+			t.Errorf("Expected req.Id == %d but got %v", i+2, req.Id)
+		}
+	}
+	// Empty batches are a single invalid request
+	requests, batch, err = jsonDecoder.ReadRequest()
+	if err != nil || batch || len(requests) != 1 || requests[0].Err() == nil {
+		t.Errorf("Expected single invalid request for empty batch, got %d requests (batch %v, err %v)", len(requests), batch, err)
+	}
+}
+
 func TestJsonDecoderWithInvalidIncompleteMessage(t *testing.T) {
 	reqdata := []byte(`{"jsonrpc":"2.0","method":"modules","pa`)
 	decoder := newJsonTestConn(reqdata)
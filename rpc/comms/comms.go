@@ -17,6 +17,7 @@
 package comms
 
 import (
+	"context"
 	"io"
 	"net"
 
@@ -24,6 +25,7 @@ import (
 	"strings"
 
 	"strconv"
+	"time"
 
 	"github.com/expanse-project/go-expanse/logger"
 	"github.com/expanse-project/go-expanse/logger/glog"
@@ -33,6 +35,9 @@ import (
 
 const (
 	maxHttpSizeReqLength = 1024 * 1024 // 1MB
+
+	// readTimeout is the time a client without subscriptions may stay idle.
+	readTimeout = codec.READ_TIMEOUT * time.Second
 )
 
 var (
@@ -46,6 +51,11 @@ var (
 	DefaultHttpRpcApis = strings.Join([]string{
 		shared.DbApiName, shared.EthApiName, shared.NetApiName, shared.Web3ApiName,
 	}, ",")
+
+	// List with API's which are offered over the WebSocket interface by default
+	DefaultWsApis = strings.Join([]string{
+		shared.NetApiName, shared.EthApiName, shared.Web3ApiName,
+	}, ",")
 )
 
 type ExpanseClient interface {
@@ -59,61 +69,112 @@ type ExpanseClient interface {
 	SupportedModules() (map[string]string, error)
 }
 
-func handle(id int, conn net.Conn, api shared.ExpanseApi, c codec.Codec) {
+// requestBatch is a set of requests read from a connection.
+type requestBatch struct {
+	requests []*shared.Request
+	isBatch  bool
+}
+
+func handle(id int, conn net.Conn, api shared.ExpanseApi, c codec.Codec, queue *requestQueue) {
 	codec := c.New(conn)
 
+	// Requests are read in the background so a disconnect is noticed while
+	// a request is executing. The context of all requests is cancelled when
+	// the connection goes away.
+	//
+	// Handlers may push notifications to the client through the notifier of
+	// the connection, clients holding subscriptions aren't dropped when idle.
+	notifier := shared.NewNotifier(func(msg interface{}) error {
+		return codec.WriteNotification(msg.(*shared.Notification))
+	}, func(active bool) {
+		if active {
+			codec.SetReadTimeout(0)
+		} else {
+			codec.SetReadTimeout(readTimeout)
+		}
+	})
+	ctx := shared.WithNotifier(context.Background(), notifier)
+	if addr := conn.RemoteAddr(); addr != nil {
+		ctx = shared.WithRemote(ctx, addr.String())
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	batches := make(chan requestBatch)
+	go func() {
+		defer cancel()
+		defer close(batches)
+		for {
+			requests, isBatch, err := codec.ReadRequest()
+			if err == io.EOF {
+				return
+			} else if err != nil {
+				glog.V(logger.Debug).Infof("Closed IPC Conn %06d recv err - %v\n", id, err)
+				return
+			}
+			select {
+			case batches <- requestBatch{requests, isBatch}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
 	defer func() {
 		if r := recover(); r != nil {
 			glog.Errorf("panic: %v\n", r)
 		}
+		cancel()
+		notifier.Close()
 		codec.Close()
 	}()
 
-	for {
-		requests, isBatch, err := codec.ReadRequest()
-		if err == io.EOF {
-			return
-		} else if err != nil {
-			glog.V(logger.Debug).Infof("Closed IPC Conn %06d recv err - %v\n", id, err)
-			return
-		}
-
-		if isBatch {
-			responses := make([]*interface{}, len(requests))
-			responseCount := 0
-			for _, req := range requests {
-				res, err := api.Execute(req)
-				if req.Id != nil {
-					rpcResponse := shared.NewRpcResponse(req.Id, req.Jsonrpc, res, err)
-					responses[responseCount] = rpcResponse
-					responseCount += 1
-				}
-			}
-
-			err = codec.WriteResponse(responses[:responseCount])
+	for batch := range batches {
+		if batch.isBatch {
+			err := codec.WriteResponse(executeBatch(ctx, api, queue, batch.requests))
 			if err != nil {
 				glog.V(logger.Debug).Infof("Closed IPC Conn %06d send err - %v\n", id, err)
 				return
 			}
+			notifier.Activate()
 		} else {
 			var rpcResponse interface{}
-			res, err := api.Execute(requests[0])
+			req := batch.requests[0]
+			res, err := queue.execute(api, req.WithContext(ctx))
 
-			rpcResponse = shared.NewRpcResponse(requests[0].Id, requests[0].Jsonrpc, res, err)
+			rpcResponse = shared.NewRpcResponse(req.Id, req.Jsonrpc, res, err)
 			err = codec.WriteResponse(rpcResponse)
 			if err != nil {
 				glog.V(logger.Debug).Infof("Closed IPC Conn %06d send err - %v\n", id, err)
 				return
 			}
+			notifier.Activate()
+		}
+	}
+}
+
+// executeBatch runs the requests of a batch in order and returns the responses
+// in the same order. Notifications aren't answered, invalid entries are with an
+// error of their own without affecting the rest of the batch.
+func executeBatch(ctx context.Context, api shared.ExpanseApi, queue *requestQueue, requests []*shared.Request) []*interface{} {
+	responses := make([]*interface{}, 0, len(requests))
+	for _, req := range requests {
+		res, err := queue.execute(api, req.WithContext(ctx))
+		if req.Id != nil || req.Err() != nil {
+			responses = append(responses, shared.NewRpcResponse(req.Id, req.Jsonrpc, res, err))
 		}
 	}
+	return responses
 }
 
 // Endpoint must be in the form of:
 // ${protocol}:${path}
 // e.g. ipc:/tmp/gexp.ipc
 //      rpc:localhost:9656
+// or a comma separated list of them, connecting to the first reachable one and
+// failing over to the others (see NewFailoverClient).
 func ClientFromEndpoint(endpoint string, c codec.Codec) (ExpanseClient, error) {
+	if strings.Contains(endpoint, ",") {
+		return NewFailoverClient(strings.Split(endpoint, ","), c)
+	}
 	if strings.HasPrefix(endpoint, "ipc:") {
 		cfg := IpcConfig{
 			Endpoint: endpoint[4:],
@@ -0,0 +1,140 @@
+// Copyright 2016 The go-expanse Authors
+// This file is part of the go-expanse library.
+//
+// The go-expanse library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-expanse library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-expanse library. If not, see <http://www.gnu.org/licenses/>.
+
+package comms
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/expanse-project/go-expanse/rpc/codec"
+	"github.com/expanse-project/go-expanse/rpc/shared"
+)
+
+// testMessage is a response or a notification received by a test client.
+type testMessage struct {
+	Id     interface{}               `json:"id"`
+	Result json.RawMessage           `json:"result"`
+	Method string                    `json:"method"`
+	Params shared.NotificationParams `json:"params"`
+}
+
+func TestSubscriptions(t *testing.T) {
+	stopped := make(chan string, 2)
+	api := &testApi{func(req *shared.Request) (interface{}, error) {
+		notifier, ok := shared.NotifierFromContext(req.Context())
+		if !ok {
+			return nil, fmt.Errorf("no notifier")
+		}
+		switch req.Method {
+		case "test_subscribe":
+			return notifier.Subscribe("test", func(sub *shared.Subscription) {
+				for i := 0; i < 3; i++ {
+					sub.Notify(i)
+				}
+				<-sub.Quit()
+				stopped <- sub.ID
+			})
+		case "test_unsubscribe":
+			var ids []string
+			json.Unmarshal(req.Params, &ids)
+			return notifier.Unsubscribe(ids[0]), nil
+		}
+		return nil, fmt.Errorf("unknown method %s", req.Method)
+	}}
+	server, client := net.Pipe()
+	go handle(0, server, api, codec.JSON, newRequestQueue("test", 0))
+
+	client.SetDeadline(time.Now().Add(5 * time.Second))
+	dec := json.NewDecoder(client)
+	read := func() *testMessage {
+		msg := new(testMessage)
+		if err := dec.Decode(msg); err != nil {
+			t.Fatalf("failed to read message: %v", err)
+		}
+		return msg
+	}
+
+	// The response announcing the subscription precedes its notifications
+	fmt.Fprint(client, `{"jsonrpc":"2.0","id":1,"method":"test_subscribe","params":[]}`)
+	res := read()
+	var id string
+	if err := json.Unmarshal(res.Result, &id); err != nil || res.Id != float64(1) {
+		t.Fatalf("subscribe response mismatch: %+v", res)
+	}
+	for i := 0; i < 3; i++ {
+		msg := read()
+		if msg.Id != nil || msg.Method != "test_subscription" || msg.Params.Subscription != id || msg.Params.Result != float64(i) {
+			t.Fatalf("notification %d mismatch: %+v", i, msg)
+		}
+	}
+	// Unsubscribing stops the subscription, the id can't be reused
+	for _, want := range []string{"true", "false"} {
+		fmt.Fprintf(client, `{"jsonrpc":"2.0","id":2,"method":"test_unsubscribe","params":["%s"]}`, id)
+		if res := read(); string(res.Result) != want {
+			t.Fatalf("unsubscribe response mismatch: have %s, want %s", res.Result, want)
+		}
+	}
+	if stop := <-stopped; stop != id {
+		t.Fatalf("stopped subscription mismatch: have %s, want %s", stop, id)
+	}
+	// Dropping the connection stops the remaining subscriptions
+	fmt.Fprint(client, `{"jsonrpc":"2.0","id":3,"method":"test_subscribe","params":[]}`)
+	if err := json.Unmarshal(read().Result, &id); err != nil {
+		t.Fatalf("failed to subscribe: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		read()
+	}
+	client.Close()
+	select {
+	case stop := <-stopped:
+		if stop != id {
+			t.Fatalf("stopped subscription mismatch: have %s, want %s", stop, id)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("subscription not stopped after disconnect")
+	}
+}
+
+func TestSubscriptionLimit(t *testing.T) {
+	defer func(limit int) { shared.MaxSubscriptions = limit }(shared.MaxSubscriptions)
+	shared.MaxSubscriptions = 2
+
+	n := shared.NewNotifier(func(interface{}) error { return nil }, nil)
+	run := func(sub *shared.Subscription) { <-sub.Quit() }
+	id, err := n.Subscribe("test", run)
+	if err != nil {
+		t.Fatalf("failed to subscribe: %v", err)
+	}
+	if _, err := n.Subscribe("test", run); err != nil {
+		t.Fatalf("failed to subscribe: %v", err)
+	}
+	if _, err := n.Subscribe("test", run); err == nil {
+		t.Fatalf("subscription beyond the limit accepted")
+	}
+	n.Unsubscribe(id)
+	if _, err := n.Subscribe("test", run); err != nil {
+		t.Fatalf("failed to subscribe after unsubscribing: %v", err)
+	}
+	n.Close()
+	if _, err := n.Subscribe("test", run); err == nil {
+		t.Fatalf("subscription on closed notifier accepted")
+	}
+}
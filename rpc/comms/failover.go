@@ -0,0 +1,303 @@
+// Copyright 2016 The go-expanse Authors
+// This file is part of the go-expanse library.
+//
+// The go-expanse library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-expanse library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-expanse library. If not, see <http://www.gnu.org/licenses/>.
+
+package comms
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/expanse-project/go-expanse/logger"
+	"github.com/expanse-project/go-expanse/logger/glog"
+	"github.com/expanse-project/go-expanse/rpc/codec"
+	"github.com/expanse-project/go-expanse/rpc/shared"
+)
+
+var (
+	// Methods installing a filter on the node, by name without the namespace.
+	// They are replayed on the new node after a failover. Subscriptions are
+	// not carried over: their notifications are pushed with the id of the
+	// node, which the client codec can't tell apart from responses.
+	installMethods = map[string]bool{
+		"newFilter":                   true,
+		"newBlockFilter":              true,
+		"newPendingTransactionFilter": true,
+		"newConfirmationFilter":       true,
+		"newDroppedTransactionFilter": true,
+	}
+	// Methods taking the id of an installed filter as their first parameter,
+	// and whether they remove it.
+	idMethods = map[string]bool{
+		"getFilterChanges": false,
+		"getFilterLogs":    false,
+		"getMessages":      false,
+		"uninstallFilter":  true,
+	}
+)
+
+// installation is a filter installed through a failover client.
+type installation struct {
+	req    shared.Request  // Request which installed it, replayed after a failover
+	remote json.RawMessage // Id of the filter on the current node
+}
+
+// failoverClient is an ExpanseClient talking to the first reachable of a list
+// of redundant nodes. When sending to the current node fails, it moves on to
+// the next one, reinstalling the filters created through it. Their ids handed
+// out to the caller stay valid across failovers.
+//
+// Requests are only resent if sending them failed. If the connection is lost
+// while waiting for a response, the request may have been executed, so the
+// error is returned and only the following requests go to the next node.
+type failoverClient struct {
+	endpoints []string
+	dial      func(endpoint string) (ExpanseClient, error)
+	current   int
+	client    ExpanseClient
+
+	last      *shared.Request          // Request awaiting its response
+	installed map[string]*installation // Filters by the id known to the caller
+	nextId    uint64
+}
+
+// NewFailoverClient creates a client connected to the first reachable of the
+// given endpoints, in the format of ClientFromEndpoint.
+func NewFailoverClient(endpoints []string, c codec.Codec) (ExpanseClient, error) {
+	return newFailoverClient(endpoints, func(endpoint string) (ExpanseClient, error) {
+		return ClientFromEndpoint(endpoint, c)
+	})
+}
+
+func newFailoverClient(endpoints []string, dial func(string) (ExpanseClient, error)) (*failoverClient, error) {
+	if len(endpoints) == 0 {
+		return nil, fmt.Errorf("no endpoints given")
+	}
+	self := &failoverClient{
+		endpoints: endpoints,
+		dial:      dial,
+		current:   -1,
+		installed: make(map[string]*installation),
+	}
+	if err := self.failover(); err != nil {
+		return nil, err
+	}
+	return self, nil
+}
+
+// failover connects to the next reachable endpoint after the current one and
+// reinstalls the filters on it.
+func (self *failoverClient) failover() error {
+	if self.client != nil {
+		self.client.Close()
+		self.client = nil
+	}
+	for i := 1; i <= len(self.endpoints); i++ {
+		next := (self.current + i) % len(self.endpoints)
+		endpoint := self.endpoints[next]
+
+		client, err := self.dial(endpoint)
+		if err != nil {
+			glog.V(logger.Warn).Infof("RPC endpoint %s unreachable: %v", endpoint, err)
+			continue
+		}
+		if err := self.reinstall(client); err != nil {
+			glog.V(logger.Warn).Infof("RPC endpoint %s unreachable: %v", endpoint, err)
+			client.Close()
+			continue
+		}
+		if self.current >= 0 {
+			glog.V(logger.Info).Infof("RPC failover to %s", endpoint)
+		}
+		self.current, self.client = next, client
+		return nil
+	}
+	return fmt.Errorf("all RPC endpoints unreachable")
+}
+
+// reinstall replays the requests installing the filters on client.
+// Installations the node rejects are dropped, their ids become unknown.
+func (self *failoverClient) reinstall(client ExpanseClient) error {
+	for id, inst := range self.installed {
+		req := inst.req
+		if err := client.Send(&req); err != nil {
+			return err
+		}
+		res, err := client.Recv()
+		if err != nil {
+			if res == nil {
+				return err
+			}
+			glog.V(logger.Warn).Infof("failed to reinstall %s %s: %v", req.Method, id, err)
+			delete(self.installed, id)
+			continue
+		}
+		success, ok := res.(*shared.SuccessResponse)
+		if !ok {
+			glog.V(logger.Warn).Infof("failed to reinstall %s %s: unexpected response %v", req.Method, id, res)
+			delete(self.installed, id)
+			continue
+		}
+		if inst.remote, err = json.Marshal(success.Result); err != nil {
+			delete(self.installed, id)
+		}
+	}
+	return nil
+}
+
+func (self *failoverClient) Close() {
+	if self.client != nil {
+		self.client.Close()
+	}
+}
+
+// Send sends msg to the current node, failing over to the others until one of
+// them accepts it.
+func (self *failoverClient) Send(msg interface{}) error {
+	req, isRequest := msg.(*shared.Request)
+	if isRequest {
+		self.last = req
+	}
+	var err error
+	for attempt := 0; attempt < len(self.endpoints); attempt++ {
+		if self.client == nil {
+			if err = self.failover(); err != nil {
+				return err
+			}
+		}
+		out := msg
+		if isRequest {
+			out = self.translate(req)
+		}
+		if err = self.client.Send(out); err == nil {
+			return nil
+		}
+		glog.V(logger.Warn).Infof("RPC endpoint %s failed: %v", self.endpoints[self.current], err)
+		self.client.Close()
+		self.client = nil
+	}
+	return err
+}
+
+// Recv receives the response of the last request. If the connection is lost,
+// the next request is sent to another node.
+func (self *failoverClient) Recv() (interface{}, error) {
+	if self.client == nil {
+		return nil, fmt.Errorf("not connected")
+	}
+	res, err := self.client.Recv()
+	if err != nil && res == nil {
+		self.client.Close()
+		self.client = nil
+		self.last = nil
+		return nil, err
+	}
+	if success, ok := res.(*shared.SuccessResponse); ok && self.last != nil {
+		self.track(self.last, success)
+	}
+	if _, ok := res.(*shared.Request); !ok {
+		self.last = nil // agent requests precede the response
+	}
+	return res, err
+}
+
+func (self *failoverClient) SupportedModules() (map[string]string, error) {
+	for attempt := 0; attempt < len(self.endpoints); attempt++ {
+		if self.client == nil {
+			if err := self.failover(); err != nil {
+				return nil, err
+			}
+		}
+		modules, err := self.client.SupportedModules()
+		if err == nil {
+			return modules, nil
+		}
+		self.client.Close()
+		self.client = nil
+	}
+	return nil, fmt.Errorf("all RPC endpoints unreachable")
+}
+
+// translate returns req with the id of the filter it refers to replaced by its
+// id on the current node.
+func (self *failoverClient) translate(req *shared.Request) *shared.Request {
+	if _, ok := idMethods[methodName(req.Method)]; !ok {
+		return req
+	}
+	params, id := firstParam(req.Params)
+	inst := self.installed[id]
+	if inst == nil {
+		return req
+	}
+	params[0] = inst.remote
+	out := *req
+	out.Params, _ = json.Marshal(params)
+	return &out
+}
+
+// track records the filters installed and removed by req, replacing the ids of
+// new ones by ids which remain valid across failovers. The replacements have
+// the JSON type of the original ids, numbers or hex strings.
+func (self *failoverClient) track(req *shared.Request, res *shared.SuccessResponse) {
+	name := methodName(req.Method)
+	if installMethods[name] {
+		remote, err := json.Marshal(res.Result)
+		if err != nil {
+			return
+		}
+		self.nextId++
+		id := fmt.Sprintf("0x%x", self.nextId)
+		self.installed[id] = &installation{req: *req, remote: remote}
+
+		var num uint64
+		if json.Unmarshal(remote, &num) == nil {
+			res.Result = self.nextId
+		} else {
+			res.Result = id
+		}
+		return
+	}
+	if idMethods[name] {
+		if _, id := firstParam(req.Params); id != "" {
+			delete(self.installed, id)
+		}
+	}
+}
+
+// methodName returns the name of method without its namespace.
+func methodName(method string) string {
+	return method[strings.IndexByte(method, '_')+1:]
+}
+
+// firstParam decodes the parameters of a request, returning them along with
+// the first one as an id, or "" if there is none.
+func firstParam(raw json.RawMessage) ([]json.RawMessage, string) {
+	var params []json.RawMessage
+	if err := json.Unmarshal(raw, &params); err != nil || len(params) == 0 {
+		return nil, ""
+	}
+	var (
+		str string
+		num uint64
+	)
+	if err := json.Unmarshal(params[0], &str); err == nil {
+		return params, strings.ToLower(str)
+	}
+	if err := json.Unmarshal(params[0], &num); err == nil {
+		return params, fmt.Sprintf("0x%x", num)
+	}
+	return params, ""
+}
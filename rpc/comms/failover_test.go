@@ -0,0 +1,174 @@
+// Copyright 2016 The go-expanse Authors
+// This file is part of the go-expanse library.
+//
+// The go-expanse library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-expanse library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-expanse library. If not, see <http://www.gnu.org/licenses/>.
+
+package comms
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/expanse-project/go-expanse/rpc/shared"
+)
+
+// failoverTestNode is a node handing out filter ids from its own counter and
+// echoing the filter id of other requests.
+type failoverTestNode struct {
+	down    bool
+	filters map[string]bool
+	nextId  int
+	methods []string
+	res     interface{}
+}
+
+func (n *failoverTestNode) Close() {}
+
+func (n *failoverTestNode) Send(msg interface{}) error {
+	if n.down {
+		return errors.New("connection refused")
+	}
+	req := msg.(*shared.Request)
+	n.methods = append(n.methods, req.Method)
+
+	switch methodName(req.Method) {
+	case "newFilter":
+		n.nextId++
+		id := fmt.Sprintf("0x%x", n.nextId+100*len(n.methods))
+		n.filters[id] = true
+		n.res = &shared.SuccessResponse{Id: req.Id, Result: id}
+	default:
+		_, id := firstParam(req.Params)
+		if !n.filters[id] {
+			n.res = &shared.ErrorResponse{Id: req.Id, Error: &shared.ErrorObject{Message: "filter not found"}}
+		} else {
+			n.res = &shared.SuccessResponse{Id: req.Id, Result: id}
+		}
+	}
+	return nil
+}
+
+func (n *failoverTestNode) Recv() (interface{}, error) {
+	if _, ok := n.res.(*shared.ErrorResponse); ok {
+		return n.res, errors.New("filter not found")
+	}
+	return n.res, nil
+}
+
+func (n *failoverTestNode) SupportedModules() (map[string]string, error) { return nil, nil }
+
+func TestFailoverClient(t *testing.T) {
+	nodes := map[string]*failoverTestNode{
+		"a": {filters: make(map[string]bool)},
+		"b": {filters: make(map[string]bool)},
+	}
+	client, err := newFailoverClient([]string{"a", "b"}, func(endpoint string) (ExpanseClient, error) {
+		if nodes[endpoint].down {
+			return nil, errors.New("connection refused")
+		}
+		return nodes[endpoint], nil
+	})
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	call := func(method string, params ...interface{}) (interface{}, error) {
+		raw, _ := json.Marshal(params)
+		if err := client.Send(&shared.Request{Id: 1, Jsonrpc: "2.0", Method: method, Params: raw}); err != nil {
+			return nil, err
+		}
+		res, err := client.Recv()
+		if err != nil {
+			return nil, err
+		}
+		return res.(*shared.SuccessResponse).Result, nil
+	}
+	// Install a filter on the first node and poll it
+	id, err := call("eth_newFilter", map[string]string{})
+	if err != nil {
+		t.Fatalf("failed to install filter: %v", err)
+	}
+	if _, err := call("eth_getFilterChanges", id); err != nil {
+		t.Fatalf("failed to poll filter: %v", err)
+	}
+	// Once the first node is down, the filter is reinstalled on the second
+	// one and polled there under the same id
+	nodes["a"].down = true
+	if _, err := call("eth_getFilterChanges", id); err != nil {
+		t.Fatalf("failed to poll filter after failover: %v", err)
+	}
+	if want := []string{"eth_newFilter", "eth_getFilterChanges"}; fmt.Sprint(nodes["b"].methods) != fmt.Sprint(want) {
+		t.Errorf("second node requests mismatch: have %v, want %v", nodes["b"].methods, want)
+	}
+	// Uninstalled filters aren't reinstalled anymore
+	if _, err := call("eth_uninstallFilter", id); err != nil {
+		t.Fatalf("failed to uninstall filter: %v", err)
+	}
+	if len(client.installed) != 0 {
+		t.Errorf("uninstalled filter still tracked")
+	}
+	// With all nodes down requests fail, until one comes back
+	nodes["b"].down = true
+	if _, err := call("eth_newFilter", map[string]string{}); err == nil {
+		t.Fatalf("request succeeded without reachable nodes")
+	}
+	nodes["a"].down = false
+	if _, err := call("eth_newFilter", map[string]string{}); err != nil {
+		t.Fatalf("failed to reconnect: %v", err)
+	}
+}
+
+// Tests that filter ids keep their JSON type and subscriptions aren't tracked.
+func TestFailoverIds(t *testing.T) {
+	client := &failoverClient{installed: make(map[string]*installation)}
+
+	res := &shared.SuccessResponse{Result: 42}
+	client.track(&shared.Request{Method: "eth_newFilter"}, res)
+	if blob, _ := json.Marshal(res.Result); string(blob) != "1" {
+		t.Errorf("numeric filter id mismatch: have %s, want 1", blob)
+	}
+	res = &shared.SuccessResponse{Result: "0x2a"}
+	client.track(&shared.Request{Method: "eth_newBlockFilter"}, res)
+	if blob, _ := json.Marshal(res.Result); string(blob) != `"0x2"` {
+		t.Errorf("string filter id mismatch: have %s, want \"0x2\"", blob)
+	}
+	res = &shared.SuccessResponse{Result: "0x2a"}
+	client.track(&shared.Request{Method: "eth_subscribe"}, res)
+	if res.Result != "0x2a" || len(client.installed) != 2 {
+		t.Errorf("subscription tracked: result %v, %d installations", res.Result, len(client.installed))
+	}
+}
+
+// rejectingTestNode answers every request with a response carrying an error.
+type rejectingTestNode struct{ failoverTestNode }
+
+func (n *rejectingTestNode) Recv() (interface{}, error) {
+	return &shared.SuccessResponse{Result: "0x1"}, errors.New("rejected")
+}
+
+// Tests that installations the new node rejects are dropped, even when the
+// error comes with a response.
+func TestFailoverReinstallRejected(t *testing.T) {
+	client := &failoverClient{installed: map[string]*installation{
+		"0x1": {req: shared.Request{Method: "eth_newFilter"}},
+	}}
+	node := &rejectingTestNode{failoverTestNode{filters: make(map[string]bool)}}
+	if err := client.reinstall(node); err != nil {
+		t.Fatalf("reinstall failed: %v", err)
+	}
+	if len(client.installed) != 0 {
+		t.Errorf("rejected installation still tracked")
+	}
+}
@@ -17,6 +17,8 @@
 package comms
 
 import (
+	"context"
+	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"net"
@@ -39,7 +41,7 @@ import (
 const (
 	serverIdleTimeout  = 10 * time.Second // idle keep-alive connections
 	serverReadTimeout  = 15 * time.Second // per-request read timeout
-	serverWriteTimeout = 15 * time.Second // per-request read timeout
+	serverWriteTimeout = 15 * time.Second // per-request write timeout
 )
 
 var (
@@ -51,6 +53,36 @@ type HttpConfig struct {
 	ListenAddress string
 	ListenPort    uint
 	CorsDomain    string
+	MaxPending    int // requests queued or executing, 0 for the default
+
+	ReadTimeout    time.Duration // per-request read timeout, 0 for the default
+	WriteTimeout   time.Duration // per-request write timeout, 0 for the default
+	IdleTimeout    time.Duration // idle keep-alive connection timeout, 0 for the default
+	MaxRequestSize int64         // maximum request body size in bytes, 0 for the default
+
+	// Serve over TLS if a certificate is set, requiring clients to authenticate
+	// with one of the certificates stored in ClientCertDir.
+	TLSCert       string // server certificate file (PEM)
+	TLSKey        string // server private key file (PEM)
+	ClientCertDir string // directory of allowed client certificates (PEM)
+}
+
+// withDefaults returns a copy of the config with the unset limits replaced by
+// their defaults.
+func (cfg HttpConfig) withDefaults() HttpConfig {
+	if cfg.ReadTimeout <= 0 {
+		cfg.ReadTimeout = serverReadTimeout
+	}
+	if cfg.WriteTimeout <= 0 {
+		cfg.WriteTimeout = serverWriteTimeout
+	}
+	if cfg.IdleTimeout <= 0 {
+		cfg.IdleTimeout = serverIdleTimeout
+	}
+	if cfg.MaxRequestSize <= 0 {
+		cfg.MaxRequestSize = maxHttpSizeReqLength
+	}
+	return cfg
 }
 
 // stopServer augments http.Server with idle connection tracking.
@@ -65,8 +97,11 @@ type stopServer struct {
 }
 
 type handler struct {
-	codec codec.Codec
-	api   shared.ExpanseApi
+	codec        codec.Codec
+	api          shared.ExpanseApi
+	queue        *requestQueue
+	maxSize      int64         // maximum request body size
+	writeTimeout time.Duration // deadline of request processing
 }
 
 // StartHTTP starts listening for RPC requests sent via HTTP.
@@ -81,8 +116,19 @@ func StartHttp(cfg HttpConfig, codec codec.Codec, api shared.ExpanseApi) error {
 		}
 		return nil // RPC service already running on given host/port
 	}
+	cfg = cfg.withDefaults()
+
+	var tlsConfig *tls.Config
+	if cfg.TLSCert != "" {
+		var err error
+		if tlsConfig, err = newTLSConfig(cfg.TLSCert, cfg.TLSKey, cfg.ClientCertDir); err != nil {
+			glog.V(logger.Error).Infof("Can't set up TLS for %s:%d: %v", cfg.ListenAddress, cfg.ListenPort, err)
+			return err
+		}
+	}
+
 	// Set up the request handler, wrapping it with CORS headers if configured.
-	handler := http.Handler(&handler{codec, api})
+	handler := http.Handler(&handler{codec, api, newRequestQueue("HTTP", cfg.MaxPending), cfg.MaxRequestSize, cfg.WriteTimeout})
 	if len(cfg.CorsDomain) > 0 {
 		opts := cors.Options{
 			AllowedMethods: []string{"POST"},
@@ -91,7 +137,7 @@ func StartHttp(cfg HttpConfig, codec codec.Codec, api shared.ExpanseApi) error {
 		handler = cors.New(opts).Handler(handler)
 	}
 	// Start the server.
-	s, err := listenHTTP(addr, handler)
+	s, err := listenHTTP(addr, handler, cfg, tlsConfig)
 	if err != nil {
 		glog.V(logger.Error).Infof("Can't listen on %s:%d: %v", cfg.ListenAddress, cfg.ListenPort, err)
 		return err
@@ -103,8 +149,9 @@ func StartHttp(cfg HttpConfig, codec codec.Codec, api shared.ExpanseApi) error {
 func (h *handler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
-	// Limit request size to resist DoS
-	if req.ContentLength > maxHttpSizeReqLength {
+	// Limit request size to resist DoS. Requests announcing a too large body
+	// are rejected before reading it, others once the limit is exceeded.
+	if req.ContentLength > h.maxSize {
 		err := fmt.Errorf("Request too large")
 		response := shared.NewRpcErrorResponse(-1, shared.JsonRpcVersion, -32700, err)
 		sendJSON(w, &response)
@@ -112,36 +159,34 @@ func (h *handler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 	}
 
 	defer req.Body.Close()
-	payload, err := ioutil.ReadAll(req.Body)
-	if err != nil {
-		err := fmt.Errorf("Could not read request body")
+	payload, err := ioutil.ReadAll(io.LimitReader(req.Body, h.maxSize+1))
+	if err != nil || int64(len(payload)) > h.maxSize {
+		if err != nil {
+			err = fmt.Errorf("Could not read request body")
+		} else {
+			err = fmt.Errorf("Request too large")
+		}
 		response := shared.NewRpcErrorResponse(-1, shared.JsonRpcVersion, -32700, err)
 		sendJSON(w, &response)
 		return
 	}
 
+	// Abort request processing if the client goes away or the
+	// response can't be written anymore.
+	ctx, cancel := context.WithTimeout(shared.WithRemote(req.Context(), req.RemoteAddr), h.writeTimeout)
+	defer cancel()
+
 	c := h.codec.New(nil)
 	var rpcReq shared.Request
 	if err = c.Decode(payload, &rpcReq); err == nil {
-		reply, err := h.api.Execute(&rpcReq)
+		reply, err := h.queue.execute(h.api, rpcReq.WithContext(ctx))
 		res := shared.NewRpcResponse(rpcReq.Id, rpcReq.Jsonrpc, reply, err)
 		sendJSON(w, &res)
 		return
 	}
 
-	var reqBatch []shared.Request
-	if err = c.Decode(payload, &reqBatch); err == nil {
-		resBatch := make([]*interface{}, len(reqBatch))
-		resCount := 0
-		for i, rpcReq := range reqBatch {
-			reply, err := h.api.Execute(&rpcReq)
-			if rpcReq.Id != nil { // this leaves nil entries in the response batch for later removal
-				resBatch[i] = shared.NewRpcResponse(rpcReq.Id, rpcReq.Jsonrpc, reply, err)
-				resCount += 1
-			}
-		}
-		// make response omitting nil entries
-		sendJSON(w, resBatch[:resCount])
+	if reqBatch, err := shared.DecodeBatch(payload); err == nil {
+		sendJSON(w, executeBatch(ctx, h.api, h.queue, reqBatch))
 		return
 	}
 
@@ -172,17 +217,21 @@ func StopHttp() {
 	}
 }
 
-func listenHTTP(addr string, h http.Handler) (*stopServer, error) {
+func listenHTTP(addr string, h http.Handler, cfg HttpConfig, tlsConfig *tls.Config) (*stopServer, error) {
 	l, err := net.Listen("tcp", addr)
 	if err != nil {
 		return nil, err
 	}
+	if tlsConfig != nil {
+		l = tls.NewListener(l, tlsConfig)
+	}
 	s := &stopServer{l: l, idle: make(map[net.Conn]struct{})}
 	s.Server = &http.Server{
 		Addr:         addr,
 		Handler:      h,
-		ReadTimeout:  serverReadTimeout,
-		WriteTimeout: serverWriteTimeout,
+		ReadTimeout:  cfg.ReadTimeout,
+		WriteTimeout: cfg.WriteTimeout,
+		IdleTimeout:  cfg.IdleTimeout,
 		ConnState:    s.connState,
 	}
 	go s.Serve(l)
@@ -0,0 +1,130 @@
+// Copyright 2016 The go-expanse Authors
+// This file is part of the go-expanse library.
+//
+// The go-expanse library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-expanse library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-expanse library. If not, see <http://www.gnu.org/licenses/>.
+
+package comms
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/expanse-project/go-expanse/rpc/codec"
+	"github.com/expanse-project/go-expanse/rpc/shared"
+)
+
+func TestHttpRequestSizeLimit(t *testing.T) {
+	api := &testApi{func(*shared.Request) (interface{}, error) {
+		return "pong", nil
+	}}
+	cfg := HttpConfig{MaxRequestSize: 64}.withDefaults()
+	h := &handler{codec.JSON, api, newRequestQueue("test", 0), cfg.MaxRequestSize, cfg.WriteTimeout}
+
+	// Oversized requests must be rejected both when announcing their size
+	// and when streaming the body without a content length.
+	large := `{"jsonrpc":"2.0","id":1,"method":"test_call","params":["` + strings.Repeat("a", 64) + `"]}`
+	for _, chunked := range []bool{false, true} {
+		req := httptest.NewRequest("POST", "/", strings.NewReader(large))
+		if chunked {
+			req.ContentLength = -1
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if res := rec.Body.String(); !strings.Contains(res, "Request too large") {
+			t.Errorf("chunked %v: response mismatch: have %s, want request too large error", chunked, res)
+		}
+	}
+}
+
+func TestHttpRemote(t *testing.T) {
+	var remote string
+	api := &testApi{func(req *shared.Request) (interface{}, error) {
+		remote = shared.RemoteFromContext(req.Context())
+		return "pong", nil
+	}}
+	cfg := HttpConfig{}.withDefaults()
+	h := &handler{codec.JSON, api, newRequestQueue("test", 0), cfg.MaxRequestSize, cfg.WriteTimeout}
+
+	// The client is identified by its host, whichever port it connects from
+	req := httptest.NewRequest("POST", "/", strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"test_call"}`))
+	req.RemoteAddr = "192.0.2.1:54321"
+	h.ServeHTTP(httptest.NewRecorder(), req)
+	if remote != "192.0.2.1" {
+		t.Errorf("remote mismatch: have %q, want %q", remote, "192.0.2.1")
+	}
+}
+
+func TestHttpBatch(t *testing.T) {
+	api := &testApi{func(req *shared.Request) (interface{}, error) {
+		if req.Method == "test_fail" {
+			return nil, shared.NewNotImplementedError(req.Method)
+		}
+		return req.Method, nil
+	}}
+	cfg := HttpConfig{}.withDefaults()
+	h := &handler{codec.JSON, api, newRequestQueue("test", 0), cfg.MaxRequestSize, cfg.WriteTimeout}
+
+	// Responses keep the order of the requests, notifications aren't answered
+	// and failing or malformed entries don't affect the others
+	batch := `[{"jsonrpc":"2.0","id":1,"method":"test_a"},{"jsonrpc":"2.0","method":"test_notify"},` +
+		`{"jsonrpc":"2.0","id":"x","method":"test_fail"},{"jsonrpc":"2.0","id":3,"method":7},5,{"jsonrpc":"2.0","id":4,"method":"test_b"}]`
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("POST", "/", strings.NewReader(batch)))
+
+	var res []struct {
+		Id     interface{}
+		Result string
+		Error  *shared.ErrorObject
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &res); err != nil {
+		t.Fatalf("invalid batch response %s: %v", rec.Body, err)
+	}
+	want := []struct {
+		id     interface{}
+		result string
+		code   int
+	}{
+		{float64(1), "test_a", 0}, {"x", "", -32601}, {float64(3), "", -32600}, {nil, "", -32600}, {float64(4), "test_b", 0},
+	}
+	if len(res) != len(want) {
+		t.Fatalf("response count mismatch: have %d, want %d: %s", len(res), len(want), rec.Body)
+	}
+	for i, w := range want {
+		code := 0
+		if res[i].Error != nil {
+			code = res[i].Error.Code
+		}
+		if res[i].Id != w.id || res[i].Result != w.result || code != w.code {
+			t.Errorf("response %d mismatch: have id %v result %q code %d, want id %v result %q code %d", i, res[i].Id, res[i].Result, code, w.id, w.result, w.code)
+		}
+	}
+	// Empty batches are answered with a single error
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("POST", "/", strings.NewReader("[]")))
+	if body := rec.Body.String(); !strings.Contains(body, "-32600") || strings.HasPrefix(body, "[") {
+		t.Errorf("empty batch response mismatch: %s", body)
+	}
+}
+
+func TestHttpConfigDefaults(t *testing.T) {
+	cfg := HttpConfig{}.withDefaults()
+	if cfg.ReadTimeout != serverReadTimeout || cfg.WriteTimeout != serverWriteTimeout || cfg.IdleTimeout != serverIdleTimeout {
+		t.Errorf("timeout defaults mismatch: have %v/%v/%v", cfg.ReadTimeout, cfg.WriteTimeout, cfg.IdleTimeout)
+	}
+	if cfg.MaxRequestSize != maxHttpSizeReqLength {
+		t.Errorf("request size default mismatch: have %d, want %d", cfg.MaxRequestSize, maxHttpSizeReqLength)
+	}
+}
@@ -33,10 +33,14 @@ type Stopper interface {
 	Stop()
 }
 
+// InitFunc creates the API serving the requests of a connection. Every
+// connection has its own API, so state like installed filters isn't shared
+// between clients. The Stopper is stopped once the connection is closed.
 type InitFunc func(conn net.Conn) (Stopper, shared.ExpanseApi, error)
 
 type IpcConfig struct {
-	Endpoint string
+	Endpoint   string
+	MaxPending int // requests queued or executing over all connections, 0 for the default
 }
 
 type ipcClient struct {
@@ -109,6 +113,7 @@ func StartIpc(cfg IpcConfig, codec codec.Codec, initializer InitFunc) error {
 
 func ipcLoop(cfg IpcConfig, codec codec.Codec, initializer InitFunc, l net.Listener) {
 	glog.V(logger.Info).Infof("IPC service started (%s)\n", cfg.Endpoint)
+	queue := newRequestQueue("IPC", cfg.MaxPending)
 	defer os.Remove(cfg.Endpoint)
 	defer l.Close()
 	for {
@@ -127,7 +132,7 @@ func ipcLoop(cfg IpcConfig, codec codec.Codec, initializer InitFunc, l net.Liste
 				return
 			}
 			defer stopper.Stop()
-			handle(id, conn, api, codec)
+			handle(id, conn, api, codec, queue)
 		}()
 	}
 }
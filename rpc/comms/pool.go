@@ -0,0 +1,115 @@
+// Copyright 2016 The go-expanse Authors
+// This file is part of the go-expanse library.
+//
+// The go-expanse library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-expanse library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-expanse library. If not, see <http://www.gnu.org/licenses/>.
+
+package comms
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+	"sync/atomic"
+
+	"github.com/expanse-project/go-expanse/logger"
+	"github.com/expanse-project/go-expanse/logger/glog"
+	"github.com/expanse-project/go-expanse/rpc/shared"
+)
+
+var (
+	// Number of workers executing RPC requests, shared by all transports
+	RpcWorkers = 4 * runtime.NumCPU()
+
+	// Default number of requests a transport may have queued or executing
+	DefaultMaxPending = 256
+)
+
+var (
+	workersOnce sync.Once
+	jobs        chan *job
+)
+
+// job is a single API request waiting to be executed by a worker.
+type job struct {
+	api  shared.ExpanseApi
+	req  *shared.Request
+	res  interface{}
+	err  error
+	done chan struct{}
+}
+
+// requestQueue limits the number of requests a transport may have waiting for
+// or running on the shared worker pool. Requests beyond the limit are rejected
+// with an overload error instead of piling up in memory.
+type requestQueue struct {
+	transport string
+	limit     int32
+	pending   int32
+}
+
+func newRequestQueue(transport string, limit int) *requestQueue {
+	if limit <= 0 {
+		limit = DefaultMaxPending
+	}
+	return &requestQueue{transport: transport, limit: int32(limit)}
+}
+
+// execute runs req on the worker pool and waits for its result. The request
+// is abandoned if its context is cancelled before a worker picks it up.
+func (q *requestQueue) execute(api shared.ExpanseApi, req *shared.Request) (interface{}, error) {
+	if err := req.Err(); err != nil {
+		return nil, err
+	}
+	if atomic.AddInt32(&q.pending, 1) > q.limit {
+		atomic.AddInt32(&q.pending, -1)
+		return nil, shared.NewOverloadedError(q.transport)
+	}
+	defer atomic.AddInt32(&q.pending, -1)
+
+	workersOnce.Do(startWorkers)
+	j := &job{api: api, req: req, done: make(chan struct{})}
+	select {
+	case jobs <- j:
+	case <-req.Context().Done():
+		return nil, req.Context().Err()
+	}
+	<-j.done
+	return j.res, j.err
+}
+
+func startWorkers() {
+	jobs = make(chan *job)
+	for i := 0; i < RpcWorkers; i++ {
+		go worker()
+	}
+}
+
+func worker() {
+	for j := range jobs {
+		j.res, j.err = run(j.api, j.req)
+		close(j.done)
+	}
+}
+
+// run executes a single request, turning a panic into an error so a faulty
+// API method can't take down the worker.
+func run(api shared.ExpanseApi, req *shared.Request) (res interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			glog.V(logger.Error).Infof("panic while executing %s: %v\n", req.Method, r)
+			res, err = nil, fmt.Errorf("internal error executing %s", req.Method)
+		}
+	}()
+	return api.Execute(req)
+}
@@ -0,0 +1,100 @@
+// Copyright 2016 The go-expanse Authors
+// This file is part of the go-expanse library.
+//
+// The go-expanse library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-expanse library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-expanse library. If not, see <http://www.gnu.org/licenses/>.
+
+package comms
+
+import (
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/expanse-project/go-expanse/rpc/shared"
+)
+
+// testApi executes requests by calling fn.
+type testApi struct {
+	fn func(*shared.Request) (interface{}, error)
+}
+
+func (api *testApi) Name() string       { return "test" }
+func (api *testApi) ApiVersion() string { return "1.0" }
+func (api *testApi) Methods() []string  { return []string{"test_call"} }
+
+func (api *testApi) Execute(req *shared.Request) (interface{}, error) {
+	return api.fn(req)
+}
+
+// testStopper calls itself when stopped.
+type testStopper func()
+
+func (fn testStopper) Stop() { fn() }
+
+// testInit returns an InitFunc serving all connections with api.
+func testInit(api shared.ExpanseApi) InitFunc {
+	return func(net.Conn) (Stopper, shared.ExpanseApi, error) {
+		return testStopper(func() {}), api, nil
+	}
+}
+
+func TestRequestQueueOverload(t *testing.T) {
+	release := make(chan struct{})
+	api := &testApi{func(*shared.Request) (interface{}, error) {
+		<-release
+		return true, nil
+	}}
+	queue := newRequestQueue("test", 2)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if res, err := queue.execute(api, &shared.Request{Method: "test_call"}); err != nil || res != true {
+				t.Errorf("queued request failed: res %v, err %v", res, err)
+			}
+		}()
+	}
+	for atomic.LoadInt32(&queue.pending) < 2 {
+		time.Sleep(time.Millisecond)
+	}
+	if _, err := queue.execute(api, &shared.Request{Method: "test_call"}); err == nil {
+		t.Fatalf("request beyond the queue limit accepted")
+	} else if _, ok := err.(*shared.OverloadedError); !ok {
+		t.Fatalf("error type mismatch: have %T, want *shared.OverloadedError", err)
+	}
+	close(release)
+	wg.Wait()
+
+	if res, err := queue.execute(api, &shared.Request{Method: "test_call"}); err != nil || res != true {
+		t.Errorf("request after draining failed: res %v, err %v", res, err)
+	}
+}
+
+func TestRequestQueuePanic(t *testing.T) {
+	api := &testApi{func(*shared.Request) (interface{}, error) {
+		panic("boom")
+	}}
+	queue := newRequestQueue("test", 0)
+
+	if _, err := queue.execute(api, &shared.Request{Method: "test_call"}); err == nil {
+		t.Fatalf("panicking request returned no error")
+	}
+	if queue.pending != 0 {
+		t.Errorf("pending count mismatch: have %d, want 0", queue.pending)
+	}
+}
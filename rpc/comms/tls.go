@@ -0,0 +1,102 @@
+// Copyright 2016 The go-expanse Authors
+// This file is part of the go-expanse library.
+//
+// The go-expanse library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-expanse library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-expanse library. If not, see <http://www.gnu.org/licenses/>.
+
+package comms
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+)
+
+// errUnknownClientCert is returned by the TLS handshake if a client presents a
+// certificate which isn't in the allowed set.
+var errUnknownClientCert = errors.New("client certificate not allowed")
+
+// loadClientCerts reads the PEM encoded certificates of all files in dir. These
+// are the certificates clients are allowed to authenticate with.
+func loadClientCerts(dir string) ([]*x509.Certificate, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var certs []*x509.Certificate
+	for _, file := range files {
+		if file.IsDir() {
+			continue
+		}
+		path := filepath.Join(dir, file.Name())
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		for {
+			var block *pem.Block
+			if block, data = pem.Decode(data); block == nil {
+				break
+			}
+			if block.Type != "CERTIFICATE" {
+				continue
+			}
+			cert, err := x509.ParseCertificate(block.Bytes)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %v", path, err)
+			}
+			certs = append(certs, cert)
+		}
+	}
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("no client certificates in %s", dir)
+	}
+	return certs, nil
+}
+
+// newTLSConfig creates the TLS configuration of an RPC server using the given
+// certificate and key. Clients must authenticate with one of the certificates
+// found in clientDir. These are pinned: a certificate issued by an allowed
+// one is rejected, so both self-signed and CA issued client certificates can
+// be listed.
+func newTLSConfig(certFile, keyFile, clientDir string) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, err
+	}
+	clients, err := loadClientCerts(clientDir)
+	if err != nil {
+		return nil, err
+	}
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ClientAuth:   tls.RequireAnyClientCert,
+		MinVersion:   tls.VersionTLS12,
+		VerifyPeerCertificate: func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+			if len(rawCerts) == 0 {
+				return errUnknownClientCert
+			}
+			for _, client := range clients {
+				if bytes.Equal(rawCerts[0], client.Raw) {
+					return nil
+				}
+			}
+			return errUnknownClientCert
+		},
+	}, nil
+}
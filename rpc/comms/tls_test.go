@@ -0,0 +1,133 @@
+// Copyright 2016 The go-expanse Authors
+// This file is part of the go-expanse library.
+//
+// The go-expanse library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-expanse library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-expanse library. If not, see <http://www.gnu.org/licenses/>.
+
+package comms
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// newTestCert creates a self-signed certificate for 127.0.0.1, writing it and
+// its key in PEM format into dir.
+func newTestCert(t *testing.T, dir, name string) (tls.Certificate, string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer})
+
+	certFile, keyFile := filepath.Join(dir, name+".pem"), filepath.Join(dir, name+".key")
+	if err := ioutil.WriteFile(certFile, certPEM, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(keyFile, keyPEM, 0600); err != nil {
+		t.Fatal(err)
+	}
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert, certFile, keyFile
+}
+
+func TestHttpClientCertAuth(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rpctls")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	clientDir := filepath.Join(dir, "rpcclients")
+	if err := os.Mkdir(clientDir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	server, serverCert, serverKey := newTestCert(t, dir, "server")
+	allowed, _, _ := newTestCert(t, clientDir, "allowed")
+	unknown, _, _ := newTestCert(t, dir, "unknown")
+
+	// Without allowed client certificates, TLS can't be configured.
+	if _, err := newTLSConfig(serverCert, serverKey, dir+"-missing"); err == nil {
+		t.Fatal("expected error for missing client certificate directory")
+	}
+	tlsConfig, err := newTLSConfig(serverCert, serverKey, clientDir)
+	if err != nil {
+		t.Fatalf("failed to create TLS config: %v", err)
+	}
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	s, err := listenHTTP("127.0.0.1:0", handler, HttpConfig{}.withDefaults(), tlsConfig)
+	if err != nil {
+		t.Fatalf("failed to start server: %v", err)
+	}
+	defer s.Close()
+
+	leaf, err := x509.ParseCertificate(server.Certificate[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	roots := x509.NewCertPool()
+	roots.AddCert(leaf)
+	tests := []struct {
+		name  string
+		certs []tls.Certificate
+		ok    bool
+	}{
+		{"allowed", []tls.Certificate{allowed}, true},
+		{"unknown", []tls.Certificate{unknown}, false},
+		{"none", nil, false},
+	}
+	for _, tt := range tests {
+		client := &http.Client{Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{RootCAs: roots, Certificates: tt.certs},
+		}}
+		resp, err := client.Post("https://"+s.l.Addr().String(), "application/json", nil)
+		if err == nil {
+			resp.Body.Close()
+		}
+		if (err == nil) != tt.ok {
+			t.Errorf("%s: request result mismatch: have error %v, want success %v", tt.name, err, tt.ok)
+		}
+	}
+}
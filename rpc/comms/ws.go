@@ -0,0 +1,550 @@
+// Copyright 2016 The go-expanse Authors
+// This file is part of the go-expanse library.
+//
+// The go-expanse library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-expanse library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-expanse library. If not, see <http://www.gnu.org/licenses/>.
+
+package comms
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/expanse-project/go-expanse/logger"
+	"github.com/expanse-project/go-expanse/logger/glog"
+	"github.com/expanse-project/go-expanse/rpc/codec"
+)
+
+// WebSocket frame opcodes (RFC 6455, section 5.2).
+const (
+	wsContinuation = 0x0
+	wsText         = 0x1
+	wsBinary       = 0x2
+	wsClose        = 0x8
+	wsPing         = 0x9
+	wsPong         = 0xa
+)
+
+// wsRsv1 is the frame header bit marking the first frame of a compressed
+// message once permessage-deflate was negotiated (RFC 7692, section 6).
+const wsRsv1 = 0x40
+
+// wsDeflateTail is stripped from the end of every compressed message by the
+// sender and has to be appended again before inflating it.
+var wsDeflateTail = []byte{0x00, 0x00, 0xff, 0xff}
+
+// wsGUID is appended to the client key to compute the handshake response.
+const wsGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+var (
+	wsServerMu sync.Mutex
+	wsServer   *wsListener
+
+	errWsUnmasked      = errors.New("websocket: unmasked client frame")
+	errWsFrameTooLarge = errors.New("websocket: frame too large")
+	errWsMsgTooLarge   = errors.New("websocket: message too large")
+	errWsCompressed    = errors.New("websocket: unexpected compressed frame")
+)
+
+type WsConfig struct {
+	ListenAddress string
+	ListenPort    uint
+	Origins       string // space or comma separated browser origins allowed to connect, "*" for any
+	MaxPending    int    // requests queued or executing over all connections, 0 for the default
+	MaxFrameSize  int    // maximum payload of a received data frame, 0 for the default
+	Compression   bool   // whether permessage-deflate is offered to clients
+}
+
+// wsListener accepts WebSocket connections and tracks them, so they can be
+// dropped when the service is stopped.
+type wsListener struct {
+	*stopServer
+	codec   codec.Codec
+	init    InitFunc
+	queue   *requestQueue
+	origins []string
+
+	maxFrame   uint64 // maximum payload of a received data frame
+	maxMessage uint64 // maximum payload of a received message, over all its frames
+	deflate    bool   // whether permessage-deflate may be negotiated
+
+	mu    sync.Mutex
+	conns map[*wsConn]struct{}
+}
+
+// StartWs starts listening for RPC requests sent via WebSocket. The API serving
+// the requests of a connection is created by initializer.
+func StartWs(cfg WsConfig, codec codec.Codec, initializer InitFunc) error {
+	wsServerMu.Lock()
+	defer wsServerMu.Unlock()
+
+	addr := fmt.Sprintf("%s:%d", cfg.ListenAddress, cfg.ListenPort)
+	if wsServer != nil {
+		if addr != wsServer.Addr {
+			return fmt.Errorf("WebSocket service already running on %s ", wsServer.Addr)
+		}
+		return nil // WebSocket service already running on given host/port
+	}
+	l := &wsListener{
+		codec: codec,
+		init:  initializer,
+		queue: newRequestQueue("WS", cfg.MaxPending),
+		conns: make(map[*wsConn]struct{}),
+
+		maxFrame:   maxHttpSizeReqLength,
+		maxMessage: maxHttpSizeReqLength,
+		deflate:    cfg.Compression,
+	}
+	if cfg.MaxFrameSize > 0 {
+		l.maxFrame = uint64(cfg.MaxFrameSize)
+	}
+	if l.maxFrame > l.maxMessage {
+		l.maxMessage = l.maxFrame
+	}
+	l.origins = parseOrigins(cfg.Origins)
+	s, err := listenHTTP(addr, l, HttpConfig{}.withDefaults(), nil)
+	if err != nil {
+		glog.V(logger.Error).Infof("Can't listen on %s:%d: %v", cfg.ListenAddress, cfg.ListenPort, err)
+		return err
+	}
+	l.stopServer = s
+	wsServer = l
+	glog.V(logger.Info).Infof("WebSocket service started (%s)\n", addr)
+	return nil
+}
+
+// StopWs shuts down the WebSocket service, closing all of its connections.
+func StopWs() {
+	wsServerMu.Lock()
+	defer wsServerMu.Unlock()
+	if wsServer != nil {
+		wsServer.Close()
+		wsServer = nil
+	}
+}
+
+// Close stops accepting connections and closes the open ones.
+func (l *wsListener) Close() {
+	l.stopServer.Close()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for c := range l.conns {
+		c.Close()
+	}
+}
+
+// parseOrigins splits a space or comma separated list of origins into their
+// normalised forms.
+func parseOrigins(list string) []string {
+	var origins []string
+	for _, origin := range strings.FieldsFunc(list, func(r rune) bool { return r == ',' || unicode.IsSpace(r) }) {
+		origins = append(origins, normaliseOrigin(origin))
+	}
+	return origins
+}
+
+// normaliseOrigin lower-cases an origin and strips trailing slashes, as the
+// scheme and host of origins are case insensitive and carry no path.
+func normaliseOrigin(origin string) string {
+	return strings.TrimRight(strings.ToLower(origin), "/")
+}
+
+// allowOrigin reports whether a connection from origin may be accepted.
+// Clients not sending an origin aren't browsers and are always allowed.
+func (l *wsListener) allowOrigin(origin string) bool {
+	if origin == "" {
+		return true
+	}
+	origin = normaliseOrigin(origin)
+	for _, allowed := range l.origins {
+		if allowed == "*" || allowed == origin {
+			return true
+		}
+	}
+	return false
+}
+
+// ServeHTTP performs the WebSocket handshake and serves RPC requests over the
+// connection until it is closed.
+func (l *wsListener) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != "GET" || !strings.EqualFold(req.Header.Get("Upgrade"), "websocket") || !headerHasToken(req.Header, "Connection", "upgrade") {
+		http.Error(w, "WebSocket upgrade required", http.StatusBadRequest)
+		return
+	}
+	if req.Header.Get("Sec-WebSocket-Version") != "13" {
+		w.Header().Set("Sec-WebSocket-Version", "13")
+		http.Error(w, "Unsupported WebSocket version", http.StatusUpgradeRequired)
+		return
+	}
+	key := req.Header.Get("Sec-WebSocket-Key")
+	if key == "" {
+		http.Error(w, "Missing WebSocket key", http.StatusBadRequest)
+		return
+	}
+	if origin := req.Header.Get("Origin"); !l.allowOrigin(origin) {
+		glog.V(logger.Debug).Infof("rejected WebSocket connection from origin %s", origin)
+		http.Error(w, "Origin not allowed", http.StatusForbidden)
+		return
+	}
+	hj, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "WebSocket not supported", http.StatusInternalServerError)
+		return
+	}
+	conn, rw, err := hj.Hijack()
+	if err != nil {
+		glog.V(logger.Debug).Infof("WebSocket hijack failed: %v", err)
+		return
+	}
+	// The handshake deadlines of the HTTP server don't apply anymore.
+	conn.SetDeadline(time.Time{})
+
+	rw.WriteString("HTTP/1.1 101 Switching Protocols\r\n")
+	rw.WriteString("Upgrade: websocket\r\nConnection: Upgrade\r\n")
+	rw.WriteString("Sec-WebSocket-Accept: " + wsAcceptKey(key) + "\r\n")
+	deflate := l.deflate && wsAcceptDeflate(req.Header)
+	if deflate {
+		rw.WriteString("Sec-WebSocket-Extensions: permessage-deflate; server_no_context_takeover; client_no_context_takeover\r\n")
+	}
+	rw.WriteString("\r\n")
+	if err := rw.Flush(); err != nil {
+		conn.Close()
+		return
+	}
+	c := &wsConn{Conn: conn, r: rw.Reader, maxFrame: l.maxFrame, maxMessage: l.maxMessage, deflate: deflate}
+
+	l.mu.Lock()
+	l.conns[c] = struct{}{}
+	l.mu.Unlock()
+	defer func() {
+		l.mu.Lock()
+		delete(l.conns, c)
+		l.mu.Unlock()
+	}()
+
+	stopper, api, err := l.init(c)
+	if err != nil {
+		glog.V(logger.Error).Infof("Unable to initialize WebSocket connection: %v", err)
+		c.Close()
+		return
+	}
+	defer stopper.Stop()
+
+	id := newIpcConnId()
+	glog.V(logger.Debug).Infof("new WebSocket connection with id %06d started", id)
+	handle(id, c, api, l.codec, l.queue)
+}
+
+// headerHasToken reports whether the comma separated header contains token.
+func headerHasToken(h http.Header, name, token string) bool {
+	for _, value := range h[http.CanonicalHeaderKey(name)] {
+		for _, t := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(t), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// wsAcceptDeflate reports whether the client offered permessage-deflate with
+// parameters the server can honour. Messages are always compressed without
+// context takeover, but the window size of the compressor is fixed, so offers
+// asking the server for a smaller window are declined.
+func wsAcceptDeflate(h http.Header) bool {
+	for _, value := range h[http.CanonicalHeaderKey("Sec-WebSocket-Extensions")] {
+		for _, offer := range strings.Split(value, ",") {
+			params := strings.Split(offer, ";")
+			if !strings.EqualFold(strings.TrimSpace(params[0]), "permessage-deflate") {
+				continue
+			}
+			acceptable := true
+			for _, param := range params[1:] {
+				name, value := strings.TrimSpace(param), ""
+				if i := strings.IndexByte(name, '='); i >= 0 {
+					name, value = strings.TrimSpace(name[:i]), strings.Trim(strings.TrimSpace(name[i+1:]), `"`)
+				}
+				switch name {
+				case "server_no_context_takeover", "client_no_context_takeover", "client_max_window_bits":
+				case "server_max_window_bits":
+					acceptable = acceptable && value == "15"
+				default:
+					acceptable = false
+				}
+			}
+			if acceptable {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// wsAcceptKey computes the Sec-WebSocket-Accept value for a client key.
+func wsAcceptKey(key string) string {
+	h := sha1.New()
+	io.WriteString(h, key+wsGUID)
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+// wsConn is the server side of a WebSocket connection. Reads return the
+// payload of the received data frames as a continuous stream, control frames
+// are answered transparently. Every write is sent as a single text frame.
+//
+// If permessage-deflate was negotiated, compressed messages are collected in
+// full and inflated before being handed to Read, and writes are compressed.
+type wsConn struct {
+	net.Conn
+	r *bufio.Reader
+
+	maxFrame   uint64 // maximum payload of a received data frame
+	maxMessage uint64 // maximum payload of a received message
+	deflate    bool   // whether permessage-deflate was negotiated
+
+	received   uint64        // payload received of the current message
+	remaining  uint64        // unread payload of the current data frame
+	mask       [4]byte       // masking key of the current data frame
+	pos        int           // offset into the masking key
+	compressed []byte        // payload of the compressed message being received
+	inflating  bool          // whether a compressed message is being received
+	inflated   *bytes.Reader // unread payload of the last compressed message
+
+	wmu       sync.Mutex
+	closeSent bool // no frames may follow a close frame
+	closeOnce sync.Once
+	flater    *flate.Writer
+	flatebuf  bytes.Buffer
+}
+
+func (c *wsConn) Read(p []byte) (int, error) {
+	for c.remaining == 0 {
+		if c.inflated != nil && c.inflated.Len() > 0 {
+			return c.inflated.Read(p)
+		}
+		if err := c.nextFrame(); err != nil {
+			return 0, err
+		}
+	}
+	if uint64(len(p)) > c.remaining {
+		p = p[:c.remaining]
+	}
+	n, err := c.r.Read(p)
+	for i := 0; i < n; i++ {
+		p[i] ^= c.mask[c.pos&3]
+		c.pos++
+	}
+	c.remaining -= uint64(n)
+	return n, err
+}
+
+// nextFrame reads the next frame header. Data frames are left to be read by
+// Read, control frames are consumed and answered.
+func (c *wsConn) nextFrame() error {
+	var head [2]byte
+	if _, err := io.ReadFull(c.r, head[:]); err != nil {
+		return err
+	}
+	if head[1]&0x80 == 0 {
+		return errWsUnmasked
+	}
+	length := uint64(head[1] & 0x7f)
+	switch length {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(c.r, ext[:]); err != nil {
+			return err
+		}
+		length = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(c.r, ext[:]); err != nil {
+			return err
+		}
+		length = binary.BigEndian.Uint64(ext[:])
+	}
+	if _, err := io.ReadFull(c.r, c.mask[:]); err != nil {
+		return err
+	}
+	c.pos = 0
+
+	switch opcode := head[0] & 0x0f; opcode {
+	case wsContinuation, wsText, wsBinary:
+		if length > c.maxFrame {
+			return errWsFrameTooLarge
+		}
+		// The limit applies to whole messages too, which could be split
+		// into any number of continuation frames otherwise.
+		if opcode != wsContinuation {
+			c.received = 0
+		}
+		if c.received += length; c.received > c.maxMessage {
+			return errWsMsgTooLarge
+		}
+		if head[0]&wsRsv1 != 0 {
+			if !c.deflate || opcode == wsContinuation {
+				return errWsCompressed
+			}
+			c.inflating, c.compressed = true, c.compressed[:0]
+		}
+		if !c.inflating {
+			c.remaining = length
+			return nil
+		}
+		return c.readCompressed(length, head[0]&0x80 != 0)
+
+	case wsClose, wsPing, wsPong:
+		if length > 125 {
+			return errWsFrameTooLarge
+		}
+		payload := make([]byte, length)
+		if _, err := io.ReadFull(c.r, payload); err != nil {
+			return err
+		}
+		for i := range payload {
+			payload[i] ^= c.mask[i&3]
+		}
+		switch opcode {
+		case wsClose:
+			c.writeFrame(wsClose, payload)
+			return io.EOF
+		case wsPing:
+			return c.writeFrame(wsPong, payload)
+		}
+		return nil
+
+	default:
+		return fmt.Errorf("websocket: unknown opcode %d", opcode)
+	}
+}
+
+// readCompressed appends the payload of a frame of a compressed message to the
+// message, inflating it once the final frame was received.
+func (c *wsConn) readCompressed(length uint64, fin bool) error {
+	if uint64(len(c.compressed))+length > c.maxMessage {
+		return errWsMsgTooLarge
+	}
+	start := len(c.compressed)
+	c.compressed = append(c.compressed, make([]byte, length)...)
+	if _, err := io.ReadFull(c.r, c.compressed[start:]); err != nil {
+		return err
+	}
+	for i := start; i < len(c.compressed); i++ {
+		c.compressed[i] ^= c.mask[(i-start)&3]
+	}
+	if !fin {
+		return nil
+	}
+	c.inflating = false
+
+	r := flate.NewReader(io.MultiReader(bytes.NewReader(c.compressed), bytes.NewReader(wsDeflateTail)))
+	defer r.Close()
+	payload, err := ioutil.ReadAll(io.LimitReader(r, int64(c.maxMessage)+1))
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return err
+	}
+	if uint64(len(payload)) > c.maxMessage {
+		return errWsMsgTooLarge
+	}
+	c.inflated = bytes.NewReader(payload)
+	return nil
+}
+
+func (c *wsConn) Write(p []byte) (int, error) {
+	var err error
+	if c.deflate {
+		err = c.writeCompressed(p)
+	} else {
+		err = c.writeFrame(wsText, p)
+	}
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// writeCompressed sends p as a compressed text frame. Every message is
+// compressed on its own, as no context takeover was negotiated.
+func (c *wsConn) writeCompressed(p []byte) error {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+
+	c.flatebuf.Reset()
+	if c.flater == nil {
+		c.flater, _ = flate.NewWriter(&c.flatebuf, flate.BestSpeed)
+	} else {
+		c.flater.Reset(&c.flatebuf)
+	}
+	c.flater.Write(p)
+	c.flater.Flush()
+
+	return c.writeFrameLocked(wsRsv1|wsText, bytes.TrimSuffix(c.flatebuf.Bytes(), wsDeflateTail))
+}
+
+// writeFrame sends an unfragmented, unmasked frame.
+func (c *wsConn) writeFrame(opcode byte, payload []byte) error {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+
+	return c.writeFrameLocked(opcode, payload)
+}
+
+// writeFrameLocked sends a frame, the reserved bits may be set along with the
+// opcode. The write lock must be held.
+func (c *wsConn) writeFrameLocked(opcode byte, payload []byte) error {
+	if c.closeSent {
+		return io.ErrClosedPipe
+	}
+	c.closeSent = opcode == wsClose
+
+	frame := make([]byte, 0, len(payload)+10)
+	frame = append(frame, 0x80|opcode)
+	switch length := len(payload); {
+	case length < 126:
+		frame = append(frame, byte(length))
+	case length <= 0xffff:
+		frame = append(frame, 126, byte(length>>8), byte(length))
+	default:
+		frame = append(frame, 127)
+		var ext [8]byte
+		binary.BigEndian.PutUint64(ext[:], uint64(length))
+		frame = append(frame, ext[:]...)
+	}
+	frame = append(frame, payload...)
+	_, err := c.Conn.Write(frame)
+	return err
+}
+
+// Close sends a close frame and closes the underlying connection.
+func (c *wsConn) Close() error {
+	var err error
+	c.closeOnce.Do(func() {
+		c.writeFrame(wsClose, nil)
+		err = c.Conn.Close()
+	})
+	return err
+}
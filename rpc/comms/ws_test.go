@@ -0,0 +1,275 @@
+// Copyright 2016 The go-expanse Authors
+// This file is part of the go-expanse library.
+//
+// The go-expanse library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-expanse library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-expanse library. If not, see <http://www.gnu.org/licenses/>.
+
+package comms
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/expanse-project/go-expanse/rpc/codec"
+	"github.com/expanse-project/go-expanse/rpc/shared"
+)
+
+// wsClientFrame encodes a masked client frame.
+func wsClientFrame(fin bool, opcode byte, payload string) []byte {
+	mask := [4]byte{1, 2, 3, 4}
+	head := opcode
+	if fin {
+		head |= 0x80
+	}
+	frame := []byte{head, 0x80 | byte(len(payload))}
+	frame = append(frame, mask[:]...)
+	for i := 0; i < len(payload); i++ {
+		frame = append(frame, payload[i]^mask[i&3])
+	}
+	return frame
+}
+
+// wsDeflate compresses a message payload as permessage-deflate does.
+func wsDeflate(payload string) string {
+	buf := new(bytes.Buffer)
+	w, _ := flate.NewWriter(buf, flate.BestSpeed)
+	io.WriteString(w, payload)
+	w.Flush()
+	return string(bytes.TrimSuffix(buf.Bytes(), wsDeflateTail))
+}
+
+// wsInflate decompresses a message payload compressed by permessage-deflate.
+func wsInflate(payload string) (string, error) {
+	r := flate.NewReader(io.MultiReader(strings.NewReader(payload), bytes.NewReader(wsDeflateTail)))
+	data, err := ioutil.ReadAll(r)
+	if err == io.ErrUnexpectedEOF {
+		err = nil
+	}
+	return string(data), err
+}
+
+// wsDial connects to the running WebSocket service and performs the handshake
+// with the given extra request headers.
+func wsDial(t *testing.T, headers string) (net.Conn, *bufio.Reader, *http.Response) {
+	conn, err := net.Dial("tcp", wsServer.l.Addr().String())
+	if err != nil {
+		t.Fatalf("failed to dial: %v", err)
+	}
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	io.WriteString(conn, "GET / HTTP/1.1\r\nHost: localhost\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n"+
+		"Sec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\nSec-WebSocket-Version: 13\r\n"+headers+"\r\n")
+	r := bufio.NewReader(conn)
+	res, err := http.ReadResponse(r, nil)
+	if err != nil {
+		conn.Close()
+		t.Fatalf("failed to read handshake response: %v", err)
+	}
+	if res.StatusCode != http.StatusSwitchingProtocols {
+		conn.Close()
+		t.Fatalf("handshake status mismatch: have %d, want %d", res.StatusCode, http.StatusSwitchingProtocols)
+	}
+	return conn, r, res
+}
+
+// wsReadFrame reads an unmasked server frame with a short payload.
+func wsReadFrame(r *bufio.Reader) (byte, string, error) {
+	var head [2]byte
+	if _, err := io.ReadFull(r, head[:]); err != nil {
+		return 0, "", err
+	}
+	payload := make([]byte, head[1]&0x7f)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return 0, "", err
+	}
+	return head[0] & 0x0f, string(payload), nil
+}
+
+func TestWsService(t *testing.T) {
+	api := &testApi{func(req *shared.Request) (interface{}, error) {
+		return "pong", nil
+	}}
+	if err := StartWs(WsConfig{ListenAddress: "127.0.0.1"}, codec.JSON, testInit(api)); err != nil {
+		t.Fatalf("failed to start service: %v", err)
+	}
+	defer StopWs()
+
+	conn, err := net.Dial("tcp", wsServer.l.Addr().String())
+	if err != nil {
+		t.Fatalf("failed to dial: %v", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	// Perform the handshake with the example key of RFC 6455
+	io.WriteString(conn, "GET / HTTP/1.1\r\nHost: localhost\r\nUpgrade: websocket\r\nConnection: keep-alive, Upgrade\r\n"+
+		"Sec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\nSec-WebSocket-Version: 13\r\n\r\n")
+	r := bufio.NewReader(conn)
+	res, err := http.ReadResponse(r, nil)
+	if err != nil {
+		t.Fatalf("failed to read handshake response: %v", err)
+	}
+	if res.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("handshake status mismatch: have %d, want %d", res.StatusCode, http.StatusSwitchingProtocols)
+	}
+	if accept := res.Header.Get("Sec-WebSocket-Accept"); accept != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Fatalf("accept key mismatch: have %s", accept)
+	}
+	// Send a request fragmented over two frames with a ping in between
+	req := `{"jsonrpc":"2.0","id":1,"method":"test_call"}`
+	conn.Write(wsClientFrame(false, wsText, req[:10]))
+	conn.Write(wsClientFrame(true, wsPing, "hi"))
+	conn.Write(wsClientFrame(true, wsContinuation, req[10:]))
+
+	if opcode, payload, err := wsReadFrame(r); err != nil || opcode != wsPong || payload != "hi" {
+		t.Fatalf("pong mismatch: have %d %q %v", opcode, payload, err)
+	}
+	opcode, payload, err := wsReadFrame(r)
+	if err != nil || opcode != wsText || !strings.Contains(payload, `"result":"pong"`) {
+		t.Fatalf("response mismatch: have %d %q %v", opcode, payload, err)
+	}
+	// Stopping the service must close the connection
+	StopWs()
+	if opcode, _, err := wsReadFrame(r); err != nil || opcode != wsClose {
+		t.Fatalf("close frame mismatch: have %d %v", opcode, err)
+	}
+	if _, _, err := wsReadFrame(r); err != io.EOF {
+		t.Fatalf("connection not closed: %v", err)
+	}
+}
+
+func TestWsOrigins(t *testing.T) {
+	l := &wsListener{origins: strings.Split("http://a.com http://b.com", " ")}
+	tests := map[string]bool{"": true, "http://a.com": true, "http://b.com": true, "http://c.com": false}
+	for origin, want := range tests {
+		if have := l.allowOrigin(origin); have != want {
+			t.Errorf("origin %q: have %v, want %v", origin, have, want)
+		}
+	}
+	if !(&wsListener{origins: []string{"*"}}).allowOrigin("http://c.com") {
+		t.Errorf("wildcard origin not allowed")
+	}
+	// Lists may be comma separated with stray whitespace, origins match
+	// regardless of case and trailing slashes
+	l = &wsListener{origins: parseOrigins(" http://A.com/,  https://b.com:8080 ")}
+	tests = map[string]bool{"http://a.com": true, "HTTPS://B.COM:8080": true, "https://b.com": false, "http://b.com:8080": false}
+	for origin, want := range tests {
+		if have := l.allowOrigin(origin); have != want {
+			t.Errorf("origin %q: have %v, want %v", origin, have, want)
+		}
+	}
+	if origins := parseOrigins(""); len(origins) != 0 {
+		t.Errorf("empty list parsed to %q", origins)
+	}
+}
+
+func TestWsCompression(t *testing.T) {
+	api := &testApi{func(req *shared.Request) (interface{}, error) {
+		return "pong", nil
+	}}
+	if err := StartWs(WsConfig{ListenAddress: "127.0.0.1", Compression: true}, codec.JSON, testInit(api)); err != nil {
+		t.Fatalf("failed to start service: %v", err)
+	}
+	defer StopWs()
+
+	// Clients not offering compression get uncompressed frames
+	conn, r, res := wsDial(t, "")
+	if ext := res.Header.Get("Sec-WebSocket-Extensions"); ext != "" {
+		t.Errorf("unexpected extension negotiated: %s", ext)
+	}
+	req := `{"jsonrpc":"2.0","id":1,"method":"test_call"}`
+	conn.Write(wsClientFrame(true, wsText, req))
+	if opcode, payload, err := wsReadFrame(r); err != nil || opcode != wsText || !strings.Contains(payload, `"result":"pong"`) {
+		t.Fatalf("response mismatch: have %d %q %v", opcode, payload, err)
+	}
+	conn.Close()
+
+	// Offers requiring a smaller server window are declined
+	conn, _, res = wsDial(t, "Sec-WebSocket-Extensions: permessage-deflate; server_max_window_bits=10\r\n")
+	if ext := res.Header.Get("Sec-WebSocket-Extensions"); ext != "" {
+		t.Errorf("unexpected extension negotiated: %s", ext)
+	}
+	conn.Close()
+
+	// Compressed requests spanning two frames get compressed answers
+	conn, r, res = wsDial(t, "Sec-WebSocket-Extensions: permessage-deflate; client_max_window_bits\r\n")
+	defer conn.Close()
+	if ext := res.Header.Get("Sec-WebSocket-Extensions"); !strings.HasPrefix(ext, "permessage-deflate") {
+		t.Fatalf("compression not negotiated: %q", ext)
+	}
+	compressed := wsDeflate(req)
+	conn.Write(wsClientFrame(false, wsRsv1|wsText, compressed[:5]))
+	conn.Write(wsClientFrame(true, wsContinuation, compressed[5:]))
+
+	var head [2]byte
+	if _, err := io.ReadFull(r, head[:]); err != nil {
+		t.Fatalf("failed to read response: %v", err)
+	}
+	if head[0] != 0x80|wsRsv1|wsText {
+		t.Fatalf("response header mismatch: have %#x", head[0])
+	}
+	payload := make([]byte, head[1]&0x7f)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		t.Fatalf("failed to read response: %v", err)
+	}
+	if response, err := wsInflate(string(payload)); err != nil || !strings.Contains(response, `"result":"pong"`) {
+		t.Fatalf("response mismatch: have %q %v", response, err)
+	}
+}
+
+func TestWsMaxFrameSize(t *testing.T) {
+	api := &testApi{func(req *shared.Request) (interface{}, error) {
+		return "pong", nil
+	}}
+	if err := StartWs(WsConfig{ListenAddress: "127.0.0.1", MaxFrameSize: 16}, codec.JSON, testInit(api)); err != nil {
+		t.Fatalf("failed to start service: %v", err)
+	}
+	defer StopWs()
+
+	conn, r, _ := wsDial(t, "")
+	defer conn.Close()
+
+	// Frames above the limit close the connection
+	conn.Write(wsClientFrame(false, wsText, `{"jsonrpc":"2.0",`))
+	if opcode, _, err := wsReadFrame(r); err != nil || opcode != wsClose {
+		t.Fatalf("oversized frame accepted: have %d %v", opcode, err)
+	}
+
+	// Messages above the limit close the connection, also if split into
+	// frames within it
+	wsServer.maxMessage = 32
+	conn, r, _ = wsDial(t, "")
+	defer conn.Close()
+	conn.Write(wsClientFrame(false, wsText, `{"jsonrpc":`))
+	conn.Write(wsClientFrame(false, wsContinuation, `"2.0","method":`))
+	conn.Write(wsClientFrame(true, wsContinuation, `"ping","id":1}`))
+	if opcode, _, err := wsReadFrame(r); err != nil || opcode != wsClose {
+		t.Fatalf("oversized message accepted: have %d %v", opcode, err)
+	}
+
+	// Compressed frames are refused unless compression was negotiated
+	conn, r, _ = wsDial(t, "")
+	defer conn.Close()
+	conn.Write(wsClientFrame(true, wsRsv1|wsText, wsDeflate("x")))
+	if opcode, _, err := wsReadFrame(r); err != nil || opcode != wsClose {
+		t.Fatalf("compressed frame accepted without negotiation: have %d %v", opcode, err)
+	}
+}
@@ -124,3 +124,81 @@ func NewNotAvailableError(method string, reason string) *NotAvailableError {
 		Reason: reason,
 	}
 }
+
+type OverloadedError struct {
+	Transport string
+}
+
+func (e *OverloadedError) Error() string {
+	return fmt.Sprintf("%s server overloaded, too many pending requests", e.Transport)
+}
+
+func NewOverloadedError(transport string) *OverloadedError {
+	return &OverloadedError{
+		Transport: transport,
+	}
+}
+
+// FilterOverflowError is returned when a filter dropped changes because it
+// wasn't polled often enough.
+type FilterOverflowError struct {
+	Discarded int
+}
+
+func (e *FilterOverflowError) Error() string {
+	return fmt.Sprintf("filter overflow, %d changes discarded", e.Discarded)
+}
+
+func NewFilterOverflowError(discarded int) *FilterOverflowError {
+	return &FilterOverflowError{
+		Discarded: discarded,
+	}
+}
+
+// EvaluationError is returned when user supplied metadata, like the NatSpec
+// notice of a contract, was found but failed to evaluate.
+type EvaluationError struct {
+	Msg string
+}
+
+func (e *EvaluationError) Error() string {
+	return e.Msg
+}
+
+func NewEvaluationError(msg string) *EvaluationError {
+	return &EvaluationError{
+		Msg: msg,
+	}
+}
+
+// InvalidRequestError is returned for a request which isn't a valid JSON-RPC
+// request object, e.g. an entry of a batch failing to decode.
+type InvalidRequestError struct {
+	Msg string
+}
+
+func (e *InvalidRequestError) Error() string {
+	return fmt.Sprintf("invalid request: %s", e.Msg)
+}
+
+func NewInvalidRequestError(msg string) *InvalidRequestError {
+	return &InvalidRequestError{
+		Msg: msg,
+	}
+}
+
+// InternalError is returned when a request could not be served because of an
+// inconsistency in the node itself, as opposed to the object not existing.
+type InternalError struct {
+	Msg string
+}
+
+func (e *InternalError) Error() string {
+	return fmt.Sprintf("internal error: %s", e.Msg)
+}
+
+func NewInternalError(msg string) *InternalError {
+	return &InternalError{
+		Msg: msg,
+	}
+}
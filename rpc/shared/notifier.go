@@ -0,0 +1,206 @@
+// Copyright 2016 The go-expanse Authors
+// This file is part of the go-expanse library.
+//
+// The go-expanse library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-expanse library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-expanse library. If not, see <http://www.gnu.org/licenses/>.
+
+package shared
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"sync"
+)
+
+// MaxSubscriptions is the maximum number of subscriptions a single connection
+// may hold at once.
+var MaxSubscriptions = 128
+
+var (
+	errNotifierClosed       = errors.New("connection closed")
+	errTooManySubscriptions = errors.New("too many subscriptions")
+)
+
+// notifierKey is the context key of the notifier of a connection.
+type notifierKey struct{}
+
+// Notification is a message pushed to the client of a subscription, it has no
+// id as no reply is expected.
+type Notification struct {
+	Jsonrpc string             `json:"jsonrpc"`
+	Method  string             `json:"method"`
+	Params  NotificationParams `json:"params"`
+}
+
+// NotificationParams carries the subscription a notification belongs to and
+// its payload.
+type NotificationParams struct {
+	Subscription string      `json:"subscription"`
+	Result       interface{} `json:"result"`
+}
+
+// NewNotification creates the notification delivering result to subscription
+// id of the given api namespace, e.g. "eth_subscription" for "eth".
+func NewNotification(namespace, id string, result interface{}) *Notification {
+	return &Notification{
+		Jsonrpc: "2.0",
+		Method:  namespace + "_subscription",
+		Params:  NotificationParams{Subscription: id, Result: result},
+	}
+}
+
+// Subscription is a subscription of the client of a connection. Its events are
+// delivered with Notify until Quit is closed.
+type Subscription struct {
+	ID        string
+	namespace string
+	notifier  *Notifier
+	run       func(*Subscription)
+	quit      chan struct{}
+}
+
+// Notify sends result to the client as a notification of the subscription.
+func (s *Subscription) Notify(result interface{}) error {
+	return s.notifier.send(NewNotification(s.namespace, s.ID, result))
+}
+
+// Quit returns a channel which is closed once the subscription is cancelled,
+// either by the client or because the connection went away.
+func (s *Subscription) Quit() <-chan struct{} {
+	return s.quit
+}
+
+// Notifier manages the subscriptions of a persistent connection, like IPC or
+// WebSocket, and pushes their notifications to the client. Handlers get hold
+// of it through the context of the request with NotifierFromContext.
+//
+// Subscriptions are started by Activate once the response to the request
+// creating them was sent, so the client learns the id of a subscription before
+// receiving its first notification.
+type Notifier struct {
+	send   func(interface{}) error
+	active func(bool)
+
+	mu      sync.Mutex
+	subs    map[string]*Subscription
+	pending []*Subscription
+	closed  bool
+}
+
+// NewNotifier creates a notifier sending its notifications with send. active,
+// if not nil, is called with true when the first subscription is added and
+// with false when the last one is removed, so the connection can stop
+// dropping clients which only listen.
+func NewNotifier(send func(interface{}) error, active func(bool)) *Notifier {
+	return &Notifier{
+		send:   send,
+		active: active,
+		subs:   make(map[string]*Subscription),
+	}
+}
+
+// WithNotifier returns a copy of ctx carrying the notifier n.
+func WithNotifier(ctx context.Context, n *Notifier) context.Context {
+	return context.WithValue(ctx, notifierKey{}, n)
+}
+
+// NotifierFromContext returns the notifier of the connection a request was
+// received on. ok is false for transports which can't push notifications.
+func NotifierFromContext(ctx context.Context) (n *Notifier, ok bool) {
+	n, ok = ctx.Value(notifierKey{}).(*Notifier)
+	return n, ok
+}
+
+// Subscribe adds a subscription of the given api namespace. run is started in
+// its own goroutine once the subscription is activated and is expected to
+// deliver notifications until the quit channel of the subscription is closed.
+func (n *Notifier) Subscribe(namespace string, run func(*Subscription)) (string, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.closed {
+		return "", errNotifierClosed
+	}
+	if len(n.subs) >= MaxSubscriptions {
+		return "", errTooManySubscriptions
+	}
+	sub := &Subscription{
+		ID:        newSubscriptionId(),
+		namespace: namespace,
+		notifier:  n,
+		run:       run,
+		quit:      make(chan struct{}),
+	}
+	n.subs[sub.ID] = sub
+	n.pending = append(n.pending, sub)
+	if len(n.subs) == 1 && n.active != nil {
+		n.active(true)
+	}
+	return sub.ID, nil
+}
+
+// Unsubscribe cancels the subscription with the given id, reporting whether it
+// existed.
+func (n *Notifier) Unsubscribe(id string) bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	sub, ok := n.subs[id]
+	if !ok {
+		return false
+	}
+	delete(n.subs, id)
+	close(sub.quit)
+	if len(n.subs) == 0 && n.active != nil {
+		n.active(false)
+	}
+	return true
+}
+
+// Activate starts the subscriptions added since the last call which weren't
+// cancelled in the meantime.
+func (n *Notifier) Activate() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	for _, sub := range n.pending {
+		if _, ok := n.subs[sub.ID]; ok {
+			go sub.run(sub)
+		}
+	}
+	n.pending = nil
+}
+
+// Close cancels all subscriptions, no new ones can be added afterwards.
+func (n *Notifier) Close() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	for id, sub := range n.subs {
+		delete(n.subs, id)
+		close(sub.quit)
+	}
+	n.pending = nil
+	n.closed = true
+}
+
+// newSubscriptionId returns a random, hex encoded subscription id.
+func newSubscriptionId() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic("can't read random subscription id: " + err.Error())
+	}
+	return "0x" + hex.EncodeToString(b[:])
+}
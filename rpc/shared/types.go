@@ -17,7 +17,9 @@
 package shared
 
 import (
+	"context"
 	"encoding/json"
+	"net"
 
 	"github.com/expanse-project/go-expanse/logger"
 	"github.com/expanse-project/go-expanse/logger/glog"
@@ -44,6 +46,88 @@ type Request struct {
 	Jsonrpc string          `json:"jsonrpc"`
 	Method  string          `json:"method"`
 	Params  json.RawMessage `json:"params"`
+
+	ctx context.Context
+	err error // set for requests which failed to decode
+}
+
+// NewInvalidRequest creates a placeholder for a request which failed to
+// decode. Executing it fails with an InvalidRequestError, so it is answered
+// like any other request.
+func NewInvalidRequest(id interface{}, msg string) *Request {
+	return &Request{Id: id, Jsonrpc: JsonRpcVersion, err: NewInvalidRequestError(msg)}
+}
+
+// Err returns the reason the request is invalid, or nil for valid requests.
+func (req *Request) Err() error {
+	return req.err
+}
+
+// DecodeBatch decodes a JSON array of requests. Entries failing to decode
+// don't fail the batch, they are returned as invalid requests in their place
+// so the others are still served. An error is only returned if data isn't an
+// array or the array is empty.
+func DecodeBatch(data []byte) ([]*Request, error) {
+	var entries []json.RawMessage
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, NewInvalidRequestError("empty batch")
+	}
+	requests := make([]*Request, len(entries))
+	for i, entry := range entries {
+		req := new(Request)
+		if err := json.Unmarshal(entry, req); err != nil {
+			// Answer under the id of the entry if there is any
+			var partial struct {
+				Id interface{} `json:"id"`
+			}
+			json.Unmarshal(entry, &partial)
+			req = NewInvalidRequest(partial.Id, err.Error())
+		} else if req.Method == "" {
+			req = NewInvalidRequest(req.Id, "missing method")
+		}
+		requests[i] = req
+	}
+	return requests, nil
+}
+
+// Context returns the request's context. It is cancelled when the client
+// connection goes away or the request times out. The background context
+// is returned for requests created without one.
+func (req *Request) Context() context.Context {
+	if req.ctx != nil {
+		return req.ctx
+	}
+	return context.Background()
+}
+
+// WithContext returns a shallow copy of req with its context set to ctx.
+func (req *Request) WithContext(ctx context.Context) *Request {
+	r2 := *req
+	r2.ctx = ctx
+	return &r2
+}
+
+// remoteKey is the context key of the address of the client of a connection.
+type remoteKey struct{}
+
+// WithRemote returns a copy of ctx carrying the address of the client requests
+// are received from. The port of network addresses is dropped, as clients
+// connect from ephemeral ones and only the host identifies them.
+func WithRemote(ctx context.Context, remote string) context.Context {
+	if host, _, err := net.SplitHostPort(remote); err == nil {
+		remote = host
+	}
+	return context.WithValue(ctx, remoteKey{}, remote)
+}
+
+// RemoteFromContext returns the address of the client a request was received
+// from, or "" if the transport doesn't know it.
+func RemoteFromContext(ctx context.Context) string {
+	remote, _ := ctx.Value(remoteKey{}).(string)
+	return remote
 }
 
 // RPC response
@@ -95,6 +179,18 @@ func NewRpcResponse(id interface{}, jsonrpcver string, reply interface{}, err er
 	case *NotReadyError:
 		jsonerr := &ErrorObject{-32000, err.Error()}
 		response = &ErrorResponse{Jsonrpc: jsonrpcver, Id: id, Error: jsonerr}
+	case *OverloadedError:
+		jsonerr := &ErrorObject{-32005, err.Error()}
+		response = &ErrorResponse{Jsonrpc: jsonrpcver, Id: id, Error: jsonerr}
+	case *FilterOverflowError:
+		jsonerr := &ErrorObject{-32006, err.Error()}
+		response = &ErrorResponse{Jsonrpc: jsonrpcver, Id: id, Error: jsonerr}
+	case *InvalidRequestError:
+		jsonerr := &ErrorObject{-32600, err.Error()}
+		response = &ErrorResponse{Jsonrpc: jsonrpcver, Id: id, Error: jsonerr}
+	case *EvaluationError:
+		jsonerr := &ErrorObject{-32007, err.Error()}
+		response = &ErrorResponse{Jsonrpc: jsonrpcver, Id: id, Error: jsonerr}
 	case *DecodeParamError, *InsufficientParamsError, *ValidationError, *InvalidTypeError:
 		jsonerr := &ErrorObject{-32602, err.Error()}
 		response = &ErrorResponse{Jsonrpc: jsonrpcver, Id: id, Error: jsonerr}
@@ -19,17 +19,19 @@ package shared
 import "strings"
 
 const (
-	AdminApiName    = "admin"
-	EthApiName      = "exp"
-	DbApiName       = "db"
-	DebugApiName    = "debug"
-	MergedApiName   = "merged"
-	MinerApiName    = "miner"
-	NetApiName      = "net"
-	ShhApiName      = "shh"
-	TxPoolApiName   = "txpool"
-	PersonalApiName = "personal"
-	Web3ApiName     = "web3"
+	AdminApiName     = "admin"
+	CliqueApiName    = "clique"
+	EthApiName       = "exp"
+	DbApiName        = "db"
+	DebugApiName     = "debug"
+	MergedApiName    = "merged"
+	MinerApiName     = "miner"
+	NetApiName       = "net"
+	ShhApiName       = "shh"
+	TxPoolApiName    = "txpool"
+	PersonalApiName  = "personal"
+	RegistrarApiName = "registrar"
+	Web3ApiName      = "web3"
 
 	JsonRpcVersion = "2.0"
 )
@@ -37,7 +39,7 @@ const (
 var (
 	// All API's
 	AllApis = strings.Join([]string{
-		AdminApiName, DbApiName, EthApiName, DebugApiName, MinerApiName, NetApiName,
-		ShhApiName, TxPoolApiName, PersonalApiName, Web3ApiName,
+		AdminApiName, CliqueApiName, DbApiName, EthApiName, DebugApiName, MinerApiName, NetApiName,
+		ShhApiName, TxPoolApiName, PersonalApiName, RegistrarApiName, Web3ApiName,
 	}, ",")
 )
@@ -140,6 +140,8 @@ func RunBlockTest(file string, skipTests []string) error {
 }
 
 func runBlockTests(bt map[string]*BlockTest, skipTests []string) error {
+	defer useFixtureParams()()
+
 	skipTest := make(map[string]bool, len(skipTests))
 	for _, name := range skipTests {
 		skipTest[name] = true
@@ -282,6 +284,8 @@ func (t *BlockTest) InsertPreState(db ethdb.Database, am *accounts.Manager) (*st
    post state.
 */
 func (t *BlockTest) TryBlocksInsert(blockchain *core.BlockChain) ([]btBlock, error) {
+	defer useFixtureParams()()
+
 	validBlocks := make([]btBlock, 0)
 	// insert the test blocks, which will execute all transactions
 	for _, b := range t.Json.Blocks {
@@ -524,7 +528,7 @@ func mustConvertBytes(in string) []byte {
 	h := unfuckFuckedHex(strings.TrimPrefix(in, "0x"))
 	out, err := hex.DecodeString(h)
 	if err != nil {
-		panic(fmt.Errorf("invalid hex: %q: ", h, err))
+		panic(fmt.Errorf("invalid hex: %q: %v", h, err))
 	}
 	return out
 }
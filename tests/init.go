@@ -22,11 +22,14 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"math"
+	"math/big"
 	"net/http"
 	"os"
 	"path/filepath"
 
 	"github.com/expanse-project/go-expanse/core"
+	"github.com/expanse-project/go-expanse/params"
 )
 
 var (
@@ -64,10 +67,39 @@ func init() {
 	core.DisableBadBlockReporting = true
 }
 
+// useFixtureParams switches the consensus parameters in which Expanse
+// differs from Ethereum to the values the JSON fixtures were generated
+// with, returning a function restoring the previous ones. The parameters are
+// global, so they are only switched while fixtures run, never on package
+// load: the runners are linked into gexp too. The VM and state transition
+// rules are shared and need no changes.
+func useFixtureParams() (restore func()) {
+	var (
+		reward   = core.BlockReward
+		divisor  = core.HomesteadDurationDivisor
+		duration = params.DurationLimit
+		bound    = params.DifficultyBoundDivisor2
+		fork     = params.HardFork1
+	)
+	core.BlockReward = big.NewInt(5e+18)
+	core.HomesteadDurationDivisor = big.NewInt(10)
+	params.DurationLimit = big.NewInt(13)
+	params.DifficultyBoundDivisor2 = params.DifficultyBoundDivisor
+	params.HardFork1 = big.NewInt(math.MaxInt64)
+
+	return func() {
+		core.BlockReward = reward
+		core.HomesteadDurationDivisor = divisor
+		params.DurationLimit = duration
+		params.DifficultyBoundDivisor2 = bound
+		params.HardFork1 = fork
+	}
+}
+
 func readJson(reader io.Reader, value interface{}) error {
 	data, err := ioutil.ReadAll(reader)
 	if err != nil {
-		return fmt.Errorf("Error reading JSON file", err.Error())
+		return fmt.Errorf("Error reading JSON file: %v", err)
 	}
 	if err = json.Unmarshal(data, &value); err != nil {
 		if syntaxerr, ok := err.(*json.SyntaxError); ok {
@@ -71,6 +71,8 @@ func BenchStateTest(p string, conf bconf, b *testing.B) error {
 		return fmt.Errorf("test not found: %s", conf.name)
 	}
 
+	defer useFixtureParams()()
+
 	pJit := vm.EnableJit
 	vm.EnableJit = conf.jit
 	pForceJit := vm.ForceJit
@@ -117,6 +119,8 @@ func benchStateTest(test VmTest, env map[string]string, b *testing.B) {
 }
 
 func runStateTests(tests map[string]VmTest, skipTests []string) error {
+	defer useFixtureParams()()
+
 	skipTest := make(map[string]bool, len(skipTests))
 	for _, name := range skipTests {
 		skipTest[name] = true
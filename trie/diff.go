@@ -0,0 +1,177 @@
+// Copyright 2016 The go-expanse Authors
+// This file is part of the go-expanse library.
+//
+// The go-expanse library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-expanse library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-expanse library. If not, see <http://www.gnu.org/licenses/>.
+
+package trie
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+
+	"github.com/expanse-project/go-expanse/common"
+)
+
+// MissingNodeError is returned by Diff if a node referenced by one of the
+// tries is not stored in its database, e.g. because the state was pruned.
+type MissingNodeError struct {
+	NodeHash common.Hash // hash of the missing node
+	Path     []byte      // hex encoded path of the node
+}
+
+func (err *MissingNodeError) Error() string {
+	return fmt.Sprintf("missing trie node %x (path %x)", err.NodeHash, err.Path)
+}
+
+// Diff returns the keys whose values differ between the tries a and b,
+// including keys present in only one of them, in ascending order. A
+// MissingNodeError is returned if either trie is incomplete.
+//
+// Subtries referenced by the same hash in both tries are skipped without being
+// loaded, so the cost of a diff is proportional to the size of the difference
+// rather than the size of the tries. Both tries should be committed for this
+// to be effective.
+func Diff(a, b *Trie) ([][]byte, error) {
+	d := &differ{a: a, b: b, keys: make(map[string]struct{})}
+	if err := d.diff(a.root, b.root, nil); err != nil {
+		return nil, err
+	}
+	keys := make([][]byte, 0, len(d.keys))
+	for key := range d.keys {
+		keys = append(keys, decodeCompact([]byte(key)))
+	}
+	sort.Sort(keySlice(keys))
+	return keys, nil
+}
+
+// differ collects the hex encoded paths of the differing keys of two tries.
+type differ struct {
+	a, b *Trie
+	keys map[string]struct{}
+}
+
+func (d *differ) diff(an, bn node, path []byte) error {
+	if an == nil && bn == nil {
+		return nil
+	}
+	if ah, ok := an.(hashNode); ok {
+		if bh, ok := bn.(hashNode); ok && bytes.Equal(ah, bh) {
+			return nil
+		}
+	}
+	an, err := resolveNode(d.a, an, path)
+	if err != nil {
+		return err
+	}
+	bn, err = resolveNode(d.b, bn, path)
+	if err != nil {
+		return err
+	}
+	// Descend in lockstep as long as both tries have the same shape.
+	switch an := an.(type) {
+	case fullNode:
+		if bn, ok := bn.(fullNode); ok {
+			for i := 0; i < 16; i++ {
+				if err := d.diff(an[i], bn[i], concat(path, byte(i))); err != nil {
+					return err
+				}
+			}
+			d.diffValues(an[16], bn[16], path)
+			return nil
+		}
+	case shortNode:
+		if bn, ok := bn.(shortNode); ok && bytes.Equal(an.Key, bn.Key) {
+			if av, ok := an.Val.(valueNode); ok {
+				d.diffValues(av, bn.Val, concat(path, remTerm(an.Key)...))
+				return nil
+			}
+			return d.diff(an.Val, bn.Val, concat(path, an.Key...))
+		}
+	}
+	// The shapes differ, compare all the leaves below this point.
+	al, bl := make(map[string][]byte), make(map[string][]byte)
+	if err := leaves(d.a, an, path, al); err != nil {
+		return err
+	}
+	if err := leaves(d.b, bn, path, bl); err != nil {
+		return err
+	}
+	for key, av := range al {
+		if bv, ok := bl[key]; !ok || !bytes.Equal(av, bv) {
+			d.keys[key] = struct{}{}
+		}
+	}
+	for key := range bl {
+		if _, ok := al[key]; !ok {
+			d.keys[key] = struct{}{}
+		}
+	}
+	return nil
+}
+
+func (d *differ) diffValues(an, bn node, path []byte) {
+	av, _ := an.(valueNode)
+	bv, _ := bn.(valueNode)
+	if !bytes.Equal(av, bv) {
+		d.keys[string(path)] = struct{}{}
+	}
+}
+
+// leaves collects all values stored below n, keyed by their hex encoded path.
+func leaves(t *Trie, n node, path []byte, out map[string][]byte) error {
+	n, err := resolveNode(t, n, path)
+	if err != nil {
+		return err
+	}
+	switch n := n.(type) {
+	case fullNode:
+		for i := 0; i < 16; i++ {
+			if err := leaves(t, n[i], concat(path, byte(i)), out); err != nil {
+				return err
+			}
+		}
+		if v, ok := n[16].(valueNode); ok {
+			out[string(path)] = v
+		}
+	case shortNode:
+		if v, ok := n.Val.(valueNode); ok {
+			out[string(concat(path, remTerm(n.Key)...))] = v
+			return nil
+		}
+		return leaves(t, n.Val, concat(path, n.Key...), out)
+	case valueNode:
+		out[string(path)] = n
+	}
+	return nil
+}
+
+// resolveNode loads n from the database of t if it is a hash node, failing
+// with a MissingNodeError if it isn't stored there.
+func resolveNode(t *Trie, n node, path []byte) (node, error) {
+	hash, ok := n.(hashNode)
+	if !ok {
+		return n, nil
+	}
+	if resolved := t.resolveHash(hash); resolved != nil {
+		return resolved, nil
+	}
+	return nil, &MissingNodeError{NodeHash: common.BytesToHash(hash), Path: path}
+}
+
+type keySlice [][]byte
+
+func (s keySlice) Len() int           { return len(s) }
+func (s keySlice) Less(i, j int) bool { return bytes.Compare(s[i], s[j]) < 0 }
+func (s keySlice) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
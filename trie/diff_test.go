@@ -0,0 +1,120 @@
+// Copyright 2016 The go-expanse Authors
+// This file is part of the go-expanse library.
+//
+// The go-expanse library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-expanse library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-expanse library. If not, see <http://www.gnu.org/licenses/>.
+
+package trie
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/expanse-project/go-expanse/common"
+	"github.com/expanse-project/go-expanse/ethdb"
+)
+
+func TestDiff(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+	orig, _ := New(common.Hash{}, db)
+
+	for i := 0; i < 1000; i++ {
+		orig.Update([]byte(fmt.Sprintf("key%04d", i)), []byte(fmt.Sprintf("value%d", i)))
+	}
+	origRoot, _ := orig.Commit()
+
+	modified, _ := New(origRoot, db)
+	want := make(map[string]bool)
+	for i := 0; i < 50; i++ {
+		n := rand.Intn(1500)
+		key := fmt.Sprintf("key%04d", n)
+		switch {
+		case n >= 1000:
+			modified.Update([]byte(key), []byte("new"))
+		case i%2 == 0:
+			modified.Delete([]byte(key))
+		default:
+			modified.Update([]byte(key), []byte("changed"))
+		}
+		want[key] = true
+	}
+	// Setting a value to its original content is not a modification.
+	modified.Update([]byte("key0001"), []byte("value1"))
+	delete(want, "key0001")
+	modifiedRoot, _ := modified.Commit()
+
+	a, _ := New(origRoot, db)
+	b, _ := New(modifiedRoot, db)
+	for _, tries := range [][2]*Trie{{a, b}, {b, a}} {
+		keys, err := Diff(tries[0], tries[1])
+		if err != nil {
+			t.Fatalf("diff error: %v", err)
+		}
+		if len(keys) != len(want) {
+			t.Errorf("key count mismatch: have %d, want %d", len(keys), len(want))
+		}
+		for i, key := range keys {
+			if !want[string(key)] {
+				t.Errorf("unexpected key %q in diff", key)
+			}
+			if i > 0 && bytes.Compare(keys[i-1], key) >= 0 {
+				t.Errorf("keys not sorted: %q before %q", keys[i-1], key)
+			}
+		}
+	}
+	if keys, _ := Diff(a, a); len(keys) != 0 {
+		t.Errorf("expected no differences between identical tries, got %q", keys)
+	}
+}
+
+func TestDiffEmpty(t *testing.T) {
+	// Keys which are prefixes of each other store values in full nodes.
+	a := newEmpty()
+	b := newEmpty()
+	b.Update([]byte("foo"), []byte("bar"))
+	b.Update([]byte("food"), []byte("baz"))
+
+	keys, err := Diff(a, b)
+	if err != nil {
+		t.Fatalf("diff error: %v", err)
+	}
+	if len(keys) != 2 || string(keys[0]) != "foo" || string(keys[1]) != "food" {
+		t.Errorf("unexpected diff against empty trie: %q", keys)
+	}
+}
+
+func TestDiffMissingNode(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+	orig, _ := New(common.Hash{}, db)
+	for i := 0; i < 100; i++ {
+		// Values are large enough for the leaves to be stored as separate nodes.
+		orig.Update([]byte(fmt.Sprintf("missing%04d", i)), bytes.Repeat([]byte{byte(i)}, 32))
+	}
+	root, _ := orig.Commit()
+
+	// Drop everything but the root node, as a pruned database would.
+	a, _ := New(root, db)
+	for _, key := range db.Keys() {
+		if !bytes.Equal(key, root[:]) {
+			db.Delete(key)
+		}
+	}
+	for _, tries := range [][2]*Trie{{a, newEmpty()}, {newEmpty(), a}} {
+		keys, err := Diff(tries[0], tries[1])
+		if _, ok := err.(*MissingNodeError); !ok {
+			t.Errorf("expected MissingNodeError, got keys %q, error %v", keys, err)
+		}
+	}
+}
@@ -17,10 +17,8 @@
 package trie
 
 import (
-	"hash"
-
 	"github.com/expanse-project/go-expanse/common"
-	"github.com/expanse-project/go-expanse/crypto/sha3"
+	"github.com/expanse-project/go-expanse/crypto"
 )
 
 var secureKeyPrefix = []byte("secure-key-")
@@ -38,7 +36,6 @@ var secureKeyPrefix = []byte("secure-key-")
 type SecureTrie struct {
 	*Trie
 
-	hash       hash.Hash
 	secKeyBuf  []byte
 	hashKeyBuf []byte
 }
@@ -97,12 +94,9 @@ func (t *SecureTrie) secKey(key []byte) []byte {
 }
 
 func (t *SecureTrie) hashKey(key []byte) []byte {
-	if t.hash == nil {
-		t.hash = sha3.NewKeccak256()
-		t.hashKeyBuf = make([]byte, 32)
-	}
-	t.hash.Reset()
-	t.hash.Write(key)
-	t.hashKeyBuf = t.hash.Sum(t.hashKeyBuf[:0])
+	h := crypto.GetKeccak256()
+	h.Write(key)
+	t.hashKeyBuf = h.Sum(t.hashKeyBuf[:0])
+	crypto.PutKeccak256(h)
 	return t.hashKeyBuf
 }
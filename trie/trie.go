@@ -22,11 +22,11 @@ import (
 	"errors"
 	"fmt"
 	"hash"
-
+	"runtime"
+	"sync"
 
 	"github.com/expanse-project/go-expanse/common"
 	"github.com/expanse-project/go-expanse/crypto"
-	"github.com/expanse-project/go-expanse/crypto/sha3"
 	"github.com/expanse-project/go-expanse/logger"
 	"github.com/expanse-project/go-expanse/logger/glog"
 	"github.com/expanse-project/go-expanse/rlp"
@@ -47,6 +47,10 @@ var (
 
 var ErrMissingRoot = errors.New("missing root node")
 
+// parallelHash enables hashing the subtries below a full root node
+// concurrently. It is only worth the overhead if there are multiple cores.
+var parallelHash = runtime.NumCPU() > 1
+
 // Database must be implemented by backing stores for the trie.
 type Database interface {
 	DatabaseWriter
@@ -70,7 +74,6 @@ type DatabaseWriter interface {
 type Trie struct {
 	root node
 	db   Database
-	*hasher
 }
 
 // New creates a trie with an existing root node from db.
@@ -361,10 +364,26 @@ func (t *Trie) hashRoot(db DatabaseWriter) (node, error) {
 	if t.root == nil {
 		return hashNode(emptyRoot.Bytes()), nil
 	}
-	if t.hasher == nil {
-		t.hasher = newHasher()
+	h := newHasher()
+	defer h.release()
+	if n, ok := t.root.(fullNode); ok && parallelHash && hasFullChildren(n, 2) {
+		return h.hashParallel(n, db)
 	}
-	return t.hasher.hash(t.root, db, true)
+	return h.hash(t.root, db, true)
+}
+
+// hasFullChildren reports whether at least min children of n are unhashed
+// full nodes, i.e. the trie below n is large enough to be hashed in parallel.
+func hasFullChildren(n fullNode, min int) bool {
+	count := 0
+	for i := 0; i < 16; i++ {
+		if _, ok := n[i].(fullNode); ok {
+			if count++; count >= min {
+				return true
+			}
+		}
+	}
+	return false
 }
 
 type hasher struct {
@@ -373,7 +392,14 @@ type hasher struct {
 }
 
 func newHasher() *hasher {
-	return &hasher{tmp: new(bytes.Buffer), sha: sha3.NewKeccak256()}
+	return &hasher{tmp: new(bytes.Buffer), sha: crypto.GetKeccak256()}
+}
+
+// release returns the hasher's Keccak state to the shared pool.
+// The hasher must not be used afterwards.
+func (h *hasher) release() {
+	crypto.PutKeccak256(h.sha)
+	h.sha = nil
 }
 
 func (h *hasher) hash(n node, db DatabaseWriter, force bool) (node, error) {
@@ -387,6 +413,75 @@ func (h *hasher) hash(n node, db DatabaseWriter, force bool) (node, error) {
 	return n, nil
 }
 
+// hashParallel hashes the subtries below the root node n concurrently and
+// then the root itself. Each subtrie collects its nodes in a separate batch,
+// the batches are written to db once all subtries have been hashed.
+func (h *hasher) hashParallel(n fullNode, db DatabaseWriter) (node, error) {
+	var (
+		batches [16]nodeBatch
+		errs    [16]error
+		wg      sync.WaitGroup
+	)
+	for i := 0; i < 16; i++ {
+		if n[i] == nil {
+			// Ensure that nil children are encoded as empty strings.
+			n[i] = valueNode(nil)
+			continue
+		}
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			sub := newHasher()
+			defer sub.release()
+
+			var w DatabaseWriter
+			if db != nil {
+				w = &batches[i]
+			}
+			n[i], errs[i] = sub.hash(n[i], w, false)
+		}(i)
+	}
+	wg.Wait()
+
+	for i := range errs {
+		if errs[i] != nil {
+			return hashNode{}, errs[i]
+		}
+	}
+	if db != nil {
+		for i := range batches {
+			if err := batches[i].writeTo(db); err != nil {
+				return hashNode{}, err
+			}
+		}
+	}
+	if n[16] == nil {
+		n[16] = valueNode(nil)
+	}
+	return h.store(n, db, true)
+}
+
+// nodeBatch collects encoded nodes in memory to be written later.
+type nodeBatch struct {
+	keys, values [][]byte
+}
+
+func (b *nodeBatch) Put(key, value []byte) error {
+	b.keys = append(b.keys, common.CopyBytes(key))
+	b.values = append(b.values, common.CopyBytes(value))
+	return nil
+}
+
+func (b *nodeBatch) writeTo(db DatabaseWriter) error {
+	for i, key := range b.keys {
+		if err := db.Put(key, b.values[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // hashChildren replaces child nodes of n with their hashes if the encoded
 // size of the child is larger than a hash.
 func (h *hasher) replaceChildren(n node, db DatabaseWriter) (node, error) {
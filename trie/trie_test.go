@@ -26,6 +26,7 @@ import (
 
 	"github.com/davecgh/go-spew/spew"
 	"github.com/expanse-project/go-expanse/common"
+	"github.com/expanse-project/go-expanse/crypto"
 	"github.com/expanse-project/go-expanse/ethdb"
 )
 
@@ -327,6 +328,56 @@ func TestLargeData(t *testing.T) {
 	}
 }
 
+// Tests that hashing and committing the subtries of a large trie concurrently
+// yields the same root and stores the same nodes as doing it serially.
+func TestParallelHash(t *testing.T) {
+	defer func(enabled bool) { parallelHash = enabled }(parallelHash)
+
+	build := func() (*Trie, *ethdb.MemDatabase) {
+		db, _ := ethdb.NewMemDatabase()
+		trie, _ := New(common.Hash{}, db)
+		for i := 0; i < 2000; i++ {
+			key := crypto.Sha3([]byte(fmt.Sprintf("key%d", i)))
+			trie.Update(key, []byte(fmt.Sprintf("value%d", i)))
+		}
+		return trie, db
+	}
+	parallelHash = false
+	serial, serialDb := build()
+	parallelHash = true
+	parallel, parallelDb := build()
+
+	if !hasFullChildren(parallel.root.(fullNode), 2) {
+		t.Fatal("test trie too small to be hashed in parallel")
+	}
+	if h1, h2 := serial.Hash(), parallel.Hash(); h1 != h2 {
+		t.Fatalf("hash mismatch: serial %x, parallel %x", h1, h2)
+	}
+	parallelHash = false
+	root1, err := serial.Commit()
+	if err != nil {
+		t.Fatalf("serial commit failed: %v", err)
+	}
+	parallelHash = true
+	root2, err := parallel.Commit()
+	if err != nil {
+		t.Fatalf("parallel commit failed: %v", err)
+	}
+	if root1 != root2 {
+		t.Fatalf("root mismatch: serial %x, parallel %x", root1, root2)
+	}
+	if len(serialDb.Keys()) != len(parallelDb.Keys()) {
+		t.Fatalf("stored node count mismatch: serial %d, parallel %d", len(serialDb.Keys()), len(parallelDb.Keys()))
+	}
+	for _, key := range serialDb.Keys() {
+		v1, _ := serialDb.Get(key)
+		v2, _ := parallelDb.Get(key)
+		if !bytes.Equal(v1, v2) {
+			t.Errorf("node %x mismatch: serial %x, parallel %x", key, v1, v2)
+		}
+	}
+}
+
 func BenchmarkGet(b *testing.B)      { benchGet(b, false) }
 func BenchmarkGetDB(b *testing.B)    { benchGet(b, true) }
 func BenchmarkUpdateBE(b *testing.B) { benchUpdate(b, binary.BigEndian) }
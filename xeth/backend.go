@@ -0,0 +1,111 @@
+// Copyright 2016 The go-expanse Authors
+// This file is part of the go-expanse library.
+//
+// The go-expanse library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-expanse library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-expanse library. If not, see <http://www.gnu.org/licenses/>.
+
+package xeth
+
+import (
+	"math/big"
+
+	"github.com/expanse-project/go-expanse/accounts"
+	"github.com/expanse-project/go-expanse/common"
+	"github.com/expanse-project/go-expanse/common/compiler"
+	"github.com/expanse-project/go-expanse/core"
+	"github.com/expanse-project/go-expanse/core/state"
+	"github.com/expanse-project/go-expanse/core/types"
+	"github.com/expanse-project/go-expanse/core/vm"
+	"github.com/expanse-project/go-expanse/ethdb"
+	"github.com/expanse-project/go-expanse/event"
+	"github.com/expanse-project/go-expanse/miner"
+	"github.com/expanse-project/go-expanse/whisper"
+)
+
+// ChainReader provides access to the blocks and receipts of the local chain
+// and to its events.
+type ChainReader interface {
+	CurrentBlock() *types.Block
+	GetBlock(hash common.Hash) *types.Block
+	GetBlockByNumber(number uint64) *types.Block
+	GetHeader(hash common.Hash) *types.Header
+	GetTd(hash common.Hash) *big.Int
+	GetBlockReceipts(hash common.Hash) types.Receipts
+	GasLimit() *big.Int
+
+	SubscribeChainEvent(ch chan<- core.ChainEvent) event.FeedSubscription
+	SubscribeChainHeadEvent(ch chan<- core.ChainHeadEvent) event.FeedSubscription
+	SubscribeLogsEvent(ch chan<- vm.Logs) event.FeedSubscription
+	SubscribeRemovedLogsEvent(ch chan<- core.RemovedLogsEvent) event.FeedSubscription
+}
+
+// StateReader provides access to the committed and pending state.
+type StateReader interface {
+	ChainDb() ethdb.Database
+	PendingBlock() *types.Block
+
+	// Pending returns the pending block along with a copy of its state which
+	// the caller may modify. Both are taken from the same snapshot.
+	Pending() (*types.Block, *state.StateDB)
+}
+
+// TxSender submits transactions to the network and tracks the pending ones.
+type TxSender interface {
+	SendTx(tx *types.Transaction, origin core.TxOrigin) error
+	ValidateTx(tx *types.Transaction) error
+	GetPoolTransaction(hash common.Hash) *types.Transaction
+	PendingNonce(addr common.Address) uint64
+	ReserveNonce(addr common.Address) (uint64, error)
+	ReleaseNonce(addr common.Address, nonce uint64) bool
+	SuggestPrice() *big.Int
+	DefaultGas() *big.Int
+
+	SubscribeTxPreEvent(ch chan<- core.TxPreEvent) event.FeedSubscription
+	SubscribeTxDropEvent(ch chan<- core.TxDropEvent) event.FeedSubscription
+}
+
+// NodeInfo provides the node level services exposed through XEth, such as
+// mining control and version information.
+type NodeInfo interface {
+	ClientVersion() string
+	EthVersion() int
+	NetVersion() int
+	ShhVersion() int
+	PeerCount() int
+	IsListening() bool
+
+	Etherbase() (common.Address, error)
+	IsMining() bool
+	StartMining(threads int, gpus string) error
+	StopMining()
+	HashRate() int64
+	RegisterAgent(agent miner.Agent)
+	UnregisterAgent(agent miner.Agent)
+
+	Solc() (*compiler.Solidity, error)
+	SetSolc(solcPath string) (*compiler.Solidity, error)
+	DappDb() ethdb.Database
+	Whisper() *whisper.Whisper
+}
+
+// Backend is the node functionality XEth is built on. It is implemented by
+// exp.ApiBackend for full nodes, and can be mocked to test the RPC layer.
+type Backend interface {
+	ChainReader
+	StateReader
+	TxSender
+	NodeInfo
+
+	AccountManager() *accounts.Manager
+	EventMux() *event.TypeMux
+}
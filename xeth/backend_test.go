@@ -0,0 +1,179 @@
+// Copyright 2016 The go-expanse Authors
+// This file is part of the go-expanse library.
+//
+// The go-expanse library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-expanse library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-expanse library. If not, see <http://www.gnu.org/licenses/>.
+
+package xeth
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/expanse-project/go-expanse/common"
+	"github.com/expanse-project/go-expanse/core"
+	"github.com/expanse-project/go-expanse/core/state"
+	"github.com/expanse-project/go-expanse/core/types"
+	"github.com/expanse-project/go-expanse/ethdb"
+)
+
+// testBackend is a mock chain backed by a generated list of blocks. Methods not
+// needed by the tests are left to the embedded nil Backend.
+type testBackend struct {
+	Backend
+
+	db     ethdb.Database
+	blocks []*types.Block
+	hashes map[common.Hash]*types.Block
+
+	pendingBlock *types.Block
+	pendingState *state.StateDB
+}
+
+func newTestBackend(n int) *testBackend {
+	db, _ := ethdb.NewMemDatabase()
+	genesis := core.WriteGenesisBlockForTesting(db)
+	blocks, _ := core.GenerateChain(genesis, db, n, func(int, *core.BlockGen) {})
+
+	b := &testBackend{
+		db:     db,
+		blocks: append([]*types.Block{genesis}, blocks...),
+		hashes: make(map[common.Hash]*types.Block),
+	}
+	for _, block := range b.blocks {
+		b.hashes[block.Hash()] = block
+	}
+	return b
+}
+
+func (b *testBackend) ChainDb() ethdb.Database                { return b.db }
+func (b *testBackend) CurrentBlock() *types.Block             { return b.blocks[len(b.blocks)-1] }
+func (b *testBackend) GetBlock(hash common.Hash) *types.Block { return b.hashes[hash] }
+func (b *testBackend) GetTd(hash common.Hash) *big.Int {
+	if block := b.hashes[hash]; block != nil {
+		return new(big.Int).Mul(block.Number(), big.NewInt(10))
+	}
+	return nil
+}
+
+func (b *testBackend) Pending() (*types.Block, *state.StateDB) {
+	return b.pendingBlock, b.pendingState.Copy()
+}
+
+func (b *testBackend) GetBlockByNumber(number uint64) *types.Block {
+	if number < uint64(len(b.blocks)) {
+		return b.blocks[number]
+	}
+	return nil
+}
+
+func (b *testBackend) GetHeader(hash common.Hash) *types.Header {
+	if block := b.hashes[hash]; block != nil {
+		return block.Header()
+	}
+	return nil
+}
+
+// Tests that XEth can serve chain queries from a mock backend.
+func TestBackendChainAccess(t *testing.T) {
+	backend := newTestBackend(8)
+	xeth := NewTest(backend, nil)
+
+	if head := xeth.EthBlockByNumber(-1); head != backend.CurrentBlock() {
+		t.Errorf("head mismatch: have %v, want %v", head, backend.CurrentBlock())
+	}
+	if block := xeth.EthBlockByNumber(3); block != backend.blocks[3] {
+		t.Errorf("block #3 mismatch: have %v, want %v", block, backend.blocks[3])
+	}
+	if block := xeth.EthBlockByHash(backend.blocks[5].Hash().Hex()); block != backend.blocks[5] {
+		t.Errorf("block by hash mismatch: have %v, want %v", block, backend.blocks[5])
+	}
+	if td := xeth.Td(backend.blocks[4].Hash()); td == nil || td.Cmp(big.NewInt(40)) != 0 {
+		t.Errorf("td mismatch: have %v, want %v", td, 40)
+	}
+	for confirmations, want := range map[uint64]*types.Block{
+		0:       backend.blocks[8],
+		3:       backend.blocks[5],
+		8:       backend.blocks[0],
+		9:       nil,
+		1 << 63: nil,
+	} {
+		if block := xeth.ConfirmedBlock(confirmations); block != want {
+			t.Errorf("confirmations %d: block mismatch: have %v, want %v", confirmations, block, want)
+		}
+	}
+	if xeth := xeth.AtStateNum(2); xeth == nil || xeth.State().State() == nil {
+		t.Errorf("state of block #2 not available")
+	}
+}
+
+// Tests that calls can be executed within the environment of past blocks.
+func TestCallAt(t *testing.T) {
+	backend := newTestBackend(8)
+	xeth := NewTest(backend, nil)
+
+	// Init code returning the block number as the contract code
+	code := "0x4360005260206000f3"
+	for num, want := range map[int64]int64{3: 3, -1: 8, 0: 0} {
+		ret, _, err := xeth.CallAt(num, "0x0000000000000000000000000000000000000001", "", "0", "", "1", code)
+		if err != nil {
+			t.Fatalf("block %d: call failed: %v", num, err)
+		}
+		if have := common.String2Big(ret); have.Int64() != want {
+			t.Errorf("block %d: number mismatch: have %v, want %d", num, have, want)
+		}
+	}
+	if _, _, err := xeth.CallAt(9, "", "", "0", "", "1", code); err == nil {
+		t.Errorf("expected error for unknown block")
+	}
+}
+
+// Tests that pending state reads see the block being mined and can't modify it.
+func TestPendingState(t *testing.T) {
+	backend := newTestBackend(8)
+	xeth := NewTest(backend, nil)
+
+	// Mine a block with an account only existing in the pending state
+	blocks, _ := core.GenerateChain(backend.CurrentBlock(), backend.db, 1, func(int, *core.BlockGen) {})
+	backend.pendingBlock = blocks[0]
+	backend.pendingState, _ = state.New(backend.CurrentBlock().Root(), backend.db)
+
+	addr := common.Address{0x42}
+	backend.pendingState.AddBalance(addr, big.NewInt(1000))
+	backend.pendingState.SetNonce(addr, 3)
+	backend.pendingState.SetCode(addr, []byte{0x60})
+
+	if balance := xeth.PendingBalance(addr.Hex()); balance.Cmp(big.NewInt(1000)) != 0 {
+		t.Errorf("pending balance mismatch: have %v, want 1000", balance)
+	}
+	if nonce := xeth.AtStateNum(-2).TxCountAt(addr.Hex()); nonce != 3 {
+		t.Errorf("pending nonce mismatch: have %d, want 3", nonce)
+	}
+	if code := xeth.PendingCode(addr.Hex()); len(code) != 1 || code[0] != 0x60 {
+		t.Errorf("pending code mismatch: have %x, want 60", code)
+	}
+	// Views on the pending state are snapshots
+	view := xeth.AtStateNum(-2)
+	view.State().State().AddBalance(addr, big.NewInt(1))
+	if balance := xeth.PendingBalance(addr.Hex()); balance.Cmp(big.NewInt(1000)) != 0 {
+		t.Errorf("pending balance modified through view: have %v, want 1000", balance)
+	}
+	// Calls execute on top of the pending state within the pending block
+	ret, _, err := xeth.CallAt(-2, addr.Hex(), "", "0", "", "1", "0x4360005260206000f3")
+	if err != nil {
+		t.Fatalf("pending call failed: %v", err)
+	}
+	if have := common.String2Big(ret); have.Int64() != 9 {
+		t.Errorf("pending call number mismatch: have %v, want 9", have)
+	}
+}
@@ -36,6 +36,9 @@ type Frontend interface {
 	//
 	// ConfirmTransaction is not used for Call transactions
 	// because they cannot change any state.
+	//
+	// Deprecated: it is only consulted when no Policy is set on the
+	// XEth, through FrontendPolicy.
 	ConfirmTransaction(tx string) bool
 }
 
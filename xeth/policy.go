@@ -0,0 +1,246 @@
+// Copyright 2016 The go-expanse Authors
+// This file is part of the go-expanse library.
+//
+// The go-expanse library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-expanse library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-expanse library. If not, see <http://www.gnu.org/licenses/>.
+
+package xeth
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"time"
+
+	"github.com/expanse-project/go-expanse/common"
+	"github.com/expanse-project/go-expanse/common/hexutil"
+	"github.com/expanse-project/go-expanse/logger"
+	"github.com/expanse-project/go-expanse/logger/glog"
+)
+
+// ApproverTimeout is the time an external approver has to answer a request.
+var ApproverTimeout = 30 * time.Second
+
+// TxRequest describes a transaction initiated through XEth that awaits signing.
+type TxRequest struct {
+	From     common.Address
+	To       *common.Address // nil for contract creations
+	Value    *big.Int
+	Gas      *big.Int
+	GasPrice *big.Int
+	Data     []byte
+}
+
+func (req *TxRequest) String() string {
+	to := "[contract creation]"
+	if req.To != nil {
+		to = req.To.Hex()
+	}
+	return fmt.Sprintf("from=%s to=%s value=%v gas=%v gasprice=%v data=%d bytes", req.From.Hex(), to, req.Value, req.Gas, req.GasPrice, len(req.Data))
+}
+
+// Policy decides whether transactions initiated through XEth may be signed and
+// sent. It replaces the ConfirmTransaction prompt of the Frontend.
+type Policy interface {
+	// Approve returns whether the transaction may be sent, and a reason for
+	// the decision that is recorded in the log.
+	Approve(req *TxRequest) (approved bool, reason string)
+}
+
+// FrontendPolicy defers the decision to the deprecated ConfirmTransaction hook
+// of a Frontend. It is used when no explicit policy is configured.
+type FrontendPolicy struct {
+	Frontend Frontend
+}
+
+func (p FrontendPolicy) Approve(req *TxRequest) (bool, string) {
+	to, data := "", ""
+	if req.To != nil {
+		to = req.To.Hex()
+	}
+	if len(req.Data) > 0 {
+		data = common.ToHex(req.Data)
+	}
+	// this minimalistic recoding is enough (works for natspec.js)
+	jsontx := fmt.Sprintf(`{"params":[{"to":"%s","data": "%s"}]}`, to, data)
+	if p.Frontend.ConfirmTransaction(jsontx) {
+		return true, "confirmed by frontend"
+	}
+	return false, "rejected by frontend"
+}
+
+// Rule matches transactions by destination and limits. Unset fields match
+// any transaction.
+type Rule struct {
+	Name        string          `json:"name"`
+	To          *common.Address `json:"to"`       // destination, nil for any
+	Create      bool            `json:"create"`   // only match contract creations
+	MaxValue    *hexutil.Big    `json:"maxValue"` // maximum value transferred
+	MaxGas      *hexutil.Big    `json:"maxGas"`
+	MaxGasPrice *hexutil.Big    `json:"maxGasPrice"`
+	Approve     bool            `json:"approve"` // decision for matching transactions
+}
+
+// Match reports whether the rule applies to the transaction.
+func (r *Rule) Match(req *TxRequest) bool {
+	switch {
+	case r.Create && req.To != nil:
+		return false
+	case r.To != nil && (req.To == nil || *r.To != *req.To):
+		return false
+	case r.MaxValue != nil && req.Value.Cmp(r.MaxValue.ToInt()) > 0:
+		return false
+	case r.MaxGas != nil && req.Gas.Cmp(r.MaxGas.ToInt()) > 0:
+		return false
+	case r.MaxGasPrice != nil && req.GasPrice.Cmp(r.MaxGasPrice.ToInt()) > 0:
+		return false
+	}
+	return true
+}
+
+// RulePolicy decides using the first matching rule. Transactions matched by no
+// rule are passed to the fallback policy, or rejected if there is none.
+type RulePolicy struct {
+	Rules    []Rule
+	Fallback Policy
+}
+
+func (p *RulePolicy) Approve(req *TxRequest) (bool, string) {
+	for i, rule := range p.Rules {
+		if rule.Match(req) {
+			name := rule.Name
+			if name == "" {
+				name = fmt.Sprintf("#%d", i)
+			}
+			if rule.Approve {
+				return true, "approved by rule " + name
+			}
+			return false, "rejected by rule " + name
+		}
+	}
+	if p.Fallback != nil {
+		return p.Fallback.Approve(req)
+	}
+	return false, "no matching rule"
+}
+
+// ExternalPolicy delegates the decision to an external approver, which is sent
+// the transaction as JSON and must answer with {"approved": bool}.
+type ExternalPolicy struct {
+	URL string
+}
+
+type approverRequest struct {
+	From     common.Address  `json:"from"`
+	To       *common.Address `json:"to"`
+	Value    *hexutil.Big    `json:"value"`
+	Gas      *hexutil.Big    `json:"gas"`
+	GasPrice *hexutil.Big    `json:"gasPrice"`
+	Data     hexutil.Bytes   `json:"data"`
+}
+
+type approverResponse struct {
+	Approved bool   `json:"approved"`
+	Reason   string `json:"reason"`
+}
+
+func (p *ExternalPolicy) Approve(req *TxRequest) (bool, string) {
+	body, err := json.Marshal(&approverRequest{
+		From:     req.From,
+		To:       req.To,
+		Value:    (*hexutil.Big)(req.Value),
+		Gas:      (*hexutil.Big)(req.Gas),
+		GasPrice: (*hexutil.Big)(req.GasPrice),
+		Data:     req.Data,
+	})
+	if err != nil {
+		return false, err.Error()
+	}
+	client := &http.Client{Timeout: ApproverTimeout}
+	resp, err := client.Post(p.URL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return false, fmt.Sprintf("approver unreachable: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Sprintf("approver failed: %s", resp.Status)
+	}
+	var res approverResponse
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return false, fmt.Sprintf("invalid approver response: %v", err)
+	}
+	reason := res.Reason
+	if reason == "" {
+		reason = "no reason given"
+	}
+	if res.Approved {
+		return true, "approved by " + p.URL + ": " + reason
+	}
+	return false, "rejected by " + p.URL + ": " + reason
+}
+
+// policyFile is the JSON format of a policy file. Rules are tried in order,
+// unmatched transactions are delegated to the approver if set and rejected
+// otherwise.
+type policyFile struct {
+	Rules    []Rule `json:"rules"`
+	Approver string `json:"approver"`
+}
+
+// LoadPolicy reads a transaction policy from a JSON file.
+func LoadPolicy(path string) (Policy, error) {
+	blob, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var file policyFile
+	if err := json.Unmarshal(blob, &file); err != nil {
+		return nil, fmt.Errorf("invalid policy file %s: %v", path, err)
+	}
+	if len(file.Rules) == 0 && file.Approver == "" {
+		return nil, errors.New("policy file defines neither rules nor an approver")
+	}
+	policy := &RulePolicy{Rules: file.Rules}
+	if file.Approver != "" {
+		policy.Fallback = &ExternalPolicy{URL: file.Approver}
+	}
+	return policy, nil
+}
+
+// approve asks the policy for a decision on the transaction and logs it.
+func approve(policy Policy, req *TxRequest) bool {
+	approved, reason := policy.Approve(req)
+	if approved {
+		glog.V(logger.Info).Infof("transaction approved (%s): %v", reason, req)
+	} else {
+		glog.V(logger.Warn).Infof("transaction rejected (%s): %v", reason, req)
+	}
+	return approved
+}
+
+// approveHash decides on signing an arbitrary hash. A hash reveals nothing the
+// rules of a policy could be checked against, while a signed transaction hash
+// is as good as a sent transaction, so hashes are only signed if no policy is
+// configured and the frontend decides.
+func approveHash(policy Policy, from common.Address, hash common.Hash) bool {
+	if _, ok := policy.(FrontendPolicy); ok {
+		return true
+	}
+	glog.V(logger.Warn).Infof("signing rejected (hashes can't be checked against the transaction policy): from=%s hash=%x", from.Hex(), hash)
+	return false
+}
@@ -0,0 +1,179 @@
+// Copyright 2016 The go-expanse Authors
+// This file is part of the go-expanse library.
+//
+// The go-expanse library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-expanse library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-expanse library. If not, see <http://www.gnu.org/licenses/>.
+
+package xeth
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/expanse-project/go-expanse/accounts"
+	"github.com/expanse-project/go-expanse/common"
+	"github.com/expanse-project/go-expanse/crypto"
+)
+
+func testTxRequest(to *common.Address, value, gas int64) *TxRequest {
+	return &TxRequest{
+		From:     common.HexToAddress("0x01"),
+		To:       to,
+		Value:    big.NewInt(value),
+		Gas:      big.NewInt(gas),
+		GasPrice: big.NewInt(1),
+	}
+}
+
+// Tests that rules are applied in order and unmatched transactions fall back.
+func TestRulePolicy(t *testing.T) {
+	var (
+		trusted = common.HexToAddress("0xaa")
+		other   = common.HexToAddress("0xbb")
+	)
+	blob := `{"rules": [
+		{"name": "blocked", "to": "0x00000000000000000000000000000000000000bb", "approve": false},
+		{"name": "trusted", "to": "0x00000000000000000000000000000000000000aa", "maxGas": "0x5208", "approve": true},
+		{"name": "deploy", "create": true, "approve": true}
+	]}`
+	var file policyFile
+	if err := json.Unmarshal([]byte(blob), &file); err != nil {
+		t.Fatalf("failed to parse policy: %v", err)
+	}
+	policy := &RulePolicy{Rules: file.Rules}
+	tests := []struct {
+		req     *TxRequest
+		approve bool
+		reason  string
+	}{
+		{testTxRequest(&trusted, 1000, 21000), true, "approved by rule trusted"},
+		{testTxRequest(&trusted, 1000, 21001), false, "no matching rule"},
+		{testTxRequest(&other, 1, 21000), false, "rejected by rule blocked"},
+		{testTxRequest(nil, 1, 100000), true, "approved by rule deploy"},
+	}
+	for i, tt := range tests {
+		approve, reason := policy.Approve(tt.req)
+		if approve != tt.approve || reason != tt.reason {
+			t.Errorf("test %d: decision mismatch: have %v (%s), want %v (%s)", i, approve, reason, tt.approve, tt.reason)
+		}
+	}
+	// Unmatched transactions are passed to the fallback
+	policy.Fallback = &RulePolicy{Rules: []Rule{{Name: "any", Approve: true}}}
+	if approve, reason := policy.Approve(tests[1].req); !approve {
+		t.Errorf("fallback not consulted: %s", reason)
+	}
+}
+
+// Tests that decisions can be delegated to an external approver.
+func TestExternalPolicy(t *testing.T) {
+	limit := big.NewInt(100)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req approverRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		json.NewEncoder(w).Encode(&approverResponse{
+			Approved: req.Value.ToInt().Cmp(limit) <= 0,
+			Reason:   "value limit",
+		})
+	}))
+	defer server.Close()
+
+	to := common.HexToAddress("0xaa")
+	policy := &ExternalPolicy{URL: server.URL}
+	if approve, reason := policy.Approve(testTxRequest(&to, 100, 21000)); !approve {
+		t.Errorf("small transaction rejected: %s", reason)
+	}
+	if approve, _ := policy.Approve(testTxRequest(&to, 101, 21000)); approve {
+		t.Errorf("large transaction approved")
+	}
+	// An unreachable approver rejects everything
+	server.Close()
+	if approve, _ := policy.Approve(testTxRequest(&to, 1, 21000)); approve {
+		t.Errorf("transaction approved by unreachable approver")
+	}
+}
+
+// policyBackend is a test backend with an account manager, which audits the
+// signing operations.
+type policyBackend struct {
+	*testBackend
+	am *accounts.Manager
+}
+
+func (b *policyBackend) AccountManager() *accounts.Manager { return b.am }
+
+// Tests that the signing paths are subject to the policy as well, as signed
+// transactions can be sent without Transact.
+func TestPolicySigning(t *testing.T) {
+	dir, err := ioutil.TempDir("", "xeth-policy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	backend := &policyBackend{
+		testBackend: newTestBackend(0),
+		am:          accounts.NewManager(crypto.NewKeyStorePlain(dir)),
+	}
+	xeth := NewTest(backend, nil)
+	xeth.SetPolicy(&RulePolicy{Rules: []Rule{{Name: "none", Approve: false}}})
+
+	if _, err := xeth.SignTransaction("0x01", "0x00000000000000000000000000000000000000aa", "0x0", "1", "21000", "1", ""); err == nil {
+		t.Errorf("transaction signed against the policy")
+	}
+	if _, err := xeth.Sign("0x01", "0x01", false); err == nil {
+		t.Errorf("hash signed despite a configured policy")
+	}
+}
+
+func TestLoadPolicy(t *testing.T) {
+	dir, err := ioutil.TempDir("", "xeth-policy-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	tests := []struct {
+		blob string
+		fail bool
+	}{
+		{`{"rules": [{"approve": true}]}`, false},
+		{`{"approver": "http://localhost:8080"}`, false},
+		{`{}`, true},
+		{`{"rules": [{"to": "0x1234"}]}`, true},
+	}
+	for i, tt := range tests {
+		path := filepath.Join(dir, "policy.json")
+		if err := ioutil.WriteFile(path, []byte(tt.blob), 0600); err != nil {
+			t.Fatal(err)
+		}
+		policy, err := LoadPolicy(path)
+		if tt.fail && err == nil {
+			t.Errorf("test %d: invalid policy loaded: %v", i, policy)
+		}
+		if !tt.fail && err != nil {
+			t.Errorf("test %d: failed to load policy: %v", i, err)
+		}
+	}
+	if _, err := LoadPolicy(filepath.Join(dir, "missing.json")); err == nil {
+		t.Errorf("missing policy file loaded")
+	}
+}
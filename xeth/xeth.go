@@ -19,6 +19,7 @@ package xeth
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -35,18 +36,17 @@ import (
 	"github.com/expanse-project/go-expanse/core/types"
 	"github.com/expanse-project/go-expanse/core/vm"
 	"github.com/expanse-project/go-expanse/crypto"
-	"github.com/expanse-project/go-expanse/exp"
+	"github.com/expanse-project/go-expanse/exp/downloader"
 	"github.com/expanse-project/go-expanse/exp/filters"
 	"github.com/expanse-project/go-expanse/logger"
 	"github.com/expanse-project/go-expanse/logger/glog"
 	"github.com/expanse-project/go-expanse/miner"
+	"github.com/expanse-project/go-expanse/params"
 	"github.com/expanse-project/go-expanse/rlp"
 )
 
 var (
 	filterTickerTime = 5 * time.Minute
-	defaultGasPrice  = big.NewInt(10000000000000) //150000000000
-	defaultGas       = big.NewInt(90000)          //500000
 	dappStorePre     = []byte("dapp-")
 	addrReg          = regexp.MustCompile(`^(0x)?[a-fA-F0-9]{40}$`)
 )
@@ -57,64 +57,62 @@ const (
 	BlockFilterTy
 	TransactionFilterTy
 	LogFilterTy
+	ConfirmationFilterTy
+	DroppedTxFilterTy
 )
 
 type XEth struct {
 	quit chan struct{}
 
-	logMu    sync.RWMutex
-	logQueue map[int]*logQueue
-
-	blockMu    sync.RWMutex
-	blockQueue map[int]*hashQueue
-
-	transactionMu    sync.RWMutex
-	transactionQueue map[int]*hashQueue
-
 	messagesMu sync.RWMutex
 	messages   map[int]*whisperFilter
 
 	transactMu sync.Mutex
 
 	// read-only fields
-	backend       *exp.Expanse
+	backend       Backend
 	frontend      Frontend
+	policy        Policy
+	origin        string
 	agent         *miner.RemoteAgent
-	gpo           *exp.GasPriceOracle
+	ownAgent      bool // whether agent is registered by and stopped with self
 	state         *State
 	whisper       *Whisper
 	filterManager *filters.FilterSystem
 }
 
-func NewTest(exp *exp.Expanse, frontend Frontend) *XEth {
-	return &XEth{backend: exp, frontend: frontend}
+func NewTest(backend Backend, frontend Frontend) *XEth {
+	return &XEth{backend: backend, frontend: frontend}
 }
 
 // New creates an XEth that uses the given frontend.
 // If a nil Frontend is provided, a default frontend which
 // confirms all transactions will be used.
-func New(expanse *exp.Expanse, frontend Frontend) *XEth {
+func New(backend Backend, frontend Frontend) *XEth {
+	xeth := newXEth(backend, frontend, miner.NewRemoteAgent())
+	xeth.ownAgent = true
+	backend.RegisterAgent(xeth.agent)
+	return xeth
+}
+
+// newXEth creates an XEth serving remote mining through the given agent.
+func newXEth(backend Backend, frontend Frontend, agent *miner.RemoteAgent) *XEth {
 	xeth := &XEth{
-		backend:          expanse,
-		frontend:         frontend,
-		quit:             make(chan struct{}),
-		filterManager:    filters.NewFilterSystem(expanse.EventMux()),
-		logQueue:         make(map[int]*logQueue),
-		blockQueue:       make(map[int]*hashQueue),
-		transactionQueue: make(map[int]*hashQueue),
-		messages:         make(map[int]*whisperFilter),
-		agent:            miner.NewRemoteAgent(),
-		gpo:              exp.NewGasPriceOracle(expanse),
-	}
-	if expanse.Whisper() != nil {
-		xeth.whisper = NewWhisper(expanse.Whisper())
-	}
-	expanse.Miner().Register(xeth.agent)
+		backend:       backend,
+		frontend:      frontend,
+		quit:          make(chan struct{}),
+		filterManager: filters.NewFilterSystem(backend, backend),
+		messages:      make(map[int]*whisperFilter),
+		agent:         agent,
+	}
+	if backend.Whisper() != nil {
+		xeth.whisper = NewWhisper(backend.Whisper())
+	}
 	if frontend == nil {
 		xeth.frontend = dummyFrontend{}
 	}
-	state, _ := xeth.backend.BlockChain().State()
-	xeth.state = NewState(xeth, state)
+	statedb, _ := state.New(backend.CurrentBlock().Root(), backend.ChainDb())
+	xeth.state = NewState(xeth, statedb)
 	go xeth.start()
 	return xeth
 }
@@ -126,33 +124,6 @@ done:
 	for {
 		select {
 		case <-timer.C:
-			self.logMu.Lock()
-			for id, filter := range self.logQueue {
-				if time.Since(filter.timeout) > filterTickerTime {
-					self.filterManager.Remove(id)
-					delete(self.logQueue, id)
-				}
-			}
-			self.logMu.Unlock()
-
-			self.blockMu.Lock()
-			for id, filter := range self.blockQueue {
-				if time.Since(filter.timeout) > filterTickerTime {
-					self.filterManager.Remove(id)
-					delete(self.blockQueue, id)
-				}
-			}
-			self.blockMu.Unlock()
-
-			self.transactionMu.Lock()
-			for id, filter := range self.transactionQueue {
-				if time.Since(filter.timeout) > filterTickerTime {
-					self.filterManager.Remove(id)
-					delete(self.transactionQueue, id)
-				}
-			}
-			self.transactionMu.Unlock()
-
 			self.messagesMu.Lock()
 			for id, filter := range self.messages {
 				if time.Since(filter.activity()) > filterTickerTime {
@@ -172,7 +143,16 @@ done:
 func (self *XEth) Stop() {
 	close(self.quit)
 	self.filterManager.Stop()
-	self.backend.Miner().Unregister(self.agent)
+
+	self.messagesMu.Lock()
+	for id := range self.messages {
+		self.Whisper().Unwatch(id)
+		delete(self.messages, id)
+	}
+	self.messagesMu.Unlock()
+	if self.ownAgent {
+		self.backend.UnregisterAgent(self.agent)
+	}
 }
 
 func cAddress(a []string) []common.Address {
@@ -194,10 +174,13 @@ func cTopics(t [][]string) [][]common.Hash {
 	return topics
 }
 
-func DefaultGas() *big.Int { return new(big.Int).Set(defaultGas) }
+// DefaultGas returns the gas of transactions sent without one.
+func (self *XEth) DefaultGas() *big.Int {
+	return self.backend.DefaultGas()
+}
 
 func (self *XEth) DefaultGasPrice() *big.Int {
-	return self.gpo.SuggestPrice()
+	return self.backend.SuggestPrice()
 }
 
 func (self *XEth) RemoteMining() *miner.RemoteAgent { return self.agent }
@@ -207,7 +190,7 @@ func (self *XEth) AtStateNum(num int64) *XEth {
 	var err error
 	switch num {
 	case -2:
-		st = self.backend.Miner().PendingState().Copy()
+		_, st = self.backend.Pending()
 	default:
 		if block := self.getBlockByHeight(num); block != nil {
 			st, err = state.New(block.Root(), self.backend.ChainDb())
@@ -215,7 +198,7 @@ func (self *XEth) AtStateNum(num int64) *XEth {
 				return nil
 			}
 		} else {
-			st, err = state.New(self.backend.BlockChain().GetBlockByNumber(0).Root(), self.backend.ChainDb())
+			st, err = state.New(self.backend.GetBlockByNumber(0).Root(), self.backend.ChainDb())
 			if err != nil {
 				return nil
 			}
@@ -225,11 +208,22 @@ func (self *XEth) AtStateNum(num int64) *XEth {
 	return self.WithState(st)
 }
 
+// AtBlock returns a view on the state of the given block, or nil if the state
+// is not available.
+func (self *XEth) AtBlock(block *types.Block) *XEth {
+	st, err := state.New(block.Root(), self.backend.ChainDb())
+	if err != nil {
+		return nil
+	}
+	return self.WithState(st)
+}
+
 func (self *XEth) WithState(statedb *state.StateDB) *XEth {
 	xeth := &XEth{
 		backend:  self.backend,
 		frontend: self.frontend,
-		gpo:      self.gpo,
+		policy:   self.policy,
+		origin:   self.origin,
 	}
 
 	xeth.state = NewState(xeth, statedb)
@@ -248,39 +242,34 @@ func (self *XEth) State() *State { return self.state }
 func (self *XEth) UpdateState() (wait chan *big.Int) {
 	wait = make(chan *big.Int)
 	go func() {
-		eventSub := self.backend.EventMux().Subscribe(core.ChainHeadEvent{})
-		defer eventSub.Unsubscribe()
+		headCh := make(chan core.ChainHeadEvent, core.ChainChanSize)
+		headSub := self.backend.SubscribeChainHeadEvent(headCh)
+		defer func() { headSub.Unsubscribe() }()
 
 		var m, n *big.Int
 		var ok bool
 
-		eventCh := eventSub.Chan()
 		for {
 			select {
-			case event, ok := <-eventCh:
-				if !ok {
-					// Event subscription closed, set the channel to nil to stop spinning
-					eventCh = nil
-					continue
+			case event := <-headCh:
+				m = event.Block.Number()
+				if n != nil && n.Cmp(m) < 0 {
+					wait <- n
+					n = nil
 				}
-				// A real event arrived, process if new head block assignment
-				if event, ok := event.Data.(core.ChainHeadEvent); ok {
-					m = event.Block.Number()
-					if n != nil && n.Cmp(m) < 0 {
-						wait <- n
-						n = nil
-					}
-					statedb, err := state.New(event.Block.Root(), self.backend.ChainDb())
-					if err != nil {
-						glog.V(logger.Error).Infoln("Could not create new state: %v", err)
-						return
-					}
-					self.state = NewState(self, statedb)
+				statedb, err := state.New(event.Block.Root(), self.backend.ChainDb())
+				if err != nil {
+					glog.V(logger.Error).Infoln("Could not create new state: %v", err)
+					return
 				}
+				self.state = NewState(self, statedb)
 			case n, ok = <-wait:
 				if !ok {
 					return
 				}
+			case err := <-headSub.Err():
+				glog.V(logger.Debug).Infoln("resubscribing to chain head events:", err)
+				headSub = self.backend.SubscribeChainHeadEvent(headCh)
 			}
 		}
 	}()
@@ -294,7 +283,7 @@ func (self *XEth) getBlockByHeight(height int64) *types.Block {
 
 	switch height {
 	case -2:
-		return self.backend.Miner().PendingBlock()
+		return self.backend.PendingBlock()
 	case -1:
 		return self.CurrentBlock()
 	default:
@@ -305,28 +294,34 @@ func (self *XEth) getBlockByHeight(height int64) *types.Block {
 		num = uint64(height)
 	}
 
-	return self.backend.BlockChain().GetBlockByNumber(num)
+	return self.backend.GetBlockByNumber(num)
 }
 
 func (self *XEth) BlockByHash(strHash string) *Block {
 	hash := common.HexToHash(strHash)
-	block := self.backend.BlockChain().GetBlock(hash)
+	block := self.backend.GetBlock(hash)
 
 	return NewBlock(block)
 }
 
 func (self *XEth) EthBlockByHash(strHash string) *types.Block {
 	hash := common.HexToHash(strHash)
-	block := self.backend.BlockChain().GetBlock(hash)
+	block := self.backend.GetBlock(hash)
 
 	return block
 }
 
+// EthHeaderByHash returns the header of the block with the given hash, which
+// may be stored without the rest of the block.
+func (self *XEth) EthHeaderByHash(strHash string) *types.Header {
+	return self.backend.GetHeader(common.HexToHash(strHash))
+}
+
 func (self *XEth) EthTransactionByHash(hash string) (*types.Transaction, common.Hash, uint64, uint64) {
 	if tx, hash, number, index := core.GetTransaction(self.backend.ChainDb(), common.HexToHash(hash)); tx != nil {
 		return tx, hash, number, index
 	}
-	return self.backend.TxPool().GetTransaction(common.HexToHash(hash)), common.Hash{}, 0, 0
+	return self.backend.GetPoolTransaction(common.HexToHash(hash)), common.Hash{}, 0, 0
 }
 
 func (self *XEth) BlockByNumber(num int64) *Block {
@@ -338,15 +333,38 @@ func (self *XEth) EthBlockByNumber(num int64) *types.Block {
 }
 
 func (self *XEth) Td(hash common.Hash) *big.Int {
-	return self.backend.BlockChain().GetTd(hash)
+	return self.backend.GetTd(hash)
 }
 
 func (self *XEth) CurrentBlock() *types.Block {
-	return self.backend.BlockChain().CurrentBlock()
+	return self.backend.CurrentBlock()
+}
+
+// ConfirmedBlock returns the block which has the given number of confirmations
+// on top of it. The chain is walked back from the current head via the parent
+// hashes, so the returned block is always an ancestor of the head it was
+// computed at. Nil is returned if the chain is not long enough.
+func (self *XEth) ConfirmedBlock(confirmations uint64) *types.Block {
+	chain := self.backend
+
+	header := chain.CurrentBlock().Header()
+	if confirmations > header.Number.Uint64() {
+		return nil
+	}
+	for i := uint64(0); i < confirmations && header != nil; i++ {
+		if header.Number.Sign() == 0 {
+			return nil
+		}
+		header = chain.GetHeader(header.ParentHash)
+	}
+	if header == nil {
+		return nil
+	}
+	return chain.GetBlock(header.Hash())
 }
 
 func (self *XEth) GetBlockReceipts(bhash common.Hash) types.Receipts {
-	return core.GetBlockReceipts(self.backend.ChainDb(), bhash)
+	return self.backend.GetBlockReceipts(bhash)
 }
 
 func (self *XEth) GetTxReceipt(txhash common.Hash) *types.Receipt {
@@ -354,7 +372,7 @@ func (self *XEth) GetTxReceipt(txhash common.Hash) *types.Receipt {
 }
 
 func (self *XEth) GasLimit() *big.Int {
-	return self.backend.BlockChain().GasLimit()
+	return self.backend.GasLimit()
 }
 
 func (self *XEth) Block(v interface{}) *Block {
@@ -412,7 +430,16 @@ func (self *XEth) IsMining() bool {
 }
 
 func (self *XEth) HashRate() int64 {
-	return self.backend.Miner().HashRate()
+	return self.backend.HashRate()
+}
+
+// ChainId returns the chain id replay protected transactions are signed with,
+// as configured in the genesis, or nil if the chain doesn't configure one.
+func (self *XEth) ChainId() *big.Int {
+	if params.ChainId == nil {
+		return nil
+	}
+	return new(big.Int).Set(params.ChainId)
 }
 
 func (self *XEth) EthVersion() string {
@@ -473,6 +500,25 @@ func (self *XEth) TxCountAt(address string) int {
 	return int(self.State().state.GetNonce(common.HexToAddress(address)))
 }
 
+// PendingTxCount returns the number of transactions sent by address including
+// its transactions in the pool, which is the nonce of its next transaction.
+func (self *XEth) PendingTxCount(address string) int {
+	return int(self.backend.PendingNonce(common.HexToAddress(address)))
+}
+
+// PendingBalance and PendingCode return the balance and code of address in the
+// block being mined. Each of them reads a snapshot of its own, use
+// AtStateNum(-2) to read several values from the same one.
+func (self *XEth) PendingBalance(address string) *big.Int {
+	_, statedb := self.backend.Pending()
+	return statedb.GetBalance(common.HexToAddress(address))
+}
+
+func (self *XEth) PendingCode(address string) []byte {
+	_, statedb := self.backend.Pending()
+	return statedb.GetCode(common.HexToAddress(address))
+}
+
 func (self *XEth) CodeAt(address string) string {
 	return common.ToHex(self.State().state.GetCode(common.HexToAddress(address)))
 }
@@ -486,151 +532,145 @@ func (self *XEth) IsContract(address string) bool {
 }
 
 func (self *XEth) UninstallFilter(id int) bool {
-	defer self.filterManager.Remove(id)
-
-	if _, ok := self.logQueue[id]; ok {
-		self.logMu.Lock()
-		defer self.logMu.Unlock()
-		delete(self.logQueue, id)
-		return true
-	}
-	if _, ok := self.blockQueue[id]; ok {
-		self.blockMu.Lock()
-		defer self.blockMu.Unlock()
-		delete(self.blockQueue, id)
-		return true
-	}
-	if _, ok := self.transactionQueue[id]; ok {
-		self.transactionMu.Lock()
-		defer self.transactionMu.Unlock()
-		delete(self.transactionQueue, id)
-		return true
-	}
-
-	return false
+	return self.filterManager.Uninstall(id)
 }
 
 func (self *XEth) NewLogFilter(earliest, latest int64, skip, max int, address []string, topics [][]string) int {
-	self.logMu.Lock()
-	defer self.logMu.Unlock()
-
 	filter := filters.New(self.backend.ChainDb())
-	id := self.filterManager.Add(filter)
-	self.logQueue[id] = &logQueue{timeout: time.Now()}
-
 	filter.SetBeginBlock(earliest)
 	filter.SetEndBlock(latest)
 	filter.SetAddresses(cAddress(address))
 	filter.SetTopics(cTopics(topics))
-	filter.LogsCallback = func(logs vm.Logs) {
-		self.logMu.Lock()
-		defer self.logMu.Unlock()
 
-		if queue := self.logQueue[id]; queue != nil {
-			queue.add(logs...)
-		}
-	}
-
-	return id
+	return self.filterManager.Install(filters.LogFilter, filter)
 }
 
 func (self *XEth) NewTransactionFilter() int {
-	self.transactionMu.Lock()
-	defer self.transactionMu.Unlock()
+	return self.filterManager.Install(filters.TransactionFilter, filters.New(self.backend.ChainDb()))
+}
 
-	filter := filters.New(self.backend.ChainDb())
-	id := self.filterManager.Add(filter)
-	self.transactionQueue[id] = &hashQueue{timeout: time.Now()}
+func (self *XEth) NewBlockFilter() int {
+	return self.filterManager.Install(filters.BlockFilter, filters.New(self.backend.ChainDb()))
+}
 
-	filter.TransactionCallback = func(tx *types.Transaction) {
-		self.transactionMu.Lock()
-		defer self.transactionMu.Unlock()
+func (self *XEth) NewConfirmationFilter(hash string, confirmations uint64) int {
+	return self.filterManager.InstallConfirmation(filters.New(self.backend.ChainDb()), common.HexToHash(hash), confirmations)
+}
 
-		if queue := self.transactionQueue[id]; queue != nil {
-			queue.add(tx.Hash())
-		}
-	}
-	return id
+func (self *XEth) NewDroppedTxFilter() int {
+	return self.filterManager.Install(filters.DroppedTxFilter, filters.New(self.backend.ChainDb()))
 }
 
-func (self *XEth) NewBlockFilter() int {
-	self.blockMu.Lock()
-	defer self.blockMu.Unlock()
+// SubscribeBlocks calls fn for every block added to the canonical chain until
+// the returned function is called. fn is called from the event loop of the
+// filter system and must not block.
+func (self *XEth) SubscribeBlocks(fn func(*types.Block)) (unsubscribe func()) {
+	filter := filters.New(self.backend.ChainDb())
+	filter.BlockCallback = func(block *types.Block, logs vm.Logs) { fn(block) }
+	return self.subscribe(filter)
+}
 
+// SubscribePendingTransactions calls fn for every transaction entering the
+// transaction pool until the returned function is called. fn must not block.
+func (self *XEth) SubscribePendingTransactions(fn func(*types.Transaction)) (unsubscribe func()) {
 	filter := filters.New(self.backend.ChainDb())
-	id := self.filterManager.Add(filter)
-	self.blockQueue[id] = &hashQueue{timeout: time.Now()}
+	filter.TransactionCallback = fn
+	return self.subscribe(filter)
+}
 
-	filter.BlockCallback = func(block *types.Block, logs vm.Logs) {
-		self.blockMu.Lock()
-		defer self.blockMu.Unlock()
+// SubscribeLogs calls fn with the new logs matching the given addresses and
+// topics, and again with the removed flag set if their block is reorganised
+// out of the chain, until the returned function is called. fn must not block.
+func (self *XEth) SubscribeLogs(address []string, topics [][]string, fn func(vm.Logs)) (unsubscribe func()) {
+	filter := filters.New(self.backend.ChainDb())
+	filter.SetAddresses(cAddress(address))
+	filter.SetTopics(cTopics(topics))
+	filter.LogsCallback = fn
+	return self.subscribe(filter)
+}
 
-		if queue := self.blockQueue[id]; queue != nil {
-			queue.add(block.Hash())
+// SubscribeSyncing calls fn with the downloader.StartEvent, DoneEvent and
+// FailedEvent of every synchronisation with a peer until the returned function
+// is called. fn must not block.
+func (self *XEth) SubscribeSyncing(fn func(ev interface{})) (unsubscribe func()) {
+	sub := self.backend.EventMux().Subscribe(downloader.StartEvent{}, downloader.DoneEvent{}, downloader.FailedEvent{})
+	go func() {
+		for ev := range sub.Chan() {
+			fn(ev.Data)
 		}
-	}
-	return id
+	}()
+	return sub.Unsubscribe
+}
+
+func (self *XEth) subscribe(filter *filters.Filter) func() {
+	id := self.filterManager.Add(filter)
+	return func() { self.filterManager.Remove(id) }
 }
 
 func (self *XEth) GetFilterType(id int) byte {
-	if _, ok := self.blockQueue[id]; ok {
+	switch self.filterManager.Type(id) {
+	case filters.BlockFilter:
 		return BlockFilterTy
-	} else if _, ok := self.transactionQueue[id]; ok {
+	case filters.TransactionFilter:
 		return TransactionFilterTy
-	} else if _, ok := self.logQueue[id]; ok {
+	case filters.LogFilter:
 		return LogFilterTy
+	case filters.ConfirmationFilter:
+		return ConfirmationFilterTy
+	case filters.DroppedTxFilter:
+		return DroppedTxFilterTy
 	}
 
 	return UnknownFilterTy
 }
 
-func (self *XEth) LogFilterChanged(id int) vm.Logs {
-	self.logMu.Lock()
-	defer self.logMu.Unlock()
+// FilterChangesDiscarded returns the number of changes the filter with the
+// given id dropped since the last call because it wasn't polled often enough.
+func (self *XEth) FilterChangesDiscarded(id int) int {
+	return self.filterManager.Discarded(id)
+}
 
-	if self.logQueue[id] != nil {
-		return self.logQueue[id].get()
-	}
-	return nil
+func (self *XEth) LogFilterChanged(id int) vm.Logs {
+	return self.filterManager.LogChanges(id)
 }
 
 func (self *XEth) BlockFilterChanged(id int) []common.Hash {
-	self.blockMu.Lock()
-	defer self.blockMu.Unlock()
-
-	if self.blockQueue[id] != nil {
-		return self.blockQueue[id].get()
-	}
-	return nil
+	return self.filterManager.HashChanges(id)
 }
 
 func (self *XEth) TransactionFilterChanged(id int) []common.Hash {
-	self.blockMu.Lock()
-	defer self.blockMu.Unlock()
+	return self.filterManager.HashChanges(id)
+}
 
-	if self.transactionQueue[id] != nil {
-		return self.transactionQueue[id].get()
-	}
-	return nil
+func (self *XEth) ConfirmationFilterChanged(id int) []filters.ConfirmationEvent {
+	return self.filterManager.ConfirmationChanges(id)
+}
+
+func (self *XEth) DroppedTxFilterChanged(id int) []core.TxDropEvent {
+	return self.filterManager.DropChanges(id)
 }
 
-func (self *XEth) Logs(id int) vm.Logs {
+// Logs returns all logs matching the installed filter with the given id.
+// The search is aborted when ctx is done.
+func (self *XEth) Logs(ctx context.Context, id int) (vm.Logs, error) {
 	filter := self.filterManager.Get(id)
 	if filter != nil {
-		return filter.Find()
+		return filter.FindContext(ctx)
 	}
 
-	return nil
+	return nil, nil
 }
 
-func (self *XEth) AllLogs(earliest, latest int64, skip, max int, address []string, topics [][]string) vm.Logs {
+// AllLogs returns all logs matching the given criteria. The search is
+// aborted when ctx is done.
+func (self *XEth) AllLogs(ctx context.Context, earliest, latest int64, skip, max int, address []string, topics [][]string) (vm.Logs, error) {
 	filter := filters.New(self.backend.ChainDb())
 	filter.SetBeginBlock(earliest)
 	filter.SetEndBlock(latest)
 	filter.SetAddresses(cAddress(address))
 	filter.SetTopics(cTopics(topics))
 
-	return filter.Find()
+	return filter.FindContext(ctx)
 }
 
 // NewWhisperFilter creates and registers a new message filter to watch for
@@ -662,6 +702,7 @@ func (p *XEth) UninstallWhisperFilter(id int) bool {
 	defer p.messagesMu.Unlock()
 
 	if _, ok := p.messages[id]; ok {
+		p.Whisper().Unwatch(id)
 		delete(p.messages, id)
 		return true
 	}
@@ -768,14 +809,19 @@ func (self *XEth) FromNumber(str string) string {
 }
 
 func (self *XEth) PushTx(encodedTx string) (string, error) {
-	tx := new(types.Transaction)
-	err := rlp.DecodeBytes(common.FromHex(encodedTx), tx)
+	return self.PushTxFromRemote("", encodedTx)
+}
+
+// PushTxFromRemote is PushTx for a transaction submitted by the client with the
+// given address, which is recorded as its origin in the pool statistics.
+func (self *XEth) PushTxFromRemote(remote, encodedTx string) (string, error) {
+	tx, err := self.DecodeTx(encodedTx)
 	if err != nil {
 		glog.V(logger.Error).Infoln(err)
 		return "", err
 	}
 
-	err = self.backend.TxPool().Add(tx)
+	err = self.backend.SendTx(tx, self.txOrigin(remote))
 	if err != nil {
 		return "", err
 	}
@@ -795,8 +841,46 @@ func (self *XEth) PushTx(encodedTx string) (string, error) {
 	return tx.Hash().Hex(), nil
 }
 
+// DecodeTx decodes an RLP encoded, hex formatted transaction.
+func (self *XEth) DecodeTx(encodedTx string) (*types.Transaction, error) {
+	tx := new(types.Transaction)
+	if err := rlp.DecodeBytes(common.FromHex(encodedTx), tx); err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
+// ValidateTx runs the checks the transaction pool admits transactions with on
+// tx without sending it, returning the reason it would be rejected.
+func (self *XEth) ValidateTx(tx *types.Transaction) error {
+	return self.backend.ValidateTx(tx)
+}
+
 func (self *XEth) Call(fromStr, toStr, valueStr, gasStr, gasPriceStr, dataStr string) (string, string, error) {
-	statedb := self.State().State().Copy()
+	return self.call(self.State().State().Copy(), self.CurrentBlock().Header(), fromStr, toStr, valueStr, gasStr, gasPriceStr, dataStr)
+}
+
+// CallAt is Call executing the message on top of the state of the block with
+// the given number (-1 being the latest and -2 the pending block), within the
+// environment of that block. It fails if the block or its state is not known,
+// e.g. because it was pruned or skipped by fast sync.
+func (self *XEth) CallAt(num int64, fromStr, toStr, valueStr, gasStr, gasPriceStr, dataStr string) (string, string, error) {
+	if num == -2 {
+		block, statedb := self.backend.Pending()
+		return self.call(statedb, block.Header(), fromStr, toStr, valueStr, gasStr, gasPriceStr, dataStr)
+	}
+	block := self.getBlockByHeight(num)
+	if block == nil {
+		return "", "", fmt.Errorf("block #%d not found", num)
+	}
+	statedb, err := state.New(block.Root(), self.backend.ChainDb())
+	if err != nil {
+		return "", "", fmt.Errorf("state of block #%d not available: %v", block.NumberU64(), err)
+	}
+	return self.call(statedb, block.Header(), fromStr, toStr, valueStr, gasStr, gasPriceStr, dataStr)
+}
+
+func (self *XEth) call(statedb *state.StateDB, header *types.Header, fromStr, toStr, valueStr, gasStr, gasPriceStr, dataStr string) (string, string, error) {
 	var from *state.StateObject
 	if len(fromStr) == 0 {
 		accounts, err := self.backend.AccountManager().Accounts()
@@ -831,17 +915,96 @@ func (self *XEth) Call(fromStr, toStr, valueStr, gasStr, gasPriceStr, dataStr st
 		msg.gasPrice = self.DefaultGasPrice()
 	}
 
-	header := self.CurrentBlock().Header()
-	vmenv := core.NewEnv(statedb, self.backend.BlockChain(), msg, header)
+	vmenv := core.NewEnv(statedb, self.backend, msg, header)
 	gp := new(core.GasPool).AddGas(common.MaxBig)
 	res, gas, err := core.ApplyMessage(vmenv, msg, gp)
 	return common.ToHex(res), gas.String(), err
 }
 
+// ConfirmTransaction asks the frontend to confirm a transaction.
+//
+// Deprecated: transactions are approved by the configured Policy.
 func (self *XEth) ConfirmTransaction(tx string) bool {
 	return self.frontend.ConfirmTransaction(tx)
 }
 
+// SetPolicy sets the policy deciding which transactions initiated through
+// Transact or SignTransaction are signed. A nil policy defers to the frontend,
+// with a policy set arbitrary hashes are not signed anymore.
+func (self *XEth) SetPolicy(policy Policy) {
+	self.policy = policy
+}
+
+// Policy returns the transaction policy in effect.
+func (self *XEth) Policy() Policy {
+	if self.policy == nil {
+		return FrontendPolicy{self.frontend}
+	}
+	return self.policy
+}
+
+// Fork creates a new XEth on the same backend and with the same transaction
+// policy, used through the transport named origin. Unlike self it is not bound
+// to the frontend of a connection, so it can serve listeners outliving it.
+func (self *XEth) Fork(origin string) *XEth {
+	xeth := New(self.backend, nil)
+	xeth.policy = self.policy
+	xeth.SetOrigin(origin)
+	return xeth
+}
+
+// Session creates an XEth serving a single connection of the transport served
+// by self. It has filters of its own, removed when it is stopped, but shares
+// the remote mining agent of self, so work fetched over one connection can be
+// submitted over another and extraNonce leases don't overlap. A session must be
+// stopped before self.
+func (self *XEth) Session() *XEth {
+	xeth := newXEth(self.backend, nil, self.agent)
+	xeth.policy = self.policy
+	xeth.SetOrigin(self.origin)
+	return xeth
+}
+
+// SetOrigin sets the name of the transport (e.g. "ipc") through which this
+// XEth is used, recorded with every signing operation in the audit log.
+func (self *XEth) SetOrigin(origin string) {
+	self.origin = origin
+}
+
+// txOrigin returns the origin of transactions submitted through this XEth by
+// the client with the given address.
+func (self *XEth) txOrigin(remote string) core.TxOrigin {
+	transport := self.origin
+	if transport == "" {
+		transport = core.TxOriginLocal
+	}
+	return core.TxOrigin{Transport: transport, Remote: remote}
+}
+
+// audit records a signing operation in the audit log of the account manager,
+// if it has one.
+func (self *XEth) audit(method string, from common.Address, to *common.Address, value *big.Int, hash *common.Hash, err error) {
+	log := self.backend.AccountManager().AuditLog()
+	if log == nil {
+		return
+	}
+	entry := accounts.AuditEntry{
+		Account: from,
+		To:      to,
+		Value:   value,
+		Hash:    hash,
+		Method:  method,
+		Origin:  self.origin,
+		Result:  "ok",
+	}
+	if err != nil {
+		entry.Result = err.Error()
+	}
+	if err := log.Record(entry); err != nil {
+		glog.V(logger.Error).Infof("failed to record %s in audit log: %v\n", method, err)
+	}
+}
+
 func (self *XEth) doSign(from common.Address, hash common.Hash, didUnlock bool) ([]byte, error) {
 	sig, err := self.backend.AccountManager().Sign(accounts.Account{Address: from}, hash.Bytes())
 	if err == accounts.ErrLocked {
@@ -864,7 +1027,13 @@ func (self *XEth) Sign(fromStr, hashStr string, didUnlock bool) (string, error)
 		from = common.HexToAddress(fromStr)
 		hash = common.HexToHash(hashStr)
 	)
+	if !approveHash(self.Policy(), from, hash) {
+		err := fmt.Errorf("Signing not confirmed")
+		self.audit("sign", from, nil, nil, &hash, err)
+		return "", err
+	}
 	sig, err := self.doSign(from, hash, didUnlock)
+	self.audit("sign", from, nil, nil, &hash, err)
 	if err != nil {
 		return "", err
 	}
@@ -895,7 +1064,7 @@ func (self *XEth) SignTransaction(fromStr, toStr, nonceStr, valueStr, gasStr, ga
 	)
 
 	if len(gasStr) == 0 {
-		gas = DefaultGas()
+		gas = self.DefaultGas()
 	} else {
 		gas = common.Big(gasStr)
 	}
@@ -911,12 +1080,28 @@ func (self *XEth) SignTransaction(fromStr, toStr, nonceStr, valueStr, gasStr, ga
 		contractCreation = true
 	}
 
+	// The signed transaction can be sent through any node, so it needs the
+	// same approval as transactions sent by Transact.
+	req := &TxRequest{From: from, Value: value, Gas: gas, GasPrice: price, Data: data}
+	if !contractCreation {
+		req.To = &to
+	}
+	if !approve(self.Policy(), req) {
+		err := fmt.Errorf("Transaction not confirmed")
+		self.audit("signTransaction", from, req.To, value, nil, err)
+		return nil, err
+	}
+
 	var nonce uint64
 	if len(nonceStr) != 0 {
 		nonce = common.Big(nonceStr).Uint64()
 	} else {
-		state := self.backend.TxPool().State()
-		nonce = state.GetNonce(from)
+		// The signed transaction is likely to be submitted soon, so its
+		// nonce mustn't be handed out to other transactions meanwhile.
+		var err error
+		if nonce, err = self.backend.ReserveNonce(from); err != nil {
+			return nil, err
+		}
 	}
 	var tx *types.Transaction
 	if contractCreation {
@@ -927,21 +1112,25 @@ func (self *XEth) SignTransaction(fromStr, toStr, nonceStr, valueStr, gasStr, ga
 
 	signed, err := self.sign(tx, from, false)
 	if err != nil {
+		if len(nonceStr) == 0 {
+			self.backend.ReleaseNonce(from, nonce)
+		}
+		self.audit("signTransaction", from, tx.To(), value, nil, err)
 		return nil, err
 	}
+	hash := signed.Hash()
+	self.audit("signTransaction", from, tx.To(), value, &hash, nil)
 
 	return signed, nil
 }
 
 func (self *XEth) Transact(fromStr, toStr, nonceStr, valueStr, gasStr, gasPriceStr, codeStr string) (string, error) {
+	return self.TransactFromRemote("", fromStr, toStr, nonceStr, valueStr, gasStr, gasPriceStr, codeStr)
+}
 
-	// this minimalistic recoding is enough (works for natspec.js)
-	var jsontx = fmt.Sprintf(`{"params":[{"to":"%s","data": "%s"}]}`, toStr, codeStr)
-	if !self.ConfirmTransaction(jsontx) {
-		err := fmt.Errorf("Transaction not confirmed")
-		return "", err
-	}
-
+// TransactFromRemote is Transact for a transaction requested by the client with
+// the given address, which is recorded as its origin in the pool statistics.
+func (self *XEth) TransactFromRemote(remote, fromStr, toStr, nonceStr, valueStr, gasStr, gasPriceStr, codeStr string) (string, error) {
 	if len(toStr) > 0 && toStr != "0x" && !isAddress(toStr) {
 		return "", errors.New("Invalid address")
 	}
@@ -957,7 +1146,7 @@ func (self *XEth) Transact(fromStr, toStr, nonceStr, valueStr, gasStr, gasPriceS
 	)
 
 	if len(gasStr) == 0 {
-		gas = DefaultGas()
+		gas = self.DefaultGas()
 	} else {
 		gas = common.Big(gasStr)
 	}
@@ -973,6 +1162,16 @@ func (self *XEth) Transact(fromStr, toStr, nonceStr, valueStr, gasStr, gasPriceS
 		contractCreation = true
 	}
 
+	req := &TxRequest{From: from, Value: value, Gas: gas, GasPrice: price, Data: data}
+	if !contractCreation {
+		req.To = &to
+	}
+	if !approve(self.Policy(), req) {
+		err := fmt.Errorf("Transaction not confirmed")
+		self.audit("sendTransaction", from, req.To, value, nil, err)
+		return "", err
+	}
+
 	// 2015-05-18 Is this still needed?
 	// TODO if no_private_key then
 	//if _, exists := p.register[args.From]; exists {
@@ -999,12 +1198,20 @@ func (self *XEth) Transact(fromStr, toStr, nonceStr, valueStr, gasStr, gasPriceS
 	self.transactMu.Lock()
 	defer self.transactMu.Unlock()
 
-	var nonce uint64
+	var (
+		nonce    uint64
+		reserved bool
+	)
 	if len(nonceStr) != 0 {
 		nonce = common.Big(nonceStr).Uint64()
 	} else {
-		state := self.backend.TxPool().State()
-		nonce = state.GetNonce(from)
+		// Reserve the nonce, so it isn't handed out to other callers
+		// before the transaction is in the pool.
+		var err error
+		if nonce, err = self.backend.ReserveNonce(from); err != nil {
+			return "", err
+		}
+		reserved = true
 	}
 	var tx *types.Transaction
 	if contractCreation {
@@ -1014,12 +1221,18 @@ func (self *XEth) Transact(fromStr, toStr, nonceStr, valueStr, gasStr, gasPriceS
 	}
 
 	signed, err := self.sign(tx, from, false)
-	if err != nil {
-		return "", err
+	if err == nil {
+		err = self.backend.SendTx(signed, self.txOrigin(remote))
 	}
-	if err = self.backend.TxPool().Add(signed); err != nil {
+	if err != nil {
+		if reserved {
+			self.backend.ReleaseNonce(from, nonce)
+		}
+		self.audit("sendTransaction", from, tx.To(), value, nil, err)
 		return "", err
 	}
+	hash := signed.Hash()
+	self.audit("sendTransaction", from, tx.To(), value, &hash, nil)
 
 	if contractCreation {
 		addr := crypto.CreateAddress(from, nonce)
@@ -1031,13 +1244,15 @@ func (self *XEth) Transact(fromStr, toStr, nonceStr, valueStr, gasStr, gasPriceS
 	return signed.Hash().Hex(), nil
 }
 
+// sign signs tx with the key of from under the signature rules of the next
+// block, replay protecting it once the chain enabled protection.
 func (self *XEth) sign(tx *types.Transaction, from common.Address, didUnlock bool) (*types.Transaction, error) {
-	hash := tx.SigHash()
-	sig, err := self.doSign(from, hash, didUnlock)
+	signer := types.MakeSigner(new(big.Int).Add(self.CurrentBlock().Number(), common.Big1))
+	sig, err := self.doSign(from, signer.Hash(tx), didUnlock)
 	if err != nil {
 		return tx, err
 	}
-	return tx.WithSignature(sig)
+	return signer.WithSignature(tx, sig)
 }
 
 // callmsg is the message type used for call transations.
@@ -1058,53 +1273,3 @@ func (m callmsg) GasPrice() *big.Int                    { return m.gasPrice }
 func (m callmsg) Gas() *big.Int                         { return m.gas }
 func (m callmsg) Value() *big.Int                       { return m.value }
 func (m callmsg) Data() []byte                          { return m.data }
-
-type logQueue struct {
-	mu sync.Mutex
-
-	logs    vm.Logs
-	timeout time.Time
-	id      int
-}
-
-func (l *logQueue) add(logs ...*vm.Log) {
-	l.mu.Lock()
-	defer l.mu.Unlock()
-
-	l.logs = append(l.logs, logs...)
-}
-
-func (l *logQueue) get() vm.Logs {
-	l.mu.Lock()
-	defer l.mu.Unlock()
-
-	l.timeout = time.Now()
-	tmp := l.logs
-	l.logs = nil
-	return tmp
-}
-
-type hashQueue struct {
-	mu sync.Mutex
-
-	hashes  []common.Hash
-	timeout time.Time
-	id      int
-}
-
-func (l *hashQueue) add(hashes ...common.Hash) {
-	l.mu.Lock()
-	defer l.mu.Unlock()
-
-	l.hashes = append(l.hashes, hashes...)
-}
-
-func (l *hashQueue) get() []common.Hash {
-	l.mu.Lock()
-	defer l.mu.Unlock()
-
-	l.timeout = time.Now()
-	tmp := l.hashes
-	l.hashes = nil
-	return tmp
-}